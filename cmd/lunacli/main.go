@@ -1,13 +1,16 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
 
+	"lunabox/internal/cli"
 	"lunabox/internal/cli/ipcclient"
 )
 
 // localOnlyCommands 必须在本地运行、不转发给 GUI 的命令。
+// 库管理与备份命令（game / category / tag / session / backup）在 GUI 未运行时以单机模式直接打开数据库，
 // 其余命令统一要求 GUI 进程在线，以保持语义一致。
 var localOnlyCommands = map[string]bool{
 	"luna-sama": true,
@@ -26,20 +29,48 @@ func main() {
 		return
 	}
 
+	args, err := cli.AbsolutePathArgs(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
 	// 其余命令：必须有 GUI 进程（通过 IPC 执行）
+	// 退出码按错误类型区分，见 ipccore.ErrorKind
 	if ipcclient.IsServerRunning() {
 		if err := ipcclient.RemoteRun(args); err != nil {
 			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-			os.Exit(1)
+			os.Exit(ipcclient.ExitCode(err))
 		}
 		return
 	}
 
-	fmt.Println("Error: LunaBox application is not running.")
-	fmt.Println("Please start LunaBox first to use CLI commands.")
-	os.Exit(1)
+	// GUI 未运行：库管理、备份与年度回顾命令直接打开数据库执行。GUI 运行时数据库被独占，必须走 IPC。
+	if cli.SupportsStandalone(args) {
+		os.Exit(runStandalone(args))
+	}
+
+	fmt.Fprintln(os.Stderr, "Error: LunaBox application is not running.")
+	fmt.Fprintln(os.Stderr, "Please start LunaBox (or `lunabox daemon` on a headless machine) first to use CLI commands.")
+	os.Exit(ipcclient.ExitCodeUnavailable)
 }
 
 func shouldRunLocally(args []string) bool {
 	return len(args) > 0 && localOnlyCommands[args[0]]
 }
+
+// runStandalone 以单机模式执行命令并返回退出码
+func runStandalone(args []string) int {
+	app, closeApp, err := cli.OpenStandaloneApp(context.Background())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return cli.ExitCode(err)
+	}
+	defer closeApp()
+
+	if _, err := cli.Execute(os.Stdout, app, args); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return cli.ExitCode(err)
+	}
+	return 0
+}
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"lunabox/internal/appconf"
+	"lunabox/internal/appdb"
+	"lunabox/internal/applog"
+	"lunabox/internal/cli/ipcclient"
+	"lunabox/internal/cli/ipcserver"
+	"lunabox/internal/utils/dbutils"
+	"lunabox/internal/utils/sessionend"
+	"lunabox/internal/wailsruntime"
+)
+
+// daemonCommand 以无界面模式启动 LunaBox 的子命令
+const daemonCommand = "daemon"
+
+const daemonUsage = `Usage: lunabox daemon
+
+Run LunaBox without the GUI. The daemon opens the same database and config as
+the desktop app and keeps playtime tracking, scheduled cloud sync, the MCP
+server and the lunacli IPC server running until it receives Ctrl+C or SIGTERM.
+
+The daemon and the GUI cannot run at the same time; stop one before starting
+the other.
+`
+
+// errDaemonAlreadyRunning 已有 GUI 或 daemon 实例持有 IPC 服务器
+var errDaemonAlreadyRunning = errors.New("LunaBox is already running (GUI or daemon); stop it before starting the daemon")
+
+// runDaemon 运行无界面模式并返回进程退出码
+func runDaemon(appLogger *applog.FileLogger, args []string) int {
+	if len(args) > 0 {
+		if args[0] == "-h" || args[0] == "--help" || args[0] == "help" {
+			fmt.Print(daemonUsage)
+			return 0
+		}
+		fmt.Fprintf(os.Stderr, "Error: unexpected argument %q\n\n%s", args[0], daemonUsage)
+		return 2
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := serveDaemon(ctx, appLogger); err != nil {
+		appLogger.Error("daemon failed: " + err.Error())
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// serveDaemon 启动服务图并阻塞到 stopCtx 结束，随后按 GUI 退出流程保存数据
+func serveDaemon(stopCtx context.Context, appLogger *applog.FileLogger) error {
+	if ipcclient.IsServerRunning() {
+		return errDaemonAlreadyRunning
+	}
+
+	applog.SetMode(applog.ModeGUI)
+	applog.SetLogger(appLogger)
+	appLogger.Info("daemon startup initiated")
+
+	// 服务使用独立的 ctx，收到退出信号后仍能完成备份和数据库关闭
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	loadedConfig, err := appconf.LoadConfig()
+	if err != nil {
+		return fmt.Errorf("读取应用配置失败: %w", err)
+	}
+	config = loadedConfig
+	applyPendingRestores(config, appLogger)
+
+	db, err = appdb.Open(ctx, config, appLogger)
+	if err != nil {
+		return err
+	}
+
+	// daemon 没有窗口：事件无人接收，对话框、安装确认和应用内更新不可用
+	svc := newAppServices(nil)
+	svc.init(ctx, db, config)
+
+	if err := svc.sessionService.CleanupUnfinishedSessions(); err != nil {
+		appLogger.Error("startup cleanup unfinished sessions failed: " + err.Error())
+	}
+
+	systemSessionEnding := make(chan struct{})
+	sessionEndHook, err := sessionend.Start(sessionend.Options{
+		Reason: "LunaBox 正在保存数据并退出",
+		OnQueryEndSession: func() {
+			close(systemSessionEnding)
+		},
+	})
+	if err != nil {
+		appLogger.Error("failed to start Windows session-end hook: " + err.Error())
+	}
+
+	if err := svc.mcpServerService.ApplyConfig(*config); err != nil {
+		appLogger.Error("failed to apply MCP server config: " + err.Error())
+	}
+	ipcHTTPServer = ipcserver.StartServer(svc.cliApp(ctx, db, config), wailsruntime.Unavailable())
+	if ipcHTTPServer == nil {
+		// lunacli 与协议启动都依赖 IPC 服务器，没有它的 daemon 无法被使用，直接以失败退出
+		abortDaemonStartup(svc, sessionEndHook, appLogger)
+		return errors.New("IPC 服务器启动失败，详见日志")
+	}
+	if shouldRunAutomaticCloudSync(config) {
+		svc.cloudSyncService.RunStartupSync()
+	}
+	svc.cloudSyncService.StartScheduledSync()
+	svc.remotePushService.Start()
+	appLogger.Info("daemon started")
+	fmt.Println("LunaBox daemon is running. Press Ctrl+C to stop.")
+
+	isSystemSessionEnding := false
+	select {
+	case <-stopCtx.Done():
+	case <-systemSessionEnding:
+		isSystemSessionEnding = true
+	}
+	shutdownDaemon(svc, appLogger, isSystemSessionEnding)
+	stopSessionEndHook(sessionEndHook, appLogger)
+	return nil
+}
+
+// abortDaemonStartup 在后台任务启动前放弃启动：关闭已打开的 MCP 服务器、会话结束钩子和数据库，不做退出备份
+func abortDaemonStartup(svc *appServices, sessionEndHook *sessionend.Hook, appLogger *applog.FileLogger) {
+	if err := svc.mcpServerService.Shutdown(); err != nil {
+		appLogger.Error("failed to shutdown MCP server: " + err.Error())
+	}
+	stopSessionEndHook(sessionEndHook, appLogger)
+
+	closeCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := dbutils.SafeCloseDuckDB(closeCtx, db, appLogger); err != nil {
+		appLogger.Error("database shutdown completed with error: " + err.Error())
+	}
+	db = nil
+}
+
+func stopSessionEndHook(sessionEndHook *sessionend.Hook, appLogger *applog.FileLogger) {
+	if sessionEndHook == nil {
+		return
+	}
+	sessionEndHook.ReleaseShutdownBlockReason()
+	if err := sessionEndHook.Stop(); err != nil {
+		appLogger.Error("failed to shutdown Windows session-end hook: " + err.Error())
+	}
+}
+
+// shutdownDaemon 停止后台任务并保存数据，步骤与 GUI 退出保持一致
+func shutdownDaemon(svc *appServices, appLogger *applog.FileLogger, systemSessionEnding bool) {
+	appLogger.Info("daemon shutdown initiated")
+	svc.cloudSyncService.StopScheduledSync()
+	svc.remotePushService.Stop()
+
+	if err := ipcserver.ShutdownServer(ipcHTTPServer); err != nil {
+		appLogger.Error("failed to shutdown IPC server: " + err.Error())
+	}
+	ipcHTTPServer = nil
+	if err := svc.mcpServerService.Shutdown(); err != nil {
+		appLogger.Error("failed to shutdown MCP server: " + err.Error())
+	}
+
+	if latestConfig, err := svc.configService.GetAppConfig(); err != nil {
+		appLogger.Error("failed to get latest config: " + err.Error())
+	} else {
+		config = &latestConfig
+	}
+	svc.startService.CleanupPendingSessions()
+	if !systemSessionEnding && config.AutoBackupDB {
+		if _, err := svc.backupService.CreateDBBackupForShutdown(); err != nil {
+			appLogger.Error("automatic local database backup failed: " + err.Error())
+		}
+	}
+
+	closeCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := dbutils.SafeCloseDuckDB(closeCtx, db, appLogger); err != nil {
+		appLogger.Error("database shutdown completed with error: " + err.Error())
+	}
+	db = nil
+	if err := appconf.SaveConfig(config); err != nil {
+		appLogger.Error("failed to save config: " + err.Error())
+	}
+	appLogger.Info("daemon shutdown completed")
+}
@@ -34,6 +34,14 @@ type HikarinagiStatusPushFailureEvent = {
   error?: string;
 };
 
+type VNDBStatusPushFailureEvent = {
+  game_id?: string;
+  game_name?: string;
+  vn_id?: string;
+  local_status?: string;
+  error?: string;
+};
+
 type UseAppRuntimeEffectsOptions = {
   config: appconf.AppConfig | null;
   refreshConfig: () => Promise<void>;
@@ -298,6 +306,31 @@ export function useAppRuntimeEffects({
     return unsubscribe;
   }, [t]);
 
+  useEffect(() => {
+    const unsubscribe = onWailsEvent(
+      "vndb:status-push-failed",
+      (payload?: VNDBStatusPushFailureEvent) => {
+        const gameName
+          = payload?.game_name?.trim()
+            || t("settings.basic.vndbStatusPushFailedUnknownGame");
+        const error
+          = payload?.error?.trim()
+            || t("settings.basic.vndbStatusPushFailedUnknownReason");
+        toast.error(
+          t("settings.basic.vndbStatusPushFailed", {
+            game: gameName,
+            error,
+          }),
+          {
+            id: `vndb-status-push-failed-${payload?.game_id || "unknown"}`,
+          },
+        );
+      },
+    );
+
+    return unsubscribe;
+  }, [t]);
+
   useEffect(() => {
     const unsubscribe = onWailsEvent("app:main-window-shown", () => {
       void refreshHomeData();
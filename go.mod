@@ -3,6 +3,7 @@ module lunabox
 go 1.26.3
 
 require (
+	github.com/Microsoft/go-winio v0.6.2
 	github.com/PuerkitoBio/goquery v1.10.3
 	github.com/Umbrae-Labs/umbra-sdk/umbra-go v0.3.0
 	github.com/aws/aws-sdk-go-v2 v1.41.0
@@ -16,14 +17,18 @@ require (
 	github.com/labstack/gommon v0.4.2
 	github.com/mattn/go-runewidth v0.0.19
 	github.com/mattn/go-sqlite3 v1.14.48
+	github.com/pkg/sftp v1.13.10
 	github.com/spf13/cobra v1.10.2
 	github.com/syndtr/goleveldb v1.0.0
 	github.com/wailsapp/wails/v3 v3.0.0-beta.5
 	github.com/zeebo/blake3 v0.2.4
+	golang.org/x/crypto v0.51.0
 	golang.org/x/image v0.41.0
 	golang.org/x/mod v0.35.0
+	golang.org/x/net v0.55.0
 	golang.org/x/sys v0.45.0
 	golift.io/xtractr v0.3.0
+	gopkg.in/yaml.v3 v3.0.1
 	lunabox/updater v0.0.0
 	resty.dev/v3 v3.0.0-rc.3
 )
@@ -41,6 +46,7 @@ require (
 	github.com/duckdb/duckdb-go-bindings/lib/windows-amd64 v0.3.5 // indirect
 	github.com/ebitengine/purego v0.8.3 // indirect
 	github.com/klauspost/compress v1.18.4 // indirect
+	github.com/kr/fs v0.1.0 // indirect
 	github.com/onsi/gomega v1.34.1 // indirect
 	github.com/tetratelabs/wazero v1.9.0 // indirect
 )
@@ -98,9 +104,7 @@ require (
 	github.com/valyala/fasttemplate v1.2.2 // indirect
 	github.com/zeebo/xxh3 v1.1.0 // indirect
 	go4.org v0.0.0-20260112195520-a5071408f32f // indirect
-	golang.org/x/crypto v0.51.0 // indirect
 	golang.org/x/exp v0.0.0-20260410095643-746e56fc9e2f // indirect
-	golang.org/x/net v0.55.0 // indirect
 	golang.org/x/sync v0.20.0 // indirect
 	golang.org/x/telemetry v0.0.0-20260409153401-be6f6cb8b1fa // indirect
 	golang.org/x/text v0.37.0 // indirect
@@ -40,11 +40,26 @@ const MinHomeGameCarouselIntervalSec = 4
 const DefaultProcessDetectionTimeoutSec = 60
 const MinProcessDetectionTimeoutSec = 60
 const MaxProcessDetectionTimeoutSec = 600
+const DefaultCloudSyncHistoryLimit = 10
+const MaxCloudSyncHistoryLimit = 100
+const DefaultCloudSyncTombstoneHorizonDays = 90
+const MinCloudSyncTombstoneHorizonDays = 7
+const MaxCloudSyncTombstoneHorizonDays = 3650
 const DefaultBatchImportScanPreset = "scan_parent"
 const MaxBatchImportHierarchyDepth = 5
 const DefaultGameCardLayout = "portrait"
 const DefaultUmbraBaseURL = "https://umbrae.cc"
 
+// BackupPasswordRotation 记录一次备份密码轮换的新旧身份与密钥。
+// 配置中的 BackupUserID 已切换为 NewUserID 时表示复制校验已完成，只剩清理旧命名空间。
+type BackupPasswordRotation struct {
+	OldUserID        string `json:"old_user_id"`
+	OldEncryptionKey string `json:"old_encryption_key,omitempty"`
+	NewUserID        string `json:"new_user_id"`
+	NewEncryptionKey string `json:"new_encryption_key"`
+	StartedAt        string `json:"started_at"`
+}
+
 // AppConfig 应用配置结构体
 type AppConfig struct {
 	BangumiAccessToken            string                       `json:"access_token,omitempty"`
@@ -64,7 +79,8 @@ type AppConfig struct {
 	HikarinagiAuthError           string                       `json:"hikarinagi_auth_error,omitempty"`
 	HikarinagiStatusPushEnabled   *bool                        `json:"hikarinagi_status_push_enabled,omitempty"`
 	VNDBAccessToken               string                       `json:"vndb_access_token,omitempty"`
-	MetadataSources               []string                     `json:"metadata_sources,omitempty"`        // 元数据拉取来源列表（bangumi/vndb/ymgal/steam/dlsite/touchgal/hikarinagi/erogamescape）
+	VNDBStatusPushEnabled         *bool                        `json:"vndb_status_push_enabled,omitempty"`
+	MetadataSources               []string                     `json:"metadata_sources,omitempty"`        // 元数据拉取来源列表（bangumi/vndb/ymgal/steam/dlsite/touchgal/hikarinagi/erogamescape 及元数据插件 ID）
 	AllowDuplicateMetadataImport  bool                         `json:"allow_duplicate_metadata_import"`   // 批量/外部导入时允许相同 source_type + source_id
 	BangumiCoverSource            enums2.MetadataCoverSource   `json:"bangumi_cover_source,omitempty"`    // Bangumi 封面来源
 	VNDBCoverSource               enums2.MetadataCoverSource   `json:"vndb_cover_source,omitempty"`       // VNDB 封面来源
@@ -88,9 +104,10 @@ type AppConfig struct {
 	MCPPort             int    `json:"mcp_port,omitempty"`          // MCP HTTP 服务监听端口（仅绑定 127.0.0.1）
 	// 云备份配置
 	CloudBackupEnabled   bool   `json:"cloud_backup_enabled"`             // 是否启用云备份
-	CloudBackupProvider  string `json:"cloud_backup_provider,omitempty"`  // 云备份提供商: s3, onedrive, umbra, webdav
+	CloudBackupProvider  string `json:"cloud_backup_provider,omitempty"`  // 云备份提供商: s3, onedrive, umbra, webdav, local, sftp
 	BackupPassword       string `json:"backup_password,omitempty"`        // 备份密码（用于生成 user-id 和加密）
 	BackupUserID         string `json:"backup_user_id,omitempty"`         // 云端用户标识（由备份密码 hash 生成）
+	BackupEncryptionKey  string `json:"backup_encryption_key,omitempty"`  // 云端端到端加密主密钥（由备份密码派生，base64）
 	CloudSyncEnabled     bool   `json:"cloud_sync_enabled"`               // 是否启用云同步
 	AutoCloudSyncEnabled bool   `json:"auto_cloud_sync_enabled"`          // 是否启用自动云同步（启动时 + 定时）
 	CloudSyncIntervalSec int    `json:"cloud_sync_interval_sec"`          // 定时全量同步间隔（秒）
@@ -103,6 +120,15 @@ type AppConfig struct {
 	S3AccessKey          string `json:"s3_access_key,omitempty"`          // S3 Access Key
 	S3SecretKey          string `json:"s3_secret_key,omitempty"`          // S3 Secret Key
 	CloudBackupRetention int    `json:"cloud_backup_retention,omitempty"` // 云端保留备份数量
+
+	// 进行中的备份密码轮换（中断后据此续传，仅由 BackupService 维护）
+	BackupPasswordRotation *BackupPasswordRotation `json:"backup_password_rotation,omitempty"`
+
+	// 云同步在远端保留的历史版本数量（0 表示使用默认值）
+	CloudSyncHistoryLimit int `json:"cloud_sync_history_limit,omitempty"`
+	// 删除墓碑的压缩期限（天）：早于该期限且所有已知设备都已看到的墓碑会被清理，0 表示使用默认值
+	CloudSyncTombstoneHorizonDays int `json:"cloud_sync_tombstone_horizon_days,omitempty"`
+
 	// OneDrive OAuth 配置
 	OneDriveClientID     string `json:"onedrive_client_id,omitempty"`     // OneDrive Client ID
 	OneDriveRefreshToken string `json:"onedrive_refresh_token,omitempty"` // OneDrive Refresh Token（OAuth 授权后获得）
@@ -110,6 +136,17 @@ type AppConfig struct {
 	WebDAVURL      string `json:"webdav_url,omitempty"`      // WebDAV 服务地址（可含子路径）
 	WebDAVUsername string `json:"webdav_username,omitempty"` // WebDAV 用户名
 	WebDAVPassword string `json:"webdav_password,omitempty"` // WebDAV 密码
+	// 本地目录配置（可指向已挂载的 NAS 共享目录）
+	LocalSyncPath string `json:"local_sync_path,omitempty"` // 本地同步目录（绝对路径或 file:// URL）
+	// SFTP 配置
+	SFTPHost               string `json:"sftp_host,omitempty"`                 // SFTP 服务器地址
+	SFTPPort               int    `json:"sftp_port,omitempty"`                 // SFTP 端口（0 表示 22）
+	SFTPUsername           string `json:"sftp_username,omitempty"`             // SFTP 用户名
+	SFTPPassword           string `json:"sftp_password,omitempty"`             // SFTP 密码（可与私钥二选一）
+	SFTPPrivateKeyPath     string `json:"sftp_private_key_path,omitempty"`     // SFTP 私钥文件路径
+	SFTPKeyPassphrase      string `json:"sftp_key_passphrase,omitempty"`       // SFTP 私钥密码
+	SFTPHostKeyFingerprint string `json:"sftp_host_key_fingerprint,omitempty"` // SFTP 主机密钥指纹（SHA256:...，为空时使用 known_hosts）
+	SFTPRootPath           string `json:"sftp_root_path,omitempty"`            // SFTP 远端根目录（相对路径基于家目录）
 	// Umbra OAuth 配置（token 与设备密钥由 DPAPI 加密存储，不写入配置文件）
 	UmbraBaseURL       string `json:"umbra_base_url,omitempty"`      // Umbra 服务地址
 	UmbraAuthenticated bool   `json:"umbra_authenticated,omitempty"` // 是否已完成 OAuth 与设备注册
@@ -207,6 +244,7 @@ func LoadConfig() (*AppConfig, error) {
 		HikarinagiAuthError:           "",
 		HikarinagiStatusPushEnabled:   boolPtr(true),
 		VNDBAccessToken:               "",
+		VNDBStatusPushEnabled:         boolPtr(false),
 		MetadataSources:               cloneStringSlice(defaultMetadataSources),
 		AllowDuplicateMetadataImport:  false,
 		BangumiCoverSource:            enums2.MetadataCoverSourceHikarinagi,
@@ -227,6 +265,7 @@ func LoadConfig() (*AppConfig, error) {
 		CloudBackupProvider:           "umbra",
 		BackupPassword:                "",
 		BackupUserID:                  "",
+		BackupEncryptionKey:           "",
 		CloudSyncEnabled:              false,
 		AutoCloudSyncEnabled:          false,
 		CloudSyncIntervalSec:          60,
@@ -240,11 +279,22 @@ func LoadConfig() (*AppConfig, error) {
 		S3AccessKey:                   "",
 		S3SecretKey:                   "",
 		CloudBackupRetention:          5,
+		CloudSyncHistoryLimit:         DefaultCloudSyncHistoryLimit,
+		CloudSyncTombstoneHorizonDays: DefaultCloudSyncTombstoneHorizonDays,
 		OneDriveClientID:              "",
 		OneDriveRefreshToken:          "",
 		WebDAVURL:                     "",
 		WebDAVUsername:                "",
 		WebDAVPassword:                "",
+		LocalSyncPath:                 "",
+		SFTPHost:                      "",
+		SFTPPort:                      0,
+		SFTPUsername:                  "",
+		SFTPPassword:                  "",
+		SFTPPrivateKeyPath:            "",
+		SFTPKeyPassphrase:             "",
+		SFTPHostKeyFingerprint:        "",
+		SFTPRootPath:                  "",
 		UmbraBaseURL:                  DefaultUmbraBaseURL,
 		UmbraAuthenticated:            false,
 		LastDBBackupTime:              "",
@@ -334,6 +384,8 @@ func LoadConfig() (*AppConfig, error) {
 	config.ScrapedTagLimit = NormalizeScrapedTagLimit(config.ScrapedTagLimit)
 	config.HomeGameCarouselIntervalSec = NormalizeHomeGameCarouselIntervalSec(config.HomeGameCarouselIntervalSec)
 	config.ProcessDetectionTimeoutSec = NormalizeProcessDetectionTimeoutSec(config.ProcessDetectionTimeoutSec)
+	config.CloudSyncHistoryLimit = NormalizeCloudSyncHistoryLimit(config.CloudSyncHistoryLimit)
+	config.CloudSyncTombstoneHorizonDays = NormalizeCloudSyncTombstoneHorizonDays(config.CloudSyncTombstoneHorizonDays)
 	config.GameCardLayout = NormalizeGameCardLayout(config.GameCardLayout)
 	NormalizeBatchImportPreferences(config)
 
@@ -363,7 +415,14 @@ func LoadConfig() (*AppConfig, error) {
 	// 备份口令只在初始化时使用，不应长期明文落盘。
 	if config.BackupPassword != "" {
 		if config.BackupUserID == "" {
-			config.BackupUserID = utils.GenerateUserID(config.BackupPassword)
+			if userID, key, err := utils.DeriveBackupCredentials(config.BackupPassword); err == nil {
+				config.BackupUserID = userID
+				config.BackupEncryptionKey = key
+			}
+		} else if config.BackupEncryptionKey == "" {
+			if key, ok, err := utils.ResolveBackupCredentials(config.BackupPassword, config.BackupUserID); err == nil && ok {
+				config.BackupEncryptionKey = key
+			}
 		}
 		config.BackupPassword = ""
 		shouldSaveSanitizedConfig = true
@@ -447,6 +506,15 @@ func IsHikarinagiStatusPushEnabled(config *AppConfig) bool {
 	return *config.HikarinagiStatusPushEnabled
 }
 
+// IsVNDBStatusPushEnabled 默认关闭：VNDB 令牌最初只用于元数据，需用户明确开启后才写入其列表
+func IsVNDBStatusPushEnabled(config *AppConfig) bool {
+	if config == nil || config.VNDBStatusPushEnabled == nil {
+		return false
+	}
+
+	return *config.VNDBStatusPushEnabled
+}
+
 func NormalizeScrapedTagLimit(limit int) int {
 	if limit < -1 {
 		return -1
@@ -477,6 +545,31 @@ func NormalizeProcessDetectionTimeoutSec(timeoutSec int) int {
 	return timeoutSec
 }
 
+// NormalizeCloudSyncHistoryLimit 把云同步历史版本保留数量约束到 [1, MaxCloudSyncHistoryLimit]，未设置时使用默认值。
+func NormalizeCloudSyncHistoryLimit(limit int) int {
+	if limit <= 0 {
+		return DefaultCloudSyncHistoryLimit
+	}
+	if limit > MaxCloudSyncHistoryLimit {
+		return MaxCloudSyncHistoryLimit
+	}
+	return limit
+}
+
+// NormalizeCloudSyncTombstoneHorizonDays 把墓碑压缩期限约束到 [MinCloudSyncTombstoneHorizonDays, MaxCloudSyncTombstoneHorizonDays]，未设置时使用默认值。
+func NormalizeCloudSyncTombstoneHorizonDays(days int) int {
+	if days <= 0 {
+		return DefaultCloudSyncTombstoneHorizonDays
+	}
+	if days < MinCloudSyncTombstoneHorizonDays {
+		return MinCloudSyncTombstoneHorizonDays
+	}
+	if days > MaxCloudSyncTombstoneHorizonDays {
+		return MaxCloudSyncTombstoneHorizonDays
+	}
+	return days
+}
+
 func NormalizeBatchImportPreferences(config *AppConfig) bool {
 	if config == nil {
 		return false
@@ -500,7 +593,7 @@ func NormalizeBatchImportPreferences(config *AppConfig) bool {
 	}
 
 	if config.BatchImportPreferredSource != "" {
-		if _, ok := allowedMetadataSourceSet[config.BatchImportPreferredSource]; !ok {
+		if !isAllowedMetadataSource(config.BatchImportPreferredSource) {
 			config.BatchImportPreferredSource = ""
 			changed = true
 		}
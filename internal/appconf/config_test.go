@@ -15,6 +15,15 @@ func TestNormalizeMetadataSourcesAcceptsOptInSources(t *testing.T) {
 	}
 }
 
+func TestNormalizeMetadataSourcesKeepsPluginSources(t *testing.T) {
+	got := normalizeMetadataSources([]string{"Plugin:Getchu", "getchu", "plugin:", "bangumi", "plugin:getchu"})
+	want := []string{"plugin:getchu", "bangumi"}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %#v, got %#v", want, got)
+	}
+}
+
 func TestNormalizeMetadataSourcesUsesExpectedDefaults(t *testing.T) {
 	got := normalizeMetadataSources(nil)
 	want := []string{"bangumi", "vndb", "hikarinagi", "steam"}
@@ -20,7 +20,7 @@ func normalizeMetadataSources(sources []string) []string {
 		if normalized == "" {
 			continue
 		}
-		if _, ok := allowedMetadataSourceSet[normalized]; !ok {
+		if !isAllowedMetadataSource(normalized) {
 			continue
 		}
 		if _, exists := seen[normalized]; exists {
@@ -37,6 +37,15 @@ func normalizeMetadataSources(sources []string) []string {
 	return result
 }
 
+// isAllowedMetadataSource 内置来源或符合插件 ID 规则的来源。插件可能尚未加载，
+// 是否实际可用在拉取时由 gamehelper.ConfiguredMetadataSources 判断。
+func isAllowedMetadataSource(source string) bool {
+	if _, ok := allowedMetadataSourceSet[source]; ok {
+		return true
+	}
+	return enums2.IsPluginSourceType(enums2.SourceType(source))
+}
+
 func cloneStringSlice(values []string) []string {
 	if len(values) == 0 {
 		return []string{}
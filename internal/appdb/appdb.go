@@ -0,0 +1,65 @@
+package appdb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+
+	"lunabox/internal/appconf"
+	"lunabox/internal/migrations"
+	"lunabox/internal/utils/apputils"
+	"lunabox/internal/utils/dbutils"
+)
+
+// FileName 应用数据库文件名，位于数据目录下
+const FileName = "lunabox.db"
+
+// Path 返回应用数据库路径
+func Path() (string, error) {
+	dataDir, err := apputils.GetDataDir()
+	if err != nil {
+		return "", fmt.Errorf("获取应用数据目录失败: %w", err)
+	}
+	return filepath.Join(dataDir, FileName), nil
+}
+
+// Open 打开应用数据库，设置时区并完成结构初始化与迁移。
+// GUI 与 lunacli 单机模式共用，保证两者看到的数据库结构一致。
+func Open(ctx context.Context, config *appconf.AppConfig, logger dbutils.Logger) (*sql.DB, error) {
+	dbPath, err := Path()
+	if err != nil {
+		return nil, err
+	}
+	db, err := dbutils.OpenDuckDBWithWALRecovery(ctx, dbPath, logger)
+	if err != nil {
+		return nil, fmt.Errorf("打开数据库失败: %w", err)
+	}
+	if _, err = db.Exec("SET GLOBAL checkpoint_threshold = '4 MiB'"); err != nil {
+		logger.Warning("Failed to set DuckDB automatic checkpoint threshold; using the default: " + err.Error())
+	}
+	timeZone := config.TimeZone
+	if timeZone == "" {
+		timeZone = "UTC"
+	}
+	if _, err = db.Exec(fmt.Sprintf("SET TimeZone = '%s'", timeZone)); err != nil {
+		logger.Warning("Failed to set timezone: " + err.Error())
+	}
+
+	if err := migrations.InitSchema(db); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("初始化数据库结构失败: %w", err)
+	}
+	if err := migrations.Run(ctx, db); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("数据库迁移失败: %w", err)
+	}
+	if err := migrations.InitIndexes(db); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("初始化数据库索引失败: %w", err)
+	}
+	if err := dbutils.CheckpointDuckDB(ctx, db); err != nil {
+		logger.Warning("Database checkpoint after schema initialization failed; committed changes remain in WAL: " + err.Error())
+	}
+	return db, nil
+}
@@ -2,48 +2,440 @@ package cli
 
 import (
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"lunabox/internal/appconf"
+	"lunabox/internal/common/vo"
+	"lunabox/internal/models"
+	"lunabox/internal/service/cloudprovider"
 
 	"github.com/spf13/cobra"
 )
 
+// BackupResult backup 命令的结构化结果
+type BackupResult struct {
+	GameID   string             `json:"game_id"`
+	GameName string             `json:"game_name"`
+	Backup   *models.GameBackup `json:"backup"`
+}
+
+// DBBackupResult backup --database 的结构化结果
+type DBBackupResult struct {
+	Backup   *vo.DBBackupInfo `json:"backup"`
+	Uploaded bool             `json:"uploaded"`
+}
+
+// FullBackupResult backup --all 的结构化结果
+type FullBackupResult struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+// DBBackupPruneResult backup prune 的结构化结果
+type DBBackupPruneResult struct {
+	Kept    int               `json:"kept"`
+	Deleted []vo.DBBackupInfo `json:"deleted"`
+}
+
+// RestoreResult backup restore 的结构化结果，恢复在 LunaBox 下次启动时执行
+type RestoreResult struct {
+	Type    string `json:"type"` // database | full
+	Source  string `json:"source"`
+	Pending bool   `json:"pending"`
+}
+
 func newBackupCmd(app *CoreApp) *cobra.Command {
 	var gameQuery string
+	var database bool
+	var upload bool
+	var fullPath string
 
 	cmd := &cobra.Command{
 		Use:   "backup",
-		Short: "Create local backup",
-		// TODO: Add support for:
-		//   -d, --database: Backup database
-		//   -a, --all <path>: Full backup to specified path
+		Short: "Create local backups of game saves, the database or all data",
+		Long: `Create a backup. Exactly one of --game, --database or --all is required.
+
+  --game      snapshot the save directory of one game
+  --database  export the database and covers into the database backup folder
+              (old backups beyond the configured retention are removed);
+              add --upload to also upload it to the configured cloud storage
+  --all       write a full data backup (database, settings, covers, backups) to a .zip
+
+Use the subcommands to list, prune, upload and restore database backups.`,
+		Example: `  lunacli backup -g "Tsukiotsu"
+  lunacli backup --database --upload
+  lunacli backup --all /mnt/backup/lunabox-full.zip`,
+		Annotations: standaloneAnnotations,
+		Args:        cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// Check that exactly one backup type is specified
+			types := 0
+			for _, set := range []bool{gameQuery != "", database, fullPath != ""} {
+				if set {
+					types++
+				}
+			}
+			if types == 0 {
+				return usageError("please specify backup type using flags (see --help)")
+			}
+			if types > 1 {
+				return usageError("--game, --database and --all cannot be used together")
+			}
+			if upload && !database {
+				return usageError("--upload can only be used with --database")
+			}
+			if err := requireBackupService(app); err != nil {
+				return err
+			}
+
+			switch {
+			case gameQuery != "":
+				return runGameBackup(cmd, app, gameQuery)
+			case database:
+				return runDBBackup(cmd, app, upload)
+			default:
+				return runFullBackup(cmd, app, fullPath)
+			}
+		},
+	}
+
+	cmd.Flags().StringVarP(&gameQuery, "game", "g", "", "Backup game save (ID, ID prefix, or name - supports fuzzy matching)")
+	cmd.Flags().BoolVarP(&database, "database", "d", false, "Backup the database and covers")
+	cmd.Flags().BoolVar(&upload, "upload", false, "Upload the database backup to cloud storage (with --database)")
+	cmd.Flags().StringVarP(&fullPath, "all", "a", "", "Full data backup to the given .zip path")
+	_ = cmd.MarkFlagFilename("all", "zip")
+
+	cmd.AddCommand(newBackupListCmd(app))
+	cmd.AddCommand(newBackupPruneCmd(app))
+	cmd.AddCommand(newBackupUploadCmd(app))
+	cmd.AddCommand(newBackupCloudCmd(app))
+	cmd.AddCommand(newBackupRestoreCmd(app))
+	return cmd
+}
+
+// requireBackupService 检查备份服务是否可用
+func requireBackupService(app *CoreApp) error {
+	if app.BackupService == nil {
+		return unavailableError("backup is unavailable")
+	}
+	return nil
+}
+
+// requireCloudBackup 检查云备份是否已配置
+func requireCloudBackup(app *CoreApp) error {
+	if app.Config == nil || !cloudprovider.IsConfigured(app.Config) {
+		return unavailableError("cloud backup is not configured")
+	}
+	return nil
+}
+
+// saveBackupConfig 持久化备份服务写入内存配置的字段（上次备份时间、待恢复备份），
+// 单机模式下没有 GUI 退出时的保存流程，必须立即写盘
+func saveBackupConfig(app *CoreApp) error {
+	if app.Config == nil {
+		return nil
+	}
+	if err := appconf.SaveConfig(app.Config); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	return nil
+}
+
+func runGameBackup(cmd *cobra.Command, app *CoreApp, gameQuery string) error {
+	gameID, gameName, err := resolveGame(cmd, app, gameQuery)
+	if err != nil {
+		return err
+	}
+
+	backup, err := app.BackupService.CreateBackup(gameID)
+	if err != nil {
+		return err
+	}
+
+	result := BackupResult{GameID: gameID, GameName: gameName, Backup: backup}
+	return writeResult(cmd, result, func(w io.Writer) {
+		fmt.Fprintln(w, "✓ Game save backup created successfully!")
+		fmt.Fprintf(w, "Game: %s\n", gameName)
+		fmt.Fprintf(w, "File: %s\n", backup.Name)
+		fmt.Fprintf(w, "Size: %s\n", formatBytes(backup.Size))
+		fmt.Fprintf(w, "Path: %s\n", backup.Path)
+	})
+}
+
+func runDBBackup(cmd *cobra.Command, app *CoreApp, upload bool) error {
+	if upload {
+		if err := requireCloudBackup(app); err != nil {
+			return err
+		}
+	}
+
+	backup, err := app.BackupService.CreateDBBackup()
+	if err != nil {
+		return fmt.Errorf("database backup failed: %w", err)
+	}
+	if err := saveBackupConfig(app); err != nil {
+		return err
+	}
+	if upload {
+		if err := app.BackupService.UploadDBBackupToCloud(backup.Path); err != nil {
+			return fmt.Errorf("local backup created at %s, but cloud upload failed: %w", backup.Path, err)
+		}
+	}
+
+	result := DBBackupResult{Backup: backup, Uploaded: upload}
+	return writeResult(cmd, result, func(w io.Writer) {
+		fmt.Fprintln(w, "✓ Database backup created successfully!")
+		fmt.Fprintf(w, "File: %s\n", backup.Name)
+		fmt.Fprintf(w, "Size: %s\n", formatBytes(backup.Size))
+		fmt.Fprintf(w, "Path: %s\n", backup.Path)
+		if upload {
+			fmt.Fprintln(w, "✓ Uploaded to cloud storage")
+		}
+	})
+}
+
+func runFullBackup(cmd *cobra.Command, app *CoreApp, path string) error {
+	if !strings.EqualFold(filepath.Ext(path), ".zip") {
+		return usageError("full backup path must end with .zip: %s", path)
+	}
+
+	if err := app.BackupService.CreateFullDataBackup(path); err != nil {
+		return fmt.Errorf("full data backup failed: %w", err)
+	}
+	if err := saveBackupConfig(app); err != nil {
+		return err
+	}
+	result := FullBackupResult{Path: path}
+	if stat, err := os.Stat(path); err == nil {
+		result.Size = stat.Size()
+	}
+	return writeResult(cmd, result, func(w io.Writer) {
+		fmt.Fprintln(w, "✓ Full data backup created successfully!")
+		fmt.Fprintf(w, "Size: %s\n", formatBytes(result.Size))
+		fmt.Fprintf(w, "Path: %s\n", path)
+	})
+}
+
+// resolveDBBackup 按文件名或完整路径查找本地数据库备份
+func resolveDBBackup(app *CoreApp, query string) (vo.DBBackupInfo, error) {
+	status, err := app.BackupService.GetDBBackups()
+	if err != nil {
+		return vo.DBBackupInfo{}, fmt.Errorf("failed to list database backups: %w", err)
+	}
+	for _, b := range status.Backups {
+		if b.Name == query || b.Path == query {
+			return b, nil
+		}
+	}
+	return vo.DBBackupInfo{}, notFoundError("no database backup found: %s (see `lunacli backup list`)", query)
+}
+
+func newBackupListCmd(app *CoreApp) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List local database backups, newest first",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireBackupService(app); err != nil {
+				return err
+			}
+			status, err := app.BackupService.GetDBBackups()
+			if err != nil {
+				return fmt.Errorf("failed to list database backups: %w", err)
+			}
+			if status.Backups == nil {
+				status.Backups = []vo.DBBackupInfo{}
+			}
+			return writeResult(cmd, status, func(w io.Writer) {
+				if status.LastBackupTime != "" {
+					fmt.Fprintf(w, "Last backup: %s\n", status.LastBackupTime)
+				}
+				if len(status.Backups) == 0 {
+					fmt.Fprintln(w, "No database backups.")
+					return
+				}
+				fmt.Fprintf(w, "Database backups (%d):\n", len(status.Backups))
+				for _, b := range status.Backups {
+					fmt.Fprintf(w, "  %s  %10s  %s\n", b.CreatedAt.Local().Format("2006-01-02 15:04:05"), formatBytes(b.Size), b.Name)
+				}
+			})
+		},
+	}
+}
+
+func newBackupPruneCmd(app *CoreApp) *cobra.Command {
+	var keep int
+
+	cmd := &cobra.Command{
+		Use:   "prune --keep <n>",
+		Short: "Delete all but the newest n local database backups",
+		Args:  cobra.NoArgs,
 		RunE: func(cmd *cobra.Command, args []string) error {
-			w := cmd.OutOrStdout()
+			if err := requireBackupService(app); err != nil {
+				return err
+			}
+			if keep < 1 {
+				return usageError("--keep must be at least 1")
+			}
+			status, err := app.BackupService.GetDBBackups()
+			if err != nil {
+				return fmt.Errorf("failed to list database backups: %w", err)
+			}
 
-			// Check if at least one backup type is specified
-			if gameQuery == "" {
-				return fmt.Errorf("please specify backup type using flags (see --help)")
+			result := DBBackupPruneResult{Deleted: []vo.DBBackupInfo{}}
+			for i, b := range status.Backups {
+				if i < keep {
+					result.Kept++
+					continue
+				}
+				if err := app.BackupService.DeleteDBBackup(b.Path); err != nil {
+					return fmt.Errorf("failed to delete %s: %w", b.Name, err)
+				}
+				result.Deleted = append(result.Deleted, b)
 			}
+			return writeResult(cmd, result, func(w io.Writer) {
+				fmt.Fprintf(w, "✓ Kept %d database backup(s), deleted %d\n", result.Kept, len(result.Deleted))
+				for _, b := range result.Deleted {
+					fmt.Fprintf(w, "  - %s\n", b.Name)
+				}
+			})
+		},
+	}
 
-			// Perform game save backup
-			gameID, gameName, err := resolveGame(w, app, gameQuery)
+	cmd.Flags().IntVar(&keep, "keep", 0, "Number of newest backups to keep")
+	_ = cmd.MarkFlagRequired("keep")
+	return cmd
+}
+
+func newBackupUploadCmd(app *CoreApp) *cobra.Command {
+	return &cobra.Command{
+		Use:   "upload <backup>",
+		Short: "Upload a local database backup (file name or path) to cloud storage",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireBackupService(app); err != nil {
+				return err
+			}
+			if err := requireCloudBackup(app); err != nil {
+				return err
+			}
+			backup, err := resolveDBBackup(app, args[0])
 			if err != nil {
 				return err
 			}
+			if err := app.BackupService.UploadDBBackupToCloud(backup.Path); err != nil {
+				return fmt.Errorf("cloud upload failed: %w", err)
+			}
+			result := DBBackupResult{Backup: &backup, Uploaded: true}
+			return writeResult(cmd, result, func(w io.Writer) {
+				fmt.Fprintf(w, "✓ Uploaded %s to cloud storage\n", backup.Name)
+			})
+		},
+	}
+}
 
-			backup, err := app.BackupService.CreateBackup(gameID)
+func newBackupCloudCmd(app *CoreApp) *cobra.Command {
+	return &cobra.Command{
+		Use:   "cloud",
+		Short: "List database backups in cloud storage",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireBackupService(app); err != nil {
+				return err
+			}
+			if err := requireCloudBackup(app); err != nil {
+				return err
+			}
+			items, err := app.BackupService.GetCloudDBBackups()
 			if err != nil {
+				return fmt.Errorf("failed to list cloud backups: %w", err)
+			}
+			if items == nil {
+				items = []vo.CloudBackupItem{}
+			}
+			return writeResult(cmd, items, func(w io.Writer) {
+				if len(items) == 0 {
+					fmt.Fprintln(w, "No database backups in cloud storage.")
+					return
+				}
+				fmt.Fprintf(w, "Cloud database backups (%d):\n", len(items))
+				for _, item := range items {
+					fmt.Fprintf(w, "  %s  %s\n", item.CreatedAt.Local().Format("2006-01-02 15:04:05"), item.Key)
+				}
+			})
+		},
+	}
+}
+
+func newBackupRestoreCmd(app *CoreApp) *cobra.Command {
+	var cloudKey string
+	var fullPath string
+
+	cmd := &cobra.Command{
+		Use:   "restore [backup]",
+		Short: "Schedule a restore that runs the next time LunaBox starts",
+		Long: `Schedule a restore. The current data is replaced the next time LunaBox starts.
+
+  restore <backup>       a local database backup (file name or path, see backup list)
+  restore --cloud <key>  download a database backup from cloud storage (see backup cloud)
+  restore --full <path>  a full data backup .zip created with backup --all`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireBackupService(app); err != nil {
 				return err
 			}
+			sources := 0
+			for _, set := range []bool{len(args) == 1, cloudKey != "", fullPath != ""} {
+				if set {
+					sources++
+				}
+			}
+			if sources != 1 {
+				return usageError("please specify exactly one of <backup>, --cloud or --full")
+			}
 
-			fmt.Fprintln(w, "✓ Game save backup created successfully!")
-			fmt.Fprintf(w, "Game: %s\n", gameName)
-			fmt.Fprintf(w, "File: %s\n", backup.Name)
-			fmt.Fprintf(w, "Size: %s\n", formatBytes(backup.Size))
-			fmt.Fprintf(w, "Path: %s\n", backup.Path)
-			return nil
+			var result RestoreResult
+			switch {
+			case cloudKey != "":
+				if err := requireCloudBackup(app); err != nil {
+					return err
+				}
+				if err := app.BackupService.ScheduleDBRestoreFromCloud(cloudKey); err != nil {
+					return fmt.Errorf("failed to schedule restore: %w", err)
+				}
+				result = RestoreResult{Type: "database", Source: cloudKey, Pending: true}
+			case fullPath != "":
+				if err := app.BackupService.ScheduleFullDataRestore(fullPath); err != nil {
+					return fmt.Errorf("failed to schedule restore: %w", err)
+				}
+				result = RestoreResult{Type: "full", Source: fullPath, Pending: true}
+			default:
+				backup, err := resolveDBBackup(app, args[0])
+				if err != nil {
+					return err
+				}
+				if err := app.BackupService.ScheduleDBRestore(backup.Path); err != nil {
+					return fmt.Errorf("failed to schedule restore: %w", err)
+				}
+				result = RestoreResult{Type: "database", Source: backup.Path, Pending: true}
+			}
+			if err := saveBackupConfig(app); err != nil {
+				return err
+			}
+
+			return writeResult(cmd, result, func(w io.Writer) {
+				fmt.Fprintf(w, "✓ %s restore scheduled from %s\n", result.Type, result.Source)
+				fmt.Fprintln(w, "Restart LunaBox to apply it.")
+			})
 		},
 	}
 
-	cmd.Flags().StringVarP(&gameQuery, "game", "g", "", "Backup game save (ID, ID prefix, or name - supports fuzzy matching)")
+	cmd.Flags().StringVar(&cloudKey, "cloud", "", "Cloud object key of a database backup")
+	cmd.Flags().StringVar(&fullPath, "full", "", "Path of a full data backup .zip")
+	_ = cmd.MarkFlagFilename("full", "zip")
 	return cmd
 }
 
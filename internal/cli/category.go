@@ -0,0 +1,327 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"lunabox/internal/common/vo"
+	"lunabox/internal/models"
+
+	"github.com/spf13/cobra"
+)
+
+// CategoryGamesResult 收藏夹与其中游戏的结构化结果
+type CategoryGamesResult struct {
+	Category vo.CategoryVO `json:"category"`
+	Games    []models.Game `json:"games"`
+}
+
+// CategoryMembershipResult assign / remove 命令的结构化结果
+type CategoryMembershipResult struct {
+	CategoryID   string `json:"category_id"`
+	CategoryName string `json:"category_name"`
+	GameID       string `json:"game_id"`
+	GameName     string `json:"game_name"`
+	Member       bool   `json:"member"`
+}
+
+func newCategoryCmd(app *CoreApp) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:         "category",
+		Short:       "Manage categories (collections) and their games",
+		Annotations: standaloneAnnotations,
+	}
+	cmd.AddCommand(newCategoryListCmd(app))
+	cmd.AddCommand(newCategoryAddCmd(app))
+	cmd.AddCommand(newCategoryRenameCmd(app))
+	cmd.AddCommand(newCategoryDeleteCmd(app))
+	cmd.AddCommand(newCategoryGamesCmd(app))
+	cmd.AddCommand(newCategoryAssignCmd(app))
+	cmd.AddCommand(newCategoryRemoveCmd(app))
+	return cmd
+}
+
+// requireCategoryService 检查收藏夹服务是否可用
+func requireCategoryService(app *CoreApp) error {
+	if app.CategoryService == nil {
+		return unavailableError("category management is unavailable")
+	}
+	return nil
+}
+
+// resolveCategory 按 ID 或名称（不区分大小写）查找收藏夹
+func resolveCategory(app *CoreApp, query string) (vo.CategoryVO, error) {
+	if err := requireCategoryService(app); err != nil {
+		return vo.CategoryVO{}, err
+	}
+	categories, err := app.CategoryService.GetCategories()
+	if err != nil {
+		return vo.CategoryVO{}, fmt.Errorf("failed to get categories: %w", err)
+	}
+
+	var matches []vo.CategoryVO
+	for _, c := range categories {
+		if c.ID == query {
+			return c, nil
+		}
+		if strings.EqualFold(c.Name, query) {
+			matches = append(matches, c)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return vo.CategoryVO{}, notFoundError("no category found matching: %s", query)
+	case 1:
+		return matches[0], nil
+	}
+
+	candidates := make([]GameCandidate, 0, len(matches))
+	for _, c := range matches {
+		candidates = append(candidates, GameCandidate{ID: c.ID, Name: c.Name})
+	}
+	return vo.CategoryVO{}, ambiguousError(candidates, "multiple categories named %q; use the category ID instead", query)
+}
+
+func newCategoryListCmd(app *CoreApp) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List all categories",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireCategoryService(app); err != nil {
+				return err
+			}
+			categories, err := app.CategoryService.GetCategories()
+			if err != nil {
+				return fmt.Errorf("failed to get categories: %w", err)
+			}
+			if categories == nil {
+				categories = []vo.CategoryVO{}
+			}
+			return writeResult(cmd, categories, func(w io.Writer) {
+				if len(categories) == 0 {
+					fmt.Fprintln(w, "No categories.")
+					return
+				}
+				for _, c := range categories {
+					label := c.Name
+					if c.Emoji != "" {
+						label = c.Emoji + " " + label
+					}
+					if c.IsSystem {
+						label += " (system)"
+					}
+					fmt.Fprintf(w, "  %s  %s  [%d games]\n", c.ID, label, c.GameCount)
+				}
+			})
+		},
+	}
+}
+
+func newCategoryAddCmd(app *CoreApp) *cobra.Command {
+	var emoji string
+
+	cmd := &cobra.Command{
+		Use:   "add <name>",
+		Short: "Create a category",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireCategoryService(app); err != nil {
+				return err
+			}
+			name := strings.TrimSpace(args[0])
+			if name == "" {
+				return usageError("category name cannot be empty")
+			}
+			before, err := app.CategoryService.GetCategories()
+			if err != nil {
+				return fmt.Errorf("failed to get categories: %w", err)
+			}
+			existing := make(map[string]bool, len(before))
+			for _, c := range before {
+				existing[c.ID] = true
+			}
+
+			if err := app.CategoryService.AddCategory(name, emoji); err != nil {
+				return fmt.Errorf("failed to add category: %w", err)
+			}
+
+			// AddCategory 不返回新 ID，通过前后对比找到刚创建的收藏夹
+			after, err := app.CategoryService.GetCategories()
+			if err != nil {
+				return fmt.Errorf("failed to get categories: %w", err)
+			}
+			created := vo.CategoryVO{Name: name, Emoji: emoji}
+			for _, c := range after {
+				if !existing[c.ID] && c.Name == name {
+					created = c
+					break
+				}
+			}
+			return writeResult(cmd, created, func(w io.Writer) {
+				fmt.Fprintf(w, "✓ Category added: %s\n", created.Name)
+				if created.ID != "" {
+					fmt.Fprintf(w, "ID: %s\n", created.ID)
+				}
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&emoji, "emoji", "", "Emoji shown before the category name")
+	return cmd
+}
+
+func newCategoryRenameCmd(app *CoreApp) *cobra.Command {
+	var emoji string
+
+	cmd := &cobra.Command{
+		Use:   "rename <category> <new-name>",
+		Short: "Rename a category and optionally change its emoji",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			category, err := resolveCategory(app, args[0])
+			if err != nil {
+				return err
+			}
+			name := strings.TrimSpace(args[1])
+			if name == "" {
+				return usageError("category name cannot be empty")
+			}
+			if category.IsSystem {
+				return usageError("cannot rename system category: %s", category.Name)
+			}
+			if cmd.Flags().Changed("emoji") {
+				category.Emoji = emoji
+			}
+			if err := app.CategoryService.UpdateCategory(category.ID, name, category.Emoji); err != nil {
+				return fmt.Errorf("failed to rename category: %w", err)
+			}
+			updated, err := app.CategoryService.GetCategoryByID(category.ID)
+			if err != nil {
+				return fmt.Errorf("failed to get category: %w", err)
+			}
+			return writeResult(cmd, updated, func(w io.Writer) {
+				fmt.Fprintf(w, "✓ Category renamed: %s → %s\n", category.Name, updated.Name)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&emoji, "emoji", "", "New emoji (pass an empty string to clear it)")
+	return cmd
+}
+
+func newCategoryDeleteCmd(app *CoreApp) *cobra.Command {
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:   "delete <category>",
+		Short: "Delete a category; the games in it are kept",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			category, err := resolveCategory(app, args[0])
+			if err != nil {
+				return err
+			}
+			if category.IsSystem {
+				return usageError("cannot delete system category: %s", category.Name)
+			}
+			if !yes {
+				return usageError("refusing to delete category %s without --yes", category.Name)
+			}
+			if err := app.CategoryService.DeleteCategory(category.ID); err != nil {
+				return fmt.Errorf("failed to delete category: %w", err)
+			}
+			return writeResult(cmd, DeletedResult{ID: category.ID, Name: category.Name, Deleted: true}, func(w io.Writer) {
+				fmt.Fprintf(w, "✓ Category deleted: %s\n", category.Name)
+			})
+		},
+	}
+
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Confirm the deletion")
+	return cmd
+}
+
+func newCategoryGamesCmd(app *CoreApp) *cobra.Command {
+	return &cobra.Command{
+		Use:   "games <category>",
+		Short: "List the games in a category",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			category, err := resolveCategory(app, args[0])
+			if err != nil {
+				return err
+			}
+			games, err := app.CategoryService.GetGamesByCategory(category.ID)
+			if err != nil {
+				return fmt.Errorf("failed to get games: %w", err)
+			}
+			if games == nil {
+				games = []models.Game{}
+			}
+			result := CategoryGamesResult{Category: category, Games: games}
+			return writeResult(cmd, result, func(w io.Writer) {
+				fmt.Fprintf(w, "%s (%d games):\n", category.Name, len(games))
+				for _, g := range games {
+					fmt.Fprintf(w, "  %s  %s\n", shortGameID(g.ID), g.Name)
+				}
+			})
+		},
+	}
+}
+
+func newCategoryAssignCmd(app *CoreApp) *cobra.Command {
+	return &cobra.Command{
+		Use:   "assign <category> <game>",
+		Short: "Add a game to a category",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			category, err := resolveCategory(app, args[0])
+			if err != nil {
+				return err
+			}
+			gameID, gameName, err := resolveGame(cmd, app, args[1])
+			if err != nil {
+				return err
+			}
+			if err := app.CategoryService.AddGamesToCategories([]string{gameID}, []string{category.ID}); err != nil {
+				return fmt.Errorf("failed to add game to category: %w", err)
+			}
+			result := CategoryMembershipResult{
+				CategoryID: category.ID, CategoryName: category.Name,
+				GameID: gameID, GameName: gameName, Member: true,
+			}
+			return writeResult(cmd, result, func(w io.Writer) {
+				fmt.Fprintf(w, "✓ %s added to %s\n", gameName, category.Name)
+			})
+		},
+	}
+}
+
+func newCategoryRemoveCmd(app *CoreApp) *cobra.Command {
+	return &cobra.Command{
+		Use:   "remove <category> <game>",
+		Short: "Remove a game from a category",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			category, err := resolveCategory(app, args[0])
+			if err != nil {
+				return err
+			}
+			gameID, gameName, err := resolveGame(cmd, app, args[1])
+			if err != nil {
+				return err
+			}
+			if err := app.CategoryService.RemoveGamesFromCategory([]string{gameID}, category.ID); err != nil {
+				return fmt.Errorf("failed to remove game from category: %w", err)
+			}
+			result := CategoryMembershipResult{
+				CategoryID: category.ID, CategoryName: category.Name,
+				GameID: gameID, GameName: gameName, Member: false,
+			}
+			return writeResult(cmd, result, func(w io.Writer) {
+				fmt.Fprintf(w, "✓ %s removed from %s\n", gameName, category.Name)
+			})
+		},
+	}
+}
@@ -3,8 +3,10 @@ package cli
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"io"
 	"lunabox/internal/appconf"
+	"lunabox/internal/cli/ipccore"
 	"lunabox/internal/service"
 
 	_ "github.com/duckdb/duckdb-go/v2"
@@ -12,14 +14,18 @@ import (
 
 // CoreApp CLI 模式的核心应用 (也可用于 GUI 传递 Context)
 type CoreApp struct {
-	Config         *appconf.AppConfig
-	DB             *sql.DB
-	Ctx            context.Context // Export Ctx
-	GameService    *service.GameService
-	StartService   *service.StartService
-	SessionService *service.SessionService
-	BackupService  *service.BackupService
-	VersionService *service.VersionService
+	Config            *appconf.AppConfig
+	DB                *sql.DB
+	Ctx               context.Context // Export Ctx
+	GameService       *service.GameService
+	StartService      *service.StartService
+	SessionService    *service.SessionService
+	CategoryService   *service.CategoryService
+	TagService        *service.TagService
+	BackupService     *service.BackupService
+	VersionService    *service.VersionService
+	WrappedService    *service.WrappedService
+	DataExportService *service.DataExportService
 }
 
 // RunCommand 执行 CLI 命令
@@ -27,10 +33,32 @@ type CoreApp struct {
 // app: 已初始化的 CoreApp
 // args: 命令行参数 (不包含程序名)
 func RunCommand(w io.Writer, app *CoreApp, args []string) error {
+	_, err := Execute(w, app, args)
+	return err
+}
+
+// Execute 执行 CLI 命令并返回结构化结果。
+// 返回的错误均可通过 ErrorKindOf / ExitCode 得到错误类型；
+// json / yaml 输出模式下，错误同样以结构化文档写入 w。
+func Execute(w io.Writer, app *CoreApp, args []string) (CommandResult, error) {
+	state := &commandState{format: OutputTable}
 	rootCmd := NewRootCmd(app)
 	rootCmd.SetOut(w)
 	rootCmd.SetErr(w) // 将错误输出也重定向到 w，以便 IPC 可以捕获
 	rootCmd.SetArgs(args)
 
-	return rootCmd.Execute()
+	err := rootCmd.ExecuteContext(withCommandState(context.Background(), state))
+	if err == nil {
+		return state.result, nil
+	}
+
+	// 未进入命令本身就失败的（未知命令、参数或 flag 错误）属于用法错误
+	var cmdErr *CommandError
+	if !state.started && !errors.As(err, &cmdErr) {
+		err = &CommandError{Kind: ipccore.ErrorKindUsage, Err: err}
+	}
+	if state.format != OutputTable {
+		_ = encodeStructured(w, state.format, newCommandErrorOutput(err))
+	}
+	return CommandResult{Format: state.format}, err
 }
@@ -2,6 +2,7 @@ package cli
 
 import (
 	"fmt"
+	"io"
 	"strings"
 
 	"github.com/mattn/go-runewidth"
@@ -14,10 +15,9 @@ func newDetailCmd(app *CoreApp) *cobra.Command {
 		Short: "Show detailed information for a game",
 		Args:  cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			w := cmd.OutOrStdout()
 			gameQuery := args[0]
 
-			gameID, _, err := resolveGame(w, app, gameQuery)
+			gameID, _, err := resolveGame(cmd, app, gameQuery)
 			if err != nil {
 				return err
 			}
@@ -27,28 +27,29 @@ func newDetailCmd(app *CoreApp) *cobra.Command {
 				return err
 			}
 
-			fmt.Fprintln(w)
-			fmt.Fprintf(w, "Name: %s\n", game.Name)
-			fmt.Fprintf(w, "ID: %s\n", game.ID)
-			fmt.Fprintf(w, "Status: %s\n", formatGameStatus(string(game.Status)))
-			fmt.Fprintf(w, "Source: %s\n", emptyAsNA(string(game.SourceType)))
-			fmt.Fprintf(w, "Company: %s\n", emptyAsNA(game.Company))
-			fmt.Fprintf(w, "Launch Path: %s\n", emptyAsNA(game.Path))
-			fmt.Fprintf(w, "Save Path: %s\n", emptyAsNA(game.SavePath))
-			fmt.Fprintf(w, "Process Name: %s\n", emptyAsNA(game.ProcessName))
-			fmt.Fprintf(w, "Use Locale Emulator: %t\n", game.UseLocaleEmulator)
-			fmt.Fprintf(w, "Use Magpie: %t\n", game.UseMagpie)
-			fmt.Fprintf(w, "Created At: %s\n", game.CreatedAt.Format("2006-01-02 15:04:05"))
-			fmt.Fprintf(w, "Cached At: %s\n", game.CachedAt.Format("2006-01-02 15:04:05"))
-
-			// Format summary with word wrap
-			summary := strings.TrimSpace(game.Summary)
-			if summary == "" {
-				fmt.Fprintf(w, "Summary: N/A\n")
-			} else {
-				fmt.Fprintf(w, "Summary:\n%s\n", wrapText(summary, 70, "  "))
-			}
-			return nil
+			return writeResult(cmd, game, func(w io.Writer) {
+				fmt.Fprintln(w)
+				fmt.Fprintf(w, "Name: %s\n", game.Name)
+				fmt.Fprintf(w, "ID: %s\n", game.ID)
+				fmt.Fprintf(w, "Status: %s\n", formatGameStatus(string(game.Status)))
+				fmt.Fprintf(w, "Source: %s\n", emptyAsNA(string(game.SourceType)))
+				fmt.Fprintf(w, "Company: %s\n", emptyAsNA(game.Company))
+				fmt.Fprintf(w, "Launch Path: %s\n", emptyAsNA(game.Path))
+				fmt.Fprintf(w, "Save Path: %s\n", emptyAsNA(game.SavePath))
+				fmt.Fprintf(w, "Process Name: %s\n", emptyAsNA(game.ProcessName))
+				fmt.Fprintf(w, "Use Locale Emulator: %t\n", game.UseLocaleEmulator)
+				fmt.Fprintf(w, "Use Magpie: %t\n", game.UseMagpie)
+				fmt.Fprintf(w, "Created At: %s\n", game.CreatedAt.Format("2006-01-02 15:04:05"))
+				fmt.Fprintf(w, "Cached At: %s\n", game.CachedAt.Format("2006-01-02 15:04:05"))
+
+				// Format summary with word wrap
+				summary := strings.TrimSpace(game.Summary)
+				if summary == "" {
+					fmt.Fprintf(w, "Summary: N/A\n")
+				} else {
+					fmt.Fprintf(w, "Summary:\n%s\n", wrapText(summary, 70, "  "))
+				}
+			})
 		},
 	}
 }
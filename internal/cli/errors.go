@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+
+	"lunabox/internal/cli/ipccore"
+)
+
+// CommandError 带错误类型的命令错误，类型决定 lunacli 的退出码
+type CommandError struct {
+	Kind ipccore.ErrorKind
+	Err  error
+	// Candidates 查询匹配到多个游戏时的候选列表
+	Candidates []GameCandidate
+}
+
+func (e *CommandError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+// GameCandidate 游戏查询的候选项
+type GameCandidate struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+func newCommandError(kind ipccore.ErrorKind, format string, args ...any) *CommandError {
+	return &CommandError{Kind: kind, Err: fmt.Errorf(format, args...)}
+}
+
+func usageError(format string, args ...any) error {
+	return newCommandError(ipccore.ErrorKindUsage, format, args...)
+}
+
+func notFoundError(format string, args ...any) error {
+	return newCommandError(ipccore.ErrorKindNotFound, format, args...)
+}
+
+func unavailableError(format string, args ...any) error {
+	return newCommandError(ipccore.ErrorKindUnavailable, format, args...)
+}
+
+func ambiguousError(candidates []GameCandidate, format string, args ...any) error {
+	err := newCommandError(ipccore.ErrorKindAmbiguous, format, args...)
+	err.Candidates = candidates
+	return err
+}
+
+// ErrorKindOf 返回错误的类型，未分类的错误视为一般错误
+func ErrorKindOf(err error) ipccore.ErrorKind {
+	var cmdErr *CommandError
+	if errors.As(err, &cmdErr) {
+		return cmdErr.Kind
+	}
+	return ipccore.ErrorKindGeneral
+}
+
+// ExitCode 返回错误对应的退出码
+func ExitCode(err error) int {
+	if err == nil {
+		return ipccore.ExitCodeOK
+	}
+	return ErrorKindOf(err).ExitCode()
+}
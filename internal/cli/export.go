@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+
+	"lunabox/internal/common/enums"
+	"lunabox/internal/common/vo"
+
+	"github.com/spf13/cobra"
+)
+
+// ExportResult export 写入文件后的结构化结果
+type ExportResult struct {
+	Entity enums.ExportEntity `json:"entity"`
+	Format enums.ExportFormat `json:"format"`
+	Rows   int                `json:"rows"`
+	Path   string             `json:"path"`
+}
+
+func newExportCmd(app *CoreApp) *cobra.Command {
+	var req vo.DataExportRequest
+	var format, status, path string
+
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export library data as CSV, NDJSON or iCalendar",
+		Long: `Export raw library data as CSV or NDJSON (one JSON object per line).
+Play sessions can also be exported as an iCalendar (.ics) feed.
+Data is written to stdout unless --path is given; the global --output format
+only applies to the summary printed after writing a file.`,
+	}
+
+	cmd.PersistentFlags().StringVarP(&format, "format", "f", string(enums.ExportCSV), "Output format: csv, ndjson, or ics (sessions only)")
+	cmd.PersistentFlags().StringVar(&req.StartDate, "from", "", "Only include rows on or after this date (YYYY-MM-DD)")
+	cmd.PersistentFlags().StringVar(&req.EndDate, "to", "", "Only include rows on or before this date (YYYY-MM-DD)")
+	cmd.PersistentFlags().StringVarP(&req.Category, "category", "c", "", "Only include games in this category (ID or name)")
+	cmd.PersistentFlags().StringVarP(&status, "status", "s", "", "Only include games with this status (not_started, want_to_play, playing, completed, on_hold)")
+	cmd.PersistentFlags().StringVarP(&path, "path", "p", "", "Write to this file path instead of stdout")
+	_ = cmd.MarkPersistentFlagFilename("path")
+
+	entities := []struct {
+		entity enums.ExportEntity
+		short  string
+		date   string
+	}{
+		{enums.ExportGames, "Export games", "date added"},
+		{enums.ExportSessions, "Export play sessions", "session start time"},
+		{enums.ExportTags, "Export game tags", "date added"},
+		{enums.ExportReviews, "Export personal reviews", "last update"},
+		{enums.ExportProgress, "Export play progress notes", "last update"},
+	}
+	for _, item := range entities {
+		entity := item.entity
+		cmd.AddCommand(&cobra.Command{
+			Use:   string(entity),
+			Short: item.short,
+			Long:  fmt.Sprintf("%s. The --from/--to range filters by %s.", item.short, item.date),
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				if app.DataExportService == nil {
+					return unavailableError("data export is unavailable")
+				}
+				req.Entity = entity
+				req.Format = enums.ExportFormat(format)
+				req.Status = enums.GameStatus(status)
+
+				if path == "" {
+					_, err := app.DataExportService.WriteExport(cmd.OutOrStdout(), req)
+					return err
+				}
+				rows, err := app.DataExportService.WriteExportFile(req, path)
+				if err != nil {
+					return err
+				}
+				result := ExportResult{Entity: entity, Format: req.Format, Rows: rows, Path: path}
+				return writeResult(cmd, result, func(w io.Writer) {
+					fmt.Fprintf(w, "✓ Exported %d %s to %s\n", rows, entity, path)
+				})
+			},
+		})
+	}
+	return cmd
+}
@@ -0,0 +1,276 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"lunabox/internal/common/enums"
+	"lunabox/internal/common/vo"
+	"lunabox/internal/models"
+
+	"github.com/google/uuid"
+	"github.com/spf13/cobra"
+)
+
+// DeletedResult 删除类命令的结构化结果
+type DeletedResult struct {
+	ID      string `json:"id"`
+	Name    string `json:"name,omitempty"`
+	Deleted bool   `json:"deleted"`
+}
+
+// gameFlags add / update 共用的游戏字段 flag，只有显式指定的字段会被写入
+type gameFlags struct {
+	name        string
+	path        string
+	savePath    string
+	processName string
+	company     string
+	summary     string
+	aliases     []string
+	status      string
+	useLE       bool
+	useMagpie   bool
+}
+
+func (f *gameFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.path, "path", "", "Launch executable path")
+	cmd.Flags().StringVar(&f.savePath, "save-path", "", "Save file or directory path")
+	_ = cmd.MarkFlagFilename("path")
+	_ = cmd.MarkFlagFilename("save-path")
+	cmd.Flags().StringVar(&f.processName, "process", "", "Process name used for play time tracking")
+	cmd.Flags().StringVar(&f.company, "company", "", "Developer / brand")
+	cmd.Flags().StringVar(&f.summary, "summary", "", "Summary")
+	cmd.Flags().StringArrayVar(&f.aliases, "alias", nil, "Alias (repeatable; replaces existing aliases)")
+	cmd.Flags().StringVarP(&f.status, "status", "s", "", "Status: not_started, want_to_play, playing, completed, on_hold")
+	cmd.Flags().BoolVar(&f.useLE, "le", false, "Launch with Locale Emulator by default")
+	cmd.Flags().BoolVar(&f.useMagpie, "magpie", false, "Launch with Magpie by default")
+}
+
+// apply 将显式指定的 flag 写入 game，返回是否有字段被修改
+func (f *gameFlags) apply(cmd *cobra.Command, game *models.Game) (bool, error) {
+	flags := cmd.Flags()
+	changed := false
+	setString := func(name string, target *string, value string) {
+		if flags.Changed(name) {
+			*target = strings.TrimSpace(value)
+			changed = true
+		}
+	}
+	setString("name", &game.Name, f.name)
+	setString("path", &game.Path, f.path)
+	setString("save-path", &game.SavePath, f.savePath)
+	setString("process", &game.ProcessName, f.processName)
+	setString("company", &game.Company, f.company)
+	setString("summary", &game.Summary, f.summary)
+	if flags.Changed("alias") {
+		game.Aliases = f.aliases
+		changed = true
+	}
+	if flags.Changed("status") {
+		status, err := parseGameStatus(f.status)
+		if err != nil {
+			return false, err
+		}
+		game.Status = status
+		changed = true
+	}
+	if flags.Changed("le") {
+		game.UseLocaleEmulator = f.useLE
+		changed = true
+	}
+	if flags.Changed("magpie") {
+		game.UseMagpie = f.useMagpie
+		changed = true
+	}
+	if flags.Changed("name") && game.Name == "" {
+		return false, usageError("game name cannot be empty")
+	}
+	return changed, nil
+}
+
+func parseGameStatus(value string) (enums.GameStatus, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	names := make([]string, 0, len(enums.AllGameStatuses))
+	for _, item := range enums.AllGameStatuses {
+		if string(item.Value) == value {
+			return item.Value, nil
+		}
+		names = append(names, string(item.Value))
+	}
+	return "", usageError("unsupported status: %s (use %s)", value, strings.Join(names, ", "))
+}
+
+func newGameCmd(app *CoreApp) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:         "game",
+		Short:       "Add, edit and delete games in your library",
+		Annotations: standaloneAnnotations,
+	}
+	cmd.AddCommand(newGameAddCmd(app))
+	cmd.AddCommand(newGameUpdateCmd(app))
+	cmd.AddCommand(newGameStatusCmd(app))
+	cmd.AddCommand(newGameDeleteCmd(app))
+	return cmd
+}
+
+func newGameAddCmd(app *CoreApp) *cobra.Command {
+	var flags gameFlags
+	var source, sourceID string
+
+	cmd := &cobra.Command{
+		Use:   "add [name]",
+		Short: "Add a game, optionally filling metadata from an online source",
+		Long: `Add a game to the library.
+With --source and --source-id the metadata (name, cover, summary, tags) is fetched
+from that source first; a positional name then overrides the fetched one.`,
+		Example: `  lunacli game add "Tsuki ni Yorisou Otome no Sahou" --path "D:\Games\Tsukiotsu\game.exe"
+  lunacli game add --source bangumi --source-id 12345 --status playing`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			meta := vo.GameMetadataFromWebVO{Source: enums.Local}
+			if source != "" || sourceID != "" {
+				if source == "" || sourceID == "" {
+					return usageError("--source and --source-id must be used together")
+				}
+				fetched, err := app.GameService.FetchMetadataFromWeb(vo.MetadataRequest{
+					Source: enums.SourceType(strings.ToLower(strings.TrimSpace(source))),
+					ID:     strings.TrimSpace(sourceID),
+				})
+				if err != nil {
+					return fmt.Errorf("failed to fetch metadata: %w", err)
+				}
+				meta = fetched
+			}
+
+			if len(args) == 1 {
+				if err := cmd.Flags().Set("name", args[0]); err != nil {
+					return err
+				}
+			}
+			if _, err := flags.apply(cmd, &meta.Game); err != nil {
+				return err
+			}
+			if strings.TrimSpace(meta.Game.Name) == "" {
+				return usageError("please specify a game name or --source/--source-id")
+			}
+
+			meta.Game.ID = uuid.New().String()
+			if err := app.GameService.AddGameFromWebMetadata(meta); err != nil {
+				return err
+			}
+			game, err := app.GameService.GetGameByID(meta.Game.ID)
+			if err != nil {
+				return err
+			}
+			return writeResult(cmd, game, func(w io.Writer) {
+				fmt.Fprintln(w, "✓ Game added!")
+				fmt.Fprintf(w, "Name: %s\n", game.Name)
+				fmt.Fprintf(w, "ID: %s\n", game.ID)
+			})
+		},
+	}
+
+	flags.register(cmd)
+	// name 由位置参数提供，这里只作为 apply 的修改标记
+	cmd.Flags().StringVar(&flags.name, "name", "", "Game name (same as the positional argument)")
+	cmd.Flags().StringVar(&source, "source", "", "Metadata source, e.g. bangumi, vndb, steam, ymgal")
+	cmd.Flags().StringVar(&sourceID, "source-id", "", "Game ID on the metadata source")
+	return cmd
+}
+
+func newGameUpdateCmd(app *CoreApp) *cobra.Command {
+	var flags gameFlags
+
+	cmd := &cobra.Command{
+		Use:   "update <game>",
+		Short: "Edit game fields; only the flags you pass are changed",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			gameID, _, err := resolveGame(cmd, app, args[0])
+			if err != nil {
+				return err
+			}
+			game, err := app.GameService.GetGameByID(gameID)
+			if err != nil {
+				return err
+			}
+
+			changed, err := flags.apply(cmd, &game)
+			if err != nil {
+				return err
+			}
+			if !changed {
+				return usageError("nothing to update; pass at least one field flag (see --help)")
+			}
+			if err := app.GameService.UpdateGame(game); err != nil {
+				return err
+			}
+			return writeResult(cmd, game, func(w io.Writer) {
+				fmt.Fprintf(w, "✓ Game updated: %s\n", game.Name)
+			})
+		},
+	}
+
+	flags.register(cmd)
+	cmd.Flags().StringVar(&flags.name, "name", "", "Game name")
+	return cmd
+}
+
+func newGameStatusCmd(app *CoreApp) *cobra.Command {
+	return &cobra.Command{
+		Use:   "status <game> <status>",
+		Short: "Change the play status of a game",
+		Long:  "Change the play status of a game. Status: not_started, want_to_play, playing, completed, on_hold.",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			status, err := parseGameStatus(args[1])
+			if err != nil {
+				return err
+			}
+			gameID, gameName, err := resolveGame(cmd, app, args[0])
+			if err != nil {
+				return err
+			}
+			if err := app.GameService.BatchUpdateStatus([]string{gameID}, string(status)); err != nil {
+				return err
+			}
+			game, err := app.GameService.GetGameByID(gameID)
+			if err != nil {
+				return err
+			}
+			return writeResult(cmd, game, func(w io.Writer) {
+				fmt.Fprintf(w, "✓ %s → %s\n", gameName, formatGameStatus(string(status)))
+			})
+		},
+	}
+}
+
+func newGameDeleteCmd(app *CoreApp) *cobra.Command {
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:   "delete <game>",
+		Short: "Delete a game together with its play sessions, tags and category links",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			gameID, gameName, err := resolveGame(cmd, app, args[0])
+			if err != nil {
+				return err
+			}
+			if !yes {
+				return usageError("refusing to delete %s without --yes", gameName)
+			}
+			if err := app.GameService.DeleteGame(gameID); err != nil {
+				return err
+			}
+			return writeResult(cmd, DeletedResult{ID: gameID, Name: gameName, Deleted: true}, func(w io.Writer) {
+				fmt.Fprintf(w, "✓ Game deleted: %s\n", gameName)
+			})
+		},
+	}
+
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Confirm the deletion")
+	return cmd
+}
@@ -21,6 +21,11 @@ func RemoteLaunch(req interface{}) error {
 	return ipccore.RemoteLaunch(req)
 }
 
+// ExitCode 返回远程命令错误对应的退出码
+func ExitCode(err error) int {
+	return ipccore.ExitCode(err)
+}
+
 // RemoteRun 在远程 Server 上执行命令
 func RemoteRun(args []string) error {
 	output, err := ipccore.RemoteRun(args)
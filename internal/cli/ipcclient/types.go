@@ -4,3 +4,6 @@ import "lunabox/internal/cli/ipccore"
 
 type CommandRequest = ipccore.CommandRequest
 type CommandResponse = ipccore.CommandResponse
+
+// ExitCodeUnavailable GUI 未运行等无法执行命令时的退出码
+const ExitCodeUnavailable = ipccore.ExitCodeUnavailable
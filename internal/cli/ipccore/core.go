@@ -2,85 +2,77 @@ package ipccore
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
-	"os"
-	"path/filepath"
 	"time"
 )
 
 const (
-	ServerAddr  = "127.0.0.1"
-	Port        = 56789
 	PingTimeout = 500 * time.Millisecond
+
+	// unixBaseURL Unix 套接字与命名管道不使用 host，仅用于拼出合法的请求 URL
+	unixBaseURL = "http://lunabox"
 )
 
 type CommandRequest struct {
 	Args []string `json:"args"`
 }
 
+// CommandResponse /run 响应。Output 为按 --output 渲染好的文本；
+// 命令产生结构化结果时 Data 同时携带其 JSON，调用方无需再解析 Output。
 type CommandResponse struct {
-	Output string `json:"output"`
-	Error  string `json:"error,omitempty"`
-}
-
-type endpointInfo struct {
-	Port int `json:"port"`
-}
-
-func serverURLForPort(port int) string {
-	return fmt.Sprintf("http://%s:%d", ServerAddr, port)
+	Output    string          `json:"output"`
+	Format    string          `json:"format,omitempty"`
+	Data      json.RawMessage `json:"data,omitempty"`
+	Error     string          `json:"error,omitempty"`
+	ErrorKind ErrorKind       `json:"error_kind,omitempty"`
+	ExitCode  int             `json:"exit_code,omitempty"`
 }
 
-func endpointFilePath() string {
-	return filepath.Join(os.TempDir(), "lunabox_ipc_endpoint.json")
+// serverConn 已通过 ping 验证的 GUI 连接
+type serverConn struct {
+	endpoint Endpoint
+	baseURL  string
+	client   *http.Client
 }
 
-func readSavedPort() (int, bool) {
-	content, err := os.ReadFile(endpointFilePath())
-	if err != nil {
-		return 0, false
+func newServerConn(endpoint Endpoint) *serverConn {
+	baseURL := unixBaseURL
+	if endpoint.Network == NetworkTCP {
+		baseURL = "http://" + endpoint.Address
 	}
-
-	var info endpointInfo
-	if err := json.Unmarshal(content, &info); err != nil {
-		return 0, false
+	transport := &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return DialEndpoint(ctx, endpoint)
+		},
 	}
-
-	if info.Port <= 0 {
-		return 0, false
+	return &serverConn{
+		endpoint: endpoint,
+		baseURL:  baseURL,
+		client:   &http.Client{Transport: transport},
 	}
-
-	return info.Port, true
 }
 
-func candidateServerURLs() []string {
-	urls := make([]string, 0, 2)
-	seen := make(map[string]struct{})
-
-	add := func(u string) {
-		if u == "" {
-			return
-		}
-		if _, ok := seen[u]; ok {
-			return
-		}
-		seen[u] = struct{}{}
-		urls = append(urls, u)
+func (c *serverConn) do(ctx context.Context, method string, path string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
 	}
-
-	if savedPort, ok := readSavedPort(); ok {
-		add(serverURLForPort(savedPort))
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
 	}
-	add(serverURLForPort(Port))
-
-	return urls
+	SetAuthorization(req, c.endpoint.Token)
+	return c.client.Do(req)
 }
 
-func pingServer(url string) bool {
-	client := http.Client{Timeout: PingTimeout}
-	resp, err := client.Get(url + "/ping")
+func (c *serverConn) ping() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), PingTimeout)
+	defer cancel()
+
+	resp, err := c.do(ctx, http.MethodGet, "/ping", nil)
 	if err != nil {
 		return false
 	}
@@ -89,28 +81,37 @@ func pingServer(url string) bool {
 	return resp.StatusCode == http.StatusOK
 }
 
-func findRunningServerURL() (string, bool) {
-	for _, url := range candidateServerURLs() {
-		if pingServer(url) {
-			return url, true
-		}
+func (c *serverConn) post(path string, req interface{}) (*http.Response, error) {
+	jsonBody, _ := json.Marshal(req)
+	return c.do(context.Background(), http.MethodPost, path, jsonBody)
+}
+
+// findRunningServer 通过 endpoint 文件定位 GUI。文件中同时保存了会话令牌，
+// 因此只有能读取该文件的当前用户才能连上。
+func findRunningServer() (*serverConn, bool) {
+	endpoint, ok := ReadEndpoint()
+	if !ok {
+		return nil, false
+	}
+	conn := newServerConn(endpoint)
+	if !conn.ping() {
+		return nil, false
 	}
-	return "", false
+	return conn, true
 }
 
 func IsServerRunning() bool {
-	_, ok := findRunningServerURL()
+	_, ok := findRunningServer()
 	return ok
 }
 
 func RemoteInstall(req interface{}) error {
-	serverURL, ok := findRunningServerURL()
+	conn, ok := findRunningServer()
 	if !ok {
 		return fmt.Errorf("failed to connect to LunaBox: IPC server not running")
 	}
 
-	jsonBody, _ := json.Marshal(req)
-	resp, err := http.Post(serverURL+"/install", "application/json", bytes.NewReader(jsonBody))
+	resp, err := conn.post("/install", req)
 	if err != nil {
 		return fmt.Errorf("failed to connect to LunaBox: %w", err)
 	}
@@ -120,17 +121,24 @@ func RemoteInstall(req interface{}) error {
 		return fmt.Errorf("LunaBox returned error status: %d", resp.StatusCode)
 	}
 
+	var installResp InstallResponse
+	if err := json.NewDecoder(resp.Body).Decode(&installResp); err != nil {
+		return fmt.Errorf("failed to decode install response: %w", err)
+	}
+	if installResp.Error != "" {
+		return fmt.Errorf("%s", installResp.Error)
+	}
+
 	return nil
 }
 
 func RemoteLaunch(req interface{}) error {
-	serverURL, ok := findRunningServerURL()
+	conn, ok := findRunningServer()
 	if !ok {
 		return fmt.Errorf("failed to connect to LunaBox: IPC server not running")
 	}
 
-	jsonBody, _ := json.Marshal(req)
-	resp, err := http.Post(serverURL+"/launch", "application/json", bytes.NewReader(jsonBody))
+	resp, err := conn.post("/launch", req)
 	if err != nil {
 		return fmt.Errorf("failed to connect to LunaBox: %w", err)
 	}
@@ -151,35 +159,49 @@ func RemoteLaunch(req interface{}) error {
 	return nil
 }
 
+// RemoteRun 在 GUI 进程中执行命令，返回渲染后的输出。失败时返回 *RemoteError。
 func RemoteRun(args []string) (string, error) {
-	serverURL, ok := findRunningServerURL()
-	if !ok {
-		return "", fmt.Errorf("failed to connect to server: IPC server not running")
+	resp, err := RemoteRunCommand(args)
+	if err != nil {
+		return "", err
 	}
+	if resp.Error != "" {
+		kind := resp.ErrorKind
+		if kind == "" {
+			kind = ErrorKindGeneral
+		}
+		return resp.Output, &RemoteError{Kind: kind, Message: resp.Error}
+	}
+	return resp.Output, nil
+}
 
-	reqBody := CommandRequest{Args: args}
-	jsonBody, _ := json.Marshal(reqBody)
+// RemoteRunCommand 在 GUI 进程中执行命令并返回完整响应（含结构化结果）
+func RemoteRunCommand(args []string) (CommandResponse, error) {
+	conn, ok := findRunningServer()
+	if !ok {
+		return CommandResponse{}, &RemoteError{Kind: ErrorKindUnavailable, Message: "failed to connect to server: IPC server not running"}
+	}
 
-	resp, err := http.Post(serverURL+"/run", "application/json", bytes.NewReader(jsonBody))
+	resp, err := conn.post("/run", CommandRequest{Args: args})
 	if err != nil {
-		return "", fmt.Errorf("failed to connect to server: %w", err)
+		return CommandResponse{}, &RemoteError{Kind: ErrorKindUnavailable, Message: fmt.Sprintf("failed to connect to server: %v", err)}
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("server returned error status: %d", resp.StatusCode)
+		return CommandResponse{}, fmt.Errorf("server returned error status: %d", resp.StatusCode)
 	}
 
 	var cmdResp CommandResponse
 	if err := json.NewDecoder(resp.Body).Decode(&cmdResp); err != nil {
-		return "", fmt.Errorf("failed to decode response: %w", err)
-	}
-
-	if cmdResp.Error != "" {
-		return cmdResp.Output, fmt.Errorf("%s", cmdResp.Error)
+		return CommandResponse{}, fmt.Errorf("failed to decode response: %w", err)
 	}
+	return cmdResp, nil
+}
 
-	return cmdResp.Output, nil
+type InstallResponse struct {
+	TaskID string `json:"task_id,omitempty"`
+	Error  string `json:"error,omitempty"`
 }
 
 type LaunchResponse struct {
@@ -0,0 +1,84 @@
+package ipccore
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestRemoteRunAuthenticatesOverUnixSocket(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix socket transport is not used on Windows")
+	}
+	t.Setenv("XDG_RUNTIME_DIR", t.TempDir())
+
+	dir, err := RuntimeDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener, err := net.Listen(NetworkUnix, filepath.Join(dir, "ipc.sock"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := NewToken()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !IsAuthorized(r, token) {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path == "/run" {
+			var req CommandRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			_ = json.NewEncoder(w).Encode(CommandResponse{Output: "ran " + req.Args[0]})
+		}
+	})}
+	go server.Serve(listener)
+	t.Cleanup(func() { _ = server.Close() })
+
+	if IsServerRunning() {
+		t.Fatal("server should not be discoverable without an endpoint file")
+	}
+
+	endpoint := Endpoint{Network: NetworkUnix, Address: listener.Addr().String(), Token: "wrong"}
+	if err := SaveEndpoint(endpoint); err != nil {
+		t.Fatal(err)
+	}
+	if IsServerRunning() {
+		t.Fatal("server should reject a stale token")
+	}
+
+	endpoint.Token = token
+	if err := SaveEndpoint(endpoint); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(filepath.Join(dir, endpointFileName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Fatalf("expected endpoint file mode 0600, got %v", perm)
+	}
+	if info, err := os.Stat(dir); err != nil {
+		t.Fatal(err)
+	} else if perm := info.Mode().Perm(); perm != 0o700 {
+		t.Fatalf("expected runtime dir mode 0700, got %v", perm)
+	}
+
+	output, err := RemoteRun([]string{"list"})
+	if err != nil || output != "ran list" {
+		t.Fatalf("unexpected remote run result: %q err=%v", output, err)
+	}
+
+	ClearEndpoint()
+	if IsServerRunning() {
+		t.Fatal("server should not be discoverable after the endpoint is cleared")
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !windows
+
+package ipccore
+
+import (
+	"context"
+	"fmt"
+	"net"
+)
+
+func dialPipe(context.Context, string) (net.Conn, error) {
+	return nil, fmt.Errorf("named pipes are only supported on Windows")
+}
@@ -0,0 +1,168 @@
+package ipccore
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// NetworkUnix Unix 域套接字（Linux / macOS）
+	NetworkUnix = "unix"
+	// NetworkPipe Windows 命名管道
+	NetworkPipe = "pipe"
+	// NetworkTCP 本地 TCP 回退，仅在无法创建前两者时使用
+	NetworkTCP = "tcp"
+
+	endpointFileName = "ipc_endpoint.json"
+	tokenBytes       = 32
+)
+
+// Endpoint 描述运行中 GUI 的 IPC 地址与本次会话的访问令牌，保存在仅当前用户可读的目录中
+type Endpoint struct {
+	Network string `json:"network"`
+	Address string `json:"address"`
+	Token   string `json:"token"`
+}
+
+// RuntimeDir 返回存放 endpoint 文件与 Unix 套接字的目录，并确保其权限为 0700。
+// 优先使用 XDG_RUNTIME_DIR，其次为用户缓存目录，二者都是按用户隔离的。
+func RuntimeDir() (string, error) {
+	var dir string
+	if runtimeDir := strings.TrimSpace(os.Getenv("XDG_RUNTIME_DIR")); runtimeDir != "" {
+		dir = filepath.Join(runtimeDir, "lunabox")
+	} else {
+		cacheDir, err := os.UserCacheDir()
+		if err != nil {
+			return "", fmt.Errorf("resolve user cache dir: %w", err)
+		}
+		dir = filepath.Join(cacheDir, "LunaBox", "ipc")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("create ipc runtime dir: %w", err)
+	}
+	// MkdirAll 不会修改已存在目录的权限
+	if err := os.Chmod(dir, 0o700); err != nil {
+		return "", fmt.Errorf("restrict ipc runtime dir: %w", err)
+	}
+	return dir, nil
+}
+
+func endpointFilePath() (string, error) {
+	dir, err := RuntimeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, endpointFileName), nil
+}
+
+// NewToken 生成随机的会话令牌
+func NewToken() (string, error) {
+	buf := make([]byte, tokenBytes)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("generate ipc token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// ReadEndpoint 读取运行中 GUI 发布的 endpoint
+func ReadEndpoint() (Endpoint, bool) {
+	path, err := endpointFilePath()
+	if err != nil {
+		return Endpoint{}, false
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Endpoint{}, false
+	}
+
+	var endpoint Endpoint
+	if err := json.Unmarshal(content, &endpoint); err != nil {
+		return Endpoint{}, false
+	}
+	if endpoint.Network == "" || endpoint.Address == "" || endpoint.Token == "" {
+		return Endpoint{}, false
+	}
+	return endpoint, true
+}
+
+// SaveEndpoint 以 0600 权限写入 endpoint 文件。先写临时文件再重命名，避免客户端读到半截内容。
+func SaveEndpoint(endpoint Endpoint) error {
+	path, err := endpointFilePath()
+	if err != nil {
+		return err
+	}
+	content, err := json.Marshal(endpoint)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), endpointFileName+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("create endpoint file: %w", err)
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	if err := tmp.Chmod(0o600); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("restrict endpoint file: %w", err)
+	}
+	if _, err := tmp.Write(content); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("write endpoint file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write endpoint file: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("publish endpoint file: %w", err)
+	}
+	return nil
+}
+
+// ClearEndpoint 删除 endpoint 文件
+func ClearEndpoint() {
+	if path, err := endpointFilePath(); err == nil {
+		_ = os.Remove(path)
+	}
+}
+
+// DialEndpoint 按 endpoint 的传输方式建立连接
+func DialEndpoint(ctx context.Context, endpoint Endpoint) (net.Conn, error) {
+	switch endpoint.Network {
+	case NetworkUnix, NetworkTCP:
+		var dialer net.Dialer
+		return dialer.DialContext(ctx, endpoint.Network, endpoint.Address)
+	case NetworkPipe:
+		return dialPipe(ctx, endpoint.Address)
+	default:
+		return nil, fmt.Errorf("unsupported ipc network: %s", endpoint.Network)
+	}
+}
+
+// SetAuthorization 为请求附加会话令牌
+func SetAuthorization(req *http.Request, token string) {
+	req.Header.Set("Authorization", "Bearer "+token)
+}
+
+// IsAuthorized 校验请求携带的会话令牌。
+// 浏览器发起的简单跨域请求无法附带 Authorization 头，因此网页无法冒充 CLI。
+func IsAuthorized(r *http.Request, token string) bool {
+	if token == "" {
+		return false
+	}
+	provided, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(provided), []byte(token)) == 1
+}
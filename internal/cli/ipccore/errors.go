@@ -0,0 +1,62 @@
+package ipccore
+
+import "errors"
+
+// ErrorKind 命令失败的类型，决定 lunacli 的退出码
+type ErrorKind string
+
+const (
+	ErrorKindGeneral     ErrorKind = "error"
+	ErrorKindUsage       ErrorKind = "usage"
+	ErrorKindNotFound    ErrorKind = "not_found"
+	ErrorKindAmbiguous   ErrorKind = "ambiguous"
+	ErrorKindUnavailable ErrorKind = "unavailable"
+)
+
+// lunacli 的退出码，脚本可据此区分失败原因
+const (
+	ExitCodeOK          = 0
+	ExitCodeError       = 1
+	ExitCodeUsage       = 2
+	ExitCodeNotFound    = 3
+	ExitCodeAmbiguous   = 4
+	ExitCodeUnavailable = 5
+)
+
+// ExitCode 返回错误类型对应的退出码
+func (k ErrorKind) ExitCode() int {
+	switch k {
+	case ErrorKindUsage:
+		return ExitCodeUsage
+	case ErrorKindNotFound:
+		return ExitCodeNotFound
+	case ErrorKindAmbiguous:
+		return ExitCodeAmbiguous
+	case ErrorKindUnavailable:
+		return ExitCodeUnavailable
+	default:
+		return ExitCodeError
+	}
+}
+
+// RemoteError GUI 进程返回的命令错误，保留错误类型以便客户端设置退出码
+type RemoteError struct {
+	Kind    ErrorKind
+	Message string
+}
+
+func (e *RemoteError) Error() string {
+	return e.Message
+}
+
+// ExitCode 返回错误对应的退出码，nil 为 0，未分类的错误为 1
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitCodeOK
+	}
+	var remoteErr *RemoteError
+	if errors.As(err, &remoteErr) {
+		return remoteErr.Kind.ExitCode()
+	}
+	return ExitCodeError
+}
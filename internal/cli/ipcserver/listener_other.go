@@ -0,0 +1,45 @@
+//go:build !windows
+
+package ipcserver
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"lunabox/internal/cli/ipccore"
+)
+
+const socketFileName = "ipc.sock"
+
+// listenLocal 在仅当前用户可访问的运行时目录中创建 Unix 域套接字
+func listenLocal() (net.Listener, ipccore.Endpoint, error) {
+	dir, err := ipccore.RuntimeDir()
+	if err != nil {
+		return nil, ipccore.Endpoint{}, err
+	}
+	path := filepath.Join(dir, socketFileName)
+
+	if _, err := os.Lstat(path); err == nil {
+		// 仍能连上说明另一个实例正在使用，不能抢占；否则是上次异常退出残留的套接字
+		if conn, dialErr := net.DialTimeout(ipccore.NetworkUnix, path, 200*time.Millisecond); dialErr == nil {
+			_ = conn.Close()
+			return nil, ipccore.Endpoint{}, fmt.Errorf("ipc socket %s is in use by another instance", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, ipccore.Endpoint{}, fmt.Errorf("remove stale ipc socket: %w", err)
+		}
+	}
+
+	listener, err := net.Listen(ipccore.NetworkUnix, path)
+	if err != nil {
+		return nil, ipccore.Endpoint{}, err
+	}
+	if err := os.Chmod(path, 0o600); err != nil {
+		_ = listener.Close()
+		return nil, ipccore.Endpoint{}, fmt.Errorf("restrict ipc socket: %w", err)
+	}
+	return listener, ipccore.Endpoint{Network: ipccore.NetworkUnix, Address: path}, nil
+}
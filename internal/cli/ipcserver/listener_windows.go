@@ -0,0 +1,35 @@
+//go:build windows
+
+package ipcserver
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/Microsoft/go-winio"
+	"golang.org/x/sys/windows"
+
+	"lunabox/internal/cli/ipccore"
+)
+
+// listenLocal 创建仅当前用户（及 SYSTEM）可访问的命名管道。
+// 管道名带随机后缀，防止其他用户抢先创建同名管道。
+func listenLocal() (net.Listener, ipccore.Endpoint, error) {
+	tokenUser, err := windows.GetCurrentProcessToken().GetTokenUser()
+	if err != nil {
+		return nil, ipccore.Endpoint{}, fmt.Errorf("resolve current user: %w", err)
+	}
+	suffix, err := ipccore.NewToken()
+	if err != nil {
+		return nil, ipccore.Endpoint{}, err
+	}
+
+	path := `\\.\pipe\lunabox-ipc-` + suffix[:16]
+	listener, err := winio.ListenPipe(path, &winio.PipeConfig{
+		SecurityDescriptor: fmt.Sprintf("D:P(A;;GA;;;SY)(A;;GA;;;%s)", tokenUser.User.Sid.String()),
+	})
+	if err != nil {
+		return nil, ipccore.Endpoint{}, err
+	}
+	return listener, ipccore.Endpoint{Network: ipccore.NetworkPipe, Address: path}, nil
+}
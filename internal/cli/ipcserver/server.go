@@ -11,10 +11,11 @@ import (
 
 	"lunabox/internal/applog"
 	"lunabox/internal/cli"
+	"lunabox/internal/cli/ipccore"
 	"lunabox/internal/wailsruntime"
 )
 
-// StartServer 启动 IPC 服务器 (在 GUI 进程中运行)
+// StartServer 启动 IPC 服务器 (在 GUI 或 daemon 进程中运行)
 func StartServer(app *cli.CoreApp, runtime wailsruntime.Runtime) *http.Server {
 	mux := http.NewServeMux()
 
@@ -45,13 +46,23 @@ func StartServer(app *cli.CoreApp, runtime wailsruntime.Runtime) *http.Server {
 
 		// 捕获输出
 		var outputBuf bytes.Buffer
-		err := cli.RunCommand(&outputBuf, app, req.Args)
+		result, err := cli.Execute(&outputBuf, app, req.Args)
 
 		resp := CommandResponse{
 			Output: outputBuf.String(),
+			Format: string(result.Format),
+		}
+		if result.Data != nil {
+			if data, marshalErr := json.Marshal(result.Data); marshalErr == nil {
+				resp.Data = data
+			} else {
+				applog.LogWarningf(app.Ctx, "IPC Server failed to encode command result: %v", marshalErr)
+			}
 		}
 		if err != nil {
 			resp.Error = err.Error()
+			resp.ErrorKind = cli.ErrorKindOf(err)
+			resp.ExitCode = cli.ExitCode(err)
 		}
 
 		w.Header().Set("Content-Type", "application/json")
@@ -69,10 +80,15 @@ func StartServer(app *cli.CoreApp, runtime wailsruntime.Runtime) *http.Server {
 			http.Error(w, "Invalid request body", http.StatusBadRequest)
 			return
 		}
+		w.Header().Set("Content-Type", "application/json")
+		// 安装需要用户在前端确认，daemon 模式没有窗口可以弹出
+		if !wailsruntime.IsAvailable(runtime) {
+			json.NewEncoder(w).Encode(InstallResponse{Error: "LunaBox is running in daemon mode; open the GUI to install games"})
+			return
+		}
 		// 不直接开始下载，只推送事件让前端弹出确认窗口
 		// 用户确认后前端调用 DownloadService.StartDownload
 		runtime.Emit("install:pending", req)
-		w.Header().Set("Content-Type", "application/json")
 		json.NewEncoder(w).Encode(InstallResponse{TaskID: ""})
 	})
 
@@ -99,13 +115,23 @@ func StartServer(app *cli.CoreApp, runtime wailsruntime.Runtime) *http.Server {
 		json.NewEncoder(w).Encode(resp)
 	})
 
-	listener, port, err := chooseIPCListener()
+	token, err := ipccore.NewToken()
+	if err != nil {
+		applog.LogErrorf(app.Ctx, "IPC Server failed to create token: %v", err)
+		return nil
+	}
+	listener, endpoint, err := chooseIPCListener(app.Ctx)
 	if err != nil {
-		applog.LogErrorf(app.Ctx, "IPC Server failed to acquire port: %v", err)
+		applog.LogErrorf(app.Ctx, "IPC Server failed to acquire listener: %v", err)
+		return nil
+	}
+	endpoint.Token = token
+	if err := ipccore.SaveEndpoint(endpoint); err != nil {
+		applog.LogErrorf(app.Ctx, "IPC Server failed to publish endpoint: %v", err)
+		_ = listener.Close()
 		return nil
 	}
-	savePort(port)
-	server := &http.Server{Handler: mux}
+	server := &http.Server{Handler: requireToken(token, mux)}
 
 	applog.LogInfof(app.Ctx, "IPC Server starting on %s", listener.Addr().String())
 	go func() {
@@ -117,14 +143,25 @@ func StartServer(app *cli.CoreApp, runtime wailsruntime.Runtime) *http.Server {
 	return server
 }
 
+// requireToken 拒绝未携带本次会话令牌的请求
+func requireToken(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !ipccore.IsAuthorized(r, token) {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 // ShutdownServer 关闭 IPC 服务器并清理 endpoint 文件
 func ShutdownServer(server *http.Server) error {
 	if server == nil {
-		clearSavedPort()
+		ipccore.ClearEndpoint()
 		return nil
 	}
 
-	defer clearSavedPort()
+	defer ipccore.ClearEndpoint()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancel()
@@ -1,99 +1,45 @@
 package ipcserver
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
 	"net"
-	"os"
-	"path/filepath"
 
+	"lunabox/internal/applog"
 	"lunabox/internal/cli/ipccore"
 )
 
+// 仅当无法创建 Unix 套接字 / 命名管道时才回退到本地 TCP 端口
 const (
 	ServerAddr = "127.0.0.1"
 	Port       = 56789
 	PortMax    = 56820
 )
 
-func serverURLForPort(port int) string {
-	return fmt.Sprintf("http://%s:%d", ServerAddr, port)
-}
-
-func endpointFilePath() string {
-	return filepath.Join(os.TempDir(), "lunabox_ipc_endpoint.json")
-}
-
-type endpointInfo struct {
-	Port int `json:"port"`
-}
-
-func readSavedPort() (int, bool) {
-	content, err := os.ReadFile(endpointFilePath())
-	if err != nil {
-		return 0, false
-	}
-	var info endpointInfo
-	if err := json.Unmarshal(content, &info); err != nil {
-		return 0, false
-	}
-	if info.Port <= 0 {
-		return 0, false
-	}
-	return info.Port, true
-}
-
-func savePort(port int) {
-	if port <= 0 {
-		return
+// chooseIPCListener 优先使用按用户隔离的本地传输，失败时回退到 TCP
+func chooseIPCListener(ctx context.Context) (net.Listener, ipccore.Endpoint, error) {
+	listener, endpoint, err := listenLocal()
+	if err == nil {
+		return listener, endpoint, nil
 	}
-	content, err := json.Marshal(endpointInfo{Port: port})
-	if err != nil {
-		return
-	}
-	_ = os.WriteFile(endpointFilePath(), content, 0644)
-}
-
-func clearSavedPort() {
-	_ = os.Remove(endpointFilePath())
-}
+	applog.LogWarningf(ctx, "IPC Server falling back to TCP: %v", err)
 
-func chooseIPCListener() (net.Listener, int, error) {
-	ports := make([]int, 0, (PortMax-Port)+2)
-	if savedPort, ok := readSavedPort(); ok {
-		ports = append(ports, savedPort)
-	}
 	for p := Port; p <= PortMax; p++ {
-		ports = append(ports, p)
-	}
-
-	seen := make(map[int]struct{})
-	for _, p := range ports {
-		if p <= 0 {
-			continue
-		}
-		if _, ok := seen[p]; ok {
-			continue
-		}
-		seen[p] = struct{}{}
-
-		ln, err := net.Listen("tcp", fmt.Sprintf("%s:%d", ServerAddr, p))
+		address := fmt.Sprintf("%s:%d", ServerAddr, p)
+		ln, err := net.Listen(ipccore.NetworkTCP, address)
 		if err == nil {
-			return ln, p, nil
+			return ln, ipccore.Endpoint{Network: ipccore.NetworkTCP, Address: address}, nil
 		}
 	}
 
-	return nil, 0, fmt.Errorf("no available ipc port in range %d-%d", Port, PortMax)
+	return nil, ipccore.Endpoint{}, fmt.Errorf("no available ipc port in range %d-%d", Port, PortMax)
 }
 
 type CommandRequest = ipccore.CommandRequest
 type CommandResponse = ipccore.CommandResponse
 
 // InstallResponse IPC /install 响应
-type InstallResponse struct {
-	TaskID string `json:"task_id,omitempty"`
-	Error  string `json:"error,omitempty"`
-}
+type InstallResponse = ipccore.InstallResponse
 
 // LaunchResponse IPC /launch 响应
 type LaunchResponse = ipccore.LaunchResponse
@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"lunabox/internal/cli/ipccore"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// OutputFormat 命令输出格式，由全局 --output 指定
+type OutputFormat string
+
+const (
+	OutputTable OutputFormat = "table"
+	OutputJSON  OutputFormat = "json"
+	OutputYAML  OutputFormat = "yaml"
+)
+
+func parseOutputFormat(value string) (OutputFormat, error) {
+	switch format := OutputFormat(strings.ToLower(strings.TrimSpace(value))); format {
+	case "", OutputTable:
+		return OutputTable, nil
+	case OutputJSON, OutputYAML:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unsupported output format: %s (use table, json or yaml)", value)
+	}
+}
+
+// CommandResult 一次命令执行的结构化结果
+type CommandResult struct {
+	Format OutputFormat
+	// Data 命令产生的结构化结果；没有结构化结果的命令（如 export 输出到 stdout）为 nil
+	Data any
+}
+
+// commandState 在一次执行中由根命令与子命令共享，通过 cmd.Context() 传递
+type commandState struct {
+	format OutputFormat
+	// started 为 true 表示已通过参数校验进入命令本身，之前的错误都属于用法错误
+	started bool
+	result  CommandResult
+}
+
+type commandStateKey struct{}
+
+func withCommandState(ctx context.Context, state *commandState) context.Context {
+	return context.WithValue(ctx, commandStateKey{}, state)
+}
+
+func stateFromCommand(cmd *cobra.Command) *commandState {
+	if ctx := cmd.Context(); ctx != nil {
+		if state, ok := ctx.Value(commandStateKey{}).(*commandState); ok {
+			return state
+		}
+	}
+	return &commandState{format: OutputTable}
+}
+
+// outputFormat 返回当前命令的输出格式
+func outputFormat(cmd *cobra.Command) OutputFormat {
+	return stateFromCommand(cmd).format
+}
+
+// writeResult 记录命令结果并按输出格式写出；table 格式调用 printTable 输出给人看的文本
+func writeResult(cmd *cobra.Command, data any, printTable func(w io.Writer)) error {
+	state := stateFromCommand(cmd)
+	state.result = CommandResult{Format: state.format, Data: data}
+	if state.format == OutputTable {
+		printTable(cmd.OutOrStdout())
+		return nil
+	}
+	return encodeStructured(cmd.OutOrStdout(), state.format, data)
+}
+
+// commandErrorOutput 结构化输出模式下的错误文档
+type commandErrorOutput struct {
+	Error commandErrorBody `json:"error"`
+}
+
+type commandErrorBody struct {
+	Kind       ipccore.ErrorKind `json:"kind"`
+	Message    string            `json:"message"`
+	Candidates []GameCandidate   `json:"candidates,omitempty"`
+}
+
+func newCommandErrorOutput(err error) commandErrorOutput {
+	body := commandErrorBody{Kind: ErrorKindOf(err), Message: err.Error()}
+	var cmdErr *CommandError
+	if errors.As(err, &cmdErr) {
+		body.Candidates = cmdErr.Candidates
+	}
+	return commandErrorOutput{Error: body}
+}
+
+func encodeStructured(w io.Writer, format OutputFormat, data any) error {
+	content, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode output: %w", err)
+	}
+	if format == OutputJSON {
+		_, err = fmt.Fprintf(w, "%s\n", content)
+		return err
+	}
+
+	// 经由 JSON 转换，使 YAML 的字段名与顺序和 JSON 输出一致
+	var node yaml.Node
+	if err := yaml.Unmarshal(content, &node); err != nil {
+		return fmt.Errorf("failed to encode output: %w", err)
+	}
+	resetYAMLStyle(&node)
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&node); err != nil {
+		return fmt.Errorf("failed to encode output: %w", err)
+	}
+	return encoder.Close()
+}
+
+// resetYAMLStyle 去掉从 JSON 解析得到的流式风格与双引号，输出常规块风格 YAML。
+// 看起来像数字或布尔值的字符串仍会被编码器加上引号。
+func resetYAMLStyle(node *yaml.Node) {
+	node.Style = 0
+	for _, child := range node.Content {
+		resetYAMLStyle(child)
+	}
+}
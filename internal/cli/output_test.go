@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"lunabox/internal/cli/ipccore"
+	"lunabox/internal/service"
+	"lunabox/internal/version"
+)
+
+func TestExecuteRendersVersionInEachOutputFormat(t *testing.T) {
+	app := &CoreApp{VersionService: service.NewVersionService()}
+
+	var table bytes.Buffer
+	result, err := Execute(&table, app, []string{"version"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Format != OutputTable || !strings.HasPrefix(table.String(), "LunaBox v"+version.Version) {
+		t.Fatalf("unexpected table output: %q", table.String())
+	}
+	if info, ok := result.Data.(map[string]string); !ok || info["version"] != version.Version {
+		t.Fatalf("expected structured result alongside table output, got %#v", result.Data)
+	}
+
+	var jsonOut bytes.Buffer
+	if _, err := Execute(&jsonOut, app, []string{"version", "--output", "json"}); err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]string
+	if err := json.Unmarshal(jsonOut.Bytes(), &decoded); err != nil {
+		t.Fatalf("json output is not valid JSON: %v\n%s", err, jsonOut.String())
+	}
+	if decoded["version"] != version.Version || decoded["buildMode"] != version.BuildMode {
+		t.Fatalf("unexpected json output: %#v", decoded)
+	}
+
+	var yamlOut bytes.Buffer
+	if _, err := Execute(&yamlOut, app, []string{"-o", "yaml", "version"}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(yamlOut.String(), "\nversion: ") || strings.Contains(yamlOut.String(), "{") {
+		t.Fatalf("unexpected yaml output: %q", yamlOut.String())
+	}
+}
+
+func TestExecuteReportsErrorKinds(t *testing.T) {
+	app := &CoreApp{}
+
+	cases := []struct {
+		name string
+		args []string
+		kind ipccore.ErrorKind
+		code int
+	}{
+		{"unknown command", []string{"--output", "json", "nope"}, ipccore.ErrorKindUsage, ipccore.ExitCodeUsage},
+		{"bad output format", []string{"version", "--output", "xml"}, ipccore.ErrorKindUsage, ipccore.ExitCodeUsage},
+		{"missing argument", []string{"detail", "--output", "json"}, ipccore.ErrorKindUsage, ipccore.ExitCodeUsage},
+		{"missing flag", []string{"backup", "--output", "json"}, ipccore.ErrorKindUsage, ipccore.ExitCodeUsage},
+		{"exclusive flags", []string{"backup", "-g", "foo", "-d"}, ipccore.ErrorKindUsage, ipccore.ExitCodeUsage},
+		{"upload without database", []string{"backup", "--upload", "-a", "/tmp/full.zip"}, ipccore.ErrorKindUsage, ipccore.ExitCodeUsage},
+		{"service unavailable", []string{"version", "--output", "json"}, ipccore.ErrorKindUnavailable, ipccore.ExitCodeUnavailable},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var out bytes.Buffer
+			_, err := Execute(&out, app, tc.args)
+			if err == nil {
+				t.Fatal("expected an error")
+			}
+			if ErrorKindOf(err) != tc.kind || ExitCode(err) != tc.code {
+				t.Fatalf("expected %s (%d), got %s (%d): %v", tc.kind, tc.code, ErrorKindOf(err), ExitCode(err), err)
+			}
+		})
+	}
+
+	var out bytes.Buffer
+	_, err := Execute(&out, app, []string{"version", "-o", "json"})
+	var doc commandErrorOutput
+	if jsonErr := json.Unmarshal(out.Bytes(), &doc); jsonErr != nil {
+		t.Fatalf("expected a JSON error document, got %q", out.String())
+	}
+	if doc.Error.Kind != ipccore.ErrorKindUnavailable || doc.Error.Message != err.Error() {
+		t.Fatalf("unexpected error document: %#v", doc)
+	}
+}
@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// AbsolutePathArgs 把标记为文件名的 flag（MarkFlagFilename）的值转换为绝对路径。
+// 通过 IPC 转发的命令在 GUI 或 daemon 进程中执行，相对路径必须先按 lunacli 的工作目录解析。
+func AbsolutePathArgs(args []string) ([]string, error) {
+	root := NewRootCmd(&CoreApp{})
+	cmd, _, err := root.Find(args)
+	if err != nil || cmd == root {
+		return args, nil
+	}
+
+	resolved := append([]string(nil), args...)
+	for i := 0; i < len(resolved); i++ {
+		arg := resolved[i]
+		if arg == "--" {
+			break
+		}
+		var name, value string
+		var shorthand, inline bool
+		switch {
+		case strings.HasPrefix(arg, "--"):
+			name, value, inline = strings.Cut(arg[2:], "=")
+		case strings.HasPrefix(arg, "-") && len(arg) > 1:
+			shorthand = true
+			name = arg[1:2]
+			if rest := arg[2:]; rest != "" {
+				value, inline = strings.TrimPrefix(rest, "="), true
+			}
+		default:
+			continue
+		}
+		if !isFilenameFlag(cmd, name, shorthand) {
+			continue
+		}
+		if !inline {
+			if i+1 >= len(resolved) {
+				break
+			}
+			i++
+			value = resolved[i]
+		}
+		if value == "" {
+			continue
+		}
+		absPath, err := filepath.Abs(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve path %s: %w", value, err)
+		}
+		resolved[i] = strings.TrimSuffix(resolved[i], value) + absPath
+	}
+	return resolved, nil
+}
+
+// isFilenameFlag 判断命令（含继承的全局 flag）中的 flag 是否标记为文件名
+func isFilenameFlag(cmd *cobra.Command, name string, shorthand bool) bool {
+	local, inherited := cmd.Flags(), cmd.InheritedFlags()
+	flag := local.Lookup(name)
+	if shorthand {
+		flag = local.ShorthandLookup(name)
+	}
+	if flag == nil {
+		flag = inherited.Lookup(name)
+		if shorthand {
+			flag = inherited.ShorthandLookup(name)
+		}
+	}
+	if flag == nil {
+		return false
+	}
+	_, ok := flag.Annotations[cobra.BashCompFilenameExt]
+	return ok
+}
@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestAbsolutePathArgsResolvesFilenameFlags(t *testing.T) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("get working directory: %v", err)
+	}
+	abs := func(path string) string { return filepath.Join(cwd, path) }
+
+	cases := []struct {
+		args []string
+		want []string
+	}{
+		{[]string{"backup", "--all", "out.zip"}, []string{"backup", "--all", abs("out.zip")}},
+		{[]string{"backup", "-a", "out.zip"}, []string{"backup", "-a", abs("out.zip")}},
+		{[]string{"backup", "restore", "--full=backups/full.zip"}, []string{"backup", "restore", "--full=" + abs("backups/full.zip")}},
+		{[]string{"-o", "json", "export", "games", "-p", "games.csv"}, []string{"-o", "json", "export", "games", "-p", abs("games.csv")}},
+		{[]string{"wrapped", "--year", "2025", "-pwrapped"}, []string{"wrapped", "--year", "2025", "-p" + abs("wrapped")}},
+		{[]string{"game", "add", "Foo", "--path", "foo.exe", "--save-path", ""}, []string{"game", "add", "Foo", "--path", abs("foo.exe"), "--save-path", ""}},
+		{[]string{"game", "update", "Foo", "--name", "out.zip"}, []string{"game", "update", "Foo", "--name", "out.zip"}},
+		{[]string{"list"}, []string{"list"}},
+	}
+	for _, tc := range cases {
+		got, err := AbsolutePathArgs(tc.args)
+		if err != nil {
+			t.Fatalf("AbsolutePathArgs(%q) error = %v", tc.args, err)
+		}
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("AbsolutePathArgs(%q) = %q, want %q", tc.args, got, tc.want)
+		}
+	}
+}
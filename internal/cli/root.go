@@ -5,6 +5,7 @@ import "github.com/spf13/cobra"
 // NewRootCmd creates the root command for the CLI
 func NewRootCmd(app *CoreApp) *cobra.Command {
 	var showVersion bool
+	var output string
 
 	cmd := &cobra.Command{
 		Use:   "lunacli",
@@ -13,10 +14,19 @@ func NewRootCmd(app *CoreApp) *cobra.Command {
 Manage and play your gal games from the command line.`,
 		SilenceErrors: true, // Errors are returned to caller
 		SilenceUsage:  true, // Only show usage on flag errors
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			format, err := parseOutputFormat(output)
+			if err != nil {
+				return usageError("%w", err)
+			}
+			state := stateFromCommand(cmd)
+			state.format = format
+			state.started = true
+			return nil
+		},
 		RunE: func(cmd *cobra.Command, args []string) error {
 			if showVersion {
-				printVersion(cmd.OutOrStdout(), app)
-				return nil
+				return writeVersion(cmd, app)
 			}
 			return cmd.Help()
 		},
@@ -25,12 +35,19 @@ Manage and play your gal games from the command line.`,
 	// Disable mousetrap (prevents exit when GUI app double-clicked)
 	cobra.MousetrapHelpText = ""
 
+	cmd.PersistentFlags().StringVarP(&output, "output", "o", string(OutputTable), "Output format: table, json, or yaml")
 	cmd.Flags().BoolVarP(&showVersion, "version", "v", false, "Print the version number of LunaBox")
 
 	cmd.AddCommand(newStartCmd(app))
 	cmd.AddCommand(newListCmd(app))
 	cmd.AddCommand(newDetailCmd(app))
+	cmd.AddCommand(newGameCmd(app))
+	cmd.AddCommand(newCategoryCmd(app))
+	cmd.AddCommand(newTagCmd(app))
+	cmd.AddCommand(newSessionCmd(app))
 	cmd.AddCommand(newBackupCmd(app))
+	cmd.AddCommand(newWrappedCmd(app))
+	cmd.AddCommand(newExportCmd(app))
 	cmd.AddCommand(newVersionCmd(app))
 	cmd.AddCommand(newLunaCmd(app))
 	cmd.AddCommand(newProtocolCmd(app))
@@ -0,0 +1,145 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"lunabox/internal/models"
+
+	"github.com/spf13/cobra"
+)
+
+// sessionTimeLayout session add --start 接受的时间格式（本地时区）
+const sessionTimeLayout = "2006-01-02 15:04"
+
+// GameSessionsResult 游戏与其游玩记录的结构化结果
+type GameSessionsResult struct {
+	GameID   string               `json:"game_id"`
+	GameName string               `json:"game_name"`
+	Sessions []models.PlaySession `json:"sessions"`
+}
+
+func newSessionCmd(app *CoreApp) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:         "session",
+		Short:       "View, add and delete play sessions",
+		Annotations: standaloneAnnotations,
+	}
+	cmd.AddCommand(newSessionListCmd(app))
+	cmd.AddCommand(newSessionAddCmd(app))
+	cmd.AddCommand(newSessionDeleteCmd(app))
+	return cmd
+}
+
+// requireSessionService 检查游玩记录服务是否可用
+func requireSessionService(app *CoreApp) error {
+	if app.SessionService == nil {
+		return unavailableError("play session management is unavailable")
+	}
+	return nil
+}
+
+func newSessionListCmd(app *CoreApp) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list <game>",
+		Short: "List the play sessions of a game, newest first",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireSessionService(app); err != nil {
+				return err
+			}
+			gameID, gameName, err := resolveGame(cmd, app, args[0])
+			if err != nil {
+				return err
+			}
+			sessions, err := app.SessionService.GetPlaySessions(gameID)
+			if err != nil {
+				return err
+			}
+			if sessions == nil {
+				sessions = []models.PlaySession{}
+			}
+			result := GameSessionsResult{GameID: gameID, GameName: gameName, Sessions: sessions}
+			return writeResult(cmd, result, func(w io.Writer) {
+				if len(sessions) == 0 {
+					fmt.Fprintf(w, "No play sessions for %s.\n", gameName)
+					return
+				}
+				fmt.Fprintf(w, "Play sessions of %s (%d):\n", gameName, len(sessions))
+				for _, s := range sessions {
+					fmt.Fprintf(w, "  %s  %s  %d min\n", s.ID, s.StartTime.Local().Format(sessionTimeLayout), s.Duration/60)
+				}
+			})
+		},
+	}
+}
+
+func newSessionAddCmd(app *CoreApp) *cobra.Command {
+	var start string
+	var minutes int
+
+	cmd := &cobra.Command{
+		Use:     "add <game>",
+		Short:   "Record a play session manually",
+		Example: `  lunacli session add "Tsukiotsu" --start "2026-01-02 20:30" --minutes 90`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireSessionService(app); err != nil {
+				return err
+			}
+			startTime, err := time.ParseInLocation(sessionTimeLayout, start, time.Local)
+			if err != nil {
+				return usageError("invalid --start %q, expected format YYYY-MM-DD HH:MM", start)
+			}
+			if minutes <= 0 {
+				return usageError("--minutes must be greater than 0")
+			}
+			gameID, gameName, err := resolveGame(cmd, app, args[0])
+			if err != nil {
+				return err
+			}
+			session, err := app.SessionService.AddPlaySession(gameID, startTime, minutes)
+			if err != nil {
+				return err
+			}
+			return writeResult(cmd, session, func(w io.Writer) {
+				fmt.Fprintf(w, "✓ Added %d min session to %s\n", minutes, gameName)
+				fmt.Fprintf(w, "ID: %s\n", session.ID)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&start, "start", "", "Start time in local time, format \"YYYY-MM-DD HH:MM\"")
+	cmd.Flags().IntVar(&minutes, "minutes", 0, "Duration in minutes")
+	_ = cmd.MarkFlagRequired("start")
+	_ = cmd.MarkFlagRequired("minutes")
+	return cmd
+}
+
+func newSessionDeleteCmd(app *CoreApp) *cobra.Command {
+	var yes bool
+
+	cmd := &cobra.Command{
+		Use:   "delete <session-id>",
+		Short: "Delete a play session by its full ID (see session list)",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireSessionService(app); err != nil {
+				return err
+			}
+			if !yes {
+				return usageError("refusing to delete play session %s without --yes", args[0])
+			}
+			if err := app.SessionService.DeletePlaySession(args[0]); err != nil {
+				return err
+			}
+			return writeResult(cmd, DeletedResult{ID: args[0], Deleted: true}, func(w io.Writer) {
+				fmt.Fprintf(w, "✓ Play session deleted: %s\n", args[0])
+			})
+		},
+	}
+
+	cmd.Flags().BoolVarP(&yes, "yes", "y", false, "Confirm the deletion")
+	return cmd
+}
@@ -0,0 +1,119 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"path/filepath"
+	"time"
+
+	"lunabox/internal/appconf"
+	"lunabox/internal/appdb"
+	"lunabox/internal/applog"
+	"lunabox/internal/service"
+	"lunabox/internal/utils/apputils"
+	"lunabox/internal/utils/dbutils"
+)
+
+// standaloneAnnotation 标记可在 GUI 未运行时由 lunacli 直接打开数据库执行的顶层命令
+const standaloneAnnotation = "lunabox:standalone"
+
+var standaloneAnnotations = map[string]string{standaloneAnnotation: "true"}
+
+// SupportsStandalone 判断命令是否可以在 GUI 未运行时以单机模式执行
+func SupportsStandalone(args []string) bool {
+	root := NewRootCmd(&CoreApp{})
+	cmd, _, err := root.Find(args)
+	if err != nil || cmd == root {
+		return false
+	}
+	for cmd.Parent() != root {
+		cmd = cmd.Parent()
+	}
+	return cmd.Annotations[standaloneAnnotation] == "true"
+}
+
+// OpenStandaloneApp 在 GUI 未运行时直接打开数据库，只初始化库管理、备份与年度回顾命令需要的服务。
+// 日志写入 logs/lunacli.log，避免污染命令输出。返回的 close 函数会检查点并关闭数据库。
+func OpenStandaloneApp(ctx context.Context) (*CoreApp, func(), error) {
+	logDir, err := apputils.GetSubDir("logs")
+	if err != nil {
+		return nil, nil, unavailableError("failed to prepare log directory: %w", err)
+	}
+	logger := applog.NewFileLogger(filepath.Join(logDir, "lunacli.log"), slog.LevelInfo)
+	applog.SetLogger(logger)
+	applog.SetMode(applog.ModeGUI)
+
+	config, err := appconf.LoadConfig()
+	if err != nil {
+		return nil, nil, unavailableError("failed to load config: %w", err)
+	}
+	// 待恢复的备份会在 GUI 下次启动时覆盖数据库，此时修改的数据会丢失
+	if config.PendingFullRestore != "" || config.PendingDBRestore != "" {
+		return nil, nil, unavailableError("a backup restore is pending; start LunaBox once to finish it before editing the library")
+	}
+
+	db, err := appdb.Open(ctx, config, logger)
+	if err != nil {
+		return nil, nil, unavailableError("%w", err)
+	}
+
+	app := newStandaloneApp(ctx, db, config)
+	closeApp := func() {
+		closeCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = dbutils.SafeCloseDuckDB(closeCtx, db, logger)
+	}
+	return app, closeApp, nil
+}
+
+// newStandaloneApp 构造单机模式的服务。元数据服务用于按来源添加游戏；
+// 远程推送服务只初始化不启动，状态变更写入发件箱，由下次启动的 GUI 或 daemon 投递。
+func newStandaloneApp(ctx context.Context, db *sql.DB, config *appconf.AppConfig) *CoreApp {
+	gameService := service.NewGameService()
+	categoryService := service.NewCategoryService()
+	tagService := service.NewTagService()
+	sessionService := service.NewSessionService()
+	backupService := service.NewBackupService()
+	versionService := service.NewVersionService()
+	bangumiService := service.NewBangumiService()
+	hikarinagiService := service.NewHikarinagiService()
+	vndbService := service.NewVNDBService()
+	remotePushService := service.NewRemotePushService()
+	templateService := service.NewTemplateService()
+	wrappedService := service.NewWrappedService()
+
+	gameService.Init(ctx, db, config)
+	categoryService.Init(ctx, db, config)
+	tagService.Init(ctx, db, config)
+	sessionService.Init(ctx, db, config)
+	backupService.Init(ctx, db, config)
+	versionService.Init(ctx)
+	bangumiService.Init(ctx, db, config)
+	hikarinagiService.Init(ctx, db, config)
+	vndbService.Init(ctx, db, config)
+	remotePushService.Init(ctx, db, config)
+	templateService.Init(ctx, db, config)
+	wrappedService.Init(ctx, db, config)
+
+	gameService.SetTagService(tagService)
+	gameService.SetBangumiService(bangumiService)
+	bangumiService.SetGameService(gameService)
+	gameService.SetHikarinagiService(hikarinagiService)
+	gameService.SetVNDBService(vndbService)
+	vndbService.SetGameService(gameService)
+	remotePushService.SetGameService(gameService)
+	remotePushService.SetBangumiService(bangumiService)
+	remotePushService.SetHikarinagiService(hikarinagiService)
+	remotePushService.SetVNDBService(vndbService)
+	gameService.SetRemotePushService(remotePushService)
+	wrappedService.SetTemplateService(templateService)
+
+	return &CoreApp{
+		Config: config, DB: db, Ctx: ctx,
+		GameService: gameService, SessionService: sessionService,
+		CategoryService: categoryService, TagService: tagService,
+		BackupService: backupService, VersionService: versionService,
+		WrappedService: wrappedService,
+	}
+}
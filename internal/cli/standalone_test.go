@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"lunabox/internal/appconf"
+	"lunabox/internal/cli/ipccore"
+	"lunabox/internal/common/enums"
+	"lunabox/internal/migrations"
+	"lunabox/internal/service/remotestatus"
+
+	_ "github.com/duckdb/duckdb-go/v2"
+)
+
+func TestSupportsStandaloneOnlyForAnnotatedCommands(t *testing.T) {
+	cases := []struct {
+		args []string
+		want bool
+	}{
+		{[]string{"game", "add", "Foo", "--path", "C:\\foo.exe"}, true},
+		{[]string{"-o", "json", "category", "list"}, true},
+		{[]string{"tag", "games", "百合"}, true},
+		{[]string{"session", "add", "Foo", "--start", "2026-01-02 20:30", "--minutes", "30"}, true},
+		{[]string{"game"}, true},
+		{[]string{"list"}, false},
+		{[]string{"start", "Foo"}, false},
+		{[]string{"backup", "--database", "--upload"}, true},
+		{[]string{"backup", "prune", "--keep", "7"}, true},
+		{[]string{"wrapped", "--year", "2025"}, true},
+		{[]string{"nope"}, false},
+		{nil, false},
+	}
+	for _, tc := range cases {
+		if got := SupportsStandalone(tc.args); got != tc.want {
+			t.Errorf("SupportsStandalone(%q) = %v, want %v", tc.args, got, tc.want)
+		}
+	}
+}
+
+func TestParseGameStatus(t *testing.T) {
+	status, err := parseGameStatus(" Playing ")
+	if err != nil || status != enums.StatusPlaying {
+		t.Fatalf("expected playing, got %q (%v)", status, err)
+	}
+	if _, err := parseGameStatus("dropped"); ErrorKindOf(err) != ipccore.ErrorKindUsage {
+		t.Fatalf("expected usage error for unknown status, got %v", err)
+	}
+}
+
+// openStandaloneTestDB 打开内存数据库并建立与正式库相同的结构
+func openStandaloneTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	if err := migrations.InitSchema(db); err != nil {
+		t.Fatalf("init schema: %v", err)
+	}
+	if err := migrations.Run(context.Background(), db); err != nil {
+		t.Fatalf("run migrations: %v", err)
+	}
+	return db
+}
+
+func TestStandaloneGameStatusQueuesRemotePush(t *testing.T) {
+	db := openStandaloneTestDB(t)
+	if _, err := db.Exec(`INSERT INTO games (id, name, status, source_type, source_id, cached_at, created_at, updated_at)
+		VALUES ('game-1', 'Standalone Game', 'not_started', 'bangumi', '42', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`); err != nil {
+		t.Fatalf("insert game: %v", err)
+	}
+
+	ctx := context.Background()
+	app := newStandaloneApp(ctx, db, &appconf.AppConfig{BangumiAccessToken: "access-token"})
+	if _, err := Execute(io.Discard, app, []string{"game", "status", "game-1", "playing"}); err != nil {
+		t.Fatalf("game status: %v", err)
+	}
+
+	jobs, err := remotestatus.DueJobs(ctx, db, time.Now().Add(time.Hour), 10)
+	if err != nil {
+		t.Fatalf("load queued jobs: %v", err)
+	}
+	if len(jobs) != 1 || jobs[0].Provider != enums.Bangumi || jobs[0].Kind != remotestatus.JobKindStatus || jobs[0].SourceID != "42" {
+		t.Fatalf("expected one queued Bangumi status push, got %+v", jobs)
+	}
+}
+
+func TestStandaloneWrappedExportsBundle(t *testing.T) {
+	db := openStandaloneTestDB(t)
+	if _, err := db.Exec(`INSERT INTO games (id, name, status, cached_at, created_at, updated_at)
+		VALUES ('game-1', 'Standalone Game', 'completed', CURRENT_TIMESTAMP, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP)`); err != nil {
+		t.Fatalf("insert game: %v", err)
+	}
+	if _, err := db.Exec(`INSERT INTO play_sessions (id, game_id, start_time, end_time, duration, updated_at)
+		VALUES ('session-1', 'game-1', '2025-03-01 20:00:00', '2025-03-01 22:00:00', 7200, CURRENT_TIMESTAMP)`); err != nil {
+		t.Fatalf("insert session: %v", err)
+	}
+
+	app := newStandaloneApp(context.Background(), db, &appconf.AppConfig{})
+	path := filepath.Join(t.TempDir(), "wrapped.zip")
+	if _, err := Execute(io.Discard, app, []string{"wrapped", "--year", "2025", "--path", path}); err != nil {
+		t.Fatalf("wrapped: %v", err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Size() == 0 {
+		t.Fatalf("expected wrapped archive at %s: %v", path, err)
+	}
+}
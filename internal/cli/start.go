@@ -12,6 +12,13 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// StartResult start 命令的结构化结果
+type StartResult struct {
+	GameID   string `json:"game_id"`
+	GameName string `json:"game_name"`
+	Started  bool   `json:"started"`
+}
+
 func newStartCmd(app *CoreApp) *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "start <game>",
@@ -19,7 +26,6 @@ func newStartCmd(app *CoreApp) *cobra.Command {
 		Args:  cobra.ExactArgs(1), // Expect exactly one argument
 		RunE: func(cmd *cobra.Command, args []string) error {
 			gameQuery := args[0]
-			w := cmd.OutOrStdout()
 
 			// 获取 flags
 			useLE, _ := cmd.Flags().GetBool("le")
@@ -31,7 +37,7 @@ func newStartCmd(app *CoreApp) *cobra.Command {
 
 			// 解析游戏 ID
 			applog.LogInfof(app.Ctx, "Looking for game: %s", gameQuery)
-			gameID, gameName, err := resolveGame(w, app, gameQuery)
+			gameID, gameName, err := resolveGame(cmd, app, gameQuery)
 			if err != nil {
 				applog.LogErrorf(app.Ctx, "Failed to find game: %v", err)
 				return err
@@ -43,13 +49,13 @@ func newStartCmd(app *CoreApp) *cobra.Command {
 			launchOptions := launcher.LaunchOptions{}
 			if cmd.Flags().Changed("le") {
 				if useLE && app.Config.LocaleEmulatorPath == "" {
-					return fmt.Errorf("Locale Emulator path is not configured")
+					return unavailableError("Locale Emulator path is not configured")
 				}
 				launchOptions.UseLocaleEmulator = &useLE
 			}
 			if cmd.Flags().Changed("magpie") {
 				if useMagpie && app.Config.MagpiePath == "" {
-					return fmt.Errorf("Magpie path is not configured")
+					return unavailableError("Magpie path is not configured")
 				}
 				launchOptions.UseMagpie = &useMagpie
 			}
@@ -103,10 +109,10 @@ func newStartCmd(app *CoreApp) *cobra.Command {
 				return fmt.Errorf("game failed to start")
 			}
 
-			fmt.Fprintln(w, "Game started successfully!")
-			fmt.Fprintln(w, "Recording play duration...")
-
-			return nil
+			return writeResult(cmd, StartResult{GameID: gameID, GameName: gameName, Started: true}, func(w io.Writer) {
+				fmt.Fprintln(w, "Game started successfully!")
+				fmt.Fprintln(w, "Recording play duration...")
+			})
 		},
 	}
 
@@ -128,7 +134,7 @@ func boolPtrToString(b *bool) string {
 }
 
 // resolveGame 解析游戏查询（ID / ID前缀 / 别名 / 名称模糊匹配）
-func resolveGame(w io.Writer, app *CoreApp, query string) (gameID string, gameName string, err error) {
+func resolveGame(cmd *cobra.Command, app *CoreApp, query string) (gameID string, gameName string, err error) {
 	// 1. 先尝试作为 ID 精确查找
 	game, err := app.GameService.GetGameByID(query)
 	if err == nil {
@@ -144,16 +150,10 @@ func resolveGame(w io.Writer, app *CoreApp, query string) (gameID string, gameNa
 	queryLower := strings.ToLower(query)
 
 	// 3. 尝试作为 ID 前缀匹配（支持短ID）
-	var idPrefixMatches []struct {
-		ID   string
-		Name string
-	}
+	var idPrefixMatches []GameCandidate
 	for _, g := range games {
 		if strings.HasPrefix(strings.ToLower(g.ID), queryLower) {
-			idPrefixMatches = append(idPrefixMatches, struct {
-				ID   string
-				Name string
-			}{g.ID, g.Name})
+			idPrefixMatches = append(idPrefixMatches, GameCandidate{ID: g.ID, Name: g.Name})
 		}
 	}
 
@@ -164,16 +164,8 @@ func resolveGame(w io.Writer, app *CoreApp, query string) (gameID string, gameNa
 
 	// 如果ID前缀有多个匹配，提示用户
 	if len(idPrefixMatches) > 1 {
-		fmt.Fprintf(w, "\nMultiple games found with ID prefix '%s':\n\n", query)
-		for i, match := range idPrefixMatches {
-			shortID := match.ID
-			if len(shortID) > 8 {
-				shortID = shortID[:8]
-			}
-			fmt.Fprintf(w, "  %d. %s (ID: %s)\n", i+1, match.Name, shortID)
-		}
-		fmt.Fprintln(w)
-		return "", "", fmt.Errorf("please use a longer ID prefix to match exactly one game")
+		printGameCandidates(cmd, fmt.Sprintf("Multiple games found with ID prefix '%s':", query), idPrefixMatches)
+		return "", "", ambiguousError(idPrefixMatches, "please use a longer ID prefix to match exactly one game")
 	}
 
 	// 4. 作为名称精确匹配（不区分大小写）
@@ -184,10 +176,7 @@ func resolveGame(w io.Writer, app *CoreApp, query string) (gameID string, gameNa
 	}
 
 	// 5. 名称或别名模糊匹配（包含查询字符串）
-	var matches []struct {
-		ID   string
-		Name string
-	}
+	var matches []GameCandidate
 
 	for _, g := range games {
 		matched := strings.Contains(strings.ToLower(g.Name), queryLower)
@@ -200,15 +189,12 @@ func resolveGame(w io.Writer, app *CoreApp, query string) (gameID string, gameNa
 			}
 		}
 		if matched {
-			matches = append(matches, struct {
-				ID   string
-				Name string
-			}{g.ID, g.Name})
+			matches = append(matches, GameCandidate{ID: g.ID, Name: g.Name})
 		}
 	}
 
 	if len(matches) == 0 {
-		return "", "", fmt.Errorf("no game found matching: %s", query)
+		return "", "", notFoundError("no game found matching: %s", query)
 	}
 
 	if len(matches) == 1 {
@@ -216,14 +202,27 @@ func resolveGame(w io.Writer, app *CoreApp, query string) (gameID string, gameNa
 	}
 
 	// 多个匹配结果，提示用户
-	fmt.Fprintf(w, "\nMultiple games found matching '%s':\n\n", query)
-	for i, match := range matches {
-		shortID := match.ID
-		if len(shortID) > 8 {
-			shortID = shortID[:8]
-		}
-		fmt.Fprintf(w, "  %d. %s (ID: %s)\n", i+1, match.Name, shortID)
+	printGameCandidates(cmd, fmt.Sprintf("Multiple games found matching '%s':", query), matches)
+	return "", "", ambiguousError(matches, "please use the exact game ID or refine your search")
+}
+
+// printGameCandidates 在 table 模式下列出候选游戏；结构化输出模式下候选项随错误文档输出
+func printGameCandidates(cmd *cobra.Command, title string, candidates []GameCandidate) {
+	if outputFormat(cmd) != OutputTable {
+		return
+	}
+	w := cmd.OutOrStdout()
+	fmt.Fprintf(w, "\n%s\n\n", title)
+	for i, match := range candidates {
+		fmt.Fprintf(w, "  %d. %s (ID: %s)\n", i+1, match.Name, shortGameID(match.ID))
 	}
 	fmt.Fprintln(w)
-	return "", "", fmt.Errorf("please use the exact game ID or refine your search")
+}
+
+// shortGameID 返回 ID 的前 8 位，用于列表展示
+func shortGameID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
 }
@@ -0,0 +1,207 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"lunabox/internal/models"
+
+	"github.com/spf13/cobra"
+)
+
+// GameTagsResult 游戏与其 tag 的结构化结果
+type GameTagsResult struct {
+	GameID   string           `json:"game_id"`
+	GameName string           `json:"game_name"`
+	Tags     []models.GameTag `json:"tags"`
+}
+
+// TagGamesResult 包含指定 tag 的游戏
+type TagGamesResult struct {
+	Tag   string          `json:"tag"`
+	Games []GameCandidate `json:"games"`
+}
+
+func newTagCmd(app *CoreApp) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:         "tag",
+		Short:       "Manage game tags",
+		Annotations: standaloneAnnotations,
+	}
+	cmd.AddCommand(newTagListCmd(app))
+	cmd.AddCommand(newTagAddCmd(app))
+	cmd.AddCommand(newTagRemoveCmd(app))
+	cmd.AddCommand(newTagGamesCmd(app))
+	return cmd
+}
+
+// requireTagService 检查 tag 服务是否可用
+func requireTagService(app *CoreApp) error {
+	if app.TagService == nil {
+		return unavailableError("tag management is unavailable")
+	}
+	return nil
+}
+
+func newTagListCmd(app *CoreApp) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list <game>",
+		Short: "List the tags of a game",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireTagService(app); err != nil {
+				return err
+			}
+			gameID, gameName, err := resolveGame(cmd, app, args[0])
+			if err != nil {
+				return err
+			}
+			tags, err := app.TagService.GetTagsByGame(gameID)
+			if err != nil {
+				return err
+			}
+			if tags == nil {
+				tags = []models.GameTag{}
+			}
+			result := GameTagsResult{GameID: gameID, GameName: gameName, Tags: tags}
+			return writeResult(cmd, result, func(w io.Writer) {
+				if len(tags) == 0 {
+					fmt.Fprintf(w, "%s has no tags.\n", gameName)
+					return
+				}
+				fmt.Fprintf(w, "Tags of %s:\n", gameName)
+				for _, t := range tags {
+					spoiler := ""
+					if t.IsSpoiler {
+						spoiler = " (spoiler)"
+					}
+					fmt.Fprintf(w, "  %s [%s]%s\n", t.Name, t.Source, spoiler)
+				}
+			})
+		},
+	}
+}
+
+func newTagAddCmd(app *CoreApp) *cobra.Command {
+	return &cobra.Command{
+		Use:   "add <game> <tag>...",
+		Short: "Add user tags to a game",
+		Args:  cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireTagService(app); err != nil {
+				return err
+			}
+			gameID, gameName, err := resolveGame(cmd, app, args[0])
+			if err != nil {
+				return err
+			}
+			for _, name := range args[1:] {
+				name = strings.TrimSpace(name)
+				if name == "" {
+					return usageError("tag name cannot be empty")
+				}
+				if err := app.TagService.AddUserTag(gameID, name); err != nil {
+					return err
+				}
+			}
+			tags, err := app.TagService.GetTagsByGame(gameID)
+			if err != nil {
+				return err
+			}
+			if tags == nil {
+				tags = []models.GameTag{}
+			}
+			result := GameTagsResult{GameID: gameID, GameName: gameName, Tags: tags}
+			return writeResult(cmd, result, func(w io.Writer) {
+				fmt.Fprintf(w, "✓ Added %d tag(s) to %s\n", len(args)-1, gameName)
+			})
+		},
+	}
+}
+
+func newTagRemoveCmd(app *CoreApp) *cobra.Command {
+	var source string
+
+	cmd := &cobra.Command{
+		Use:   "remove <game> <tag>",
+		Short: "Remove a tag from a game",
+		Long: `Remove a tag from a game.
+Scraped tags can be removed too, but they may come back when metadata is refreshed.
+If the same tag name exists for several sources, pass --source to pick one.`,
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireTagService(app); err != nil {
+				return err
+			}
+			gameID, gameName, err := resolveGame(cmd, app, args[0])
+			if err != nil {
+				return err
+			}
+			tags, err := app.TagService.GetTagsByGame(gameID)
+			if err != nil {
+				return err
+			}
+
+			var matches []models.GameTag
+			for _, t := range tags {
+				if strings.EqualFold(t.Name, args[1]) && (source == "" || t.Source == source) {
+					matches = append(matches, t)
+				}
+			}
+			switch {
+			case len(matches) == 0:
+				return notFoundError("%s has no tag: %s", gameName, args[1])
+			case len(matches) > 1:
+				candidates := make([]GameCandidate, 0, len(matches))
+				for _, t := range matches {
+					candidates = append(candidates, GameCandidate{ID: t.ID, Name: t.Name + " [" + t.Source + "]"})
+				}
+				return ambiguousError(candidates, "tag %s exists for several sources; pass --source", args[1])
+			}
+
+			tag := matches[0]
+			if err := app.TagService.DeleteTag(tag.ID); err != nil {
+				return err
+			}
+			return writeResult(cmd, DeletedResult{ID: tag.ID, Name: tag.Name, Deleted: true}, func(w io.Writer) {
+				fmt.Fprintf(w, "✓ Removed tag %s from %s\n", tag.Name, gameName)
+			})
+		},
+	}
+
+	cmd.Flags().StringVar(&source, "source", "", "Only remove the tag from this source, e.g. user, bangumi, vndb")
+	return cmd
+}
+
+func newTagGamesCmd(app *CoreApp) *cobra.Command {
+	return &cobra.Command{
+		Use:   "games <tag>",
+		Short: "List the games that have a tag",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := requireTagService(app); err != nil {
+				return err
+			}
+			ids, err := app.TagService.GetGameIDsByTag(args[0])
+			if err != nil {
+				return err
+			}
+			games := make([]GameCandidate, 0, len(ids))
+			for _, id := range ids {
+				game, err := app.GameService.GetGameByID(id)
+				if err != nil {
+					return err
+				}
+				games = append(games, GameCandidate{ID: game.ID, Name: game.Name})
+			}
+			result := TagGamesResult{Tag: args[0], Games: games}
+			return writeResult(cmd, result, func(w io.Writer) {
+				fmt.Fprintf(w, "Games tagged %s (%d):\n", args[0], len(games))
+				for _, g := range games {
+					fmt.Fprintf(w, "  %s  %s\n", shortGameID(g.ID), g.Name)
+				}
+			})
+		},
+	}
+}
@@ -13,22 +13,23 @@ func newVersionCmd(app *CoreApp) *cobra.Command {
 		Short:   "Print the version number of LunaBox",
 		Long:    `All software has versions. This is LunaBox's`,
 		Aliases: []string{"v"},
-		Run: func(cmd *cobra.Command, args []string) {
-			printVersion(cmd.OutOrStdout(), app)
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return writeVersion(cmd, app)
 		},
 	}
 }
 
-func printVersion(w io.Writer, app *CoreApp) {
-	// Use VersionService if available
-	if app.VersionService != nil {
-		info := app.VersionService.GetVersionInfo()
+func writeVersion(cmd *cobra.Command, app *CoreApp) error {
+	if app.VersionService == nil {
+		// Fallback (should not happen in normal flow)
+		return unavailableError("version information unavailable")
+	}
+
+	info := app.VersionService.GetVersionInfo()
+	return writeResult(cmd, info, func(w io.Writer) {
 		fmt.Fprintf(w, "LunaBox v%s\n", info["version"])
 		fmt.Fprintf(w, "Commit: %s\n", info["commit"])
 		fmt.Fprintf(w, "Build Time: %s\n", info["buildTime"])
 		fmt.Fprintf(w, "Build Mode: %s\n", info["buildMode"])
-	} else {
-		// Fallback (should not happen in normal flow)
-		fmt.Fprintln(w, "Version information unavailable")
-	}
+	})
 }
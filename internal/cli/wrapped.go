@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"time"
+
+	"lunabox/internal/utils/apputils"
+
+	"github.com/spf13/cobra"
+)
+
+// WrappedResult wrapped 命令的结构化结果，只包含报告摘要
+type WrappedResult struct {
+	Year              int    `json:"year"`
+	Path              string `json:"path"`
+	TotalPlayDuration int    `json:"total_play_duration"`
+	TotalPlayCount    int    `json:"total_play_count"`
+	GamesPlayedCount  int    `json:"games_played_count"`
+	CompletedCount    int    `json:"completed_count"`
+}
+
+func newWrappedCmd(app *CoreApp) *cobra.Command {
+	var year int
+	var path string
+
+	cmd := &cobra.Command{
+		Use:   "wrapped",
+		Short: "Export the year-in-review report as a self-contained HTML bundle",
+		Long: `Export the year-in-review report as a self-contained HTML bundle.
+The --path may end with .zip to write an archive; otherwise the pages are
+written into that directory. Defaults to lunabox-wrapped-<year>.zip on the desktop.
+
+Works without the GUI: when LunaBox is not running, lunacli reads the database
+directly.`,
+		Annotations: standaloneAnnotations,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if app.WrappedService == nil {
+				return unavailableError("wrapped report is unavailable")
+			}
+			if year == 0 {
+				year = time.Now().Year()
+			}
+
+			if path == "" {
+				desktop, err := apputils.GetDesktopDir()
+				if err != nil {
+					return fmt.Errorf("failed to resolve desktop directory: %w", err)
+				}
+				path = filepath.Join(desktop, fmt.Sprintf("lunabox-wrapped-%d.zip", year))
+			}
+
+			report, err := app.WrappedService.WriteWrappedBundle(year, path)
+			if err != nil {
+				return err
+			}
+
+			result := WrappedResult{
+				Year:              year,
+				Path:              path,
+				TotalPlayDuration: report.TotalPlayDuration,
+				TotalPlayCount:    report.TotalPlayCount,
+				GamesPlayedCount:  report.GamesPlayedCount,
+				CompletedCount:    report.CompletedCount,
+			}
+			return writeResult(cmd, result, func(w io.Writer) {
+				fmt.Fprintf(w, "✓ %d wrapped report exported!\n", year)
+				fmt.Fprintf(w, "Play time: %s across %d games (%d sessions)\n", formatWrappedHours(report.TotalPlayDuration), report.GamesPlayedCount, report.TotalPlayCount)
+				fmt.Fprintf(w, "Completed: %d\n", report.CompletedCount)
+				fmt.Fprintf(w, "Path: %s\n", path)
+			})
+		},
+	}
+
+	cmd.Flags().IntVarP(&year, "year", "y", 0, "Year to summarize (defaults to the current year)")
+	cmd.Flags().StringVarP(&path, "path", "p", "", "Output path (.zip archive or directory)")
+	_ = cmd.MarkFlagFilename("path")
+	return cmd
+}
+
+func formatWrappedHours(seconds int) string {
+	return fmt.Sprintf("%.1fh", float64(seconds)/3600)
+}
@@ -107,6 +107,8 @@ type CloudSyncTombstone struct {
 	EntityType string    `json:"entity_type"`
 	EntityID   string    `json:"entity_id"`
 	DeletedAt  time.Time `json:"deleted_at"`
+	// PublishedAt 是墓碑首次随 manifest 进入远端的时间；尚未同步过的本地墓碑为零值
+	PublishedAt time.Time `json:"published_at,omitzero"`
 }
 
 type CloudSyncCoverAsset struct {
@@ -126,6 +128,19 @@ type CloudSyncLocalState struct {
 	Covers   map[string]CloudSyncLocalCover
 }
 
+// CloudSyncConflict 记录三方合并中双方都改了同一字段且取值不同的情况。
+// 各 Value 字段保存该字段的 JSON 编码；Resolution 为自动采用的一方（local / remote）。
+type CloudSyncConflict struct {
+	EntityType  string    `json:"entity_type"`
+	EntityID    string    `json:"entity_id"`
+	Field       string    `json:"field"`
+	BaseValue   string    `json:"base_value"`
+	LocalValue  string    `json:"local_value"`
+	RemoteValue string    `json:"remote_value"`
+	Resolution  string    `json:"resolution"`
+	DetectedAt  time.Time `json:"detected_at"`
+}
+
 type CloudSyncCandidate struct {
 	Timestamp time.Time
 	Source    int
@@ -13,6 +13,13 @@ type CloudSyncManifest struct {
 	Buckets       map[string]map[string]CloudSyncBucketRef `json:"buckets"`
 	Singletons    map[string]CloudSyncBucketRef            `json:"singletons"`
 	Covers        []CloudSyncCoverRef                      `json:"covers"`
+	// Devices 记录每台设备最近一次完成同步的时间，墓碑压缩据此判断墓碑是否已被所有设备看到。
+	// 超过压缩期限未同步的设备会被移出，重新出现时按"可能错过已压缩墓碑"处理。
+	Devices map[string]time.Time `json:"devices,omitempty"`
+	// DevicesSince 是设备登记开始的时间；登记满一个压缩期限后才允许压缩，给所有活跃设备留出登记机会
+	DevicesSince time.Time `json:"devices_since,omitzero"`
+	// TombstonesCompactedThrough 是已被压缩墓碑中最晚的 published_at
+	TombstonesCompactedThrough time.Time `json:"tombstones_compacted_through,omitzero"`
 }
 
 // CloudSyncBucketRef 描述一个桶文件或单文件的指纹。
@@ -49,3 +56,20 @@ type CloudSyncCoverRef struct {
 	UpdatedAt time.Time `json:"updated_at"`
 	Hash      string    `json:"hash,omitempty"`
 }
+
+// CloudSyncHistoryIndex 是 sync/history/index.json 的内容，按时间倒序列出仍保留的历史 revision。
+// 每个 revision 的 manifest 副本与其引用的桶内容都按 hash 存放在 sync/history 下，互不覆盖。
+type CloudSyncHistoryIndex struct {
+	SchemaVersion int                    `json:"schema_version"`
+	Revisions     []CloudSyncRevisionRef `json:"revisions"`
+}
+
+// CloudSyncRevisionRef 是历史索引中的一条 revision。
+// Objects 为该 revision 引用的历史对象文件名，GC 据此判断对象是否仍被引用。
+type CloudSyncRevisionRef struct {
+	RevisionID string    `json:"revision_id"`
+	ExportedAt time.Time `json:"exported_at"`
+	DeviceID   string    `json:"device_id"`
+	GameCount  int       `json:"game_count"`
+	Objects    []string  `json:"objects"`
+}
@@ -0,0 +1,39 @@
+package enums
+
+type ExportEntity string
+
+const (
+	ExportGames    ExportEntity = "games"    // 游戏库
+	ExportSessions ExportEntity = "sessions" // 游玩记录
+	ExportTags     ExportEntity = "tags"     // 游戏标签
+	ExportReviews  ExportEntity = "reviews"  // 个人评价
+	ExportProgress ExportEntity = "progress" // 游玩进度
+)
+
+var AllExportEntities = []struct {
+	Value  ExportEntity
+	TSName string
+}{
+	{ExportGames, "GAMES"},
+	{ExportSessions, "SESSIONS"},
+	{ExportTags, "TAGS"},
+	{ExportReviews, "REVIEWS"},
+	{ExportProgress, "PROGRESS"},
+}
+
+type ExportFormat string
+
+const (
+	ExportCSV    ExportFormat = "csv"
+	ExportNDJSON ExportFormat = "ndjson" // 每行一个 JSON 对象
+	ExportICS    ExportFormat = "ics"    // iCalendar，仅支持游玩记录
+)
+
+var AllExportFormats = []struct {
+	Value  ExportFormat
+	TSName string
+}{
+	{ExportCSV, "CSV"},
+	{ExportNDJSON, "NDJSON"},
+	{ExportICS, "ICS"},
+}
@@ -1,5 +1,10 @@
 package enums
 
+import (
+	"regexp"
+	"strings"
+)
+
 type SourceType string
 
 const (
@@ -29,3 +34,19 @@ var AllSourceTypes = []struct {
 	{Hikarinagi, "HIKARINAGI"},
 	{ErogameScape, "EROGAMESCAPE"},
 }
+
+// PluginSourcePrefix 元数据插件注册的来源统一带此前缀，避免与现有或将来的内置来源重名
+const PluginSourcePrefix = "plugin:"
+
+var pluginSourceIDPattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,31}$`)
+
+// PluginSourceType 返回插件 ID 对应的来源
+func PluginSourceType(pluginID string) SourceType {
+	return SourceType(PluginSourcePrefix + pluginID)
+}
+
+// IsPluginSourceType 判断来源是否符合插件来源格式："plugin:" 加上小写字母开头的 2~32 位小写字母、数字、- 或 _
+func IsPluginSourceType(source SourceType) bool {
+	pluginID, found := strings.CutPrefix(string(source), PluginSourcePrefix)
+	return found && pluginSourceIDPattern.MatchString(pluginID)
+}
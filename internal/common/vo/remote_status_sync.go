@@ -1,5 +1,7 @@
 package vo
 
+import "time"
+
 // RemoteStatusSyncProgress describes the progress and result of uploading all
 // local game statuses for one remote metadata provider.
 type RemoteStatusSyncProgress struct {
@@ -13,3 +15,25 @@ type RemoteStatusSyncProgress struct {
 	FailedGameNames []string `json:"failed_game_names"`
 	LastError       string   `json:"last_error"`
 }
+
+// RemotePushJob is one queued push of a local status or review change to a
+// remote provider. Dead jobs stopped retrying and wait for the user.
+type RemotePushJob struct {
+	ID            string    `json:"id"`
+	Provider      string    `json:"provider"`
+	Kind          string    `json:"kind"` // status / review
+	GameID        string    `json:"game_id"`
+	GameName      string    `json:"game_name"`
+	SourceID      string    `json:"source_id"`
+	Attempts      int       `json:"attempts"`
+	LastError     string    `json:"last_error"`
+	NextAttemptAt time.Time `json:"next_attempt_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// RemotePushQueueStatus summarizes the remote push outbox.
+type RemotePushQueueStatus struct {
+	Running     bool            `json:"running"`
+	Pending     int             `json:"pending"`
+	DeadLetters []RemotePushJob `json:"dead_letters"`
+}
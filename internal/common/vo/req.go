@@ -141,6 +141,46 @@ type ImportSelection struct {
 	SourceID   string           `json:"source_id,omitempty"`
 }
 
+// GenericImportMapping 通用 CSV/JSON 导入的字段映射。
+// 字段值为源文件中的列名（CSV 表头或 JSON 键，JSON 可用 "." 访问嵌套字段），留空表示不导入该字段。
+type GenericImportMapping struct {
+	Format      string `json:"format,omitempty"`       // csv 或 json，留空时按扩展名判断
+	RecordsPath string `json:"records_path,omitempty"` // JSON 中游戏数组所在路径，留空表示根节点即数组
+
+	Name          string `json:"name"`
+	Aliases       string `json:"aliases,omitempty"`
+	Company       string `json:"company,omitempty"`
+	Summary       string `json:"summary,omitempty"`
+	Rating        string `json:"rating,omitempty"`
+	ReleaseDate   string `json:"release_date,omitempty"`
+	CoverURL      string `json:"cover_url,omitempty"`
+	Path          string `json:"path,omitempty"`
+	GameDirectory string `json:"game_directory,omitempty"`
+	SavePath      string `json:"save_path,omitempty"`
+	ProcessName   string `json:"process_name,omitempty"`
+	Tags          string `json:"tags,omitempty"`
+	Status        string `json:"status,omitempty"`
+	SourceType    string `json:"source_type,omitempty"`
+	SourceID      string `json:"source_id,omitempty"`
+	CreatedAt     string `json:"created_at,omitempty"`
+	PlayTime      string `json:"play_time,omitempty"` // 总游玩时长，超出游玩记录合计的部分补为一条汇总记录
+
+	// SourceIDs 元数据源到 ID 列的映射，如 {"vndb": "VNDB ID"}
+	SourceIDs map[string]string `json:"source_ids,omitempty"`
+	// StatusValues 源文件状态值到 LunaBox 状态的映射，优先于内置识别
+	StatusValues map[string]string `json:"status_values,omitempty"`
+
+	// Sessions JSON 中每个游戏的游玩记录数组字段；CSV 中同名同路径的多行视为同一游戏的多条记录
+	Sessions        string `json:"sessions,omitempty"`
+	SessionStart    string `json:"session_start,omitempty"`
+	SessionEnd      string `json:"session_end,omitempty"`
+	SessionDuration string `json:"session_duration,omitempty"`
+
+	DurationUnit  string  `json:"duration_unit,omitempty"`  // 纯数字时长的单位：seconds、minutes、hours，默认 minutes
+	RatingScale   float64 `json:"rating_scale,omitempty"`   // 源评分满分，默认 10
+	ListSeparator string  `json:"list_separator,omitempty"` // 别名和标签的分隔符，默认识别 , ; | 、
+}
+
 // ImportMetadataDuplicateRequest 元数据重复检查请求。
 type ImportMetadataDuplicateRequest struct {
 	Source   enums.SourceType `json:"source"`
@@ -198,6 +238,23 @@ type PeriodStatsRequest struct {
 	Dimension enums.Period `json:"dimension"`  // day, week, month
 	StartDate string       `json:"start_date"` // YYYY-MM-DD (可选，不传则使用默认范围)
 	EndDate   string       `json:"end_date"`   // YYYY-MM-DD (可选，不传则使用默认范围)
+	Compare   bool         `json:"compare"`    // 同时统计紧邻的上一个等长周期，并返回各项差值
+}
+
+// CompletionForecastRequest 通关时长预估请求参数
+type CompletionForecastRequest struct {
+	Dimension         enums.Period `json:"dimension"`           // 计算日均游玩时长所用的周期，默认 month
+	UseMetadataLength bool         `json:"use_metadata_length"` // 是否参考元数据源提供的游戏时长（如 VNDB length_minutes）
+}
+
+// DataExportRequest 原始数据导出请求参数
+type DataExportRequest struct {
+	Entity    enums.ExportEntity `json:"entity"`
+	Format    enums.ExportFormat `json:"format"`
+	StartDate string             `json:"start_date"` // YYYY-MM-DD（可选，含当天）
+	EndDate   string             `json:"end_date"`   // YYYY-MM-DD（可选，含当天）
+	Category  string             `json:"category"`   // 分类 ID 或名称（可选）
+	Status    enums.GameStatus   `json:"status"`     // 游戏状态（可选）
 }
 
 // GameStatsRequest 游戏统计请求参数
@@ -267,6 +324,11 @@ type MCPMetadataSearchRequest struct {
 	Meta  json.RawMessage `json:"_meta,omitempty"`
 }
 
+type MCPCompletionForecastRequest struct {
+	UseMetadataLength bool            `json:"use_metadata_length"`
+	Meta              json.RawMessage `json:"_meta,omitempty"`
+}
+
 type MCPGameStatisticRequest struct {
 	Period string          `json:"period"`
 	Meta   json.RawMessage `json:"_meta,omitempty"`
@@ -101,6 +101,75 @@ type BangumiStatusPushFailureEvent struct {
 	Error       string `json:"error"`
 }
 
+// BangumiCollectionChange Bangumi 收藏同步中某个字段的变化
+type BangumiCollectionChange struct {
+	Field     string `json:"field"`     // status / review
+	Direction string `json:"direction"` // to_local（以 Bangumi 为准） / to_remote（以本地为准）
+	Local     string `json:"local"`     // 本地取值
+	Remote    string `json:"remote"`    // Bangumi 取值
+}
+
+// BangumiCollectionSyncItem Bangumi 收藏同步中一个条目的处理结果
+type BangumiCollectionSyncItem struct {
+	SubjectID       string                    `json:"subject_id"`
+	GameID          string                    `json:"game_id"` // 本地游戏 ID，预览中待新建的条目为空
+	GameName        string                    `json:"game_name"`
+	Action          string                    `json:"action"` // create / update / unchanged / failed
+	Changes         []BangumiCollectionChange `json:"changes"`
+	RemoteUpdatedAt string                    `json:"remote_updated_at"`
+	Error           string                    `json:"error,omitempty"`
+}
+
+// BangumiCollectionSyncReport Bangumi 收藏双向同步（或预览）的结果
+type BangumiCollectionSyncReport struct {
+	DryRun    bool                        `json:"dry_run"`
+	Total     int                         `json:"total"`
+	Created   int                         `json:"created"`
+	Updated   int                         `json:"updated"`
+	Unchanged int                         `json:"unchanged"`
+	Failed    int                         `json:"failed"`
+	Items     []BangumiCollectionSyncItem `json:"items"`
+}
+
+// VNDBAuthInfo VNDB 令牌对应的用户及权限
+type VNDBAuthInfo struct {
+	UserID       string   `json:"user_id"`
+	Username     string   `json:"username"`
+	Permissions  []string `json:"permissions"`
+	CanReadList  bool     `json:"can_read_list"`
+	CanWriteList bool     `json:"can_write_list"`
+}
+
+// VNDBUListImportItem VNDB 列表导入中一个条目的处理结果
+type VNDBUListImportItem struct {
+	VNID     string `json:"vn_id"`
+	GameID   string `json:"game_id"` // 本地游戏 ID，预览中待新建的条目为空
+	GameName string `json:"game_name"`
+	Action   string `json:"action"` // create / update / unchanged / failed
+	Status   string `json:"status"` // 按 VNDB 标签对应的本地状态，没有状态标签时为空
+	Rating   *int   `json:"rating"` // 按 VNDB 投票换算的 1~10 评分
+	Error    string `json:"error,omitempty"`
+}
+
+// VNDBUListImportReport VNDB 列表导入（或预览）的结果
+type VNDBUListImportReport struct {
+	DryRun    bool                  `json:"dry_run"`
+	Total     int                   `json:"total"`
+	Created   int                   `json:"created"`
+	Updated   int                   `json:"updated"`
+	Unchanged int                   `json:"unchanged"`
+	Failed    int                   `json:"failed"`
+	Items     []VNDBUListImportItem `json:"items"`
+}
+
+type VNDBStatusPushFailureEvent struct {
+	GameID      string `json:"game_id"`
+	GameName    string `json:"game_name"`
+	VNID        string `json:"vn_id"`
+	LocalStatus string `json:"local_status"`
+	Error       string `json:"error"`
+}
+
 type HikarinagiAuthStatus struct {
 	Authorized           bool   `json:"authorized"`
 	NeedsReauthorization bool   `json:"needs_reauthorization"`
@@ -240,6 +309,106 @@ type PeriodStats struct {
 	Heatmap                []HeatmapCell      `json:"heatmap"`              // 年维度时填充：本期间内按日聚合
 	HourlyDistribution     []HourPlayPoint    `json:"hourly_distribution"`  // 24 小时游玩时段分布
 	WeekdayDistribution    []WeekdayPlayPoint `json:"weekday_distribution"` // 7 天每天分布
+	Breakdowns             PeriodBreakdowns   `json:"breakdowns"`           // 按分类、标签、厂商、启动方式拆分的时长
+	Previous               *PeriodStats       `json:"previous,omitempty"`   // compare 时填充：上一个等长周期的统计
+	Delta                  *PeriodStatsDelta  `json:"delta,omitempty"`      // compare 时填充：本期减上一期
+}
+
+// PeriodBreakdownItem 本期间内某个分组的游玩汇总。Key 为空表示未分类 / 未填写厂商。
+type PeriodBreakdownItem struct {
+	Key           string `json:"key"`            // 分类 ID、标签名、厂商名或启动方式
+	Name          string `json:"name"`           // 显示名称
+	TotalDuration int    `json:"total_duration"` // seconds
+	PlayCount     int    `json:"play_count"`
+	GameCount     int    `json:"game_count"` // 游玩过的游戏数（去重）
+}
+
+// PeriodBreakdowns 本期间内的分组时长；一个游戏属于多个分类或标签时，时长会计入每一组
+type PeriodBreakdowns struct {
+	Category   []PeriodBreakdownItem `json:"category"`
+	Tag        []PeriodBreakdownItem `json:"tag"` // 已过滤剧透标签
+	Company    []PeriodBreakdownItem `json:"company"`
+	LaunchMode []PeriodBreakdownItem `json:"launch_mode"`
+}
+
+// StatsDelta 某个分组或时间点在两个周期中的数值
+type StatsDelta struct {
+	Key      string `json:"key"`
+	Name     string `json:"name"`
+	Current  int    `json:"current"`
+	Previous int    `json:"previous"`
+	Delta    int    `json:"delta"` // current - previous
+}
+
+// PeriodBreakdownDeltas 各分组时长在两个周期间的变化
+type PeriodBreakdownDeltas struct {
+	Category   []StatsDelta `json:"category"`
+	Tag        []StatsDelta `json:"tag"`
+	Company    []StatsDelta `json:"company"`
+	LaunchMode []StatsDelta `json:"launch_mode"`
+}
+
+// PeriodStatsDelta 本期与上一期的差值（本期 - 上一期）。
+// 时间线按周期内的位置对齐（本期第 N 天对上一期第 N 天），Key 为本期标签。
+type PeriodStatsDelta struct {
+	PreviousStartDate      string                `json:"previous_start_date"`
+	PreviousEndDate        string                `json:"previous_end_date"`
+	TotalPlayCount         int                   `json:"total_play_count"`
+	TotalPlayDuration      int                   `json:"total_play_duration"`
+	TotalGamesCount        int                   `json:"total_games_count"`
+	CompletedGamesCount    int                   `json:"completed_games_count"`
+	LibraryGamesCount      int                   `json:"library_games_count"`
+	AllSessionsCount       int                   `json:"all_sessions_count"`
+	AllSessionsDuration    int                   `json:"all_sessions_duration"`
+	AllCompletedGamesCount int                   `json:"all_completed_games_count"`
+	ActiveDays             int                   `json:"active_days"`
+	AvgDailyDuration       int                   `json:"avg_daily_duration"`
+	AvgSessionDuration     int                   `json:"avg_session_duration"`
+	MaxStreak              int                   `json:"max_streak"`
+	CurrentStreak          int                   `json:"current_streak"`
+	NewGamesCount          int                   `json:"new_games_count"`
+	PlayTimeLeaderboard    []StatsDelta          `json:"play_time_leaderboard"` // 按游戏 ID
+	Timeline               []StatsDelta          `json:"timeline"`
+	TagDistribution        []StatsDelta          `json:"tag_distribution"`
+	Heatmap                []StatsDelta          `json:"heatmap"`
+	HourlyDistribution     []StatsDelta          `json:"hourly_distribution"`  // Key 为 0~23
+	WeekdayDistribution    []StatsDelta          `json:"weekday_distribution"` // Key 为 0~6
+	Breakdowns             PeriodBreakdownDeltas `json:"breakdowns"`
+}
+
+// GameCompletionEstimate 单个游戏的通关时长预估，时长单位均为秒
+type GameCompletionEstimate struct {
+	GameID                 string           `json:"game_id"`
+	GameName               string           `json:"game_name"`
+	Company                string           `json:"company"`
+	Status                 enums.GameStatus `json:"status"`
+	PlayedDuration         int              `json:"played_duration"`
+	EstimatedTotalDuration int              `json:"estimated_total_duration"` // 0 表示无法预估
+	RemainingDuration      int              `json:"remaining_duration"`
+	Basis                  string           `json:"basis"`                   // similar, metadata, similar+metadata, library, none
+	SimilarGamesCount      int              `json:"similar_games_count"`     // 参与预估的相似已通关游戏数
+	MetadataLengthMinutes  int              `json:"metadata_length_minutes"` // 元数据源提供的时长（分钟），0 表示未提供
+	ProjectedFinishDate    string           `json:"projected_finish_date"`   // 按当前节奏依次游玩时的预计通关日期 YYYY-MM-DD
+}
+
+// CompletionForecast 在玩 / 想玩游戏的通关预估及积压清空预测
+type CompletionForecast struct {
+	PaceDimension          enums.Period             `json:"pace_dimension"`
+	PaceStartDate          string                   `json:"pace_start_date"`
+	PaceEndDate            string                   `json:"pace_end_date"`
+	AvgDailyDuration       int                      `json:"avg_daily_duration"` // 计算节奏所用的日均游玩时长（秒）
+	TotalRemainingDuration int                      `json:"total_remaining_duration"`
+	EstimatedGamesCount    int                      `json:"estimated_games_count"`
+	UnestimatedGamesCount  int                      `json:"unestimated_games_count"`
+	EstimatedDays          int                      `json:"estimated_days"`       // 清空积压所需天数，日均时长为 0 时为 -1
+	EstimatedClearDate     string                   `json:"estimated_clear_date"` // 积压清空日期 YYYY-MM-DD，无法预测时为空
+	Games                  []GameCompletionEstimate `json:"games"`                // 在玩游戏在前，想玩游戏在后
+}
+
+// DataExportResult 原始数据导出结果
+type DataExportResult struct {
+	Path string `json:"path"` // 用户取消保存时为空
+	Rows int    `json:"rows"`
 }
 
 // AISummaryResponse AI总结响应
@@ -269,6 +438,27 @@ type CloudBackupStatus struct {
 	Configured bool   `json:"configured"` // 是否已配置
 	UserID     string `json:"user_id"`    // 用户标识
 	Provider   string `json:"provider"`   // 云备份提供商: s3, onedrive
+	Encrypted  bool   `json:"encrypted"`  // 是否已启用端到端加密
+
+	RotationPending bool `json:"rotation_pending"` // 是否有未完成的备份密码轮换
+}
+
+// CloudEncryptionMigrationResult 云端明文对象加密迁移结果
+type CloudEncryptionMigrationResult struct {
+	Total            int      `json:"total"`             // 扫描到的对象数量
+	Migrated         int      `json:"migrated"`          // 本次重新加密上传的数量
+	AlreadyEncrypted int      `json:"already_encrypted"` // 已经是加密信封的数量
+	Failed           int      `json:"failed"`            // 失败数量
+	FailedKeys       []string `json:"failed_keys"`       // 失败的对象 key
+}
+
+// CloudPasswordRotationResult 备份密码轮换结果
+type CloudPasswordRotationResult struct {
+	UserID   string `json:"user_id"`  // 轮换后的用户标识
+	Total    int    `json:"total"`    // 旧命名空间下的对象数量
+	Copied   int    `json:"copied"`   // 本次复制（重新加密）的数量
+	Verified int    `json:"verified"` // 校验通过的数量（含上次中断前已复制的对象）
+	Deleted  int    `json:"deleted"`  // 已删除的旧对象数量
 }
 
 // UmbraUserProfile Umbra 当前授权账户信息。
@@ -290,6 +480,81 @@ type CloudSyncStatus struct {
 	LastSyncError  string `json:"last_sync_error"`
 }
 
+// CloudSyncConflict 云同步逐字段合并冲突（已按 LWW 自动处理，等待用户确认）
+type CloudSyncConflict struct {
+	EntityType  string `json:"entity_type"`  // game / game_progress / game_review
+	EntityID    string `json:"entity_id"`    // 实体 ID（评价为 game_id）
+	EntityName  string `json:"entity_name"`  // 对应游戏名称，便于展示
+	Field       string `json:"field"`        // 字段名（同步格式中的 JSON key）
+	BaseValue   string `json:"base_value"`   // 上次同步时的取值（JSON）
+	LocalValue  string `json:"local_value"`  // 本机取值（JSON）
+	RemoteValue string `json:"remote_value"` // 云端取值（JSON）
+	Resolution  string `json:"resolution"`   // 当前采用的一方: local / remote
+	DetectedAt  string `json:"detected_at"`  // 检测时间
+}
+
+// CloudSyncPreviewItem 同步预览中一条记录的变化
+type CloudSyncPreviewItem struct {
+	EntityType string `json:"entity_type"` // game / play_session / game_tag / game_review / tombstone / ...
+	EntityID   string `json:"entity_id"`   // 实体 ID（墓碑为 "实体类型/ID"）
+	GameID     string `json:"game_id"`     // 所属游戏 ID
+	GameName   string `json:"game_name"`   // 所属游戏名称
+	Change     string `json:"change"`      // added / updated / deleted
+}
+
+// CloudSyncPreviewCount 同步预览中某类实体的变化计数
+type CloudSyncPreviewCount struct {
+	EntityType string `json:"entity_type"`
+	Added      int    `json:"added"`
+	Updated    int    `json:"updated"`
+	Deleted    int    `json:"deleted"`
+}
+
+// CloudSyncPreviewSide 同步完成后某一侧（本地或云端）将发生的变化
+type CloudSyncPreviewSide struct {
+	Entities []CloudSyncPreviewCount `json:"entities"`
+	Items    []CloudSyncPreviewItem  `json:"items"`
+}
+
+// CloudSyncPreview 云同步演练结果（不写入任何数据）
+type CloudSyncPreview struct {
+	RemoteExists bool                 `json:"remote_exists"` // 云端是否已有同步数据
+	Local        CloudSyncPreviewSide `json:"local"`         // 本地将发生的变化
+	Remote       CloudSyncPreviewSide `json:"remote"`        // 云端将发生的变化
+	Conflicts    []CloudSyncConflict  `json:"conflicts"`     // 将按 LWW 自动处理的字段冲突
+}
+
+// CloudSyncRevision 云同步历史版本
+type CloudSyncRevision struct {
+	RevisionID  string `json:"revision_id"`  // 版本 ID
+	ExportedAt  string `json:"exported_at"`  // 生成时间
+	DeviceID    string `json:"device_id"`    // 生成该版本的设备
+	GameCount   int    `json:"game_count"`   // 该版本中的游戏数量
+	LocalSynced bool   `json:"local_synced"` // 是否为本机最近一次同步到的版本
+}
+
+// CloudSyncEntityDiff 某类实体在历史版本与本地之间的差异计数
+type CloudSyncEntityDiff struct {
+	EntityType   string `json:"entity_type"`   // game / category / play_session / ...
+	OnlyLocal    int    `json:"only_local"`    // 仅本地存在（回滚后将被删除）
+	OnlyRevision int    `json:"only_revision"` // 仅历史版本存在（回滚后将被恢复）
+	Changed      int    `json:"changed"`       // 两侧都存在但内容不同
+}
+
+// CloudSyncGameDiff 历史版本与本地之间有差异的游戏
+type CloudSyncGameDiff struct {
+	GameID string `json:"game_id"`
+	Name   string `json:"name"`
+	Change string `json:"change"` // only_local / only_revision / changed
+}
+
+// CloudSyncRevisionDiff 历史版本与本地数据的差异
+type CloudSyncRevisionDiff struct {
+	RevisionID string                `json:"revision_id"`
+	Entities   []CloudSyncEntityDiff `json:"entities"`
+	Games      []CloudSyncGameDiff   `json:"games"`
+}
+
 // CloudBackupItem 云端备份项
 type CloudBackupItem struct {
 	Key       string    `json:"key"`        // S3 对象 key
@@ -298,6 +563,43 @@ type CloudBackupItem struct {
 	CreatedAt time.Time `json:"created_at"` // 创建时间
 }
 
+// SaveFileEntry 存档备份（或当前存档）中的一个文件
+type SaveFileEntry struct {
+	Path    string    `json:"path"`     // 相对存档根目录的路径，使用 / 分隔；单文件存档为文件名
+	Size    int64     `json:"size"`     // 文件大小（字节）
+	ModTime time.Time `json:"mod_time"` // 修改时间
+	Hash    string    `json:"hash"`     // 文件内容 SHA-256
+}
+
+// SaveFileDiff 两份存档中同一路径文件的比较结果
+type SaveFileDiff struct {
+	Path   string         `json:"path"`
+	Change string         `json:"change"` // added / removed / modified / unchanged（按内容哈希判断）
+	From   *SaveFileEntry `json:"from"`   // 基准一侧的文件，added 时为空
+	To     *SaveFileEntry `json:"to"`     // 对比一侧的文件，removed 时为空
+}
+
+// SaveBackupDiff 两个存档备份（或备份与当前存档）之间的差异
+type SaveBackupDiff struct {
+	FromPath  string         `json:"from_path"` // 基准备份路径
+	ToPath    string         `json:"to_path"`   // 对比备份路径，与当前存档比较时为空
+	Files     []SaveFileDiff `json:"files"`     // 按路径排序，包含未变化的文件
+	Added     int            `json:"added"`
+	Removed   int            `json:"removed"`
+	Modified  int            `json:"modified"`
+	Unchanged int            `json:"unchanged"`
+}
+
+// SavePathCandidate 自动探测到的候选存档路径。
+// session_writes 来自游玩期间对存档根目录的定时轮询：可能包含同一时段其他程序的写入，
+// 也可能漏掉较深目录或两次轮询之间创建又删除的文件，仅供用户确认。
+type SavePathCandidate struct {
+	Path             string   `json:"path"`
+	Confidence       float64  `json:"confidence"`        // 置信度 0~1
+	Reasons          []string `json:"reasons"`           // game_save_folder / name_match / save_files / wine_prefix / session_writes
+	ObservedSessions int      `json:"observed_sessions"` // 在多少次游玩中观察到该目录被写入
+}
+
 // DBBackupInfo 数据库备份信息
 type DBBackupInfo struct {
 	Path      string    `json:"path"`       // 备份文件路径
@@ -455,3 +757,19 @@ type MCPGameStatisticResponse struct {
 	RecentSessions    []MCPGameStatisticSession `json:"recent_sessions"`
 	SpoilerContext    SpoilerContext            `json:"spoiler_context"`
 }
+
+// MetadataPluginInfo 已加载的元数据插件
+type MetadataPluginInfo struct {
+	ID             enums.SourceType `json:"id"`              // 插件注册的来源 ID
+	Name           string           `json:"name"`            // 显示名称
+	Kind           string           `json:"kind"`            // executable | scraper
+	ManifestPath   string           `json:"manifest_path"`   // 清单文件路径
+	SupportsSearch bool             `json:"supports_search"` // 是否支持按名称搜索
+}
+
+// MetadataPluginList 插件目录的加载结果，Errors 为被跳过的无效清单
+type MetadataPluginList struct {
+	Directory string               `json:"directory"`
+	Plugins   []MetadataPluginInfo `json:"plugins"`
+	Errors    []string             `json:"errors"`
+}
@@ -0,0 +1,78 @@
+package vo
+
+import "time"
+
+// WrappedReport 年度回顾报告，时长单位均为秒
+type WrappedReport struct {
+	Year               int    `json:"year"`
+	StartDate          string `json:"start_date"`
+	EndDate            string `json:"end_date"`
+	TotalPlayDuration  int    `json:"total_play_duration"`
+	TotalPlayCount     int    `json:"total_play_count"`
+	GamesPlayedCount   int    `json:"games_played_count"`
+	ActiveDays         int    `json:"active_days"`
+	AvgSessionDuration int    `json:"avg_session_duration"`
+
+	TopStreak      WrappedStreak   `json:"top_streak"`
+	BiggestSession *WrappedSession `json:"biggest_session,omitempty"` // 单次最长的游玩记录
+	FirstSession   *WrappedSession `json:"first_session,omitempty"`   // 年内第一次游玩
+	LastSession    *WrappedSession `json:"last_session,omitempty"`    // 年内最后一次游玩
+	BusiestMonth   *WrappedMonth   `json:"busiest_month,omitempty"`
+	Months         []WrappedMonth  `json:"months"` // 1~12 月，无游玩的月份时长为 0
+	TopTag         *WrappedTag     `json:"top_tag,omitempty"`
+	TopGames       []WrappedGame   `json:"top_games"`
+
+	// 通关统计：库中没有通关时间，以“状态为已通关且最后一次游玩发生在本年”视为本年通关
+	CompletedCount       int               `json:"completed_count"`
+	CompletedGames       []WrappedGame     `json:"completed_games"`
+	LongestOwnedFinished *WrappedOwnedGame `json:"longest_owned_finished,omitempty"` // 入库最久、终于在本年通关的游戏
+}
+
+// WrappedStreak 连续游玩天数
+type WrappedStreak struct {
+	Days      int    `json:"days"`
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+}
+
+// WrappedSession 一次游玩记录
+type WrappedSession struct {
+	GameID    string    `json:"game_id"`
+	GameName  string    `json:"game_name"`
+	CoverURL  string    `json:"cover_url"`
+	StartTime time.Time `json:"start_time"`
+	Duration  int       `json:"duration"`
+}
+
+// WrappedMonth 单月游玩汇总
+type WrappedMonth struct {
+	Month         string `json:"month"` // YYYY-MM
+	TotalDuration int    `json:"total_duration"`
+	PlayCount     int    `json:"play_count"`
+}
+
+// WrappedTag 游玩时长最多的标签
+type WrappedTag struct {
+	Name          string `json:"name"`
+	TotalDuration int    `json:"total_duration"`
+	GameCount     int    `json:"game_count"`
+}
+
+// WrappedGame 年内游玩过的游戏
+type WrappedGame struct {
+	GameID        string `json:"game_id"`
+	GameName      string `json:"game_name"`
+	CoverURL      string `json:"cover_url"`
+	TotalDuration int    `json:"total_duration"` // 年内游玩时长
+	PlayCount     int    `json:"play_count"`
+}
+
+// WrappedOwnedGame 入库很久后才通关的游戏
+type WrappedOwnedGame struct {
+	GameID     string    `json:"game_id"`
+	GameName   string    `json:"game_name"`
+	CoverURL   string    `json:"cover_url"`
+	AddedAt    time.Time `json:"added_at"`
+	FinishedAt time.Time `json:"finished_at"` // 最后一次游玩时间
+	OwnedDays  int       `json:"owned_days"`
+}
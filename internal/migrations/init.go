@@ -48,7 +48,8 @@ func InitSchema(db *sql.DB) error {
 			use_locale_emulator BOOLEAN DEFAULT FALSE,
 			use_magpie BOOLEAN DEFAULT FALSE,
 			is_nsfw BOOLEAN DEFAULT FALSE,
-			metadata_locked BOOLEAN DEFAULT FALSE
+			metadata_locked BOOLEAN DEFAULT FALSE,
+			status_updated_at TIMESTAMPTZ
 		)`,
 		`CREATE TABLE IF NOT EXISTS game_metadata_sources (
 			game_id TEXT NOT NULL,
@@ -79,6 +80,7 @@ func InitSchema(db *sql.DB) error {
 			parent_id TEXT DEFAULT '',
 			secondary_id TEXT DEFAULT '',
 			deleted_at TIMESTAMPTZ NOT NULL,
+			published_at TIMESTAMPTZ,
 			PRIMARY KEY (entity_type, entity_id, parent_id, secondary_id)
 		)`,
 		`CREATE TABLE IF NOT EXISTS game_progress (
@@ -131,6 +133,47 @@ func InitSchema(db *sql.DB) error {
 			remote_revision_id TEXT NOT NULL,
 			updated_at TIMESTAMPTZ NOT NULL
 		)`,
+		`CREATE TABLE IF NOT EXISTS cloud_sync_base (
+			bucket_key TEXT PRIMARY KEY,
+			revision_id TEXT NOT NULL,
+			payload TEXT NOT NULL,
+			updated_at TIMESTAMPTZ NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS cloud_sync_conflicts (
+			entity_type TEXT NOT NULL,
+			entity_id TEXT NOT NULL,
+			field TEXT NOT NULL,
+			base_value TEXT NOT NULL DEFAULT 'null',
+			local_value TEXT NOT NULL DEFAULT 'null',
+			remote_value TEXT NOT NULL DEFAULT 'null',
+			resolution TEXT NOT NULL,
+			detected_at TIMESTAMPTZ NOT NULL,
+			PRIMARY KEY (entity_type, entity_id, field)
+		)`,
+		`CREATE TABLE IF NOT EXISTS save_path_candidates (
+			game_id TEXT NOT NULL,
+			path TEXT NOT NULL,
+			confidence DOUBLE NOT NULL DEFAULT 0,
+			reasons TEXT NOT NULL DEFAULT '[]',
+			observed_sessions INTEGER NOT NULL DEFAULT 0,
+			dismissed BOOLEAN NOT NULL DEFAULT FALSE,
+			detected_at TIMESTAMPTZ NOT NULL,
+			PRIMARY KEY (game_id, path)
+		)`,
+		`CREATE TABLE IF NOT EXISTS remote_push_outbox (
+			id TEXT PRIMARY KEY,
+			provider TEXT NOT NULL,
+			kind TEXT NOT NULL,
+			game_id TEXT NOT NULL,
+			source_id TEXT NOT NULL,
+			revision INTEGER NOT NULL DEFAULT 1,
+			attempts INTEGER NOT NULL DEFAULT 0,
+			last_error TEXT NOT NULL DEFAULT '',
+			dead BOOLEAN NOT NULL DEFAULT FALSE,
+			next_attempt_at TIMESTAMPTZ NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL,
+			updated_at TIMESTAMPTZ NOT NULL
+		)`,
 		`CREATE TABLE IF NOT EXISTS game_filter_presets (
 			id TEXT PRIMARY KEY,
 			name TEXT NOT NULL,
@@ -795,6 +795,108 @@ func migration172(tx *sql.Tx) error {
 	return nil
 }
 
+// migration173 adds the last-synced base buckets and field-level merge conflicts for cloud sync.
+func migration173(tx *sql.Tx) error {
+	if _, err := tx.Exec(`
+		CREATE TABLE IF NOT EXISTS cloud_sync_base (
+			bucket_key TEXT PRIMARY KEY,
+			revision_id TEXT NOT NULL,
+			payload TEXT NOT NULL,
+			updated_at TIMESTAMPTZ NOT NULL
+		)
+	`); err != nil {
+		return fmt.Errorf("failed to create cloud_sync_base table: %w", err)
+	}
+	if _, err := tx.Exec(`
+		CREATE TABLE IF NOT EXISTS cloud_sync_conflicts (
+			entity_type TEXT NOT NULL,
+			entity_id TEXT NOT NULL,
+			field TEXT NOT NULL,
+			base_value TEXT NOT NULL DEFAULT 'null',
+			local_value TEXT NOT NULL DEFAULT 'null',
+			remote_value TEXT NOT NULL DEFAULT 'null',
+			resolution TEXT NOT NULL,
+			detected_at TIMESTAMPTZ NOT NULL,
+			PRIMARY KEY (entity_type, entity_id, field)
+		)
+	`); err != nil {
+		return fmt.Errorf("failed to create cloud_sync_conflicts table: %w", err)
+	}
+	return nil
+}
+
+// migration174 为 sync_tombstones 增加 published_at，记录墓碑首次进入远端的时间，供墓碑压缩判断各设备是否已看到
+func migration174(tx *sql.Tx) error {
+	if _, err := tx.Exec(`
+		ALTER TABLE sync_tombstones
+		ADD COLUMN IF NOT EXISTS published_at TIMESTAMPTZ
+	`); err != nil {
+		return fmt.Errorf("failed to add published_at column to sync_tombstones: %w", err)
+	}
+	return nil
+}
+
+// migration175 新增 save_path_candidates，保存游玩期间探测到的候选存档路径（仅本机，不参与云同步）
+func migration175(tx *sql.Tx) error {
+	if _, err := tx.Exec(`
+		CREATE TABLE IF NOT EXISTS save_path_candidates (
+			game_id TEXT NOT NULL,
+			path TEXT NOT NULL,
+			confidence DOUBLE NOT NULL DEFAULT 0,
+			reasons TEXT NOT NULL DEFAULT '[]',
+			observed_sessions INTEGER NOT NULL DEFAULT 0,
+			dismissed BOOLEAN NOT NULL DEFAULT FALSE,
+			detected_at TIMESTAMPTZ NOT NULL,
+			PRIMARY KEY (game_id, path)
+		)
+	`); err != nil {
+		return fmt.Errorf("failed to create save_path_candidates table: %w", err)
+	}
+	return nil
+}
+
+// migration176 新增 remote_push_outbox：本地状态、评价变更先入队，由后台任务按提供方限速投递（仅本机，不参与云同步）
+func migration176(tx *sql.Tx) error {
+	if _, err := tx.Exec(`
+		CREATE TABLE IF NOT EXISTS remote_push_outbox (
+			id TEXT PRIMARY KEY,
+			provider TEXT NOT NULL,
+			kind TEXT NOT NULL,
+			game_id TEXT NOT NULL,
+			source_id TEXT NOT NULL,
+			revision INTEGER NOT NULL DEFAULT 1,
+			attempts INTEGER NOT NULL DEFAULT 0,
+			last_error TEXT NOT NULL DEFAULT '',
+			dead BOOLEAN NOT NULL DEFAULT FALSE,
+			next_attempt_at TIMESTAMPTZ NOT NULL,
+			created_at TIMESTAMPTZ NOT NULL,
+			updated_at TIMESTAMPTZ NOT NULL
+		)
+	`); err != nil {
+		return fmt.Errorf("failed to create remote_push_outbox table: %w", err)
+	}
+	return nil
+}
+
+// migration177 为 games 增加 status_updated_at，只记录游玩状态本身的变更时间，供远端收藏同步判断本地状态的新旧。
+// 已有游戏用 updated_at 回填（与此前的比较口径一致）。
+func migration177(tx *sql.Tx) error {
+	if _, err := tx.Exec(`
+		ALTER TABLE games
+		ADD COLUMN IF NOT EXISTS status_updated_at TIMESTAMPTZ
+	`); err != nil {
+		return fmt.Errorf("failed to add status_updated_at column to games: %w", err)
+	}
+	if _, err := tx.Exec(`
+		UPDATE games
+		SET status_updated_at = COALESCE(updated_at, created_at)
+		WHERE status_updated_at IS NULL
+	`); err != nil {
+		return fmt.Errorf("failed to backfill games status_updated_at: %w", err)
+	}
+	return nil
+}
+
 // 所有迁移按版本号顺序排列
 var migrations = []Migration{
 	{
@@ -917,6 +1019,31 @@ var migrations = []Migration{
 		Description: "Add user-authored game reviews",
 		Up:          migration172,
 	},
+	{
+		Version:     173,
+		Description: "Add cloud sync merge base and conflict tables",
+		Up:          migration173,
+	},
+	{
+		Version:     174,
+		Description: "Add published_at to sync tombstones for tombstone compaction",
+		Up:          migration174,
+	},
+	{
+		Version:     175,
+		Description: "Add save path candidates for automatic save discovery",
+		Up:          migration175,
+	},
+	{
+		Version:     176,
+		Description: "Add remote status push outbox",
+		Up:          migration176,
+	},
+	{
+		Version:     177,
+		Description: "Add status_updated_at to games for remote collection sync",
+		Up:          migration177,
+	},
 	// {
 	// 	Version:     114,
 	// 	Description: "Convert UTC timestamps to local time (+8 hours for historical data)",
@@ -46,6 +46,8 @@ type GameBackup struct {
 	Path      string    `json:"path"` // 备份文件路径（作为唯一标识）
 	Name      string    `json:"name"` // 文件名
 	GameID    string    `json:"game_id"`
-	Size      int64     `json:"size"`       // 备份文件大小（字节）
+	Size      int64     `json:"size"`       // 备份文件大小（字节）；快照为存档原始大小
 	CreatedAt time.Time `json:"created_at"` // 创建时间（来自文件修改时间）
+	// AddedSize 快照备份实际新增占用的空间（去重后），旧版 zip 备份为 0
+	AddedSize int64 `json:"added_size,omitempty"`
 }
@@ -3,9 +3,10 @@ package models
 import "time"
 
 type SyncTombstone struct {
-	EntityType  string    `json:"entity_type"`
-	EntityID    string    `json:"entity_id"`
-	ParentID    string    `json:"parent_id"`
-	SecondaryID string    `json:"secondary_id"`
-	DeletedAt   time.Time `json:"deleted_at"`
+	EntityType  string     `json:"entity_type"`
+	EntityID    string     `json:"entity_id"`
+	ParentID    string     `json:"parent_id"`
+	SecondaryID string     `json:"secondary_id"`
+	DeletedAt   time.Time  `json:"deleted_at"`
+	PublishedAt *time.Time `json:"published_at,omitempty"` // 首次同步到远端的时间，未同步时为空
 }
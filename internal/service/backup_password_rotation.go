@@ -0,0 +1,359 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"lunabox/internal/appconf"
+	"lunabox/internal/applog"
+	"lunabox/internal/common/vo"
+	"lunabox/internal/service/cloudprovider"
+	"lunabox/internal/utils"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ========== 备份密码轮换 ==========
+//
+// 轮换分两阶段：
+//  1. 复制校验：把旧命名空间下的每个对象用旧密钥解密、新密钥加密写到新命名空间，
+//     再读回比对明文 sha256；全部通过且重新列出的新命名空间包含每个旧对象后，才切换配置中的 BackupUserID。
+//  2. 清理：删除旧命名空间的对象。删除前再列一次旧目录，把切换前刚写入、尚未复制的对象补齐。
+//
+// 轮换状态（新旧身份与密钥）在开始前写入配置，任何一步中断都可以通过
+// ResumeCloudPasswordRotation 续传；已复制且校验一致的对象不会重复上传。
+// Umbra 的命名空间与 BackupUserID 无关，此时退化为原地重新加密，没有清理阶段。
+// 旧版（v1）身份的用户 ID 是密码的快速哈希，可以用相同的新旧密码轮换到 v2 身份。
+
+// rotationWorkState 是一次轮换运行中使用的 provider 与临时目录。
+type rotationWorkState struct {
+	state       appconf.BackupPasswordRotation
+	raw         cloudprovider.CloudStorageProvider
+	oldProvider cloudprovider.CloudStorageProvider
+	newProvider cloudprovider.CloudStorageProvider
+	inPlace     bool
+	tempDir     string
+	seq         int
+}
+
+// RotateCloudBackupPassword 用新密码替换备份密码，并把云端数据迁移到新密码对应的命名空间。
+// 存在未完成的轮换时，只接受与其相同的新密码，并从中断处继续。
+func (s *BackupService) RotateCloudBackupPassword(oldPassword, newPassword string) (vo.CloudPasswordRotationResult, error) {
+	if !s.rotationMu.TryLock() {
+		return vo.CloudPasswordRotationResult{}, fmt.Errorf("备份密码轮换正在进行中")
+	}
+	defer s.rotationMu.Unlock()
+
+	if strings.TrimSpace(s.config.BackupUserID) == "" {
+		return vo.CloudPasswordRotationResult{}, fmt.Errorf("备份密码未设置")
+	}
+	if newPassword == "" {
+		return vo.CloudPasswordRotationResult{}, fmt.Errorf("新备份密码不能为空")
+	}
+	// 新身份总是 v2；原身份可能是旧版（v1），新旧密码相同时即为迁移到 v2 身份
+	newUserID, newKey, err := utils.DeriveBackupCredentials(newPassword)
+	if err != nil {
+		return vo.CloudPasswordRotationResult{}, fmt.Errorf("生成加密密钥失败: %w", err)
+	}
+
+	if pending := s.config.BackupPasswordRotation; pending != nil {
+		_, oldOK, err := utils.ResolveBackupCredentials(oldPassword, pending.OldUserID)
+		if err != nil {
+			return vo.CloudPasswordRotationResult{}, fmt.Errorf("生成加密密钥失败: %w", err)
+		}
+		if !oldOK || newUserID != pending.NewUserID {
+			applog.LogWarningf(s.ctx, "RotateCloudBackupPassword: passwords do not match pending rotation")
+			return vo.CloudPasswordRotationResult{}, fmt.Errorf("存在未完成的密码轮换，请使用相同的新旧密码继续")
+		}
+		applog.LogInfof(s.ctx, "RotateCloudBackupPassword: resuming pending rotation")
+		return s.runPasswordRotation(*pending)
+	}
+
+	// 旧对象可能是加密前上传的明文，轮换按迁移流程读取并原样透传，因此总是按旧密码派生旧密钥
+	oldUserID := s.config.BackupUserID
+	oldKey, oldOK, err := utils.ResolveBackupCredentials(oldPassword, oldUserID)
+	if err != nil {
+		return vo.CloudPasswordRotationResult{}, fmt.Errorf("生成加密密钥失败: %w", err)
+	}
+	if !oldOK {
+		applog.LogWarningf(s.ctx, "RotateCloudBackupPassword: old password does not match backup user id")
+		return vo.CloudPasswordRotationResult{}, fmt.Errorf("原备份密码不正确")
+	}
+	if newUserID == oldUserID {
+		return vo.CloudPasswordRotationResult{}, fmt.Errorf("新密码不能与原密码相同")
+	}
+
+	state := appconf.BackupPasswordRotation{
+		OldUserID:        oldUserID,
+		OldEncryptionKey: oldKey,
+		NewUserID:        newUserID,
+		NewEncryptionKey: newKey,
+		StartedAt:        time.Now().Format(time.RFC3339),
+	}
+	s.config.BackupPasswordRotation = &state
+	if err := s.saveConfig(s.config); err != nil {
+		s.config.BackupPasswordRotation = nil
+		applog.LogErrorf(s.ctx, "RotateCloudBackupPassword: failed to save rotation state: %v", err)
+		return vo.CloudPasswordRotationResult{}, fmt.Errorf("保存配置失败: %w", err)
+	}
+
+	applog.LogInfof(s.ctx, "RotateCloudBackupPassword: rotation started")
+	return s.runPasswordRotation(state)
+}
+
+// ResumeCloudPasswordRotation 继续上次中断的备份密码轮换。
+func (s *BackupService) ResumeCloudPasswordRotation() (vo.CloudPasswordRotationResult, error) {
+	if !s.rotationMu.TryLock() {
+		return vo.CloudPasswordRotationResult{}, fmt.Errorf("备份密码轮换正在进行中")
+	}
+	defer s.rotationMu.Unlock()
+
+	pending := s.config.BackupPasswordRotation
+	if pending == nil {
+		return vo.CloudPasswordRotationResult{}, fmt.Errorf("没有未完成的密码轮换")
+	}
+	applog.LogInfof(s.ctx, "ResumeCloudPasswordRotation: resuming pending rotation")
+	return s.runPasswordRotation(*pending)
+}
+
+func (s *BackupService) runPasswordRotation(state appconf.BackupPasswordRotation) (vo.CloudPasswordRotationResult, error) {
+	result := vo.CloudPasswordRotationResult{UserID: state.NewUserID}
+
+	oldKey, err := utils.DecodeBackupEncryptionKey(state.OldEncryptionKey)
+	if err != nil {
+		return result, fmt.Errorf("轮换状态中的密钥无效: %w", err)
+	}
+	newKey, err := utils.DecodeBackupEncryptionKey(state.NewEncryptionKey)
+	if err != nil {
+		return result, fmt.Errorf("轮换状态中的密钥无效: %w", err)
+	}
+
+	provider, err := s.getCloudProvider()
+	if err != nil {
+		return result, err
+	}
+	raw := cloudprovider.Unwrap(provider)
+
+	tempDir, err := os.MkdirTemp("", "lunabox_rotate_*")
+	if err != nil {
+		return result, fmt.Errorf("创建临时目录失败: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	work := &rotationWorkState{
+		state:       state,
+		raw:         raw,
+		oldProvider: cloudprovider.NewMigrationProvider(raw, oldKey),
+		newProvider: cloudprovider.NewEncryptedProvider(raw, newKey),
+		inPlace:     raw.GetCloudPath(state.OldUserID, "") == raw.GetCloudPath(state.NewUserID, ""),
+		tempDir:     tempDir,
+	}
+
+	// 阶段一：复制校验，全部通过后才切换配置
+	if s.config.BackupUserID != state.NewUserID {
+		if err := s.copyRotationObjects(work, &result); err != nil {
+			return result, err
+		}
+
+		previousUserID, previousKey := s.config.BackupUserID, s.config.BackupEncryptionKey
+		s.config.BackupUserID = state.NewUserID
+		s.config.BackupEncryptionKey = state.NewEncryptionKey
+		if err := s.saveConfig(s.config); err != nil {
+			s.config.BackupUserID, s.config.BackupEncryptionKey = previousUserID, previousKey
+			applog.LogErrorf(s.ctx, "runPasswordRotation: failed to switch backup user id: %v", err)
+			return result, fmt.Errorf("保存配置失败: %w", err)
+		}
+		applog.LogInfof(s.ctx, "runPasswordRotation: switched to new backup user id, verified=%d", result.Verified)
+	}
+
+	// 阶段二：清理旧命名空间
+	if !work.inPlace {
+		if err := s.cleanupRotationSource(work, &result); err != nil {
+			return result, err
+		}
+	}
+
+	s.config.BackupPasswordRotation = nil
+	if err := s.saveConfig(s.config); err != nil {
+		applog.LogErrorf(s.ctx, "runPasswordRotation: failed to clear rotation state: %v", err)
+		return result, fmt.Errorf("保存配置失败: %w", err)
+	}
+
+	applog.LogInfof(s.ctx, "runPasswordRotation: rotation finished total=%d copied=%d verified=%d deleted=%d",
+		result.Total, result.Copied, result.Verified, result.Deleted)
+	return result, nil
+}
+
+// copyRotationObjects 复制并校验旧命名空间下的全部对象，重新列出新命名空间确认每个旧对象都已就位才返回成功。
+func (s *BackupService) copyRotationObjects(work *rotationWorkState, result *vo.CloudPasswordRotationResult) error {
+	sourceKeys, err := s.listCloudObjectKeys(work.raw, work.state.OldUserID)
+	if err != nil {
+		return err
+	}
+	existing, err := s.rotationTargetKeySet(work)
+	if err != nil {
+		return err
+	}
+
+	result.Total = len(sourceKeys)
+	for _, sourceKey := range sourceKeys {
+		targetKey, err := rotationTargetKey(work, sourceKey)
+		if err != nil {
+			return err
+		}
+		_, exists := existing[targetKey]
+		copied, err := s.copyRotationObject(work, sourceKey, targetKey, exists)
+		if err != nil {
+			applog.LogErrorf(s.ctx, "copyRotationObjects: failed to rotate %s: %v", sourceKey, err)
+			return fmt.Errorf("迁移 %s 失败: %w", sourceKey, err)
+		}
+		if copied {
+			result.Copied++
+		}
+		result.Verified++
+	}
+
+	// 逐个读回只能证明单个对象写入成功；再列一次新命名空间，确认旧对象在新目录中都能被找到，
+	// 否则切换身份后这些对象对同步、恢复和之后的清理都不可见
+	targetKeys, err := s.rotationTargetKeySet(work)
+	if err != nil {
+		return err
+	}
+	var missing []string
+	for _, sourceKey := range sourceKeys {
+		targetKey, err := rotationTargetKey(work, sourceKey)
+		if err != nil {
+			return err
+		}
+		if _, ok := targetKeys[targetKey]; !ok {
+			missing = append(missing, targetKey)
+		}
+	}
+	if len(missing) > 0 {
+		applog.LogErrorf(s.ctx, "copyRotationObjects: %d objects missing from target listing, first: %s", len(missing), missing[0])
+		return fmt.Errorf("校验失败：新目录中缺少 %d 个对象（如 %s）", len(missing), missing[0])
+	}
+	return nil
+}
+
+// cleanupRotationSource 删除旧命名空间下的对象；新命名空间缺失的对象先补齐再删除。
+func (s *BackupService) cleanupRotationSource(work *rotationWorkState, result *vo.CloudPasswordRotationResult) error {
+	sourceKeys, err := s.listCloudObjectKeys(work.raw, work.state.OldUserID)
+	if err != nil {
+		return err
+	}
+	existing, err := s.rotationTargetKeySet(work)
+	if err != nil {
+		return err
+	}
+	if result.Total == 0 {
+		result.Total = len(sourceKeys)
+	}
+
+	for _, sourceKey := range sourceKeys {
+		targetKey, err := rotationTargetKey(work, sourceKey)
+		if err != nil {
+			return err
+		}
+		if _, ok := existing[targetKey]; !ok {
+			if _, err := s.copyRotationObject(work, sourceKey, targetKey, false); err != nil {
+				return fmt.Errorf("迁移 %s 失败: %w", sourceKey, err)
+			}
+			result.Copied++
+			result.Verified++
+		}
+		if err := work.raw.DeleteObject(s.ctx, sourceKey); err != nil {
+			applog.LogWarningf(s.ctx, "cleanupRotationSource: failed to delete %s: %v", sourceKey, err)
+			return fmt.Errorf("删除旧对象 %s 失败: %w", sourceKey, err)
+		}
+		result.Deleted++
+	}
+	return nil
+}
+
+// copyRotationObject 把单个对象迁移到新命名空间并读回校验，返回本次是否实际上传。
+// 目标已存在且明文哈希一致时视为上次中断前已完成。
+func (s *BackupService) copyRotationObject(work *rotationWorkState, sourceKey, targetKey string, targetExists bool) (bool, error) {
+	work.seq++
+	sourcePath := filepath.Join(work.tempDir, fmt.Sprintf("source_%d", work.seq))
+	targetPath := filepath.Join(work.tempDir, fmt.Sprintf("target_%d", work.seq))
+	defer os.Remove(sourcePath)
+	defer os.Remove(targetPath)
+
+	if err := work.oldProvider.DownloadFile(s.ctx, sourceKey, sourcePath); err != nil {
+		// 原地轮换时，已经用新密钥重新加密的对象无法再用旧密钥解开
+		if work.inPlace && errors.Is(err, utils.ErrBackupKeyMismatch) {
+			if err := work.newProvider.DownloadFile(s.ctx, targetKey, targetPath); err != nil {
+				return false, err
+			}
+			return false, nil
+		}
+		return false, err
+	}
+	sourceHash, err := sha256File(sourcePath)
+	if err != nil {
+		return false, err
+	}
+
+	if targetExists && !work.inPlace {
+		if err := work.newProvider.DownloadFile(s.ctx, targetKey, targetPath); err == nil {
+			if targetHash, err := sha256File(targetPath); err == nil && targetHash == sourceHash {
+				return false, nil
+			}
+		}
+	}
+
+	if err := work.newProvider.UploadFile(s.ctx, targetKey, sourcePath); err != nil {
+		return false, err
+	}
+	if err := work.newProvider.DownloadFile(s.ctx, targetKey, targetPath); err != nil {
+		return true, fmt.Errorf("读回校验失败: %w", err)
+	}
+	targetHash, err := sha256File(targetPath)
+	if err != nil {
+		return true, err
+	}
+	if targetHash != sourceHash {
+		return true, fmt.Errorf("校验失败：内容哈希不一致")
+	}
+	return true, nil
+}
+
+func (s *BackupService) rotationTargetKeySet(work *rotationWorkState) (map[string]struct{}, error) {
+	keys, err := s.listCloudObjectKeys(work.raw, work.state.NewUserID)
+	if err != nil {
+		return nil, err
+	}
+	set := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		set[key] = struct{}{}
+	}
+	return set, nil
+}
+
+// rotationTargetKey 把旧命名空间下的 key 映射到新命名空间下的同名 key。
+func rotationTargetKey(work *rotationWorkState, sourceKey string) (string, error) {
+	oldPrefix := work.raw.GetCloudPath(work.state.OldUserID, "")
+	if !strings.HasPrefix(sourceKey, oldPrefix) {
+		return "", fmt.Errorf("对象 %s 不在旧命名空间内", sourceKey)
+	}
+	return work.raw.GetCloudPath(work.state.NewUserID, strings.TrimPrefix(sourceKey, oldPrefix)), nil
+}
+
+func sha256File(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
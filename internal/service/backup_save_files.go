@@ -0,0 +1,441 @@
+package service
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"lunabox/internal/common/vo"
+	"lunabox/internal/service/savestore"
+	"lunabox/internal/utils/apputils"
+)
+
+const (
+	saveFileAdded     = "added"
+	saveFileRemoved   = "removed"
+	saveFileModified  = "modified"
+	saveFileUnchanged = "unchanged"
+)
+
+// saveBackupContent 是一个本地存档备份（快照或旧版 zip）的文件视图
+type saveBackupContent struct {
+	gameID     string
+	entries    []vo.SaveFileEntry
+	singleFile bool
+	// stage 把选中的文件按相对路径还原到 dir 下
+	stage func(dir string, paths []string) error
+}
+
+// openSaveBackup 读取本地备份的文件列表；调用方需持有 saveStoreMu
+func (s *BackupService) openSaveBackup(backupPath string) (*saveBackupContent, error) {
+	backupDir, err := s.GetBackupDir()
+	if err != nil {
+		return nil, err
+	}
+	if !isPathWithinBase(backupDir, backupPath) {
+		return nil, fmt.Errorf("无效的备份路径")
+	}
+	relPath, _ := filepath.Rel(backupDir, backupPath)
+	parts := strings.Split(relPath, string(filepath.Separator))
+	if len(parts) < 2 {
+		return nil, fmt.Errorf("无效的备份路径格式")
+	}
+	if _, err := os.Stat(backupPath); os.IsNotExist(err) {
+		return nil, fmt.Errorf("备份文件不存在: %s", backupPath)
+	}
+
+	store, snapshot, isSnapshot, err := s.localSnapshotOf(backupPath)
+	if err != nil {
+		return nil, fmt.Errorf("读取快照失败: %w", err)
+	}
+	if isSnapshot {
+		content, err := snapshotSaveContent(store, snapshot)
+		if err != nil {
+			return nil, err
+		}
+		content.gameID = parts[0]
+		return content, nil
+	}
+	content, err := zipSaveContent(backupPath)
+	if err != nil {
+		return nil, err
+	}
+	content.gameID = parts[0]
+	return content, nil
+}
+
+func snapshotSaveContent(store *savestore.Store, snapshot savestore.Snapshot) (*saveBackupContent, error) {
+	entries := make([]vo.SaveFileEntry, 0, len(snapshot.Files))
+	for _, file := range snapshot.Files {
+		hash, err := store.FileHash(file)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, vo.SaveFileEntry{Path: file.Path, Size: file.Size, ModTime: file.ModTime, Hash: hash})
+	}
+	return &saveBackupContent{
+		entries:    entries,
+		singleFile: snapshot.SingleFile,
+		stage: func(dir string, paths []string) error {
+			return store.RestoreFiles(snapshot, dir, paths)
+		},
+	}, nil
+}
+
+func zipSaveContent(zipPath string) (*saveBackupContent, error) {
+	reader, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return nil, fmt.Errorf("打开备份失败: %w", err)
+	}
+	defer reader.Close()
+
+	var entries []vo.SaveFileEntry
+	hasDir := false
+	for _, f := range reader.File {
+		if f.FileInfo().IsDir() {
+			hasDir = true
+			continue
+		}
+		name, ok := cleanZipEntryName(f.Name)
+		if !ok {
+			return nil, fmt.Errorf("备份中的文件路径无效: %s", f.Name)
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("读取备份失败: %w", err)
+		}
+		hash, err := hashReader(rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("读取备份失败: %w", err)
+		}
+		entries = append(entries, vo.SaveFileEntry{Path: name, Size: int64(f.UncompressedSize64), ModTime: f.Modified, Hash: hash})
+	}
+
+	return &saveBackupContent{
+		entries: entries,
+		// 与 RestoreBackup 的判断一致：只有一个顶层文件时视为单文件存档
+		singleFile: !hasDir && len(entries) == 1 && !strings.Contains(entries[0].Path, "/"),
+		stage: func(dir string, paths []string) error {
+			return extractZipSaveFiles(zipPath, dir, paths)
+		},
+	}, nil
+}
+
+func cleanZipEntryName(name string) (string, bool) {
+	name = strings.ReplaceAll(name, "\\", "/")
+	cleaned := path.Clean(strings.TrimPrefix(name, "/"))
+	if cleaned == "." || cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", false
+	}
+	return cleaned, true
+}
+
+func extractZipSaveFiles(zipPath, dir string, paths []string) error {
+	reader, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return fmt.Errorf("打开备份失败: %w", err)
+	}
+	defer reader.Close()
+
+	wanted := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		wanted[p] = false
+	}
+	for _, f := range reader.File {
+		if f.FileInfo().IsDir() {
+			continue
+		}
+		name, ok := cleanZipEntryName(f.Name)
+		if !ok {
+			continue
+		}
+		if _, ok := wanted[name]; !ok {
+			continue
+		}
+		dest := filepath.Join(dir, filepath.FromSlash(name))
+		if !isPathWithinBase(dir, dest) {
+			return fmt.Errorf("非法的文件路径: %s", name)
+		}
+		if err := extractZipEntry(f, dest); err != nil {
+			return fmt.Errorf("解压 %s 失败: %w", name, err)
+		}
+		wanted[name] = true
+	}
+	for p, found := range wanted {
+		if !found {
+			return fmt.Errorf("备份中不存在文件: %s", p)
+		}
+	}
+	return nil
+}
+
+func extractZipEntry(f *zip.File, dest string) error {
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+	src, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	out, err := os.OpenFile(dest, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, src); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	if !f.Modified.IsZero() {
+		_ = os.Chtimes(dest, f.Modified, f.Modified)
+	}
+	return nil
+}
+
+// listCurrentSaveFiles 列出当前存档的文件；存档路径不存在时返回空列表
+func listCurrentSaveFiles(savePath string) ([]vo.SaveFileEntry, error) {
+	info, err := os.Stat(savePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []vo.SaveFileEntry{}, nil
+		}
+		return nil, fmt.Errorf("读取存档路径失败: %w", err)
+	}
+	if !info.IsDir() {
+		hash, err := hashFile(savePath)
+		if err != nil {
+			return nil, err
+		}
+		return []vo.SaveFileEntry{{Path: filepath.Base(savePath), Size: info.Size(), ModTime: info.ModTime(), Hash: hash}}, nil
+	}
+
+	var entries []vo.SaveFileEntry
+	err = filepath.WalkDir(savePath, func(p string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			return walkErr
+		}
+		// 与备份一致，只比较普通文件
+		if d.IsDir() || !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(savePath, p)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		hash, err := hashFile(p)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, vo.SaveFileEntry{Path: filepath.ToSlash(rel), Size: info.Size(), ModTime: info.ModTime(), Hash: hash})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("读取存档失败: %w", err)
+	}
+	return entries, nil
+}
+
+func hashFile(p string) (string, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	return hashReader(f)
+}
+
+func hashReader(r io.Reader) (string, error) {
+	h := sha256.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func sortSaveFileEntries(entries []vo.SaveFileEntry) {
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Path < entries[j].Path })
+}
+
+// diffSaveFiles 按路径比较两组文件，内容哈希不同即视为修改（只有修改时间不同不算）
+func diffSaveFiles(from, to []vo.SaveFileEntry) vo.SaveBackupDiff {
+	toByPath := make(map[string]vo.SaveFileEntry, len(to))
+	for _, entry := range to {
+		toByPath[entry.Path] = entry
+	}
+	diff := vo.SaveBackupDiff{Files: []vo.SaveFileDiff{}}
+	for i := range from {
+		old := from[i]
+		item := vo.SaveFileDiff{Path: old.Path, From: &old}
+		if current, ok := toByPath[old.Path]; ok {
+			item.To = &current
+			delete(toByPath, old.Path)
+			if current.Hash == old.Hash {
+				item.Change = saveFileUnchanged
+				diff.Unchanged++
+			} else {
+				item.Change = saveFileModified
+				diff.Modified++
+			}
+		} else {
+			item.Change = saveFileRemoved
+			diff.Removed++
+		}
+		diff.Files = append(diff.Files, item)
+	}
+	for _, entry := range to {
+		if _, ok := toByPath[entry.Path]; !ok {
+			continue
+		}
+		added := entry
+		diff.Files = append(diff.Files, vo.SaveFileDiff{Path: added.Path, Change: saveFileAdded, To: &added})
+		diff.Added++
+	}
+	sort.Slice(diff.Files, func(i, j int) bool { return diff.Files[i].Path < diff.Files[j].Path })
+	return diff
+}
+
+// ListBackupFiles 列出本地存档备份（快照或 zip）中的文件
+func (s *BackupService) ListBackupFiles(backupPath string) ([]vo.SaveFileEntry, error) {
+	s.saveStoreMu.Lock()
+	defer s.saveStoreMu.Unlock()
+	content, err := s.openSaveBackup(backupPath)
+	if err != nil {
+		return nil, err
+	}
+	sortSaveFileEntries(content.entries)
+	return content.entries, nil
+}
+
+// DiffBackups 比较两个本地存档备份：以 fromPath 为基准，列出 toPath 中新增、删除与修改的文件
+func (s *BackupService) DiffBackups(fromPath, toPath string) (vo.SaveBackupDiff, error) {
+	s.saveStoreMu.Lock()
+	defer s.saveStoreMu.Unlock()
+	from, err := s.openSaveBackup(fromPath)
+	if err != nil {
+		return vo.SaveBackupDiff{}, err
+	}
+	to, err := s.openSaveBackup(toPath)
+	if err != nil {
+		return vo.SaveBackupDiff{}, err
+	}
+	diff := diffSaveFiles(from.entries, to.entries)
+	diff.FromPath, diff.ToPath = fromPath, toPath
+	return diff, nil
+}
+
+// DiffBackupWithCurrentSave 比较本地存档备份与当前存档：以备份为基准，列出当前存档中新增、删除与修改的文件
+func (s *BackupService) DiffBackupWithCurrentSave(backupPath string) (vo.SaveBackupDiff, error) {
+	s.saveStoreMu.Lock()
+	defer s.saveStoreMu.Unlock()
+	content, err := s.openSaveBackup(backupPath)
+	if err != nil {
+		return vo.SaveBackupDiff{}, err
+	}
+	savePath, err := s.gameSavePath(content.gameID)
+	if err != nil {
+		return vo.SaveBackupDiff{}, err
+	}
+	current, err := listCurrentSaveFiles(savePath)
+	if err != nil {
+		return vo.SaveBackupDiff{}, err
+	}
+	diff := diffSaveFiles(content.entries, current)
+	diff.FromPath = backupPath
+	return diff, nil
+}
+
+// RestoreBackupFiles 只把备份中选中的文件恢复到当前存档，其余文件保持不变。
+// 选中的文件先全部还原到临时目录并校验，成功后打包当前存档作为恢复前备份，再逐个覆盖。
+func (s *BackupService) RestoreBackupFiles(backupPath string, paths []string) error {
+	if len(paths) == 0 {
+		return fmt.Errorf("未选择要恢复的文件")
+	}
+	s.saveStoreMu.Lock()
+	defer s.saveStoreMu.Unlock()
+
+	content, err := s.openSaveBackup(backupPath)
+	if err != nil {
+		return err
+	}
+	savePath, err := s.gameSavePath(content.gameID)
+	if err != nil {
+		return err
+	}
+
+	selected := make([]string, 0, len(paths))
+	seen := make(map[string]struct{}, len(paths))
+	for _, p := range paths {
+		if _, ok := seen[p]; ok {
+			continue
+		}
+		seen[p] = struct{}{}
+		selected = append(selected, p)
+	}
+
+	backupDir, err := s.GetBackupDir()
+	if err != nil {
+		return err
+	}
+	gameBackupDir := filepath.Join(backupDir, content.gameID)
+	tempDir := filepath.Join(gameBackupDir, "temp_restore")
+	os.RemoveAll(tempDir)
+	if err := os.MkdirAll(tempDir, 0755); err != nil {
+		return fmt.Errorf("创建临时目录失败: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+	if err := content.stage(tempDir, selected); err != nil {
+		return fmt.Errorf("还原备份文件失败: %w", err)
+	}
+
+	if err := backupCurrentSave(gameBackupDir, savePath, "before_partial_restore"); err != nil {
+		return err
+	}
+
+	if content.singleFile {
+		if err := os.MkdirAll(filepath.Dir(savePath), 0755); err != nil {
+			return fmt.Errorf("创建父目录失败: %w", err)
+		}
+		if err := apputils.CopyFile(filepath.Join(tempDir, filepath.FromSlash(selected[0])), savePath); err != nil {
+			return fmt.Errorf("恢复文件失败: %w", err)
+		}
+		return nil
+	}
+	for _, p := range selected {
+		dest := filepath.Join(savePath, filepath.FromSlash(p))
+		if !isPathWithinBase(savePath, dest) {
+			return fmt.Errorf("非法的文件路径: %s", p)
+		}
+		if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+			return fmt.Errorf("创建目录失败: %w", err)
+		}
+		if err := apputils.CopyFile(filepath.Join(tempDir, filepath.FromSlash(p)), dest); err != nil {
+			return fmt.Errorf("恢复文件 %s 失败: %w", p, err)
+		}
+	}
+	return nil
+}
+
+func (s *BackupService) gameSavePath(gameID string) (string, error) {
+	var savePath string
+	err := s.db.QueryRowContext(s.ctx, "SELECT COALESCE(save_path, '') FROM games WHERE id = ?", gameID).Scan(&savePath)
+	if err != nil || savePath == "" {
+		return "", fmt.Errorf("存档路径未设置")
+	}
+	return savePath, nil
+}
@@ -0,0 +1,263 @@
+package service
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"lunabox/internal/applog"
+	"lunabox/internal/common/vo"
+	"lunabox/internal/models"
+	"lunabox/internal/service/cloudprovider"
+	umbraprovider "lunabox/internal/service/cloudprovider/umbra"
+	"lunabox/internal/service/savestore"
+	"lunabox/internal/utils/apputils"
+	"lunabox/internal/utils/archiveutils"
+)
+
+// 游戏存档备份以去重快照的形式保存在 backups/{gameID}/ 下（snapshots/ 清单 + blobs/ 数据块），
+// 云端镜像在 saves/{gameID}/snapshots 与 saves/{gameID}/blobs。
+// 旧版整包 zip 备份仍可列出、恢复与删除，新备份不再生成 zip。
+
+// saveStore 返回游戏的本地快照仓库
+func (s *BackupService) saveStore(gameID string) (*savestore.Store, error) {
+	if !isValidCloudPathSegment(gameID) {
+		return nil, fmt.Errorf("无效的游戏标识")
+	}
+	backupDir, err := s.GetBackupDir()
+	if err != nil {
+		return nil, err
+	}
+	return savestore.Open(filepath.Join(backupDir, gameID)), nil
+}
+
+// saveRemote 返回游戏存档在云端的快照仓库
+func (s *BackupService) saveRemote(provider cloudprovider.CloudStorageProvider, gameID string) savestore.Remote {
+	return savestore.NewRemote(provider, provider.GetCloudPath(s.config.BackupUserID, fmt.Sprintf("saves/%s/", gameID)))
+}
+
+// supportsSaveSnapshots 判断 provider 能否存放快照；Umbra 只接受固定形态的存档 zip
+func supportsSaveSnapshots(provider cloudprovider.CloudStorageProvider) bool {
+	_, isUmbra := cloudprovider.Unwrap(provider).(*umbraprovider.Provider)
+	return !isUmbra
+}
+
+func snapshotToGameBackup(store *savestore.Store, snapshot savestore.Snapshot) models.GameBackup {
+	return models.GameBackup{
+		Path:      store.SnapshotPath(snapshot.ID),
+		Name:      snapshot.ID,
+		GameID:    snapshot.GameID,
+		Size:      snapshot.Size,
+		AddedSize: snapshot.AddedSize,
+		CreatedAt: snapshot.CreatedAt,
+	}
+}
+
+// snapshotIDOf 判断备份路径是否为快照清单（backups/{gameID}/snapshots/{id}.json），是则返回所属仓库与快照 ID
+func (s *BackupService) snapshotIDOf(backupPath string) (*savestore.Store, string, bool) {
+	backupDir, err := s.GetBackupDir()
+	if err != nil {
+		return nil, "", false
+	}
+	relPath, err := filepath.Rel(backupDir, backupPath)
+	if err != nil {
+		return nil, "", false
+	}
+	parts := strings.Split(relPath, string(filepath.Separator))
+	if len(parts) != 3 {
+		return nil, "", false
+	}
+	store, err := s.saveStore(parts[0])
+	if err != nil {
+		return nil, "", false
+	}
+	id, ok := store.IsSnapshotPath(backupPath)
+	return store, id, ok
+}
+
+// localSnapshotOf 判断备份路径是否为快照清单，是则读取快照
+func (s *BackupService) localSnapshotOf(backupPath string) (*savestore.Store, savestore.Snapshot, bool, error) {
+	store, id, ok := s.snapshotIDOf(backupPath)
+	if !ok {
+		return nil, savestore.Snapshot{}, false, nil
+	}
+	snapshot, err := store.Load(id)
+	if err != nil {
+		return nil, savestore.Snapshot{}, true, err
+	}
+	return store, snapshot, true, nil
+}
+
+// gcLocalSaveStore 回收本地仓库中不再被引用的数据块；失败只记录日志，下次清理时会重试
+func (s *BackupService) gcLocalSaveStore(store *savestore.Store) {
+	result, err := store.GC()
+	if err != nil {
+		applog.LogWarningf(s.ctx, "SaveBackup: gc failed for %s: %v", store.Root(), err)
+		return
+	}
+	if result.RemovedBlobs > 0 {
+		applog.LogInfof(s.ctx, "SaveBackup: gc removed %d blobs (%d bytes) from %s", result.RemovedBlobs, result.FreedBytes, store.Root())
+	}
+}
+
+// restoreSnapshotToSavePath 用快照替换当前存档。
+// 快照先完整还原到临时目录并逐块校验，成功后才备份并删除现有存档，避免恢复失败时存档已被删掉。
+func (s *BackupService) restoreSnapshotToSavePath(gameID string, store *savestore.Store, snapshot savestore.Snapshot, preRestoreSuffix string) error {
+	savePath, err := s.gameSavePath(gameID)
+	if err != nil {
+		return err
+	}
+
+	tempDir := filepath.Join(store.Root(), "temp_restore")
+	os.RemoveAll(tempDir)
+	if err := os.MkdirAll(tempDir, 0755); err != nil {
+		return fmt.Errorf("创建临时目录失败: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	staged := filepath.Join(tempDir, "save")
+	if err := store.Restore(snapshot, staged); err != nil {
+		return fmt.Errorf("还原快照失败: %w", err)
+	}
+
+	if err := backupCurrentSave(store.Root(), savePath, preRestoreSuffix); err != nil {
+		return err
+	}
+
+	if err := os.RemoveAll(savePath); err != nil {
+		return fmt.Errorf("删除原存档失败: %w", err)
+	}
+	if snapshot.SingleFile {
+		if err := os.MkdirAll(filepath.Dir(savePath), 0755); err != nil {
+			return fmt.Errorf("创建父目录失败: %w", err)
+		}
+		if err := apputils.CopyFile(staged, savePath); err != nil {
+			return fmt.Errorf("恢复文件失败: %w", err)
+		}
+		return nil
+	}
+	if err := os.MkdirAll(savePath, 0755); err != nil {
+		return fmt.Errorf("创建存档目录失败: %w", err)
+	}
+	if err := apputils.CopyDir(staged, savePath); err != nil {
+		return fmt.Errorf("恢复目录失败: %w", err)
+	}
+	return nil
+}
+
+// backupCurrentSave 恢复前把当前存档打包到 {gameBackupDir}/pre_restore；存档不存在时跳过。
+// 同一秒内多次恢复时追加序号，不覆盖之前的恢复前备份。
+func backupCurrentSave(gameBackupDir, savePath, suffix string) error {
+	if _, err := os.Stat(savePath); err != nil {
+		return nil
+	}
+	preRestoreDir := filepath.Join(gameBackupDir, "pre_restore")
+	os.MkdirAll(preRestoreDir, 0755)
+	base := fmt.Sprintf("%s_%s", time.Now().Format(savestore.IDLayout), suffix)
+	preRestorePath := filepath.Join(preRestoreDir, base+".zip")
+	for i := 2; ; i++ {
+		if _, err := os.Stat(preRestorePath); os.IsNotExist(err) {
+			break
+		}
+		preRestorePath = filepath.Join(preRestoreDir, fmt.Sprintf("%s_%d.zip", base, i))
+	}
+	_, err := archiveutils.ZipFileOrDirectory(savePath, preRestorePath)
+	return err
+}
+
+// exportSnapshotZip 把快照打包为旧版 zip 格式，用于只接受 zip 的云存储
+func exportSnapshotZip(store *savestore.Store, snapshot savestore.Snapshot) (string, func(), error) {
+	tempDir, err := os.MkdirTemp("", "lunabox_save_export_*")
+	if err != nil {
+		return "", nil, err
+	}
+	cleanup := func() { os.RemoveAll(tempDir) }
+	staged := filepath.Join(tempDir, "save")
+	if snapshot.SingleFile {
+		staged = filepath.Join(tempDir, snapshot.Files[0].Path)
+	}
+	if err := store.Restore(snapshot, staged); err != nil {
+		cleanup()
+		return "", nil, fmt.Errorf("还原快照失败: %w", err)
+	}
+	zipPath := filepath.Join(tempDir, snapshot.ID+".zip")
+	if _, err := archiveutils.ZipFileOrDirectory(staged, zipPath); err != nil {
+		cleanup()
+		return "", nil, err
+	}
+	return zipPath, cleanup, nil
+}
+
+// uploadSnapshotToCloud 把本地快照推送到云端，只上传云端缺少的数据块
+func (s *BackupService) uploadSnapshotToCloud(provider cloudprovider.CloudStorageProvider, gameID string, store *savestore.Store, snapshot savestore.Snapshot) error {
+	if !supportsSaveSnapshots(provider) {
+		zipPath, cleanup, err := exportSnapshotZip(store, snapshot)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+		return s.uploadGameBackupZip(provider, gameID, zipPath)
+	}
+	uploaded, err := s.saveRemote(provider, gameID).Push(s.ctx, store, snapshot)
+	if err != nil {
+		return fmt.Errorf("上传失败: %w", err)
+	}
+	applog.LogInfof(s.ctx, "SaveBackup: snapshot %s uploaded for game %s, new blobs=%d", snapshot.ID, gameID, uploaded)
+	return nil
+}
+
+// listCloudSnapshotItems 列出云端的存档快照
+func (s *BackupService) listCloudSnapshotItems(provider cloudprovider.CloudStorageProvider, gameID string) ([]vo.CloudBackupItem, error) {
+	if !supportsSaveSnapshots(provider) {
+		return nil, nil
+	}
+	remote := s.saveRemote(provider, gameID)
+	keys, err := remote.ListSnapshots(s.ctx)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]vo.CloudBackupItem, 0, len(keys))
+	for _, key := range keys {
+		id, _ := savestore.SnapshotIDFromKey(key)
+		createdAt, _ := savestore.ParseIDTime(id)
+		items = append(items, vo.CloudBackupItem{Key: key, Name: id, CreatedAt: createdAt})
+	}
+	return items, nil
+}
+
+// isCloudSnapshotKey 判断云端 key 是否为存档快照清单
+func isCloudSnapshotKey(cloudKey string) bool {
+	_, ok := savestore.SnapshotIDFromKey(cloudKey)
+	return ok
+}
+
+// fetchCloudSnapshot 下载云端快照；本地仓库已有的数据块不会重复下载。
+// 下载的数据块在被本地快照引用前可能被 GC 回收，调用方需持有 saveStoreMu 直到用完快照。
+func (s *BackupService) fetchCloudSnapshot(provider cloudprovider.CloudStorageProvider, gameID, cloudKey string) (*savestore.Store, savestore.Snapshot, error) {
+	store, err := s.saveStore(gameID)
+	if err != nil {
+		return nil, savestore.Snapshot{}, err
+	}
+	snapshot, err := s.saveRemote(provider, gameID).Fetch(s.ctx, cloudKey, store)
+	if err != nil {
+		return nil, savestore.Snapshot{}, fmt.Errorf("下载失败: %w", err)
+	}
+	if snapshot.GameID != "" && snapshot.GameID != gameID {
+		return nil, savestore.Snapshot{}, fmt.Errorf("云端快照不属于当前游戏")
+	}
+	return store, snapshot, nil
+}
+
+// gcCloudSaveStore 回收云端不再被引用的数据块；失败只记录日志
+func (s *BackupService) gcCloudSaveStore(provider cloudprovider.CloudStorageProvider, gameID string) {
+	removed, err := s.saveRemote(provider, gameID).GC(s.ctx)
+	if err != nil {
+		applog.LogWarningf(s.ctx, "SaveBackup: cloud gc failed for game %s: %v", gameID, err)
+		return
+	}
+	if removed > 0 {
+		applog.LogInfof(s.ctx, "SaveBackup: cloud gc removed %d blobs for game %s", removed, gameID)
+	}
+}
@@ -13,12 +13,15 @@ import (
 	"lunabox/internal/service/cloudprovider"
 	"lunabox/internal/service/cloudprovider/onedrive"
 	umbraprovider "lunabox/internal/service/cloudprovider/umbra"
+	"lunabox/internal/service/cloudsync"
 	"lunabox/internal/service/importer"
+	"lunabox/internal/service/savestore"
 	"lunabox/internal/utils"
 	"lunabox/internal/utils/apputils"
 	"lunabox/internal/utils/archiveutils"
 	"lunabox/internal/version"
 	"os"
+	"path"
 	"path/filepath"
 	"regexp"
 	"sort"
@@ -35,6 +38,12 @@ type BackupService struct {
 	config  *appconf.AppConfig
 	runtime wailsruntime.Runtime
 
+	saveConfig       func(*appconf.AppConfig) error
+	newCloudProvider func(context.Context, *appconf.AppConfig) (cloudprovider.CloudStorageProvider, error)
+	rotationMu       sync.Mutex
+	// saveStoreMu 串行化存档快照仓库的写入与 GC，避免 GC 删掉正在写入、尚未被清单引用的块
+	saveStoreMu sync.Mutex
+
 	umbraAuthMu      sync.Mutex
 	umbraAuthSession *umbraAuthSession
 
@@ -63,7 +72,11 @@ func importDuckDBDatabase(db *sql.DB, importDir string) error {
 }
 
 func NewBackupService() *BackupService {
-	return &BackupService{runtime: wailsruntime.Unavailable()}
+	return &BackupService{
+		runtime:          wailsruntime.Unavailable(),
+		saveConfig:       appconf.SaveConfig,
+		newCloudProvider: cloudprovider.NewCloudProvider,
+	}
 }
 
 //wails:ignore
@@ -86,9 +99,27 @@ func ConfigureBackupServiceQuitSyncDBBackupHooks(s *BackupService, onStart func(
 	s.onQuitSyncDBBackupFinish = onFinish
 }
 
+// SetConfigSaverForTest 替换配置持久化函数，供服务测试隔离真实配置文件。
+//
+//wails:ignore
+func (s *BackupService) SetConfigSaverForTest(save func(*appconf.AppConfig) error) {
+	if save != nil {
+		s.saveConfig = save
+	}
+}
+
+// SetCloudProviderFactoryForTest 替换云存储 provider 的构造函数，供服务测试注入内存 provider。
+//
+//wails:ignore
+func (s *BackupService) SetCloudProviderFactoryForTest(factory func(context.Context, *appconf.AppConfig) (cloudprovider.CloudStorageProvider, error)) {
+	if factory != nil {
+		s.newCloudProvider = factory
+	}
+}
+
 // getCloudProvider 获取云备份提供商
 func (s *BackupService) getCloudProvider() (cloudprovider.CloudStorageProvider, error) {
-	return cloudprovider.NewCloudProvider(s.ctx, s.config)
+	return s.newCloudProvider(s.ctx, s.config)
 }
 
 func isPathWithinBase(basePath, targetPath string) bool {
@@ -128,7 +159,7 @@ func (s *BackupService) validateGameCloudKey(provider cloudprovider.CloudStorage
 	if !isValidCloudPathSegment(gameID) {
 		return fmt.Errorf("无效的游戏标识")
 	}
-	if cloudKey == "" || !strings.HasSuffix(strings.ToLower(cloudKey), ".zip") {
+	if cloudKey == "" || (!strings.HasSuffix(strings.ToLower(cloudKey), ".zip") && !isCloudSnapshotKey(cloudKey)) {
 		return fmt.Errorf("无效的云端备份 key")
 	}
 
@@ -193,12 +224,12 @@ func (s *BackupService) SelectBackupRestorePath() (string, error) {
 
 // ========== 云备份配置相关方法 ==========
 
-// SetupCloudBackup 设置云备份密码（只能设置一次）
+// SetupCloudBackup 设置云备份密码（只能设置一次，修改请使用 RotateCloudBackupPassword）
 func (s *BackupService) SetupCloudBackup(password string) (string, error) {
 	// 检查是否已经设置过密码
 	if s.config.BackupUserID != "" {
 		applog.LogWarningf(s.ctx, "SetupCloudBackup: backup password already set")
-		return "", fmt.Errorf("备份密码已设置，无法修改")
+		return "", fmt.Errorf("备份密码已设置，如需修改请使用修改密码功能")
 	}
 
 	if password == "" {
@@ -206,14 +237,19 @@ func (s *BackupService) SetupCloudBackup(password string) (string, error) {
 		return "", fmt.Errorf("备份密码不能为空")
 	}
 
-	// 生成用户ID
-	userID := utils.GenerateUserID(password)
+	// 生成用户ID与端到端加密密钥
+	userID, encryptionKey, err := utils.DeriveBackupCredentials(password)
+	if err != nil {
+		applog.LogErrorf(s.ctx, "SetupCloudBackup: failed to derive encryption key: %v", err)
+		return "", fmt.Errorf("生成加密密钥失败: %w", err)
+	}
 
 	// 更新配置
 	s.config.BackupUserID = userID
+	s.config.BackupEncryptionKey = encryptionKey
 
 	// 立即保存配置到文件
-	if err := appconf.SaveConfig(s.config); err != nil {
+	if err := s.saveConfig(s.config); err != nil {
 		applog.LogErrorf(s.ctx, "SetupCloudBackup: failed to save config: %v", err)
 		return "", fmt.Errorf("保存配置失败: %w", err)
 	}
@@ -222,6 +258,139 @@ func (s *BackupService) SetupCloudBackup(password string) (string, error) {
 	return userID, nil
 }
 
+// EnableCloudEncryption 为加密功能上线前设置的备份密码补充派生加密密钥。
+// 密码必须与当前 BackupUserID 对应，否则会把数据加密到别人无法解开的密钥下。
+func (s *BackupService) EnableCloudEncryption(password string) error {
+	if s.config.BackupUserID == "" {
+		return fmt.Errorf("备份密码未设置")
+	}
+	encryptionKey, ok, err := utils.ResolveBackupCredentials(password, s.config.BackupUserID)
+	if err != nil {
+		return fmt.Errorf("生成加密密钥失败: %w", err)
+	}
+	if !ok {
+		applog.LogWarningf(s.ctx, "EnableCloudEncryption: password does not match backup user id")
+		return fmt.Errorf("备份密码不正确")
+	}
+	s.config.BackupEncryptionKey = encryptionKey
+	if err := s.saveConfig(s.config); err != nil {
+		applog.LogErrorf(s.ctx, "EnableCloudEncryption: failed to save config: %v", err)
+		return fmt.Errorf("保存配置失败: %w", err)
+	}
+
+	applog.LogInfof(s.ctx, "EnableCloudEncryption: cloud encryption enabled")
+	return nil
+}
+
+// MigrateCloudObjectsToEncrypted 把云端遗留的明文对象重新加密上传（原 key 覆盖写入）。
+// 已是加密信封的对象直接跳过，因此可以重复执行。
+func (s *BackupService) MigrateCloudObjectsToEncrypted() (vo.CloudEncryptionMigrationResult, error) {
+	result := vo.CloudEncryptionMigrationResult{FailedKeys: []string{}}
+	if !cloudprovider.HasRequiredBackupUserID(s.config) {
+		return result, fmt.Errorf("备份用户 ID 未设置")
+	}
+	if strings.TrimSpace(s.config.BackupEncryptionKey) == "" {
+		return result, fmt.Errorf("请先设置备份密码以启用加密")
+	}
+
+	provider, err := s.getCloudProvider()
+	if err != nil {
+		return result, err
+	}
+	keys, err := s.listCloudObjectKeys(provider, s.config.BackupUserID)
+	if err != nil {
+		return result, err
+	}
+
+	tempDir, err := os.MkdirTemp("", "lunabox_encrypt_migrate_*")
+	if err != nil {
+		return result, fmt.Errorf("创建临时目录失败: %w", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	rawProvider := cloudprovider.Unwrap(provider)
+	for i, key := range keys {
+		result.Total++
+		localPath := filepath.Join(tempDir, fmt.Sprintf("object_%d", i))
+		encrypted, err := s.migrateCloudObject(provider, rawProvider, key, localPath)
+		_ = os.Remove(localPath)
+		switch {
+		case err != nil:
+			applog.LogWarningf(s.ctx, "MigrateCloudObjectsToEncrypted: failed to migrate %s: %v", key, err)
+			result.Failed++
+			result.FailedKeys = append(result.FailedKeys, key)
+		case encrypted:
+			result.AlreadyEncrypted++
+		default:
+			result.Migrated++
+		}
+	}
+
+	applog.LogInfof(s.ctx, "MigrateCloudObjectsToEncrypted: total=%d migrated=%d already=%d failed=%d",
+		result.Total, result.Migrated, result.AlreadyEncrypted, result.Failed)
+	return result, nil
+}
+
+func (s *BackupService) migrateCloudObject(provider, rawProvider cloudprovider.CloudStorageProvider, key, localPath string) (bool, error) {
+	if err := rawProvider.DownloadFile(s.ctx, key, localPath); err != nil {
+		return false, err
+	}
+	encrypted, err := utils.IsBackupEnvelopeFile(localPath)
+	if err != nil || encrypted {
+		return encrypted, err
+	}
+	return false, provider.UploadFile(s.ctx, key, localPath)
+}
+
+// listCloudObjectKeys 列出用户命名空间下全部已知目录中的对象：
+// 数据库备份、存档、云同步 library、封面与历史版本目录。
+// WebDAV / OneDrive / 本地目录 / SFTP 的 ListObjects 只返回直接子项，需要按目录逐个列举；
+// 存档目录从云端枚举而不是取自本地库，其他设备添加或本机已删除的游戏的存档同样会被列出。
+func (s *BackupService) listCloudObjectKeys(provider cloudprovider.CloudStorageProvider, userID string) ([]string, error) {
+	dirs := []string{
+		"database/", "saves/", cloudsync.LibraryDir + "/", cloudsync.CoverDir + "/",
+		cloudsync.HistoryDir + "/", cloudsync.HistoryManifestDir + "/", cloudsync.HistoryObjectDir + "/",
+	}
+	for _, sub := range cloudsync.EntitySubDirs {
+		dirs = append(dirs, cloudsync.LibraryDir+"/"+sub+"/")
+	}
+
+	// 不支持列目录的 provider（S3、Umbra）按前缀递归列举，saves/ 已包含全部存档
+	if lister, ok := cloudprovider.Unwrap(provider).(cloudprovider.DirectoryListProvider); ok {
+		gameDirs, err := lister.ListDirs(s.ctx, provider.GetCloudPath(userID, "saves/"))
+		if err != nil {
+			return nil, fmt.Errorf("列出云端存档目录失败: %w", err)
+		}
+		for _, gameID := range gameDirs {
+			if isValidCloudPathSegment(gameID) {
+				dirs = append(dirs, fmt.Sprintf("saves/%s/", gameID), fmt.Sprintf("saves/%s/snapshots/", gameID), fmt.Sprintf("saves/%s/blobs/", gameID))
+			}
+		}
+	}
+
+	seen := make(map[string]struct{})
+	var keys []string
+	for _, dir := range dirs {
+		listed, err := provider.ListObjects(s.ctx, provider.GetCloudPath(userID, dir))
+		if err != nil {
+			return nil, fmt.Errorf("列出云端目录 %s 失败: %w", dir, err)
+		}
+		for _, key := range listed {
+			// 跳过目录项（OneDrive children 会返回子目录本身）
+			if path.Ext(key) == "" {
+				continue
+			}
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
 // TestS3Connection 测试 S3 连接
 func (s *BackupService) TestS3Connection(config appconf.AppConfig) error {
 	if err := cloudprovider.TestConnection(s.ctx, cloudprovider.ProviderS3, &config); err != nil {
@@ -245,6 +414,24 @@ func (s *BackupService) TestWebDAVConnection(config appconf.AppConfig) error {
 	return nil
 }
 
+// TestLocalConnection 测试本地同步目录是否存在且可写
+func (s *BackupService) TestLocalConnection(config appconf.AppConfig) error {
+	if err := cloudprovider.TestConnection(s.ctx, cloudprovider.ProviderLocal, &config); err != nil {
+		applog.LogErrorf(s.ctx, "TestLocalConnection: connection test failed: %v", err)
+		return fmt.Errorf("连接测试失败: %w", err)
+	}
+	return nil
+}
+
+// TestSFTPConnection 测试 SFTP 连接
+func (s *BackupService) TestSFTPConnection(config appconf.AppConfig) error {
+	if err := cloudprovider.TestConnection(s.ctx, cloudprovider.ProviderSFTP, &config); err != nil {
+		applog.LogErrorf(s.ctx, "TestSFTPConnection: connection test failed: %v", err)
+		return fmt.Errorf("连接测试失败: %w", err)
+	}
+	return nil
+}
+
 // StartUmbraAuth 启动 Umbra OAuth 与设备注册流程。
 // OAuth client ID 与安装令牌由发行构建注入，不接受用户手动填写。
 func (s *BackupService) StartUmbraAuth(config appconf.AppConfig) error {
@@ -377,6 +564,9 @@ func (s *BackupService) GetCloudBackupStatus() vo.CloudBackupStatus {
 		Configured: cloudprovider.IsConfigured(s.config),
 		UserID:     s.config.BackupUserID,
 		Provider:   s.config.CloudBackupProvider,
+		Encrypted:  strings.TrimSpace(s.config.BackupEncryptionKey) != "",
+
+		RotationPending: s.config.BackupPasswordRotation != nil,
 	}
 }
 
@@ -572,14 +762,14 @@ func (s *BackupService) OpenBackupFolder(gameID string) error {
 
 // ========== 游戏存档本地备份方法 ==========
 
-// GetGameBackups 获取游戏的备份历史（直接读取文件夹，不使用数据库）
+// GetGameBackups 获取游戏的备份历史（直接读取文件夹，不使用数据库），包含快照与旧版 zip 备份
 func (s *BackupService) GetGameBackups(gameID string) ([]models.GameBackup, error) {
-	backupDir, err := s.GetBackupDir()
+	store, err := s.saveStore(gameID)
 	if err != nil {
 		return nil, err
 	}
 
-	gameBackupDir := filepath.Join(backupDir, gameID)
+	gameBackupDir := store.Root()
 	entries, err := os.ReadDir(gameBackupDir)
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -588,9 +778,17 @@ func (s *BackupService) GetGameBackups(gameID string) ([]models.GameBackup, erro
 		return nil, err
 	}
 
-	var backups []models.GameBackup
+	snapshots, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+	backups := make([]models.GameBackup, 0, len(snapshots))
+	for _, snapshot := range snapshots {
+		backups = append(backups, snapshotToGameBackup(store, snapshot))
+	}
+
 	for _, entry := range entries {
-		// 只处理 .zip 文件，跳过目录（如 pre_restore、cloud_download）
+		// 旧版 zip 备份：只处理 .zip 文件，跳过目录（如 snapshots、blobs、pre_restore、cloud_download）
 		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".zip") {
 			continue
 		}
@@ -629,41 +827,24 @@ func (s *BackupService) CreateBackup(gameID string) (*models.GameBackup, error)
 		return nil, fmt.Errorf("the save path is not exist: %s", savePath)
 	}
 
-	backupDir, err := s.GetBackupDir()
+	store, err := s.saveStore(gameID)
 	if err != nil {
 		return nil, err
 	}
-	gameBackupDir := filepath.Join(backupDir, gameID)
-	if err := os.MkdirAll(gameBackupDir, 0755); err != nil {
-		return nil, err
-	}
 
-	timestamp := time.Now().Format("2006-01-02T15-04-05")
-	backupFileName := fmt.Sprintf("%s.zip", timestamp)
-	backupPath := filepath.Join(gameBackupDir, backupFileName)
-
-	size, err := archiveutils.ZipFileOrDirectory(savePath, backupPath)
+	s.saveStoreMu.Lock()
+	snapshot, err := store.Create(gameID, savePath, time.Now())
+	s.saveStoreMu.Unlock()
 	if err != nil {
 		return nil, fmt.Errorf("fail to backup: %w", err)
 	}
+	applog.LogInfof(s.ctx, "SaveBackup: snapshot %s created for game %s, size=%d added=%d", snapshot.ID, gameID, snapshot.Size, snapshot.AddedSize)
 
-	// 获取文件信息以得到准确的修改时间
-	stat, err := os.Stat(backupPath)
-	if err != nil {
-		return nil, err
-	}
-
-	backup := &models.GameBackup{
-		Path:      backupPath,
-		Name:      backupFileName,
-		GameID:    gameID,
-		Size:      size,
-		CreatedAt: stat.ModTime(),
-	}
+	backup := snapshotToGameBackup(store, snapshot)
 
 	s.cleanupOldLocalBackups(gameID)
 
-	return backup, nil
+	return &backup, nil
 }
 
 // cleanupOldLocalBackups 清理旧的本地游戏备份
@@ -678,8 +859,21 @@ func (s *BackupService) cleanupOldLocalBackups(gameID string) {
 		return
 	}
 
+	var store *savestore.Store
 	for i := retention; i < len(backups); i++ {
-		s.DeleteBackup(backups[i].Path)
+		removed, err := s.removeLocalBackup(backups[i].Path)
+		if err != nil {
+			applog.LogWarningf(s.ctx, "SaveBackup: failed to remove old backup %s: %v", backups[i].Path, err)
+			continue
+		}
+		if removed != nil {
+			store = removed
+		}
+	}
+	if store != nil {
+		s.saveStoreMu.Lock()
+		s.gcLocalSaveStore(store)
+		s.saveStoreMu.Unlock()
 	}
 }
 
@@ -709,6 +903,16 @@ func (s *BackupService) RestoreBackup(backupPath string) error {
 		return fmt.Errorf("备份文件不存在: %s", backupPath)
 	}
 
+	store, snapshot, isSnapshot, err := s.localSnapshotOf(backupPath)
+	if err != nil {
+		return fmt.Errorf("读取快照失败: %w", err)
+	}
+	if isSnapshot {
+		s.saveStoreMu.Lock()
+		defer s.saveStoreMu.Unlock()
+		return s.restoreSnapshotToSavePath(gameID, store, snapshot, "before_restore")
+	}
+
 	var savePath string
 	err = s.db.QueryRowContext(s.ctx, "SELECT COALESCE(save_path, '') FROM games WHERE id = ?", gameID).Scan(&savePath)
 	if err != nil || savePath == "" {
@@ -775,19 +979,36 @@ func (s *BackupService) RestoreBackup(backupPath string) error {
 	return nil
 }
 
-// DeleteBackup 删除备份（参数改为备份路径）
+// DeleteBackup 删除备份（参数改为备份路径）；删除快照后回收不再被引用的数据块
 func (s *BackupService) DeleteBackup(backupPath string) error {
+	store, err := s.removeLocalBackup(backupPath)
+	if err != nil || store == nil {
+		return err
+	}
+	s.saveStoreMu.Lock()
+	s.gcLocalSaveStore(store)
+	s.saveStoreMu.Unlock()
+	return nil
+}
+
+// removeLocalBackup 删除一个本地备份（快照清单或旧版 zip）。
+// 删除的是快照时返回其所属仓库，数据块留给调用方 GC 回收。
+func (s *BackupService) removeLocalBackup(backupPath string) (*savestore.Store, error) {
 	backupDir, err := s.GetBackupDir()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// 验证备份路径在合法目录下
 	if !isPathWithinBase(backupDir, backupPath) {
-		return fmt.Errorf("无效的备份路径")
+		return nil, fmt.Errorf("无效的备份路径")
 	}
 
-	return os.Remove(backupPath)
+	store, id, ok := s.snapshotIDOf(backupPath)
+	if ok {
+		return store, store.Delete(id)
+	}
+	return nil, os.Remove(backupPath)
 }
 
 // ========== 游戏存档云备份方法 ==========
@@ -811,6 +1032,27 @@ func (s *BackupService) UploadGameBackupToCloud(gameID string, backupPath string
 		return fmt.Errorf("备份文件不存在: %s", backupPath)
 	}
 
+	store, snapshot, isSnapshot, err := s.localSnapshotOf(backupPath)
+	if err != nil {
+		return fmt.Errorf("读取快照失败: %w", err)
+	}
+	if isSnapshot {
+		s.saveStoreMu.Lock()
+		err = s.uploadSnapshotToCloud(provider, gameID, store, snapshot)
+		s.saveStoreMu.Unlock()
+	} else {
+		err = s.uploadGameBackupZip(provider, gameID, backupPath)
+	}
+	if err != nil {
+		return err
+	}
+
+	s.cleanupOldCloudBackups(gameID)
+	return nil
+}
+
+// uploadGameBackupZip 以旧版 zip 形式上传存档备份，并更新 latest.zip
+func (s *BackupService) uploadGameBackupZip(provider cloudprovider.CloudStorageProvider, gameID string, backupPath string) error {
 	timestamp := time.Now().Format("2006-01-02T15-04-05")
 	cloudPath := provider.GetCloudPath(s.config.BackupUserID, fmt.Sprintf("saves/%s/%s.zip", gameID, timestamp))
 
@@ -829,8 +1071,6 @@ func (s *BackupService) UploadGameBackupToCloud(gameID string, backupPath string
 	if err := provider.UploadFile(s.ctx, latestPath, backupPath); err != nil {
 		return fmt.Errorf("更新 latest 备份失败: %w", err)
 	}
-
-	s.cleanupOldCloudBackups(gameID)
 	return nil
 }
 
@@ -853,8 +1093,24 @@ func (s *BackupService) GetCloudGameBackups(gameID string) ([]vo.CloudBackupItem
 	if err != nil {
 		return nil, err
 	}
+	// S3 递归列出，会带上 snapshots/、blobs/ 下的对象；旧版 zip 只在游戏目录的直接子项中
+	zipKeys := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if strings.HasSuffix(strings.ToLower(key), ".zip") && path.Base(path.Dir(key)) == gameID {
+			zipKeys = append(zipKeys, key)
+		}
+	}
 
-	return s.parseCloudBackupItems(keys, ""), nil
+	items := s.parseCloudBackupItems(zipKeys, "")
+	snapshotItems, err := s.listCloudSnapshotItems(provider, gameID)
+	if err != nil {
+		return nil, err
+	}
+	items = append(items, snapshotItems...)
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].CreatedAt.After(items[j].CreatedAt)
+	})
+	return items, nil
 }
 
 // DownloadCloudBackup 从云端下载备份
@@ -867,6 +1123,22 @@ func (s *BackupService) DownloadCloudBackup(cloudKey string, gameID string) (str
 		return "", err
 	}
 
+	if isCloudSnapshotKey(cloudKey) {
+		// 快照下载到本地仓库，作为一条本地备份出现在列表中
+		s.saveStoreMu.Lock()
+		defer s.saveStoreMu.Unlock()
+		store, snapshot, err := s.fetchCloudSnapshot(provider, gameID, cloudKey)
+		if err != nil {
+			return "", err
+		}
+		if _, err := store.Load(snapshot.ID); err != nil {
+			if err := store.Save(snapshot); err != nil {
+				return "", err
+			}
+		}
+		return store.SnapshotPath(snapshot.ID), nil
+	}
+
 	backupDir, err := s.GetBackupDir()
 	if err != nil {
 		return "", err
@@ -885,6 +1157,23 @@ func (s *BackupService) DownloadCloudBackup(cloudKey string, gameID string) (str
 
 // RestoreFromCloud 从云端恢复备份
 func (s *BackupService) RestoreFromCloud(cloudKey string, gameID string) error {
+	if isCloudSnapshotKey(cloudKey) {
+		provider, err := s.getCloudProvider()
+		if err != nil {
+			return err
+		}
+		if err := s.validateGameCloudKey(provider, gameID, cloudKey); err != nil {
+			return err
+		}
+		s.saveStoreMu.Lock()
+		defer s.saveStoreMu.Unlock()
+		store, snapshot, err := s.fetchCloudSnapshot(provider, gameID, cloudKey)
+		if err != nil {
+			return err
+		}
+		return s.restoreSnapshotToSavePath(gameID, store, snapshot, "before_cloud_restore")
+	}
+
 	localPath, err := s.DownloadCloudBackup(cloudKey, gameID)
 	if err != nil {
 		return err
@@ -974,9 +1263,19 @@ func (s *BackupService) cleanupOldCloudBackups(gameID string) {
 		return
 	}
 
+	removedSnapshot := false
 	for i := retention; i < len(items); i++ {
+		if isCloudSnapshotKey(items[i].Key) {
+			if err := s.saveRemote(provider, gameID).Delete(s.ctx, items[i].Key); err == nil {
+				removedSnapshot = true
+			}
+			continue
+		}
 		provider.DeleteObject(s.ctx, items[i].Key)
 	}
+	if removedSnapshot {
+		s.gcCloudSaveStore(provider, gameID)
+	}
 }
 
 // ========== 数据库本地备份方法 ==========
@@ -0,0 +1,511 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"lunabox/internal/applog"
+	"lunabox/internal/common/enums"
+	"lunabox/internal/common/vo"
+	"lunabox/internal/models"
+	"lunabox/internal/service/cloudsync"
+	"lunabox/internal/service/gamehelper"
+	"lunabox/internal/utils/dbutils"
+	"lunabox/internal/utils/httputils"
+	"lunabox/internal/utils/metadata"
+	"lunabox/internal/version"
+
+	"github.com/google/uuid"
+)
+
+// Bangumi 收藏双向同步：拉取授权用户的游戏收藏，与本地游戏的状态和评价逐条比对。
+// 两侧取值不同时按时间戳决定方向：Bangumi 一侧使用收藏的 updated_at，
+// 本地状态使用游戏的 status_updated_at（只在状态本身变化时刷新，编辑封面、刷新元数据等不影响），
+// 本地评价使用评价的 updated_at。
+// 只处理 Bangumi 收藏中存在的条目；本地独有的条目由 SyncAllGameStatuses 推送。
+
+const (
+	bangumiUserCollectionsAPIFormat = "https://api.bgm.tv/v0/users/%s/collections"
+	bangumiCollectionPageSize       = 50
+	bangumiSubjectTypeGame          = 4
+
+	bangumiCollectionActionCreate    = "create"
+	bangumiCollectionActionUpdate    = "update"
+	bangumiCollectionActionUnchanged = "unchanged"
+	bangumiCollectionActionFailed    = "failed"
+
+	bangumiCollectionFieldStatus = "status"
+	bangumiCollectionFieldReview = "review"
+	bangumiCollectionToLocal     = "to_local"
+	bangumiCollectionToRemote    = "to_remote"
+)
+
+type bangumiUserCollection struct {
+	SubjectID   int                `json:"subject_id"`
+	SubjectType int                `json:"subject_type"`
+	Type        int                `json:"type"`
+	Rate        int                `json:"rate"`
+	Comment     string             `json:"comment"`
+	UpdatedAt   time.Time          `json:"updated_at"`
+	Subject     bangumiSlimSubject `json:"subject"`
+}
+
+type bangumiSlimSubject struct {
+	ID           int     `json:"id"`
+	Name         string  `json:"name"`
+	NameCN       string  `json:"name_cn"`
+	ShortSummary string  `json:"short_summary"`
+	Date         string  `json:"date"`
+	Score        float64 `json:"score"`
+	Images       struct {
+		Large  string `json:"large"`
+		Common string `json:"common"`
+	} `json:"images"`
+}
+
+type bangumiUserCollectionPage struct {
+	Data   []bangumiUserCollection `json:"data"`
+	Total  int                     `json:"total"`
+	Limit  int                     `json:"limit"`
+	Offset int                     `json:"offset"`
+}
+
+// bangumiLocalCollection 是与某个 Bangumi 条目关联的本地游戏的状态与评价
+type bangumiLocalCollection struct {
+	gameID          string
+	name            string
+	status          enums.GameStatus
+	statusUpdatedAt time.Time
+	review          *models.GameReview
+}
+
+// PreviewCollectionSync 拉取 Bangumi 收藏并与本地库比对，返回同步将发生的变化，不写入任何数据
+func (s *BangumiService) PreviewCollectionSync() (vo.BangumiCollectionSyncReport, error) {
+	return s.syncCollections(true)
+}
+
+// SyncCollections 拉取 Bangumi 收藏：新建本地没有的游戏，并按时间戳双向同步状态与评价
+func (s *BangumiService) SyncCollections() (vo.BangumiCollectionSyncReport, error) {
+	return s.syncCollections(false)
+}
+
+func (s *BangumiService) syncCollections(dryRun bool) (vo.BangumiCollectionSyncReport, error) {
+	s.batchSyncMu.Lock()
+	defer s.batchSyncMu.Unlock()
+
+	report := vo.BangumiCollectionSyncReport{DryRun: dryRun, Items: []vo.BangumiCollectionSyncItem{}}
+	if s.gameService == nil {
+		return report, fmt.Errorf("游戏服务未初始化")
+	}
+	ctx := s.resolveContext(nil)
+	collections, err := s.fetchUserCollections(ctx)
+	if err != nil {
+		return report, err
+	}
+
+	for _, collection := range collections {
+		item := s.syncCollection(ctx, collection, dryRun)
+		switch item.Action {
+		case bangumiCollectionActionCreate:
+			report.Created++
+		case bangumiCollectionActionUpdate:
+			report.Updated++
+		case bangumiCollectionActionUnchanged:
+			report.Unchanged++
+		case bangumiCollectionActionFailed:
+			report.Failed++
+		}
+		report.Items = append(report.Items, item)
+	}
+	report.Total = len(report.Items)
+
+	applog.LogInfof(ctx, "Bangumi collection sync (dry run: %t): %d total, %d created, %d updated, %d unchanged, %d failed",
+		dryRun, report.Total, report.Created, report.Updated, report.Unchanged, report.Failed)
+	return report, nil
+}
+
+func (s *BangumiService) syncCollection(ctx context.Context, collection bangumiUserCollection, dryRun bool) vo.BangumiCollectionSyncItem {
+	subjectID := strconv.Itoa(collection.SubjectID)
+	item := vo.BangumiCollectionSyncItem{
+		SubjectID:       subjectID,
+		GameName:        bangumiSubjectDisplayName(collection.Subject),
+		Changes:         []vo.BangumiCollectionChange{},
+		RemoteUpdatedAt: collection.UpdatedAt.Format(time.RFC3339),
+	}
+	fail := func(err error) vo.BangumiCollectionSyncItem {
+		item.Action = bangumiCollectionActionFailed
+		item.Error = err.Error()
+		applog.LogWarningf(ctx, "Bangumi collection sync failed for subject %s: %v", subjectID, err)
+		return item
+	}
+
+	local, err := s.loadLocalCollection(subjectID)
+	if err != nil {
+		return fail(err)
+	}
+	if local == nil {
+		item.Action = bangumiCollectionActionCreate
+		if status, ok := mapBangumiCollectionTypeToGameStatus(collection.Type); ok {
+			item.Changes = append(item.Changes, vo.BangumiCollectionChange{
+				Field: bangumiCollectionFieldStatus, Direction: bangumiCollectionToLocal, Remote: string(status),
+			})
+		}
+		if collection.Rate > 0 || strings.TrimSpace(collection.Comment) != "" {
+			item.Changes = append(item.Changes, vo.BangumiCollectionChange{
+				Field: bangumiCollectionFieldReview, Direction: bangumiCollectionToLocal,
+				Remote: formatBangumiReview(collection.Rate, collection.Comment),
+			})
+		}
+		if dryRun {
+			return item
+		}
+		gameID, name, err := s.createGameFromCollection(ctx, collection)
+		if err != nil {
+			return fail(err)
+		}
+		item.GameID, item.GameName = gameID, name
+		if collection.Rate > 0 || strings.TrimSpace(collection.Comment) != "" {
+			if err := s.saveLocalReviewFromCollection(ctx, gameID, collection); err != nil {
+				return fail(err)
+			}
+		}
+		return item
+	}
+
+	item.GameID, item.GameName = local.gameID, local.name
+	item.Changes = planBangumiCollectionChanges(collection, *local)
+	if len(item.Changes) == 0 {
+		item.Action = bangumiCollectionActionUnchanged
+		return item
+	}
+	item.Action = bangumiCollectionActionUpdate
+	if dryRun {
+		return item
+	}
+	for _, change := range item.Changes {
+		if err := s.applyCollectionChange(ctx, subjectID, collection, *local, change); err != nil {
+			return fail(err)
+		}
+	}
+	return item
+}
+
+// planBangumiCollectionChanges 比较 Bangumi 收藏与本地记录，返回需要同步的字段及方向
+func planBangumiCollectionChanges(collection bangumiUserCollection, local bangumiLocalCollection) []vo.BangumiCollectionChange {
+	changes := []vo.BangumiCollectionChange{}
+
+	if remoteStatus, ok := mapBangumiCollectionTypeToGameStatus(collection.Type); ok {
+		localType, _ := mapGameStatusToBangumiCollectionType(local.status)
+		if localType != normalizeBangumiCollectionType(collection.Type) {
+			direction := bangumiCollectionToRemote
+			if collection.UpdatedAt.After(local.statusUpdatedAt) {
+				direction = bangumiCollectionToLocal
+			}
+			changes = append(changes, vo.BangumiCollectionChange{
+				Field: bangumiCollectionFieldStatus, Direction: direction,
+				Local: string(local.status), Remote: string(remoteStatus),
+			})
+		}
+	}
+
+	remoteComment := strings.TrimSpace(collection.Comment)
+	remoteHasReview := collection.Rate > 0 || remoteComment != ""
+	localRating, localContent := 0, ""
+	if local.review != nil {
+		if local.review.Rating != nil {
+			localRating = *local.review.Rating
+		}
+		localContent = strings.TrimSpace(local.review.Content)
+	}
+	if localRating != collection.Rate || localContent != remoteComment {
+		var direction string
+		switch {
+		case local.review == nil:
+			direction = bangumiCollectionToLocal
+		case !remoteHasReview:
+			// Bangumi 无法区分“未评价”和“删除了评价”，本地已有评价时以本地为准
+			direction = bangumiCollectionToRemote
+		case collection.UpdatedAt.After(local.review.UpdatedAt):
+			direction = bangumiCollectionToLocal
+		default:
+			direction = bangumiCollectionToRemote
+		}
+		changes = append(changes, vo.BangumiCollectionChange{
+			Field: bangumiCollectionFieldReview, Direction: direction,
+			Local:  formatBangumiReview(localRating, localContent),
+			Remote: formatBangumiReview(collection.Rate, remoteComment),
+		})
+	}
+	return changes
+}
+
+func (s *BangumiService) applyCollectionChange(
+	ctx context.Context,
+	subjectID string,
+	collection bangumiUserCollection,
+	local bangumiLocalCollection,
+	change vo.BangumiCollectionChange,
+) error {
+	switch {
+	case change.Field == bangumiCollectionFieldStatus && change.Direction == bangumiCollectionToLocal:
+		return updateGameStatusFromRemote(ctx, s.db, local.gameID, enums.GameStatus(change.Remote))
+	case change.Field == bangumiCollectionFieldStatus:
+		return s.upsertSubjectCollectionStatus(ctx, subjectID, local.status)
+	case change.Direction == bangumiCollectionToLocal:
+		return s.saveLocalReviewFromCollection(ctx, local.gameID, collection)
+	default:
+		return s.syncGameReview(ctx, subjectID, *local.review)
+	}
+}
+
+func (s *BangumiService) loadLocalCollection(subjectID string) (*bangumiLocalCollection, error) {
+	gameID, ok := s.gameService.findGameIDBySource(enums.Bangumi, subjectID)
+	if !ok {
+		return nil, nil
+	}
+
+	local := bangumiLocalCollection{gameID: gameID}
+	var status string
+	err := s.db.QueryRowContext(s.ctx, `
+		SELECT name, COALESCE(status, 'not_started'), COALESCE(status_updated_at, updated_at, created_at, CURRENT_TIMESTAMP)
+		FROM games WHERE id = ?
+	`, gameID).Scan(&local.name, &status, &local.statusUpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("读取本地游戏失败: %w", err)
+	}
+	local.status = enums.GameStatus(status)
+
+	var review models.GameReview
+	var rating sql.NullInt64
+	err = s.db.QueryRowContext(s.ctx, `
+		SELECT rating, COALESCE(content, ''), updated_at FROM game_reviews WHERE game_id = ?
+	`, gameID).Scan(&rating, &review.Content, &review.UpdatedAt)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("读取游戏评价失败: %w", err)
+	}
+	if err == nil {
+		review.GameID = gameID
+		if rating.Valid {
+			value := int(rating.Int64)
+			review.Rating = &value
+		}
+		local.review = &review
+	}
+	return &local, nil
+}
+
+// createGameFromCollection 按 Bangumi 条目新建本地游戏。
+// 优先使用完整的条目元数据，获取失败时退回收藏列表中的简要信息。
+func (s *BangumiService) createGameFromCollection(ctx context.Context, collection bangumiUserCollection) (string, string, error) {
+	subjectID := strconv.Itoa(collection.SubjectID)
+	game := models.Game{
+		Name:        bangumiSubjectDisplayName(collection.Subject),
+		CoverURL:    firstNonEmptyString(collection.Subject.Images.Large, collection.Subject.Images.Common),
+		Summary:     collection.Subject.ShortSummary,
+		Rating:      collection.Subject.Score,
+		ReleaseDate: strings.TrimSpace(collection.Subject.Date),
+	}
+	var tags []metadata.TagItem
+
+	token, err := s.getValidAccessToken(ctx)
+	if err != nil {
+		return "", "", err
+	}
+	options := append(gamehelper.MetadataGetterOptions(s.config), metadata.WithHTTPClient(s.httpClient))
+	result, err := metadata.NewBangumiInfoGetter(options...).FetchMetadata(subjectID, token)
+	if err != nil {
+		applog.LogWarningf(ctx, "Bangumi collection sync: failed to fetch metadata for subject %s, using collection summary: %v", subjectID, err)
+	} else {
+		game = result.Game
+		tags = result.Tags
+	}
+
+	game.ID = uuid.New().String()
+	game.SourceType = enums.Bangumi
+	game.SourceID = subjectID
+	if status, ok := mapBangumiCollectionTypeToGameStatus(collection.Type); ok {
+		game.Status = status
+	}
+	if strings.TrimSpace(game.Name) == "" {
+		game.Name = subjectID
+	}
+	if err := s.gameService.AddGameFromWebMetadata(vo.GameMetadataFromWebVO{Source: enums.Bangumi, Game: game, Tags: tags}); err != nil {
+		return "", "", fmt.Errorf("新建游戏失败: %w", err)
+	}
+	return game.ID, game.Name, nil
+}
+
+// updateGameStatusFromRemote 以远端状态覆盖本地状态，同时刷新 status_updated_at 作为下次比对的依据
+func updateGameStatusFromRemote(ctx context.Context, db *sql.DB, gameID string, status enums.GameStatus) error {
+	now := time.Now()
+	return dbutils.WithDuckDBWriteLock(db, func() error {
+		_, err := db.ExecContext(ctx, `UPDATE games SET status = ?, status_updated_at = ?, updated_at = ? WHERE id = ?`, string(status), now, now, gameID)
+		if err != nil {
+			return fmt.Errorf("更新游戏状态失败: %w", err)
+		}
+		return nil
+	})
+}
+
+func (s *BangumiService) saveLocalReviewFromCollection(ctx context.Context, gameID string, collection bangumiUserCollection) error {
+	var rating any
+	if collection.Rate >= 1 && collection.Rate <= 10 {
+		rating = collection.Rate
+	}
+	now := time.Now()
+	return dbutils.WithDuckDBWriteLock(s.db, func() error {
+		_, err := s.db.ExecContext(ctx, `
+			INSERT INTO game_reviews (game_id, rating, content, is_spoiler, created_at, updated_at)
+			VALUES (?, ?, ?, FALSE, ?, ?)
+			ON CONFLICT (game_id) DO UPDATE SET
+				rating = EXCLUDED.rating,
+				content = EXCLUDED.content,
+				updated_at = EXCLUDED.updated_at
+		`, gameID, rating, strings.TrimSpace(collection.Comment), now, now)
+		if err != nil {
+			return fmt.Errorf("保存游戏评价失败: %w", err)
+		}
+		return cloudsync.DeleteTombstone(ctx, s.db, cloudsync.EntityGameReview, gameID)
+	})
+}
+
+// fetchUserCollections 分页读取授权用户的全部游戏收藏
+func (s *BangumiService) fetchUserCollections(ctx context.Context) ([]bangumiUserCollection, error) {
+	token, err := s.getValidAccessToken(ctx)
+	if err != nil {
+		return nil, err
+	}
+	username, err := s.resolveCollectionUsername(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	var collections []bangumiUserCollection
+	for offset := 0; ; {
+		page, err := s.fetchUserCollectionPage(ctx, token, username, offset)
+		if errors.Is(err, errBangumiUnauthorized) {
+			token, err = s.refreshAccessToken(ctx)
+			if err != nil {
+				return nil, err
+			}
+			page, err = s.fetchUserCollectionPage(ctx, token, username, offset)
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, collection := range page.Data {
+			if collection.SubjectType == bangumiSubjectTypeGame && collection.SubjectID > 0 {
+				collections = append(collections, collection)
+			}
+		}
+		offset += len(page.Data)
+		if len(page.Data) == 0 || offset >= page.Total {
+			return collections, nil
+		}
+	}
+}
+
+func (s *BangumiService) resolveCollectionUsername(ctx context.Context, token string) (string, error) {
+	s.mu.Lock()
+	username := firstNonEmptyString(s.config.BangumiAuthorizedUsername, s.config.BangumiAuthorizedUserID)
+	s.mu.Unlock()
+	if username != "" {
+		return username, nil
+	}
+
+	user, err := s.fetchCurrentUser(ctx, token)
+	if err != nil {
+		return "", err
+	}
+	return user.Username, nil
+}
+
+func (s *BangumiService) fetchUserCollectionPage(ctx context.Context, accessToken, username string, offset int) (bangumiUserCollectionPage, error) {
+	query := url.Values{
+		"subject_type": {strconv.Itoa(bangumiSubjectTypeGame)},
+		"limit":        {strconv.Itoa(bangumiCollectionPageSize)},
+		"offset":       {strconv.Itoa(offset)},
+	}
+	reqURL := fmt.Sprintf(bangumiUserCollectionsAPIFormat, url.PathEscape(username)) + "?" + query.Encode()
+	req, err := http.NewRequestWithContext(s.resolveContext(ctx), http.MethodGet, reqURL, nil)
+	if err != nil {
+		return bangumiUserCollectionPage{}, fmt.Errorf("创建 Bangumi 收藏列表请求失败: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+accessToken)
+	req.Header.Set("User-Agent", version.UserAgent())
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := httputils.DoWithRetry(req.Context(), s.httpClient, req, httputils.RetryPolicy{
+		MaxRetries:    1,
+		FallbackDelay: time.Second,
+		MaxDelay:      30 * time.Second,
+	})
+	if err != nil {
+		return bangumiUserCollectionPage{}, fmt.Errorf("请求 Bangumi 收藏列表失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return bangumiUserCollectionPage{}, fmt.Errorf("读取 Bangumi 收藏列表响应失败: %w", err)
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		return bangumiUserCollectionPage{}, fmt.Errorf("%w: %s", errBangumiUnauthorized, strings.TrimSpace(string(body)))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return bangumiUserCollectionPage{}, fmt.Errorf("Bangumi 收藏列表接口返回 HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var page bangumiUserCollectionPage
+	if err := json.Unmarshal(body, &page); err != nil {
+		return bangumiUserCollectionPage{}, fmt.Errorf("解析 Bangumi 收藏列表失败: %w", err)
+	}
+	return page, nil
+}
+
+func bangumiSubjectDisplayName(subject bangumiSlimSubject) string {
+	return firstNonEmptyString(subject.NameCN, subject.Name)
+}
+
+func formatBangumiReview(rating int, comment string) string {
+	parts := make([]string, 0, 2)
+	if rating > 0 {
+		parts = append(parts, fmt.Sprintf("%d/10", rating))
+	}
+	if comment = strings.TrimSpace(comment); comment != "" {
+		parts = append(parts, comment)
+	}
+	return strings.Join(parts, " ")
+}
+
+// normalizeBangumiCollectionType 把“抛弃”视为“搁置”，本地没有对应的状态
+func normalizeBangumiCollectionType(collectionType int) int {
+	if collectionType == 5 {
+		return 4
+	}
+	return collectionType
+}
+
+func mapBangumiCollectionTypeToGameStatus(collectionType int) (enums.GameStatus, bool) {
+	switch normalizeBangumiCollectionType(collectionType) {
+	case 1:
+		return enums.StatusWantToPlay, true
+	case 2:
+		return enums.StatusCompleted, true
+	case 3:
+		return enums.StatusPlaying, true
+	case 4:
+		return enums.StatusOnHold, true
+	default:
+		return "", false
+	}
+}
@@ -99,6 +99,7 @@ type BangumiService struct {
 	ctx          context.Context
 	db           *sql.DB
 	config       *appconf.AppConfig
+	gameService  *GameService
 	httpClient   *http.Client
 	runtime      wailsruntime.Runtime
 	openURL      func(string) error
@@ -157,6 +158,11 @@ func (s *BangumiService) SetRuntime(runtime wailsruntime.Runtime) {
 	}
 }
 
+//wails:ignore
+func (s *BangumiService) SetGameService(gameService *GameService) {
+	s.gameService = gameService
+}
+
 //wails:ignore
 func (s *BangumiService) SetHTTPClient(client *http.Client) {
 	if client != nil {
@@ -859,7 +865,7 @@ func (s *BangumiService) postSubjectCollectionPayload(ctx context.Context, subje
 		resp.StatusCode != http.StatusOK &&
 		resp.StatusCode != http.StatusAccepted {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("Bangumi 收藏接口返回 HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+		return httputils.NewStatusError(resp, fmt.Sprintf("Bangumi 收藏接口返回 HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(body))))
 	}
 
 	return nil
@@ -3,6 +3,8 @@ package service
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"fmt"
 	"lunabox/internal/appconf"
 	"lunabox/internal/applog"
 	"lunabox/internal/common/vo"
@@ -61,19 +63,10 @@ func (s *CloudSyncService) GetCloudSyncStatus() vo.CloudSyncStatus {
 }
 
 func (s *CloudSyncService) SyncNow() (vo.CloudSyncStatus, error) {
-	s.mu.Lock()
-	if s.syncing {
-		status := s.currentStatusLocked()
-		s.mu.Unlock()
+	status, started := s.beginSync()
+	if !started {
 		return status, nil
 	}
-	s.syncing = true
-	s.config.LastCloudSyncStatus = cloudSyncStateSyncing
-	s.config.LastCloudSyncError = ""
-	_ = appconf.SaveConfig(s.config)
-	status := s.currentStatusLocked()
-	s.mu.Unlock()
-	s.emitStatusChanged(status)
 
 	if !s.config.CloudSyncEnabled {
 		return s.finishSync(cloudSyncStateIdle, "", nil)
@@ -93,6 +86,312 @@ func (s *CloudSyncService) SyncNow() (vo.CloudSyncStatus, error) {
 	return s.finishSync(cloudSyncStateSuccess, "", nil)
 }
 
+// GetCloudSyncConflicts 列出同步时双方都修改了同一字段的冲突，供用户手动确认。
+func (s *CloudSyncService) GetCloudSyncConflicts() ([]vo.CloudSyncConflict, error) {
+	conflicts, err := cloudsync.ListConflicts(s.ctx, s.db)
+	if err != nil {
+		applog.LogErrorf(s.ctx, "GetCloudSyncConflicts: %v", err)
+		return nil, fmt.Errorf("读取同步冲突失败: %w", err)
+	}
+
+	return s.conflictVOs(conflicts), nil
+}
+
+// conflictVOs 为冲突补充所属游戏名称；进度冲突的 entity_id 是进度 ID，需要先映射回游戏。
+func (s *CloudSyncService) conflictVOs(conflicts []cloudsync.Conflict) []vo.CloudSyncConflict {
+	gameNames := make(map[string]string)
+	rows, err := s.db.QueryContext(s.ctx, `SELECT id, COALESCE(name, '') FROM games`)
+	if err == nil {
+		for rows.Next() {
+			var id, name string
+			if rows.Scan(&id, &name) == nil {
+				gameNames[id] = name
+			}
+		}
+		rows.Close()
+	}
+	progressGames := make(map[string]string)
+	rows, err = s.db.QueryContext(s.ctx, `SELECT id, game_id FROM game_progress`)
+	if err == nil {
+		for rows.Next() {
+			var id, gameID string
+			if rows.Scan(&id, &gameID) == nil {
+				progressGames[id] = gameID
+			}
+		}
+		rows.Close()
+	}
+
+	result := make([]vo.CloudSyncConflict, 0, len(conflicts))
+	for _, c := range conflicts {
+		gameID := c.EntityID
+		if c.EntityType == cloudsync.EntityGameProgress {
+			gameID = progressGames[c.EntityID]
+		}
+		result = append(result, vo.CloudSyncConflict{
+			EntityType:  c.EntityType,
+			EntityID:    c.EntityID,
+			EntityName:  gameNames[gameID],
+			Field:       c.Field,
+			BaseValue:   c.BaseValue,
+			LocalValue:  c.LocalValue,
+			RemoteValue: c.RemoteValue,
+			Resolution:  c.Resolution,
+			DetectedAt:  c.DetectedAt.Format(time.RFC3339),
+		})
+	}
+	return result
+}
+
+// PreviewSync 以真实云端数据演练一次同步，返回同步后本地与云端将发生的变化，不写入任何数据。
+// 用于在执行可能造成大量删除的同步前先行确认。
+func (s *CloudSyncService) PreviewSync() (vo.CloudSyncPreview, error) {
+	var result vo.CloudSyncPreview
+	provider, err := s.historyProvider()
+	if err != nil {
+		return result, err
+	}
+
+	helper := cloudsync.NewHelper(s.ctx, s.db, s.config)
+	preview, err := helper.PreviewSync(provider)
+	if err != nil {
+		if errors.Is(err, cloudsync.ErrManifestSchemaTooNew) {
+			return result, err
+		}
+		applog.LogErrorf(s.ctx, "PreviewSync: %v", err)
+		return result, fmt.Errorf("云同步预览失败: %w", err)
+	}
+
+	result.RemoteExists = preview.RemoteExists
+	result.Local = previewSideVO(preview.Local, preview.GameNames)
+	result.Remote = previewSideVO(preview.Remote, preview.GameNames)
+	result.Conflicts = s.conflictVOs(preview.Conflicts)
+	return result, nil
+}
+
+func previewSideVO(changes []cloudsync.RecordChange, gameNames map[string]string) vo.CloudSyncPreviewSide {
+	side := vo.CloudSyncPreviewSide{
+		Entities: make([]vo.CloudSyncPreviewCount, 0),
+		Items:    make([]vo.CloudSyncPreviewItem, 0, len(changes)),
+	}
+	counts := make(map[string]int)
+	for _, change := range changes {
+		idx, ok := counts[change.EntityType]
+		if !ok {
+			idx = len(side.Entities)
+			counts[change.EntityType] = idx
+			side.Entities = append(side.Entities, vo.CloudSyncPreviewCount{EntityType: change.EntityType})
+		}
+		switch change.Change {
+		case cloudsync.RecordAdded:
+			side.Entities[idx].Added++
+		case cloudsync.RecordUpdated:
+			side.Entities[idx].Updated++
+		case cloudsync.RecordDeleted:
+			side.Entities[idx].Deleted++
+		}
+		side.Items = append(side.Items, vo.CloudSyncPreviewItem{
+			EntityType: change.EntityType,
+			EntityID:   change.EntityID,
+			GameID:     change.GameID,
+			GameName:   gameNames[change.GameID],
+			Change:     change.Change,
+		})
+	}
+	return side
+}
+
+// ResolveCloudSyncConflict 手动处理一条同步冲突，choice 为 local（保留本机取值）或 remote（采用云端取值）。
+// 改动写回本地后会在下一次同步时推送到云端。
+func (s *CloudSyncService) ResolveCloudSyncConflict(entityType, entityID, field, choice string) error {
+	s.mu.Lock()
+	syncing := s.syncing
+	s.mu.Unlock()
+	if syncing {
+		return fmt.Errorf("云同步进行中，请稍后再处理冲突")
+	}
+
+	helper := cloudsync.NewHelper(s.ctx, s.db, s.config)
+	if err := helper.ResolveConflict(entityType, entityID, field, choice); err != nil {
+		if errors.Is(err, cloudsync.ErrConflictNotFound) {
+			return fmt.Errorf("冲突记录不存在或已处理")
+		}
+		applog.LogErrorf(s.ctx, "ResolveCloudSyncConflict: %v", err)
+		return fmt.Errorf("处理同步冲突失败: %w", err)
+	}
+	applog.LogInfof(s.ctx, "ResolveCloudSyncConflict: %s/%s/%s resolved with %s", entityType, entityID, field, choice)
+	return nil
+}
+
+// ListCloudSyncRevisions 列出云端保留的历史版本（新版本在前）。
+func (s *CloudSyncService) ListCloudSyncRevisions() ([]vo.CloudSyncRevision, error) {
+	provider, err := s.historyProvider()
+	if err != nil {
+		return nil, err
+	}
+	helper := cloudsync.NewHelper(s.ctx, s.db, s.config)
+	index, err := helper.LoadHistoryIndex(provider)
+	if err != nil {
+		applog.LogErrorf(s.ctx, "ListCloudSyncRevisions: %v", err)
+		return nil, fmt.Errorf("读取云同步历史版本失败: %w", err)
+	}
+
+	localRevisionID := ""
+	if state, err := cloudsync.LoadSyncState(s.ctx, s.db); err == nil {
+		localRevisionID = state[cloudsync.StateKeyManifest].RemoteRevisionID
+	}
+	result := make([]vo.CloudSyncRevision, 0, len(index.Revisions))
+	for _, revision := range index.Revisions {
+		result = append(result, vo.CloudSyncRevision{
+			RevisionID:  revision.RevisionID,
+			ExportedAt:  revision.ExportedAt.Format(time.RFC3339),
+			DeviceID:    revision.DeviceID,
+			GameCount:   revision.GameCount,
+			LocalSynced: revision.RevisionID == localRevisionID,
+		})
+	}
+	return result, nil
+}
+
+// DiffCloudSyncRevision 比较某个历史版本与本地数据，供回滚前预览。
+func (s *CloudSyncService) DiffCloudSyncRevision(revisionID string) (vo.CloudSyncRevisionDiff, error) {
+	result := vo.CloudSyncRevisionDiff{RevisionID: revisionID}
+	provider, err := s.historyProvider()
+	if err != nil {
+		return result, err
+	}
+	helper := cloudsync.NewHelper(s.ctx, s.db, s.config)
+	revision, err := helper.LoadRevisionSnapshot(provider, revisionID)
+	if err != nil {
+		return result, s.revisionError("DiffCloudSyncRevision", err)
+	}
+	localState, err := helper.BuildLocalState()
+	if err != nil {
+		applog.LogErrorf(s.ctx, "DiffCloudSyncRevision: build local state: %v", err)
+		return result, fmt.Errorf("读取本地数据失败: %w", err)
+	}
+
+	counts := make(map[string]*vo.CloudSyncEntityDiff)
+	entityOrder := make([]string, 0)
+	for _, change := range cloudsync.DiffSnapshots(localState.Snapshot, revision) {
+		entry, ok := counts[change.EntityType]
+		if !ok {
+			entry = &vo.CloudSyncEntityDiff{EntityType: change.EntityType}
+			counts[change.EntityType] = entry
+			entityOrder = append(entityOrder, change.EntityType)
+		}
+		switch change.Change {
+		case cloudsync.RecordOnlyLocal:
+			entry.OnlyLocal++
+		case cloudsync.RecordOnlyRevision:
+			entry.OnlyRevision++
+		case cloudsync.RecordChanged:
+			entry.Changed++
+		}
+		if change.EntityType == cloudsync.EntityGame {
+			result.Games = append(result.Games, vo.CloudSyncGameDiff{
+				GameID: change.EntityID,
+				Name:   change.Label,
+				Change: change.Change,
+			})
+		}
+	}
+	for _, entityType := range entityOrder {
+		result.Entities = append(result.Entities, *counts[entityType])
+	}
+	return result, nil
+}
+
+// RollbackCloudSyncRevision 把本地与云端数据回滚到指定历史版本，回滚结果作为新版本同步到其他设备。
+func (s *CloudSyncService) RollbackCloudSyncRevision(revisionID string) (vo.CloudSyncStatus, error) {
+	provider, err := s.historyProvider()
+	if err != nil {
+		return s.GetCloudSyncStatus(), err
+	}
+	status, started := s.beginSync()
+	if !started {
+		return status, fmt.Errorf("云同步进行中，请稍后再回滚")
+	}
+
+	helper := cloudsync.NewHelper(s.ctx, s.db, s.config)
+	if err := helper.RollbackToRevision(provider, revisionID); err != nil {
+		err = s.revisionError("RollbackCloudSyncRevision", err)
+		return s.finishSync(cloudSyncStateFailed, err.Error(), err)
+	}
+	applog.LogInfof(s.ctx, "RollbackCloudSyncRevision: rolled back to revision %s", revisionID)
+	return s.finishSync(cloudSyncStateSuccess, "", nil)
+}
+
+// CollectCloudSyncGarbage 清理云端不再被任何保留版本引用的同步文件，返回删除的历史文件数量。
+func (s *CloudSyncService) CollectCloudSyncGarbage() (int, error) {
+	provider, err := s.historyProvider()
+	if err != nil {
+		return 0, err
+	}
+	// GC 与同步互斥，但不改变上次同步的状态记录
+	s.mu.Lock()
+	if s.syncing {
+		s.mu.Unlock()
+		return 0, fmt.Errorf("云同步进行中，请稍后再清理")
+	}
+	s.syncing = true
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.syncing = false
+		s.mu.Unlock()
+	}()
+
+	helper := cloudsync.NewHelper(s.ctx, s.db, s.config)
+	deleted, err := helper.CollectGarbage(provider)
+	if err != nil {
+		applog.LogErrorf(s.ctx, "CollectCloudSyncGarbage: %v", err)
+		return deleted, fmt.Errorf("清理云同步文件失败: %w", err)
+	}
+	applog.LogInfof(s.ctx, "CollectCloudSyncGarbage: deleted %d unreferenced history files", deleted)
+	return deleted, nil
+}
+
+func (s *CloudSyncService) historyProvider() (cloudprovider.CloudStorageProvider, error) {
+	if !s.config.CloudSyncEnabled || !cloudprovider.IsConfigured(s.config) {
+		return nil, fmt.Errorf("云同步未启用或未配置")
+	}
+	provider, err := cloudprovider.NewCloudProvider(s.ctx, s.config)
+	if err != nil {
+		return nil, fmt.Errorf("创建云存储客户端失败: %w", err)
+	}
+	return provider, nil
+}
+
+func (s *CloudSyncService) revisionError(op string, err error) error {
+	switch {
+	case errors.Is(err, cloudsync.ErrRevisionNotFound):
+		return fmt.Errorf("历史版本不存在或已被清理")
+	case errors.Is(err, cloudsync.ErrRevisionIncomplete):
+		return fmt.Errorf("历史版本数据不完整，无法还原: %w", err)
+	}
+	applog.LogErrorf(s.ctx, "%s: %v", op, err)
+	return fmt.Errorf("读取历史版本失败: %w", err)
+}
+
+// beginSync 标记同步开始；已有同步在进行时返回 false 与当前状态。
+func (s *CloudSyncService) beginSync() (vo.CloudSyncStatus, bool) {
+	s.mu.Lock()
+	if s.syncing {
+		status := s.currentStatusLocked()
+		s.mu.Unlock()
+		return status, false
+	}
+	s.syncing = true
+	s.config.LastCloudSyncStatus = cloudSyncStateSyncing
+	s.config.LastCloudSyncError = ""
+	_ = appconf.SaveConfig(s.config)
+	status := s.currentStatusLocked()
+	s.mu.Unlock()
+	s.emitStatusChanged(status)
+	return status, true
+}
+
 func (s *CloudSyncService) currentStatusLocked() vo.CloudSyncStatus {
 	return vo.CloudSyncStatus{
 		Enabled:        s.config.CloudSyncEnabled,
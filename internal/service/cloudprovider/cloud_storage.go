@@ -21,3 +21,19 @@ type CloudStorageProvider interface {
 type BatchUploadProvider interface {
 	UploadFiles(ctx context.Context, items []batchupload.Item) error
 }
+
+// DirectoryListProvider is an optional capability implemented by providers whose
+// ListObjects only returns the files directly under a prefix (local directory,
+// SFTP, WebDAV, OneDrive). ListDirs returns the names of the immediate
+// subdirectories so callers can walk nested layouts. Providers without it list
+// prefixes recursively.
+type DirectoryListProvider interface {
+	ListDirs(ctx context.Context, prefix string) ([]string, error)
+}
+
+// JSONPayloadProvider is an optional capability implemented by providers that
+// only accept valid JSON for some object keys (e.g. Umbra sync records). The
+// encryption layer wraps ciphertext for those keys in a JSON envelope.
+type JSONPayloadProvider interface {
+	RequiresJSONPayload(cloudPath string) bool
+}
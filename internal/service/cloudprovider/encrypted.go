@@ -0,0 +1,166 @@
+package cloudprovider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"lunabox/internal/service/cloudprovider/batchupload"
+	"lunabox/internal/utils"
+)
+
+// EncryptedProvider 在任意 CloudStorageProvider 外层提供端到端加密：
+// 上传前把本地文件封装为加密信封，下载后校验并解密。设置了密钥时明文对象
+// 返回 utils.ErrBackupEnvelopeMissing；只有 NewMigrationProvider 会透传旧版明文。
+// inner 实现 JSONPayloadProvider 时，对要求 JSON 的对象使用 JSON 包装的信封。
+// key 为空时上传保持明文，但下载到加密对象会返回 utils.ErrBackupKeyMissing，
+// 避免把密文当作 zip/json 交给上层。
+type EncryptedProvider struct {
+	inner          CloudStorageProvider
+	key            []byte
+	allowPlaintext bool
+}
+
+// encryptedBatchProvider 在内层 provider 支持批量上传时保留该能力。
+type encryptedBatchProvider struct {
+	*EncryptedProvider
+	batch BatchUploadProvider
+}
+
+var (
+	_ CloudStorageProvider = (*EncryptedProvider)(nil)
+	_ BatchUploadProvider  = (*encryptedBatchProvider)(nil)
+)
+
+// NewEncryptedProvider 用主密钥包装 inner；inner 支持批量上传时返回值也支持。
+func NewEncryptedProvider(inner CloudStorageProvider, key []byte) CloudStorageProvider {
+	return newEncryptedProvider(&EncryptedProvider{inner: inner, key: key})
+}
+
+// NewMigrationProvider 与 NewEncryptedProvider 相同，但下载时接受加密前上传的旧版明文。
+// 只用于密码轮换等显式迁移旧数据的流程，普通同步与恢复必须使用 NewEncryptedProvider。
+func NewMigrationProvider(inner CloudStorageProvider, key []byte) CloudStorageProvider {
+	return newEncryptedProvider(&EncryptedProvider{inner: inner, key: key, allowPlaintext: true})
+}
+
+func newEncryptedProvider(provider *EncryptedProvider) CloudStorageProvider {
+	inner := provider.inner
+	if batch, ok := inner.(BatchUploadProvider); ok {
+		return &encryptedBatchProvider{EncryptedProvider: provider, batch: batch}
+	}
+	return provider
+}
+
+// Unwrap 返回被包装的原始 provider。
+func (p *EncryptedProvider) Unwrap() CloudStorageProvider {
+	return p.inner
+}
+
+// Encrypting 返回上传时是否会加密。
+func (p *EncryptedProvider) Encrypting() bool {
+	return len(p.key) > 0
+}
+
+func (p *EncryptedProvider) UploadFile(ctx context.Context, cloudPath, localPath string) error {
+	if !p.Encrypting() {
+		return p.inner.UploadFile(ctx, cloudPath, localPath)
+	}
+	sealedPath, err := p.sealToTemp(cloudPath, localPath)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(sealedPath)
+	return p.inner.UploadFile(ctx, cloudPath, sealedPath)
+}
+
+func (p *EncryptedProvider) DownloadFile(ctx context.Context, cloudPath, localPath string) error {
+	tempFile, err := os.CreateTemp(filepath.Dir(localPath), ".lunabox_sealed_*")
+	if err != nil {
+		return fmt.Errorf("创建解密临时文件失败: %w", err)
+	}
+	sealedPath := tempFile.Name()
+	tempFile.Close()
+	defer os.Remove(sealedPath)
+
+	if err := p.inner.DownloadFile(ctx, cloudPath, sealedPath); err != nil {
+		return err
+	}
+	decrypt := utils.DecryptBackupFile
+	if p.allowPlaintext {
+		decrypt = utils.DecryptLegacyBackupFile
+	}
+	if _, err := decrypt(p.key, sealedPath, localPath); err != nil {
+		return fmt.Errorf("解密 %s 失败: %w", cloudPath, err)
+	}
+	return nil
+}
+
+func (p *EncryptedProvider) ListObjects(ctx context.Context, prefix string) ([]string, error) {
+	return p.inner.ListObjects(ctx, prefix)
+}
+
+func (p *EncryptedProvider) DeleteObject(ctx context.Context, key string) error {
+	return p.inner.DeleteObject(ctx, key)
+}
+
+func (p *EncryptedProvider) TestConnection(ctx context.Context) error {
+	return p.inner.TestConnection(ctx)
+}
+
+func (p *EncryptedProvider) EnsureDir(ctx context.Context, path string) error {
+	return p.inner.EnsureDir(ctx, path)
+}
+
+func (p *EncryptedProvider) GetCloudPath(userID, subPath string) string {
+	return p.inner.GetCloudPath(userID, subPath)
+}
+
+func (p *encryptedBatchProvider) UploadFiles(ctx context.Context, items []batchupload.Item) error {
+	if !p.Encrypting() {
+		return p.batch.UploadFiles(ctx, items)
+	}
+	sealedItems := make([]batchupload.Item, 0, len(items))
+	defer func() {
+		for _, item := range sealedItems {
+			_ = os.Remove(item.LocalPath)
+		}
+	}()
+	for _, item := range items {
+		sealedPath, err := p.sealToTemp(item.CloudPath, item.LocalPath)
+		if err != nil {
+			return err
+		}
+		sealedItems = append(sealedItems, batchupload.Item{CloudPath: item.CloudPath, LocalPath: sealedPath})
+	}
+	return p.batch.UploadFiles(ctx, sealedItems)
+}
+
+func (p *EncryptedProvider) sealToTemp(cloudPath, localPath string) (string, error) {
+	tempFile, err := os.CreateTemp("", "lunabox_sealed_*")
+	if err != nil {
+		return "", fmt.Errorf("创建加密临时文件失败: %w", err)
+	}
+	sealedPath := tempFile.Name()
+	tempFile.Close()
+	encrypt := utils.EncryptBackupFile
+	if jsonProvider, ok := p.inner.(JSONPayloadProvider); ok && jsonProvider.RequiresJSONPayload(cloudPath) {
+		encrypt = utils.EncryptBackupJSONFile
+	}
+	if err := encrypt(p.key, localPath, sealedPath); err != nil {
+		_ = os.Remove(sealedPath)
+		return "", fmt.Errorf("加密 %s 失败: %w", filepath.Base(localPath), err)
+	}
+	return sealedPath, nil
+}
+
+// Unwrap 去掉所有装饰层，返回实际的存储 provider（用于按具体类型决定并发等策略）。
+func Unwrap(provider CloudStorageProvider) CloudStorageProvider {
+	for {
+		wrapper, ok := provider.(interface{ Unwrap() CloudStorageProvider })
+		if !ok {
+			return provider
+		}
+		provider = wrapper.Unwrap()
+	}
+}
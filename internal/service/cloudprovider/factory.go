@@ -6,23 +6,31 @@ import (
 	"strings"
 
 	"lunabox/internal/appconf"
+	"lunabox/internal/service/cloudprovider/local"
 	"lunabox/internal/service/cloudprovider/onedrive"
 	"lunabox/internal/service/cloudprovider/s3"
+	"lunabox/internal/service/cloudprovider/sftp"
 	"lunabox/internal/service/cloudprovider/umbra"
 	"lunabox/internal/service/cloudprovider/webdav"
+	"lunabox/internal/utils"
 	"lunabox/internal/version"
 )
 
 // ProviderType 云存储提供商类型
 type ProviderType string
 
-var _ BatchUploadProvider = (*umbra.Provider)(nil)
+var (
+	_ BatchUploadProvider = (*umbra.Provider)(nil)
+	_ JSONPayloadProvider = (*umbra.Provider)(nil)
+)
 
 const (
 	ProviderS3       ProviderType = "s3"
 	ProviderOneDrive ProviderType = "onedrive"
 	ProviderUmbra    ProviderType = "umbra"
 	ProviderWebDAV   ProviderType = "webdav"
+	ProviderLocal    ProviderType = "local"
+	ProviderSFTP     ProviderType = "sftp"
 )
 
 // HasRequiredBackupUserID reports whether the selected provider has the local backup identity it requires.
@@ -30,12 +38,37 @@ func HasRequiredBackupUserID(config *appconf.AppConfig) bool {
 	return ProviderType(config.CloudBackupProvider) == ProviderUmbra || strings.TrimSpace(config.BackupUserID) != ""
 }
 
-// NewCloudProvider 根据配置创建云存储提供商
+// NewCloudProvider 根据配置创建云存储提供商，并套上端到端加密层。
 func NewCloudProvider(ctx context.Context, config *appconf.AppConfig) (CloudStorageProvider, error) {
 	if !config.CloudBackupEnabled {
 		return nil, fmt.Errorf("云备份未启用")
 	}
 
+	provider, err := newStorageProvider(ctx, config)
+	if err != nil {
+		return nil, err
+	}
+	key, err := BackupEncryptionKey(config)
+	if err != nil {
+		return nil, err
+	}
+	return NewEncryptedProvider(provider, key), nil
+}
+
+// BackupEncryptionKey 解析配置中的加密主密钥；未设置时返回 nil。
+func BackupEncryptionKey(config *appconf.AppConfig) ([]byte, error) {
+	encoded := strings.TrimSpace(config.BackupEncryptionKey)
+	if encoded == "" {
+		return nil, nil
+	}
+	key, err := utils.DecodeBackupEncryptionKey(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("备份加密密钥无效，请重新设置备份密码: %w", err)
+	}
+	return key, nil
+}
+
+func newStorageProvider(ctx context.Context, config *appconf.AppConfig) (CloudStorageProvider, error) {
 	switch ProviderType(config.CloudBackupProvider) {
 	case ProviderOneDrive:
 		return newOneDriveProviderFromConfig(config)
@@ -45,6 +78,10 @@ func NewCloudProvider(ctx context.Context, config *appconf.AppConfig) (CloudStor
 		return newUmbraProviderFromConfig(ctx, config)
 	case ProviderWebDAV:
 		return newWebDAVProviderFromConfig(config)
+	case ProviderLocal:
+		return newLocalProviderFromConfig(config)
+	case ProviderSFTP:
+		return newSFTPProviderFromConfig(config)
 	default:
 		return nil, fmt.Errorf("未知的云备份提供商: %s", config.CloudBackupProvider)
 	}
@@ -60,6 +97,27 @@ func newWebDAVProviderFromConfig(config *appconf.AppConfig) (*webdav.Provider, e
 	})
 }
 
+// newLocalProviderFromConfig 从配置创建本地目录 Provider
+func newLocalProviderFromConfig(config *appconf.AppConfig) (*local.Provider, error) {
+	return local.NewProvider(local.Config{
+		Path: config.LocalSyncPath,
+	})
+}
+
+// newSFTPProviderFromConfig 从配置创建 SFTP Provider
+func newSFTPProviderFromConfig(config *appconf.AppConfig) (*sftp.Provider, error) {
+	return sftp.NewProvider(sftp.Config{
+		Host:               config.SFTPHost,
+		Port:               config.SFTPPort,
+		Username:           config.SFTPUsername,
+		Password:           config.SFTPPassword,
+		PrivateKeyPath:     config.SFTPPrivateKeyPath,
+		KeyPassphrase:      config.SFTPKeyPassphrase,
+		HostKeyFingerprint: config.SFTPHostKeyFingerprint,
+		RootPath:           config.SFTPRootPath,
+	})
+}
+
 func newUmbraProviderFromConfig(ctx context.Context, config *appconf.AppConfig) (*umbra.Provider, error) {
 	return umbra.NewProvider(ctx, umbra.Config{
 		BaseURL:     config.UmbraBaseURL,
@@ -116,6 +174,19 @@ func TestConnection(ctx context.Context, providerType ProviderType, config *appc
 			return err
 		}
 		return provider.TestConnection(ctx)
+	case ProviderLocal:
+		provider, err := newLocalProviderFromConfig(config)
+		if err != nil {
+			return err
+		}
+		return provider.TestConnection(ctx)
+	case ProviderSFTP:
+		provider, err := newSFTPProviderFromConfig(config)
+		if err != nil {
+			return err
+		}
+		defer provider.Close()
+		return provider.TestConnection(ctx)
 	default:
 		return fmt.Errorf("未知的云备份提供商: %s", providerType)
 	}
@@ -133,6 +204,11 @@ func IsConfigured(config *appconf.AppConfig) bool {
 		return config.S3Endpoint != "" && config.S3AccessKey != "" && config.BackupUserID != ""
 	case ProviderWebDAV:
 		return config.WebDAVURL != "" && config.BackupUserID != ""
+	case ProviderLocal:
+		return config.LocalSyncPath != "" && config.BackupUserID != ""
+	case ProviderSFTP:
+		return config.SFTPHost != "" && config.SFTPUsername != "" &&
+			(config.SFTPPassword != "" || config.SFTPPrivateKeyPath != "") && config.BackupUserID != ""
 	case ProviderUmbra:
 		if !config.UmbraAuthenticated || config.UmbraBaseURL == "" || strings.TrimSpace(version.UmbraOAuthClientID) == "" {
 			return false
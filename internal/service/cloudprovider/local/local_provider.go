@@ -0,0 +1,283 @@
+package local
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// tempPrefix 上传过程中的临时文件前缀，ListObjects 会跳过这些文件
+const tempPrefix = ".lunabox-tmp-"
+
+// Config 本地目录配置
+type Config struct {
+	// Path 目标目录，可以是普通路径或 file:// URL（例如挂载的 NAS 共享目录）
+	Path string
+}
+
+// Provider 把本地（或已挂载的网络）目录当作云存储使用。
+// 写入先落到同目录的临时文件再 rename，读者不会看到写了一半的对象。
+type Provider struct {
+	root string
+}
+
+// NewProvider 创建本地目录 Provider
+func NewProvider(cfg Config) (*Provider, error) {
+	root, err := parseRoot(cfg.Path)
+	if err != nil {
+		return nil, err
+	}
+	return &Provider{root: root}, nil
+}
+
+// parseRoot 把配置中的路径或 file:// URL 解析为绝对路径
+func parseRoot(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return "", fmt.Errorf("本地同步目录未配置")
+	}
+	if strings.HasPrefix(strings.ToLower(raw), "file:") {
+		u, err := url.Parse(raw)
+		if err != nil || (u.Host != "" && u.Host != "localhost") || u.RawQuery != "" || u.Fragment != "" {
+			return "", fmt.Errorf("本地同步目录地址无效")
+		}
+		raw = u.Path
+		// file:///C:/data → /C:/data
+		if runtime.GOOS == "windows" && len(raw) >= 3 && raw[0] == '/' && raw[2] == ':' {
+			raw = raw[1:]
+		}
+	}
+	root := filepath.Clean(filepath.FromSlash(raw))
+	if !filepath.IsAbs(root) {
+		return "", fmt.Errorf("本地同步目录必须是绝对路径")
+	}
+	return root, nil
+}
+
+// normalizeKey 统一 key 形态：正斜杠分隔、无首尾斜杠
+func normalizeKey(key string) string {
+	return strings.Trim(strings.ReplaceAll(key, "\\", "/"), "/")
+}
+
+// resolve 把 key 映射为根目录下的文件路径，拒绝越出根目录的 key
+func (p *Provider) resolve(key string) (string, error) {
+	key = normalizeKey(key)
+	for _, seg := range strings.Split(key, "/") {
+		if seg == ".." {
+			return "", fmt.Errorf("无效的对象路径: %s", key)
+		}
+	}
+	if key == "" {
+		return p.root, nil
+	}
+	return filepath.Join(p.root, filepath.FromSlash(key)), nil
+}
+
+// checkRoot 确认根目录存在；挂载点缺失时不自动创建，避免把数据写到本机磁盘上
+func (p *Provider) checkRoot() error {
+	info, err := os.Stat(p.root)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("本地同步目录不存在，请确认共享目录已挂载: %s", p.root)
+		}
+		return fmt.Errorf("访问本地同步目录失败: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("本地同步路径不是目录: %s", p.root)
+	}
+	return nil
+}
+
+func (p *Provider) UploadFile(ctx context.Context, cloudPath, localPath string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	target, err := p.resolve(cloudPath)
+	if err != nil {
+		return err
+	}
+	if err := p.checkRoot(); err != nil {
+		return err
+	}
+
+	src, err := os.Open(localPath)
+	if err != nil {
+		return fmt.Errorf("打开文件失败: %w", err)
+	}
+	defer src.Close()
+
+	dir := filepath.Dir(target)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+	tmp, err := os.CreateTemp(dir, tempPrefix+"*")
+	if err != nil {
+		return fmt.Errorf("创建临时文件失败: %w", err)
+	}
+	tmpPath := tmp.Name()
+	committed := false
+	defer func() {
+		if !committed {
+			_ = os.Remove(tmpPath)
+		}
+	}()
+
+	if _, err := io.Copy(tmp, src); err != nil {
+		tmp.Close()
+		return fmt.Errorf("上传失败: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("上传失败: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("上传失败: %w", err)
+	}
+	if err := os.Rename(tmpPath, target); err != nil {
+		return fmt.Errorf("上传失败: %w", err)
+	}
+	committed = true
+	return nil
+}
+
+func (p *Provider) DownloadFile(ctx context.Context, cloudPath, localPath string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	source, err := p.resolve(cloudPath)
+	if err != nil {
+		return err
+	}
+	src, err := os.Open(source)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("下载失败: object not found: %s", normalizeKey(cloudPath))
+		}
+		return fmt.Errorf("下载失败: %w", err)
+	}
+	defer src.Close()
+
+	file, err := os.Create(localPath)
+	if err != nil {
+		return fmt.Errorf("创建文件失败: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := io.Copy(file, src); err != nil {
+		return fmt.Errorf("写入文件失败: %w", err)
+	}
+	return nil
+}
+
+// ListObjects 与 WebDAV 一致，只返回目录下的直接文件
+func (p *Provider) ListObjects(ctx context.Context, prefix string) ([]string, error) {
+	entries, err := p.readDir(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	dirKey := normalizeKey(prefix)
+	keys := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), tempPrefix) {
+			continue
+		}
+		if dirKey == "" {
+			keys = append(keys, entry.Name())
+		} else {
+			keys = append(keys, dirKey+"/"+entry.Name())
+		}
+	}
+	return keys, nil
+}
+
+// ListDirs 返回 prefix 下直接子目录的名称
+func (p *Provider) ListDirs(ctx context.Context, prefix string) ([]string, error) {
+	entries, err := p.readDir(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+// readDir 读取 prefix 对应目录的直接子项，目录不存在时返回空
+func (p *Provider) readDir(ctx context.Context, prefix string) ([]os.DirEntry, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	dir, err := p.resolve(prefix)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("列出对象失败: %w", err)
+	}
+	return entries, nil
+}
+
+func (p *Provider) DeleteObject(ctx context.Context, key string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	target, err := p.resolve(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(target); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("删除失败: %w", err)
+	}
+	return nil
+}
+
+// TestConnection 检查根目录存在且可写
+func (p *Provider) TestConnection(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if err := p.checkRoot(); err != nil {
+		return err
+	}
+	probe, err := os.CreateTemp(p.root, tempPrefix+"probe-*")
+	if err != nil {
+		return fmt.Errorf("连接测试失败: 目录不可写: %w", err)
+	}
+	probe.Close()
+	_ = os.Remove(probe.Name())
+	return nil
+}
+
+func (p *Provider) EnsureDir(ctx context.Context, dirPath string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	dir, err := p.resolve(dirPath)
+	if err != nil {
+		return err
+	}
+	if err := p.checkRoot(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+	return nil
+}
+
+func (p *Provider) GetCloudPath(userID, subPath string) string {
+	return fmt.Sprintf("LunaBox/v1/%s/%s", userID, subPath)
+}
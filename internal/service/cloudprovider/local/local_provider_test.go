@@ -0,0 +1,119 @@
+package local
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func writeTemp(t *testing.T, content string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "payload")
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestProviderRoundTripAndListsDirectFilesOnly(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	provider, err := NewProvider(Config{Path: "file://" + filepath.ToSlash(root)})
+	if err != nil {
+		t.Fatalf("NewProvider failed: %v", err)
+	}
+	if err := provider.TestConnection(ctx); err != nil {
+		t.Fatalf("TestConnection failed: %v", err)
+	}
+
+	key := provider.GetCloudPath("user", "sync/library/manifest.json")
+	if err := provider.UploadFile(ctx, key, writeTemp(t, "v1")); err != nil {
+		t.Fatalf("UploadFile failed: %v", err)
+	}
+	if err := provider.UploadFile(ctx, key, writeTemp(t, "v2")); err != nil {
+		t.Fatalf("overwrite failed: %v", err)
+	}
+	if err := provider.UploadFile(ctx, provider.GetCloudPath("user", "sync/library/games/0.json"), writeTemp(t, "g")); err != nil {
+		t.Fatal(err)
+	}
+	// 残留的临时文件不应出现在列表中
+	dir := filepath.Join(root, "LunaBox", "v1", "user", "sync", "library")
+	if err := os.WriteFile(filepath.Join(dir, tempPrefix+"stale"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := provider.ListObjects(ctx, provider.GetCloudPath("user", "sync/library/"))
+	if err != nil {
+		t.Fatalf("ListObjects failed: %v", err)
+	}
+	sort.Strings(keys)
+	if want := []string{"LunaBox/v1/user/sync/library/manifest.json"}; !reflect.DeepEqual(keys, want) {
+		t.Fatalf("keys = %v, want %v", keys, want)
+	}
+	dirs, err := provider.ListDirs(ctx, provider.GetCloudPath("user", "sync/library/"))
+	if err != nil {
+		t.Fatalf("ListDirs failed: %v", err)
+	}
+	if want := []string{"games"}; !reflect.DeepEqual(dirs, want) {
+		t.Fatalf("dirs = %v, want %v", dirs, want)
+	}
+
+	destination := filepath.Join(t.TempDir(), "manifest.json")
+	if err := provider.DownloadFile(ctx, key, destination); err != nil {
+		t.Fatalf("DownloadFile failed: %v", err)
+	}
+	if data, _ := os.ReadFile(destination); string(data) != "v2" {
+		t.Fatalf("downloaded = %q, want v2", data)
+	}
+
+	if err := provider.DeleteObject(ctx, key); err != nil {
+		t.Fatalf("DeleteObject failed: %v", err)
+	}
+	if err := provider.DeleteObject(ctx, key); err != nil {
+		t.Fatalf("deleting a missing object should succeed: %v", err)
+	}
+	err = provider.DownloadFile(ctx, key, destination)
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("missing object error = %v, want not found", err)
+	}
+	if keys, err := provider.ListObjects(ctx, "does/not/exist"); err != nil || len(keys) != 0 {
+		t.Fatalf("missing dir list = %v, %v", keys, err)
+	}
+	if dirs, err := provider.ListDirs(ctx, "does/not/exist"); err != nil || len(dirs) != 0 {
+		t.Fatalf("missing dir subdirectories = %v, %v", dirs, err)
+	}
+}
+
+func TestProviderRejectsUnsafeKeysAndMissingMount(t *testing.T) {
+	ctx := context.Background()
+	if _, err := NewProvider(Config{Path: "relative/dir"}); err == nil {
+		t.Fatal("relative path should be rejected")
+	}
+
+	root := t.TempDir()
+	provider, err := NewProvider(Config{Path: root})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := provider.UploadFile(ctx, "../escape.txt", writeTemp(t, "x")); err == nil {
+		t.Fatal("key escaping the root should be rejected")
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(root), "escape.txt")); !os.IsNotExist(err) {
+		t.Fatal("file written outside the root")
+	}
+
+	unmounted, err := NewProvider(Config{Path: filepath.Join(root, "nas")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := unmounted.EnsureDir(ctx, "LunaBox/v1/user/saves"); err == nil {
+		t.Fatal("EnsureDir should fail when the root does not exist")
+	}
+	if _, err := os.Stat(filepath.Join(root, "nas")); !os.IsNotExist(err) {
+		t.Fatal("missing root must not be created")
+	}
+}
@@ -53,8 +53,9 @@ type OneDriveTokenResponse struct {
 
 // OneDriveItem OneDrive 文件/文件夹项
 type OneDriveItem struct {
-	ID   string `json:"id"`
-	Name string `json:"name"`
+	ID     string    `json:"id"`
+	Name   string    `json:"name"`
+	Folder *struct{} `json:"folder,omitempty"`
 }
 
 // OneDriveListResponse OneDrive 列表响应
@@ -400,9 +401,37 @@ func (p *OneDriveProvider) DownloadFile(ctx context.Context, cloudPath, localPat
 }
 
 func (p *OneDriveProvider) ListObjects(ctx context.Context, folderPath string) ([]string, error) {
-	if err := p.ensureValidToken(ctx); err != nil {
+	folderPath, items, err := p.listChildren(ctx, folderPath)
+	if err != nil {
 		return nil, err
 	}
+	allItems := make([]string, 0, len(items))
+	for _, item := range items {
+		allItems = append(allItems, folderPath+"/"+item.Name)
+	}
+	return allItems, nil
+}
+
+// ListDirs 返回 folderPath 下直接子文件夹的名称
+func (p *OneDriveProvider) ListDirs(ctx context.Context, folderPath string) ([]string, error) {
+	_, items, err := p.listChildren(ctx, folderPath)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(items))
+	for _, item := range items {
+		if item.Folder != nil {
+			names = append(names, item.Name)
+		}
+	}
+	return names, nil
+}
+
+// listChildren 分页读取文件夹的直接子项，返回规范化后的文件夹路径；文件夹不存在时返回空
+func (p *OneDriveProvider) listChildren(ctx context.Context, folderPath string) (string, []OneDriveItem, error) {
+	if err := p.ensureValidToken(ctx); err != nil {
+		return "", nil, err
+	}
 
 	if !strings.HasPrefix(folderPath, "/") {
 		folderPath = "/" + folderPath
@@ -411,37 +440,35 @@ func (p *OneDriveProvider) ListObjects(ctx context.Context, folderPath string) (
 
 	apiURL := fmt.Sprintf("%s/special/approot:%s:/children", oneDriveAPIBase, folderPath)
 
-	var allItems []string
+	var allItems []OneDriveItem
 	for apiURL != "" {
 		req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
 		if err != nil {
-			return nil, err
+			return "", nil, err
 		}
 		req.Header.Set("Authorization", "Bearer "+p.accessToken)
 
 		resp, err := p.httpClient.Do(req)
 		if err != nil {
-			return nil, err
+			return "", nil, err
 		}
 
 		if resp.StatusCode == 404 {
 			resp.Body.Close()
-			return []string{}, nil
+			return folderPath, nil, nil
 		}
 
 		var listResp OneDriveListResponse
 		if err := json.NewDecoder(resp.Body).Decode(&listResp); err != nil {
 			resp.Body.Close()
-			return nil, err
+			return "", nil, err
 		}
 		resp.Body.Close()
 
-		for _, item := range listResp.Value {
-			allItems = append(allItems, folderPath+"/"+item.Name)
-		}
+		allItems = append(allItems, listResp.Value...)
 		apiURL = listResp.NextLink
 	}
-	return allItems, nil
+	return folderPath, allItems, nil
 }
 
 func (p *OneDriveProvider) DeleteObject(ctx context.Context, remotePath string) error {
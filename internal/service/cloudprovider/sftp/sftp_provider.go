@@ -0,0 +1,497 @@
+package sftp
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	pkgsftp "github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+const (
+	defaultPort = 22
+	dialTimeout = 15 * time.Second
+	// idleTimeout 连接空闲超过该时长后自动断开；provider 按次创建且没有 Close，靠它回收连接
+	idleTimeout = 30 * time.Second
+	// tempPrefix 上传过程中的临时文件前缀，ListObjects 会跳过这些文件
+	tempPrefix = ".lunabox-tmp-"
+)
+
+// Config SFTP 配置
+type Config struct {
+	Host           string
+	Port           int
+	Username       string
+	Password       string
+	PrivateKeyPath string // OpenSSH / PEM 格式私钥文件路径
+	KeyPassphrase  string
+	// HostKeyFingerprint 服务器主机密钥指纹（SHA256:...）；为空时回退到 ~/.ssh/known_hosts
+	HostKeyFingerprint string
+	// RootPath 远端根目录；相对路径相对于登录用户的家目录
+	RootPath string
+}
+
+// Provider SFTP 云存储提供商。
+// 同一 Provider 内复用一条 SSH 连接，写入先落到临时文件再 rename。
+type Provider struct {
+	addr         string
+	root         string
+	clientConfig *ssh.ClientConfig
+
+	mu        sync.Mutex
+	sshClient *ssh.Client
+	client    *pkgsftp.Client
+	active    int
+	idleTimer *time.Timer
+}
+
+// HostKeyError 主机密钥未被信任时返回，携带服务器实际指纹便于用户核对后填写
+type HostKeyError struct {
+	Fingerprint string
+	Mismatch    bool
+}
+
+func (e *HostKeyError) Error() string {
+	if e.Mismatch {
+		return fmt.Sprintf("SFTP 服务器主机密钥与已保存的不一致 (%s)，可能存在中间人攻击", e.Fingerprint)
+	}
+	return fmt.Sprintf("未信任的 SFTP 服务器主机密钥 (%s)，请核对后填写主机指纹", e.Fingerprint)
+}
+
+// NewProvider 创建 SFTP Provider（不会立即连接）
+func NewProvider(cfg Config) (*Provider, error) {
+	host := strings.TrimSpace(cfg.Host)
+	if host == "" {
+		return nil, fmt.Errorf("SFTP 服务器地址未配置")
+	}
+	if strings.TrimSpace(cfg.Username) == "" {
+		return nil, fmt.Errorf("SFTP 用户名未配置")
+	}
+	port := cfg.Port
+	if port == 0 {
+		port = defaultPort
+	}
+	if port < 0 || port > 65535 {
+		return nil, fmt.Errorf("SFTP 端口无效")
+	}
+
+	var auth []ssh.AuthMethod
+	if keyPath := strings.TrimSpace(cfg.PrivateKeyPath); keyPath != "" {
+		signer, err := loadSigner(keyPath, cfg.KeyPassphrase)
+		if err != nil {
+			return nil, err
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+	if cfg.Password != "" {
+		auth = append(auth, ssh.Password(cfg.Password))
+	}
+	if len(auth) == 0 {
+		return nil, fmt.Errorf("SFTP 需要配置密码或私钥")
+	}
+
+	hostKeyCallback, err := newHostKeyCallback(cfg.HostKeyFingerprint)
+	if err != nil {
+		return nil, err
+	}
+
+	root := path.Clean(strings.ReplaceAll(strings.TrimSpace(cfg.RootPath), "\\", "/"))
+	if root == "" {
+		root = "."
+	}
+
+	return &Provider{
+		addr: net.JoinHostPort(host, strconv.Itoa(port)),
+		root: root,
+		clientConfig: &ssh.ClientConfig{
+			User:            cfg.Username,
+			Auth:            auth,
+			HostKeyCallback: hostKeyCallback,
+			Timeout:         dialTimeout,
+		},
+	}, nil
+}
+
+func loadSigner(keyPath, passphrase string) (ssh.Signer, error) {
+	raw, err := os.ReadFile(filepath.Clean(keyPath))
+	if err != nil {
+		return nil, fmt.Errorf("读取 SFTP 私钥失败: %w", err)
+	}
+	var signer ssh.Signer
+	if passphrase != "" {
+		signer, err = ssh.ParsePrivateKeyWithPassphrase(raw, []byte(passphrase))
+	} else {
+		signer, err = ssh.ParsePrivateKey(raw)
+	}
+	if err != nil {
+		var missing *ssh.PassphraseMissingError
+		if errors.As(err, &missing) {
+			return nil, fmt.Errorf("SFTP 私钥已加密，请填写私钥密码")
+		}
+		return nil, fmt.Errorf("解析 SFTP 私钥失败: %w", err)
+	}
+	return signer, nil
+}
+
+// newHostKeyCallback 优先校验配置的指纹，否则使用用户的 known_hosts；两者都没有时拒绝连接
+func newHostKeyCallback(fingerprint string) (ssh.HostKeyCallback, error) {
+	fingerprint = strings.TrimSpace(fingerprint)
+	if fingerprint != "" {
+		if !strings.HasPrefix(fingerprint, "SHA256:") {
+			fingerprint = "SHA256:" + fingerprint
+		}
+		return func(_ string, _ net.Addr, key ssh.PublicKey) error {
+			actual := ssh.FingerprintSHA256(key)
+			if subtle.ConstantTimeCompare([]byte(actual), []byte(fingerprint)) != 1 {
+				return &HostKeyError{Fingerprint: actual, Mismatch: true}
+			}
+			return nil
+		}, nil
+	}
+
+	var known ssh.HostKeyCallback
+	if home, err := os.UserHomeDir(); err == nil {
+		knownHostsPath := filepath.Join(home, ".ssh", "known_hosts")
+		if _, statErr := os.Stat(knownHostsPath); statErr == nil {
+			known, err = knownhosts.New(knownHostsPath)
+			if err != nil {
+				return nil, fmt.Errorf("读取 known_hosts 失败: %w", err)
+			}
+		}
+	}
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		if known == nil {
+			return &HostKeyError{Fingerprint: ssh.FingerprintSHA256(key)}
+		}
+		err := known(hostname, remote, key)
+		var keyErr *knownhosts.KeyError
+		if errors.As(err, &keyErr) {
+			return &HostKeyError{Fingerprint: ssh.FingerprintSHA256(key), Mismatch: len(keyErr.Want) > 0}
+		}
+		return err
+	}, nil
+}
+
+// acquire 取得（必要时建立）共享连接；调用方用完后必须调用 release
+func (p *Provider) acquire(ctx context.Context) (*pkgsftp.Client, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.idleTimer != nil {
+		p.idleTimer.Stop()
+		p.idleTimer = nil
+	}
+	if p.client == nil {
+		if err := p.connectLocked(ctx); err != nil {
+			return nil, err
+		}
+	}
+	p.active++
+	return p.client, nil
+}
+
+func (p *Provider) connectLocked(ctx context.Context) error {
+	dialer := net.Dialer{Timeout: dialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", p.addr)
+	if err != nil {
+		return fmt.Errorf("连接 SFTP 服务器失败: %w", err)
+	}
+	sshConn, chans, reqs, err := ssh.NewClientConn(conn, p.addr, p.clientConfig)
+	if err != nil {
+		conn.Close()
+		var hostKeyErr *HostKeyError
+		if errors.As(err, &hostKeyErr) {
+			return hostKeyErr
+		}
+		return fmt.Errorf("SSH 握手失败: %w", err)
+	}
+	sshClient := ssh.NewClient(sshConn, chans, reqs)
+	client, err := pkgsftp.NewClient(sshClient, pkgsftp.UseConcurrentWrites(true))
+	if err != nil {
+		sshClient.Close()
+		return fmt.Errorf("启动 SFTP 会话失败: %w", err)
+	}
+	p.sshClient = sshClient
+	p.client = client
+	return nil
+}
+
+// release 归还连接；失败来自断线时丢弃连接，下次 acquire 重连
+func (p *Provider) release(client *pkgsftp.Client, opErr error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.active--
+	if opErr != nil && errors.Is(opErr, pkgsftp.ErrSSHFxConnectionLost) && p.client == client {
+		p.closeLocked()
+	}
+	if p.active == 0 && p.client != nil {
+		p.idleTimer = time.AfterFunc(idleTimeout, p.closeIdle)
+	}
+}
+
+func (p *Provider) closeIdle() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.active == 0 {
+		p.closeLocked()
+	}
+}
+
+func (p *Provider) closeLocked() {
+	if p.client != nil {
+		p.client.Close()
+		p.client = nil
+	}
+	if p.sshClient != nil {
+		p.sshClient.Close()
+		p.sshClient = nil
+	}
+}
+
+// Close 立即断开连接
+func (p *Provider) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.idleTimer != nil {
+		p.idleTimer.Stop()
+		p.idleTimer = nil
+	}
+	p.closeLocked()
+	return nil
+}
+
+// normalizeKey 统一 key 形态：正斜杠分隔、无首尾斜杠
+func normalizeKey(key string) string {
+	return strings.Trim(strings.ReplaceAll(key, "\\", "/"), "/")
+}
+
+// remotePath 把 key 映射为根目录下的远端路径，拒绝越出根目录的 key
+func (p *Provider) remotePath(key string) (string, error) {
+	key = normalizeKey(key)
+	for _, seg := range strings.Split(key, "/") {
+		if seg == ".." {
+			return "", fmt.Errorf("无效的对象路径: %s", key)
+		}
+	}
+	if key == "" {
+		return p.root, nil
+	}
+	return path.Join(p.root, key), nil
+}
+
+func (p *Provider) UploadFile(ctx context.Context, cloudPath, localPath string) (err error) {
+	target, err := p.remotePath(cloudPath)
+	if err != nil {
+		return err
+	}
+	src, err := os.Open(localPath)
+	if err != nil {
+		return fmt.Errorf("打开文件失败: %w", err)
+	}
+	defer src.Close()
+
+	client, err := p.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { p.release(client, err) }()
+
+	dir := path.Dir(target)
+	if err := client.MkdirAll(dir); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+	tmpPath := path.Join(dir, fmt.Sprintf("%s%d-%s", tempPrefix, time.Now().UnixNano(), path.Base(target)))
+	dst, err := client.OpenFile(tmpPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return fmt.Errorf("上传失败: %w", err)
+	}
+	if _, err := dst.ReadFrom(src); err != nil {
+		dst.Close()
+		_ = client.Remove(tmpPath)
+		return fmt.Errorf("上传失败: %w", err)
+	}
+	if err := dst.Close(); err != nil {
+		_ = client.Remove(tmpPath)
+		return fmt.Errorf("上传失败: %w", err)
+	}
+	if err := p.replace(client, tmpPath, target); err != nil {
+		_ = client.Remove(tmpPath)
+		return fmt.Errorf("上传失败: %w", err)
+	}
+	return nil
+}
+
+// replace 原子替换目标文件；服务器不支持 posix-rename 扩展时退化为先删后改名
+func (p *Provider) replace(client *pkgsftp.Client, from, to string) error {
+	if _, ok := client.HasExtension("posix-rename@openssh.com"); ok {
+		return client.PosixRename(from, to)
+	}
+	if err := client.Remove(to); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return client.Rename(from, to)
+}
+
+func (p *Provider) DownloadFile(ctx context.Context, cloudPath, localPath string) (err error) {
+	source, err := p.remotePath(cloudPath)
+	if err != nil {
+		return err
+	}
+	client, err := p.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { p.release(client, err) }()
+
+	src, err := client.Open(source)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("下载失败: object not found: %s", normalizeKey(cloudPath))
+		}
+		return fmt.Errorf("下载失败: %w", err)
+	}
+	defer src.Close()
+
+	file, err := os.Create(localPath)
+	if err != nil {
+		return fmt.Errorf("创建文件失败: %w", err)
+	}
+	defer file.Close()
+
+	if _, err := src.WriteTo(file); err != nil {
+		return fmt.Errorf("写入文件失败: %w", err)
+	}
+	return nil
+}
+
+// ListObjects 与 WebDAV 一致，只返回目录下的直接文件
+func (p *Provider) ListObjects(ctx context.Context, prefix string) ([]string, error) {
+	entries, err := p.readDir(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	dirKey := normalizeKey(prefix)
+	keys := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() || strings.HasPrefix(entry.Name(), tempPrefix) {
+			continue
+		}
+		if dirKey == "" {
+			keys = append(keys, entry.Name())
+		} else {
+			keys = append(keys, dirKey+"/"+entry.Name())
+		}
+	}
+	return keys, nil
+}
+
+// ListDirs 返回 prefix 下直接子目录的名称
+func (p *Provider) ListDirs(ctx context.Context, prefix string) ([]string, error) {
+	entries, err := p.readDir(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+// readDir 读取 prefix 对应远端目录的直接子项，目录不存在时返回空
+func (p *Provider) readDir(ctx context.Context, prefix string) (entries []os.FileInfo, err error) {
+	dir, err := p.remotePath(prefix)
+	if err != nil {
+		return nil, err
+	}
+	client, err := p.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { p.release(client, err) }()
+
+	entries, err = client.ReadDirContext(ctx, dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("列出对象失败: %w", err)
+	}
+	return entries, nil
+}
+
+func (p *Provider) DeleteObject(ctx context.Context, key string) (err error) {
+	target, err := p.remotePath(key)
+	if err != nil {
+		return err
+	}
+	client, err := p.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { p.release(client, err) }()
+
+	if err := client.Remove(target); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("删除失败: %w", err)
+	}
+	return nil
+}
+
+// TestConnection 建立连接并确认根目录可用（不存在时创建）
+func (p *Provider) TestConnection(ctx context.Context) (err error) {
+	client, err := p.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { p.release(client, err) }()
+
+	if err := client.MkdirAll(p.root); err != nil {
+		return fmt.Errorf("连接测试失败: 无法创建根目录: %w", err)
+	}
+	info, err := client.Stat(p.root)
+	if err != nil {
+		return fmt.Errorf("连接测试失败: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("连接测试失败: 根路径不是目录: %s", p.root)
+	}
+	return nil
+}
+
+func (p *Provider) EnsureDir(ctx context.Context, dirPath string) (err error) {
+	dir, err := p.remotePath(dirPath)
+	if err != nil {
+		return err
+	}
+	client, err := p.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { p.release(client, err) }()
+
+	if err := client.MkdirAll(dir); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+	return nil
+}
+
+func (p *Provider) GetCloudPath(userID, subPath string) string {
+	return fmt.Sprintf("LunaBox/v1/%s/%s", userID, subPath)
+}
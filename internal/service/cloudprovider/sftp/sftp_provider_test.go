@@ -0,0 +1,188 @@
+package sftp
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pkgsftp "github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+// startTestServer 启动一个仅支持密码登录与 sftp 子系统的进程内 SSH 服务器
+func startTestServer(t *testing.T) (host string, port int, fingerprint string) {
+	t.Helper()
+	_, hostPriv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hostSigner, err := ssh.NewSignerFromKey(hostPriv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	config := &ssh.ServerConfig{
+		PasswordCallback: func(meta ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			if meta.User() == "luna" && string(password) == "secret" {
+				return nil, nil
+			}
+			return nil, errors.New("denied")
+		},
+	}
+	config.AddHostKey(hostSigner)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { listener.Close() })
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go serveConn(conn, config)
+		}
+	}()
+
+	addr := listener.Addr().(*net.TCPAddr)
+	return addr.IP.String(), addr.Port, ssh.FingerprintSHA256(hostSigner.PublicKey())
+}
+
+func serveConn(conn net.Conn, config *ssh.ServerConfig) {
+	_, chans, reqs, err := ssh.NewServerConn(conn, config)
+	if err != nil {
+		conn.Close()
+		return
+	}
+	go ssh.DiscardRequests(reqs)
+	for newChannel := range chans {
+		if newChannel.ChannelType() != "session" {
+			newChannel.Reject(ssh.UnknownChannelType, "unsupported")
+			continue
+		}
+		channel, requests, err := newChannel.Accept()
+		if err != nil {
+			continue
+		}
+		go func() {
+			for req := range requests {
+				ok := req.Type == "subsystem" && len(req.Payload) > 4 && string(req.Payload[4:]) == "sftp"
+				req.Reply(ok, nil)
+				if ok {
+					server, err := pkgsftp.NewServer(channel)
+					if err != nil {
+						channel.Close()
+						return
+					}
+					server.Serve()
+					channel.Close()
+				}
+			}
+		}()
+	}
+}
+
+func writeTemp(t *testing.T, content string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "payload")
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestProviderRoundTripOverSFTP(t *testing.T) {
+	ctx := context.Background()
+	host, port, fingerprint := startTestServer(t)
+	root := filepath.ToSlash(t.TempDir())
+
+	provider, err := NewProvider(Config{
+		Host:               host,
+		Port:               port,
+		Username:           "luna",
+		Password:           "secret",
+		HostKeyFingerprint: fingerprint,
+		RootPath:           root,
+	})
+	if err != nil {
+		t.Fatalf("NewProvider failed: %v", err)
+	}
+	defer provider.Close()
+
+	if err := provider.TestConnection(ctx); err != nil {
+		t.Fatalf("TestConnection failed: %v", err)
+	}
+	key := provider.GetCloudPath("user", "database/backup.zip")
+	if err := provider.UploadFile(ctx, key, writeTemp(t, "first")); err != nil {
+		t.Fatalf("UploadFile failed: %v", err)
+	}
+	if err := provider.UploadFile(ctx, key, writeTemp(t, "second")); err != nil {
+		t.Fatalf("overwrite failed: %v", err)
+	}
+
+	keys, err := provider.ListObjects(ctx, provider.GetCloudPath("user", "database/"))
+	if err != nil {
+		t.Fatalf("ListObjects failed: %v", err)
+	}
+	if len(keys) != 1 || keys[0] != "LunaBox/v1/user/database/backup.zip" {
+		t.Fatalf("keys = %v", keys)
+	}
+	dirs, err := provider.ListDirs(ctx, provider.GetCloudPath("user", ""))
+	if err != nil {
+		t.Fatalf("ListDirs failed: %v", err)
+	}
+	if len(dirs) != 1 || dirs[0] != "database" {
+		t.Fatalf("dirs = %v", dirs)
+	}
+
+	destination := filepath.Join(t.TempDir(), "backup.zip")
+	if err := provider.DownloadFile(ctx, key, destination); err != nil {
+		t.Fatalf("DownloadFile failed: %v", err)
+	}
+	if data, _ := os.ReadFile(destination); string(data) != "second" {
+		t.Fatalf("downloaded = %q, want second", data)
+	}
+
+	if err := provider.DeleteObject(ctx, key); err != nil {
+		t.Fatalf("DeleteObject failed: %v", err)
+	}
+	err = provider.DownloadFile(ctx, key, destination)
+	if err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Fatalf("missing object error = %v, want not found", err)
+	}
+}
+
+func TestProviderRejectsUnknownHostKey(t *testing.T) {
+	host, port, fingerprint := startTestServer(t)
+	provider, err := NewProvider(Config{
+		Host:               host,
+		Port:               port,
+		Username:           "luna",
+		Password:           "secret",
+		HostKeyFingerprint: "SHA256:" + strings.Repeat("A", 43),
+		RootPath:           filepath.ToSlash(t.TempDir()),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer provider.Close()
+
+	err = provider.TestConnection(context.Background())
+	var hostKeyErr *HostKeyError
+	if !errors.As(err, &hostKeyErr) || !hostKeyErr.Mismatch || hostKeyErr.Fingerprint != fingerprint {
+		t.Fatalf("TestConnection error = %v, want host key mismatch reporting %s", err, fingerprint)
+	}
+	if _, err := NewProvider(Config{Host: host, Port: port, Username: "luna"}); err == nil {
+		t.Fatal("provider without password or key should be rejected")
+	}
+	if _, err := NewProvider(Config{Host: host, Port: 70000, Username: "luna", Password: "x"}); err == nil {
+		t.Fatal("invalid port should be rejected")
+	}
+}
@@ -10,8 +10,17 @@ import (
 )
 
 const (
-	gameSubjectPrefix  = "game_"
-	coverSubjectPrefix = "cover_"
+	gameSubjectPrefix    = "game_"
+	coverSubjectPrefix   = "cover_"
+	historySubjectPrefix = "history_"
+)
+
+// 云同步历史（sync/history/...）映射为资产备份而不是同步记录：
+// 历史索引会随保留数量增长，可能超过同步记录的 256 KiB 上限。
+const (
+	historyIndexKind    = "index"
+	historyManifestKind = "manifests"
+	historyObjectKind   = "objects"
 )
 
 type listQuery struct {
@@ -51,6 +60,17 @@ func addressForSubPath(subPath string) (umbrsdk.BackupAddress, error) {
 			return umbrsdk.BackupAddress{}, err
 		}
 		return validatedAddress(umbrsdk.AssetBackup(subject, "cover_"+strings.TrimPrefix(ext, ".")))
+
+	case clean == "sync/history/index.json":
+		return validatedAddress(umbrsdk.AssetBackup(historySubjectPrefix+historyIndexKind, historyIndexKind))
+
+	case len(parts) == 4 && parts[0] == "sync" && parts[1] == "history" &&
+		(parts[2] == historyManifestKind || parts[2] == historyObjectKind) && strings.HasSuffix(parts[3], ".json"):
+		version := strings.TrimSuffix(parts[3], ".json")
+		if version == "" {
+			return umbrsdk.BackupAddress{}, fmt.Errorf("Umbra 不支持的同步历史路径: %s", subPath)
+		}
+		return validatedAddress(umbrsdk.AssetBackup(historySubjectPrefix+parts[2], version))
 	default:
 		return umbrsdk.BackupAddress{}, fmt.Errorf("Umbra 不支持的云端路径: %s", subPath)
 	}
@@ -63,6 +83,8 @@ func listQueryForSubPath(subPath string) (listQuery, error) {
 	switch {
 	case clean == "database":
 		return listQuery{filter: umbrsdk.BackupListFilter{Category: umbrsdk.CategoryDB}, prefix: "database/"}, nil
+	case clean == "saves":
+		return listQuery{filter: umbrsdk.BackupListFilter{Category: umbrsdk.CategoryGame}, prefix: "saves/"}, nil
 	case len(parts) == 2 && parts[0] == "saves":
 		subject, err := encodeSubject(gameSubjectPrefix, parts[1])
 		if err != nil {
@@ -74,6 +96,16 @@ func listQueryForSubPath(subPath string) (listQuery, error) {
 		}, nil
 	case clean == "sync/covers":
 		return listQuery{filter: umbrsdk.BackupListFilter{Category: umbrsdk.CategoryAsset}, prefix: "sync/covers/"}, nil
+	case clean == "sync/history":
+		return listQuery{
+			filter: umbrsdk.BackupListFilter{Category: umbrsdk.CategoryAsset, Subject: historySubjectPrefix + historyIndexKind},
+			prefix: "sync/history/",
+		}, nil
+	case clean == "sync/history/"+historyManifestKind || clean == "sync/history/"+historyObjectKind:
+		return listQuery{
+			filter: umbrsdk.BackupListFilter{Category: umbrsdk.CategoryAsset, Subject: historySubjectPrefix + parts[2]},
+			prefix: clean + "/",
+		}, nil
 	default:
 		return listQuery{}, fmt.Errorf("Umbra 不支持列出云端路径: %s", subPath)
 	}
@@ -90,6 +122,9 @@ func subPathForRecord(record umbrsdk.BackupRecord) (string, bool) {
 		}
 		return "saves/" + gameID + "/" + record.Version + ".zip", true
 	case umbrsdk.CategoryAsset:
+		if kind, ok := strings.CutPrefix(record.Subject, historySubjectPrefix); ok {
+			return subPathForHistoryRecord(kind, record.Version)
+		}
 		gameID, ok := decodeSubject(coverSubjectPrefix, record.Subject)
 		ext := strings.TrimPrefix(record.Version, "cover_")
 		if !ok || ext == "" || ext == record.Version {
@@ -100,6 +135,16 @@ func subPathForRecord(record umbrsdk.BackupRecord) (string, bool) {
 	return "", false
 }
 
+func subPathForHistoryRecord(kind, version string) (string, bool) {
+	switch kind {
+	case historyIndexKind:
+		return "sync/history/index.json", version == historyIndexKind
+	case historyManifestKind, historyObjectKind:
+		return "sync/history/" + kind + "/" + version + ".json", version != ""
+	}
+	return "", false
+}
+
 func normalizeSubPath(value string) (string, error) {
 	value = strings.Trim(strings.ReplaceAll(value, "\\", "/"), "/")
 	if value == "" {
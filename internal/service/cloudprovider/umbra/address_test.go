@@ -13,6 +13,9 @@ func TestAddressRoundTrip(t *testing.T) {
 		"database/lunabox_2026-07-10T12-30-45.zip",
 		"database/latest.zip",
 		"sync/covers/550e8400-e29b-41d4-a716-446655440000.webp",
+		"sync/history/index.json",
+		"sync/history/manifests/550e8400-e29b-41d4-a716-446655440000.json",
+		"sync/history/objects/game_metadata_sources-0123456789abcdef0123456789abcdef.json",
 	}
 
 	for _, want := range tests {
@@ -71,6 +74,38 @@ func TestListQueryForGame(t *testing.T) {
 	}
 }
 
+func TestListQueryForSyncHistory(t *testing.T) {
+	for _, dir := range []string{"sync/history/manifests", "sync/history/objects"} {
+		query, err := listQueryForSubPath(dir)
+		if err != nil {
+			t.Fatalf("listQueryForSubPath(%q) error = %v", dir, err)
+		}
+		if query.filter.Category != umbrsdk.CategoryAsset || query.filter.Subject == "" {
+			t.Fatalf("unexpected history list filter for %q: %#v", dir, query.filter)
+		}
+		if query.prefix != dir+"/" {
+			t.Fatalf("prefix = %q, want %q", query.prefix, dir+"/")
+		}
+	}
+}
+
+func TestListQueryForNamespaceRoots(t *testing.T) {
+	saves, err := listQueryForSubPath("saves/")
+	if err != nil {
+		t.Fatalf("listQueryForSubPath(saves/) error = %v", err)
+	}
+	if saves.filter.Category != umbrsdk.CategoryGame || saves.filter.Subject != "" || saves.prefix != "saves/" {
+		t.Fatalf("unexpected saves list query: %#v", saves)
+	}
+	history, err := listQueryForSubPath("sync/history/")
+	if err != nil {
+		t.Fatalf("listQueryForSubPath(sync/history/) error = %v", err)
+	}
+	if history.filter.Category != umbrsdk.CategoryAsset || history.filter.Subject != historySubjectPrefix+historyIndexKind || history.prefix != "sync/history/" {
+		t.Fatalf("unexpected history list query: %#v", history)
+	}
+}
+
 func TestAddressRejectsUnsupportedPath(t *testing.T) {
 	if _, err := addressForSubPath("other/file.bin"); err == nil {
 		t.Fatal("addressForSubPath() expected an error")
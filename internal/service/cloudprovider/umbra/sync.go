@@ -46,6 +46,18 @@ func isSyncLibrarySubPath(subPath string) bool {
 	return clean == "sync/library" || strings.HasPrefix(clean, "sync/library/")
 }
 
+// IsSyncRecordSubPath 判断子路径是否映射为 Umbra 同步记录（载荷必须是 JSON）。
+func IsSyncRecordSubPath(subPath string) bool {
+	_, ok, err := syncKeyForSubPath(subPath)
+	return ok && err == nil
+}
+
+// RequiresJSONPayload 同步记录只接受 JSON，加密层需要对其使用 JSON 包装的信封。
+func (p *Provider) RequiresJSONPayload(cloudPath string) bool {
+	_, ok, err := p.syncKeyForCloudPath(cloudPath)
+	return ok && err == nil
+}
+
 func (p *Provider) syncKeyForCloudPath(cloudPath string) (umbrsdk.SyncRecordKey, bool, error) {
 	subPath, err := p.subPath(cloudPath)
 	if err != nil {
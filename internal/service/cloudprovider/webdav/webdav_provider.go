@@ -254,6 +254,43 @@ func (p *Provider) DownloadFile(ctx context.Context, cloudPath, localPath string
 }
 
 func (p *Provider) ListObjects(ctx context.Context, prefix string) ([]string, error) {
+	children, err := p.listChildren(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	keys := make([]string, 0, len(children))
+	for _, child := range children {
+		// 只返回文件，跳过集合
+		if !child.collection {
+			keys = append(keys, child.key)
+		}
+	}
+	return keys, nil
+}
+
+// ListDirs 返回 prefix 下直接子集合（目录）的名称
+func (p *Provider) ListDirs(ctx context.Context, prefix string) ([]string, error) {
+	children, err := p.listChildren(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(children))
+	for _, child := range children {
+		if child.collection {
+			names = append(names, path.Base(child.key))
+		}
+	}
+	return names, nil
+}
+
+// davChild 是 PROPFIND Depth: 1 返回的一个直接子项
+type davChild struct {
+	key        string
+	collection bool
+}
+
+// listChildren 列出 prefix 对应集合的直接子项（不含集合自身），集合不存在时返回空
+func (p *Provider) listChildren(ctx context.Context, prefix string) ([]davChild, error) {
 	dirKey := normalizeKey(prefix)
 
 	req, err := p.newRequest(ctx, "PROPFIND", p.urlForCollection(dirKey), strings.NewReader(propfindBody))
@@ -270,7 +307,7 @@ func (p *Provider) ListObjects(ctx context.Context, prefix string) ([]string, er
 	defer resp.Body.Close()
 
 	if resp.StatusCode == http.StatusNotFound {
-		return []string{}, nil
+		return nil, nil
 	}
 	if resp.StatusCode != http.StatusMultiStatus && resp.StatusCode != http.StatusOK {
 		return nil, responseError("列出对象", resp)
@@ -286,12 +323,8 @@ func (p *Provider) ListObjects(ctx context.Context, prefix string) ([]string, er
 		requestPath = requestPath + "/" + dirKey
 	}
 
-	var keys []string
+	var children []davChild
 	for _, item := range ms.Responses {
-		// 只返回文件，跳过集合（含请求的目录自身）
-		if item.isCollection() {
-			continue
-		}
 		itemPath, ok := p.hrefToPath(item.Href)
 		if !ok {
 			continue
@@ -302,10 +335,10 @@ func (p *Provider) ListObjects(ctx context.Context, prefix string) ([]string, er
 		key := strings.TrimPrefix(itemPath, p.baseURL.Path)
 		key = normalizeKey(key)
 		if key != "" {
-			keys = append(keys, key)
+			children = append(children, davChild{key: key, collection: item.isCollection()})
 		}
 	}
-	return keys, nil
+	return children, nil
 }
 
 // hrefToPath 把 href（可能是绝对 URL 或编码后的路径）解析为解码后的路径
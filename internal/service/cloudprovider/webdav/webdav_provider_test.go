@@ -71,3 +71,38 @@ func TestDownloadFileUsesRestyRetryAndApplicationHeaders(t *testing.T) {
 		t.Fatalf("attempts = %d, want 2", attempts)
 	}
 }
+
+func TestListSeparatesFilesAndCollections(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Method != "PROPFIND" || req.URL.Path != "/root/saves/" || req.Header.Get("Depth") != "1" {
+			t.Fatalf("unexpected request: %s %s depth=%q", req.Method, req.URL.Path, req.Header.Get("Depth"))
+		}
+		w.WriteHeader(http.StatusMultiStatus)
+		_, _ = w.Write([]byte(`<?xml version="1.0"?>
+<d:multistatus xmlns:d="DAV:">
+  <d:response><d:href>/root/saves/</d:href><d:propstat><d:prop><d:resourcetype><d:collection/></d:resourcetype></d:prop></d:propstat></d:response>
+  <d:response><d:href>/root/saves/game-1/</d:href><d:propstat><d:prop><d:resourcetype><d:collection/></d:resourcetype></d:prop></d:propstat></d:response>
+  <d:response><d:href>/root/saves/notes.txt</d:href><d:propstat><d:prop><d:resourcetype/></d:prop></d:propstat></d:response>
+</d:multistatus>`))
+	}))
+	defer server.Close()
+
+	provider, err := NewProvider(Config{URL: server.URL + "/root"})
+	if err != nil {
+		t.Fatalf("NewProvider failed: %v", err)
+	}
+	keys, err := provider.ListObjects(context.Background(), "saves/")
+	if err != nil {
+		t.Fatalf("ListObjects failed: %v", err)
+	}
+	if len(keys) != 1 || keys[0] != "saves/notes.txt" {
+		t.Fatalf("keys = %v", keys)
+	}
+	dirs, err := provider.ListDirs(context.Background(), "saves/")
+	if err != nil {
+		t.Fatalf("ListDirs failed: %v", err)
+	}
+	if len(dirs) != 1 || dirs[0] != "game-1" {
+		t.Fatalf("dirs = %v", dirs)
+	}
+}
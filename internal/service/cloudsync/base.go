@@ -0,0 +1,80 @@
+package cloudsync
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// baseEntityKeys 是需要保存"上次同步基线"的实体类型。
+// 只有这些实体做逐字段三方合并，其余实体行级 LWW 即可（会话、标签、关联本身就是细粒度行）。
+var baseEntityKeys = []string{
+	EntityKeyGames,
+	EntityKeyGameProgresses,
+	EntityKeyGameReviews,
+}
+
+// SaveSyncBase 把本次同步完成后的桶内容保存为下一次三方合并的共同祖先。
+// 每行记录 manifest revision，读取时只有与 cloud_sync_state 中 _manifest 的 revision 一致才视为有效。
+func SaveSyncBase(ctx context.Context, db *sql.DB, revisionID string, buckets map[string]map[string]*BucketContent, now time.Time) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin cloud_sync_base tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, entityKey := range baseEntityKeys {
+		for _, ch := range bucketKeysSorted() {
+			payload, err := MarshalBucketFile(entityKey, ch, buckets[entityKey][ch])
+			if err != nil {
+				return fmt.Errorf("marshal base bucket %s/%s: %w", entityKey, ch, err)
+			}
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO cloud_sync_base (bucket_key, revision_id, payload, updated_at)
+				VALUES (?, ?, ?, ?)
+				ON CONFLICT (bucket_key) DO UPDATE SET
+					revision_id = EXCLUDED.revision_id,
+					payload = EXCLUDED.payload,
+					updated_at = EXCLUDED.updated_at
+			`, BucketKey(entityKey, ch), revisionID, string(payload), now); err != nil {
+				return fmt.Errorf("upsert cloud_sync_base %s/%s: %w", entityKey, ch, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit cloud_sync_base tx: %w", err)
+	}
+	return nil
+}
+
+// LoadSyncBase 读取指定 revision 的同步基线；没有或 revision 不匹配时返回空 Snapshot，
+// 此时合并退化为整条记录的 LWW。
+func LoadSyncBase(ctx context.Context, db *sql.DB, revisionID string) (Snapshot, error) {
+	if revisionID == "" {
+		return Snapshot{}, nil
+	}
+	rows, err := db.QueryContext(ctx, `SELECT payload FROM cloud_sync_base WHERE revision_id = ?`, revisionID)
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("query cloud_sync_base: %w", err)
+	}
+	defer rows.Close()
+
+	var base Snapshot
+	for rows.Next() {
+		var payload string
+		if err := rows.Scan(&payload); err != nil {
+			return Snapshot{}, fmt.Errorf("scan cloud_sync_base: %w", err)
+		}
+		entityKey, _, bc, err := UnmarshalBucketFile([]byte(payload))
+		if err != nil {
+			return Snapshot{}, err
+		}
+		appendBucketIntoSnapshot(&base, entityKey, &bc)
+	}
+	if err := rows.Err(); err != nil {
+		return Snapshot{}, fmt.Errorf("iterate cloud_sync_base: %w", err)
+	}
+	return base, nil
+}
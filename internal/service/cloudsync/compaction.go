@@ -0,0 +1,226 @@
+package cloudsync
+
+import (
+	"lunabox/internal/appconf"
+	"time"
+)
+
+// deviceRefreshInterval 是没有其他改动时设备登记的最长刷新间隔。
+// 登记只在改写 manifest 时顺带更新，空闲设备按此间隔补写一次，避免因长期"无事可做"被移出登记。
+const deviceRefreshInterval = 24 * time.Hour
+
+// tombstoneMaintenance 是一次同步中墓碑压缩与设备登记的计算结果，随最终 manifest 一起写出。
+type tombstoneMaintenance struct {
+	devices          map[string]time.Time
+	devicesSince     time.Time
+	compactedThrough time.Time
+	compacted        int
+	// due 表示即使 hash diff 没有工作，也需要改写 manifest（刷新登记、补写 published_at 或压缩墓碑）
+	due bool
+}
+
+// applyTo 把设备登记与压缩水位写入即将上传的 manifest。
+func (m tombstoneMaintenance) applyTo(manifest *Manifest) {
+	manifest.Devices = m.devices
+	manifest.DevicesSince = m.devicesSince
+	manifest.TombstonesCompactedThrough = m.compactedThrough
+}
+
+func (h *Helper) tombstoneHorizon() time.Duration {
+	return time.Duration(appconf.NormalizeCloudSyncTombstoneHorizonDays(h.config.CloudSyncTombstoneHorizonDays)) * 24 * time.Hour
+}
+
+// maintainTombstones 为最终墓碑补齐 published_at、更新设备登记并压缩过期墓碑。
+// reference 是远端当前的墓碑集合（未拉取 singleton 时即本地墓碑）：已在其中且删除时间一致的墓碑沿用其 published_at，
+// 其余墓碑视为本次首次发布。
+func (h *Helper) maintainTombstones(tombstones, reference []Tombstone, remote Manifest, now time.Time) ([]Tombstone, tombstoneMaintenance) {
+	horizon := h.tombstoneHorizon()
+	deviceID := h.currentDeviceID()
+
+	out := tombstoneMaintenance{
+		devices:          make(map[string]time.Time, len(remote.Devices)+1),
+		devicesSince:     remote.DevicesSince,
+		compactedThrough: remote.TombstonesCompactedThrough,
+	}
+	for id, seenAt := range remote.Devices {
+		if now.Sub(seenAt) <= horizon {
+			out.devices[id] = seenAt
+		}
+	}
+	lastSeen, registered := remote.Devices[deviceID]
+	out.devices[deviceID] = now
+	if out.devicesSince.IsZero() {
+		out.devicesSince = now
+	}
+	out.due = !registered || now.Sub(lastSeen) >= deviceRefreshInterval || remote.DevicesSince.IsZero()
+
+	stamped, restamped := stampTombstones(tombstones, reference, now)
+	kept, through := compactTombstones(stamped, out.devices, out.devicesSince, now, horizon)
+	out.compacted = len(stamped) - len(kept)
+	if through.After(out.compactedThrough) {
+		out.compactedThrough = through
+	}
+	out.due = out.due || restamped || out.compacted > 0
+	return kept, out
+}
+
+// stampTombstones 返回补齐 published_at 后的墓碑副本，以及是否有墓碑被重新盖章。
+func stampTombstones(tombstones, reference []Tombstone, now time.Time) ([]Tombstone, bool) {
+	published := make(map[string]Tombstone, len(reference))
+	for _, tombstone := range reference {
+		if !tombstone.PublishedAt.IsZero() {
+			published[tombstone.EntityType+"/"+tombstone.EntityID] = tombstone
+		}
+	}
+	out := make([]Tombstone, len(tombstones))
+	changed := false
+	for i, tombstone := range tombstones {
+		previous, ok := published[tombstone.EntityType+"/"+tombstone.EntityID]
+		switch {
+		case ok && previous.DeletedAt.Truncate(time.Second).Equal(tombstone.DeletedAt.Truncate(time.Second)):
+			tombstone.PublishedAt = previous.PublishedAt
+		default:
+			tombstone.PublishedAt = now
+		}
+		if !tombstone.PublishedAt.Equal(tombstones[i].PublishedAt) {
+			changed = true
+		}
+		out[i] = tombstone
+	}
+	return out, changed
+}
+
+// compactTombstones 丢弃删除时间早于期限、且发布后所有已登记设备都完成过同步的墓碑。
+// 设备登记不足一个期限时不压缩：此前一直在用的设备可能还没来得及登记。
+// 返回保留的墓碑与被丢弃墓碑中最晚的 published_at。
+func compactTombstones(tombstones []Tombstone, devices map[string]time.Time, devicesSince, now time.Time, horizon time.Duration) ([]Tombstone, time.Time) {
+	if now.Sub(devicesSince) < horizon {
+		return tombstones, time.Time{}
+	}
+	var oldestSeen time.Time
+	for _, seenAt := range devices {
+		if oldestSeen.IsZero() || seenAt.Before(oldestSeen) {
+			oldestSeen = seenAt
+		}
+	}
+	cutoff := now.Add(-horizon)
+
+	kept := make([]Tombstone, 0, len(tombstones))
+	var through time.Time
+	for _, tombstone := range tombstones {
+		if !tombstone.PublishedAt.IsZero() && tombstone.DeletedAt.Before(cutoff) && tombstone.PublishedAt.Before(oldestSeen) {
+			if tombstone.PublishedAt.After(through) {
+				through = tombstone.PublishedAt
+			}
+			continue
+		}
+		kept = append(kept, tombstone)
+	}
+	return kept, through
+}
+
+// missedCompaction 判断当前设备是否可能错过了已被压缩的墓碑：
+// 远端做过压缩，而本设备曾经同步过却不在（或早于水位出现在）设备登记中——通常是超过期限后才重新上线。
+func (h *Helper) missedCompaction(remote Manifest, cachedState map[string]SyncStateRow) bool {
+	if remote.TombstonesCompactedThrough.IsZero() {
+		return false
+	}
+	if _, synced := cachedState[StateKeyManifest]; !synced {
+		return false
+	}
+	seenAt, ok := remote.Devices[h.currentDeviceID()]
+	return !ok || !seenAt.After(remote.TombstonesCompactedThrough)
+}
+
+// inferCompactedDeletions 为错过压缩的设备补出被压缩掉的删除：
+// 本地记录在上次同步时已存在（updated_at 不晚于上次同步）、远端对应桶已拉取且完整，却既没有该记录也没有墓碑，
+// 说明它在远端被删除且墓碑已被压缩。删除必然发生在本设备上次同步之后，补出的墓碑以上次同步时间作为删除时间，
+// 保证它在 LWW 中胜过本地的旧版本；上次同步后本地又修改过的记录不在此列，按正常合并保留。
+func inferCompactedDeletions(local Snapshot, remote Manifest, remoteBuckets map[string]map[string]*BucketContent, remoteCategories []Category, categoriesPulled bool, remoteTombstones []Tombstone, lastSynced time.Time) []Tombstone {
+	deletedAt := lastSynced
+	if remote.TombstonesCompactedThrough.After(deletedAt) {
+		deletedAt = remote.TombstonesCompactedThrough
+	}
+	tombstoned := make(map[string]struct{}, len(remoteTombstones))
+	for _, tombstone := range remoteTombstones {
+		tombstoned[tombstone.EntityType+"/"+tombstone.EntityID] = struct{}{}
+	}
+
+	// 只信任内容条数与远端 manifest 一致的桶，避免把下载缺失的桶误判为整桶删除
+	pulled := func(entityKey, gameID string) (*BucketContent, bool) {
+		ch := BucketKeyOfGame(gameID)
+		bc := remoteBuckets[entityKey][ch]
+		if bc == nil || BucketItemCount(entityKey, bc) != remote.Buckets[entityKey][ch].Count {
+			return nil, false
+		}
+		return bc, true
+	}
+
+	var out []Tombstone
+	out = append(out, inferMissing(entityGame, local.Games, func(g Game) string { return g.ID }, func(g Game) time.Time { return g.UpdatedAt },
+		func(g Game) (bool, bool) {
+			bc, ok := pulled(EntityKeyGames, g.ID)
+			return ok, ok && containsRecord(bc.Games, g.ID, func(x Game) string { return x.ID })
+		}, tombstoned, lastSynced, deletedAt)...)
+	out = append(out, inferMissing(entityPlaySession, local.PlaySessions, func(s PlaySession) string { return s.ID }, func(s PlaySession) time.Time { return s.UpdatedAt },
+		func(s PlaySession) (bool, bool) {
+			bc, ok := pulled(EntityKeyPlaySessions, s.GameID)
+			return ok, ok && containsRecord(bc.PlaySessions, s.ID, func(x PlaySession) string { return x.ID })
+		}, tombstoned, lastSynced, deletedAt)...)
+	out = append(out, inferMissing(entityGameProgress, local.GameProgresses, func(p GameProgress) string { return p.ID }, func(p GameProgress) time.Time { return p.UpdatedAt },
+		func(p GameProgress) (bool, bool) {
+			bc, ok := pulled(EntityKeyGameProgresses, p.GameID)
+			return ok, ok && containsRecord(bc.GameProgresses, p.ID, func(x GameProgress) string { return x.ID })
+		}, tombstoned, lastSynced, deletedAt)...)
+	out = append(out, inferMissing(entityGameReview, local.GameReviews, func(r GameReview) string { return r.GameID }, func(r GameReview) time.Time { return r.UpdatedAt },
+		func(r GameReview) (bool, bool) {
+			bc, ok := pulled(EntityKeyGameReviews, r.GameID)
+			return ok, ok && containsRecord(bc.GameReviews, r.GameID, func(x GameReview) string { return x.GameID })
+		}, tombstoned, lastSynced, deletedAt)...)
+	out = append(out, inferMissing(entityGameTag, local.GameTags, tagKey, func(t GameTag) time.Time { return t.UpdatedAt },
+		func(t GameTag) (bool, bool) {
+			bc, ok := pulled(EntityKeyGameTags, t.GameID)
+			return ok, ok && containsRecord(bc.GameTags, tagKey(t), tagKey)
+		}, tombstoned, lastSynced, deletedAt)...)
+	out = append(out, inferMissing(entityGameMetadataSource, local.MetadataSources, metadataSourceKey, func(s MetadataSource) time.Time { return s.UpdatedAt },
+		func(s MetadataSource) (bool, bool) {
+			bc, ok := pulled(EntityKeyGameMetadataSources, s.GameID)
+			return ok, ok && containsRecord(bc.MetadataSources, metadataSourceKey(s), metadataSourceKey)
+		}, tombstoned, lastSynced, deletedAt)...)
+	out = append(out, inferMissing(entityGameCategory, local.GameCategories, relationKey, func(r Relation) time.Time { return r.UpdatedAt },
+		func(r Relation) (bool, bool) {
+			bc, ok := pulled(EntityKeyGameCategories, r.GameID)
+			return ok, ok && containsRecord(bc.GameCategories, relationKey(r), relationKey)
+		}, tombstoned, lastSynced, deletedAt)...)
+	if categoriesPulled && len(remoteCategories) == remote.Singletons[SingletonCategories].Count {
+		out = append(out, inferMissing(entityCategory, local.Categories, func(c Category) string { return c.ID }, func(c Category) time.Time { return c.UpdatedAt },
+			func(c Category) (bool, bool) {
+				return true, containsRecord(remoteCategories, c.ID, func(x Category) string { return x.ID })
+			}, tombstoned, lastSynced, deletedAt)...)
+	}
+	return out
+}
+
+// inferMissing 对单一实体类型执行 inferCompactedDeletions 的判断；lookup 返回（远端内容是否可信, 远端是否存在该记录）。
+func inferMissing[T any](entityType string, local []T, key func(T) string, updatedAt func(T) time.Time, lookup func(T) (bool, bool), tombstoned map[string]struct{}, lastSynced, deletedAt time.Time) []Tombstone {
+	var out []Tombstone
+	for _, item := range local {
+		id := key(item)
+		if _, ok := tombstoned[entityType+"/"+id]; ok || updatedAt(item).After(lastSynced) {
+			continue
+		}
+		if trusted, exists := lookup(item); trusted && !exists {
+			out = append(out, Tombstone{EntityType: entityType, EntityID: id, DeletedAt: deletedAt})
+		}
+	}
+	return out
+}
+
+func containsRecord[T any](items []T, id string, key func(T) string) bool {
+	for _, item := range items {
+		if key(item) == id {
+			return true
+		}
+	}
+	return false
+}
@@ -6,21 +6,27 @@ import (
 	"sync"
 
 	"lunabox/internal/service/cloudprovider"
+	"lunabox/internal/service/cloudprovider/local"
 	"lunabox/internal/service/cloudprovider/onedrive"
 	"lunabox/internal/service/cloudprovider/s3"
+	"lunabox/internal/service/cloudprovider/sftp"
 	"lunabox/internal/service/cloudprovider/umbra"
 )
 
 // ConcurrencyFor 根据具体 provider 类型返回安全的并发上限。
 // OneDrive Graph API 易被节流；S3 受网络/带宽限制可以放宽。
 func ConcurrencyFor(provider cloudprovider.CloudStorageProvider) int {
-	switch provider.(type) {
+	switch cloudprovider.Unwrap(provider).(type) {
 	case *onedrive.OneDriveProvider:
 		return ConcurrencyOneDrive
 	case *s3.S3Provider:
 		return ConcurrencyS3
 	case *umbra.Provider:
 		return ConcurrencyUmbra
+	case *local.Provider:
+		return ConcurrencyLocal
+	case *sftp.Provider:
+		return ConcurrencySFTP
 	default:
 		return ConcurrencyOneDrive
 	}
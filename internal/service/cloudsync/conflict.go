@@ -0,0 +1,192 @@
+package cloudsync
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"lunabox/internal/utils/dbutils"
+)
+
+const (
+	ConflictResolutionLocal  = "local"
+	ConflictResolutionRemote = "remote"
+)
+
+// ErrConflictNotFound 表示要处理的冲突记录不存在（可能已被处理或被后续同步覆盖）。
+var ErrConflictNotFound = errors.New("cloud sync conflict not found")
+
+// SaveConflicts 记录三方合并中自动按 LWW 处理的字段冲突；同一实体字段只保留最近一次。
+func SaveConflicts(ctx context.Context, db *sql.DB, conflicts []Conflict) error {
+	if len(conflicts) == 0 {
+		return nil
+	}
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin cloud_sync_conflicts tx: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, c := range conflicts {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO cloud_sync_conflicts (entity_type, entity_id, field, base_value, local_value, remote_value, resolution, detected_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT (entity_type, entity_id, field) DO UPDATE SET
+				base_value = EXCLUDED.base_value,
+				local_value = EXCLUDED.local_value,
+				remote_value = EXCLUDED.remote_value,
+				resolution = EXCLUDED.resolution,
+				detected_at = EXCLUDED.detected_at
+		`, c.EntityType, c.EntityID, c.Field, c.BaseValue, c.LocalValue, c.RemoteValue, c.Resolution, c.DetectedAt); err != nil {
+			return fmt.Errorf("upsert cloud_sync_conflicts %s/%s/%s: %w", c.EntityType, c.EntityID, c.Field, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("commit cloud_sync_conflicts tx: %w", err)
+	}
+	return nil
+}
+
+// ListConflicts 按检测时间倒序列出未处理的冲突。
+func ListConflicts(ctx context.Context, db *sql.DB) ([]Conflict, error) {
+	rows, err := db.QueryContext(ctx, `
+		SELECT entity_type, entity_id, field, base_value, local_value, remote_value, resolution, detected_at
+		FROM cloud_sync_conflicts
+		ORDER BY detected_at DESC, entity_type, entity_id, field
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("query cloud_sync_conflicts: %w", err)
+	}
+	defer rows.Close()
+
+	conflicts := make([]Conflict, 0)
+	for rows.Next() {
+		var c Conflict
+		if err := rows.Scan(&c.EntityType, &c.EntityID, &c.Field, &c.BaseValue, &c.LocalValue, &c.RemoteValue, &c.Resolution, &c.DetectedAt); err != nil {
+			return nil, fmt.Errorf("scan cloud_sync_conflicts: %w", err)
+		}
+		conflicts = append(conflicts, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("iterate cloud_sync_conflicts: %w", err)
+	}
+	return conflicts, nil
+}
+
+func loadConflict(ctx context.Context, db *sql.DB, entityType, entityID, field string) (Conflict, error) {
+	c := Conflict{EntityType: entityType, EntityID: entityID, Field: field}
+	err := db.QueryRowContext(ctx, `
+		SELECT base_value, local_value, remote_value, resolution, detected_at
+		FROM cloud_sync_conflicts
+		WHERE entity_type = ? AND entity_id = ? AND field = ?
+	`, entityType, entityID, field).Scan(&c.BaseValue, &c.LocalValue, &c.RemoteValue, &c.Resolution, &c.DetectedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return c, ErrConflictNotFound
+	}
+	if err != nil {
+		return c, fmt.Errorf("query cloud_sync_conflicts: %w", err)
+	}
+	return c, nil
+}
+
+func deleteConflict(ctx context.Context, exec ExecContexter, entityType, entityID, field string) error {
+	if _, err := exec.ExecContext(ctx, `DELETE FROM cloud_sync_conflicts WHERE entity_type = ? AND entity_id = ? AND field = ?`, entityType, entityID, field); err != nil {
+		return fmt.Errorf("delete cloud_sync_conflicts %s/%s/%s: %w", entityType, entityID, field, err)
+	}
+	return nil
+}
+
+// ResolveConflict 手动处理一条冲突：choice 为 local / remote。
+// 选择与自动结果一致时只删除记录；否则把所选取值写回本地并刷新 updated_at，下次同步推送到云端。
+func (h *Helper) ResolveConflict(entityType, entityID, field, choice string) error {
+	if choice != ConflictResolutionLocal && choice != ConflictResolutionRemote {
+		return fmt.Errorf("invalid conflict resolution: %s", choice)
+	}
+	conflict, err := loadConflict(h.ctx, h.db, entityType, entityID, field)
+	if err != nil {
+		return err
+	}
+	if choice == conflict.Resolution {
+		return deleteConflict(h.ctx, h.db, entityType, entityID, field)
+	}
+
+	value := conflict.LocalValue
+	if choice == ConflictResolutionRemote {
+		value = conflict.RemoteValue
+	}
+	return dbutils.WithDuckDBWriteLock(h.db, func() error {
+		tx, err := h.db.BeginTx(h.ctx, nil)
+		if err != nil {
+			return fmt.Errorf("begin conflict resolve tx: %w", err)
+		}
+		defer tx.Rollback()
+
+		if err := h.applyConflictValue(tx, entityType, entityID, field, value); err != nil {
+			return err
+		}
+		if err := deleteConflict(h.ctx, tx, entityType, entityID, field); err != nil {
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("commit conflict resolve tx: %w", err)
+		}
+		return nil
+	})
+}
+
+// applyConflictValue 把字段取值写回本地记录；记录已被删除时静默跳过。
+func (h *Helper) applyConflictValue(tx *sql.Tx, entityType, entityID, field, value string) error {
+	now := h.now()
+	switch entityType {
+	case entityGame:
+		games, err := h.listGames()
+		if err != nil {
+			return err
+		}
+		for _, game := range games {
+			if game.ID != entityID {
+				continue
+			}
+			patched, err := patchRecordField(gameFromModel(game), field, value, now)
+			if err != nil {
+				return err
+			}
+			coverURL, _, _ := h.lookupExistingGameCover(entityID)
+			return h.upsertGame(tx, gameToModel(patched, coverURL))
+		}
+	case entityGameProgress:
+		progresses, err := h.listGameProgresses()
+		if err != nil {
+			return err
+		}
+		for _, progress := range progresses {
+			if progress.ID != entityID {
+				continue
+			}
+			patched, err := patchRecordField(gameProgressFromModel(progress), field, value, now)
+			if err != nil {
+				return err
+			}
+			return h.upsertGameProgress(tx, gameProgressToModel(patched))
+		}
+	case entityGameReview:
+		reviews, err := h.listGameReviews()
+		if err != nil {
+			return err
+		}
+		for _, review := range reviews {
+			if review.GameID != entityID {
+				continue
+			}
+			patched, err := patchRecordField(gameReviewFromModel(review), field, value, now)
+			if err != nil {
+				return err
+			}
+			return h.upsertGameReview(tx, gameReviewToModel(patched))
+		}
+	default:
+		return fmt.Errorf("unsupported conflict entity type: %s", entityType)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+package cloudsync
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// mergeFields 以 base 为共同祖先，对 local / remote 做逐字段三方合并：
+//   - 两侧取值相同，或只有一侧相对 base 有改动 → 直接采用；
+//   - 两侧都改了且取值不同 → 按整条记录的 LWW 规则（updated_at 较新者，相同则远端）取值，并记录冲突。
+//
+// 字段以 JSON key 为粒度，比较前统一时间精度（与桶 hash 的归一化规则一致）。
+// 合并结果的 updated_at 取两侧较新值，保证 apply 时不会被本地旧行的 updated_at 挡住。
+func mergeFields[T any](entityType, entityID string, base, local, remote T, localUpdatedAt, remoteUpdatedAt, detectedAt time.Time) (T, []Conflict, error) {
+	var merged T
+	baseFields, err := recordFields(base)
+	if err != nil {
+		return merged, nil, err
+	}
+	localFields, err := recordFields(local)
+	if err != nil {
+		return merged, nil, err
+	}
+	remoteFields, err := recordFields(remote)
+	if err != nil {
+		return merged, nil, err
+	}
+
+	remoteWins := !localUpdatedAt.After(remoteUpdatedAt)
+	out := make(map[string]json.RawMessage, len(localFields))
+	var conflicts []Conflict
+	for _, key := range unionFieldKeys(baseFields, localFields, remoteFields) {
+		if key == "updated_at" {
+			continue
+		}
+		localValue, remoteValue, baseValue := localFields[key], remoteFields[key], baseFields[key]
+		var chosen json.RawMessage
+		switch {
+		case fieldValuesEqual(localValue, remoteValue), fieldValuesEqual(remoteValue, baseValue):
+			chosen = localValue
+		case fieldValuesEqual(localValue, baseValue):
+			chosen = remoteValue
+		default:
+			resolution := ConflictResolutionLocal
+			chosen = localValue
+			if remoteWins {
+				resolution = ConflictResolutionRemote
+				chosen = remoteValue
+			}
+			conflicts = append(conflicts, Conflict{
+				EntityType:  entityType,
+				EntityID:    entityID,
+				Field:       key,
+				BaseValue:   fieldValueString(baseValue),
+				LocalValue:  fieldValueString(localValue),
+				RemoteValue: fieldValueString(remoteValue),
+				Resolution:  resolution,
+				DetectedAt:  detectedAt,
+			})
+		}
+		if chosen != nil {
+			out[key] = chosen
+		}
+	}
+
+	updatedAt := remoteUpdatedAt
+	if localUpdatedAt.After(remoteUpdatedAt) {
+		updatedAt = localUpdatedAt
+	}
+	rawUpdatedAt, err := json.Marshal(updatedAt)
+	if err != nil {
+		return merged, nil, err
+	}
+	out["updated_at"] = rawUpdatedAt
+
+	raw, err := json.Marshal(out)
+	if err != nil {
+		return merged, nil, fmt.Errorf("encode merged %s %s: %w", entityType, entityID, err)
+	}
+	if err := json.Unmarshal(raw, &merged); err != nil {
+		return merged, nil, fmt.Errorf("decode merged %s %s: %w", entityType, entityID, err)
+	}
+	return merged, conflicts, nil
+}
+
+// patchRecordField 把 record 的某个 JSON 字段替换为 rawValue（"null" 表示清空），并刷新 updated_at。
+func patchRecordField[T any](record T, field, rawValue string, updatedAt time.Time) (T, error) {
+	var patched T
+	fields, err := recordFields(record)
+	if err != nil {
+		return patched, err
+	}
+	if rawValue == "" || rawValue == "null" {
+		delete(fields, field)
+	} else {
+		fields[field] = json.RawMessage(rawValue)
+	}
+	rawUpdatedAt, err := json.Marshal(updatedAt)
+	if err != nil {
+		return patched, err
+	}
+	fields["updated_at"] = rawUpdatedAt
+
+	raw, err := json.Marshal(fields)
+	if err != nil {
+		return patched, err
+	}
+	if err := json.Unmarshal(raw, &patched); err != nil {
+		return patched, fmt.Errorf("decode patched field %s: %w", field, err)
+	}
+	return patched, nil
+}
+
+func recordFields(record any) (map[string]json.RawMessage, error) {
+	raw, err := json.Marshal(record)
+	if err != nil {
+		return nil, fmt.Errorf("encode record fields: %w", err)
+	}
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, fmt.Errorf("decode record fields: %w", err)
+	}
+	return fields, nil
+}
+
+func unionFieldKeys(maps ...map[string]json.RawMessage) []string {
+	seen := make(map[string]struct{})
+	for _, m := range maps {
+		for key := range m {
+			seen[key] = struct{}{}
+		}
+	}
+	keys := make([]string, 0, len(seen))
+	for key := range seen {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// fieldValuesEqual 比较两个 JSON 字段值；缺失字段（omitempty）等价于 null。
+func fieldValuesEqual(left, right json.RawMessage) bool {
+	return reflect.DeepEqual(normalizedFieldValue(left), normalizedFieldValue(right))
+}
+
+func normalizedFieldValue(raw json.RawMessage) any {
+	if len(raw) == 0 {
+		return nil
+	}
+	var generic any
+	if err := json.Unmarshal(raw, &generic); err != nil {
+		return string(raw)
+	}
+	return normalizeTimes(generic)
+}
+
+func fieldValueString(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return "null"
+	}
+	return string(raw)
+}
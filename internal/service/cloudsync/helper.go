@@ -26,6 +26,9 @@ type Manifest = dto.CloudSyncManifest
 type BucketRef = dto.CloudSyncBucketRef
 type BucketFile = dto.CloudSyncBucketFile
 type CoverRef = dto.CloudSyncCoverRef
+type Conflict = dto.CloudSyncConflict
+type HistoryIndex = dto.CloudSyncHistoryIndex
+type RevisionRef = dto.CloudSyncRevisionRef
 
 const (
 	SchemaVersion   = 4
@@ -44,6 +47,12 @@ const (
 	CategoriesFileKey = "sync/library/categories.json"
 	TombstonesFileKey = "sync/library/tombstones.json"
 
+	// 历史版本：index 记录保留的 revision，manifests/ 存 manifest 副本，objects/ 按 hash 存桶内容
+	HistoryDir         = "sync/history"
+	HistoryIndexKey    = "sync/history/index.json"
+	HistoryManifestDir = "sync/history/manifests"
+	HistoryObjectDir   = "sync/history/objects"
+
 	// v2 分桶：每个实体类型 16 个桶，按 game_id 首个 hex 字符路由
 	BucketCount       = 16
 	BucketHexAlphabet = "0123456789abcdef"
@@ -55,6 +64,8 @@ const (
 	ConcurrencyOneDrive = 4
 	ConcurrencyS3       = 16
 	ConcurrencyUmbra    = 6
+	ConcurrencyLocal    = 8
+	ConcurrencySFTP     = 4
 
 	entityGame               = EntityGame
 	entityCategory           = EntityCategory
@@ -0,0 +1,352 @@
+package cloudsync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"lunabox/internal/appconf"
+	"lunabox/internal/applog"
+	"lunabox/internal/service/cloudprovider"
+	"lunabox/internal/service/cloudprovider/batchupload"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// ErrRevisionNotFound 表示历史索引中没有该 revision（从未存在或已被裁剪）。
+var ErrRevisionNotFound = errors.New("cloud sync revision not found")
+
+// ErrRevisionIncomplete 表示历史 revision 引用的对象缺失，无法完整还原。
+// 多设备同时同步时，一端的 GC 可能删除另一端尚未写入索引的对象，此时只能选择其他版本。
+var ErrRevisionIncomplete = errors.New("cloud sync revision is incomplete")
+
+// historyObjectName 返回历史对象的文件名。
+// 桶内容的 hash 已包含全部 item（含 game_id），同一 entity 下 hash 相同即内容相同，可安全去重。
+func historyObjectName(entityKey, hash string) string {
+	return entityKey + "-" + hash + ".json"
+}
+
+func (h *Helper) historyObjectKey(provider cloudprovider.CloudStorageProvider, name string) string {
+	return provider.GetCloudPath(h.config.BackupUserID, filepath.ToSlash(filepath.Join(HistoryObjectDir, name)))
+}
+
+func (h *Helper) historyManifestKey(provider cloudprovider.CloudStorageProvider, revisionID string) string {
+	return provider.GetCloudPath(h.config.BackupUserID, filepath.ToSlash(filepath.Join(HistoryManifestDir, revisionID+".json")))
+}
+
+// LoadHistoryIndex 读取 sync/history/index.json；不存在时返回空索引。
+func (h *Helper) LoadHistoryIndex(provider cloudprovider.CloudStorageProvider) (HistoryIndex, error) {
+	raw, exists, err := h.downloadToBytes(provider, provider.GetCloudPath(h.config.BackupUserID, HistoryIndexKey))
+	if err != nil {
+		return HistoryIndex{}, fmt.Errorf("download history index: %w", err)
+	}
+	if !exists {
+		return HistoryIndex{SchemaVersion: SchemaVersion}, nil
+	}
+	var index HistoryIndex
+	if err := json.Unmarshal(raw, &index); err != nil {
+		return HistoryIndex{}, fmt.Errorf("decode history index: %w", err)
+	}
+	return index, nil
+}
+
+func (h *Helper) saveHistoryIndex(provider cloudprovider.CloudStorageProvider, index HistoryIndex) error {
+	index.SchemaVersion = SchemaVersion
+	payload, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encode history index: %w", err)
+	}
+	if err := h.uploadBytes(provider, provider.GetCloudPath(h.config.BackupUserID, HistoryIndexKey), payload); err != nil {
+		return fmt.Errorf("upload history index: %w", err)
+	}
+	return nil
+}
+
+// RecordRevision 在 manifest 提交前把该 revision 写入历史：
+//  1. 把 manifest 引用、但历史对象目录中还没有的桶/单文件按 hash 上传；
+//  2. 上传 manifest 副本；
+//  3. 把 revision 插到索引头部，超出保留数量的旧 revision 被裁剪并触发 GC。
+//
+// buckets / categories / tombstones 必须与 manifest 描述的内容一致（即本次同步的最终结果）。
+// 若随后 manifest 提交失败，索引中会留下一个未生效的 revision；它本身是一致的合并结果，回滚到它同样安全。
+func (h *Helper) RecordRevision(
+	provider cloudprovider.CloudStorageProvider,
+	manifest Manifest,
+	buckets map[string]map[string]*BucketContent,
+	categories []Category,
+	tombstones []Tombstone,
+) error {
+	index, err := h.LoadHistoryIndex(provider)
+	if err != nil {
+		return err
+	}
+	if len(index.Revisions) > 0 && index.Revisions[0].RevisionID == manifest.RevisionID {
+		return nil
+	}
+
+	existingKeys, err := provider.ListObjects(h.ctx, provider.GetCloudPath(h.config.BackupUserID, HistoryObjectDir))
+	if err != nil {
+		return fmt.Errorf("list history objects: %w", err)
+	}
+	existing := make(map[string]struct{}, len(existingKeys))
+	for _, key := range existingKeys {
+		existing[key] = struct{}{}
+	}
+
+	ref := RevisionRef{
+		RevisionID: manifest.RevisionID,
+		ExportedAt: manifest.ExportedAt,
+		DeviceID:   manifest.DeviceID,
+	}
+	items := make([]batchupload.Item, 0)
+	tempPaths := make([]string, 0)
+	defer func() {
+		for _, tempPath := range tempPaths {
+			_ = os.Remove(tempPath)
+		}
+	}()
+	addObject := func(name string, payload func() ([]byte, error)) error {
+		ref.Objects = append(ref.Objects, name)
+		cloudKey := h.historyObjectKey(provider, name)
+		if _, ok := existing[cloudKey]; ok {
+			return nil
+		}
+		existing[cloudKey] = struct{}{}
+		raw, err := payload()
+		if err != nil {
+			return err
+		}
+		tempPath, err := writeUploadTempFile(raw)
+		if err != nil {
+			return err
+		}
+		tempPaths = append(tempPaths, tempPath)
+		items = append(items, batchupload.Item{CloudPath: cloudKey, LocalPath: tempPath})
+		return nil
+	}
+
+	for _, entityKey := range EntityKeys() {
+		for _, ch := range bucketKeysSorted() {
+			bucketRef := manifest.Buckets[entityKey][ch]
+			if bucketRef.Count == 0 {
+				continue
+			}
+			if entityKey == EntityKeyGames {
+				ref.GameCount += bucketRef.Count
+			}
+			entity, bucketChar := entityKey, ch
+			if err := addObject(historyObjectName(entityKey, bucketRef.Hash), func() ([]byte, error) {
+				return MarshalBucketFile(entity, bucketChar, buckets[entity][bucketChar])
+			}); err != nil {
+				return fmt.Errorf("prepare history object %s: %w", BucketKey(entityKey, ch), err)
+			}
+		}
+	}
+	for _, name := range []string{SingletonCategories, SingletonTombstones} {
+		singletonRef := manifest.Singletons[name]
+		if singletonRef.Count == 0 {
+			continue
+		}
+		singleton := name
+		if err := addObject(historyObjectName(name, singletonRef.Hash), func() ([]byte, error) {
+			return marshalSingletonFile(singleton, categories, tombstones)
+		}); err != nil {
+			return fmt.Errorf("prepare history object %s: %w", name, err)
+		}
+	}
+
+	if err := h.uploadFileItems(provider, items); err != nil {
+		return fmt.Errorf("upload history objects: %w", err)
+	}
+	payload, err := EncodeManifest(manifest)
+	if err != nil {
+		return err
+	}
+	if err := h.uploadBytes(provider, h.historyManifestKey(provider, manifest.RevisionID), payload); err != nil {
+		return fmt.Errorf("upload history manifest: %w", err)
+	}
+
+	index.Revisions = append([]RevisionRef{ref}, index.Revisions...)
+	limit := appconf.NormalizeCloudSyncHistoryLimit(h.config.CloudSyncHistoryLimit)
+	var pruned []RevisionRef
+	if len(index.Revisions) > limit {
+		pruned = append(pruned, index.Revisions[limit:]...)
+		index.Revisions = index.Revisions[:limit]
+	}
+	if err := h.saveHistoryIndex(provider, index); err != nil {
+		return err
+	}
+	applog.LogInfof(h.ctx, "CloudSync: recorded revision %s in history objects_uploaded=%d retained=%d pruned=%d", manifest.RevisionID, len(items), len(index.Revisions), len(pruned))
+
+	if len(pruned) > 0 {
+		if _, err := h.collectHistoryGarbage(provider, index); err != nil {
+			applog.LogWarningf(h.ctx, "CloudSync: history GC after pruning failed: %v", err)
+		}
+	}
+	return nil
+}
+
+// CollectGarbage 清理远端不再被引用的对象：
+//   - 历史目录中不在索引里的 manifest 副本与不被任何保留 revision 引用的对象；
+//   - 当前 manifest 未引用的实时桶文件（见 CleanOrphans）。
+//
+// 返回删除的历史文件数量。
+func (h *Helper) CollectGarbage(provider cloudprovider.CloudStorageProvider) (int, error) {
+	index, err := h.LoadHistoryIndex(provider)
+	if err != nil {
+		return 0, err
+	}
+	deleted, err := h.collectHistoryGarbage(provider, index)
+	if err != nil {
+		return deleted, err
+	}
+
+	manifest, exists, err := h.LoadRemoteManifest(provider)
+	if err != nil {
+		return deleted, fmt.Errorf("load remote manifest: %w", err)
+	}
+	if exists {
+		if err := h.CleanOrphans(provider, manifest); err != nil {
+			return deleted, err
+		}
+	}
+	return deleted, nil
+}
+
+func (h *Helper) collectHistoryGarbage(provider cloudprovider.CloudStorageProvider, index HistoryIndex) (int, error) {
+	referenced := make(map[string]struct{})
+	for _, revision := range index.Revisions {
+		referenced[h.historyManifestKey(provider, revision.RevisionID)] = struct{}{}
+		for _, name := range revision.Objects {
+			referenced[h.historyObjectKey(provider, name)] = struct{}{}
+		}
+	}
+
+	var candidates []string
+	for _, dir := range []string{HistoryManifestDir, HistoryObjectDir} {
+		keys, err := provider.ListObjects(h.ctx, provider.GetCloudPath(h.config.BackupUserID, dir))
+		if err != nil {
+			return 0, fmt.Errorf("list %s: %w", dir, err)
+		}
+		candidates = append(candidates, keys...)
+	}
+
+	deleted := 0
+	for _, key := range candidates {
+		if _, ok := referenced[key]; ok {
+			continue
+		}
+		// 跳过目录项（OneDrive children 可能返回子目录路径本身）
+		if !strings.HasSuffix(key, ".json") {
+			continue
+		}
+		if err := provider.DeleteObject(h.ctx, key); err != nil {
+			applog.LogWarningf(h.ctx, "CloudSync: failed to delete unreferenced history object %s: %v", key, err)
+			continue
+		}
+		deleted++
+	}
+	if deleted > 0 {
+		applog.LogInfof(h.ctx, "CloudSync: history GC deleted %d unreferenced objects", deleted)
+	}
+	return deleted, nil
+}
+
+// LoadRevisionSnapshot 从历史目录还原某个 revision 的完整数据。
+// 封面不做版本化，返回的 Covers 仅为 manifest 中的引用信息。
+func (h *Helper) LoadRevisionSnapshot(provider cloudprovider.CloudStorageProvider, revisionID string) (Snapshot, error) {
+	index, err := h.LoadHistoryIndex(provider)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	found := false
+	for _, revision := range index.Revisions {
+		if revision.RevisionID == revisionID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return Snapshot{}, ErrRevisionNotFound
+	}
+
+	raw, exists, err := h.downloadToBytes(provider, h.historyManifestKey(provider, revisionID))
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("download history manifest: %w", err)
+	}
+	if !exists {
+		return Snapshot{}, fmt.Errorf("history manifest %s: %w", revisionID, ErrRevisionIncomplete)
+	}
+	manifest, err := DecodeManifest(raw)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	if manifest.SchemaVersion > SchemaVersion {
+		return Snapshot{}, ErrManifestSchemaTooNew
+	}
+
+	type objectRef struct {
+		entityKey string
+		ch        string
+		name      string
+	}
+	refs := make([]objectRef, 0)
+	for _, entityKey := range EntityKeys() {
+		for _, ch := range bucketKeysSorted() {
+			bucketRef := manifest.Buckets[entityKey][ch]
+			if bucketRef.Count > 0 {
+				refs = append(refs, objectRef{entityKey: entityKey, ch: ch, name: historyObjectName(entityKey, bucketRef.Hash)})
+			}
+		}
+	}
+	for _, name := range []string{SingletonCategories, SingletonTombstones} {
+		if singletonRef := manifest.Singletons[name]; singletonRef.Count > 0 {
+			refs = append(refs, objectRef{entityKey: name, name: historyObjectName(name, singletonRef.Hash)})
+		}
+	}
+
+	buckets := EmptyBuckets()
+	var categories []Category
+	var tombstones []Tombstone
+	var mu sync.Mutex
+	err = runConcurrent(h.ctx, refs, ConcurrencyFor(provider), func(ctx context.Context, ref objectRef) error {
+		raw, exists, err := h.downloadToBytesCtx(ctx, provider, h.historyObjectKey(provider, ref.name))
+		if err != nil {
+			return fmt.Errorf("download history object %s: %w", ref.name, err)
+		}
+		if !exists {
+			return fmt.Errorf("history object %s: %w", ref.name, ErrRevisionIncomplete)
+		}
+		var file BucketFile
+		if err := json.Unmarshal(raw, &file); err != nil {
+			return fmt.Errorf("decode history object %s: %w", ref.name, err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		switch ref.entityKey {
+		case SingletonCategories:
+			categories = file.Categories
+		case SingletonTombstones:
+			tombstones = file.Tombstones
+		default:
+			_, _, bc, err := UnmarshalBucketFile(raw)
+			if err != nil {
+				return fmt.Errorf("decode history object %s: %w", ref.name, err)
+			}
+			buckets[ref.entityKey][ref.ch] = &bc
+		}
+		return nil
+	})
+	if err != nil {
+		return Snapshot{}, err
+	}
+
+	snapshot := Unbucketize(buckets, categories, tombstones)
+	snapshot.RevisionID = manifest.RevisionID
+	snapshot.ExportedAt = manifest.ExportedAt
+	snapshot.DeviceID = manifest.DeviceID
+	snapshot.Covers = remoteManifestToSnapshot(manifest).Covers
+	return snapshot, nil
+}
@@ -5,6 +5,7 @@ import (
 	"lunabox/internal/models"
 	"path/filepath"
 	"strings"
+	"time"
 )
 
 func gameFromModel(game models.Game) Game {
@@ -209,11 +210,15 @@ func gameTagToModel(tag GameTag) models.GameTag {
 }
 
 func tombstoneFromModel(tombstone models.SyncTombstone) Tombstone {
-	return Tombstone{
+	out := Tombstone{
 		EntityType: tombstone.EntityType,
 		EntityID:   tombstone.EntityID,
 		DeletedAt:  tombstone.DeletedAt,
 	}
+	if tombstone.PublishedAt != nil {
+		out.PublishedAt = *tombstone.PublishedAt
+	}
+	return out
 }
 
 func tombstoneToModel(tombstone Tombstone) models.SyncTombstone {
@@ -223,6 +228,7 @@ func tombstoneToModel(tombstone Tombstone) models.SyncTombstone {
 		ParentID:    "",
 		SecondaryID: "",
 		DeletedAt:   tombstone.DeletedAt,
+		PublishedAt: timePtr(tombstone.PublishedAt),
 	}
 }
 
@@ -237,3 +243,11 @@ func newLocalCover(game models.Game, coverPath, coverURL string) LocalCover {
 		LocalURL:  coverURL,
 	}
 }
+
+// timePtr 把零值时间映射为 NULL
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -8,6 +8,14 @@ import (
 )
 
 func (h *Helper) MergeSnapshots(local, remote Snapshot, remoteExists bool) Snapshot {
+	merged, _ := h.MergeSnapshotsWithBase(local, remote, Snapshot{}, remoteExists)
+	return merged
+}
+
+// MergeSnapshotsWithBase 在整条记录 LWW 的基础上，对 base（上次同步结果）中存在、
+// 且两侧都仍然存活的游戏、进度与评价做逐字段三方合并，返回无法自动合并的字段冲突。
+// base 为空时行为与 MergeSnapshots 完全一致。
+func (h *Helper) MergeSnapshotsWithBase(local, remote, base Snapshot, remoteExists bool) (Snapshot, []Conflict) {
 	ensureLegacyMetadataSources(&local)
 	ensureLegacyMetadataSources(&remote)
 	if !remoteExists {
@@ -15,8 +23,10 @@ func (h *Helper) MergeSnapshots(local, remote Snapshot, remoteExists bool) Snaps
 		local.ExportedAt = time.Now()
 		local.DeviceID = h.currentDeviceID()
 		sortSnapshot(&local)
-		return local
+		return local, nil
 	}
+	var conflicts []Conflict
+	detectedAt := h.now()
 
 	merged := Snapshot{
 		SchemaVersion: SchemaVersion,
@@ -29,8 +39,16 @@ func (h *Helper) MergeSnapshots(local, remote Snapshot, remoteExists bool) Snaps
 	remoteGameMap := mapGames(remote.Games)
 	localGameTombstones := mapTombstones(local.Tombstones, entityGame)
 	remoteGameTombstones := mapTombstones(remote.Tombstones, entityGame)
+	baseGameMap := mapGames(base.Games)
 	for _, id := range unionKeys4(localGameMap, remoteGameMap, localGameTombstones, remoteGameTombstones) {
 		if game, ok, deletedAt := mergeGame(localGameMap[id], remoteGameMap[id], localGameTombstones[id], remoteGameTombstones[id]); ok {
+			localGame, remoteGame := localGameMap[id], remoteGameMap[id]
+			if baseGame, hasBase := baseGameMap[id]; hasBase && !localGame.UpdatedAt.IsZero() && !remoteGame.UpdatedAt.IsZero() {
+				if fieldMerged, found, err := mergeFields(entityGame, id, baseGame, localGame, remoteGame, localGame.UpdatedAt, remoteGame.UpdatedAt, detectedAt); err == nil {
+					game = fieldMerged
+					conflicts = append(conflicts, found...)
+				}
+			}
 			merged.Games = append(merged.Games, game)
 		} else if !deletedAt.IsZero() {
 			merged.Tombstones = append(merged.Tombstones, Tombstone{EntityType: entityGame, EntityID: id, DeletedAt: deletedAt})
@@ -93,8 +111,16 @@ func (h *Helper) MergeSnapshots(local, remote Snapshot, remoteExists bool) Snaps
 	remoteProgressMap := mapGameProgresses(remote.GameProgresses)
 	localProgressTombstones := mapTombstones(local.Tombstones, entityGameProgress)
 	remoteProgressTombstones := mapTombstones(remote.Tombstones, entityGameProgress)
+	baseProgressMap := mapGameProgresses(base.GameProgresses)
 	for _, id := range unionKeys4(localProgressMap, remoteProgressMap, localProgressTombstones, remoteProgressTombstones) {
 		if progress, ok, deletedAt := mergeGameProgress(localProgressMap[id], remoteProgressMap[id], localProgressTombstones[id], remoteProgressTombstones[id]); ok {
+			localProgress, remoteProgress := localProgressMap[id], remoteProgressMap[id]
+			if baseProgress, hasBase := baseProgressMap[id]; hasBase && !localProgress.UpdatedAt.IsZero() && !remoteProgress.UpdatedAt.IsZero() {
+				if fieldMerged, found, err := mergeFields(entityGameProgress, id, baseProgress, localProgress, remoteProgress, localProgress.UpdatedAt, remoteProgress.UpdatedAt, detectedAt); err == nil {
+					progress = fieldMerged
+					conflicts = append(conflicts, found...)
+				}
+			}
 			if _, gameExists := mergedGameMap[progress.GameID]; gameExists {
 				merged.GameProgresses = append(merged.GameProgresses, progress)
 			}
@@ -107,8 +133,16 @@ func (h *Helper) MergeSnapshots(local, remote Snapshot, remoteExists bool) Snaps
 	remoteReviewMap := mapGameReviews(remote.GameReviews)
 	localReviewTombstones := mapTombstones(local.Tombstones, entityGameReview)
 	remoteReviewTombstones := mapTombstones(remote.Tombstones, entityGameReview)
+	baseReviewMap := mapGameReviews(base.GameReviews)
 	for _, id := range unionKeys4(localReviewMap, remoteReviewMap, localReviewTombstones, remoteReviewTombstones) {
 		if review, ok, deletedAt := mergeGameReview(localReviewMap[id], remoteReviewMap[id], localReviewTombstones[id], remoteReviewTombstones[id]); ok {
+			localReview, remoteReview := localReviewMap[id], remoteReviewMap[id]
+			if baseReview, hasBase := baseReviewMap[id]; hasBase && !localReview.UpdatedAt.IsZero() && !remoteReview.UpdatedAt.IsZero() {
+				if fieldMerged, found, err := mergeFields(entityGameReview, id, baseReview, localReview, remoteReview, localReview.UpdatedAt, remoteReview.UpdatedAt, detectedAt); err == nil {
+					review = fieldMerged
+					conflicts = append(conflicts, found...)
+				}
+			}
 			if _, gameExists := mergedGameMap[review.GameID]; gameExists {
 				merged.GameReviews = append(merged.GameReviews, review)
 			}
@@ -133,7 +167,7 @@ func (h *Helper) MergeSnapshots(local, remote Snapshot, remoteExists bool) Snaps
 
 	merged.Covers = h.mergeCovers(local, remote, merged.Games)
 	sortSnapshot(&merged)
-	return merged
+	return merged, conflicts
 }
 
 func (h *Helper) mergeCovers(local, remote Snapshot, mergedGames []Game) []CoverAsset {
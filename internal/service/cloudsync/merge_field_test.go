@@ -0,0 +1,98 @@
+package cloudsync
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMergeSnapshotsWithBaseKeepsEditsToDifferentFields(t *testing.T) {
+	synced := time.Date(2026, 8, 9, 0, 0, 0, 0, time.UTC)
+	base := Game{ID: "a-game", Name: "Game", Status: "not_started", Summary: "old", CreatedAt: synced, UpdatedAt: synced}
+
+	local := base
+	local.Summary = "edited on laptop"
+	local.UpdatedAt = synced.Add(time.Hour)
+
+	remote := base
+	remote.Status = "playing"
+	remote.UpdatedAt = synced.Add(2 * time.Hour)
+
+	helper := &Helper{}
+	merged, conflicts := helper.MergeSnapshotsWithBase(
+		Snapshot{Games: []Game{local}},
+		Snapshot{Games: []Game{remote}},
+		Snapshot{Games: []Game{base}},
+		true,
+	)
+
+	if len(conflicts) != 0 {
+		t.Fatalf("expected no conflicts, got %+v", conflicts)
+	}
+	if len(merged.Games) != 1 {
+		t.Fatalf("expected one merged game, got %+v", merged.Games)
+	}
+	game := merged.Games[0]
+	if game.Summary != "edited on laptop" || game.Status != "playing" {
+		t.Fatalf("field-level merge lost an edit: %+v", game)
+	}
+	if !game.UpdatedAt.Equal(remote.UpdatedAt) {
+		t.Fatalf("merged updated_at = %v, want newer side %v", game.UpdatedAt, remote.UpdatedAt)
+	}
+}
+
+func TestMergeSnapshotsWithBaseRecordsConflictingFieldEdits(t *testing.T) {
+	synced := time.Date(2026, 8, 9, 0, 0, 0, 0, time.UTC)
+	rating := 6
+	base := GameReview{GameID: "a-game", Rating: &rating, Content: "base", CreatedAt: synced, UpdatedAt: synced}
+
+	localRating := 8
+	local := base
+	local.Rating = &localRating
+	local.Content = "local text"
+	local.UpdatedAt = synced.Add(2 * time.Hour)
+
+	remoteRating := 9
+	remote := base
+	remote.Rating = &remoteRating
+	remote.IsSpoiler = true
+	remote.UpdatedAt = synced.Add(time.Hour)
+
+	helper := &Helper{}
+	merged, conflicts := helper.MergeSnapshotsWithBase(
+		Snapshot{Games: []Game{{ID: "a-game", UpdatedAt: synced}}, GameReviews: []GameReview{local}},
+		Snapshot{Games: []Game{{ID: "a-game", UpdatedAt: synced}}, GameReviews: []GameReview{remote}},
+		Snapshot{GameReviews: []GameReview{base}},
+		true,
+	)
+
+	if len(conflicts) != 1 {
+		t.Fatalf("expected exactly one conflict, got %+v", conflicts)
+	}
+	conflict := conflicts[0]
+	if conflict.EntityType != EntityGameReview || conflict.Field != "rating" || conflict.Resolution != ConflictResolutionLocal {
+		t.Fatalf("unexpected conflict: %+v", conflict)
+	}
+	if conflict.BaseValue != "6" || conflict.LocalValue != "8" || conflict.RemoteValue != "9" {
+		t.Fatalf("unexpected conflict values: %+v", conflict)
+	}
+
+	review := merged.GameReviews[0]
+	if review.Rating == nil || *review.Rating != 8 || review.Content != "local text" || !review.IsSpoiler {
+		t.Fatalf("unexpected merged review: %+v", review)
+	}
+}
+
+func TestMergeSnapshotsWithoutBaseFallsBackToRecordLWW(t *testing.T) {
+	synced := time.Date(2026, 8, 9, 0, 0, 0, 0, time.UTC)
+	local := Game{ID: "a-game", Name: "Game", Summary: "local", UpdatedAt: synced.Add(time.Hour)}
+	remote := Game{ID: "a-game", Name: "Game", Status: "playing", UpdatedAt: synced.Add(2 * time.Hour)}
+
+	helper := &Helper{}
+	merged, conflicts := helper.MergeSnapshotsWithBase(Snapshot{Games: []Game{local}}, Snapshot{Games: []Game{remote}}, Snapshot{}, true)
+	if len(conflicts) != 0 {
+		t.Fatalf("expected no conflicts without base, got %+v", conflicts)
+	}
+	if merged.Games[0].Summary != "" || merged.Games[0].Status != "playing" {
+		t.Fatalf("expected remote record to win as a whole, got %+v", merged.Games[0])
+	}
+}
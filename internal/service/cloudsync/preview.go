@@ -0,0 +1,198 @@
+package cloudsync
+
+import (
+	"fmt"
+	"lunabox/internal/service/cloudprovider"
+	"sort"
+	"time"
+)
+
+const (
+	RecordAdded   = "added"
+	RecordUpdated = "updated"
+	RecordDeleted = "deleted"
+
+	// EntityTombstone 是预览报告中墓碑变化使用的实体类型；EntityID 形如 "game/<id>"。
+	EntityTombstone = "tombstone"
+)
+
+// SyncPreview 是一次同步演练的结果：Local / Remote 分别描述同步完成后本地与云端将发生的变化。
+// 封面文件不在预览范围内。
+type SyncPreview struct {
+	RemoteExists bool
+	Local        []RecordChange
+	Remote       []RecordChange
+	Conflicts    []Conflict
+	// GameNames 汇总两侧与合并结果中的游戏名，便于上层为子记录展示所属游戏
+	GameNames map[string]string
+}
+
+// PreviewSync 以真实远端数据走一遍与 SyncToCloud 相同的合并流程，但不写入本地数据库与远端任何文件。
+func (h *Helper) PreviewSync(provider cloudprovider.CloudStorageProvider) (SyncPreview, error) {
+	preview := SyncPreview{GameNames: make(map[string]string)}
+	localState, err := h.BuildLocalState()
+	if err != nil {
+		return preview, fmt.Errorf("build local state: %w", err)
+	}
+
+	remoteManifest, manifestExists, err := h.LoadRemoteManifest(provider)
+	if err != nil {
+		return preview, fmt.Errorf("load remote manifest: %w", err)
+	}
+
+	var final, remoteBefore Snapshot
+	if !manifestExists {
+		v1Snapshot, v1Exists, err := h.LoadV1Snapshot(provider)
+		if err != nil {
+			return preview, fmt.Errorf("probe v1 snapshot: %w", err)
+		}
+		preview.RemoteExists = v1Exists
+		final = h.MergeSnapshots(localState.Snapshot, v1Snapshot, v1Exists)
+		remoteBefore = v1Snapshot
+	} else {
+		preview.RemoteExists = true
+		plan, err := h.planIncrementalSync(provider, localState, remoteManifest)
+		if err != nil {
+			return preview, err
+		}
+		final = plan.finalSnapshot
+		preview.Conflicts = plan.conflicts
+		remoteBefore, err = h.previewRemoteSnapshot(provider, remoteManifest, plan)
+		if err != nil {
+			return preview, err
+		}
+	}
+
+	preview.Local = ChangesBetween(localState.Snapshot, final)
+	preview.Remote = ChangesBetween(remoteBefore, final)
+	for _, snapshot := range []Snapshot{remoteBefore, localState.Snapshot, final} {
+		for _, game := range snapshot.Games {
+			preview.GameNames[game.ID] = game.Name
+		}
+	}
+	return preview, nil
+}
+
+// previewRemoteSnapshot 还原远端当前的完整内容，用于计算云端一侧的变化。
+// 与合并结果 hash 相同的桶直接复用合并结果，已拉取的桶复用 plan，其余桶才需要额外下载。
+func (h *Helper) previewRemoteSnapshot(provider cloudprovider.CloudStorageProvider, remoteManifest Manifest, plan incrementalPlan) (Snapshot, error) {
+	finalBuckets := Bucketize(plan.finalSnapshot)
+	finalManifest, err := BuildManifestFromBuckets(finalBuckets, plan.finalSnapshot.Categories, plan.finalSnapshot.Tombstones, nil, h.currentDeviceID(), "", h.now())
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("build preview manifest: %w", err)
+	}
+
+	buckets := EmptyBuckets()
+	var missing []string
+	for _, entityKey := range EntityKeys() {
+		for _, ch := range bucketKeysSorted() {
+			remoteRef := remoteManifest.Buckets[entityKey][ch]
+			switch {
+			case remoteRef.Count == 0:
+			case plan.remoteBuckets[entityKey][ch] != nil:
+				buckets[entityKey][ch] = plan.remoteBuckets[entityKey][ch]
+			case remoteRef.Hash == finalManifest.Buckets[entityKey][ch].Hash:
+				buckets[entityKey][ch] = finalBuckets[entityKey][ch]
+			default:
+				missing = append(missing, BucketKey(entityKey, ch))
+			}
+		}
+	}
+	if len(missing) > 0 {
+		fetched, err := h.LoadRemoteBuckets(provider, missing)
+		if err != nil {
+			return Snapshot{}, fmt.Errorf("load remote buckets for preview: %w", err)
+		}
+		for entityKey, byBucket := range fetched {
+			for ch, bc := range byBucket {
+				buckets[entityKey][ch] = bc
+			}
+		}
+	}
+
+	categories, tombstones := plan.remoteCategories, plan.remoteTombstones
+	var singletonsToFetch []string
+	if !containsString(plan.diff.SingletonsToPull, SingletonCategories) {
+		categories = nil
+		if ref := remoteManifest.Singletons[SingletonCategories]; ref.Count > 0 {
+			if ref.Hash == finalManifest.Singletons[SingletonCategories].Hash {
+				categories = plan.finalSnapshot.Categories
+			} else {
+				singletonsToFetch = append(singletonsToFetch, SingletonCategories)
+			}
+		}
+	}
+	if !containsString(plan.diff.SingletonsToPull, SingletonTombstones) {
+		tombstones = nil
+		if ref := remoteManifest.Singletons[SingletonTombstones]; ref.Count > 0 {
+			if ref.Hash == finalManifest.Singletons[SingletonTombstones].Hash {
+				tombstones = plan.finalSnapshot.Tombstones
+			} else {
+				singletonsToFetch = append(singletonsToFetch, SingletonTombstones)
+			}
+		}
+	}
+	if len(singletonsToFetch) > 0 {
+		cats, tombs, _, err := h.LoadRemoteSingletons(provider, singletonsToFetch)
+		if err != nil {
+			return Snapshot{}, fmt.Errorf("load remote singletons for preview: %w", err)
+		}
+		if containsString(singletonsToFetch, SingletonCategories) {
+			categories = cats
+		}
+		if containsString(singletonsToFetch, SingletonTombstones) {
+			tombstones = tombs
+		}
+	}
+
+	return Unbucketize(buckets, categories, tombstones), nil
+}
+
+// ChangesBetween 列出从 before 变为 after 时每条记录的新增、修改（忽略仅 updated_at 变化）与删除，
+// 以及墓碑的新增与移除。
+func ChangesBetween(before, after Snapshot) []RecordChange {
+	changes := DiffSnapshots(before, after)
+	for i := range changes {
+		switch changes[i].Change {
+		case RecordOnlyLocal:
+			changes[i].Change = RecordDeleted
+		case RecordOnlyRevision:
+			changes[i].Change = RecordAdded
+		case RecordChanged:
+			changes[i].Change = RecordUpdated
+		}
+	}
+
+	beforeTombstones := make(map[string]Tombstone, len(before.Tombstones))
+	for _, tombstone := range before.Tombstones {
+		beforeTombstones[tombstone.EntityType+"/"+tombstone.EntityID] = tombstone
+	}
+	afterTombstones := make(map[string]Tombstone, len(after.Tombstones))
+	for _, tombstone := range after.Tombstones {
+		afterTombstones[tombstone.EntityType+"/"+tombstone.EntityID] = tombstone
+	}
+	describe := func(key string, tombstone Tombstone, change string) RecordChange {
+		out := RecordChange{EntityType: EntityTombstone, EntityID: key, Change: change}
+		if tombstone.EntityType == entityGame {
+			out.GameID = tombstone.EntityID
+		}
+		return out
+	}
+	var tombstoneChanges []RecordChange
+	for key, tombstone := range afterTombstones {
+		previous, ok := beforeTombstones[key]
+		switch {
+		case !ok:
+			tombstoneChanges = append(tombstoneChanges, describe(key, tombstone, RecordAdded))
+		case !previous.DeletedAt.Truncate(time.Second).Equal(tombstone.DeletedAt.Truncate(time.Second)):
+			tombstoneChanges = append(tombstoneChanges, describe(key, tombstone, RecordUpdated))
+		}
+	}
+	for key, tombstone := range beforeTombstones {
+		if _, ok := afterTombstones[key]; !ok {
+			tombstoneChanges = append(tombstoneChanges, describe(key, tombstone, RecordDeleted))
+		}
+	}
+	sort.Slice(tombstoneChanges, func(i, j int) bool { return tombstoneChanges[i].EntityID < tombstoneChanges[j].EntityID })
+	return append(changes, tombstoneChanges...)
+}
@@ -0,0 +1,208 @@
+package cloudsync
+
+import (
+	"fmt"
+	"lunabox/internal/applog"
+	"lunabox/internal/service/cloudprovider"
+	"sort"
+	"time"
+)
+
+const (
+	RecordOnlyLocal    = "only_local"
+	RecordOnlyRevision = "only_revision"
+	RecordChanged      = "changed"
+)
+
+// RecordChange 描述一条记录在历史 revision 与本地之间的差异。
+// EntityID 与 tombstone 的 entity_id 规则一致；Label 仅对游戏有意义（游戏名）。
+type RecordChange struct {
+	EntityType string
+	EntityID   string
+	GameID     string
+	Label      string
+	Change     string
+}
+
+// DiffSnapshots 逐条比较本地与历史 revision；只比较内容，忽略 updated_at。
+func DiffSnapshots(local, revision Snapshot) []RecordChange {
+	var changes []RecordChange
+	changes = append(changes, diffRecords(entityGame, local.Games, revision.Games, func(g Game) string { return g.ID }, func(g Game) string { return g.ID }, func(g Game) string { return g.Name })...)
+	changes = append(changes, diffRecords(entityCategory, local.Categories, revision.Categories, func(c Category) string { return c.ID }, nil, nil)...)
+	changes = append(changes, diffRecords(entityGameCategory, local.GameCategories, revision.GameCategories, relationKey, func(r Relation) string { return r.GameID }, nil)...)
+	changes = append(changes, diffRecords(entityPlaySession, local.PlaySessions, revision.PlaySessions, func(s PlaySession) string { return s.ID }, func(s PlaySession) string { return s.GameID }, nil)...)
+	changes = append(changes, diffRecords(entityGameProgress, local.GameProgresses, revision.GameProgresses, func(p GameProgress) string { return p.ID }, func(p GameProgress) string { return p.GameID }, nil)...)
+	changes = append(changes, diffRecords(entityGameReview, local.GameReviews, revision.GameReviews, func(r GameReview) string { return r.GameID }, func(r GameReview) string { return r.GameID }, nil)...)
+	changes = append(changes, diffRecords(entityGameTag, local.GameTags, revision.GameTags, tagKey, func(t GameTag) string { return t.GameID }, nil)...)
+	changes = append(changes, diffRecords(entityGameMetadataSource, local.MetadataSources, revision.MetadataSources, metadataSourceKey, func(s MetadataSource) string { return s.GameID }, nil)...)
+	return changes
+}
+
+func diffRecords[T any](entityType string, local, revision []T, key, gameID, label func(T) string) []RecordChange {
+	localMap := make(map[string]T, len(local))
+	for _, item := range local {
+		localMap[key(item)] = item
+	}
+	revisionMap := make(map[string]T, len(revision))
+	for _, item := range revision {
+		revisionMap[key(item)] = item
+	}
+
+	describe := func(id string, item T, change string) RecordChange {
+		out := RecordChange{EntityType: entityType, EntityID: id, Change: change}
+		if gameID != nil {
+			out.GameID = gameID(item)
+		}
+		if label != nil {
+			out.Label = label(item)
+		}
+		return out
+	}
+
+	var changes []RecordChange
+	for id, item := range localMap {
+		revisionItem, ok := revisionMap[id]
+		switch {
+		case !ok:
+			changes = append(changes, describe(id, item, RecordOnlyLocal))
+		case !sameRecordContent(item, revisionItem):
+			changes = append(changes, describe(id, revisionItem, RecordChanged))
+		}
+	}
+	for id, item := range revisionMap {
+		if _, ok := localMap[id]; !ok {
+			changes = append(changes, describe(id, item, RecordOnlyRevision))
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].EntityID < changes[j].EntityID })
+	return changes
+}
+
+// sameRecordContent 比较两条记录除 updated_at 以外的字段是否一致。
+func sameRecordContent(left, right any) bool {
+	leftFields, err := recordFields(left)
+	if err != nil {
+		return false
+	}
+	rightFields, err := recordFields(right)
+	if err != nil {
+		return false
+	}
+	for _, key := range unionFieldKeys(leftFields, rightFields) {
+		if key == "updated_at" {
+			continue
+		}
+		if !fieldValuesEqual(leftFields[key], rightFields[key]) {
+			return false
+		}
+	}
+	return true
+}
+
+// RollbackToRevision 把整个库回滚到历史 revision，并作为一个新 revision 推送到云端。
+// 流程：
+//  1. 先完整同步一次，使本地、远端与三方合并的 base 对齐到最新 revision；
+//  2. 以历史 revision 为目标改写本地：内容不同或已被删除的记录恢复为历史取值并刷新 updated_at，
+//     历史中不存在的记录写入当前时间的 tombstone；
+//  3. 再同步一次，回滚改动在 LWW 与三方合并中都晚于远端，从而整体胜出并传播到其他设备。
+//
+// 封面不做版本化，回滚后沿用当前封面；已删除游戏恢复后没有封面，需要重新刮削。
+func (h *Helper) RollbackToRevision(provider cloudprovider.CloudStorageProvider, revisionID string) error {
+	revision, err := h.LoadRevisionSnapshot(provider, revisionID)
+	if err != nil {
+		return err
+	}
+	if err := h.SyncToCloud(provider); err != nil {
+		return fmt.Errorf("sync before rollback: %w", err)
+	}
+
+	localState, err := h.BuildLocalState()
+	if err != nil {
+		return fmt.Errorf("build local state: %w", err)
+	}
+	target := buildRollbackSnapshot(localState.Snapshot, revision, h.now())
+	if err := h.ApplyMergedSnapshot(target, nil); err != nil {
+		return fmt.Errorf("apply rollback snapshot: %w", err)
+	}
+	applog.LogInfof(h.ctx, "CloudSync: local library rolled back to revision %s, pushing as new revision", revisionID)
+
+	if err := h.SyncToCloud(provider); err != nil {
+		return fmt.Errorf("sync after rollback: %w", err)
+	}
+	return nil
+}
+
+// buildRollbackSnapshot 计算把本地改写为 revision 内容所需的完整 snapshot（供 ApplyMergedSnapshot 使用）。
+func buildRollbackSnapshot(local, revision Snapshot, now time.Time) Snapshot {
+	out := Snapshot{SchemaVersion: SchemaVersion}
+	restored := make(map[string]struct{})
+	var removed []Tombstone
+
+	var tombs []Tombstone
+	out.Games, tombs = rollbackRecords(entityGame, local.Games, revision.Games, func(g Game) string { return g.ID }, func(g *Game) { g.UpdatedAt = now }, now, restored)
+	removed = append(removed, tombs...)
+	out.Categories, tombs = rollbackRecords(entityCategory, local.Categories, revision.Categories, func(c Category) string { return c.ID }, func(c *Category) { c.UpdatedAt = now }, now, restored)
+	removed = append(removed, tombs...)
+	out.GameCategories, tombs = rollbackRecords(entityGameCategory, local.GameCategories, revision.GameCategories, relationKey, func(r *Relation) { r.UpdatedAt = now }, now, restored)
+	removed = append(removed, tombs...)
+	out.PlaySessions, tombs = rollbackRecords(entityPlaySession, local.PlaySessions, revision.PlaySessions, func(s PlaySession) string { return s.ID }, func(s *PlaySession) { s.UpdatedAt = now }, now, restored)
+	removed = append(removed, tombs...)
+	out.GameProgresses, tombs = rollbackRecords(entityGameProgress, local.GameProgresses, revision.GameProgresses, func(p GameProgress) string { return p.ID }, func(p *GameProgress) { p.UpdatedAt = now }, now, restored)
+	removed = append(removed, tombs...)
+	out.GameReviews, tombs = rollbackRecords(entityGameReview, local.GameReviews, revision.GameReviews, func(r GameReview) string { return r.GameID }, func(r *GameReview) { r.UpdatedAt = now }, now, restored)
+	removed = append(removed, tombs...)
+	out.GameTags, tombs = rollbackRecords(entityGameTag, local.GameTags, revision.GameTags, tagKey, func(t *GameTag) { t.UpdatedAt = now }, now, restored)
+	removed = append(removed, tombs...)
+	out.MetadataSources, tombs = rollbackRecords(entityGameMetadataSource, local.MetadataSources, revision.MetadataSources, metadataSourceKey, func(s *MetadataSource) { s.UpdatedAt = now }, now, restored)
+	removed = append(removed, tombs...)
+
+	// 本地已有的 tombstone 保留，但恢复的记录必须去掉对应 tombstone，否则 apply 时会被再次删除
+	for _, tombstone := range local.Tombstones {
+		if _, ok := restored[tombstone.EntityType+"/"+tombstone.EntityID]; ok {
+			continue
+		}
+		out.Tombstones = append(out.Tombstones, tombstone)
+	}
+	out.Tombstones = append(out.Tombstones, removed...)
+	sortSnapshot(&out)
+	return out
+}
+
+// rollbackRecords 返回回滚后的记录集合与需要新写入的 tombstone。
+// 与本地内容一致的记录保持原样（不刷新 updated_at），避免无意义的上传。
+func rollbackRecords[T any](entityType string, local, revision []T, key func(T) string, touch func(*T), now time.Time, restored map[string]struct{}) ([]T, []Tombstone) {
+	localMap := make(map[string]T, len(local))
+	for _, item := range local {
+		localMap[key(item)] = item
+	}
+	revisionIDs := make(map[string]struct{}, len(revision))
+
+	out := make([]T, 0, len(revision))
+	for _, item := range revision {
+		id := key(item)
+		revisionIDs[id] = struct{}{}
+		restored[entityType+"/"+id] = struct{}{}
+		if localItem, ok := localMap[id]; ok && sameRecordContent(localItem, item) {
+			out = append(out, localItem)
+			continue
+		}
+		touch(&item)
+		out = append(out, item)
+	}
+
+	var tombstones []Tombstone
+	for id := range localMap {
+		if _, ok := revisionIDs[id]; !ok {
+			tombstones = append(tombstones, Tombstone{EntityType: entityType, EntityID: id, DeletedAt: now})
+		}
+	}
+	return out, tombstones
+}
+
+func relationKey(r Relation) string { return RelationTombstoneID(r.GameID, r.CategoryID) }
+
+func tagKey(t GameTag) string { return TagTombstoneID(t.GameID, t.Source, t.Name) }
+
+func metadataSourceKey(s MetadataSource) string {
+	return MetadataSourceTombstoneID(s.GameID, s.SourceType)
+}
@@ -526,7 +526,7 @@ func (h *Helper) listGameTags() ([]models.GameTag, error) {
 }
 
 func (h *Helper) listTombstones() ([]models.SyncTombstone, error) {
-	rows, err := h.db.QueryContext(h.ctx, `SELECT entity_type, entity_id, COALESCE(parent_id, ''), COALESCE(secondary_id, ''), deleted_at FROM sync_tombstones WHERE COALESCE(parent_id, '') = '' AND COALESCE(secondary_id, '') = ''`)
+	rows, err := h.db.QueryContext(h.ctx, `SELECT entity_type, entity_id, COALESCE(parent_id, ''), COALESCE(secondary_id, ''), deleted_at, published_at FROM sync_tombstones WHERE COALESCE(parent_id, '') = '' AND COALESCE(secondary_id, '') = ''`)
 	if err != nil {
 		return nil, fmt.Errorf("query tombstones for cloud sync: %w", err)
 	}
@@ -534,7 +534,7 @@ func (h *Helper) listTombstones() ([]models.SyncTombstone, error) {
 	var items []models.SyncTombstone
 	for rows.Next() {
 		var item models.SyncTombstone
-		if err := rows.Scan(&item.EntityType, &item.EntityID, &item.ParentID, &item.SecondaryID, &item.DeletedAt); err != nil {
+		if err := rows.Scan(&item.EntityType, &item.EntityID, &item.ParentID, &item.SecondaryID, &item.DeletedAt, &item.PublishedAt); err != nil {
 			return nil, fmt.Errorf("scan tombstone for cloud sync: %w", err)
 		}
 		items = append(items, item)
@@ -630,8 +630,8 @@ func (h *Helper) upsertGame(tx *sql.Tx, game models.Game) error {
 			id, name, aliases, cover_url, cover_source_url, company, summary, rating,
 			release_date, path, game_directory, save_path, process_name, status,
 			source_type, cached_at, source_id, wine_runner, wine_args, wine_prefix,
-			created_at, updated_at, use_locale_emulator, use_magpie, is_nsfw, metadata_locked
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, '', '', '', '', ?, ?, CURRENT_TIMESTAMP, ?, ?, ?, ?, ?, ?, FALSE, FALSE, ?, ?)
+			created_at, updated_at, status_updated_at, use_locale_emulator, use_magpie, is_nsfw, metadata_locked
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, '', '', '', '', ?, ?, CURRENT_TIMESTAMP, ?, ?, ?, ?, ?, ?, ?, FALSE, FALSE, ?, ?)
 		ON CONFLICT (id) DO UPDATE SET
 			name = EXCLUDED.name,
 			aliases = EXCLUDED.aliases,
@@ -641,6 +641,7 @@ func (h *Helper) upsertGame(tx *sql.Tx, game models.Game) error {
 			summary = EXCLUDED.summary,
 			rating = EXCLUDED.rating,
 			release_date = EXCLUDED.release_date,
+			status_updated_at = CASE WHEN games.status IS DISTINCT FROM EXCLUDED.status THEN EXCLUDED.updated_at ELSE games.status_updated_at END,
 			status = EXCLUDED.status,
 			source_type = EXCLUDED.source_type,
 			source_id = EXCLUDED.source_id,
@@ -670,7 +671,7 @@ func (h *Helper) upsertGame(tx *sql.Tx, game models.Game) error {
 		   OR games.metadata_locked IS DISTINCT FROM EXCLUDED.metadata_locked
 		   OR games.created_at IS DISTINCT FROM EXCLUDED.created_at
 		   OR games.updated_at IS DISTINCT FROM EXCLUDED.updated_at)
-	`, game.ID, game.Name, aliasesJSON, game.CoverURL, game.CoverSourceURL, game.Company, game.Summary, game.Rating, game.ReleaseDate, game.Status, game.SourceType, game.SourceID, game.WineRunner, game.WineArgs, game.WinePrefix, game.CreatedAt, game.UpdatedAt, game.IsNSFW, game.MetadataLocked)
+	`, game.ID, game.Name, aliasesJSON, game.CoverURL, game.CoverSourceURL, game.Company, game.Summary, game.Rating, game.ReleaseDate, game.Status, game.SourceType, game.SourceID, game.WineRunner, game.WineArgs, game.WinePrefix, game.CreatedAt, game.UpdatedAt, game.UpdatedAt, game.IsNSFW, game.MetadataLocked)
 	if err != nil {
 		return fmt.Errorf("upsert synced game %s: %w", game.ID, err)
 	}
@@ -740,7 +741,7 @@ func (h *Helper) upsertGameTag(tx *sql.Tx, tag models.GameTag) error {
 }
 
 func (h *Helper) insertTombstone(tx *sql.Tx, tombstone models.SyncTombstone) error {
-	_, err := tx.ExecContext(h.ctx, `INSERT INTO sync_tombstones (entity_type, entity_id, parent_id, secondary_id, deleted_at) VALUES (?, ?, ?, ?, ?)`, tombstone.EntityType, tombstone.EntityID, tombstone.ParentID, tombstone.SecondaryID, tombstone.DeletedAt)
+	_, err := tx.ExecContext(h.ctx, `INSERT INTO sync_tombstones (entity_type, entity_id, parent_id, secondary_id, deleted_at, published_at) VALUES (?, ?, ?, ?, ?, ?)`, tombstone.EntityType, tombstone.EntityID, tombstone.ParentID, tombstone.SecondaryID, tombstone.DeletedAt, tombstone.PublishedAt)
 	if err != nil {
 		return fmt.Errorf("insert merged tombstone %s/%s: %w", tombstone.EntityType, tombstone.EntityID, err)
 	}
@@ -113,6 +113,10 @@ func TestApplyMergedSnapshotPreservesExistingLaunchModeAndDefaultsNewGame(t *tes
 	if launchMode != "normal" {
 		t.Fatalf("new synced game launch_mode = %q, want normal", launchMode)
 	}
+	var statusUpdatedAt time.Time
+	if err := db.QueryRow(`SELECT status_updated_at FROM games WHERE id = 'new-game'`).Scan(&statusUpdatedAt); err != nil || !statusUpdatedAt.Equal(now) {
+		t.Fatalf("new synced game status_updated_at = %v, %v; want %v", statusUpdatedAt, err, now)
+	}
 }
 
 func TestApplyMergedSnapshotDoesNotOverwriteNewerLocalGame(t *testing.T) {
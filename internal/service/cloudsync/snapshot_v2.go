@@ -37,6 +37,11 @@ func (h *Helper) EnsureSyncDirs(provider cloudprovider.CloudStorageProvider) err
 			return fmt.Errorf("ensure %s dir: %w", sub, err)
 		}
 	}
+	for _, dir := range []string{HistoryDir, HistoryManifestDir, HistoryObjectDir} {
+		if err := provider.EnsureDir(h.ctx, provider.GetCloudPath(h.config.BackupUserID, dir)); err != nil {
+			return fmt.Errorf("ensure %s dir: %w", dir, err)
+		}
+	}
 	coverPath := provider.GetCloudPath(h.config.BackupUserID, CoverDir)
 	if err := provider.EnsureDir(h.ctx, coverPath); err != nil {
 		return fmt.Errorf("ensure cover dir: %w", err)
@@ -218,17 +223,7 @@ func (h *Helper) SaveRemoteLibraryFiles(
 		if !ok {
 			return fmt.Errorf("unknown singleton: %s", name)
 		}
-		file := BucketFile{
-			SchemaVersion: SchemaVersion,
-			BucketKey:     "_singleton/" + name,
-		}
-		switch name {
-		case SingletonCategories:
-			file.Categories = categories
-		case SingletonTombstones:
-			file.Tombstones = tombstones
-		}
-		payload, err := json.MarshalIndent(file, "", "  ")
+		payload, err := marshalSingletonFile(name, categories, tombstones)
 		if err != nil {
 			return fmt.Errorf("marshal singleton %s: %w", name, err)
 		}
@@ -323,6 +318,21 @@ func singletonCloudKey(name string) (string, bool) {
 	return "", false
 }
 
+// marshalSingletonFile 把 categories / tombstones 单文件写成 BucketFile 的 JSON 字节。
+func marshalSingletonFile(name string, categories []Category, tombstones []Tombstone) ([]byte, error) {
+	file := BucketFile{
+		SchemaVersion: SchemaVersion,
+		BucketKey:     "_singleton/" + name,
+	}
+	switch name {
+	case SingletonCategories:
+		file.Categories = categories
+	case SingletonTombstones:
+		file.Tombstones = tombstones
+	}
+	return json.MarshalIndent(file, "", "  ")
+}
+
 func splitBucketKey(key string) (entity, ch string, ok bool) {
 	parts := strings.SplitN(key, "/", 2)
 	if len(parts) != 2 {
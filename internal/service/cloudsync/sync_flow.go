@@ -61,6 +61,9 @@ func (h *Helper) bootstrapOrMigrate(provider cloudprovider.CloudStorageProvider,
 		applog.LogInfof(h.ctx, "CloudSync: remote empty, performing first v2 bootstrap upload")
 		merged = h.MergeSnapshots(localState.Snapshot, Snapshot{}, false)
 	}
+	// 远端从零开始：所有墓碑按本次发布盖章，设备登记从本设备开始
+	var maintenance tombstoneMaintenance
+	merged.Tombstones, maintenance = h.maintainTombstones(merged.Tombstones, nil, Manifest{}, h.now())
 
 	coverURLs, err := h.ReconcileCoverAssets(provider, localState, v1Snapshot, v1Exists, merged)
 	if err != nil {
@@ -76,6 +79,7 @@ func (h *Helper) bootstrapOrMigrate(provider cloudprovider.CloudStorageProvider,
 	if err != nil {
 		return fmt.Errorf("build manifest during bootstrap: %w", err)
 	}
+	maintenance.applyTo(&newManifest)
 
 	// 全部桶 + 两个 singleton 可合并走 batch；manifest 仍最后单独上传。
 	allBucketKeys := allBucketKeysFromManifest(newManifest)
@@ -89,6 +93,10 @@ func (h *Helper) bootstrapOrMigrate(provider cloudprovider.CloudStorageProvider,
 	); err != nil {
 		return fmt.Errorf("upload library files during bootstrap: %w", err)
 	}
+	// 历史先于 manifest 写入，保证 revision 一旦可见即可回滚；失败只影响该 revision 的可回滚性
+	if err := h.RecordRevision(provider, newManifest, mergedBuckets, merged.Categories, merged.Tombstones); err != nil {
+		applog.LogWarningf(h.ctx, "CloudSync: record revision history failed: %v", err)
+	}
 	if err := h.SaveRemoteManifest(provider, newManifest); err != nil {
 		return fmt.Errorf("upload manifest during bootstrap: %w", err)
 	}
@@ -103,12 +111,28 @@ func (h *Helper) bootstrapOrMigrate(provider cloudprovider.CloudStorageProvider,
 	return h.persistSyncState(mergedBuckets, merged.Categories, merged.Tombstones, newManifest)
 }
 
-// runIncrementalSync 是 v2 主流程：根据 hash diff 决定拉/推哪些桶，
-// 合并落库，最后写 manifest 与 cloud_sync_state。
-func (h *Helper) runIncrementalSync(provider cloudprovider.CloudStorageProvider, localState LocalState, remoteManifest Manifest) error {
-	localBuckets := Bucketize(localState.Snapshot)
+// incrementalPlan 是增量同步中不产生任何写入的部分（hash diff + 拉取 + 合并）的结果。
+// runIncrementalSync 与 PreviewSync 共用它，保证预览与真实同步走同一套合并逻辑。
+type incrementalPlan struct {
+	localBuckets  map[string]map[string]*BucketContent
+	remoteBuckets map[string]map[string]*BucketContent
+	// remoteCategories / remoteTombstones 为本次实际拉取到的远端 singleton（未拉取时为空）
+	remoteCategories []Category
+	remoteTombstones []Tombstone
+	diff             BucketDiff
+	cachedState      map[string]SyncStateRow
+	finalSnapshot    Snapshot
+	conflicts        []Conflict
+	// maintenance 为墓碑压缩与设备登记结果；maintenance.due 时即使 diff 无工作也要改写远端
+	maintenance tombstoneMaintenance
+}
+
+// planIncrementalSync 计算本次增量同步的合并结果；只读远端与本地，不写任何数据。
+// diff 无工作时 finalSnapshot 为本地快照本身（墓碑维护可能替换其中的墓碑）。
+func (h *Helper) planIncrementalSync(provider cloudprovider.CloudStorageProvider, localState LocalState, remoteManifest Manifest) (incrementalPlan, error) {
+	plan := incrementalPlan{localBuckets: Bucketize(localState.Snapshot)}
 	localManifest, err := BuildManifestFromBuckets(
-		localBuckets,
+		plan.localBuckets,
 		localState.Snapshot.Categories,
 		localState.Snapshot.Tombstones,
 		localState.Snapshot.Covers,
@@ -117,20 +141,22 @@ func (h *Helper) runIncrementalSync(provider cloudprovider.CloudStorageProvider,
 		h.now(),
 	)
 	if err != nil {
-		return fmt.Errorf("build local manifest: %w", err)
+		return plan, fmt.Errorf("build local manifest: %w", err)
 	}
 
-	cachedState, err := LoadSyncState(h.ctx, h.db)
+	plan.cachedState, err = LoadSyncState(h.ctx, h.db)
 	if err != nil {
-		return fmt.Errorf("load cloud_sync_state: %w", err)
+		return plan, fmt.Errorf("load cloud_sync_state: %w", err)
 	}
 
-	diff := DiffBuckets(localManifest, cachedState, remoteManifest, true)
-	if !diff.HasWork() {
-		applog.LogInfof(h.ctx, "CloudSync: nothing to do (local and remote both stable)")
-		// 仍然 persist 一次 state，把 manifest revision_id 写入 _manifest 行，便于后续追踪
-		return h.persistSyncState(localBuckets, localState.Snapshot.Categories, localState.Snapshot.Tombstones, remoteManifest)
+	plan.diff = DiffBuckets(localManifest, plan.cachedState, remoteManifest, true)
+	if !plan.diff.HasWork() {
+		plan.remoteBuckets = map[string]map[string]*BucketContent{}
+		plan.finalSnapshot = localState.Snapshot
+		plan.finalSnapshot.Tombstones, plan.maintenance = h.maintainTombstones(localState.Snapshot.Tombstones, localState.Snapshot.Tombstones, remoteManifest, h.now())
+		return plan, nil
 	}
+	diff := plan.diff
 
 	// 封面引用独立存放在 manifest 中，但其 LWW 语义依赖对应游戏的 updated_at。
 	// 因此封面不一致时也要把远端游戏桶拉入本次 merge。
@@ -152,35 +178,49 @@ func (h *Helper) runIncrementalSync(provider cloudprovider.CloudStorageProvider,
 	}
 
 	// 拉差异桶
-	remoteBuckets, err := h.LoadRemoteBuckets(provider, toPull)
+	plan.remoteBuckets, err = h.LoadRemoteBuckets(provider, toPull)
 	if err != nil {
-		return fmt.Errorf("load remote buckets: %w", err)
+		return plan, fmt.Errorf("load remote buckets: %w", err)
 	}
 
 	// 拉差异 singletons —— 即使本地端有变化，也要拉远端做 LWW
-	var remoteCategories []Category
-	var remoteTombstones []Tombstone
 	if len(diff.SingletonsToPull) > 0 {
 		cats, tombs, _, sErr := h.LoadRemoteSingletons(provider, diff.SingletonsToPull)
 		if sErr != nil {
-			return fmt.Errorf("load remote singletons: %w", sErr)
+			return plan, fmt.Errorf("load remote singletons: %w", sErr)
 		}
-		remoteCategories = cats
-		remoteTombstones = tombs
+		plan.remoteCategories = cats
+		plan.remoteTombstones = tombs
 	}
 
 	// 构造 partial snapshot 喂给 MergeSnapshots
 	// changedBuckets = union(ToPull, LocalChanged) —— 涵盖任意一侧有变化的桶
 	changed := unionBucketKeys(toPull, diff.LocalChanged)
-	localSubset, remoteSubset := buildMergeSubsets(localBuckets, remoteBuckets, changed,
-		localState.Snapshot.Categories, remoteCategories,
-		localState.Snapshot.Tombstones, remoteTombstones,
+	localSubset, remoteSubset := buildMergeSubsets(plan.localBuckets, plan.remoteBuckets, changed,
+		localState.Snapshot.Categories, plan.remoteCategories,
+		localState.Snapshot.Tombstones, plan.remoteTombstones,
 		diff.SingletonsToPull, diff.SingletonsChanged,
 	)
 	localSubset.Covers = localState.Snapshot.Covers
 	remoteSubset.Covers = remoteManifestToSnapshot(remoteManifest).Covers
+	// 超过期限才回来的设备可能错过了已被压缩的墓碑，需要从远端缺失的记录反推删除，防止把它们重新推上去
+	if h.missedCompaction(remoteManifest, plan.cachedState) {
+		inferred := inferCompactedDeletions(localState.Snapshot, remoteManifest, plan.remoteBuckets,
+			plan.remoteCategories, containsString(diff.SingletonsToPull, SingletonCategories),
+			remoteSubset.Tombstones, plan.cachedState[StateKeyManifest].UpdatedAt)
+		if len(inferred) > 0 {
+			applog.LogWarningf(h.ctx, "CloudSync: device missed tombstone compaction through %s, inferred %d deletions", remoteManifest.TombstonesCompactedThrough.Format(time.RFC3339), len(inferred))
+			remoteSubset.Tombstones = append(append([]Tombstone(nil), remoteSubset.Tombstones...), inferred...)
+		}
+	}
 
-	mergedSubset := h.MergeSnapshots(localSubset, remoteSubset, true)
+	// 上次同步的结果作为三方合并的共同祖先；revision 对不上时 base 为空，退化为整条 LWW
+	base, err := LoadSyncBase(h.ctx, h.db, plan.cachedState[StateKeyManifest].RemoteRevisionID)
+	if err != nil {
+		applog.LogWarningf(h.ctx, "CloudSync: load merge base failed, falling back to record-level LWW: %v", err)
+		base = Snapshot{}
+	}
+	mergedSubset, conflicts := h.MergeSnapshotsWithBase(localSubset, remoteSubset, base, true)
 	for _, source := range mergedSubset.MetadataSources {
 		changed[BucketKey(EntityKeyGameMetadataSources, BucketKeyOfGame(source.GameID))] = struct{}{}
 	}
@@ -189,7 +229,31 @@ func (h *Helper) runIncrementalSync(provider cloudprovider.CloudStorageProvider,
 	}
 
 	// 拼回 unchanged buckets：未变化桶的本地数据本身就等于远端，直接复用
-	finalSnapshot := assembleFinalSnapshot(localBuckets, remoteBuckets, changed, mergedSubset, localState.Snapshot)
+	plan.finalSnapshot = assembleFinalSnapshot(plan.localBuckets, plan.remoteBuckets, changed, mergedSubset, localState.Snapshot)
+	plan.conflicts = conflicts
+
+	// merge 结果不携带 published_at：已在远端的墓碑沿用远端的发布时间，其余视为本次发布
+	publishedRef := localState.Snapshot.Tombstones
+	if containsString(diff.SingletonsToPull, SingletonTombstones) {
+		publishedRef = plan.remoteTombstones
+	}
+	plan.finalSnapshot.Tombstones, plan.maintenance = h.maintainTombstones(plan.finalSnapshot.Tombstones, publishedRef, remoteManifest, h.now())
+	return plan, nil
+}
+
+// runIncrementalSync 是 v2 主流程：根据 hash diff 决定拉/推哪些桶，
+// 合并落库，最后写 manifest 与 cloud_sync_state。
+func (h *Helper) runIncrementalSync(provider cloudprovider.CloudStorageProvider, localState LocalState, remoteManifest Manifest) error {
+	plan, err := h.planIncrementalSync(provider, localState, remoteManifest)
+	if err != nil {
+		return err
+	}
+	if !plan.diff.HasWork() && !plan.maintenance.due {
+		applog.LogInfof(h.ctx, "CloudSync: nothing to do (local and remote both stable)")
+		// 仍然 persist 一次 state，把 manifest revision_id 写入 _manifest 行，便于后续追踪
+		return h.persistSyncState(plan.localBuckets, localState.Snapshot.Categories, localState.Snapshot.Tombstones, remoteManifest)
+	}
+	finalSnapshot, conflicts := plan.finalSnapshot, plan.conflicts
 
 	coverURLs, err := h.ReconcileCoverAssets(provider, localState, remoteManifestToSnapshot(remoteManifest), true, finalSnapshot)
 	if err != nil {
@@ -214,6 +278,10 @@ func (h *Helper) runIncrementalSync(provider cloudprovider.CloudStorageProvider,
 	if err != nil {
 		return fmt.Errorf("build final manifest: %w", err)
 	}
+	plan.maintenance.applyTo(&finalManifest)
+	if plan.maintenance.compacted > 0 {
+		applog.LogInfof(h.ctx, "CloudSync: compacted %d tombstones seen by all %d devices", plan.maintenance.compacted, len(plan.maintenance.devices))
+	}
 
 	// 决定要上传哪些：与远端 manifest 比对 hash
 	toPushBuckets := pushBucketKeys(finalManifest, remoteManifest)
@@ -231,11 +299,23 @@ func (h *Helper) runIncrementalSync(provider cloudprovider.CloudStorageProvider,
 	}
 
 	// 总是写 manifest（即便桶/单文件没变也要写，覆盖远端 revision_id 推进）
+	if err := h.RecordRevision(provider, finalManifest, finalBuckets, finalSnapshot.Categories, finalSnapshot.Tombstones); err != nil {
+		applog.LogWarningf(h.ctx, "CloudSync: record revision history failed: %v", err)
+	}
 	if err := h.SaveRemoteManifest(provider, finalManifest); err != nil {
 		return fmt.Errorf("upload manifest: %w", err)
 	}
 
-	return h.persistSyncState(finalBuckets, finalSnapshot.Categories, finalSnapshot.Tombstones, finalManifest)
+	if err := h.persistSyncState(finalBuckets, finalSnapshot.Categories, finalSnapshot.Tombstones, finalManifest); err != nil {
+		return err
+	}
+	if len(conflicts) > 0 {
+		applog.LogWarningf(h.ctx, "CloudSync: %d field conflicts resolved by LWW, recorded for manual review", len(conflicts))
+		if err := SaveConflicts(h.ctx, h.db, conflicts); err != nil {
+			applog.LogWarningf(h.ctx, "CloudSync: record field conflicts failed: %v", err)
+		}
+	}
+	return nil
 }
 
 // persistSyncState 把每个桶/单文件的 hash 与 manifest revision 写回 cloud_sync_state。
@@ -298,7 +378,14 @@ func (h *Helper) persistSyncState(
 		UpdatedAt:        now,
 	})
 
-	return SaveSyncState(h.ctx, h.db, rows)
+	if err := SaveSyncState(h.ctx, h.db, rows); err != nil {
+		return err
+	}
+	// 基线写入失败不影响本次同步结果：下次读取时 revision 对不上，会退化为整条 LWW
+	if err := SaveSyncBase(h.ctx, h.db, manifest.RevisionID, buckets, now); err != nil {
+		applog.LogWarningf(h.ctx, "CloudSync: save merge base failed: %v", err)
+	}
+	return nil
 }
 
 // allBucketKeysFromManifest 列出 manifest 中全部非空桶的 key（"games/3" 等）。
@@ -22,6 +22,7 @@ type ExecContexter interface {
 	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
 }
 
+// UpsertTombstone 写入删除墓碑；重复删除视为新的删除事件，清空 published_at 以便重新计算压缩时机。
 func UpsertTombstone(ctx context.Context, exec ExecContexter, entityType, entityID string, deletedAt time.Time) error {
 	if entityID == "" {
 		return nil
@@ -30,7 +31,7 @@ func UpsertTombstone(ctx context.Context, exec ExecContexter, entityType, entity
 	_, err := exec.ExecContext(ctx, `
 		INSERT INTO sync_tombstones (entity_type, entity_id, parent_id, secondary_id, deleted_at)
 		VALUES (?, ?, '', '', ?)
-		ON CONFLICT (entity_type, entity_id, parent_id, secondary_id) DO UPDATE SET deleted_at = EXCLUDED.deleted_at
+		ON CONFLICT (entity_type, entity_id, parent_id, secondary_id) DO UPDATE SET deleted_at = EXCLUDED.deleted_at, published_at = NULL
 	`, entityType, entityID, deletedAt)
 	if err != nil {
 		return fmt.Errorf("upsert sync tombstone %s/%s: %w", entityType, entityID, err)
@@ -241,10 +241,16 @@ func (s *ConfigService) updateAppConfigLocked(newConfig appconf.AppConfig) error
 	appconf.SanitizeUmbraConfig(&newConfig)
 	newConfig.MCPPort = appconf.NormalizeMCPPort(newConfig.MCPPort)
 	newConfig.ProcessDetectionTimeoutSec = appconf.NormalizeProcessDetectionTimeoutSec(newConfig.ProcessDetectionTimeoutSec)
+	newConfig.CloudSyncHistoryLimit = appconf.NormalizeCloudSyncHistoryLimit(newConfig.CloudSyncHistoryLimit)
+	newConfig.CloudSyncTombstoneHorizonDays = appconf.NormalizeCloudSyncTombstoneHorizonDays(newConfig.CloudSyncTombstoneHorizonDays)
 
 	var previousConfig appconf.AppConfig
 	if s.config != nil {
 		previousConfig = *s.config
+		// 备份身份、加密密钥与轮换状态只能由 BackupService 修改，避免前端回传的旧配置覆盖轮换结果
+		newConfig.BackupUserID = previousConfig.BackupUserID
+		newConfig.BackupEncryptionKey = previousConfig.BackupEncryptionKey
+		newConfig.BackupPasswordRotation = previousConfig.BackupPasswordRotation
 	}
 
 	previousLaunchAtLogin := false
@@ -0,0 +1,483 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"lunabox/internal/appconf"
+	"lunabox/internal/common/enums"
+	"lunabox/internal/common/vo"
+	"lunabox/internal/wailsruntime"
+)
+
+// exportColumn 导出的一列：Name 为 CSV 表头 / JSON 字段名，Expr 为对应的 SQL 表达式
+type exportColumn struct {
+	Name string
+	Expr string
+}
+
+// exportSpec 描述一种可导出的数据。查询中的游戏表别名固定为 g，供分类和状态筛选使用；
+// DateColumn 是日期范围筛选所依据的列。
+type exportSpec struct {
+	Columns    []exportColumn
+	From       string
+	DateColumn string
+	OrderBy    string
+}
+
+var exportSpecs = map[enums.ExportEntity]exportSpec{
+	enums.ExportGames: {
+		Columns: []exportColumn{
+			{"id", "g.id"},
+			{"name", "COALESCE(g.name, '')"},
+			{"company", "COALESCE(g.company, '')"},
+			{"status", "COALESCE(g.status, '')"},
+			{"source_type", "COALESCE(g.source_type, '')"},
+			{"source_id", "COALESCE(g.source_id, '')"},
+			{"rating", "COALESCE(g.rating, 0)"},
+			{"release_date", "COALESCE(g.release_date, '')"},
+			{"launch_mode", "COALESCE(g.launch_mode, '')"},
+			{"is_nsfw", "COALESCE(g.is_nsfw, FALSE)"},
+			{"categories", `COALESCE((SELECT string_agg(c.name, '; ' ORDER BY c.name) FROM game_categories gc JOIN categories c ON c.id = gc.category_id WHERE gc.game_id = g.id), '')`},
+			{"total_play_duration", "CAST(COALESCE((SELECT SUM(duration) FROM play_sessions ps WHERE ps.game_id = g.id), 0) AS BIGINT)"},
+			{"last_played_at", "(SELECT MAX(start_time) FROM play_sessions ps WHERE ps.game_id = g.id)"},
+			{"created_at", "g.created_at"},
+			{"updated_at", "g.updated_at"},
+		},
+		From:       "games g",
+		DateColumn: "g.created_at",
+		OrderBy:    "g.created_at, g.id",
+	},
+	enums.ExportSessions: {
+		Columns: []exportColumn{
+			{"id", "ps.id"},
+			{"game_id", "ps.game_id"},
+			{"game_name", "COALESCE(g.name, '')"},
+			{"start_time", "ps.start_time"},
+			{"end_time", "ps.end_time"},
+			{"duration", "COALESCE(ps.duration, 0)"},
+		},
+		From:       "play_sessions ps JOIN games g ON g.id = ps.game_id",
+		DateColumn: "ps.start_time",
+		OrderBy:    "ps.start_time, ps.id",
+	},
+	enums.ExportTags: {
+		Columns: []exportColumn{
+			{"id", "gt.id"},
+			{"game_id", "gt.game_id"},
+			{"game_name", "COALESCE(g.name, '')"},
+			{"name", "gt.name"},
+			{"source", "gt.source"},
+			{"weight", "COALESCE(gt.weight, 0)"},
+			{"is_spoiler", "COALESCE(gt.is_spoiler, FALSE)"},
+			{"created_at", "gt.created_at"},
+		},
+		From:       "game_tags gt JOIN games g ON g.id = gt.game_id",
+		DateColumn: "gt.created_at",
+		OrderBy:    "g.name, gt.source, gt.name",
+	},
+	enums.ExportReviews: {
+		Columns: []exportColumn{
+			{"game_id", "gr.game_id"},
+			{"game_name", "COALESCE(g.name, '')"},
+			{"rating", "gr.rating"},
+			{"content", "COALESCE(gr.content, '')"},
+			{"is_spoiler", "COALESCE(gr.is_spoiler, FALSE)"},
+			{"created_at", "gr.created_at"},
+			{"updated_at", "gr.updated_at"},
+		},
+		From:       "game_reviews gr JOIN games g ON g.id = gr.game_id",
+		DateColumn: "gr.updated_at",
+		OrderBy:    "gr.updated_at, gr.game_id",
+	},
+	enums.ExportProgress: {
+		Columns: []exportColumn{
+			{"id", "gp.id"},
+			{"game_id", "gp.game_id"},
+			{"game_name", "COALESCE(g.name, '')"},
+			{"chapter", "COALESCE(gp.chapter, '')"},
+			{"route", "COALESCE(gp.route, '')"},
+			{"progress_note", "COALESCE(gp.progress_note, '')"},
+			{"spoiler_boundary", "COALESCE(gp.spoiler_boundary, '')"},
+			{"updated_at", "gp.updated_at"},
+		},
+		From:       "game_progress gp JOIN games g ON g.id = gp.game_id",
+		DateColumn: "gp.updated_at",
+		OrderBy:    "gp.updated_at, gp.id",
+	},
+}
+
+// DataExportService 将游戏库原始数据导出为 CSV / NDJSON，游玩记录还可导出为 iCalendar
+type DataExportService struct {
+	ctx     context.Context
+	db      *sql.DB
+	config  *appconf.AppConfig
+	runtime wailsruntime.Runtime
+}
+
+func NewDataExportService() *DataExportService {
+	return &DataExportService{runtime: wailsruntime.Unavailable()}
+}
+
+//wails:ignore
+func (s *DataExportService) Init(ctx context.Context, db *sql.DB, config *appconf.AppConfig) {
+	s.ctx = ctx
+	s.db = db
+	s.config = config
+}
+
+//wails:ignore
+func (s *DataExportService) SetRuntime(runtime wailsruntime.Runtime) {
+	if runtime != nil {
+		s.runtime = runtime
+	}
+}
+
+// ExportData 弹出保存对话框并导出数据；用户取消时返回空路径
+func (s *DataExportService) ExportData(req vo.DataExportRequest) (vo.DataExportResult, error) {
+	req.Format = normalizeExportFormat(req.Format)
+	if _, err := validateExportRequest(req); err != nil {
+		return vo.DataExportResult{}, err
+	}
+
+	ext := string(req.Format)
+	filename, err := s.runtime.SaveFile(wailsruntime.SaveDialogOptions{
+		Filename: fmt.Sprintf("lunabox-%s-%s.%s", req.Entity, time.Now().Format("20060102-150405"), ext),
+		Title:    "导出数据",
+		Filters: []wailsruntime.FileFilter{
+			{
+				DisplayName: fmt.Sprintf("%s Files (*.%s)", strings.ToUpper(ext), ext),
+				Pattern:     "*." + ext,
+			},
+		},
+	})
+	if err != nil {
+		return vo.DataExportResult{}, fmt.Errorf("failed to open save dialog: %w", err)
+	}
+	if filename == "" {
+		return vo.DataExportResult{}, nil
+	}
+
+	rows, err := s.WriteExportFile(req, filename)
+	if err != nil {
+		return vo.DataExportResult{}, err
+	}
+	return vo.DataExportResult{Path: filename, Rows: rows}, nil
+}
+
+// WriteExportFile 将数据导出到指定文件，返回导出的行数
+//
+//wails:ignore
+func (s *DataExportService) WriteExportFile(req vo.DataExportRequest, path string) (rows int, err error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return 0, fmt.Errorf("创建导出文件失败: %w", err)
+	}
+	defer func() {
+		if closeErr := file.Close(); err == nil && closeErr != nil {
+			err = fmt.Errorf("写入导出文件失败: %w", closeErr)
+		}
+	}()
+	return s.WriteExport(file, req)
+}
+
+// WriteExport 将数据按请求的格式写入 w，返回导出的行数（iCalendar 为事件数）
+//
+//wails:ignore
+func (s *DataExportService) WriteExport(w io.Writer, req vo.DataExportRequest) (int, error) {
+	req.Format = normalizeExportFormat(req.Format)
+	spec, err := validateExportRequest(req)
+	if err != nil {
+		return 0, err
+	}
+
+	query, args, err := buildExportQuery(spec, req)
+	if err != nil {
+		return 0, err
+	}
+	rows, err := s.db.QueryContext(s.ctx, query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("查询导出数据失败: %w", err)
+	}
+	defer rows.Close()
+
+	buffered := bufio.NewWriter(w)
+	var count int
+	switch req.Format {
+	case enums.ExportNDJSON:
+		count, err = writeExportNDJSON(buffered, spec.Columns, rows)
+	case enums.ExportICS:
+		count, err = writeSessionsICS(buffered, rows, time.Now())
+	default:
+		count, err = writeExportCSV(buffered, spec.Columns, rows)
+	}
+	if err != nil {
+		return count, err
+	}
+	if err := buffered.Flush(); err != nil {
+		return count, fmt.Errorf("写入导出数据失败: %w", err)
+	}
+	return count, nil
+}
+
+func normalizeExportFormat(format enums.ExportFormat) enums.ExportFormat {
+	if format == "" {
+		return enums.ExportCSV
+	}
+	return enums.ExportFormat(strings.ToLower(string(format)))
+}
+
+func validateExportRequest(req vo.DataExportRequest) (exportSpec, error) {
+	spec, ok := exportSpecs[req.Entity]
+	if !ok {
+		return exportSpec{}, fmt.Errorf("不支持导出的数据类型: %s", req.Entity)
+	}
+	switch req.Format {
+	case enums.ExportCSV, enums.ExportNDJSON:
+	case enums.ExportICS:
+		if req.Entity != enums.ExportSessions {
+			return exportSpec{}, fmt.Errorf("只有游玩记录支持导出为 iCalendar")
+		}
+	default:
+		return exportSpec{}, fmt.Errorf("不支持的导出格式: %s", req.Format)
+	}
+	if req.Status != "" && !isKnownGameStatus(req.Status) {
+		return exportSpec{}, fmt.Errorf("无效的游戏状态: %s", req.Status)
+	}
+	return spec, nil
+}
+
+func isKnownGameStatus(status enums.GameStatus) bool {
+	for _, item := range enums.AllGameStatuses {
+		if item.Value == status {
+			return true
+		}
+	}
+	return false
+}
+
+func buildExportQuery(spec exportSpec, req vo.DataExportRequest) (string, []any, error) {
+	var conditions []string
+	var args []any
+	if req.StartDate != "" {
+		start, err := time.ParseInLocation(statsDateLayout, req.StartDate, time.Local)
+		if err != nil {
+			return "", nil, fmt.Errorf("无效的开始日期: %s", req.StartDate)
+		}
+		conditions = append(conditions, spec.DateColumn+" >= ?")
+		args = append(args, start)
+	}
+	if req.EndDate != "" {
+		end, err := time.ParseInLocation(statsDateLayout, req.EndDate, time.Local)
+		if err != nil {
+			return "", nil, fmt.Errorf("无效的结束日期: %s", req.EndDate)
+		}
+		conditions = append(conditions, spec.DateColumn+" < ?")
+		args = append(args, end.AddDate(0, 0, 1))
+	}
+	if req.Status != "" {
+		conditions = append(conditions, "g.status = ?")
+		args = append(args, req.Status)
+	}
+	if category := strings.TrimSpace(req.Category); category != "" {
+		conditions = append(conditions, `EXISTS (
+			SELECT 1 FROM game_categories gc JOIN categories c ON c.id = gc.category_id
+			WHERE gc.game_id = g.id AND (c.id = ? OR c.name = ?)
+		)`)
+		args = append(args, category, category)
+	}
+
+	exprs := make([]string, 0, len(spec.Columns))
+	for _, column := range spec.Columns {
+		exprs = append(exprs, column.Expr)
+	}
+	query := "SELECT " + strings.Join(exprs, ", ") + " FROM " + spec.From
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	return query + " ORDER BY " + spec.OrderBy, args, nil
+}
+
+func scanExportRow(rows *sql.Rows, columnCount int) ([]any, error) {
+	values := make([]any, columnCount)
+	pointers := make([]any, columnCount)
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	if err := rows.Scan(pointers...); err != nil {
+		return nil, fmt.Errorf("读取导出数据失败: %w", err)
+	}
+	for i, value := range values {
+		switch v := value.(type) {
+		case []byte:
+			values[i] = string(v)
+		case time.Time:
+			values[i] = v.In(time.Local).Format(time.RFC3339)
+		}
+	}
+	return values, nil
+}
+
+func writeExportCSV(w io.Writer, columns []exportColumn, rows *sql.Rows) (int, error) {
+	writer := csv.NewWriter(w)
+	header := make([]string, 0, len(columns))
+	for _, column := range columns {
+		header = append(header, column.Name)
+	}
+	if err := writer.Write(header); err != nil {
+		return 0, fmt.Errorf("写入导出数据失败: %w", err)
+	}
+
+	count := 0
+	record := make([]string, len(columns))
+	for rows.Next() {
+		values, err := scanExportRow(rows, len(columns))
+		if err != nil {
+			return count, err
+		}
+		for i, value := range values {
+			if value == nil {
+				record[i] = ""
+			} else {
+				record[i] = fmt.Sprint(value)
+			}
+		}
+		if err := writer.Write(record); err != nil {
+			return count, fmt.Errorf("写入导出数据失败: %w", err)
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return count, fmt.Errorf("读取导出数据失败: %w", err)
+	}
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return count, fmt.Errorf("写入导出数据失败: %w", err)
+	}
+	return count, nil
+}
+
+// writeExportNDJSON 每行写出一个 JSON 对象，字段顺序与 CSV 表头一致
+func writeExportNDJSON(w io.Writer, columns []exportColumn, rows *sql.Rows) (int, error) {
+	count := 0
+	var line []byte
+	for rows.Next() {
+		values, err := scanExportRow(rows, len(columns))
+		if err != nil {
+			return count, err
+		}
+		line = append(line[:0], '{')
+		for i, value := range values {
+			if i > 0 {
+				line = append(line, ',')
+			}
+			line = strconv.AppendQuote(line, columns[i].Name)
+			line = append(line, ':')
+			encoded, err := json.Marshal(value)
+			if err != nil {
+				return count, fmt.Errorf("编码导出数据失败: %w", err)
+			}
+			line = append(line, encoded...)
+		}
+		line = append(line, '}', '\n')
+		if _, err := w.Write(line); err != nil {
+			return count, fmt.Errorf("写入导出数据失败: %w", err)
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return count, fmt.Errorf("读取导出数据失败: %w", err)
+	}
+	return count, nil
+}
+
+// writeSessionsICS 将游玩记录写为 iCalendar，每条记录一个事件，时间统一使用 UTC
+func writeSessionsICS(w io.Writer, rows *sql.Rows, now time.Time) (int, error) {
+	lines := []string{
+		"BEGIN:VCALENDAR",
+		"VERSION:2.0",
+		"PRODID:-//LunaBox//Play Sessions//EN",
+		"CALSCALE:GREGORIAN",
+		"METHOD:PUBLISH",
+		"X-WR-CALNAME:LunaBox",
+	}
+	for _, line := range lines {
+		if err := writeICSLine(w, line); err != nil {
+			return 0, err
+		}
+	}
+
+	const icsTime = "20060102T150405Z"
+	stamp := now.UTC().Format(icsTime)
+	count := 0
+	for rows.Next() {
+		var id, gameID, gameName string
+		var start, end sql.NullTime
+		var duration int
+		if err := rows.Scan(&id, &gameID, &gameName, &start, &end, &duration); err != nil {
+			return count, fmt.Errorf("读取导出数据失败: %w", err)
+		}
+		if !start.Valid {
+			continue
+		}
+		endTime := end.Time
+		if !end.Valid || !endTime.After(start.Time) {
+			endTime = start.Time.Add(time.Duration(duration) * time.Second)
+		}
+		event := []string{
+			"BEGIN:VEVENT",
+			"UID:" + escapeICSText(id) + "@lunabox",
+			"DTSTAMP:" + stamp,
+			"DTSTART:" + start.Time.UTC().Format(icsTime),
+			"DTEND:" + endTime.UTC().Format(icsTime),
+			"SUMMARY:" + escapeICSText(gameName),
+			"DESCRIPTION:" + escapeICSText(fmt.Sprintf("游玩 %s", formatDuration(duration))),
+			"CATEGORIES:LunaBox",
+			"END:VEVENT",
+		}
+		for _, line := range event {
+			if err := writeICSLine(w, line); err != nil {
+				return count, err
+			}
+		}
+		count++
+	}
+	if err := rows.Err(); err != nil {
+		return count, fmt.Errorf("读取导出数据失败: %w", err)
+	}
+	return count, writeICSLine(w, "END:VCALENDAR")
+}
+
+func escapeICSText(value string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`, "\r", `\n`).Replace(value)
+}
+
+// writeICSLine 按 RFC 5545 将超过 75 字节的行折叠，折叠点不会拆开 UTF-8 字符
+func writeICSLine(w io.Writer, line string) error {
+	var b strings.Builder
+	width := 0
+	for _, r := range line {
+		size := utf8.RuneLen(r)
+		if width+size > 75 {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	b.WriteString("\r\n")
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("写入导出数据失败: %w", err)
+	}
+	return nil
+}
@@ -1278,6 +1278,9 @@ func parseMetaSource(metaSource string) (enums2.SourceType, bool) {
 	case string(enums2.ErogameScape):
 		return enums2.ErogameScape, true
 	default:
+		if source := gamehelper.NormalizeMetadataSourceType(enums2.SourceType(metaSource)); metadatautils.IsPluginSource(source) {
+			return source, true
+		}
 		return enums2.Local, false
 	}
 }
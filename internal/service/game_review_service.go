@@ -10,6 +10,7 @@ import (
 	"lunabox/internal/common/vo"
 	"lunabox/internal/models"
 	"lunabox/internal/service/cloudsync"
+	"lunabox/internal/service/remotestatus"
 	"strings"
 	"sync"
 	"time"
@@ -26,6 +27,8 @@ type GameReviewService struct {
 	db                *sql.DB
 	bangumiService    *BangumiService
 	hikarinagiService *HikarinagiService
+	vndbService       *VNDBService
+	pushQueue         *RemotePushService
 }
 
 func NewGameReviewService() *GameReviewService {
@@ -48,6 +51,18 @@ func (s *GameReviewService) SetHikarinagiService(service *HikarinagiService) {
 	s.hikarinagiService = service
 }
 
+//wails:ignore
+func (s *GameReviewService) SetVNDBService(service *VNDBService) {
+	s.vndbService = service
+}
+
+// SetRemotePushService 设置后，保存评价时自动为已开启推送的平台入队同步任务
+//
+//wails:ignore
+func (s *GameReviewService) SetRemotePushService(pushQueue *RemotePushService) {
+	s.pushQueue = pushQueue
+}
+
 func (s *GameReviewService) GetGameReview(gameID string) (*models.GameReview, error) {
 	gameID = strings.TrimSpace(gameID)
 	if gameID == "" {
@@ -118,6 +133,9 @@ func (s *GameReviewService) SaveGameReview(review models.GameReview) (*models.Ga
 	if err := cloudsync.DeleteTombstone(s.ctx, s.db, cloudsync.EntityGameReview, review.GameID); err != nil {
 		return nil, err
 	}
+	if s.pushQueue != nil {
+		s.pushQueue.enqueueGame(review.GameID, remotestatus.JobKindReview)
+	}
 	return s.GetGameReview(review.GameID)
 }
 
@@ -196,6 +214,13 @@ func (s *GameReviewService) syncProviderReview(gameID string, provider enums.Sou
 		if err == nil {
 			err = s.hikarinagiService.syncGameReview(s.ctx, sourceID, review, timeToFinishMinutes)
 		}
+	case enums.VNDB:
+		if s.vndbService == nil {
+			item.Status = gameReviewSyncUnavailable
+			item.Error = "VNDB 服务未初始化"
+			return item
+		}
+		err = s.vndbService.syncGameReview(s.ctx, sourceID, review)
 	default:
 		item.Status = gameReviewSyncUnavailable
 		item.Error = "该平台暂不支持评价同步"
@@ -14,6 +14,7 @@ import (
 	"lunabox/internal/protocol"
 	"lunabox/internal/service/cloudsync"
 	"lunabox/internal/service/gamehelper"
+	"lunabox/internal/service/remotestatus"
 	"lunabox/internal/utils"
 	"lunabox/internal/utils/apputils"
 	"lunabox/internal/utils/dbutils"
@@ -38,6 +39,8 @@ type GameService struct {
 	tagService         *TagService
 	bangumiService     *BangumiService
 	hikarinagiService  *HikarinagiService
+	vndbService        *VNDBService
+	pushQueue          *RemotePushService
 	runtime            wailsruntime.Runtime
 	emitEvent          func(string, ...interface{})
 	imageTaskStarter   func([]CoverImageDownloadItem) string
@@ -108,6 +111,18 @@ func (s *GameService) SetHikarinagiService(hikarinagiService *HikarinagiService)
 	s.hikarinagiService = hikarinagiService
 }
 
+//wails:ignore
+func (s *GameService) SetVNDBService(vndbService *VNDBService) {
+	s.vndbService = vndbService
+}
+
+// SetRemotePushService 设置后，状态变更改为写入推送发件箱，由后台任务投递
+//
+//wails:ignore
+func (s *GameService) SetRemotePushService(pushQueue *RemotePushService) {
+	s.pushQueue = pushQueue
+}
+
 //wails:ignore
 func (s *GameService) SetImageDownloadTaskStarter(starter func([]CoverImageDownloadItem) string) {
 	s.imageTaskStarter = starter
@@ -253,9 +268,9 @@ func (s *GameService) addGameWithTags(game models.Game, tags []metadata.TagItem,
 	query := `INSERT INTO games (
 		id, name, aliases, cover_url, cover_source_url, company, summary, rating, release_date, path, game_directory,
 		save_path, process_name, launch_mode, steam_launch_id, steam_launch_kind, steam_user_id, steam_launch_options,
-		status, source_type, cached_at, source_id, created_at, updated_at,
+		status, source_type, cached_at, source_id, created_at, updated_at, status_updated_at,
 		use_locale_emulator, use_magpie, is_nsfw, metadata_locked, wine_runner, wine_args, wine_prefix
-	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
 
 	_, err := s.db.ExecContext(s.ctx, query,
 		game.ID,
@@ -282,6 +297,7 @@ func (s *GameService) addGameWithTags(game models.Game, tags []metadata.TagItem,
 		game.SourceID,
 		game.CreatedAt,
 		game.UpdatedAt,
+		game.UpdatedAt,
 		game.UseLocaleEmulator,
 		game.UseMagpie,
 		game.IsNSFW,
@@ -812,6 +828,7 @@ func (s *GameService) updateGameRecord(game models.Game) (models.Game, error) {
 		steam_launch_kind = ?,
 		steam_user_id = ?,
 		steam_launch_options = ?,
+		status_updated_at = CASE WHEN status IS DISTINCT FROM ? THEN ? ELSE status_updated_at END,
 		status = ?,
 		source_type = ?,
 		cached_at = ?,
@@ -845,6 +862,8 @@ func (s *GameService) updateGameRecord(game models.Game) (models.Game, error) {
 		game.SteamUserID,
 		game.SteamLaunchOptions,
 		string(game.Status),
+		game.UpdatedAt,
+		string(game.Status),
 		string(game.SourceType),
 		game.CachedAt,
 		game.SourceID,
@@ -1317,6 +1336,9 @@ func (s *GameService) fetchMetadataResultByRequest(req vo.MetadataRequest) (meta
 		}
 		return s.fetchMetadataResultBySource(req.Source, normalizedID)
 	default:
+		if metadata.IsPluginSource(req.Source) {
+			return s.fetchMetadataResultBySource(req.Source, sourceID)
+		}
 		return metadata.MetadataResult{}, fmt.Errorf("unsupported source type: %s", req.Source)
 	}
 }
@@ -1353,7 +1375,14 @@ func (s *GameService) fetchMetadataResultBySource(source enums2.SourceType, sour
 		}
 		return s.hikarinagiService.fetchMetadataByID(s.ctx, sourceID)
 	default:
-		return metadata.MetadataResult{}, fmt.Errorf("unsupported source type: %s", source)
+		if !metadata.IsPluginSource(source) {
+			return metadata.MetadataResult{}, fmt.Errorf("unsupported source type: %s", source)
+		}
+		getter, err := metadata.NewPluginGetter(source, s.config.Language, getterOptions...)
+		if err != nil {
+			return metadata.MetadataResult{}, err
+		}
+		return getter.FetchMetadata(sourceID, "")
 	}
 }
 
@@ -1769,8 +1798,9 @@ func (s *GameService) BatchUpdateStatus(ids []string, status string) error {
 	}
 
 	placeholders := utils.BuildPlaceholders(len(ids))
-	args := make([]interface{}, 0, 2+len(ids))
-	args = append(args, status, time.Now())
+	now := time.Now()
+	args := make([]interface{}, 0, 4+len(ids))
+	args = append(args, status, now, status, now)
 	for _, id := range ids {
 		args = append(args, id)
 	}
@@ -1786,7 +1816,7 @@ func (s *GameService) BatchUpdateStatus(ids []string, status string) error {
 
 			result, err := tx.ExecContext(
 				s.ctx,
-				fmt.Sprintf("UPDATE games SET status = ?, updated_at = ? WHERE id IN (%s)", placeholders),
+				fmt.Sprintf("UPDATE games SET status_updated_at = CASE WHEN status IS DISTINCT FROM ? THEN ? ELSE status_updated_at END, status = ?, updated_at = ? WHERE id IN (%s)", placeholders),
 				args...,
 			)
 			if err != nil {
@@ -1891,7 +1921,7 @@ func (s *GameService) pushExternalStatusAfterLocalSave(previousGame models.Game,
 }
 
 func (s *GameService) pushExternalStatusAfterBatch(ids []string, status enums2.GameStatus) {
-	if s.bangumiService == nil && s.hikarinagiService == nil {
+	if s.bangumiService == nil && s.hikarinagiService == nil && s.vndbService == nil && s.pushQueue == nil {
 		return
 	}
 
@@ -1908,6 +1938,10 @@ func (s *GameService) pushExternalStatusAfterBatch(ids []string, status enums2.G
 }
 
 func (s *GameService) pushExternalStatusForGame(game models.Game) {
+	if s.pushQueue != nil {
+		s.pushQueue.enqueueGame(game.ID, remotestatus.JobKindStatus)
+		return
+	}
 	sources, err := s.GetGameMetadataSources(game.ID)
 	if err != nil {
 		applog.LogWarningf(s.ctx, "pushExternalStatusForGame: failed to load metadata sources for %s: %v", game.ID, err)
@@ -1930,6 +1964,12 @@ func (s *GameService) pushExternalStatusForGame(game models.Game) {
 					s.handleHikarinagiStatusPushFailure(target, err)
 				}
 			}
+		case enums2.VNDB:
+			if s.vndbService != nil && s.vndbService.isGameEligibleForStatusPush(target) {
+				if err := s.vndbService.syncGameStatus(s.ctx, target); err != nil {
+					s.handleVNDBStatusPushFailure(target, err)
+				}
+			}
 		}
 	}
 }
@@ -1980,6 +2020,27 @@ func (s *GameService) handleHikarinagiStatusPushFailure(game models.Game, err er
 	}
 }
 
+func (s *GameService) handleVNDBStatusPushFailure(game models.Game, err error) {
+	applog.LogWarningf(
+		s.ctx,
+		"VNDB status push failed for game %s (%s -> %s): %v",
+		game.Name,
+		game.SourceID,
+		game.Status,
+		err,
+	)
+
+	if s.ctx != nil && s.emitEvent != nil {
+		s.emitEvent("vndb:status-push-failed", vo.VNDBStatusPushFailureEvent{
+			GameID:      game.ID,
+			GameName:    game.Name,
+			VNID:        strings.TrimSpace(game.SourceID),
+			LocalStatus: string(game.Status),
+			Error:       err.Error(),
+		})
+	}
+}
+
 func (s *GameService) findGameIDBySource(source enums2.SourceType, sourceID string) (string, bool) {
 	if s.db == nil || sourceID == "" {
 		return "", false
@@ -2134,6 +2195,20 @@ func (s *GameService) getConfiguredMetadataSearchSources() []metadataSearchSourc
 					return s.hikarinagiService.fetchMetadataCandidatesByName(s.ctx, name)
 				},
 			})
+		default:
+			getter, err := metadata.NewPluginGetter(source, language, getterOptions...)
+			if err != nil || !getter.SupportsNameSearch() {
+				continue
+			}
+			sources = append(sources, metadataSearchSource{
+				source: source,
+				fetchByName: func(name string) (metadata.MetadataResult, error) {
+					return getter.FetchMetadataByName(name, "")
+				},
+				fetchCandidatesByName: func(name string) ([]metadata.MetadataResult, error) {
+					return metadata.FetchMetadataCandidatesByName(getter, name, "")
+				},
+			})
 		}
 	}
 	return sources
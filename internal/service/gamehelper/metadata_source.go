@@ -6,6 +6,7 @@ import (
 
 	"lunabox/internal/common/enums"
 	"lunabox/internal/models"
+	"lunabox/internal/utils/metadata"
 )
 
 func IsSupportedMetadataSource(source enums.SourceType) bool {
@@ -14,7 +15,7 @@ func IsSupportedMetadataSource(source enums.SourceType) bool {
 		enums.TouchGal, enums.Hikarinagi, enums.ErogameScape:
 		return true
 	default:
-		return false
+		return metadata.IsPluginSource(NormalizeMetadataSourceType(source))
 	}
 }
 
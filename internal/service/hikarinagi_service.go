@@ -774,7 +774,7 @@ func (s *HikarinagiService) putGameRate(ctx context.Context, workID, accessToken
 		return fmt.Errorf("%w: %s", errHikarinagiUnauthorized, hikarinagiErrorMessage(body))
 	}
 	if resp.StatusCode >= http.StatusBadRequest {
-		return fmt.Errorf("Hikarinagi%s接口返回 HTTP %d: %s", operation, resp.StatusCode, hikarinagiErrorMessage(body))
+		return httputils.NewStatusError(resp, fmt.Sprintf("Hikarinagi%s接口返回 HTTP %d: %s", operation, resp.StatusCode, hikarinagiErrorMessage(body)))
 	}
 	var envelope hikarinagiAPIEnvelope[json.RawMessage]
 	if err := json.Unmarshal(body, &envelope); err != nil {
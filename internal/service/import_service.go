@@ -396,6 +396,218 @@ func (s *ImportService) ImportFromSteamLocalWithSelection(skipNoPath bool, sameP
 	return ImportResult(result), err
 }
 
+// =================== 通用 CSV/JSON 导入功能 ====================
+
+// SelectGenericImportFile 选择要导入的 CSV 或 JSON 表格
+func (s *ImportService) SelectGenericImportFile() (string, error) {
+	selection, err := s.runtime.OpenFile(wailsruntime.OpenDialogOptions{
+		Title: "选择要导入的 CSV 或 JSON 文件",
+		Filters: []wailsruntime.FileFilter{
+			{
+				DisplayName: "表格文件 (*.csv;*.json)",
+				Pattern:     "*.csv;*.json",
+			},
+		},
+	})
+	return selection, err
+}
+
+// GetGenericImportColumns 读取文件中可用于映射的列名
+func (s *ImportService) GetGenericImportColumns(filePath string, mapping vo.GenericImportMapping) ([]string, error) {
+	return importer.NewGenericImporter(s.importerDependencies(), mapping).Columns(filePath)
+}
+
+// PreviewGenericImport 按字段映射预览 CSV/JSON 导入内容（不实际导入）
+func (s *ImportService) PreviewGenericImport(filePath string, mapping vo.GenericImportMapping) ([]PreviewGame, error) {
+	previews, err := importer.NewGenericImporter(s.importerDependencies(), mapping).Preview(filePath)
+	return previewGamesFromImporter(previews), err
+}
+
+// ImportFromGenericFile 按字段映射从 CSV/JSON 文件导入游戏、状态和游玩记录
+func (s *ImportService) ImportFromGenericFile(filePath string, mapping vo.GenericImportMapping, skipNoPath bool, samePathAction string) (ImportResult, error) {
+	result, err := importer.NewGenericImporter(s.importerDependencies(), mapping).Import(filePath, skipNoPath, samePathAction)
+	return ImportResult(result), err
+}
+
+func (s *ImportService) ImportFromGenericFileWithSelection(filePath string, mapping vo.GenericImportMapping, skipNoPath bool, samePathAction string, selections []vo.ImportSelection) (ImportResult, error) {
+	if len(selections) == 0 {
+		return emptyServiceImportResult(), nil
+	}
+	result, err := importer.NewGenericImporter(s.importerDependencies(), mapping).ImportSelected(filePath, skipNoPath, samePathAction, selections)
+	return ImportResult(result), err
+}
+
+// =================== LaunchBox 导入功能 ====================
+
+// SelectLaunchBoxDirectory 选择 LaunchBox 安装目录或其 Data 目录
+func (s *ImportService) SelectLaunchBoxDirectory() (string, error) {
+	selection, err := s.runtime.OpenDirectory(wailsruntime.OpenDialogOptions{
+		Title: "选择 LaunchBox 安装目录或 Data 目录",
+	})
+	return selection, err
+}
+
+// PreviewLaunchBoxImport 预览 LaunchBox 导入内容（不实际导入）
+func (s *ImportService) PreviewLaunchBoxImport(launchBoxPath string) ([]PreviewGame, error) {
+	previews, err := importer.NewLaunchBoxImporter(s.importerDependencies()).Preview(launchBoxPath)
+	return previewGamesFromImporter(previews), err
+}
+
+// ImportFromLaunchBox 从 LaunchBox 的平台 XML 数据导入游戏
+func (s *ImportService) ImportFromLaunchBox(launchBoxPath string, skipNoPath bool) (ImportResult, error) {
+	return s.ImportFromLaunchBoxWithOptions(launchBoxPath, skipNoPath, importer.SamePathActionSkip)
+}
+
+func (s *ImportService) ImportFromLaunchBoxWithOptions(launchBoxPath string, skipNoPath bool, samePathAction string) (ImportResult, error) {
+	result, err := importer.NewLaunchBoxImporter(s.importerDependencies()).Import(launchBoxPath, skipNoPath, samePathAction)
+	return ImportResult(result), err
+}
+
+func (s *ImportService) ImportFromLaunchBoxWithSelection(launchBoxPath string, skipNoPath bool, samePathAction string, selections []vo.ImportSelection) (ImportResult, error) {
+	if len(selections) == 0 {
+		return emptyServiceImportResult(), nil
+	}
+	result, err := importer.NewLaunchBoxImporter(s.importerDependencies()).ImportSelected(launchBoxPath, skipNoPath, samePathAction, selections)
+	return ImportResult(result), err
+}
+
+// =================== GOG Galaxy 导入功能 ====================
+
+// SelectGOGGalaxyDatabase 选择 GOG Galaxy 2.0 的 galaxy-2.0.db
+func (s *ImportService) SelectGOGGalaxyDatabase() (string, error) {
+	directory := ""
+	if programData := os.Getenv("ProgramData"); programData != "" {
+		directory = existingImportDirectory(filepath.Join(programData, "GOG.com", "Galaxy", "storage"))
+	}
+	selection, err := s.runtime.OpenFile(wailsruntime.OpenDialogOptions{
+		Title:     "选择 GOG Galaxy 数据库 (galaxy-2.0.db)",
+		Directory: directory,
+		Filters: []wailsruntime.FileFilter{
+			{
+				DisplayName: "SQLite 数据库",
+				Pattern:     "*.db",
+			},
+		},
+	})
+	return selection, err
+}
+
+// PreviewGOGGalaxyImport 预览 GOG Galaxy 数据库中的游戏
+func (s *ImportService) PreviewGOGGalaxyImport(dbPath string) ([]PreviewGame, error) {
+	previews, err := importer.NewGOGGalaxyImporter(s.importerDependencies()).Preview(dbPath)
+	return previewGamesFromImporter(previews), err
+}
+
+// ImportFromGOGGalaxy 从 GOG Galaxy 数据库导入游戏和游玩时长
+func (s *ImportService) ImportFromGOGGalaxy(dbPath string, skipNoPath bool) (ImportResult, error) {
+	return s.ImportFromGOGGalaxyWithOptions(dbPath, skipNoPath, importer.SamePathActionSkip)
+}
+
+func (s *ImportService) ImportFromGOGGalaxyWithOptions(dbPath string, skipNoPath bool, samePathAction string) (ImportResult, error) {
+	result, err := importer.NewGOGGalaxyImporter(s.importerDependencies()).Import(dbPath, skipNoPath, samePathAction)
+	return ImportResult(result), err
+}
+
+func (s *ImportService) ImportFromGOGGalaxyWithSelection(dbPath string, skipNoPath bool, samePathAction string, selections []vo.ImportSelection) (ImportResult, error) {
+	if len(selections) == 0 {
+		return emptyServiceImportResult(), nil
+	}
+	result, err := importer.NewGOGGalaxyImporter(s.importerDependencies()).ImportSelected(dbPath, skipNoPath, samePathAction, selections)
+	return ImportResult(result), err
+}
+
+// =================== Lutris 导入功能 ====================
+
+// SelectLutrisDatabase 选择 Lutris 的 pga.db
+func (s *ImportService) SelectLutrisDatabase() (string, error) {
+	directory := ""
+	if home, err := os.UserHomeDir(); err == nil {
+		directory = existingImportDirectory(filepath.Join(home, ".local", "share", "lutris"))
+	}
+	selection, err := s.runtime.OpenFile(wailsruntime.OpenDialogOptions{
+		Title:     "选择 Lutris 数据库 (pga.db)",
+		Directory: directory,
+		Filters: []wailsruntime.FileFilter{
+			{
+				DisplayName: "SQLite 数据库",
+				Pattern:     "*.db",
+			},
+		},
+	})
+	return selection, err
+}
+
+// PreviewLutrisImport 预览 Lutris 数据库中的游戏
+func (s *ImportService) PreviewLutrisImport(dbPath string) ([]PreviewGame, error) {
+	previews, err := importer.NewLutrisImporter(s.importerDependencies()).Preview(dbPath)
+	return previewGamesFromImporter(previews), err
+}
+
+// ImportFromLutris 从 Lutris 数据库导入游戏和游玩时长
+func (s *ImportService) ImportFromLutris(dbPath string, skipNoPath bool) (ImportResult, error) {
+	return s.ImportFromLutrisWithOptions(dbPath, skipNoPath, importer.SamePathActionSkip)
+}
+
+func (s *ImportService) ImportFromLutrisWithOptions(dbPath string, skipNoPath bool, samePathAction string) (ImportResult, error) {
+	result, err := importer.NewLutrisImporter(s.importerDependencies()).Import(dbPath, skipNoPath, samePathAction)
+	return ImportResult(result), err
+}
+
+func (s *ImportService) ImportFromLutrisWithSelection(dbPath string, skipNoPath bool, samePathAction string, selections []vo.ImportSelection) (ImportResult, error) {
+	if len(selections) == 0 {
+		return emptyServiceImportResult(), nil
+	}
+	result, err := importer.NewLutrisImporter(s.importerDependencies()).ImportSelected(dbPath, skipNoPath, samePathAction, selections)
+	return ImportResult(result), err
+}
+
+// =================== Heroic 导入功能 ====================
+
+// SelectHeroicDirectory 选择 Heroic Games Launcher 的配置目录
+func (s *ImportService) SelectHeroicDirectory() (string, error) {
+	directory := ""
+	if configDir, err := os.UserConfigDir(); err == nil {
+		directory = existingImportDirectory(filepath.Join(configDir, "heroic"))
+	}
+	selection, err := s.runtime.OpenDirectory(wailsruntime.OpenDialogOptions{
+		Title:     "选择 Heroic 配置目录",
+		Directory: directory,
+	})
+	return selection, err
+}
+
+// PreviewHeroicImport 预览 Heroic 游戏库中的游戏
+func (s *ImportService) PreviewHeroicImport(heroicPath string) ([]PreviewGame, error) {
+	previews, err := importer.NewHeroicImporter(s.importerDependencies()).Preview(heroicPath)
+	return previewGamesFromImporter(previews), err
+}
+
+// ImportFromHeroic 从 Heroic 游戏库导入 Epic、GOG、Amazon 和手动添加的游戏
+func (s *ImportService) ImportFromHeroic(heroicPath string, skipNoPath bool) (ImportResult, error) {
+	return s.ImportFromHeroicWithOptions(heroicPath, skipNoPath, importer.SamePathActionSkip)
+}
+
+func (s *ImportService) ImportFromHeroicWithOptions(heroicPath string, skipNoPath bool, samePathAction string) (ImportResult, error) {
+	result, err := importer.NewHeroicImporter(s.importerDependencies()).Import(heroicPath, skipNoPath, samePathAction)
+	return ImportResult(result), err
+}
+
+func (s *ImportService) ImportFromHeroicWithSelection(heroicPath string, skipNoPath bool, samePathAction string, selections []vo.ImportSelection) (ImportResult, error) {
+	if len(selections) == 0 {
+		return emptyServiceImportResult(), nil
+	}
+	result, err := importer.NewHeroicImporter(s.importerDependencies()).ImportSelected(heroicPath, skipNoPath, samePathAction, selections)
+	return ImportResult(result), err
+}
+
+// existingImportDirectory 返回存在的默认目录，用作文件对话框的初始位置
+func existingImportDirectory(path string) string {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return path
+	}
+	return ""
+}
+
 func emptyServiceImportResult() ImportResult {
 	return ImportResult{
 		FailedNames:  []string{},
@@ -1036,6 +1248,20 @@ func (s *ImportService) getConfiguredMetadataSearchSources(getterOptions []metad
 					return s.hikarinagiService.fetchMetadataCandidatesByName(s.ctx, name)
 				},
 			})
+		default:
+			getter, err := metadata.NewPluginGetter(source, language, getterOptions...)
+			if err != nil || !getter.SupportsNameSearch() {
+				continue
+			}
+			sources = append(sources, metadataSearchSource{
+				source: source,
+				fetchByName: func(name string) (metadata.MetadataResult, error) {
+					return getter.FetchMetadataByName(name, "")
+				},
+				fetchCandidatesByName: func(name string) ([]metadata.MetadataResult, error) {
+					return metadata.FetchMetadataCandidatesByName(getter, name, "")
+				},
+			})
 		}
 	}
 	return sources
@@ -0,0 +1,692 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"lunabox/internal/applog"
+	"lunabox/internal/common/enums"
+	"lunabox/internal/common/vo"
+	"lunabox/internal/models"
+	"lunabox/internal/service/gamehelper"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	GenericFormatCSV  = "csv"
+	GenericFormatJSON = "json"
+
+	genericDefaultListSeparators = ",;|、，；"
+)
+
+var genericStatusAliases = map[string]enums.GameStatus{
+	"not started":  enums.StatusNotStarted,
+	"unplayed":     enums.StatusNotStarted,
+	"backlog":      enums.StatusNotStarted,
+	"未开始":          enums.StatusNotStarted,
+	"未玩":           enums.StatusNotStarted,
+	"want to play": enums.StatusWantToPlay,
+	"wishlist":     enums.StatusWantToPlay,
+	"planned":      enums.StatusWantToPlay,
+	"plan to play": enums.StatusWantToPlay,
+	"想玩":           enums.StatusWantToPlay,
+	"in progress":  enums.StatusPlaying,
+	"游玩中":          enums.StatusPlaying,
+	"在玩":           enums.StatusPlaying,
+	"finished":     enums.StatusCompleted,
+	"beaten":       enums.StatusCompleted,
+	"cleared":      enums.StatusCompleted,
+	"done":         enums.StatusCompleted,
+	"已通关":          enums.StatusCompleted,
+	"通关":           enums.StatusCompleted,
+	"on hold":      enums.StatusOnHold,
+	"paused":       enums.StatusOnHold,
+	"dropped":      enums.StatusOnHold,
+	"abandoned":    enums.StatusOnHold,
+	"搁置":           enums.StatusOnHold,
+	"弃坑":           enums.StatusOnHold,
+}
+
+// GenericImporter 按用户提供的字段映射导入 CSV 或 JSON 表格。
+type GenericImporter struct {
+	deps    Dependencies
+	mapping vo.GenericImportMapping
+}
+
+// genericRecord 源文件中的一行（CSV）或一个对象（JSON）
+type genericRecord map[string]any
+
+// genericEntry 按名称和路径聚合后的游戏，CSV 中同一游戏的多行会合并到 records
+type genericEntry struct {
+	name    string
+	path    string
+	records []genericRecord
+}
+
+func NewGenericImporter(deps Dependencies, mapping vo.GenericImportMapping) *GenericImporter {
+	return &GenericImporter{deps: deps, mapping: mapping}
+}
+
+// Columns 返回源文件中可用于映射的列名，JSON 只列出标量字段和数组字段。
+func (g *GenericImporter) Columns(filePath string) ([]string, error) {
+	records, header, err := g.loadRecords(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if header != nil {
+		return header, nil
+	}
+
+	seen := make(map[string]struct{})
+	columns := make([]string, 0)
+	for _, record := range records {
+		for _, key := range record.keys("") {
+			if _, ok := seen[key]; !ok {
+				seen[key] = struct{}{}
+				columns = append(columns, key)
+			}
+		}
+	}
+	sort.Strings(columns)
+	return columns, nil
+}
+
+func (g *GenericImporter) Preview(filePath string) ([]PreviewGame, error) {
+	games, err := g.loadGames(filePath)
+	if err != nil {
+		applog.LogErrorf(g.deps.Ctx, "PreviewGenericImport: failed to load file: %v", err)
+		return nil, err
+	}
+	return previewLibraryGames(g.deps, "PreviewGenericImport", games)
+}
+
+func (g *GenericImporter) Import(filePath string, skipNoPath bool, samePathAction string) (ImportResult, error) {
+	return g.ImportSelected(filePath, skipNoPath, samePathAction, nil)
+}
+
+func (g *GenericImporter) ImportSelected(filePath string, skipNoPath bool, samePathAction string, selections []vo.ImportSelection) (ImportResult, error) {
+	games, err := g.loadGames(filePath)
+	if err != nil {
+		applog.LogErrorf(g.deps.Ctx, "ImportFromGenericFile: failed to load file: %v", err)
+		return newImportResult(), err
+	}
+	return importLibraryGames(g.deps, "ImportFromGenericFile", games, skipNoPath, samePathAction, selections)
+}
+
+func (g *GenericImporter) loadGames(filePath string) ([]libraryGame, error) {
+	entries, err := g.loadEntries(filePath)
+	if err != nil {
+		return nil, err
+	}
+	games := make([]libraryGame, 0, len(entries))
+	for _, entry := range entries {
+		game, sessions, tags := g.convertToGame(entry)
+		games = append(games, libraryGame{Game: game, Sessions: sessions, Tags: tags})
+	}
+	return games, nil
+}
+
+func (g *GenericImporter) format(filePath string) string {
+	switch strings.ToLower(strings.TrimSpace(g.mapping.Format)) {
+	case GenericFormatCSV:
+		return GenericFormatCSV
+	case GenericFormatJSON:
+		return GenericFormatJSON
+	}
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".json", ".jsonl", ".ndjson":
+		return GenericFormatJSON
+	}
+	return GenericFormatCSV
+}
+
+// loadRecords 读取源文件，CSV 额外返回按原顺序排列的表头
+func (g *GenericImporter) loadRecords(filePath string) ([]genericRecord, []string, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("读取导入文件失败: %w", err)
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	if g.format(filePath) == GenericFormatJSON {
+		records, err := parseGenericJSON(data, g.mapping.RecordsPath)
+		return records, nil, err
+	}
+	return parseGenericCSV(data)
+}
+
+func (g *GenericImporter) loadEntries(filePath string) ([]genericEntry, error) {
+	if strings.TrimSpace(g.mapping.Name) == "" {
+		return nil, fmt.Errorf("请指定游戏名称对应的列")
+	}
+	records, _, err := g.loadRecords(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]genericEntry, 0, len(records))
+	byKey := make(map[string]int, len(records))
+	for _, record := range records {
+		name := record.text(g.mapping.Name)
+		if name == "" {
+			continue
+		}
+		path := record.text(g.mapping.Path)
+		key := strings.ToLower(name) + "\x00" + normalizeImportPath(path)
+		if index, ok := byKey[key]; ok {
+			entries[index].records = append(entries[index].records, record)
+			continue
+		}
+		byKey[key] = len(entries)
+		entries = append(entries, genericEntry{name: name, path: path, records: []genericRecord{record}})
+	}
+	return entries, nil
+}
+
+func parseGenericCSV(data []byte) ([]genericRecord, []string, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.Comma = detectGenericCSVDelimiter(data)
+
+	header, err := reader.Read()
+	if err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, nil, fmt.Errorf("CSV 文件为空")
+		}
+		return nil, nil, fmt.Errorf("解析 CSV 表头失败: %w", err)
+	}
+	for i := range header {
+		header[i] = strings.TrimSpace(header[i])
+	}
+
+	records := make([]genericRecord, 0)
+	for {
+		row, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("解析 CSV 失败: %w", err)
+		}
+		record := make(genericRecord, len(header))
+		empty := true
+		for i, column := range header {
+			if column == "" || i >= len(row) {
+				continue
+			}
+			value := strings.TrimSpace(row[i])
+			if value != "" {
+				empty = false
+			}
+			record[column] = value
+		}
+		if !empty {
+			records = append(records, record)
+		}
+	}
+	return records, header, nil
+}
+
+// detectGenericCSVDelimiter 根据表头行判断分隔符，兼容 Excel 在部分区域导出的分号和制表符格式
+func detectGenericCSVDelimiter(data []byte) rune {
+	firstLine := data
+	if index := bytes.IndexByte(data, '\n'); index >= 0 {
+		firstLine = data[:index]
+	}
+	best, bestCount := ',', bytes.Count(firstLine, []byte(","))
+	for _, candidate := range []rune{';', '\t'} {
+		if count := bytes.Count(firstLine, []byte(string(candidate))); count > bestCount {
+			best, bestCount = candidate, count
+		}
+	}
+	return best
+}
+
+func parseGenericJSON(data []byte, recordsPath string) ([]genericRecord, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var root any
+	if err := decoder.Decode(&root); err != nil {
+		return nil, fmt.Errorf("解析 JSON 失败: %w", err)
+	}
+
+	node := root
+	if path := strings.TrimSpace(recordsPath); path != "" {
+		object, ok := root.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("JSON 根节点不是对象，无法读取 %s", path)
+		}
+		value, ok := genericRecord(object).lookup(path)
+		if !ok {
+			return nil, fmt.Errorf("JSON 中不存在字段 %s", path)
+		}
+		node = value
+	}
+
+	items, ok := node.([]any)
+	if !ok {
+		return nil, fmt.Errorf("JSON 中的游戏列表必须是数组")
+	}
+	records := make([]genericRecord, 0, len(items))
+	for _, item := range items {
+		if object, ok := item.(map[string]any); ok {
+			records = append(records, genericRecord(object))
+		}
+	}
+	return records, nil
+}
+
+// lookup 先按完整列名查找，JSON 再按 "." 分隔的嵌套路径查找，最后忽略大小写匹配
+func (r genericRecord) lookup(key string) (any, bool) {
+	key = strings.TrimSpace(key)
+	if key == "" {
+		return nil, false
+	}
+	if value, ok := r[key]; ok {
+		return value, true
+	}
+	if head, rest, found := strings.Cut(key, "."); found {
+		if child, ok := r[head].(map[string]any); ok {
+			return genericRecord(child).lookup(rest)
+		}
+	}
+	for column, value := range r {
+		if strings.EqualFold(column, key) {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+func (r genericRecord) text(key string) string {
+	value, ok := r.lookup(key)
+	if !ok {
+		return ""
+	}
+	return genericValueText(value)
+}
+
+func (r genericRecord) list(key string, separator string) []string {
+	value, ok := r.lookup(key)
+	if !ok {
+		return nil
+	}
+	if items, ok := value.([]any); ok {
+		values := make([]string, 0, len(items))
+		for _, item := range items {
+			if text := genericValueText(item); text != "" {
+				values = append(values, text)
+			}
+		}
+		return values
+	}
+	return splitGenericList(genericValueText(value), separator)
+}
+
+func (r genericRecord) records(key string) []genericRecord {
+	value, ok := r.lookup(key)
+	if !ok {
+		return nil
+	}
+	items, ok := value.([]any)
+	if !ok {
+		return nil
+	}
+	records := make([]genericRecord, 0, len(items))
+	for _, item := range items {
+		if object, ok := item.(map[string]any); ok {
+			records = append(records, genericRecord(object))
+		}
+	}
+	return records
+}
+
+// keys 列出可映射的列名，嵌套对象展开为 "a.b"
+func (r genericRecord) keys(prefix string) []string {
+	columns := make([]string, 0, len(r))
+	for column, value := range r {
+		if child, ok := value.(map[string]any); ok {
+			columns = append(columns, genericRecord(child).keys(prefix+column+".")...)
+			continue
+		}
+		columns = append(columns, prefix+column)
+	}
+	return columns
+}
+
+func genericValueText(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return strings.TrimSpace(v)
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	case []any:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			if text := genericValueText(item); text != "" {
+				values = append(values, text)
+			}
+		}
+		return strings.Join(values, ", ")
+	case map[string]any:
+		return ""
+	default:
+		return strings.TrimSpace(fmt.Sprint(v))
+	}
+}
+
+func splitGenericList(raw string, separator string) []string {
+	if raw == "" {
+		return nil
+	}
+	var parts []string
+	if separator != "" {
+		parts = strings.Split(raw, separator)
+	} else {
+		parts = strings.FieldsFunc(raw, func(r rune) bool {
+			return strings.ContainsRune(genericDefaultListSeparators, r)
+		})
+	}
+	values := make([]string, 0, len(parts))
+	for _, part := range parts {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+	return values
+}
+
+// text 取该游戏所有行中第一个非空值
+func (g *GenericImporter) text(entry genericEntry, key string) string {
+	if strings.TrimSpace(key) == "" {
+		return ""
+	}
+	for _, record := range entry.records {
+		if value := record.text(key); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func (g *GenericImporter) list(entry genericEntry, key string) []string {
+	if strings.TrimSpace(key) == "" {
+		return nil
+	}
+	for _, record := range entry.records {
+		if values := record.list(key, g.mapping.ListSeparator); len(values) > 0 {
+			return values
+		}
+	}
+	return nil
+}
+
+func (g *GenericImporter) timeField(entry genericEntry, key string) time.Time {
+	return parseImportTime(g.text(entry, key), time.Local)
+}
+
+// identity 返回默认元数据源和全部可识别的元数据源
+func (g *GenericImporter) identity(entry genericEntry) (enums.SourceType, string, []models.GameMetadataSource) {
+	sources := make([]models.GameMetadataSource, 0, len(g.mapping.SourceIDs)+1)
+	seen := make(map[enums.SourceType]struct{})
+	add := func(rawType string, rawID string) {
+		sourceType, sourceID, err := gamehelper.NormalizeMetadataSource(enums.SourceType(rawType), rawID)
+		if err != nil {
+			return
+		}
+		if _, ok := seen[sourceType]; ok {
+			return
+		}
+		seen[sourceType] = struct{}{}
+		sources = append(sources, models.GameMetadataSource{SourceType: sourceType, SourceID: sourceID})
+	}
+
+	add(g.text(entry, g.mapping.SourceType), g.text(entry, g.mapping.SourceID))
+	for _, item := range enums.AllSourceTypes {
+		for rawType, column := range g.mapping.SourceIDs {
+			if gamehelper.NormalizeMetadataSourceType(enums.SourceType(rawType)) == item.Value {
+				add(rawType, g.text(entry, column))
+			}
+		}
+	}
+
+	if len(sources) == 0 {
+		return enums.Local, "", nil
+	}
+	return sources[0].SourceType, sources[0].SourceID, sources
+}
+
+func (g *GenericImporter) convertToGame(entry genericEntry) (models.Game, []models.PlaySession, []string) {
+	gameID := uuid.New().String()
+	now := time.Now()
+	createdAt := g.timeField(entry, g.mapping.CreatedAt)
+	if createdAt.IsZero() {
+		createdAt = now
+	}
+
+	sourceType, sourceID, metadataSources := g.identity(entry)
+	coverURL := g.text(entry, g.mapping.CoverURL)
+	if !gamehelper.IsDownloadableCoverURL(coverURL) {
+		coverURL = ""
+	}
+
+	game := models.Game{
+		ID:              gameID,
+		Name:            entry.name,
+		Aliases:         g.aliases(entry),
+		CoverURL:        coverURL,
+		CoverSourceURL:  coverURL,
+		Company:         g.text(entry, g.mapping.Company),
+		Summary:         g.text(entry, g.mapping.Summary),
+		Rating:          parseGenericRating(g.text(entry, g.mapping.Rating), g.mapping.RatingScale),
+		ReleaseDate:     g.text(entry, g.mapping.ReleaseDate),
+		Path:            entry.path,
+		GameDirectory:   g.text(entry, g.mapping.GameDirectory),
+		SavePath:        g.text(entry, g.mapping.SavePath),
+		ProcessName:     g.text(entry, g.mapping.ProcessName),
+		Status:          parseGenericStatus(g.text(entry, g.mapping.Status), g.mapping.StatusValues),
+		SourceType:      sourceType,
+		SourceID:        sourceID,
+		MetadataSources: metadataSources,
+		CachedAt:        now,
+		CreatedAt:       createdAt,
+		UpdatedAt:       now,
+	}
+	if game.GameDirectory == "" {
+		game.GameDirectory = importPathDir(game.Path)
+	}
+
+	return game, g.convertSessions(gameID, entry, createdAt), g.list(entry, g.mapping.Tags)
+}
+
+func (g *GenericImporter) aliases(entry genericEntry) []string {
+	values := g.list(entry, g.mapping.Aliases)
+	if len(values) == 0 {
+		return nil
+	}
+	aliases := make([]string, 0, len(values))
+	seen := map[string]struct{}{strings.ToLower(entry.name): {}}
+	for _, value := range values {
+		key := strings.ToLower(value)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		aliases = append(aliases, value)
+	}
+	return aliases
+}
+
+func (g *GenericImporter) sessionRecords(entry genericEntry) []genericRecord {
+	if strings.TrimSpace(g.mapping.Sessions) == "" {
+		return entry.records
+	}
+	records := make([]genericRecord, 0)
+	for _, record := range entry.records {
+		records = append(records, record.records(g.mapping.Sessions)...)
+	}
+	return records
+}
+
+func (g *GenericImporter) convertSessions(gameID string, entry genericEntry, createdAt time.Time) []models.PlaySession {
+	sessions := make([]models.PlaySession, 0)
+	recordedDuration := 0
+	var earliestStart time.Time
+	if g.mapping.SessionStart != "" || g.mapping.SessionEnd != "" {
+		for index, record := range g.sessionRecords(entry) {
+			startTime := parseImportTime(record.text(g.mapping.SessionStart), time.Local)
+			endTime := parseImportTime(record.text(g.mapping.SessionEnd), time.Local)
+			duration, hasDuration := parseGenericDuration(record.text(g.mapping.SessionDuration), g.mapping.DurationUnit)
+			if !hasDuration && !startTime.IsZero() && endTime.After(startTime) {
+				duration = int(endTime.Sub(startTime).Seconds())
+			}
+			if duration <= 0 {
+				continue
+			}
+			if startTime.IsZero() && !endTime.IsZero() {
+				startTime = endTime.Add(-time.Duration(duration) * time.Second)
+			}
+			if startTime.IsZero() {
+				continue
+			}
+			if !endTime.After(startTime) {
+				endTime = startTime.Add(time.Duration(duration) * time.Second)
+			}
+			sessions = append(sessions, models.PlaySession{
+				ID:        uuid.NewSHA1(uuid.NameSpaceOID, []byte(fmt.Sprintf("generic:%s:%d:%d", strings.ToLower(entry.name), startTime.Unix(), index))).String(),
+				GameID:    gameID,
+				StartTime: startTime,
+				EndTime:   endTime,
+				Duration:  duration,
+				UpdatedAt: endTime,
+			})
+			recordedDuration += duration
+			if earliestStart.IsZero() || startTime.Before(earliestStart) {
+				earliestStart = startTime
+			}
+		}
+	}
+
+	totalDuration, _ := parseGenericDuration(g.text(entry, g.mapping.PlayTime), g.mapping.DurationUnit)
+	if totalDuration > recordedDuration {
+		remainder := totalDuration - recordedDuration
+		endTime := earliestStart
+		if endTime.IsZero() {
+			endTime = createdAt
+		}
+		sessions = append(sessions, models.PlaySession{
+			ID:        uuid.NewSHA1(uuid.NameSpaceOID, []byte("generic:"+strings.ToLower(entry.name)+":aggregate")).String(),
+			GameID:    gameID,
+			StartTime: endTime.Add(-time.Duration(remainder) * time.Second),
+			EndTime:   endTime,
+			Duration:  remainder,
+			UpdatedAt: endTime,
+		})
+	}
+	return sessions
+}
+
+func parseGenericStatus(raw string, overrides map[string]string) enums.GameStatus {
+	key := strings.ToLower(strings.TrimSpace(raw))
+	if key == "" {
+		return enums.StatusNotStarted
+	}
+	for value, status := range overrides {
+		if strings.ToLower(strings.TrimSpace(value)) != key {
+			continue
+		}
+		if mapped, ok := matchGameStatus(status); ok {
+			return mapped
+		}
+	}
+	if status, ok := matchGameStatus(key); ok {
+		return status
+	}
+	if status, ok := genericStatusAliases[strings.NewReplacer("_", " ", "-", " ").Replace(key)]; ok {
+		return status
+	}
+	return enums.StatusNotStarted
+}
+
+func matchGameStatus(raw string) (enums.GameStatus, bool) {
+	raw = strings.ToLower(strings.TrimSpace(raw))
+	for _, item := range enums.AllGameStatuses {
+		if string(item.Value) == raw {
+			return item.Value, true
+		}
+	}
+	return "", false
+}
+
+func parseGenericRating(raw string, scale float64) float64 {
+	raw = strings.TrimSpace(raw)
+	if head, _, found := strings.Cut(raw, "/"); found {
+		raw = strings.TrimSpace(head)
+	}
+	rating, err := strconv.ParseFloat(raw, 64)
+	if err != nil || rating <= 0 {
+		return 0
+	}
+	if scale > 0 {
+		rating = rating * 10 / scale
+	}
+	return math.Min(math.Round(rating*10)/10, 10)
+}
+
+// parseGenericDuration 解析为秒，支持 h:mm[:ss]、Go 时长（如 1h30m）和按 unit 计量的数字
+func parseGenericDuration(raw string, unit string) (int, bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return 0, false
+	}
+	if strings.Contains(raw, ":") {
+		parts := strings.Split(raw, ":")
+		if len(parts) > 3 {
+			return 0, false
+		}
+		seconds := 0
+		multipliers := []int{3600, 60, 1}
+		for i, part := range parts {
+			value, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil || value < 0 {
+				return 0, false
+			}
+			seconds += value * multipliers[i]
+		}
+		return seconds, true
+	}
+	if duration, err := time.ParseDuration(strings.ReplaceAll(raw, " ", "")); err == nil {
+		if duration <= 0 {
+			return 0, false
+		}
+		return int(duration.Seconds()), true
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil || value <= 0 {
+		return 0, false
+	}
+	switch strings.ToLower(strings.TrimSpace(unit)) {
+	case "s", "sec", "second", "seconds":
+		return int(value), true
+	case "h", "hour", "hours":
+		return int(value * 3600), true
+	default:
+		return int(value * 60), true
+	}
+}
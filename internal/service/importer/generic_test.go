@@ -0,0 +1,177 @@
+package importer
+
+import (
+	"lunabox/internal/common/enums"
+	"lunabox/internal/common/vo"
+	"lunabox/internal/models"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGenericImporterCSVGroupsSessionRows(t *testing.T) {
+	t.Parallel()
+
+	csvPath := writeGenericTestFile(t, "library.csv", "\ufeffTitle;Developer;State;Hours;Tags;VNDB;Started;Ended\n"+
+		"Moon Game;Moon Studio;Finished;3;Drama, Mystery;v17;2026-01-02 20:00;2026-01-02 21:00\n"+
+		"Moon Game;;;;;;2026-01-03 20:00;2026-01-03 20:30\n"+
+		"Star Game;Star Works;wishlist;;;;;\n")
+	mapping := vo.GenericImportMapping{
+		Name:         "Title",
+		Company:      "Developer",
+		Status:       "State",
+		PlayTime:     "Hours",
+		Tags:         "Tags",
+		SourceIDs:    map[string]string{"vndb": "VNDB"},
+		SessionStart: "Started",
+		SessionEnd:   "Ended",
+		DurationUnit: "hours",
+	}
+
+	columns, err := NewGenericImporter(Dependencies{}, mapping).Columns(csvPath)
+	if err != nil {
+		t.Fatalf("Columns returned an error: %v", err)
+	}
+	if len(columns) != 8 || columns[0] != "Title" || columns[7] != "Ended" {
+		t.Fatalf("Unexpected columns: %v", columns)
+	}
+
+	var committed []ImportItem
+	deps := Dependencies{
+		ListGames: func() ([]models.Game, error) { return nil, nil },
+		AddItems: func(items []ImportItem) (ImportResult, error) {
+			committed = items
+			return ImportResult{Success: len(items)}, nil
+		},
+	}
+	result, err := NewGenericImporter(deps, mapping).Import(csvPath, false, SamePathActionSkip)
+	if err != nil {
+		t.Fatalf("Import returned an error: %v", err)
+	}
+	if result.Success != 2 || len(committed) != 2 {
+		t.Fatalf("Expected two games, got result=%+v items=%d", result, len(committed))
+	}
+
+	moon := committed[0]
+	if moon.Source.Game.Company != "Moon Studio" || moon.Source.Game.Status != enums.StatusCompleted {
+		t.Fatalf("Unexpected game fields: %+v", moon.Source.Game)
+	}
+	if moon.Source.Game.SourceType != enums.VNDB || moon.Source.Game.SourceID != "v17" || len(moon.Source.Game.MetadataSources) != 1 {
+		t.Fatalf("Unexpected metadata identity: %+v", moon.Source.Game)
+	}
+	if len(moon.Source.Tags) != 2 || moon.Source.Tags[1].Name != "Mystery" {
+		t.Fatalf("Unexpected tags: %+v", moon.Source.Tags)
+	}
+	if len(moon.Sessions) != 3 {
+		t.Fatalf("Expected two sessions and one aggregate session, got %+v", moon.Sessions)
+	}
+	total := 0
+	for _, session := range moon.Sessions {
+		total += session.Duration
+		if session.GameID != moon.Source.Game.ID {
+			t.Fatalf("Session is not linked to the game: %+v", session)
+		}
+	}
+	if total != 3*3600 {
+		t.Fatalf("Expected sessions to add up to the mapped play time, got %d", total)
+	}
+
+	star := committed[1].Source.Game
+	if star.Status != enums.StatusWantToPlay || star.SourceType != enums.Local || len(committed[1].Sessions) != 0 {
+		t.Fatalf("Unexpected second game: %+v sessions=%+v", star, committed[1].Sessions)
+	}
+}
+
+func TestGenericImporterJSONMergesSamePathGame(t *testing.T) {
+	t.Parallel()
+
+	jsonPath := writeGenericTestFile(t, "library.json", `{"library":{"games":[
+		{"info":{"title":"Existing Game"},"exe":"D:\\Games\\Existing\\game.exe","status":"Beaten","log":[
+			{"at":"2026-02-01T10:00:00Z","minutes":45}
+		]},
+		{"info":{"title":"New Game"},"status":"进行中"}
+	]}}`)
+	mapping := vo.GenericImportMapping{
+		RecordsPath:     "library.games",
+		Name:            "info.title",
+		Path:            "exe",
+		Status:          "status",
+		StatusValues:    map[string]string{"进行中": string(enums.StatusPlaying)},
+		Sessions:        "log",
+		SessionStart:    "at",
+		SessionDuration: "minutes",
+	}
+	existing := models.Game{ID: "existing-id", Name: "Existing Game", Path: `D:\Games\Existing\game.exe`}
+
+	previews, err := NewGenericImporter(Dependencies{
+		ListGames: func() ([]models.Game, error) { return []models.Game{existing}, nil },
+	}, mapping).Preview(jsonPath)
+	if err != nil {
+		t.Fatalf("Preview returned an error: %v", err)
+	}
+	if len(previews) != 2 || previews[0].ConflictType != ConflictTypeSamePath || previews[0].ExistingID != "existing-id" || previews[1].Exists {
+		t.Fatalf("Unexpected previews: %+v", previews)
+	}
+
+	var committed []ImportItem
+	deps := Dependencies{
+		ListGames: func() ([]models.Game, error) { return []models.Game{existing}, nil },
+		AddItems: func(items []ImportItem) (ImportResult, error) {
+			committed = items
+			return ImportResult{Success: len(items)}, nil
+		},
+	}
+	selections := []vo.ImportSelection{{Name: "Existing Game", Path: existing.Path}}
+	if _, err := NewGenericImporter(deps, mapping).ImportSelected(jsonPath, false, SamePathActionMergeSessions, selections); err != nil {
+		t.Fatalf("ImportSelected returned an error: %v", err)
+	}
+	if len(committed) != 1 {
+		t.Fatalf("Expected only the selected game, got %d items", len(committed))
+	}
+	item := committed[0]
+	if item.Action != ImportActionMergeSessions || item.ExistingGameID != "existing-id" {
+		t.Fatalf("Expected a session merge into the existing game, got %+v", item)
+	}
+	if len(item.Sessions) != 1 || item.Sessions[0].GameID != "existing-id" || item.Sessions[0].Duration != 45*60 {
+		t.Fatalf("Unexpected sessions: %+v", item.Sessions)
+	}
+	if !item.Sessions[0].StartTime.Equal(time.Date(2026, time.February, 1, 10, 0, 0, 0, time.UTC)) {
+		t.Fatalf("Unexpected session start: %s", item.Sessions[0].StartTime)
+	}
+	if item.Source.Game.Status != enums.StatusCompleted {
+		t.Fatalf("Expected built-in status alias to map Beaten, got %q", item.Source.Game.Status)
+	}
+}
+
+func TestParseGenericDurationFormats(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		raw  string
+		unit string
+		want int
+	}{
+		{"90", "", 90 * 60},
+		{"1.5", "hours", 5400},
+		{"1:30", "", 5400},
+		{"0:01:05", "", 65},
+		{"2h15m", "seconds", 8100},
+		{"-3", "", 0},
+	}
+	for _, tc := range cases {
+		if got, _ := parseGenericDuration(tc.raw, tc.unit); got != tc.want {
+			t.Fatalf("parseGenericDuration(%q, %q) = %d, want %d", tc.raw, tc.unit, got, tc.want)
+		}
+	}
+}
+
+func writeGenericTestFile(t *testing.T, name string, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("Write test file: %v", err)
+	}
+	return path
+}
@@ -0,0 +1,415 @@
+package importer
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"lunabox/internal/applog"
+	"lunabox/internal/common/enums"
+	"lunabox/internal/common/vo"
+	"lunabox/internal/models"
+	"lunabox/internal/service/gamehelper"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// GOGGalaxyImporter 导入 GOG Galaxy 2.0 的 galaxy-2.0.db，包含 GOG 本体和 Galaxy 集成的其他平台游戏。
+type GOGGalaxyImporter struct {
+	deps Dependencies
+}
+
+type gogGalaxyRelease struct {
+	ReleaseKey     string
+	Title          string
+	OriginalTitle  string
+	Meta           gogGalaxyMeta
+	Summary        string
+	CoverURL       string
+	ExecutablePath string
+	Minutes        int64
+	LastPlayed     time.Time
+	AddedAt        time.Time
+	Tags           []string
+}
+
+type gogGalaxyMeta struct {
+	ReleaseDate int64    `json:"releaseDate"`
+	Developers  []string `json:"developers"`
+	Publishers  []string `json:"publishers"`
+	Genres      []string `json:"genres"`
+}
+
+func NewGOGGalaxyImporter(deps Dependencies) *GOGGalaxyImporter {
+	return &GOGGalaxyImporter{deps: deps}
+}
+
+func (g *GOGGalaxyImporter) Preview(dbPath string) ([]PreviewGame, error) {
+	games, err := loadGOGGalaxyGames(dbPath)
+	if err != nil {
+		applog.LogErrorf(g.deps.Ctx, "PreviewGOGGalaxyImport: failed to load database: %v", err)
+		return nil, err
+	}
+	return previewLibraryGames(g.deps, "PreviewGOGGalaxyImport", games)
+}
+
+func (g *GOGGalaxyImporter) Import(dbPath string, skipNoPath bool, samePathAction string) (ImportResult, error) {
+	return g.ImportSelected(dbPath, skipNoPath, samePathAction, nil)
+}
+
+func (g *GOGGalaxyImporter) ImportSelected(dbPath string, skipNoPath bool, samePathAction string, selections []vo.ImportSelection) (ImportResult, error) {
+	games, err := loadGOGGalaxyGames(dbPath)
+	if err != nil {
+		applog.LogErrorf(g.deps.Ctx, "ImportFromGOGGalaxy: failed to load database: %v", err)
+		return newImportResult(), err
+	}
+	return importLibraryGames(g.deps, "ImportFromGOGGalaxy", games, skipNoPath, samePathAction, selections)
+}
+
+func loadGOGGalaxyGames(dbPath string) ([]libraryGame, error) {
+	db, err := openReadOnlySQLite(dbPath, "GOG Galaxy")
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	releases, err := readGOGGalaxyPieces(db)
+	if err != nil {
+		return nil, err
+	}
+	if err := filterGOGGalaxyLibrary(db, releases); err != nil {
+		return nil, err
+	}
+	if err := readGOGGalaxyActivity(db, releases); err != nil {
+		return nil, err
+	}
+	if err := readGOGGalaxyPlayTasks(db, releases); err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(releases))
+	for key := range releases {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	games := make([]libraryGame, 0, len(keys))
+	for _, key := range keys {
+		release := releases[key]
+		if strings.TrimSpace(release.Title) == "" && strings.TrimSpace(release.OriginalTitle) == "" {
+			continue
+		}
+		games = append(games, convertGOGGalaxyRelease(*release))
+	}
+	return games, nil
+}
+
+// readGOGGalaxyPieces 从 GamePieces 读取标题、元数据、简介和封面，值均为 JSON
+func readGOGGalaxyPieces(db *sql.DB) (map[string]*gogGalaxyRelease, error) {
+	rows, err := db.Query(`
+		SELECT gp.releaseKey, gpt.type, gp.value
+		FROM GamePieces gp
+		JOIN GamePieceTypes gpt ON gpt.id = gp.gamePieceTypeId
+		WHERE gpt.type IN ('title', 'originalTitle', 'meta', 'originalMeta', 'summary', 'originalImages')
+		ORDER BY gp.releaseKey
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("读取 GOG Galaxy GamePieces 表失败: %w", err)
+	}
+	defer rows.Close()
+
+	releases := make(map[string]*gogGalaxyRelease)
+	for rows.Next() {
+		var releaseKey, pieceType string
+		var value sql.NullString
+		if err := rows.Scan(&releaseKey, &pieceType, &value); err != nil {
+			return nil, fmt.Errorf("解析 GOG Galaxy 游戏信息失败: %w", err)
+		}
+		release, ok := releases[releaseKey]
+		if !ok {
+			release = &gogGalaxyRelease{ReleaseKey: releaseKey}
+			releases[releaseKey] = release
+		}
+		if !value.Valid || strings.TrimSpace(value.String) == "" || value.String == "null" {
+			continue
+		}
+		data := []byte(value.String)
+		switch pieceType {
+		case "title", "originalTitle":
+			var piece struct {
+				Title string `json:"title"`
+			}
+			if err := json.Unmarshal(data, &piece); err != nil {
+				return nil, fmt.Errorf("解析 GOG Galaxy 游戏 %s 的标题失败: %w", releaseKey, err)
+			}
+			if pieceType == "title" {
+				release.Title = strings.TrimSpace(piece.Title)
+			} else {
+				release.OriginalTitle = strings.TrimSpace(piece.Title)
+			}
+		case "meta", "originalMeta":
+			// 用户编辑过的 meta 优先于平台原始的 originalMeta
+			if pieceType == "originalMeta" && (release.Meta.ReleaseDate != 0 || len(release.Meta.Developers) > 0) {
+				continue
+			}
+			var meta gogGalaxyMeta
+			if err := json.Unmarshal(data, &meta); err != nil {
+				return nil, fmt.Errorf("解析 GOG Galaxy 游戏 %s 的元数据失败: %w", releaseKey, err)
+			}
+			release.Meta = meta
+		case "summary":
+			var piece struct {
+				Summary string `json:"summary"`
+			}
+			if err := json.Unmarshal(data, &piece); err != nil {
+				return nil, fmt.Errorf("解析 GOG Galaxy 游戏 %s 的简介失败: %w", releaseKey, err)
+			}
+			release.Summary = strings.TrimSpace(piece.Summary)
+		case "originalImages":
+			var piece struct {
+				VerticalCover string `json:"verticalCover"`
+			}
+			if err := json.Unmarshal(data, &piece); err != nil {
+				return nil, fmt.Errorf("解析 GOG Galaxy 游戏 %s 的图片失败: %w", releaseKey, err)
+			}
+			release.CoverURL = strings.TrimSpace(piece.VerticalCover)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历 GOG Galaxy 游戏信息失败: %w", err)
+	}
+	return releases, nil
+}
+
+// filterGOGGalaxyLibrary 只保留库中拥有的非 DLC 条目，并读取用户标签；GamePieces 也会缓存未拥有的商店条目
+func filterGOGGalaxyLibrary(db *sql.DB, releases map[string]*gogGalaxyRelease) error {
+	libraryColumns, err := sqliteColumns(db, "LibraryReleases")
+	if err != nil {
+		return err
+	}
+	if libraryColumns["releaseKey"] {
+		owned, err := queryGOGGalaxyKeys(db, `SELECT releaseKey FROM LibraryReleases`)
+		if err != nil {
+			return fmt.Errorf("读取 GOG Galaxy LibraryReleases 表失败: %w", err)
+		}
+		for key := range releases {
+			if _, ok := owned[key]; !ok {
+				delete(releases, key)
+			}
+		}
+	}
+
+	propertyColumns, err := sqliteColumns(db, "ReleaseProperties")
+	if err != nil {
+		return err
+	}
+	if propertyColumns["releaseKey"] && propertyColumns["isDlc"] {
+		dlcs, err := queryGOGGalaxyKeys(db, `SELECT releaseKey FROM ReleaseProperties WHERE isDlc = 1`)
+		if err != nil {
+			return fmt.Errorf("读取 GOG Galaxy ReleaseProperties 表失败: %w", err)
+		}
+		for key := range dlcs {
+			delete(releases, key)
+		}
+	}
+
+	tagColumns, err := sqliteColumns(db, "UserReleaseTags")
+	if err != nil {
+		return err
+	}
+	if !tagColumns["releaseKey"] || !tagColumns["tag"] {
+		return nil
+	}
+	rows, err := db.Query(`SELECT releaseKey, tag FROM UserReleaseTags ORDER BY releaseKey, tag`)
+	if err != nil {
+		return fmt.Errorf("读取 GOG Galaxy UserReleaseTags 表失败: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var releaseKey, tag string
+		if err := rows.Scan(&releaseKey, &tag); err != nil {
+			return fmt.Errorf("解析 GOG Galaxy 用户标签失败: %w", err)
+		}
+		if release, ok := releases[releaseKey]; ok {
+			release.Tags = append(release.Tags, tag)
+		}
+	}
+	return rows.Err()
+}
+
+func queryGOGGalaxyKeys(db *sql.DB, query string) (map[string]struct{}, error) {
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := make(map[string]struct{})
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, err
+		}
+		keys[key] = struct{}{}
+	}
+	return keys, rows.Err()
+}
+
+// readGOGGalaxyActivity 读取累计游玩分钟、最后游玩时间和购买时间，Galaxy 中的时间均为 UTC
+func readGOGGalaxyActivity(db *sql.DB, releases map[string]*gogGalaxyRelease) error {
+	if columns, err := sqliteColumns(db, "GameTimes"); err != nil {
+		return err
+	} else if columns["releaseKey"] && columns["minutesInGame"] {
+		rows, err := db.Query(`SELECT releaseKey, SUM(minutesInGame) FROM GameTimes GROUP BY releaseKey`)
+		if err != nil {
+			return fmt.Errorf("读取 GOG Galaxy GameTimes 表失败: %w", err)
+		}
+		err = scanGOGGalaxyRows(rows, releases, func(release *gogGalaxyRelease, value sql.NullString) {
+			release.Minutes, _ = strconv.ParseInt(value.String, 10, 64)
+		})
+		if err != nil {
+			return fmt.Errorf("解析 GOG Galaxy 游玩时长失败: %w", err)
+		}
+	}
+
+	if columns, err := sqliteColumns(db, "LastPlayedDates"); err != nil {
+		return err
+	} else if columns["gameId"] && columns["lastPlayedDate"] {
+		rows, err := db.Query(`SELECT gameId, lastPlayedDate FROM LastPlayedDates`)
+		if err != nil {
+			return fmt.Errorf("读取 GOG Galaxy LastPlayedDates 表失败: %w", err)
+		}
+		err = scanGOGGalaxyRows(rows, releases, func(release *gogGalaxyRelease, value sql.NullString) {
+			release.LastPlayed = parseImportTime(value.String, time.UTC)
+		})
+		if err != nil {
+			return fmt.Errorf("解析 GOG Galaxy 最后游玩时间失败: %w", err)
+		}
+	}
+
+	if columns, err := sqliteColumns(db, "ProductPurchaseDates"); err != nil {
+		return err
+	} else if columns["gameReleaseKey"] && columns["purchaseDate"] {
+		rows, err := db.Query(`SELECT gameReleaseKey, purchaseDate FROM ProductPurchaseDates`)
+		if err != nil {
+			return fmt.Errorf("读取 GOG Galaxy ProductPurchaseDates 表失败: %w", err)
+		}
+		err = scanGOGGalaxyRows(rows, releases, func(release *gogGalaxyRelease, value sql.NullString) {
+			release.AddedAt = parseImportTime(value.String, time.UTC)
+		})
+		if err != nil {
+			return fmt.Errorf("解析 GOG Galaxy 购买时间失败: %w", err)
+		}
+	}
+	return nil
+}
+
+func scanGOGGalaxyRows(rows *sql.Rows, releases map[string]*gogGalaxyRelease, apply func(*gogGalaxyRelease, sql.NullString)) error {
+	defer rows.Close()
+	for rows.Next() {
+		var releaseKey string
+		var value sql.NullString
+		if err := rows.Scan(&releaseKey, &value); err != nil {
+			return err
+		}
+		if release, ok := releases[releaseKey]; ok && value.Valid {
+			apply(release, value)
+		}
+	}
+	return rows.Err()
+}
+
+// readGOGGalaxyPlayTasks 读取主启动任务的可执行文件，只有已安装的 GOG 游戏才有
+func readGOGGalaxyPlayTasks(db *sql.DB, releases map[string]*gogGalaxyRelease) error {
+	taskColumns, err := sqliteColumns(db, "PlayTasks")
+	if err != nil {
+		return err
+	}
+	parameterColumns, err := sqliteColumns(db, "PlayTaskLaunchParameters")
+	if err != nil {
+		return err
+	}
+	if !taskColumns["gameReleaseKey"] || !parameterColumns["executablePath"] {
+		return nil
+	}
+
+	rows, err := db.Query(`
+		SELECT pt.gameReleaseKey, ptlp.executablePath
+		FROM PlayTasks pt
+		JOIN PlayTaskLaunchParameters ptlp ON ptlp.playTaskId = pt.id
+		WHERE pt.isPrimary = 1
+		ORDER BY pt.gameReleaseKey, pt."order"
+	`)
+	if err != nil {
+		return fmt.Errorf("读取 GOG Galaxy PlayTasks 表失败: %w", err)
+	}
+	err = scanGOGGalaxyRows(rows, releases, func(release *gogGalaxyRelease, value sql.NullString) {
+		if release.ExecutablePath == "" {
+			release.ExecutablePath = strings.TrimSpace(value.String)
+		}
+	})
+	if err != nil {
+		return fmt.Errorf("解析 GOG Galaxy 启动任务失败: %w", err)
+	}
+	return nil
+}
+
+func convertGOGGalaxyRelease(release gogGalaxyRelease) libraryGame {
+	gameID := uuid.New().String()
+	createdAt := release.AddedAt
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+	name := release.Title
+	if name == "" {
+		name = release.OriginalTitle
+	}
+	company := ""
+	if len(release.Meta.Developers) > 0 {
+		company = release.Meta.Developers[0]
+	} else if len(release.Meta.Publishers) > 0 {
+		company = release.Meta.Publishers[0]
+	}
+	releaseDate := ""
+	if release.Meta.ReleaseDate > 0 {
+		releaseDate = time.Unix(release.Meta.ReleaseDate, 0).UTC().Format("2006-01-02")
+	}
+	coverURL := release.CoverURL
+	if !gamehelper.IsDownloadableCoverURL(coverURL) {
+		coverURL = ""
+	}
+	sourceType, sourceID := gogGalaxyIdentity(release.ReleaseKey)
+
+	game := models.Game{
+		ID:             gameID,
+		Name:           name,
+		CoverURL:       coverURL,
+		CoverSourceURL: coverURL,
+		Company:        strings.TrimSpace(company),
+		Summary:        release.Summary,
+		ReleaseDate:    releaseDate,
+		Path:           release.ExecutablePath,
+		GameDirectory:  importPathDir(release.ExecutablePath),
+		Status:         enums.StatusNotStarted,
+		SourceType:     sourceType,
+		SourceID:       sourceID,
+		CachedAt:       time.Now(),
+		CreatedAt:      createdAt,
+	}
+	return libraryGame{
+		Game:     game,
+		Sessions: aggregatePlaySession("gog-galaxy", release.ReleaseKey, gameID, int(release.Minutes*60), release.LastPlayed, createdAt),
+		Tags:     append(append([]string(nil), release.Meta.Genres...), release.Tags...),
+	}
+}
+
+// gogGalaxyIdentity releaseKey 形如 "steam_620"、"gog_1207658924"，只有 Steam 有对应的元数据源
+func gogGalaxyIdentity(releaseKey string) (enums.SourceType, string) {
+	platform, id, found := strings.Cut(releaseKey, "_")
+	if found && platform == "steam" && id != "" {
+		return enums.Steam, id
+	}
+	return enums.Local, ""
+}
@@ -0,0 +1,280 @@
+package importer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"lunabox/internal/applog"
+	"lunabox/internal/common/enums"
+	"lunabox/internal/common/vo"
+	"lunabox/internal/models"
+	"lunabox/internal/service/gamehelper"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// HeroicImporter 导入 Heroic Games Launcher 的 Epic、GOG、Amazon 和手动添加的游戏库缓存。
+type HeroicImporter struct {
+	deps Dependencies
+}
+
+// heroicLibraryFiles Heroic 配置目录下的游戏库缓存，Epic/Amazon 使用 library 键，GOG 和手动添加的游戏使用 games 键
+var heroicLibraryFiles = []string{
+	filepath.Join("store_cache", "legendary_library.json"),
+	filepath.Join("store_cache", "gog_library.json"),
+	filepath.Join("store_cache", "nile_library.json"),
+	filepath.Join("sideload_apps", "library.json"),
+}
+
+type heroicLibraryFile struct {
+	Library []heroicGame `json:"library"`
+	Games   []heroicGame `json:"games"`
+}
+
+type heroicGame struct {
+	AppName     string        `json:"app_name"`
+	Title       string        `json:"title"`
+	Developer   string        `json:"developer"`
+	Description string        `json:"description"`
+	Runner      string        `json:"runner"`
+	ArtCover    string        `json:"art_cover"`
+	ArtSquare   string        `json:"art_square"`
+	IsInstalled bool          `json:"is_installed"`
+	Install     heroicInstall `json:"install"`
+}
+
+type heroicInstall struct {
+	InstallPath string `json:"install_path"`
+	Executable  string `json:"executable"`
+}
+
+// heroicTimestamp store/timestamp.json 中的游玩记录，totalPlayed 单位为分钟
+type heroicTimestamp struct {
+	FirstPlayed string  `json:"firstPlayed"`
+	LastPlayed  string  `json:"lastPlayed"`
+	TotalPlayed float64 `json:"totalPlayed"`
+}
+
+// heroicData 合并后的库数据，installed 以 appName 为键补齐库缓存中缺失的安装信息
+type heroicData struct {
+	games      []heroicGame
+	installed  map[string]heroicInstall
+	timestamps map[string]heroicTimestamp
+}
+
+func NewHeroicImporter(deps Dependencies) *HeroicImporter {
+	return &HeroicImporter{deps: deps}
+}
+
+func (h *HeroicImporter) Preview(heroicPath string) ([]PreviewGame, error) {
+	games, err := loadHeroicGames(heroicPath)
+	if err != nil {
+		applog.LogErrorf(h.deps.Ctx, "PreviewHeroicImport: failed to load library: %v", err)
+		return nil, err
+	}
+	return previewLibraryGames(h.deps, "PreviewHeroicImport", games)
+}
+
+func (h *HeroicImporter) Import(heroicPath string, skipNoPath bool, samePathAction string) (ImportResult, error) {
+	return h.ImportSelected(heroicPath, skipNoPath, samePathAction, nil)
+}
+
+func (h *HeroicImporter) ImportSelected(heroicPath string, skipNoPath bool, samePathAction string, selections []vo.ImportSelection) (ImportResult, error) {
+	games, err := loadHeroicGames(heroicPath)
+	if err != nil {
+		applog.LogErrorf(h.deps.Ctx, "ImportFromHeroic: failed to load library: %v", err)
+		return newImportResult(), err
+	}
+	return importLibraryGames(h.deps, "ImportFromHeroic", games, skipNoPath, samePathAction, selections)
+}
+
+func loadHeroicGames(heroicPath string) ([]libraryGame, error) {
+	data, err := loadHeroicData(heroicPath)
+	if err != nil {
+		return nil, err
+	}
+
+	games := make([]libraryGame, 0, len(data.games))
+	seen := make(map[string]struct{}, len(data.games))
+	for _, source := range data.games {
+		key := strings.ToLower(source.Runner) + "\x00" + source.AppName
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		if installed, ok := data.installed[source.AppName]; ok {
+			if source.Install.InstallPath == "" {
+				source.Install.InstallPath = installed.InstallPath
+			}
+			if source.Install.Executable == "" {
+				source.Install.Executable = installed.Executable
+			}
+		}
+		games = append(games, convertHeroicGame(source, data.timestamps[source.AppName]))
+	}
+	return games, nil
+}
+
+// loadHeroicData 读取 Heroic 配置目录，或单个游戏库缓存文件（此时从其上两级目录查找安装和游玩信息）
+func loadHeroicData(heroicPath string) (heroicData, error) {
+	data := heroicData{
+		installed:  make(map[string]heroicInstall),
+		timestamps: make(map[string]heroicTimestamp),
+	}
+	heroicPath = strings.TrimSpace(heroicPath)
+	if heroicPath == "" {
+		return data, fmt.Errorf("Heroic 路径为空")
+	}
+	info, err := os.Stat(heroicPath)
+	if err != nil {
+		return data, fmt.Errorf("读取 Heroic 路径失败: %w", err)
+	}
+
+	heroicDir := heroicPath
+	libraryFiles := make([]string, 0, len(heroicLibraryFiles))
+	if info.IsDir() {
+		for _, name := range heroicLibraryFiles {
+			libraryFiles = append(libraryFiles, filepath.Join(heroicDir, name))
+		}
+	} else {
+		heroicDir = filepath.Dir(filepath.Dir(heroicPath))
+		libraryFiles = append(libraryFiles, heroicPath)
+	}
+
+	found := false
+	for _, file := range libraryFiles {
+		var library heroicLibraryFile
+		ok, err := readHeroicJSON(file, &library)
+		if err != nil {
+			return data, err
+		}
+		if !ok {
+			continue
+		}
+		found = true
+		data.games = append(data.games, library.Library...)
+		data.games = append(data.games, library.Games...)
+	}
+	if !found {
+		return data, fmt.Errorf("未找到 Heroic 游戏库缓存，请选择 Heroic 配置目录")
+	}
+
+	var legendaryInstalled map[string]heroicInstall
+	if _, err := readHeroicJSON(filepath.Join(heroicDir, "legendaryConfig", "legendary", "installed.json"), &legendaryInstalled); err != nil {
+		return data, err
+	}
+	for appName, install := range legendaryInstalled {
+		data.installed[appName] = install
+	}
+
+	var gogInstalled struct {
+		Installed []struct {
+			AppName     string `json:"appName"`
+			InstallPath string `json:"install_path"`
+		} `json:"installed"`
+	}
+	if _, err := readHeroicJSON(filepath.Join(heroicDir, "gog_store", "installed.json"), &gogInstalled); err != nil {
+		return data, err
+	}
+	for _, install := range gogInstalled.Installed {
+		data.installed[install.AppName] = heroicInstall{
+			InstallPath: install.InstallPath,
+			Executable:  heroicGOGExecutable(install.InstallPath, install.AppName),
+		}
+	}
+
+	if _, err := readHeroicJSON(filepath.Join(heroicDir, "store", "timestamp.json"), &data.timestamps); err != nil {
+		return data, err
+	}
+	return data, nil
+}
+
+// readHeroicJSON 文件不存在时返回 false，便于跳过未使用的商店
+func readHeroicJSON(path string, target any) (bool, error) {
+	content, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("读取 Heroic 文件 %s 失败: %w", filepath.Base(path), err)
+	}
+	if err := json.Unmarshal(content, target); err != nil {
+		return false, fmt.Errorf("解析 Heroic 文件 %s 失败: %w", filepath.Base(path), err)
+	}
+	return true, nil
+}
+
+// heroicGOGExecutable 从 GOG 安装目录的 goggame-<id>.info 中读取主启动任务
+func heroicGOGExecutable(installPath string, appName string) string {
+	if installPath == "" || appName == "" {
+		return ""
+	}
+	var info struct {
+		PlayTasks []struct {
+			IsPrimary bool   `json:"isPrimary"`
+			Type      string `json:"type"`
+			Path      string `json:"path"`
+		} `json:"playTasks"`
+	}
+	if ok, err := readHeroicJSON(filepath.Join(installPath, "goggame-"+appName+".info"), &info); !ok || err != nil {
+		return ""
+	}
+	for _, task := range info.PlayTasks {
+		if task.IsPrimary && task.Type == "FileTask" && task.Path != "" {
+			return task.Path
+		}
+	}
+	return ""
+}
+
+func convertHeroicGame(source heroicGame, timestamp heroicTimestamp) libraryGame {
+	gameID := uuid.New().String()
+	createdAt := parseImportTime(timestamp.FirstPlayed, time.UTC)
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+
+	installPath := strings.TrimSpace(source.Install.InstallPath)
+	path := ""
+	if executable := strings.TrimSpace(source.Install.Executable); executable != "" {
+		path = resolveImportPath(installPath, executable)
+	}
+	coverURL := strings.TrimSpace(source.ArtSquare)
+	if !gamehelper.IsDownloadableCoverURL(coverURL) {
+		coverURL = strings.TrimSpace(source.ArtCover)
+	}
+	if !gamehelper.IsDownloadableCoverURL(coverURL) {
+		coverURL = ""
+	}
+
+	game := models.Game{
+		ID:             gameID,
+		Name:           strings.TrimSpace(source.Title),
+		CoverURL:       coverURL,
+		CoverSourceURL: coverURL,
+		Company:        strings.TrimSpace(source.Developer),
+		Summary:        strings.TrimSpace(source.Description),
+		Path:           path,
+		GameDirectory:  installPath,
+		Status:         enums.StatusNotStarted,
+		SourceType:     enums.Local,
+		CachedAt:       time.Now(),
+		CreatedAt:      createdAt,
+	}
+	if game.GameDirectory == "" {
+		game.GameDirectory = importPathDir(path)
+	}
+	if game.Name == "" {
+		game.Name = source.AppName
+	}
+
+	return libraryGame{
+		Game:     game,
+		Sessions: aggregatePlaySession("heroic", source.Runner+":"+source.AppName, gameID, int(timestamp.TotalPlayed*60), parseImportTime(timestamp.LastPlayed, time.UTC), createdAt),
+	}
+}
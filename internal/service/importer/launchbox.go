@@ -0,0 +1,205 @@
+package importer
+
+import (
+	"encoding/xml"
+	"fmt"
+	"lunabox/internal/applog"
+	"lunabox/internal/common/enums"
+	"lunabox/internal/common/vo"
+	"lunabox/internal/models"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LaunchBoxImporter 导入 LaunchBox Data 目录下各平台 XML 中的游戏。
+type LaunchBoxImporter struct {
+	deps Dependencies
+}
+
+type launchBoxData struct {
+	Games []launchBoxGame `xml:"Game"`
+}
+
+type launchBoxGame struct {
+	ID              string  `xml:"ID"`
+	Title           string  `xml:"Title"`
+	ApplicationPath string  `xml:"ApplicationPath"`
+	RootFolder      string  `xml:"RootFolder"`
+	Developer       string  `xml:"Developer"`
+	Publisher       string  `xml:"Publisher"`
+	Notes           string  `xml:"Notes"`
+	Genre           string  `xml:"Genre"`
+	Platform        string  `xml:"Platform"`
+	ReleaseDate     string  `xml:"ReleaseDate"`
+	DateAdded       string  `xml:"DateAdded"`
+	LastPlayedDate  string  `xml:"LastPlayedDate"`
+	PlayTime        int64   `xml:"PlayTime"` // 秒
+	Completed       bool    `xml:"Completed"`
+	StarRating      int     `xml:"StarRating"`      // 0~5
+	StarRatingFloat float64 `xml:"StarRatingFloat"` // 0~5，新版本支持半星
+}
+
+func NewLaunchBoxImporter(deps Dependencies) *LaunchBoxImporter {
+	return &LaunchBoxImporter{deps: deps}
+}
+
+func (l *LaunchBoxImporter) Preview(launchBoxPath string) ([]PreviewGame, error) {
+	games, err := loadLaunchBoxGames(launchBoxPath)
+	if err != nil {
+		applog.LogErrorf(l.deps.Ctx, "PreviewLaunchBoxImport: failed to load data: %v", err)
+		return nil, err
+	}
+	return previewLibraryGames(l.deps, "PreviewLaunchBoxImport", games)
+}
+
+func (l *LaunchBoxImporter) Import(launchBoxPath string, skipNoPath bool, samePathAction string) (ImportResult, error) {
+	return l.ImportSelected(launchBoxPath, skipNoPath, samePathAction, nil)
+}
+
+func (l *LaunchBoxImporter) ImportSelected(launchBoxPath string, skipNoPath bool, samePathAction string, selections []vo.ImportSelection) (ImportResult, error) {
+	games, err := loadLaunchBoxGames(launchBoxPath)
+	if err != nil {
+		applog.LogErrorf(l.deps.Ctx, "ImportFromLaunchBox: failed to load data: %v", err)
+		return newImportResult(), err
+	}
+	return importLibraryGames(l.deps, "ImportFromLaunchBox", games, skipNoPath, samePathAction, selections)
+}
+
+// loadLaunchBoxGames 读取 LaunchBox 根目录、Data 目录、Platforms 目录或单个平台 XML。
+// 相对的 ApplicationPath 以 LaunchBox 根目录为基准。
+func loadLaunchBoxGames(launchBoxPath string) ([]libraryGame, error) {
+	launchBoxPath = strings.TrimSpace(launchBoxPath)
+	if launchBoxPath == "" {
+		return nil, fmt.Errorf("LaunchBox 路径为空")
+	}
+	files, rootDir, err := findLaunchBoxDataFiles(launchBoxPath)
+	if err != nil {
+		return nil, err
+	}
+
+	games := make([]libraryGame, 0)
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("读取 LaunchBox 数据文件失败: %w", err)
+		}
+		var parsed launchBoxData
+		if err := xml.Unmarshal(data, &parsed); err != nil {
+			return nil, fmt.Errorf("解析 LaunchBox 数据文件 %s 失败: %w", filepath.Base(file), err)
+		}
+		for _, source := range parsed.Games {
+			if strings.TrimSpace(source.Title) == "" {
+				continue
+			}
+			games = append(games, convertLaunchBoxGame(source, rootDir))
+		}
+	}
+	return games, nil
+}
+
+func findLaunchBoxDataFiles(launchBoxPath string) ([]string, string, error) {
+	info, err := os.Stat(launchBoxPath)
+	if err != nil {
+		return nil, "", fmt.Errorf("读取 LaunchBox 路径失败: %w", err)
+	}
+	if !info.IsDir() {
+		if !strings.EqualFold(filepath.Ext(launchBoxPath), ".xml") {
+			return nil, "", fmt.Errorf("LaunchBox 数据文件必须是 XML")
+		}
+		dataDir := filepath.Dir(launchBoxPath)
+		if strings.EqualFold(filepath.Base(dataDir), "Platforms") {
+			dataDir = filepath.Dir(dataDir)
+		}
+		return []string{launchBoxPath}, filepath.Dir(dataDir), nil
+	}
+
+	rootDir := launchBoxPath
+	switch {
+	case strings.EqualFold(filepath.Base(launchBoxPath), "Platforms"):
+		rootDir = filepath.Dir(filepath.Dir(launchBoxPath))
+	case strings.EqualFold(filepath.Base(launchBoxPath), "Data"):
+		rootDir = filepath.Dir(launchBoxPath)
+	}
+	dataDir := filepath.Join(rootDir, "Data")
+
+	files, err := filepath.Glob(filepath.Join(dataDir, "Platforms", "*.xml"))
+	if err != nil {
+		return nil, "", fmt.Errorf("查找 LaunchBox 平台数据失败: %w", err)
+	}
+	// 早期版本把所有游戏存放在 Data/LaunchBox.xml
+	if len(files) == 0 {
+		legacyFile := filepath.Join(dataDir, "LaunchBox.xml")
+		if _, err := os.Stat(legacyFile); err == nil {
+			files = append(files, legacyFile)
+		}
+	}
+	if len(files) == 0 {
+		return nil, "", fmt.Errorf("未找到 LaunchBox 数据文件，请选择 LaunchBox 安装目录或其 Data 目录")
+	}
+	sort.Strings(files)
+	return files, rootDir, nil
+}
+
+func convertLaunchBoxGame(source launchBoxGame, rootDir string) libraryGame {
+	gameID := uuid.New().String()
+	createdAt := parseImportTime(source.DateAdded, time.Local)
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+
+	path := resolveImportPath(rootDir, source.ApplicationPath)
+	directory := resolveImportPath(rootDir, source.RootFolder)
+	if directory == "" {
+		directory = importPathDir(path)
+	}
+	company := strings.TrimSpace(source.Developer)
+	if company == "" {
+		company = strings.TrimSpace(source.Publisher)
+	}
+	rating := source.StarRatingFloat
+	if rating <= 0 {
+		rating = float64(source.StarRating)
+	}
+	status := enums.StatusNotStarted
+	if source.Completed {
+		status = enums.StatusCompleted
+	}
+
+	game := models.Game{
+		ID:            gameID,
+		Name:          strings.TrimSpace(source.Title),
+		Company:       company,
+		Summary:       strings.TrimSpace(source.Notes),
+		Rating:        rating * 2,
+		ReleaseDate:   launchBoxReleaseDate(source.ReleaseDate),
+		Path:          path,
+		GameDirectory: directory,
+		Status:        status,
+		SourceType:    enums.Local,
+		CachedAt:      time.Now(),
+		CreatedAt:     createdAt,
+	}
+	sessionKey := source.ID
+	if sessionKey == "" {
+		sessionKey = game.Name
+	}
+	return libraryGame{
+		Game:     game,
+		Sessions: aggregatePlaySession("launchbox", sessionKey, gameID, int(source.PlayTime), parseImportTime(source.LastPlayedDate, time.Local), createdAt),
+		Tags:     splitGenericList(source.Genre, ";"),
+	}
+}
+
+// launchBoxReleaseDate 发售日期在 XML 中带时间和时区，只保留日期部分
+func launchBoxReleaseDate(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if date, _, found := strings.Cut(raw, "T"); found {
+		return date
+	}
+	return raw
+}
@@ -0,0 +1,306 @@
+package importer
+
+import (
+	"database/sql"
+	"fmt"
+	"lunabox/internal/applog"
+	"lunabox/internal/common/vo"
+	"lunabox/internal/models"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	_ "github.com/mattn/go-sqlite3"
+)
+
+var importTimeLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02T15:04:05",
+	"2006-01-02",
+	"2006/01/02 15:04:05",
+	"2006/01/02 15:04",
+	"2006/01/02",
+	"2006.01.02",
+}
+
+// libraryGame 从外部游戏库转换出的游戏、游玩记录和标签
+type libraryGame struct {
+	Game     models.Game
+	Sessions []models.PlaySession
+	Tags     []string
+}
+
+// conflictPath 返回用于重复检测的路径，没有启动文件时退回安装目录
+func (g libraryGame) conflictPath() string {
+	if g.Game.Path != "" {
+		return g.Game.Path
+	}
+	return g.Game.GameDirectory
+}
+
+func previewLibraryGames(deps Dependencies, logPrefix string, games []libraryGame) ([]PreviewGame, error) {
+	existingGames, _, _, err := deps.existingGames(logPrefix)
+	if err != nil {
+		return nil, err
+	}
+	existingIndex := newExistingPreviewIndex(existingGames)
+
+	previews := make([]PreviewGame, 0, len(games))
+	for _, item := range games {
+		game := item.Game
+		conflict := previewConflict(existingIndex, game.Name, item.conflictPath(), string(game.SourceType), game.SourceID)
+		previews = append(previews, PreviewGame{
+			Name:         game.Name,
+			Developer:    game.Company,
+			SourceType:   string(game.SourceType),
+			SourceID:     game.SourceID,
+			Path:         game.Path,
+			Exists:       conflict.Type != ConflictTypeNone,
+			ConflictType: conflict.Type,
+			ExistingID:   conflict.Game.ID,
+			ExistingName: conflict.Game.Name,
+			AddTime:      game.CreatedAt,
+			HasPath:      game.Path != "",
+		})
+	}
+	return previews, nil
+}
+
+func importLibraryGames(
+	deps Dependencies,
+	logPrefix string,
+	games []libraryGame,
+	skipNoPath bool,
+	samePathAction string,
+	selections []vo.ImportSelection,
+) (ImportResult, error) {
+	result := newImportResult()
+	samePathAction = NormalizeSamePathAction(samePathAction)
+	selectionFilter := newImportSelectionFilter(selections)
+
+	startedAt := time.Now()
+	existingGames, existingNames, existingPaths, err := deps.existingGames(logPrefix)
+	if err != nil {
+		return result, err
+	}
+
+	items := make([]ImportItem, 0, len(games))
+	for _, item := range games {
+		game := item.Game
+		sessions := item.Sessions
+		path := item.conflictPath()
+		if !selectionFilter.includes(game.Name, game.Path, string(game.SourceType), game.SourceID) {
+			continue
+		}
+		if skipNoPath && game.Path == "" {
+			result.Skipped++
+			result.SkippedNames = append(result.SkippedNames, game.Name+" (无路径)")
+			continue
+		}
+
+		action := ImportActionCreate
+		existingGameID := ""
+		if conflict, exists := findExistingGameConflict(existingGames, existingNames, existingPaths, game.Name, path); exists {
+			if conflict.Type != ConflictTypeSamePath || !IsSamePathMergeAction(samePathAction) {
+				result.Skipped++
+				if conflict.Type == ConflictTypeNameAndPath {
+					result.SkippedNames = append(result.SkippedNames, game.Name+" (已存在)")
+				} else {
+					result.SkippedNames = append(result.SkippedNames, game.Name+" (路径已存在: "+conflict.Game.Name+")")
+				}
+				continue
+			}
+			action = ImportActionUpdateExisting
+			if samePathAction == SamePathActionMergeSessions {
+				action = ImportActionMergeSessions
+			}
+			existingGameID = conflict.Game.ID
+			game.ID = conflict.Game.ID
+			game.Path = conflict.Game.Path
+			for i := range sessions {
+				sessions[i].GameID = conflict.Game.ID
+			}
+		}
+
+		items = append(items, ImportItem{
+			Source: vo.GameMetadataFromWebVO{
+				Source: game.SourceType,
+				Game:   game,
+				Tags:   tagsFromNames(item.Tags),
+			},
+			Sessions:       sessions,
+			DisplayName:    game.Name,
+			Path:           path,
+			Action:         action,
+			ExistingGameID: existingGameID,
+		})
+		if action == ImportActionCreate {
+			updateExistingIndexes(existingNames, existingPaths, game, game.Name, path)
+		}
+	}
+
+	batchResult, err := addImportedItems(deps, items)
+	if err != nil {
+		applog.LogErrorf(deps.Ctx, "%s: failed to batch add games: %v", logPrefix, err)
+		return result, err
+	}
+	result.Success += batchResult.Success
+	result.Skipped += batchResult.Skipped
+	result.Failed += batchResult.Failed
+	result.SessionsImported += batchResult.SessionsImported
+	result.SkippedNames = append(result.SkippedNames, batchResult.SkippedNames...)
+	result.FailedNames = append(result.FailedNames, batchResult.FailedNames...)
+
+	applog.LogInfof(deps.Ctx, "%s: complete success=%d skipped=%d failed=%d sessions=%d total=%s", logPrefix, result.Success, result.Skipped, result.Failed, result.SessionsImported, time.Since(startedAt))
+	return result, nil
+}
+
+// aggregatePlaySession 把外部库只记录了总时长的游玩时间补为一条汇总记录，结束于最后游玩时间
+func aggregatePlaySession(source string, key string, gameID string, seconds int, lastPlayed time.Time, fallback time.Time) []models.PlaySession {
+	if seconds <= 0 {
+		return nil
+	}
+	endTime := lastPlayed
+	if endTime.IsZero() {
+		endTime = fallback
+	}
+	if endTime.IsZero() {
+		endTime = time.Now()
+	}
+	return []models.PlaySession{{
+		ID:        uuid.NewSHA1(uuid.NameSpaceOID, []byte(source+":"+key+":aggregate")).String(),
+		GameID:    gameID,
+		StartTime: endTime.Add(-time.Duration(seconds) * time.Second),
+		EndTime:   endTime,
+		Duration:  seconds,
+		UpdatedAt: endTime,
+	}}
+}
+
+// parseImportTime 支持常见日期格式和 Unix 时间戳（秒或毫秒），无时区的时间按 location 解析
+func parseImportTime(raw string, location *time.Location) time.Time {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}
+	}
+	if value, err := strconv.ParseInt(raw, 10, 64); err == nil {
+		if value > 1e11 {
+			return time.UnixMilli(value)
+		}
+		if value > 1e8 {
+			return time.Unix(value, 0)
+		}
+		return time.Time{}
+	}
+	for _, layout := range importTimeLayouts {
+		if parsed, err := time.ParseInLocation(layout, raw, location); err == nil {
+			return parsed
+		}
+	}
+	return time.Time{}
+}
+
+// unixTime 把外部库中为 0 表示未知的 Unix 秒转换为时间
+func unixTime(seconds int64) time.Time {
+	if seconds <= 0 {
+		return time.Time{}
+	}
+	return time.Unix(seconds, 0)
+}
+
+// isAbsoluteImportPath 同时识别当前系统和 Windows 风格的绝对路径，外部库可能来自另一台机器
+func isAbsoluteImportPath(path string) bool {
+	if filepath.IsAbs(path) || strings.HasPrefix(path, `\\`) {
+		return true
+	}
+	return len(path) >= 3 && path[1] == ':' && (path[2] == '\\' || path[2] == '/') &&
+		((path[0] >= 'A' && path[0] <= 'Z') || (path[0] >= 'a' && path[0] <= 'z'))
+}
+
+// resolveImportPath 把相对路径拼接到 base 下，并统一为当前系统的分隔符
+func resolveImportPath(base string, path string) string {
+	path = strings.TrimSpace(path)
+	if path == "" || isAbsoluteImportPath(path) {
+		return path
+	}
+	path = filepath.FromSlash(strings.ReplaceAll(path, `\`, "/"))
+	if base == "" {
+		return path
+	}
+	return filepath.Join(base, path)
+}
+
+// importPathDir 返回启动文件所在目录，兼容 Windows 分隔符
+func importPathDir(path string) string {
+	index := strings.LastIndexAny(path, `/\`)
+	if index <= 0 {
+		return ""
+	}
+	return path[:index]
+}
+
+// openReadOnlySQLite 以只读方式打开外部程序的 SQLite 数据库，避免锁住或修改原文件
+func openReadOnlySQLite(dbPath string, appName string) (*sql.DB, error) {
+	dbPath = strings.TrimSpace(dbPath)
+	if dbPath == "" {
+		return nil, fmt.Errorf("%s 数据库路径为空", appName)
+	}
+	info, err := os.Stat(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("读取 %s 数据库失败: %w", appName, err)
+	}
+	if info.IsDir() {
+		return nil, fmt.Errorf("%s 数据库路径不能是目录", appName)
+	}
+
+	absPath, err := filepath.Abs(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("解析 %s 数据库路径失败: %w", appName, err)
+	}
+	uriPath := filepath.ToSlash(absPath)
+	if filepath.VolumeName(absPath) != "" && !strings.HasPrefix(uriPath, "/") {
+		uriPath = "/" + uriPath
+	}
+	dsn := url.URL{Scheme: "file", Path: uriPath}
+	query := dsn.Query()
+	query.Set("mode", "ro")
+	dsn.RawQuery = query.Encode()
+
+	db, err := sql.Open("sqlite3", dsn.String())
+	if err != nil {
+		return nil, fmt.Errorf("初始化 %s 数据库读取器失败: %w", appName, err)
+	}
+	db.SetMaxOpenConns(1)
+
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("以只读方式打开 %s SQLite 数据库失败: %w", appName, err)
+	}
+	return db, nil
+}
+
+// sqliteColumns 返回表中存在的列，表不存在时返回空集合；用于兼容外部程序不同版本的表结构
+func sqliteColumns(db *sql.DB, table string) (map[string]bool, error) {
+	rows, err := db.Query(`SELECT name FROM pragma_table_info(?)`, table)
+	if err != nil {
+		return nil, fmt.Errorf("读取 %s 表结构失败: %w", table, err)
+	}
+	defer rows.Close()
+
+	columns := make(map[string]bool)
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("解析 %s 表结构失败: %w", table, err)
+		}
+		columns[name] = true
+	}
+	return columns, rows.Err()
+}
@@ -0,0 +1,230 @@
+package importer
+
+import (
+	"bytes"
+	"io/fs"
+	"lunabox/internal/common/enums"
+	"lunabox/internal/models"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLaunchBoxImporterReadsPlatformXML(t *testing.T) {
+	t.Parallel()
+
+	rootDir := copyLibraryFixture(t, "launchbox")
+
+	committed := importLibraryTestItems(t, func(deps Dependencies) (ImportResult, error) {
+		return NewLaunchBoxImporter(deps).Import(filepath.Join(rootDir, "Data"), false, SamePathActionSkip)
+	})
+	if len(committed) != 2 {
+		t.Fatalf("expected 2 games, got %d", len(committed))
+	}
+
+	lantern := committed[0]
+	game := lantern.Source.Game
+	if game.Path != filepath.Join(rootDir, "Games", "Lantern Road", "lantern.exe") || game.GameDirectory != filepath.Join(rootDir, "Games", "Lantern Road") {
+		t.Fatalf("relative application path was not resolved: %+v", game)
+	}
+	if game.Status != enums.StatusCompleted || game.Rating != 9 || game.ReleaseDate != "2024-11-20" || game.Company != "Harbor Lights" {
+		t.Fatalf("unexpected LaunchBox fields: %+v", game)
+	}
+	if len(lantern.Source.Tags) != 2 || lantern.Source.Tags[1].Name != "Puzzle" {
+		t.Fatalf("unexpected genre tags: %+v", lantern.Source.Tags)
+	}
+	assertAggregateSession(t, lantern, 5400, time.Date(2026, 1, 5, 20, 0, 0, 0, time.UTC))
+
+	tidal := committed[1].Source.Game
+	if tidal.Path != `D:\Portable\Tidal\tidal.exe` || tidal.GameDirectory != `D:\Portable\Tidal` || tidal.Company != "Tidal Works" {
+		t.Fatalf("unexpected second game: %+v", tidal)
+	}
+	if tidal.Status != enums.StatusNotStarted || len(committed[1].Sessions) != 0 {
+		t.Fatalf("game without play time should stay unplayed: %+v sessions=%+v", tidal, committed[1].Sessions)
+	}
+}
+
+func TestGOGGalaxyImporterFiltersOwnedReleases(t *testing.T) {
+	t.Parallel()
+
+	dbPath := filepath.Join(copyLibraryFixture(t, "gog-galaxy"), "galaxy-2.0.db")
+
+	previews, err := NewGOGGalaxyImporter(Dependencies{
+		ListGames: func() ([]models.Game, error) { return nil, nil },
+	}).Preview(dbPath)
+	if err != nil {
+		t.Fatalf("Preview returned an error: %v", err)
+	}
+	if len(previews) != 2 {
+		t.Fatalf("expected DLC and unowned releases to be skipped, got %+v", previews)
+	}
+
+	committed := importLibraryTestItems(t, func(deps Dependencies) (ImportResult, error) {
+		return NewGOGGalaxyImporter(deps).Import(dbPath, false, SamePathActionSkip)
+	})
+	if len(committed) != 2 {
+		t.Fatalf("expected 2 games, got %d", len(committed))
+	}
+
+	starfall := committed[0]
+	game := starfall.Source.Game
+	if game.Name != "Starfall Saga" || game.Path != `C:\GOG Games\Starfall Saga\starfall.exe` || game.GameDirectory != `C:\GOG Games\Starfall Saga` {
+		t.Fatalf("unexpected GOG game: %+v", game)
+	}
+	if game.Company != "Comet Forge" || game.ReleaseDate != "2015-05-19" || game.CoverURL != "https://images.example.com/starfall.webp" || game.SourceType != enums.Local {
+		t.Fatalf("unexpected GOG metadata: %+v", game)
+	}
+	assertAggregateSession(t, starfall, 7200, time.Date(2026, 2, 10, 18, 30, 0, 0, time.UTC))
+
+	mirror := committed[1]
+	if mirror.Source.Game.Name != "Mirror Test: Remastered" || mirror.Source.Game.SourceType != enums.Steam || mirror.Source.Game.SourceID != "620" {
+		t.Fatalf("unexpected Steam release: %+v", mirror.Source.Game)
+	}
+	if len(mirror.Source.Tags) != 1 || mirror.Source.Tags[0].Name != "Puzzle" || len(mirror.Sessions) != 1 || mirror.Sessions[0].Duration != 1800 {
+		t.Fatalf("unexpected Steam release activity: tags=%+v sessions=%+v", mirror.Source.Tags, mirror.Sessions)
+	}
+}
+
+func TestLutrisImporterReadsGameConfig(t *testing.T) {
+	t.Parallel()
+
+	dbPath := filepath.Join(copyLibraryFixture(t, "lutris"), "pga.db")
+
+	committed := importLibraryTestItems(t, func(deps Dependencies) (ImportResult, error) {
+		return NewLutrisImporter(deps).Import(dbPath, false, SamePathActionSkip)
+	})
+	if len(committed) != 2 {
+		t.Fatalf("expected 2 games, got %d", len(committed))
+	}
+
+	ember := committed[0]
+	game := ember.Source.Game
+	if game.Path != filepath.Join("/games/ember-keep", "drive_c", "Games", "Ember Keep", "ember.exe") || game.GameDirectory != "/games/ember-keep" || game.WinePrefix != "/games/ember-keep" {
+		t.Fatalf("unexpected Lutris launch fields: %+v", game)
+	}
+	if len(ember.Source.Tags) != 1 || ember.Source.Tags[0].Name != "favorite" {
+		t.Fatalf("hidden category should be skipped: %+v", ember.Source.Tags)
+	}
+	assertAggregateSession(t, ember, 9000, time.Unix(1767643200, 0))
+
+	portal := committed[1].Source.Game
+	if portal.SourceType != enums.Steam || portal.SourceID != "400" || portal.LaunchMode != enums.LaunchModeSteam || portal.SteamLaunchID != "400" {
+		t.Fatalf("unexpected Steam runner game: %+v", portal)
+	}
+}
+
+func TestParseLutrisGameConfigOnlyReadsGameSection(t *testing.T) {
+	t.Parallel()
+
+	config := parseLutrisGameConfig(strings.NewReader(`system:
+  exe: /usr/bin/wrong
+game:
+  # comment
+  exe: "/games/quoted \"exe\".exe"
+  working_dir: '/games/it''s here'
+wine:
+  prefix: /wrong/prefix
+`))
+	if config.Exe != `/games/quoted "exe".exe` || config.WorkingDir != "/games/it's here" || config.Prefix != "" {
+		t.Fatalf("unexpected config: %+v", config)
+	}
+}
+
+func TestHeroicImporterMergesInstallInfoAndPlayTime(t *testing.T) {
+	t.Parallel()
+
+	heroicDir := copyLibraryFixture(t, "heroic")
+	installDir := filepath.ToSlash(heroicDir) + "/games/Copper Bell"
+
+	committed := importLibraryTestItems(t, func(deps Dependencies) (ImportResult, error) {
+		return NewHeroicImporter(deps).Import(heroicDir, false, SamePathActionSkip)
+	})
+	if len(committed) != 2 {
+		t.Fatalf("expected duplicate library entries to be merged, got %d games", len(committed))
+	}
+
+	fennel := committed[0]
+	game := fennel.Source.Game
+	if game.Path != filepath.Join("/games/Fennel", "Binaries", "Fennel.exe") || game.GameDirectory != "/games/Fennel" || game.Company != "Sprout Co" {
+		t.Fatalf("unexpected Epic game: %+v", game)
+	}
+	if game.CoverURL != "https://cdn.example.com/fennel.jpg" || !game.CreatedAt.Equal(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Fatalf("unexpected Epic metadata: %+v", game)
+	}
+	assertAggregateSession(t, fennel, 95*60, time.Date(2026, 3, 4, 22, 0, 0, 0, time.UTC))
+
+	copper := committed[1].Source.Game
+	if copper.Path != filepath.Join(installDir, "bin", "copper.exe") || copper.CoverURL != "https://images.example.com/copper.png" {
+		t.Fatalf("unexpected GOG game: %+v", copper)
+	}
+	if len(committed[1].Sessions) != 0 {
+		t.Fatalf("game without timestamp should have no sessions: %+v", committed[1].Sessions)
+	}
+}
+
+func importLibraryTestItems(t *testing.T, run func(Dependencies) (ImportResult, error)) []ImportItem {
+	t.Helper()
+
+	var committed []ImportItem
+	deps := Dependencies{
+		ListGames: func() ([]models.Game, error) { return nil, nil },
+		AddItems: func(items []ImportItem) (ImportResult, error) {
+			committed = items
+			return ImportResult{Success: len(items)}, nil
+		},
+	}
+	result, err := run(deps)
+	if err != nil {
+		t.Fatalf("Import returned an error: %v", err)
+	}
+	if result.Success != len(committed) {
+		t.Fatalf("unexpected import result: %+v items=%d", result, len(committed))
+	}
+	return committed
+}
+
+func assertAggregateSession(t *testing.T, item ImportItem, seconds int, endTime time.Time) {
+	t.Helper()
+
+	if len(item.Sessions) != 1 {
+		t.Fatalf("expected one aggregate session, got %+v", item.Sessions)
+	}
+	session := item.Sessions[0]
+	if session.GameID != item.Source.Game.ID || session.Duration != seconds || !session.EndTime.Equal(endTime) {
+		t.Fatalf("unexpected aggregate session: %+v", session)
+	}
+	if !session.StartTime.Equal(endTime.Add(-time.Duration(seconds) * time.Second)) {
+		t.Fatalf("aggregate session should end at the last played time: %+v", session)
+	}
+}
+
+// libraryFixtureDir 保存从各启动器实际数据目录整理出的导入样本
+var libraryFixtureDir = filepath.Join("..", "test", "testdata", "library")
+
+// copyLibraryFixture 把样本目录复制到临时目录，避免导入器读取 SQLite 时在仓库中留下日志文件。
+// JSON 中的 {{FIXTURE_DIR}} 会替换为复制后的目录，用于启动器记录的绝对安装路径。
+func copyLibraryFixture(t *testing.T, name string) string {
+	t.Helper()
+
+	targetDir := t.TempDir()
+	if err := os.CopyFS(targetDir, os.DirFS(filepath.Join(libraryFixtureDir, name))); err != nil {
+		t.Fatalf("copy %s fixture: %v", name, err)
+	}
+	err := filepath.WalkDir(targetDir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() || filepath.Ext(path) != ".json" {
+			return err
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		content = bytes.ReplaceAll(content, []byte("{{FIXTURE_DIR}}"), []byte(filepath.ToSlash(targetDir)))
+		return os.WriteFile(path, content, 0o644)
+	})
+	if err != nil {
+		t.Fatalf("prepare %s fixture: %v", name, err)
+	}
+	return targetDir
+}
@@ -0,0 +1,333 @@
+package importer
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"io"
+	"lunabox/internal/applog"
+	"lunabox/internal/common/enums"
+	"lunabox/internal/common/vo"
+	"lunabox/internal/models"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LutrisImporter 导入 Lutris 的 pga.db，启动文件和 Wine 前缀从游戏的 YAML 配置中读取。
+type LutrisImporter struct {
+	deps Dependencies
+}
+
+type lutrisGame struct {
+	ID          int64
+	Name        string
+	Slug        string
+	Runner      string
+	Executable  string
+	Directory   string
+	ConfigPath  string
+	Service     string
+	ServiceID   string
+	LastPlayed  int64
+	InstalledAt int64
+	PlayHours   float64
+	Categories  []string
+}
+
+// lutrisGameConfig 游戏 YAML 配置中 game 段的字段
+type lutrisGameConfig struct {
+	Exe        string
+	Prefix     string
+	WorkingDir string
+}
+
+var lutrisGameColumns = []string{
+	"id", "name", "slug", "runner", "executable", "directory", "configpath",
+	"service", "service_id", "lastplayed", "installed_at", "playtime",
+}
+
+func NewLutrisImporter(deps Dependencies) *LutrisImporter {
+	return &LutrisImporter{deps: deps}
+}
+
+func (l *LutrisImporter) Preview(dbPath string) ([]PreviewGame, error) {
+	games, err := loadLutrisGames(dbPath)
+	if err != nil {
+		applog.LogErrorf(l.deps.Ctx, "PreviewLutrisImport: failed to load database: %v", err)
+		return nil, err
+	}
+	return previewLibraryGames(l.deps, "PreviewLutrisImport", games)
+}
+
+func (l *LutrisImporter) Import(dbPath string, skipNoPath bool, samePathAction string) (ImportResult, error) {
+	return l.ImportSelected(dbPath, skipNoPath, samePathAction, nil)
+}
+
+func (l *LutrisImporter) ImportSelected(dbPath string, skipNoPath bool, samePathAction string, selections []vo.ImportSelection) (ImportResult, error) {
+	games, err := loadLutrisGames(dbPath)
+	if err != nil {
+		applog.LogErrorf(l.deps.Ctx, "ImportFromLutris: failed to load database: %v", err)
+		return newImportResult(), err
+	}
+	return importLibraryGames(l.deps, "ImportFromLutris", games, skipNoPath, samePathAction, selections)
+}
+
+func loadLutrisGames(dbPath string) ([]libraryGame, error) {
+	db, err := openReadOnlySQLite(dbPath, "Lutris")
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	sourceGames, err := readLutrisGames(db)
+	if err != nil {
+		return nil, err
+	}
+	if err := readLutrisCategories(db, sourceGames); err != nil {
+		return nil, err
+	}
+
+	configDirs := lutrisConfigDirs(dbPath)
+	games := make([]libraryGame, 0, len(sourceGames))
+	for _, source := range sourceGames {
+		config := readLutrisGameConfig(configDirs, source.ConfigPath)
+		games = append(games, convertLutrisGame(*source, config))
+	}
+	return games, nil
+}
+
+// readLutrisGames 按当前数据库实际存在的列读取 games 表，旧版本缺失的列按空值处理
+func readLutrisGames(db *sql.DB) ([]*lutrisGame, error) {
+	columns, err := sqliteColumns(db, "games")
+	if err != nil {
+		return nil, err
+	}
+	if !columns["id"] || !columns["name"] {
+		return nil, fmt.Errorf("不是有效的 Lutris 数据库：缺少 games 表")
+	}
+	selects := make([]string, 0, len(lutrisGameColumns))
+	for _, column := range lutrisGameColumns {
+		if columns[column] {
+			selects = append(selects, column)
+		} else {
+			selects = append(selects, "NULL AS "+column)
+		}
+	}
+
+	rows, err := db.Query(`SELECT ` + strings.Join(selects, ", ") + ` FROM games ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("读取 Lutris games 表失败: %w", err)
+	}
+	defer rows.Close()
+
+	games := make([]*lutrisGame, 0)
+	for rows.Next() {
+		var (
+			game                                                  lutrisGame
+			name, slug, runner, executable, directory, configPath sql.NullString
+			service, serviceID                                    sql.NullString
+			lastPlayed, installedAt                               sql.NullInt64
+			playtime                                              sql.NullFloat64
+		)
+		if err := rows.Scan(
+			&game.ID, &name, &slug, &runner, &executable, &directory, &configPath,
+			&service, &serviceID, &lastPlayed, &installedAt, &playtime,
+		); err != nil {
+			return nil, fmt.Errorf("解析 Lutris 游戏记录失败: %w", err)
+		}
+		game.Name = strings.TrimSpace(name.String)
+		if game.Name == "" {
+			continue
+		}
+		game.Slug = slug.String
+		game.Runner = strings.ToLower(strings.TrimSpace(runner.String))
+		game.Executable = strings.TrimSpace(executable.String)
+		game.Directory = strings.TrimSpace(directory.String)
+		game.ConfigPath = strings.TrimSpace(configPath.String)
+		game.Service = strings.ToLower(strings.TrimSpace(service.String))
+		game.ServiceID = strings.TrimSpace(serviceID.String)
+		game.LastPlayed = lastPlayed.Int64
+		game.InstalledAt = installedAt.Int64
+		game.PlayHours = playtime.Float64
+		games = append(games, &game)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历 Lutris 游戏记录失败: %w", err)
+	}
+	return games, nil
+}
+
+// readLutrisCategories 读取用户分类作为标签，跳过 ".hidden" 等内部分类
+func readLutrisCategories(db *sql.DB, games []*lutrisGame) error {
+	categoryColumns, err := sqliteColumns(db, "categories")
+	if err != nil {
+		return err
+	}
+	linkColumns, err := sqliteColumns(db, "games_categories")
+	if err != nil {
+		return err
+	}
+	if !categoryColumns["name"] || !linkColumns["game_id"] || !linkColumns["category_id"] {
+		return nil
+	}
+
+	byID := make(map[int64]*lutrisGame, len(games))
+	for _, game := range games {
+		byID[game.ID] = game
+	}
+	rows, err := db.Query(`
+		SELECT gc.game_id, c.name
+		FROM games_categories gc
+		JOIN categories c ON c.id = gc.category_id
+		ORDER BY gc.game_id, c.name
+	`)
+	if err != nil {
+		return fmt.Errorf("读取 Lutris 分类失败: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var gameID int64
+		var name string
+		if err := rows.Scan(&gameID, &name); err != nil {
+			return fmt.Errorf("解析 Lutris 分类失败: %w", err)
+		}
+		if game, ok := byID[gameID]; ok && !strings.HasPrefix(name, ".") {
+			game.Categories = append(game.Categories, name)
+		}
+	}
+	return rows.Err()
+}
+
+// lutrisConfigDirs 新版 Lutris 把游戏配置放在 pga.db 同级的 games 目录，旧版放在 ~/.config/lutris/games
+func lutrisConfigDirs(dbPath string) []string {
+	dirs := []string{filepath.Join(filepath.Dir(dbPath), "games")}
+	if configHome := strings.TrimSpace(os.Getenv("XDG_CONFIG_HOME")); configHome != "" {
+		dirs = append(dirs, filepath.Join(configHome, "lutris", "games"))
+	} else if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, ".config", "lutris", "games"))
+	}
+	return dirs
+}
+
+func readLutrisGameConfig(dirs []string, configPath string) lutrisGameConfig {
+	if configPath == "" {
+		return lutrisGameConfig{}
+	}
+	for _, dir := range dirs {
+		file, err := os.Open(filepath.Join(dir, configPath+".yml"))
+		if err != nil {
+			continue
+		}
+		config := parseLutrisGameConfig(file)
+		file.Close()
+		return config
+	}
+	return lutrisGameConfig{}
+}
+
+// parseLutrisGameConfig 只解析顶层 game 段下的标量字段，Lutris 配置不使用更复杂的 YAML 结构存放这些值
+func parseLutrisGameConfig(reader io.Reader) lutrisGameConfig {
+	var config lutrisGameConfig
+	inGameSection := false
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "\t") {
+			inGameSection = trimmed == "game:"
+			continue
+		}
+		if !inGameSection {
+			continue
+		}
+		key, value, found := strings.Cut(trimmed, ":")
+		if !found {
+			continue
+		}
+		value = unquoteLutrisValue(strings.TrimSpace(value))
+		switch strings.TrimSpace(key) {
+		case "exe":
+			config.Exe = value
+		case "prefix":
+			config.Prefix = value
+		case "working_dir":
+			config.WorkingDir = value
+		}
+	}
+	return config
+}
+
+func unquoteLutrisValue(value string) string {
+	if len(value) >= 2 && (value[0] == '\'' || value[0] == '"') && value[len(value)-1] == value[0] {
+		if value[0] == '"' {
+			if unquoted, err := strconv.Unquote(value); err == nil {
+				return unquoted
+			}
+		}
+		return strings.ReplaceAll(value[1:len(value)-1], "''", "'")
+	}
+	return value
+}
+
+func convertLutrisGame(source lutrisGame, config lutrisGameConfig) libraryGame {
+	gameID := uuid.New().String()
+	createdAt := unixTime(source.InstalledAt)
+	if createdAt.IsZero() {
+		createdAt = time.Now()
+	}
+
+	directory := source.Directory
+	if directory == "" {
+		directory = config.WorkingDir
+	}
+	executable := config.Exe
+	if executable == "" {
+		executable = source.Executable
+	}
+	path := resolveImportPath(directory, executable)
+	if directory == "" {
+		directory = importPathDir(path)
+	}
+
+	game := models.Game{
+		ID:            gameID,
+		Name:          source.Name,
+		Path:          path,
+		GameDirectory: directory,
+		Status:        enums.StatusNotStarted,
+		SourceType:    enums.Local,
+		CachedAt:      time.Now(),
+		CreatedAt:     createdAt,
+	}
+	if source.Runner == "wine" {
+		game.WinePrefix = config.Prefix
+	}
+	if source.Service == "steam" && source.ServiceID != "" {
+		game.SourceType = enums.Steam
+		game.SourceID = source.ServiceID
+		// 由 Steam runner 管理的游戏没有可直接启动的文件，交给 Steam 启动
+		if source.Runner == "steam" {
+			game.LaunchMode = enums.LaunchModeSteam
+			game.SteamLaunchID = source.ServiceID
+			game.SteamLaunchKind = "native"
+		}
+	}
+
+	key := source.Slug
+	if key == "" {
+		key = strconv.FormatInt(source.ID, 10)
+	}
+	return libraryGame{
+		Game:     game,
+		Sessions: aggregatePlaySession("lutris", key, gameID, int(source.PlayHours*3600), unixTime(source.LastPlayed), createdAt),
+		Tags:     source.Categories,
+	}
+}
@@ -652,14 +652,14 @@ func (c *Committer) addImportedItems(ctx context.Context, conn *sql.Conn, items
 		id, name, cover_url, cover_source_url, company, summary, rating, release_date, path, game_directory,
 		save_path, process_name, wine_runner, wine_args, wine_prefix, launch_mode,
 		steam_launch_id, steam_launch_kind, steam_user_id, steam_launch_options,
-		source_type, cached_at, source_id, created_at, updated_at,
+		source_type, cached_at, source_id, created_at, updated_at, status_updated_at,
 		use_locale_emulator, use_magpie, is_nsfw
 	)
 	SELECT
 		id, name, cover_url, cover_source_url, company, summary, rating, release_date, path, game_directory,
 		save_path, process_name, wine_runner, wine_args, wine_prefix, launch_mode,
 		steam_launch_id, steam_launch_kind, steam_user_id, steam_launch_options,
-		source_type, cached_at, source_id, created_at, updated_at,
+		source_type, cached_at, source_id, created_at, updated_at, updated_at,
 		use_locale_emulator, use_magpie, is_nsfw
 	FROM temp_import_games`); err != nil {
 		return 0, fmt.Errorf("insert imported games from staging: %w", err)
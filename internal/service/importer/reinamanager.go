@@ -11,14 +11,12 @@ import (
 	"lunabox/internal/models/reinamanager"
 	"lunabox/internal/service/gamehelper"
 	"net/url"
-	"os"
 	"path/filepath"
 	"sort"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
-	_ "github.com/mattn/go-sqlite3"
 )
 
 type ReinaManagerImporter struct {
@@ -170,41 +168,11 @@ func (r *ReinaManagerImporter) ImportSelected(dbPath string, skipNoPath bool, sa
 
 func loadReinaManagerData(dbPath string) (reinamanager.Data, error) {
 	var result reinamanager.Data
-	dbPath = strings.TrimSpace(dbPath)
-	if dbPath == "" {
-		return result, fmt.Errorf("ReinaManager 数据库路径为空")
-	}
-	info, err := os.Stat(dbPath)
-	if err != nil {
-		return result, fmt.Errorf("读取 ReinaManager 数据库失败: %w", err)
-	}
-	if info.IsDir() {
-		return result, fmt.Errorf("ReinaManager 数据库路径不能是目录")
-	}
-
-	absPath, err := filepath.Abs(dbPath)
-	if err != nil {
-		return result, fmt.Errorf("解析 ReinaManager 数据库路径失败: %w", err)
-	}
-	uriPath := filepath.ToSlash(absPath)
-	if filepath.VolumeName(absPath) != "" && !strings.HasPrefix(uriPath, "/") {
-		uriPath = "/" + uriPath
-	}
-	dsn := url.URL{Scheme: "file", Path: uriPath}
-	query := dsn.Query()
-	query.Set("mode", "ro")
-	dsn.RawQuery = query.Encode()
-
-	db, err := sql.Open("sqlite3", dsn.String())
+	db, err := openReadOnlySQLite(dbPath, "ReinaManager")
 	if err != nil {
-		return result, fmt.Errorf("初始化 ReinaManager 数据库读取器失败: %w", err)
+		return result, err
 	}
 	defer db.Close()
-	db.SetMaxOpenConns(1)
-
-	if err := db.Ping(); err != nil {
-		return result, fmt.Errorf("以只读方式打开 ReinaManager SQLite 数据库失败: %w", err)
-	}
 
 	games, err := readReinaManagerGames(db)
 	if err != nil {
@@ -35,6 +35,7 @@ type MCPReadService struct {
 	progressService *GameProgressService
 	tagService      *TagService
 	statsProvider   AIStatsProvider
+	statsService    *StatsService
 	metadataFetcher func(name string) ([]vo.GameMetadataFromWebVO, error)
 }
 
@@ -82,6 +83,11 @@ func (s *MCPReadService) SetStatsProvider(provider AIStatsProvider) {
 	s.statsProvider = provider
 }
 
+//wails:ignore
+func (s *MCPReadService) SetStatsService(statsService *StatsService) {
+	s.statsService = statsService
+}
+
 //wails:ignore
 func (s *MCPReadService) SetMetadataFetcher(fetcher func(name string) ([]vo.GameMetadataFromWebVO, error)) {
 	s.metadataFetcher = fetcher
@@ -450,6 +456,18 @@ func (s *MCPReadService) GetGameStatistic(period enums2.Period) (vo.MCPGameStati
 	return resp, nil
 }
 
+func (s *MCPReadService) GetCompletionForecast(useMetadataLength bool) (vo.CompletionForecast, error) {
+	statsService := s.statsService
+	if statsService == nil {
+		statsService = NewStatsService()
+		statsService.Init(s.context(), s.db, s.config)
+	}
+	return statsService.GetCompletionForecast(vo.CompletionForecastRequest{
+		Dimension:         enums2.Month,
+		UseMetadataLength: useMetadataLength,
+	})
+}
+
 func (s *MCPReadService) ensureGameExists(gameID string) error {
 	if s.gameService != nil {
 		_, err := s.gameService.GetGameByID(gameID)
@@ -522,6 +540,10 @@ func (s *MCPReadService) enabledMetadataSources() map[string]struct{} {
 		switch normalized {
 		case string(enums2.Bangumi), string(enums2.VNDB), string(enums2.Ymgal), string(enums2.Steam), string(enums2.DLsite), string(enums2.TouchGal), string(enums2.Hikarinagi), string(enums2.ErogameScape):
 			result[normalized] = struct{}{}
+		default:
+			if metadata.IsPluginSource(enums2.SourceType(normalized)) {
+				result[normalized] = struct{}{}
+			}
 		}
 	}
 
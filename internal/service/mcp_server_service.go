@@ -361,6 +361,13 @@ func (h *mcpHTTPHandler) handleToolCall(rawParams json.RawMessage) (mcpToolResul
 		}
 		result, err := h.readService.GetGameStatistic(enums.Period(strings.TrimSpace(args.Period)))
 		return buildMCPToolResult(result, err), nil
+	case "get_completion_forecast":
+		var args vo.MCPCompletionForecastRequest
+		if err := decodeMCPArgs(params.Arguments, &args); err != nil {
+			return mcpToolResult{}, err
+		}
+		result, err := h.readService.GetCompletionForecast(args.UseMetadataLength)
+		return buildMCPToolResult(result, err), nil
 	default:
 		return mcpToolResult{}, fmt.Errorf("unknown tool: %s", params.Name)
 	}
@@ -481,6 +488,20 @@ func (h *mcpHTTPHandler) toolDefinitions() []mcpToolDefinition {
 				"additionalProperties": false,
 			},
 		},
+		{
+			Name:        "get_completion_forecast",
+			Description: "Estimate remaining time-to-complete for playing and want_to_play games from similar completed games (shared tags and company), optionally blended with VNDB length data, and forecast when the backlog clears at the average daily play time of the last 30 days. Durations are in seconds. This tool is read-only.",
+			InputSchema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"use_metadata_length": map[string]any{
+						"type":        "boolean",
+						"description": "Also query VNDB average length for games linked to VNDB. Defaults to false.",
+					},
+				},
+				"additionalProperties": false,
+			},
+		},
 	}
 }
 
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"sync"
+
+	"lunabox/internal/appconf"
+	"lunabox/internal/applog"
+	"lunabox/internal/common/vo"
+	"lunabox/internal/utils/apputils"
+	"lunabox/internal/utils/metadata"
+)
+
+// MetadataPluginService 管理元数据插件目录。插件加载后即可作为普通数据源加入元数据来源列表。
+type MetadataPluginService struct {
+	ctx    context.Context
+	db     *sql.DB
+	config *appconf.AppConfig
+
+	mu         sync.Mutex
+	loadErrors []string
+}
+
+func NewMetadataPluginService() *MetadataPluginService {
+	return &MetadataPluginService{}
+}
+
+//wails:ignore
+func (s *MetadataPluginService) Init(ctx context.Context, db *sql.DB, config *appconf.AppConfig) {
+	s.ctx = ctx
+	s.db = db
+	s.config = config
+	if _, err := s.ReloadMetadataPlugins(); err != nil {
+		applog.LogErrorf(ctx, "MetadataPluginService: failed to load plugins: %v", err)
+	}
+}
+
+// ListMetadataPlugins 返回已加载的插件及上次加载时跳过的无效清单
+func (s *MetadataPluginService) ListMetadataPlugins() (vo.MetadataPluginList, error) {
+	dir, err := apputils.GetMetadataPluginsDir()
+	if err != nil {
+		return vo.MetadataPluginList{}, err
+	}
+	s.mu.Lock()
+	loadErrors := append([]string{}, s.loadErrors...)
+	s.mu.Unlock()
+	return buildMetadataPluginList(dir, metadata.Plugins(), loadErrors), nil
+}
+
+// ReloadMetadataPlugins 重新扫描插件目录，替换之前加载的全部插件
+func (s *MetadataPluginService) ReloadMetadataPlugins() (vo.MetadataPluginList, error) {
+	dir, err := apputils.GetMetadataPluginsDir()
+	if err != nil {
+		return vo.MetadataPluginList{}, err
+	}
+	return s.loadMetadataPlugins(dir)
+}
+
+// OpenMetadataPluginsDir 打开插件目录
+func (s *MetadataPluginService) OpenMetadataPluginsDir() error {
+	dir, err := apputils.GetMetadataPluginsDir()
+	if err != nil {
+		return err
+	}
+	return apputils.OpenDirectory(dir)
+}
+
+func (s *MetadataPluginService) loadMetadataPlugins(dir string) (vo.MetadataPluginList, error) {
+	plugins, err := metadata.LoadPlugins(dir)
+	loadErrors := make([]string, 0)
+	if err != nil {
+		// 单个清单无效时 LoadPlugins 仍会注册其余插件，这里只记录被跳过的原因
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			for _, item := range joined.Unwrap() {
+				loadErrors = append(loadErrors, item.Error())
+			}
+		} else {
+			return vo.MetadataPluginList{}, err
+		}
+	}
+	for _, message := range loadErrors {
+		applog.LogWarningf(s.ctx, "MetadataPluginService: skipped plugin: %s", message)
+	}
+	applog.LogInfof(s.ctx, "MetadataPluginService: loaded %d plugins from %s", len(plugins), dir)
+
+	s.mu.Lock()
+	s.loadErrors = loadErrors
+	s.mu.Unlock()
+	return buildMetadataPluginList(dir, plugins, loadErrors), nil
+}
+
+func buildMetadataPluginList(dir string, plugins []metadata.PluginInfo, loadErrors []string) vo.MetadataPluginList {
+	list := vo.MetadataPluginList{
+		Directory: dir,
+		Plugins:   make([]vo.MetadataPluginInfo, 0, len(plugins)),
+		Errors:    loadErrors,
+	}
+	for _, plugin := range plugins {
+		list.Plugins = append(list.Plugins, vo.MetadataPluginInfo{
+			ID:             plugin.ID,
+			Name:           plugin.Name,
+			Kind:           plugin.Kind,
+			ManifestPath:   plugin.ManifestPath,
+			SupportsSearch: plugin.SupportsSearch,
+		})
+	}
+	return list
+}
@@ -0,0 +1,493 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"lunabox/internal/appconf"
+	"lunabox/internal/applog"
+	"lunabox/internal/common/enums"
+	"lunabox/internal/common/vo"
+	"lunabox/internal/models"
+	"lunabox/internal/service/remotestatus"
+	"lunabox/internal/utils/httputils"
+	"lunabox/internal/wailsruntime"
+)
+
+const (
+	remotePushPollInterval    = time.Minute
+	remotePushBatchSize       = 20
+	remotePushDeadLetterEvent = "remote-push:dead-letter"
+)
+
+// remotePushProviderIntervals 是同一提供方两次推送之间的最小间隔
+var remotePushProviderIntervals = map[enums.SourceType]time.Duration{
+	enums.Bangumi:    500 * time.Millisecond,
+	enums.Hikarinagi: 500 * time.Millisecond,
+	enums.VNDB:       time.Second,
+}
+
+// errRemotePushObsolete 表示任务已无需投递：游戏或评价已删除，或该提供方的推送已关闭
+var errRemotePushObsolete = errors.New("remote push job is obsolete")
+
+// RemotePushService 维护远端状态推送发件箱：本地状态、评价变更入队后由后台任务
+// 按提供方限速投递，失败按指数退避重试，多次失败的任务进入死信列表等待用户处理。
+type RemotePushService struct {
+	ctx               context.Context
+	db                *sql.DB
+	config            *appconf.AppConfig
+	gameService       *GameService
+	gameReviewService *GameReviewService
+	bangumiService    *BangumiService
+	hikarinagiService *HikarinagiService
+	vndbService       *VNDBService
+	emitEvent         func(string, ...interface{})
+	now               func() time.Time
+
+	mu         sync.Mutex
+	drainMu    sync.Mutex
+	workerStop chan struct{}
+	workerDone chan struct{}
+	wake       chan struct{}
+	lastPushAt map[enums.SourceType]time.Time
+}
+
+func NewRemotePushService() *RemotePushService {
+	runtime := wailsruntime.Unavailable()
+	return &RemotePushService{
+		emitEvent:  func(name string, data ...interface{}) { runtime.Emit(name, data...) },
+		now:        time.Now,
+		wake:       make(chan struct{}, 1),
+		lastPushAt: make(map[enums.SourceType]time.Time),
+	}
+}
+
+//wails:ignore
+func (s *RemotePushService) Init(ctx context.Context, db *sql.DB, config *appconf.AppConfig) {
+	s.ctx = ctx
+	s.db = db
+	s.config = config
+}
+
+//wails:ignore
+func (s *RemotePushService) SetRuntime(runtime wailsruntime.Runtime) {
+	if runtime == nil {
+		return
+	}
+	s.emitEvent = func(name string, data ...interface{}) {
+		runtime.Emit(name, data...)
+	}
+}
+
+//wails:ignore
+func (s *RemotePushService) SetEventEmitter(emit func(string, ...interface{})) {
+	s.emitEvent = emit
+}
+
+//wails:ignore
+func (s *RemotePushService) SetNowFunc(now func() time.Time) {
+	if now != nil {
+		s.now = now
+	}
+}
+
+//wails:ignore
+func (s *RemotePushService) SetGameService(gameService *GameService) {
+	s.gameService = gameService
+}
+
+//wails:ignore
+func (s *RemotePushService) SetGameReviewService(gameReviewService *GameReviewService) {
+	s.gameReviewService = gameReviewService
+}
+
+//wails:ignore
+func (s *RemotePushService) SetBangumiService(bangumiService *BangumiService) {
+	s.bangumiService = bangumiService
+}
+
+//wails:ignore
+func (s *RemotePushService) SetHikarinagiService(hikarinagiService *HikarinagiService) {
+	s.hikarinagiService = hikarinagiService
+}
+
+//wails:ignore
+func (s *RemotePushService) SetVNDBService(vndbService *VNDBService) {
+	s.vndbService = vndbService
+}
+
+// GetQueueStatus 返回待投递任务数和死信列表
+func (s *RemotePushService) GetQueueStatus() (vo.RemotePushQueueStatus, error) {
+	ctx := s.context()
+	pending, err := remotestatus.PendingCount(ctx, s.db)
+	if err != nil {
+		return vo.RemotePushQueueStatus{}, err
+	}
+	deadLetters, err := s.ListDeadLetters()
+	if err != nil {
+		return vo.RemotePushQueueStatus{}, err
+	}
+
+	s.mu.Lock()
+	running := s.workerStop != nil
+	s.mu.Unlock()
+	return vo.RemotePushQueueStatus{Running: running, Pending: pending, DeadLetters: deadLetters}, nil
+}
+
+// ListDeadLetters 返回多次推送失败、已停止重试的任务
+func (s *RemotePushService) ListDeadLetters() ([]vo.RemotePushJob, error) {
+	ctx := s.context()
+	jobs, err := remotestatus.DeadJobs(ctx, s.db)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]vo.RemotePushJob, 0, len(jobs))
+	for _, job := range jobs {
+		result = append(result, s.toJobVO(ctx, job))
+	}
+	return result, nil
+}
+
+// RetryDeadLetter 重新投递一个死信任务
+func (s *RemotePushService) RetryDeadLetter(id string) error {
+	count, err := remotestatus.Requeue(s.context(), s.db, strings.TrimSpace(id), s.now())
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return fmt.Errorf("推送任务不存在或不在死信列表中")
+	}
+	s.signal()
+	return nil
+}
+
+// RetryAllDeadLetters 重新投递全部死信任务，返回重新入队的数量
+func (s *RemotePushService) RetryAllDeadLetters() (int, error) {
+	count, err := remotestatus.Requeue(s.context(), s.db, "", s.now())
+	if err != nil {
+		return 0, err
+	}
+	if count > 0 {
+		s.signal()
+	}
+	return count, nil
+}
+
+// DiscardDeadLetter 放弃一个死信任务，不再推送
+func (s *RemotePushService) DiscardDeadLetter(id string) error {
+	return remotestatus.Discard(s.context(), s.db, strings.TrimSpace(id))
+}
+
+// Start 启动后台投递任务；重复调用无效
+//
+//wails:ignore
+func (s *RemotePushService) Start() {
+	s.mu.Lock()
+	if s.workerStop != nil {
+		s.mu.Unlock()
+		return
+	}
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	s.workerStop = stop
+	s.workerDone = done
+	s.mu.Unlock()
+
+	go func() {
+		defer close(done)
+		for {
+			if err := s.drain(stop); err != nil {
+				applog.LogWarningf(s.ctx, "RemotePushService: failed to drain outbox: %v", err)
+			}
+			timer := time.NewTimer(s.nextWait())
+			select {
+			case <-stop:
+				timer.Stop()
+				return
+			case <-s.wake:
+			case <-timer.C:
+			}
+			timer.Stop()
+		}
+	}()
+}
+
+// Stop 停止后台投递任务并等待当前推送结束；未投递的任务保留到下次启动
+//
+//wails:ignore
+func (s *RemotePushService) Stop() {
+	s.mu.Lock()
+	stop := s.workerStop
+	done := s.workerDone
+	s.workerStop = nil
+	s.workerDone = nil
+	s.mu.Unlock()
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-done
+}
+
+// DeliverDue 立即投递所有已到期的任务，供命令行和测试使用
+//
+//wails:ignore
+func (s *RemotePushService) DeliverDue() error {
+	return s.drain(nil)
+}
+
+// enqueueGame 为游戏关联的每个已开启推送的提供方入队一个任务，返回入队数量
+func (s *RemotePushService) enqueueGame(gameID string, kind string) int {
+	if s.gameService == nil {
+		return 0
+	}
+	sources, err := s.gameService.GetGameMetadataSources(gameID)
+	if err != nil {
+		applog.LogWarningf(s.ctx, "RemotePushService: failed to load metadata sources for %s: %v", gameID, err)
+		return 0
+	}
+
+	queued := 0
+	for _, source := range sources {
+		target := models.Game{ID: gameID, SourceType: source.SourceType, SourceID: strings.TrimSpace(source.SourceID)}
+		if !s.isEligible(target) {
+			continue
+		}
+		if err := remotestatus.Enqueue(s.context(), s.db, target.SourceType, kind, gameID, target.SourceID, s.now()); err != nil {
+			applog.LogWarningf(s.ctx, "RemotePushService: failed to enqueue %s %s push for %s: %v", target.SourceType, kind, gameID, err)
+			continue
+		}
+		queued++
+	}
+	if queued > 0 {
+		s.signal()
+	}
+	return queued
+}
+
+func (s *RemotePushService) drain(stop <-chan struct{}) error {
+	s.drainMu.Lock()
+	defer s.drainMu.Unlock()
+
+	ctx := s.context()
+	for {
+		jobs, err := remotestatus.DueJobs(ctx, s.db, s.now(), remotePushBatchSize)
+		if err != nil || len(jobs) == 0 {
+			return err
+		}
+		for _, job := range jobs {
+			if !s.throttle(job.Provider, stop) {
+				return nil
+			}
+			if err := s.process(ctx, job); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// process 投递一个任务并记录结果；只有写入发件箱失败时返回错误
+func (s *RemotePushService) process(ctx context.Context, job remotestatus.Job) error {
+	pushErr := s.deliver(ctx, job)
+	if pushErr == nil || errors.Is(pushErr, errRemotePushObsolete) {
+		if pushErr != nil {
+			applog.LogDebugf(ctx, "RemotePushService: dropped obsolete %s %s push for %s", job.Provider, job.Kind, job.GameID)
+		}
+		return remotestatus.Complete(ctx, s.db, job)
+	}
+
+	if retryAfter, ok := remotePushTransientDelay(pushErr); ok {
+		applog.LogInfof(ctx, "RemotePushService: %s %s push for %s deferred: %v", job.Provider, job.Kind, job.GameID, pushErr)
+		return remotestatus.Defer(ctx, s.db, job, pushErr, retryAfter, s.now())
+	}
+	dead, err := remotestatus.Fail(ctx, s.db, job, pushErr, s.now())
+	if err != nil {
+		return err
+	}
+	applog.LogWarningf(ctx, "RemotePushService: %s %s push for %s failed (attempt %d, dead: %t): %v",
+		job.Provider, job.Kind, job.GameID, job.Attempts+1, dead, pushErr)
+	if dead && s.ctx != nil && s.emitEvent != nil {
+		job.Attempts++
+		job.LastError = pushErr.Error()
+		s.emitEvent(remotePushDeadLetterEvent, s.toJobVO(ctx, job))
+	}
+	return nil
+}
+
+func (s *RemotePushService) deliver(ctx context.Context, job remotestatus.Job) error {
+	game := models.Game{ID: job.GameID, SourceType: job.Provider, SourceID: job.SourceID}
+	var status string
+	err := s.db.QueryRowContext(ctx, `SELECT name, COALESCE(status, 'not_started') FROM games WHERE id = ?`, job.GameID).Scan(&game.Name, &status)
+	if errors.Is(err, sql.ErrNoRows) {
+		return errRemotePushObsolete
+	}
+	if err != nil {
+		return fmt.Errorf("读取游戏失败: %w", err)
+	}
+	game.Status = enums.GameStatus(status)
+	if !s.isEligible(game) {
+		return errRemotePushObsolete
+	}
+
+	switch job.Kind {
+	case remotestatus.JobKindStatus:
+		err := s.pushStatus(ctx, game)
+		if err != nil {
+			s.reportStatusPushFailure(game, err)
+		}
+		return err
+	case remotestatus.JobKindReview:
+		if s.gameReviewService == nil {
+			return errRemotePushObsolete
+		}
+		review, err := s.gameReviewService.GetGameReview(job.GameID)
+		if err != nil {
+			return err
+		}
+		if review == nil {
+			return errRemotePushObsolete
+		}
+		return s.pushReview(ctx, game, *review)
+	default:
+		return errRemotePushObsolete
+	}
+}
+
+func (s *RemotePushService) pushStatus(ctx context.Context, game models.Game) error {
+	switch game.SourceType {
+	case enums.Bangumi:
+		return s.bangumiService.syncGameStatus(ctx, game)
+	case enums.Hikarinagi:
+		return s.hikarinagiService.syncGameStatus(ctx, game)
+	case enums.VNDB:
+		return s.vndbService.syncGameStatus(ctx, game)
+	default:
+		return errRemotePushObsolete
+	}
+}
+
+// reportStatusPushFailure 复用直接推送时的失败处理，通知界面状态推送失败（如授权失效）。
+// 暂时性失败会自动重试，不打扰用户。
+func (s *RemotePushService) reportStatusPushFailure(game models.Game, err error) {
+	if s.gameService == nil {
+		return
+	}
+	if _, transient := remotePushTransientDelay(err); transient {
+		return
+	}
+	switch game.SourceType {
+	case enums.Bangumi:
+		s.gameService.handleBangumiStatusPushFailure(game, err)
+	case enums.Hikarinagi:
+		s.gameService.handleHikarinagiStatusPushFailure(game, err)
+	case enums.VNDB:
+		s.gameService.handleVNDBStatusPushFailure(game, err)
+	}
+}
+
+func (s *RemotePushService) pushReview(ctx context.Context, game models.Game, review models.GameReview) error {
+	switch game.SourceType {
+	case enums.Bangumi:
+		return s.bangumiService.syncGameReview(ctx, game.SourceID, review)
+	case enums.Hikarinagi:
+		timeToFinishMinutes, err := s.gameReviewService.getGamePlayTimeMinutes(game.ID)
+		if err != nil {
+			return err
+		}
+		return s.hikarinagiService.syncGameReview(ctx, game.SourceID, review, timeToFinishMinutes)
+	case enums.VNDB:
+		return s.vndbService.syncGameReview(ctx, game.SourceID, review)
+	default:
+		return errRemotePushObsolete
+	}
+}
+
+// isEligible 判断提供方已授权并开启了自动推送
+func (s *RemotePushService) isEligible(game models.Game) bool {
+	switch game.SourceType {
+	case enums.Bangumi:
+		return s.bangumiService != nil && s.bangumiService.isGameEligibleForStatusPush(game)
+	case enums.Hikarinagi:
+		return s.hikarinagiService != nil && s.hikarinagiService.isGameEligibleForStatusPush(game)
+	case enums.VNDB:
+		return s.vndbService != nil && s.vndbService.isGameEligibleForStatusPush(game)
+	default:
+		return false
+	}
+}
+
+// throttle 等待到该提供方允许下一次推送；stop 关闭时返回 false
+func (s *RemotePushService) throttle(provider enums.SourceType, stop <-chan struct{}) bool {
+	interval := remotePushProviderIntervals[provider]
+	if last, ok := s.lastPushAt[provider]; ok && interval > 0 {
+		if wait := interval - time.Since(last); wait > 0 {
+			timer := time.NewTimer(wait)
+			defer timer.Stop()
+			select {
+			case <-stop:
+				return false
+			case <-timer.C:
+			}
+		}
+	}
+	s.lastPushAt[provider] = time.Now()
+	return true
+}
+
+// nextWait 返回距离下一个任务到期的时间，最长不超过轮询间隔
+func (s *RemotePushService) nextWait() time.Duration {
+	next, ok, err := remotestatus.NextAttemptAt(s.context(), s.db)
+	if err != nil || !ok {
+		return remotePushPollInterval
+	}
+	return min(max(next.Sub(s.now()), time.Second), remotePushPollInterval)
+}
+
+func (s *RemotePushService) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *RemotePushService) toJobVO(ctx context.Context, job remotestatus.Job) vo.RemotePushJob {
+	result := vo.RemotePushJob{
+		ID:            job.ID,
+		Provider:      string(job.Provider),
+		Kind:          job.Kind,
+		GameID:        job.GameID,
+		SourceID:      job.SourceID,
+		Attempts:      job.Attempts,
+		LastError:     job.LastError,
+		NextAttemptAt: job.NextAttemptAt,
+		UpdatedAt:     job.UpdatedAt,
+	}
+	_ = s.db.QueryRowContext(ctx, `SELECT name FROM games WHERE id = ?`, job.GameID).Scan(&result.GameName)
+	return result
+}
+
+func (s *RemotePushService) context() context.Context {
+	if s.ctx != nil {
+		return s.ctx
+	}
+	return context.Background()
+}
+
+// remotePushTransientDelay 判断失败是否是暂时性的：网络不可达、HTTP 429 或 5xx。
+// 这类失败只延后重试，不计入死信；服务端返回 Retry-After 时一并返回要求的等待时间。
+func remotePushTransientDelay(err error) (time.Duration, bool) {
+	var statusErr *httputils.StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.RetryAfter, statusErr.Temporary()
+	}
+	var urlErr *url.Error
+	var netErr net.Error
+	return 0, errors.As(err, &urlErr) || errors.As(err, &netErr)
+}
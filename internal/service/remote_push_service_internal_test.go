@@ -0,0 +1,32 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+
+	"lunabox/internal/utils/httputils"
+)
+
+func TestRemotePushTransientDelayClassifiesFailures(t *testing.T) {
+	cases := []struct {
+		name      string
+		err       error
+		transient bool
+		delay     time.Duration
+	}{
+		{"rate limited", &httputils.StatusError{StatusCode: 429, RetryAfter: time.Minute}, true, time.Minute},
+		{"server error", fmt.Errorf("推送失败: %w", &httputils.StatusError{StatusCode: 503}), true, 0},
+		{"bad request", &httputils.StatusError{StatusCode: 400}, false, 0},
+		{"network", &url.Error{Op: "Post", URL: "https://api.example.com", Err: errors.New("connection refused")}, true, 0},
+		{"other", errors.New("不支持同步的状态"), false, 0},
+	}
+	for _, tc := range cases {
+		delay, transient := remotePushTransientDelay(tc.err)
+		if transient != tc.transient || delay != tc.delay {
+			t.Errorf("%s: remotePushTransientDelay() = (%s, %v), want (%s, %v)", tc.name, delay, transient, tc.delay, tc.transient)
+		}
+	}
+}
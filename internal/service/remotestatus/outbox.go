@@ -0,0 +1,215 @@
+package remotestatus
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"lunabox/internal/common/enums"
+	"lunabox/internal/utils/dbutils"
+)
+
+// 发件箱任务类型。任务只记录“哪个条目需要推送什么”，投递时读取本地最新数据，
+// 因此同一条目的多次变更会合并为一个任务。
+const (
+	JobKindStatus = "status"
+	JobKindReview = "review"
+)
+
+const (
+	// MaxAttempts 非暂时性失败累计达到该次数后进入死信列表
+	MaxAttempts = 8
+
+	backoffBase = 30 * time.Second
+	backoffMax  = time.Hour
+)
+
+// Job 是 remote_push_outbox 中的一条推送任务
+type Job struct {
+	ID            string
+	Provider      enums.SourceType
+	Kind          string
+	GameID        string
+	SourceID      string
+	Revision      int64
+	Attempts      int
+	LastError     string
+	Dead          bool
+	NextAttemptAt time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// JobID 返回任务的稳定 ID，同一提供方、类型和条目只保留一个任务
+func JobID(provider enums.SourceType, kind, gameID, sourceID string) string {
+	return fmt.Sprintf("%s:%s:%s:%s", provider, kind, gameID, sourceID)
+}
+
+// Enqueue 新增或重置一个推送任务：已有任务（包括死信）会被提升修订号并立即重新投递
+func Enqueue(ctx context.Context, db *sql.DB, provider enums.SourceType, kind, gameID, sourceID string, now time.Time) error {
+	if db == nil {
+		return fmt.Errorf("游戏数据库未初始化")
+	}
+	return dbutils.WithDuckDBWriteLock(db, func() error {
+		_, err := db.ExecContext(ctx, `
+			INSERT INTO remote_push_outbox (id, provider, kind, game_id, source_id, revision, attempts, last_error, dead, next_attempt_at, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, 1, 0, '', FALSE, ?, ?, ?)
+			ON CONFLICT (id) DO UPDATE SET
+				revision = remote_push_outbox.revision + 1,
+				attempts = 0,
+				last_error = '',
+				dead = FALSE,
+				next_attempt_at = EXCLUDED.next_attempt_at,
+				updated_at = EXCLUDED.updated_at
+		`, JobID(provider, kind, gameID, sourceID), string(provider), kind, gameID, sourceID, now, now, now)
+		if err != nil {
+			return fmt.Errorf("写入 %s 推送任务失败: %w", provider, err)
+		}
+		return nil
+	})
+}
+
+// DueJobs 返回到期待投递的任务，按计划时间先后排序
+func DueJobs(ctx context.Context, db *sql.DB, now time.Time, limit int) ([]Job, error) {
+	return queryJobs(ctx, db, `WHERE NOT dead AND next_attempt_at <= ? ORDER BY next_attempt_at, created_at LIMIT ?`, now, limit)
+}
+
+// NextAttemptAt 返回最早的待投递时间；没有待投递任务时 ok 为 false
+func NextAttemptAt(ctx context.Context, db *sql.DB) (time.Time, bool, error) {
+	var next sql.NullTime
+	if err := db.QueryRowContext(ctx, `SELECT MIN(next_attempt_at) FROM remote_push_outbox WHERE NOT dead`).Scan(&next); err != nil {
+		return time.Time{}, false, fmt.Errorf("读取推送队列失败: %w", err)
+	}
+	return next.Time, next.Valid, nil
+}
+
+// DeadJobs 返回死信列表，最近失败的在前
+func DeadJobs(ctx context.Context, db *sql.DB) ([]Job, error) {
+	return queryJobs(ctx, db, `WHERE dead ORDER BY updated_at DESC, id`)
+}
+
+// PendingCount 返回尚未投递成功且未进入死信的任务数
+func PendingCount(ctx context.Context, db *sql.DB) (int, error) {
+	var count int
+	if err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM remote_push_outbox WHERE NOT dead`).Scan(&count); err != nil {
+		return 0, fmt.Errorf("读取推送队列失败: %w", err)
+	}
+	return count, nil
+}
+
+// Complete 删除已投递的任务。投递期间任务被重新入队（修订号变化）时保留，以便推送更新后的数据。
+func Complete(ctx context.Context, db *sql.DB, job Job) error {
+	return dbutils.WithDuckDBWriteLock(db, func() error {
+		if _, err := db.ExecContext(ctx, `DELETE FROM remote_push_outbox WHERE id = ? AND revision = ?`, job.ID, job.Revision); err != nil {
+			return fmt.Errorf("删除推送任务失败: %w", err)
+		}
+		return nil
+	})
+}
+
+// Fail 记录一次投递失败并按指数退避安排重试，累计失败达到 MaxAttempts 后转入死信。返回任务是否进入了死信。
+func Fail(ctx context.Context, db *sql.DB, job Job, cause error, now time.Time) (bool, error) {
+	attempts := job.Attempts + 1
+	dead := attempts >= MaxAttempts
+	err := dbutils.WithDuckDBWriteLock(db, func() error {
+		_, err := db.ExecContext(ctx, `
+			UPDATE remote_push_outbox
+			SET attempts = ?, last_error = ?, dead = ?, next_attempt_at = ?, updated_at = ?
+			WHERE id = ? AND revision = ?
+		`, attempts, cause.Error(), dead, now.Add(Backoff(attempts)), now, job.ID, job.Revision)
+		if err != nil {
+			return fmt.Errorf("更新推送任务失败: %w", err)
+		}
+		return nil
+	})
+	return dead, err
+}
+
+// Defer 记录一次暂时性失败（网络不可用、限流、服务端错误）并延后重试，不计入失败次数。
+// retryAfter 为服务端要求的等待时间；为 0 时在上一次等待时间的基础上翻倍，最长 1 小时。
+func Defer(ctx context.Context, db *sql.DB, job Job, cause error, retryAfter time.Duration, now time.Time) error {
+	delay := retryAfter
+	if delay <= 0 {
+		delay = min(max(2*job.NextAttemptAt.Sub(job.UpdatedAt), backoffBase), backoffMax)
+	}
+	return dbutils.WithDuckDBWriteLock(db, func() error {
+		_, err := db.ExecContext(ctx, `
+			UPDATE remote_push_outbox
+			SET last_error = ?, next_attempt_at = ?, updated_at = ?
+			WHERE id = ? AND revision = ?
+		`, cause.Error(), now.Add(delay), now, job.ID, job.Revision)
+		if err != nil {
+			return fmt.Errorf("更新推送任务失败: %w", err)
+		}
+		return nil
+	})
+}
+
+// Requeue 把死信重新放回队列并立即投递；id 为空时重新投递全部死信。返回重新入队的任务数。
+func Requeue(ctx context.Context, db *sql.DB, id string, now time.Time) (int, error) {
+	query := `UPDATE remote_push_outbox SET dead = FALSE, attempts = 0, last_error = '', next_attempt_at = ?, updated_at = ? WHERE dead`
+	args := []any{now, now}
+	if id != "" {
+		query += ` AND id = ?`
+		args = append(args, id)
+	}
+	var affected int64
+	err := dbutils.WithDuckDBWriteLock(db, func() error {
+		result, err := db.ExecContext(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("重新投递推送任务失败: %w", err)
+		}
+		affected, _ = result.RowsAffected()
+		return nil
+	})
+	return int(affected), err
+}
+
+// Discard 从死信列表中移除一个任务
+func Discard(ctx context.Context, db *sql.DB, id string) error {
+	return dbutils.WithDuckDBWriteLock(db, func() error {
+		if _, err := db.ExecContext(ctx, `DELETE FROM remote_push_outbox WHERE id = ? AND dead`, id); err != nil {
+			return fmt.Errorf("删除推送任务失败: %w", err)
+		}
+		return nil
+	})
+}
+
+// Backoff 返回第 attempts 次失败后的重试间隔：30 秒起逐次翻倍，最长 1 小时
+func Backoff(attempts int) time.Duration {
+	delay := backoffBase
+	for i := 1; i < attempts && delay < backoffMax; i++ {
+		delay *= 2
+	}
+	return min(delay, backoffMax)
+}
+
+func queryJobs(ctx context.Context, db *sql.DB, where string, args ...any) ([]Job, error) {
+	if db == nil {
+		return nil, fmt.Errorf("游戏数据库未初始化")
+	}
+	rows, err := db.QueryContext(ctx, `
+		SELECT id, provider, kind, game_id, source_id, revision, attempts, last_error, dead, next_attempt_at, created_at, updated_at
+		FROM remote_push_outbox `+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("读取推送任务失败: %w", err)
+	}
+	defer rows.Close()
+
+	jobs := make([]Job, 0)
+	for rows.Next() {
+		var job Job
+		var provider string
+		if err := rows.Scan(&job.ID, &provider, &job.Kind, &job.GameID, &job.SourceID, &job.Revision, &job.Attempts,
+			&job.LastError, &job.Dead, &job.NextAttemptAt, &job.CreatedAt, &job.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("读取推送任务失败: %w", err)
+		}
+		job.Provider = enums.SourceType(provider)
+		jobs = append(jobs, job)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("遍历推送任务失败: %w", err)
+	}
+	return jobs, nil
+}
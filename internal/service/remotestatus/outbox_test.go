@@ -0,0 +1,85 @@
+package remotestatus
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"lunabox/internal/common/enums"
+
+	_ "github.com/duckdb/duckdb-go/v2"
+)
+
+func TestBackoffDoublesUntilCap(t *testing.T) {
+	cases := map[int]time.Duration{
+		1:  30 * time.Second,
+		2:  time.Minute,
+		4:  4 * time.Minute,
+		7:  32 * time.Minute,
+		8:  time.Hour,
+		20: time.Hour,
+	}
+	for attempts, expected := range cases {
+		if got := Backoff(attempts); got != expected {
+			t.Fatalf("Backoff(%d) = %s, want %s", attempts, got, expected)
+		}
+	}
+}
+
+func TestDeferReschedulesWithoutConsumingAttempts(t *testing.T) {
+	db, err := sql.Open("duckdb", "")
+	if err != nil {
+		t.Fatalf("open test database: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	if _, err := db.Exec(`CREATE TABLE remote_push_outbox (
+		id TEXT PRIMARY KEY, provider TEXT NOT NULL, kind TEXT NOT NULL, game_id TEXT NOT NULL, source_id TEXT NOT NULL,
+		revision INTEGER NOT NULL DEFAULT 1, attempts INTEGER NOT NULL DEFAULT 0, last_error TEXT NOT NULL DEFAULT '',
+		dead BOOLEAN NOT NULL DEFAULT FALSE, next_attempt_at TIMESTAMPTZ NOT NULL,
+		created_at TIMESTAMPTZ NOT NULL, updated_at TIMESTAMPTZ NOT NULL
+	)`); err != nil {
+		t.Fatalf("create outbox: %v", err)
+	}
+
+	ctx := context.Background()
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	if err := Enqueue(ctx, db, enums.Bangumi, JobKindStatus, "game", "42", now); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	nextJob := func(at time.Time) Job {
+		t.Helper()
+		jobs, err := DueJobs(ctx, db, at, 1)
+		if err != nil || len(jobs) != 1 {
+			t.Fatalf("expected one due job at %s, got %+v (%v)", at, jobs, err)
+		}
+		return jobs[0]
+	}
+
+	cause := errors.New("HTTP 429")
+	if err := Defer(ctx, db, nextJob(now), cause, 2*time.Minute, now); err != nil {
+		t.Fatalf("defer with Retry-After: %v", err)
+	}
+	job := nextJob(now.Add(time.Hour))
+	if job.Attempts != 0 || job.LastError != cause.Error() || !job.NextAttemptAt.Equal(now.Add(2*time.Minute)) {
+		t.Fatalf("Retry-After should reschedule without counting an attempt: %+v", job)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if err := Defer(ctx, db, job, cause, 0, now); err != nil {
+		t.Fatalf("defer without Retry-After: %v", err)
+	}
+	job = nextJob(now.Add(time.Hour))
+	if job.Attempts != 0 || !job.NextAttemptAt.Equal(now.Add(4*time.Minute)) {
+		t.Fatalf("deferral without Retry-After should double the previous wait: %+v", job)
+	}
+
+	dead, err := Fail(ctx, db, job, errors.New("HTTP 400"), now)
+	if err != nil || dead {
+		t.Fatalf("first counted failure should not dead-letter: dead=%v err=%v", dead, err)
+	}
+	if job = nextJob(now.Add(time.Hour)); job.Attempts != 1 {
+		t.Fatalf("counted failure should consume an attempt: %+v", job)
+	}
+}
@@ -0,0 +1,245 @@
+package service
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"lunabox/internal/applog"
+	"lunabox/internal/common/vo"
+	"lunabox/internal/service/gamehelper"
+	"lunabox/internal/service/savedetect"
+	"lunabox/internal/utils/dbutils"
+)
+
+// 存档路径自动探测：常见位置规则（savedetect.Detect）每次查询时实时计算；
+// 游玩期间的写入观察（savedetect.WatchSession）在游戏启动时开始轮询存档根目录、游玩结束时停止，
+// 结果保存在 save_path_candidates 中，多次观察到会提高置信度。
+// 候选只供用户确认，不会自动写入 games.save_path。
+
+const (
+	// maxObservedSessionsWeight 超过该次数的重复观察不再提高置信度
+	maxObservedSessionsWeight = 3
+	// savePathWatchInterval 游玩期间轮询存档根目录的间隔
+	savePathWatchInterval = 30 * time.Second
+)
+
+type savePathObservation struct {
+	confidence float64
+	reasons    []savedetect.Reason
+	sessions   int
+	dismissed  bool
+}
+
+// loadSaveDetectGame 读取探测所需的游戏信息，同时返回当前存档路径
+func (s *BackupService) loadSaveDetectGame(gameID string) (savedetect.Game, string, error) {
+	var game savedetect.Game
+	var aliasesJSON, savePath string
+	err := s.db.QueryRowContext(s.ctx, `
+		SELECT COALESCE(name, ''), COALESCE(aliases, '[]'), COALESCE(company, ''), COALESCE(path, ''),
+		       COALESCE(game_directory, ''), COALESCE(process_name, ''), COALESCE(wine_prefix, ''), COALESCE(save_path, '')
+		FROM games WHERE id = ?`, gameID).Scan(
+		&game.Name, &aliasesJSON, &game.Company, &game.Path,
+		&game.GameDirectory, &game.ProcessName, &game.WinePrefix, &savePath,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return savedetect.Game{}, "", fmt.Errorf("game not found with id: %s", gameID)
+	}
+	if err != nil {
+		return savedetect.Game{}, "", fmt.Errorf("failed to get game: %w", err)
+	}
+	game.Aliases, _ = gamehelper.DecodeAliases(aliasesJSON)
+	return game, savePath, nil
+}
+
+func (s *BackupService) loadSavePathObservations(gameID string) (map[string]savePathObservation, error) {
+	rows, err := s.db.QueryContext(s.ctx, `
+		SELECT path, confidence, reasons, observed_sessions, dismissed
+		FROM save_path_candidates WHERE game_id = ?`, gameID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query save path candidates: %w", err)
+	}
+	defer rows.Close()
+
+	observations := make(map[string]savePathObservation)
+	for rows.Next() {
+		var path, reasonsJSON string
+		var observation savePathObservation
+		if err := rows.Scan(&path, &observation.confidence, &reasonsJSON, &observation.sessions, &observation.dismissed); err != nil {
+			return nil, err
+		}
+		_ = json.Unmarshal([]byte(reasonsJSON), &observation.reasons)
+		observations[path] = observation
+	}
+	return observations, rows.Err()
+}
+
+func (s *BackupService) saveSavePathObservation(gameID, path string, observation savePathObservation, now time.Time) error {
+	reasonsJSON, err := json.Marshal(observation.reasons)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(s.ctx, `
+		INSERT INTO save_path_candidates (game_id, path, confidence, reasons, observed_sessions, dismissed, detected_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (game_id, path) DO UPDATE SET
+			confidence = EXCLUDED.confidence,
+			reasons = EXCLUDED.reasons,
+			observed_sessions = EXCLUDED.observed_sessions,
+			dismissed = EXCLUDED.dismissed,
+			detected_at = EXCLUDED.detected_at`,
+		gameID, path, observation.confidence, string(reasonsJSON), observation.sessions, observation.dismissed, now,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save save path candidate: %w", err)
+	}
+	return nil
+}
+
+// StartSavePathWatch 在游戏启动时为未设置存档路径的游戏开始监听存档写入（限制与误差见 savedetect.WatchSession），
+// 无需监听时返回 nil。返回的监听必须交给 RecordSavePathSession 或自行 Stop。
+//
+//wails:ignore
+func (s *BackupService) StartSavePathWatch(gameID string, start time.Time) *savedetect.SessionWatch {
+	game, savePath, err := s.loadSaveDetectGame(gameID)
+	if err != nil || savePath != "" {
+		return nil
+	}
+	return savedetect.WatchSession(savedetect.CurrentEnv(), game, start, savePathWatchInterval)
+}
+
+// RecordSavePathSession 在一次游玩结束后停止存档写入监听，将期间有文件写入的目录记录为候选存档路径。
+// watch 为 nil 或游玩期间已设置存档路径的游戏直接跳过。
+//
+//wails:ignore
+func (s *BackupService) RecordSavePathSession(gameID string, watch *savedetect.SessionWatch) {
+	if watch == nil {
+		return
+	}
+	candidates := watch.Stop()
+	_, savePath, err := s.loadSaveDetectGame(gameID)
+	if err != nil || savePath != "" {
+		return
+	}
+	if len(candidates) == 0 {
+		applog.LogDebugf(s.ctx, "SavePathDiscovery: no save writes observed during play for game %s", gameID)
+		return
+	}
+
+	err = dbutils.WithDuckDBWriteLock(s.db, func() error {
+		observations, err := s.loadSavePathObservations(gameID)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		for _, candidate := range candidates {
+			observation := observations[candidate.Path]
+			observation.confidence = math.Max(observation.confidence, candidate.Confidence)
+			observation.reasons = savedetect.MergeReasons(observation.reasons, candidate.Reasons)
+			observation.sessions++
+			if err := s.saveSavePathObservation(gameID, candidate.Path, observation, now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		applog.LogWarningf(s.ctx, "SavePathDiscovery: failed to record candidates for game %s: %v", gameID, err)
+		return
+	}
+	applog.LogInfof(s.ctx, "SavePathDiscovery: recorded %d save path candidates for game %s", len(candidates), gameID)
+}
+
+// GetSavePathCandidates 返回游戏的候选存档路径，按置信度降序。
+// 结合常见存档位置规则与历次游玩期间观察到的写入（轮询所得，可能有遗漏或误报）；
+// 已忽略、已不存在的目录和当前存档路径不会出现。
+func (s *BackupService) GetSavePathCandidates(gameID string) ([]vo.SavePathCandidate, error) {
+	game, savePath, err := s.loadSaveDetectGame(gameID)
+	if err != nil {
+		return nil, err
+	}
+	observations, err := s.loadSavePathObservations(gameID)
+	if err != nil {
+		return nil, err
+	}
+
+	var observed []savedetect.Candidate
+	for path, observation := range observations {
+		if observation.dismissed {
+			continue
+		}
+		weight := min(observation.sessions, maxObservedSessionsWeight)
+		confidence := 1 - math.Pow(1-observation.confidence, float64(max(weight, 1)))
+		observed = append(observed, savedetect.Candidate{Path: path, Confidence: confidence, Reasons: observation.reasons})
+	}
+	merged := savedetect.Merge(savedetect.Detect(savedetect.CurrentEnv(), game), observed)
+
+	result := make([]vo.SavePathCandidate, 0, len(merged))
+	for _, candidate := range merged {
+		if observation, ok := observations[candidate.Path]; ok && observation.dismissed {
+			continue
+		}
+		if savePath != "" && filepath.Clean(savePath) == candidate.Path {
+			continue
+		}
+		if _, err := os.Stat(candidate.Path); err != nil {
+			continue
+		}
+		reasons := make([]string, 0, len(candidate.Reasons))
+		for _, reason := range candidate.Reasons {
+			reasons = append(reasons, string(reason))
+		}
+		result = append(result, vo.SavePathCandidate{
+			Path:             candidate.Path,
+			Confidence:       math.Round(candidate.Confidence*100) / 100,
+			Reasons:          reasons,
+			ObservedSessions: observations[candidate.Path].sessions,
+		})
+	}
+	return result, nil
+}
+
+// AcceptSavePathCandidate 把候选路径设为游戏的存档路径，并清空该游戏的候选记录
+func (s *BackupService) AcceptSavePathCandidate(gameID string, path string) error {
+	path = filepath.Clean(path)
+	if !filepath.IsAbs(path) {
+		return fmt.Errorf("存档路径必须是绝对路径")
+	}
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("存档路径不存在: %s", path)
+	}
+
+	return dbutils.WithDuckDBWriteLock(s.db, func() error {
+		result, err := s.db.ExecContext(s.ctx, `UPDATE games SET save_path = ? WHERE id = ?`, path, gameID)
+		if err != nil {
+			return fmt.Errorf("failed to update save_path: %w", err)
+		}
+		if rows, err := result.RowsAffected(); err == nil && rows == 0 {
+			return fmt.Errorf("game not found with id: %s", gameID)
+		}
+		if _, err := s.db.ExecContext(s.ctx, `DELETE FROM save_path_candidates WHERE game_id = ?`, gameID); err != nil {
+			return fmt.Errorf("failed to clear save path candidates: %w", err)
+		}
+		applog.LogInfof(s.ctx, "SavePathDiscovery: save path of game %s set to %s", gameID, path)
+		return nil
+	})
+}
+
+// DismissSavePathCandidate 忽略一个候选路径，之后的探测不再提出
+func (s *BackupService) DismissSavePathCandidate(gameID string, path string) error {
+	path = filepath.Clean(path)
+	return dbutils.WithDuckDBWriteLock(s.db, func() error {
+		observations, err := s.loadSavePathObservations(gameID)
+		if err != nil {
+			return err
+		}
+		observation := observations[path]
+		observation.dismissed = true
+		return s.saveSavePathObservation(gameID, path, observation, time.Now())
+	})
+}
@@ -0,0 +1,311 @@
+package savedetect
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Reason 说明候选存档路径的依据
+type Reason string
+
+const (
+	ReasonGameSaveFolder Reason = "game_save_folder" // 游戏目录下名为 save/savedata 等的文件夹
+	ReasonNameMatch      Reason = "name_match"       // 目录名与游戏名、别名、公司名或进程名匹配
+	ReasonSaveFiles      Reason = "save_files"       // 目录中有存档类文件
+	ReasonWinePrefix     Reason = "wine_prefix"      // 位于游戏的 Wine/Proton 前缀内
+	ReasonSessionWrites  Reason = "session_writes"   // 游玩期间轮询观察到目录中有文件被新增、修改或删除
+)
+
+// MinConfidence 低于该置信度的候选不提交给用户
+const MinConfidence = 0.3
+
+// Candidate 是一个候选存档路径
+type Candidate struct {
+	Path       string
+	Confidence float64 // 0~1
+	Reasons    []Reason
+}
+
+const (
+	scoreGameSaveFolder  = 0.7
+	scoreNestedSaveDir   = 0.55
+	scoreNameExact       = 0.55
+	scoreNameContains    = 0.35
+	scoreCompanyAndName  = 0.7
+	bonusSaveFiles       = 0.15
+	bonusSaveSpecificDir = 0.1 // My Games / Saved Games 只用于存档
+	maxConfidence        = 0.95
+)
+
+// saveFolderNames 是游戏目录内常见的存档文件夹名（小写）
+var saveFolderNames = map[string]struct{}{
+	"save": {}, "saves": {}, "savedata": {}, "save_data": {}, "savegame": {}, "savegames": {},
+	"saved": {}, "userdata": {}, "sav": {},
+}
+
+// saveFileExts 是常见的存档文件扩展名（小写）
+var saveFileExts = map[string]struct{}{
+	".sav": {}, ".save": {}, ".rpgsave": {}, ".rmmzsave": {}, ".rvdata2": {}, ".rxdata": {}, ".lsd": {},
+	".ksd": {}, ".kdt": {}, ".dat": {},
+}
+
+// genericExeNames 是常见的通用启动文件名，不能据此匹配目录
+var genericExeNames = map[string]struct{}{
+	"game": {}, "start": {}, "launcher": {}, "launch": {}, "play": {}, "setup": {}, "main": {},
+	"nw": {}, "app": {}, "run": {}, "config": {},
+}
+
+// Detect 按常见存档位置规则给出候选路径（不依赖游玩记录）
+func Detect(env Env, game Game) []Candidate {
+	names := gameNames(game)
+	company := normalizeName(game.Company)
+	var out []Candidate
+	for _, root := range Roots(env, game) {
+		if root.Kind == RootGameDirectory {
+			out = append(out, detectInGameDirectory(root.Path)...)
+			continue
+		}
+		out = append(out, detectInRoot(root, names, company)...)
+	}
+	return Merge(out)
+}
+
+func detectInGameDirectory(dir string) []Candidate {
+	var out []Candidate
+	children := subdirs(dir)
+	for _, child := range children {
+		if _, ok := saveFolderNames[strings.ToLower(child)]; ok {
+			out = append(out, scored(filepath.Join(dir, child), scoreGameSaveFolder, ReasonGameSaveFolder))
+			continue
+		}
+		// 如 RPG Maker MV 的 www/save
+		for _, nested := range subdirs(filepath.Join(dir, child)) {
+			if _, ok := saveFolderNames[strings.ToLower(nested)]; ok {
+				out = append(out, scored(filepath.Join(dir, child, nested), scoreNestedSaveDir, ReasonGameSaveFolder))
+			}
+		}
+	}
+	return withSaveFileBonus(out, true)
+}
+
+func detectInRoot(root Root, names []string, company string) []Candidate {
+	var out []Candidate
+	for _, child := range subdirs(root.Path) {
+		childPath := filepath.Join(root.Path, child)
+		if score := matchScore(child, names); score > 0 {
+			out = append(out, scored(childPath, score, ReasonNameMatch))
+			continue
+		}
+		// 公司名/游戏名两级目录，如 %APPDATA%/<公司>/<游戏>
+		if company == "" || !namesMatch(normalizeName(child), company) {
+			continue
+		}
+		for _, nested := range subdirs(childPath) {
+			if score := matchScore(nested, names); score > 0 {
+				out = append(out, scored(filepath.Join(childPath, nested), max(score, scoreCompanyAndName), ReasonNameMatch))
+			}
+		}
+	}
+	for i := range out {
+		if root.Kind == RootMyGames || root.Kind == RootSavedGames {
+			out[i].Confidence += bonusSaveSpecificDir
+		}
+		if root.Wine {
+			out[i].Reasons = append(out[i].Reasons, ReasonWinePrefix)
+		}
+	}
+	return withSaveFileBonus(out, false)
+}
+
+// withSaveFileBonus 为包含存档类文件的候选加分；requireFiles 时丢弃空目录
+func withSaveFileBonus(candidates []Candidate, requireFiles bool) []Candidate {
+	out := candidates[:0]
+	for _, candidate := range candidates {
+		files, saveLike := inspectFiles(candidate.Path)
+		if files == 0 && requireFiles {
+			continue
+		}
+		if saveLike {
+			candidate.Confidence += bonusSaveFiles
+			candidate.Reasons = append(candidate.Reasons, ReasonSaveFiles)
+		}
+		candidate.Confidence = min(candidate.Confidence, maxConfidence)
+		out = append(out, candidate)
+	}
+	return out
+}
+
+// inspectFiles 统计目录下（最多两层、最多 200 项）的文件数，并判断是否有存档类文件
+func inspectFiles(dir string) (int, bool) {
+	files, visited := 0, 0
+	saveLike := false
+	_ = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		visited++
+		if visited > 200 {
+			return filepath.SkipAll
+		}
+		if d.IsDir() {
+			if rel, _ := filepath.Rel(dir, p); strings.Count(rel, string(filepath.Separator)) >= 1 {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		files++
+		if isSaveLikeFile(d.Name()) {
+			saveLike = true
+		}
+		return nil
+	})
+	return files, saveLike
+}
+
+func isSaveLikeFile(name string) bool {
+	lower := strings.ToLower(name)
+	if _, ok := saveFileExts[filepath.Ext(lower)]; ok {
+		return true
+	}
+	return strings.Contains(lower, "save")
+}
+
+// gameNames 返回用于匹配目录名的游戏名（已规范化）
+func gameNames(game Game) []string {
+	raw := append([]string{game.Name}, game.Aliases...)
+	for _, exe := range []string{filepath.Base(game.Path), game.ProcessName} {
+		base := strings.TrimSuffix(exe, filepath.Ext(exe))
+		if _, generic := genericExeNames[strings.ToLower(base)]; !generic {
+			raw = append(raw, base)
+		}
+	}
+	var names []string
+	seen := make(map[string]struct{})
+	for _, name := range raw {
+		normalized := normalizeName(name)
+		if len([]rune(normalized)) < 2 {
+			continue
+		}
+		if _, ok := seen[normalized]; ok {
+			continue
+		}
+		seen[normalized] = struct{}{}
+		names = append(names, normalized)
+	}
+	return names
+}
+
+// normalizeName 转小写并去掉空格与符号，只保留字母和数字（包括中日文）
+func normalizeName(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func matchScore(dirName string, names []string) float64 {
+	normalized := normalizeName(dirName)
+	best := 0.0
+	for _, name := range names {
+		switch {
+		case normalized == name:
+			return scoreNameExact
+		case namesMatch(normalized, name):
+			best = scoreNameContains
+		}
+	}
+	return best
+}
+
+// namesMatch 判断两个规范化名称相同或互相包含（较短一方至少 4 个字符，避免误匹配）
+func namesMatch(a, b string) bool {
+	if a == "" || b == "" {
+		return false
+	}
+	if a == b {
+		return true
+	}
+	shorter, longer := a, b
+	if len([]rune(shorter)) > len([]rune(longer)) {
+		shorter, longer = longer, shorter
+	}
+	return len([]rune(shorter)) >= 4 && strings.Contains(longer, shorter)
+}
+
+func subdirs(dir string) []string {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	return names
+}
+
+func scored(path string, confidence float64, reason Reason) Candidate {
+	return Candidate{Path: filepath.Clean(path), Confidence: confidence, Reasons: []Reason{reason}}
+}
+
+// Merge 合并同一路径的候选：置信度按独立证据合并（1-(1-a)(1-b)），依据取并集。
+// 结果过滤掉低于 MinConfidence 的候选，并按置信度降序排列。
+func Merge(groups ...[]Candidate) []Candidate {
+	byPath := make(map[string]*Candidate)
+	var order []string
+	for _, group := range groups {
+		for _, candidate := range group {
+			path := filepath.Clean(candidate.Path)
+			existing, ok := byPath[path]
+			if !ok {
+				merged := Candidate{Path: path, Confidence: candidate.Confidence, Reasons: MergeReasons(nil, candidate.Reasons)}
+				byPath[path] = &merged
+				order = append(order, path)
+				continue
+			}
+			existing.Confidence = 1 - (1-existing.Confidence)*(1-candidate.Confidence)
+			existing.Reasons = MergeReasons(existing.Reasons, candidate.Reasons)
+		}
+	}
+	out := make([]Candidate, 0, len(order))
+	for _, path := range order {
+		candidate := *byPath[path]
+		candidate.Confidence = min(candidate.Confidence, maxConfidence)
+		if candidate.Confidence >= MinConfidence {
+			out = append(out, candidate)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Confidence != out[j].Confidence {
+			return out[i].Confidence > out[j].Confidence
+		}
+		return out[i].Path < out[j].Path
+	})
+	return out
+}
+
+// MergeReasons 把 reasons 中尚未出现的依据追加到 dst
+func MergeReasons(dst []Reason, reasons []Reason) []Reason {
+	for _, reason := range reasons {
+		found := false
+		for _, existing := range dst {
+			if existing == reason {
+				found = true
+				break
+			}
+		}
+		if !found {
+			dst = append(dst, reason)
+		}
+	}
+	return dst
+}
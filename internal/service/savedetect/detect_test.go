@@ -0,0 +1,151 @@
+package savedetect
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, path string, modTime time.Time) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("data"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func windowsEnv(profile string) Env {
+	return Env{GOOS: "windows", HomeDir: profile, Getenv: func(key string) string {
+		switch key {
+		case "APPDATA":
+			return filepath.Join(profile, "AppData", "Roaming")
+		case "LOCALAPPDATA":
+			return filepath.Join(profile, "AppData", "Local")
+		}
+		return ""
+	}}
+}
+
+func findCandidate(candidates []Candidate, path string) (Candidate, bool) {
+	for _, candidate := range candidates {
+		if candidate.Path == filepath.Clean(path) {
+			return candidate, true
+		}
+	}
+	return Candidate{}, false
+}
+
+func TestDetectKnownLocations(t *testing.T) {
+	root := t.TempDir()
+	profile := filepath.Join(root, "profile")
+	gameDir := filepath.Join(root, "games", "Sakura")
+	old := time.Now().Add(-48 * time.Hour)
+
+	writeFile(t, filepath.Join(gameDir, "Game.exe"), old)
+	writeFile(t, filepath.Join(gameDir, "savedata", "data0001.dat"), old)
+	writeFile(t, filepath.Join(gameDir, "empty_save_parent", "save", ".keep"), old)
+	writeFile(t, filepath.Join(profile, "AppData", "Roaming", "Sakura Soft", "Sakura Story", "sys.sav"), old)
+	writeFile(t, filepath.Join(profile, "Documents", "My Games", "SakuraStory", "slot1.bin"), old)
+	writeFile(t, filepath.Join(profile, "AppData", "Roaming", "Unrelated", "x.sav"), old)
+
+	game := Game{Name: "Sakura Story", Company: "Sakura Soft", Path: filepath.Join(gameDir, "Game.exe"), GameDirectory: gameDir}
+	candidates := Detect(windowsEnv(profile), game)
+
+	inGame, ok := findCandidate(candidates, filepath.Join(gameDir, "savedata"))
+	if !ok || inGame.Confidence < 0.8 {
+		t.Fatalf("in-game savedata missing or weak: %+v", candidates)
+	}
+	nested, ok := findCandidate(candidates, filepath.Join(profile, "AppData", "Roaming", "Sakura Soft", "Sakura Story"))
+	if !ok || nested.Confidence < 0.7 {
+		t.Fatalf("company/game appdata folder missing or weak: %+v", candidates)
+	}
+	if _, ok := findCandidate(candidates, filepath.Join(profile, "Documents", "My Games", "SakuraStory")); !ok {
+		t.Fatalf("My Games folder missing: %+v", candidates)
+	}
+	if _, ok := findCandidate(candidates, filepath.Join(profile, "AppData", "Roaming", "Unrelated")); ok {
+		t.Fatal("unrelated appdata folder should not be proposed")
+	}
+	for i := 1; i < len(candidates); i++ {
+		if candidates[i].Confidence > candidates[i-1].Confidence {
+			t.Fatalf("candidates not sorted by confidence: %+v", candidates)
+		}
+	}
+}
+
+func TestWatchSessionInProtonPrefix(t *testing.T) {
+	root := t.TempDir()
+	prefix := filepath.Join(root, "compatdata", "4000000001")
+	users := filepath.Join(prefix, "pfx", "drive_c", "users", "steamuser")
+	gameDir := filepath.Join(root, "games", "VN")
+	start := time.Now()
+	before := start.Add(-24 * time.Hour)
+	config := filepath.Join(users, "AppData", "Roaming", "Maker", "VN", "config.ini")
+
+	writeFile(t, filepath.Join(gameDir, "vn.exe"), before)
+	writeFile(t, filepath.Join(gameDir, "www", "save", "global.rpgsave"), before)
+	writeFile(t, filepath.Join(users, "AppData", "Local", "Old", "stale.dat"), before)
+	writeFile(t, config, before)
+	// 游戏启动后、快照完成前写入的文件按修改时间计入
+	writeFile(t, filepath.Join(gameDir, "www", "save", "file1.rpgsave"), start)
+
+	game := Game{Name: "VN", Path: filepath.Join(gameDir, "vn.exe"), GameDirectory: gameDir, WinePrefix: prefix}
+	watch := WatchSession(Env{GOOS: "linux"}, game, start, time.Hour)
+	<-watch.ready
+
+	// 保留原修改时间的改写只能通过大小变化发现
+	slot := filepath.Join(users, "AppData", "Roaming", "Maker", "VN", "slots", "s1.dat")
+	writeFile(t, slot, before)
+	if err := os.WriteFile(config, []byte("changed"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(config, before, before); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(t, filepath.Join(gameDir, "logs", "run.txt"), start)
+	writeFile(t, filepath.Join(users, "AppData", "Local", "Temp", "tmp.dat"), start)
+
+	candidates := watch.Stop()
+	inGame, ok := findCandidate(candidates, filepath.Join(gameDir, "www", "save"))
+	if !ok || inGame.Confidence < 0.8 {
+		t.Fatalf("in-game save folder missing or weak: %+v", candidates)
+	}
+	prefixed, ok := findCandidate(candidates, filepath.Join(users, "AppData", "Roaming", "Maker", "VN"))
+	if !ok || prefixed.Confidence < 0.8 {
+		t.Fatalf("prefix save folder should be the game-named directory: %+v", candidates)
+	}
+	if len(candidates) != 2 {
+		t.Fatalf("stale, temp and log writes should be ignored: %+v", candidates)
+	}
+	if again := watch.Stop(); len(again) != len(candidates) {
+		t.Fatalf("Stop should be idempotent: %+v", again)
+	}
+}
+
+func TestWatchSessionObservesDeletedFiles(t *testing.T) {
+	gameDir := filepath.Join(t.TempDir(), "VN")
+	before := time.Now().Add(-24 * time.Hour)
+	quicksave := filepath.Join(gameDir, "savedata", "quick.dat")
+	writeFile(t, filepath.Join(gameDir, "vn.exe"), before)
+	writeFile(t, quicksave, before)
+
+	game := Game{Name: "VN", Path: filepath.Join(gameDir, "vn.exe"), GameDirectory: gameDir}
+	watch := WatchSession(Env{GOOS: "linux"}, game, time.Now(), time.Hour)
+	<-watch.ready
+	if err := os.Remove(quicksave); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := findCandidate(watch.Stop(), filepath.Join(gameDir, "savedata")); !ok {
+		t.Fatal("directory of a file removed during play should be a candidate")
+	}
+	var nilWatch *SessionWatch
+	if nilWatch.Stop() != nil {
+		t.Fatal("nil watch should report no candidates")
+	}
+}
@@ -0,0 +1,163 @@
+package savedetect
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Env 描述探测时的用户环境，测试中可替换
+type Env struct {
+	GOOS    string
+	HomeDir string
+	Getenv  func(string) string
+}
+
+// CurrentEnv 返回当前进程的用户环境
+func CurrentEnv() Env {
+	home, _ := os.UserHomeDir()
+	return Env{GOOS: runtime.GOOS, HomeDir: home, Getenv: os.Getenv}
+}
+
+func (e Env) getenv(key string) string {
+	if e.Getenv == nil {
+		return ""
+	}
+	return strings.TrimSpace(e.Getenv(key))
+}
+
+// Game 是探测所需的游戏信息
+type Game struct {
+	Name          string
+	Aliases       []string
+	Company       string
+	Path          string // 启动路径
+	GameDirectory string
+	ProcessName   string
+	WinePrefix    string
+}
+
+// RootKind 是存档常见位置的类别
+type RootKind string
+
+const (
+	RootGameDirectory RootKind = "game_directory" // 游戏目录
+	RootAppData       RootKind = "appdata"        // %APPDATA%（Roaming）
+	RootLocalAppData  RootKind = "local_appdata"  // %LOCALAPPDATA%
+	RootLocalLow      RootKind = "locallow"       // AppData/LocalLow（Unity 游戏常用）
+	RootDocuments     RootKind = "documents"      // 文档
+	RootMyGames       RootKind = "my_games"       // Documents/My Games
+	RootSavedGames    RootKind = "saved_games"    // %USERPROFILE%/Saved Games
+)
+
+// Root 是一个需要检查的存档根目录；存档本身总是它下面的子目录
+type Root struct {
+	Path string
+	Kind RootKind
+	Wine bool // 位于 Wine/Proton 前缀内
+}
+
+// Roots 返回该游戏可能存放存档的根目录（只包含实际存在的目录）。
+// Windows 下取当前用户目录；Linux/macOS 下取 WinePrefix 中各 Wine 用户的对应目录。
+func Roots(env Env, game Game) []Root {
+	var roots []Root
+	if dir := gameDirectory(game); dir != "" {
+		roots = append(roots, Root{Path: dir, Kind: RootGameDirectory})
+	}
+
+	if env.GOOS == "windows" {
+		profile := firstNonEmpty(env.getenv("USERPROFILE"), env.HomeDir)
+		if profile != "" {
+			appData := firstNonEmpty(env.getenv("APPDATA"), filepath.Join(profile, "AppData", "Roaming"))
+			localAppData := firstNonEmpty(env.getenv("LOCALAPPDATA"), filepath.Join(profile, "AppData", "Local"))
+			roots = append(roots, windowsUserRoots(profile, appData, localAppData, false)...)
+		}
+	} else if prefix := strings.TrimSpace(game.WinePrefix); prefix != "" {
+		roots = append(roots, wineRoots(prefix)...)
+	}
+
+	seen := make(map[string]struct{}, len(roots))
+	out := roots[:0]
+	for _, root := range roots {
+		root.Path = filepath.Clean(root.Path)
+		if _, ok := seen[root.Path]; ok || !isDir(root.Path) {
+			continue
+		}
+		seen[root.Path] = struct{}{}
+		out = append(out, root)
+	}
+	return out
+}
+
+func gameDirectory(game Game) string {
+	if dir := strings.TrimSpace(game.GameDirectory); dir != "" {
+		return dir
+	}
+	if path := strings.TrimSpace(game.Path); path != "" {
+		return filepath.Dir(path)
+	}
+	return ""
+}
+
+func windowsUserRoots(profile, appData, localAppData string, wine bool) []Root {
+	documents := filepath.Join(profile, "Documents")
+	return []Root{
+		{Path: appData, Kind: RootAppData, Wine: wine},
+		{Path: localAppData, Kind: RootLocalAppData, Wine: wine},
+		{Path: filepath.Join(profile, "AppData", "LocalLow"), Kind: RootLocalLow, Wine: wine},
+		{Path: filepath.Join(documents, "My Games"), Kind: RootMyGames, Wine: wine},
+		{Path: documents, Kind: RootDocuments, Wine: wine},
+		{Path: filepath.Join(profile, "Saved Games"), Kind: RootSavedGames, Wine: wine},
+	}
+}
+
+// wineRoots 返回 Wine 前缀中各用户的存档根目录。
+// Proton 的 compatdata/<appid> 把前缀放在 pfx/ 下；旧版 Wine 使用 XP 风格的目录名。
+func wineRoots(prefix string) []Root {
+	driveC := filepath.Join(prefix, "pfx", "drive_c")
+	if !isDir(driveC) {
+		driveC = filepath.Join(prefix, "drive_c")
+	}
+	entries, err := os.ReadDir(filepath.Join(driveC, "users"))
+	if err != nil {
+		return nil
+	}
+	var roots []Root
+	for _, entry := range entries {
+		if strings.EqualFold(entry.Name(), "Public") {
+			continue
+		}
+		profile := filepath.Join(driveC, "users", entry.Name())
+		if !isDir(profile) {
+			continue
+		}
+		roots = append(roots, windowsUserRoots(
+			profile,
+			filepath.Join(profile, "AppData", "Roaming"),
+			filepath.Join(profile, "AppData", "Local"),
+			true,
+		)...)
+		roots = append(roots,
+			Root{Path: filepath.Join(profile, "Application Data"), Kind: RootAppData, Wine: true},
+			Root{Path: filepath.Join(profile, "Local Settings", "Application Data"), Kind: RootLocalAppData, Wine: true},
+			Root{Path: filepath.Join(profile, "My Documents", "My Games"), Kind: RootMyGames, Wine: true},
+			Root{Path: filepath.Join(profile, "My Documents"), Kind: RootDocuments, Wine: true},
+		)
+	}
+	return roots
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func isDir(p string) bool {
+	info, err := os.Stat(p)
+	return err == nil && info.IsDir()
+}
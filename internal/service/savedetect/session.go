@@ -0,0 +1,318 @@
+package savedetect
+
+import (
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 游玩期间轮询的范围限制，避免在巨大的 AppData 或游戏目录上耗时过长。
+// 超出深度或条目数上限的部分不会被检查，其中的存档写入会被漏掉。
+const (
+	sessionScanMaxDepth     = 5
+	sessionScanMaxEntries   = 20000
+	sessionScanMTimeSlack   = time.Minute
+	scoreSessionGameDir     = 0.65
+	scoreSessionWinePrefix  = 0.55
+	scoreSessionUserDir     = 0.3
+	bonusSessionNameMatch   = 0.35
+	bonusSessionSaveFolder  = 0.2
+	bonusSessionSaveFiles   = 0.1
+	maxSessionChangedGroups = 20
+)
+
+// noisyDirNames 是其他程序频繁写入、不会是游戏存档的目录（小写）
+var noisyDirNames = map[string]struct{}{
+	"microsoft": {}, "packages": {}, "temp": {}, "tmp": {}, "google": {}, "mozilla": {}, "nvidia": {},
+	"nvidia corporation": {}, "amd": {}, "intel": {}, "crashdumps": {}, "d3dscache": {}, "logs": {}, "log": {},
+	"crashreports": {}, "steam": {}, "discord": {}, "lunabox": {}, "shadercache": {}, "gpucache": {},
+	"code cache": {}, "windows": {}, "connecteddevicesplatform": {}, "comms": {}, "programs": {},
+}
+
+// ignoredFileExts 是日志、锁等与存档无关的文件扩展名（小写）
+var ignoredFileExts = map[string]struct{}{
+	".log": {}, ".tmp": {}, ".lock": {}, ".dmp": {}, ".etl": {}, ".pf": {}, ".crash": {},
+}
+
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+func (f fileState) equal(other fileState) bool {
+	return f.size == other.size && f.modTime.Equal(other.modTime)
+}
+
+// SessionWatch 在游玩期间监听存档根目录中的文件写入
+type SessionWatch struct {
+	roots     []Root
+	rootPaths map[string]struct{}
+	names     []string
+	since     time.Time
+	interval  time.Duration
+
+	// 以下两项按根目录下标存放，键为相对根目录的文件路径（/ 分隔）；
+	// 轮询期间只由 run 访问，Stop 等待 run 退出后再读取
+	files   []map[string]fileState
+	changed []map[string]struct{}
+
+	ready      chan struct{}
+	stop       chan struct{}
+	done       chan struct{}
+	stopOnce   sync.Once
+	candidates []Candidate
+}
+
+// WatchSession 在游玩开始时启动存档写入监听：先为各存档根目录记录文件快照，之后每隔 interval
+// 轮询一次，与上一次快照比较找出新增、修改（修改时间或大小变化）和删除的文件。快照时修改时间
+// 已不早于 since（前推 sessionScanMTimeSlack）的文件同样视为本次游玩写入，以覆盖游戏启动后、
+// 快照完成前的写入。快照在后台完成，不阻塞调用方。
+//
+// 监听基于轮询而非系统文件通知，因此：
+//   - 同一时段其他程序写入的文件也会被当作游戏写入（噪声目录已排除，但无法完全避免）；
+//   - 在两次轮询之间创建又删除的文件观察不到；
+//   - 每个根目录只检查 sessionScanMaxDepth 层、sessionScanMaxEntries 个条目，之外的写入会漏掉。
+//
+// 调用方必须在游玩结束时调用 Stop 释放轮询协程。
+func WatchSession(env Env, game Game, since time.Time, interval time.Duration) *SessionWatch {
+	roots := Roots(env, game)
+	w := &SessionWatch{
+		roots:     roots,
+		rootPaths: make(map[string]struct{}, len(roots)),
+		names:     gameNames(game),
+		since:     since,
+		interval:  interval,
+		files:     make([]map[string]fileState, len(roots)),
+		changed:   make([]map[string]struct{}, len(roots)),
+		ready:     make(chan struct{}),
+		stop:      make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	for _, root := range roots {
+		w.rootPaths[root.Path] = struct{}{}
+	}
+	go w.run()
+	return w
+}
+
+func (w *SessionWatch) run() {
+	defer close(w.done)
+	from := w.since.Add(-sessionScanMTimeSlack)
+	for i, root := range w.roots {
+		w.files[i] = scanSessionRoot(root, w.rootPaths)
+		w.changed[i] = make(map[string]struct{})
+		for rel, state := range w.files[i] {
+			if !state.modTime.Before(from) {
+				w.changed[i][rel] = struct{}{}
+			}
+		}
+	}
+	close(w.ready)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+			w.poll()
+		}
+	}
+}
+
+// poll 重新扫描各根目录，把与上一次快照不同的文件记为已写入
+func (w *SessionWatch) poll() {
+	for i, root := range w.roots {
+		current := scanSessionRoot(root, w.rootPaths)
+		for rel, state := range current {
+			if previous, ok := w.files[i][rel]; !ok || !previous.equal(state) {
+				w.changed[i][rel] = struct{}{}
+			}
+		}
+		for rel := range w.files[i] {
+			if _, ok := current[rel]; !ok {
+				w.changed[i][rel] = struct{}{}
+			}
+		}
+		w.files[i] = current
+	}
+}
+
+// Stop 停止轮询，做最后一次比较后把观察到写入的文件按所在目录归并为候选存档路径。
+// 根目录本身不会成为候选，避免把整个 AppData 或游戏目录当作存档。
+// 可重复调用，之后返回相同结果；w 为 nil 时返回 nil。
+//
+// 结果只作为候选，需多次游玩重复观察提高置信度，并由用户确认。
+func (w *SessionWatch) Stop() []Candidate {
+	if w == nil {
+		return nil
+	}
+	w.stopOnce.Do(func() {
+		close(w.stop)
+		<-w.done
+		w.poll()
+		var out []Candidate
+		for i, root := range w.roots {
+			out = append(out, sessionCandidatesInRoot(root, w.names, w.changed[i])...)
+		}
+		w.candidates = Merge(out)
+	})
+	return w.candidates
+}
+
+// scanSessionRoot 返回根目录下可能是存档的文件状态，键为相对路径（/ 分隔）。
+// 噪声目录、嵌套的其他根目录、日志等文件以及直接位于根目录下的文件不会出现。
+func scanSessionRoot(root Root, rootPaths map[string]struct{}) map[string]fileState {
+	files := make(map[string]fileState)
+	entries := 0
+	_ = filepath.WalkDir(root.Path, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		entries++
+		if entries > sessionScanMaxEntries {
+			return filepath.SkipAll
+		}
+		rel, relErr := filepath.Rel(root.Path, p)
+		if relErr != nil || rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			lower := strings.ToLower(d.Name())
+			if _, noisy := noisyDirNames[lower]; noisy || strings.Contains(lower, "cache") {
+				return filepath.SkipDir
+			}
+			// 嵌套的其他根目录（如 Documents/My Games）单独扫描
+			if _, isRoot := rootPaths[p]; isRoot {
+				return filepath.SkipDir
+			}
+			if strings.Count(rel, "/")+1 >= sessionScanMaxDepth {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if _, ignored := ignoredFileExts[strings.ToLower(filepath.Ext(d.Name()))]; ignored {
+			return nil
+		}
+		if pathDir(rel) == "" {
+			// 直接写在根目录下的文件无法归到某个存档目录
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		files[rel] = fileState{modTime: info.ModTime(), size: info.Size()}
+		return nil
+	})
+	return files
+}
+
+// changedGroup 是根目录下某个一级子目录中的写入
+type changedGroup struct {
+	dirs     []string // 有写入的目录（相对根目录，/ 分隔）
+	saveLike bool
+}
+
+func sessionCandidatesInRoot(root Root, names []string, changed map[string]struct{}) []Candidate {
+	rels := make([]string, 0, len(changed))
+	for rel := range changed {
+		rels = append(rels, rel)
+	}
+	sort.Strings(rels)
+
+	groups := make(map[string]*changedGroup)
+	var order []string
+	for _, rel := range rels {
+		dir := pathDir(rel)
+		top := strings.SplitN(dir, "/", 2)[0]
+		group, ok := groups[top]
+		if !ok {
+			if len(groups) >= maxSessionChangedGroups {
+				continue
+			}
+			group = &changedGroup{}
+			groups[top] = group
+			order = append(order, top)
+		}
+		group.dirs = append(group.dirs, dir)
+		group.saveLike = group.saveLike || isSaveLikeFile(pathBase(rel))
+	}
+
+	var out []Candidate
+	for _, top := range order {
+		group := groups[top]
+		rel := commonDir(group.dirs)
+		rel, nameMatched := preferNamedAncestor(rel, names)
+
+		candidate := Candidate{Path: filepath.Join(root.Path, filepath.FromSlash(rel)), Reasons: []Reason{ReasonSessionWrites}}
+		switch {
+		case root.Kind == RootGameDirectory:
+			candidate.Confidence = scoreSessionGameDir
+		case root.Wine:
+			candidate.Confidence = scoreSessionWinePrefix
+			candidate.Reasons = append(candidate.Reasons, ReasonWinePrefix)
+		default:
+			candidate.Confidence = scoreSessionUserDir
+		}
+		if nameMatched {
+			candidate.Confidence += bonusSessionNameMatch
+			candidate.Reasons = append(candidate.Reasons, ReasonNameMatch)
+		}
+		if _, ok := saveFolderNames[strings.ToLower(pathBase(rel))]; ok {
+			candidate.Confidence += bonusSessionSaveFolder
+			candidate.Reasons = append(candidate.Reasons, ReasonGameSaveFolder)
+		}
+		if group.saveLike {
+			candidate.Confidence += bonusSessionSaveFiles
+			candidate.Reasons = append(candidate.Reasons, ReasonSaveFiles)
+		}
+		candidate.Confidence = min(candidate.Confidence, maxConfidence)
+		out = append(out, candidate)
+	}
+	return out
+}
+
+// preferNamedAncestor 若路径上某一级目录名与游戏匹配，取该目录作为存档根（包含同级的设置文件等）
+func preferNamedAncestor(rel string, names []string) (string, bool) {
+	parts := strings.Split(rel, "/")
+	for i := range parts {
+		if matchScore(parts[i], names) > 0 {
+			return strings.Join(parts[:i+1], "/"), true
+		}
+	}
+	return rel, false
+}
+
+// commonDir 返回一组相对目录的最深公共祖先
+func commonDir(dirs []string) string {
+	common := strings.Split(dirs[0], "/")
+	for _, dir := range dirs[1:] {
+		parts := strings.Split(dir, "/")
+		n := 0
+		for n < len(common) && n < len(parts) && common[n] == parts[n] {
+			n++
+		}
+		common = common[:n]
+	}
+	return strings.Join(common, "/")
+}
+
+func pathDir(rel string) string {
+	if i := strings.LastIndex(rel, "/"); i >= 0 {
+		return rel[:i]
+	}
+	return ""
+}
+
+func pathBase(rel string) string {
+	return rel[strings.LastIndex(rel, "/")+1:]
+}
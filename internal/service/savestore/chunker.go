@@ -0,0 +1,93 @@
+package savestore
+
+import "io"
+
+// 内容定义分块（FastCDC 风格的 gear hash）参数。
+// 切点只取决于附近的字节内容，大文件中间插入或修改数据时只有受影响的块会变化，其余块仍能去重。
+// 这些常量与 gearTable 一起决定了块边界，修改后已有仓库中的块将无法再被复用。
+const (
+	minChunkSize = 512 << 10
+	avgChunkSize = 1 << 20
+	maxChunkSize = 4 << 20
+
+	// 平均块大小之前用更严格的掩码、之后用更宽松的掩码，使块大小集中在平均值附近。
+	// 取 hash 高位：左移 gear hash 的高位才综合了最近 64 个字节。
+	maskStrict = uint64(0xFFFFFC0000000000) // 高 22 位
+	maskLoose  = uint64(0xFFFFC00000000000) // 高 18 位
+)
+
+var gearTable = func() [256]uint64 {
+	// splitmix64，固定种子保证不同设备、不同版本切出相同的块
+	var table [256]uint64
+	state := uint64(0x4c756e61426f78) // "LunaBox"
+	for i := range table {
+		state += 0x9e3779b97f4a7c15
+		z := state
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		table[i] = z ^ (z >> 31)
+	}
+	return table
+}()
+
+// chunker 把输入流切分为内容定义的块
+type chunker struct {
+	r   io.Reader
+	buf []byte
+	n   int
+	eof bool
+}
+
+func newChunker(r io.Reader) *chunker {
+	return &chunker{r: r, buf: make([]byte, maxChunkSize)}
+}
+
+// next 返回下一个块（调用方持有的独立副本），输入结束时返回 io.EOF
+func (c *chunker) next() ([]byte, error) {
+	for !c.eof && c.n < len(c.buf) {
+		m, err := c.r.Read(c.buf[c.n:])
+		c.n += m
+		if err == io.EOF {
+			c.eof = true
+		} else if err != nil {
+			return nil, err
+		}
+	}
+	if c.n == 0 {
+		return nil, io.EOF
+	}
+	cut := cutPoint(c.buf[:c.n])
+	chunk := append([]byte(nil), c.buf[:cut]...)
+	c.n = copy(c.buf, c.buf[cut:c.n])
+	return chunk, nil
+}
+
+// cutPoint 返回 data 中第一个块的长度
+func cutPoint(data []byte) int {
+	n := len(data)
+	if n <= minChunkSize {
+		return n
+	}
+	if n > maxChunkSize {
+		n = maxChunkSize
+	}
+	normal := avgChunkSize
+	if normal > n {
+		normal = n
+	}
+	var hash uint64
+	i := minChunkSize
+	for ; i < normal; i++ {
+		hash = (hash << 1) + gearTable[data[i]]
+		if hash&maskStrict == 0 {
+			return i + 1
+		}
+	}
+	for ; i < n; i++ {
+		hash = (hash << 1) + gearTable[data[i]]
+		if hash&maskLoose == 0 {
+			return i + 1
+		}
+	}
+	return n
+}
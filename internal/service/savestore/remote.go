@@ -0,0 +1,247 @@
+package savestore
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"lunabox/internal/service/cloudprovider"
+	"lunabox/internal/service/cloudprovider/batchupload"
+)
+
+// Remote 是仓库在云端的镜像，Prefix 为游戏存档目录（如 ".../saves/<gameID>/"）：
+//
+//	<Prefix>snapshots/<id>.json
+//	<Prefix>blobs/<sha256>.blob
+//
+// 块平铺在一个目录下，兼容只列出直接子文件的 provider（WebDAV / OneDrive / 本地目录 / SFTP）。
+// 块与本地仓库中的压缩块逐字节相同，加密由外层 EncryptedProvider 透明处理。
+type Remote struct {
+	Provider cloudprovider.CloudStorageProvider
+	Prefix   string
+}
+
+// NewRemote 创建云端仓库视图
+func NewRemote(provider cloudprovider.CloudStorageProvider, prefix string) Remote {
+	if !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return Remote{Provider: provider, Prefix: prefix}
+}
+
+// SnapshotKey 返回快照清单的云端 key
+func (r Remote) SnapshotKey(id string) string {
+	return r.Prefix + snapshotsDir + "/" + id + snapshotExt
+}
+
+// SnapshotIDFromKey 判断云端 key 是否为快照清单，是则返回快照 ID
+func SnapshotIDFromKey(key string) (string, bool) {
+	// provider 返回的 key 形态不完全一致（前导斜杠等），只按所在目录名与文件名识别
+	name := path.Base(key)
+	if path.Base(path.Dir(key)) != snapshotsDir || !strings.HasSuffix(name, snapshotExt) {
+		return "", false
+	}
+	id := strings.TrimSuffix(name, snapshotExt)
+	return id, ValidID(id)
+}
+
+func (r Remote) blobKey(id string) string {
+	return r.Prefix + blobsDir + "/" + id + BlobExt
+}
+
+// ListSnapshots 列出云端的快照清单 key
+func (r Remote) ListSnapshots(ctx context.Context) ([]string, error) {
+	keys, err := r.Provider.ListObjects(ctx, r.Prefix+snapshotsDir+"/")
+	if err != nil {
+		return nil, err
+	}
+	out := make([]string, 0, len(keys))
+	for _, key := range keys {
+		if _, ok := SnapshotIDFromKey(key); ok {
+			out = append(out, key)
+		}
+	}
+	return out, nil
+}
+
+func (r Remote) listBlobs(ctx context.Context) (map[string]string, error) {
+	keys, err := r.Provider.ListObjects(ctx, r.Prefix+blobsDir+"/")
+	if err != nil {
+		return nil, fmt.Errorf("列出云端数据块失败: %w", err)
+	}
+	out := make(map[string]string, len(keys))
+	for _, key := range keys {
+		name := path.Base(key)
+		id := strings.TrimSuffix(name, BlobExt)
+		if path.Base(path.Dir(key)) == blobsDir && blobIDPattern.MatchString(id) && name == id+BlobExt {
+			out[id] = key
+		}
+	}
+	return out, nil
+}
+
+// Push 把本地快照上传到云端：只上传云端没有的块，最后写清单。
+// 返回上传的块数量。
+func (r Remote) Push(ctx context.Context, store *Store, snapshot Snapshot) (int, error) {
+	for _, dir := range []string{snapshotsDir, blobsDir} {
+		if err := r.Provider.EnsureDir(ctx, r.Prefix+dir); err != nil {
+			return 0, fmt.Errorf("创建云端目录失败: %w", err)
+		}
+	}
+	existing, err := r.listBlobs(ctx)
+	if err != nil {
+		return 0, err
+	}
+	uploaded, err := r.uploadMissing(ctx, store, snapshot, existing)
+	if err != nil {
+		return uploaded, err
+	}
+
+	data, err := encodeSnapshot(snapshot)
+	if err != nil {
+		return uploaded, err
+	}
+	tmp, err := os.CreateTemp("", "lunabox_save_snapshot_*.json")
+	if err != nil {
+		return uploaded, err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return uploaded, err
+	}
+	if err := tmp.Close(); err != nil {
+		return uploaded, err
+	}
+	if err := r.Provider.UploadFile(ctx, r.SnapshotKey(snapshot.ID), tmpPath); err != nil {
+		return uploaded, fmt.Errorf("上传快照清单失败: %w", err)
+	}
+
+	// 另一台设备的 GC 可能恰好在块上传之后、清单可见之前删掉了这些块；清单写入后再核对一次补齐
+	existing, err = r.listBlobs(ctx)
+	if err != nil {
+		return uploaded, err
+	}
+	repaired, err := r.uploadMissing(ctx, store, snapshot, existing)
+	return uploaded + repaired, err
+}
+
+func (r Remote) uploadMissing(ctx context.Context, store *Store, snapshot Snapshot, existing map[string]string) (int, error) {
+	var items []batchupload.Item
+	for _, id := range snapshot.BlobIDs() {
+		if _, ok := existing[id]; ok {
+			continue
+		}
+		if !store.HasBlob(id) {
+			return 0, fmt.Errorf("本地数据块缺失: %s", id)
+		}
+		items = append(items, batchupload.Item{CloudPath: r.blobKey(id), LocalPath: store.BlobFile(id)})
+	}
+	if len(items) == 0 {
+		return 0, nil
+	}
+	if batch, ok := r.Provider.(cloudprovider.BatchUploadProvider); ok {
+		if err := batch.UploadFiles(ctx, items); err != nil {
+			return 0, fmt.Errorf("上传数据块失败: %w", err)
+		}
+		return len(items), nil
+	}
+	for i, item := range items {
+		if err := r.Provider.UploadFile(ctx, item.CloudPath, item.LocalPath); err != nil {
+			return i, fmt.Errorf("上传数据块失败: %w", err)
+		}
+	}
+	return len(items), nil
+}
+
+// Load 下载并解析云端快照清单
+func (r Remote) Load(ctx context.Context, key string) (Snapshot, error) {
+	if _, ok := SnapshotIDFromKey(key); !ok {
+		return Snapshot{}, fmt.Errorf("无效的云端快照 key: %s", key)
+	}
+	tmp, err := os.CreateTemp("", "lunabox_save_snapshot_*.json")
+	if err != nil {
+		return Snapshot{}, err
+	}
+	tmpPath := tmp.Name()
+	tmp.Close()
+	defer os.Remove(tmpPath)
+	if err := r.Provider.DownloadFile(ctx, key, tmpPath); err != nil {
+		return Snapshot{}, fmt.Errorf("下载快照清单失败: %w", err)
+	}
+	data, err := os.ReadFile(tmpPath)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	return decodeSnapshot(data)
+}
+
+// Fetch 下载云端快照，并把本地仓库缺少的块拉取到 store；本地已有的块不会重复下载。
+func (r Remote) Fetch(ctx context.Context, key string, store *Store) (Snapshot, error) {
+	snapshot, err := r.Load(ctx, key)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	tmpDir, err := os.MkdirTemp("", "lunabox_save_blobs_*")
+	if err != nil {
+		return Snapshot{}, err
+	}
+	defer os.RemoveAll(tmpDir)
+	for _, id := range store.MissingBlobs(snapshot) {
+		tmpPath := filepath.Join(tmpDir, id)
+		if err := r.Provider.DownloadFile(ctx, r.blobKey(id), tmpPath); err != nil {
+			return Snapshot{}, fmt.Errorf("下载数据块 %s 失败: %w", id, err)
+		}
+		if err := store.ImportBlob(id, tmpPath); err != nil {
+			return Snapshot{}, err
+		}
+		os.Remove(tmpPath)
+	}
+	return snapshot, nil
+}
+
+// Delete 删除云端快照清单；块由 GC 回收
+func (r Remote) Delete(ctx context.Context, key string) error {
+	if _, ok := SnapshotIDFromKey(key); !ok {
+		return fmt.Errorf("无效的云端快照 key: %s", key)
+	}
+	return r.Provider.DeleteObject(ctx, key)
+}
+
+// GC 删除云端不再被任何快照引用的块，返回删除数量。
+// 任一清单读取失败时放弃回收，避免误删仍被引用的块。
+func (r Remote) GC(ctx context.Context) (int, error) {
+	keys, err := r.ListSnapshots(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("列出云端快照失败: %w", err)
+	}
+	referenced := make(map[string]struct{})
+	for _, key := range keys {
+		snapshot, err := r.Load(ctx, key)
+		if err != nil {
+			return 0, fmt.Errorf("读取云端快照 %s 失败，跳过数据块回收: %w", key, err)
+		}
+		for _, id := range snapshot.BlobIDs() {
+			referenced[id] = struct{}{}
+		}
+	}
+	blobs, err := r.listBlobs(ctx)
+	if err != nil {
+		return 0, err
+	}
+	removed := 0
+	for id, key := range blobs {
+		if _, keep := referenced[id]; keep {
+			continue
+		}
+		if err := r.Provider.DeleteObject(ctx, key); err != nil {
+			return removed, fmt.Errorf("删除云端数据块失败: %w", err)
+		}
+		removed++
+	}
+	return removed, nil
+}
@@ -0,0 +1,126 @@
+package savestore
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// FormatVersion 是快照清单的格式版本；读到更高版本时拒绝恢复，避免旧客户端按错误语义还原存档
+const FormatVersion = 1
+
+// IDLayout 是快照 ID 的时间格式，与旧版 zip 备份文件名保持一致
+const IDLayout = "2006-01-02T15-04-05"
+
+var (
+	blobIDPattern     = regexp.MustCompile(`^[0-9a-f]{64}$`)
+	snapshotIDPattern = regexp.MustCompile(`^[0-9A-Za-z][0-9A-Za-z_-]*$`)
+)
+
+// Snapshot 是一次存档备份的清单：文件列表与每个文件按顺序引用的去重块
+type Snapshot struct {
+	Version   int       `json:"version"`
+	ID        string    `json:"id"`
+	GameID    string    `json:"game_id"`
+	CreatedAt time.Time `json:"created_at"`
+	// Source 备份时的存档路径，仅供展示
+	Source string `json:"source"`
+	// SingleFile 存档路径本身是单个文件（Files 只有一项，Path 为文件名）
+	SingleFile bool     `json:"single_file,omitempty"`
+	Files      []File   `json:"files"`
+	Dirs       []string `json:"dirs,omitempty"` // 空目录，保证恢复后目录结构一致
+	Size       int64    `json:"size"`           // 存档原始总大小
+	// AddedSize 创建该快照时新写入仓库的块大小（压缩后），即这次备份实际占用的增量空间
+	AddedSize int64 `json:"added_size"`
+}
+
+// File 是快照中的一个文件
+type File struct {
+	Path    string      `json:"path"` // 相对存档根目录，使用 / 分隔
+	Size    int64       `json:"size"`
+	Mode    os.FileMode `json:"mode"`
+	ModTime time.Time   `json:"mod_time"`
+	Hash    string      `json:"hash,omitempty"` // 整个文件内容的 SHA-256，用于与 zip 备份或当前存档比对
+	Blobs   []string    `json:"blobs"`
+}
+
+// BlobIDs 返回快照引用的全部块（去重）
+func (s Snapshot) BlobIDs() []string {
+	seen := make(map[string]struct{})
+	var ids []string
+	for _, file := range s.Files {
+		for _, id := range file.Blobs {
+			if _, ok := seen[id]; ok {
+				continue
+			}
+			seen[id] = struct{}{}
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// ValidID 判断快照 ID 能否安全地用作文件名
+func ValidID(id string) bool {
+	return snapshotIDPattern.MatchString(id)
+}
+
+// ParseIDTime 从快照 ID 中解析创建时间（ID 可能带有去重后缀）
+func ParseIDTime(id string) (time.Time, bool) {
+	if len(id) < len(IDLayout) {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation(IDLayout, id[:len(IDLayout)], time.Local)
+	return t, err == nil
+}
+
+func encodeSnapshot(snapshot Snapshot) ([]byte, error) {
+	return json.MarshalIndent(snapshot, "", "  ")
+}
+
+// decodeSnapshot 解析并校验清单；来自云端的清单同样经过这里，路径与块 ID 都不可信
+func decodeSnapshot(data []byte) (Snapshot, error) {
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return Snapshot{}, fmt.Errorf("解析快照清单失败: %w", err)
+	}
+	if snapshot.Version > FormatVersion {
+		return Snapshot{}, fmt.Errorf("快照格式版本 %d 高于当前支持的 %d，请升级 LunaBox", snapshot.Version, FormatVersion)
+	}
+	if !ValidID(snapshot.ID) {
+		return Snapshot{}, fmt.Errorf("快照 ID 无效: %q", snapshot.ID)
+	}
+	if snapshot.SingleFile && len(snapshot.Files) != 1 {
+		return Snapshot{}, fmt.Errorf("单文件快照应当恰好包含一个文件")
+	}
+	for _, file := range snapshot.Files {
+		if !validRelPath(file.Path) {
+			return Snapshot{}, fmt.Errorf("快照中的文件路径无效: %q", file.Path)
+		}
+		if file.Hash != "" && !blobIDPattern.MatchString(file.Hash) {
+			return Snapshot{}, fmt.Errorf("快照中的文件哈希无效: %q", file.Hash)
+		}
+		for _, id := range file.Blobs {
+			if !blobIDPattern.MatchString(id) {
+				return Snapshot{}, fmt.Errorf("快照中的块 ID 无效: %q", id)
+			}
+		}
+	}
+	for _, dir := range snapshot.Dirs {
+		if !validRelPath(dir) {
+			return Snapshot{}, fmt.Errorf("快照中的目录路径无效: %q", dir)
+		}
+	}
+	return snapshot, nil
+}
+
+func validRelPath(p string) bool {
+	if p == "" || path.IsAbs(p) {
+		return false
+	}
+	return path.Clean(p) == p && p != "." && p != ".." && !strings.HasPrefix(p, "../")
+}
@@ -0,0 +1,556 @@
+package savestore
+
+import (
+	"bytes"
+	"compress/flate"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	snapshotsDir = "snapshots"
+	blobsDir     = "blobs"
+	// BlobExt 块文件扩展名；云端迁移/重新加密按扩展名识别对象，块文件不能没有扩展名
+	BlobExt     = ".blob"
+	snapshotExt = ".json"
+	tempPrefix  = ".tmp-"
+)
+
+// Store 是单个游戏的本地存档快照仓库：
+//
+//	<root>/snapshots/<id>.json       快照清单
+//	<root>/blobs/<ab>/<sha256>.blob  按内容寻址、deflate 压缩的块
+//
+// 块 ID 是块原文的 SHA-256，相同内容在仓库中只存一份。
+// Store 本身不加锁，调用方需要串行化同一仓库上的写操作。
+type Store struct {
+	root string
+}
+
+// Open 返回位于 root 的仓库；目录在首次写入时创建
+func Open(root string) *Store {
+	return &Store{root: root}
+}
+
+// Root 返回仓库根目录
+func (s *Store) Root() string {
+	return s.root
+}
+
+// SnapshotPath 返回快照清单的本地路径
+func (s *Store) SnapshotPath(id string) string {
+	return filepath.Join(s.root, snapshotsDir, id+snapshotExt)
+}
+
+// IsSnapshotPath 判断 p 是否为本仓库中的快照清单路径，是则返回快照 ID
+func (s *Store) IsSnapshotPath(p string) (string, bool) {
+	if filepath.Dir(filepath.Clean(p)) != filepath.Join(s.root, snapshotsDir) {
+		return "", false
+	}
+	name := filepath.Base(p)
+	if !strings.HasSuffix(name, snapshotExt) {
+		return "", false
+	}
+	id := strings.TrimSuffix(name, snapshotExt)
+	return id, ValidID(id)
+}
+
+func (s *Store) blobPath(id string) string {
+	return filepath.Join(s.root, blobsDir, id[:2], id+BlobExt)
+}
+
+// HasBlob 判断块是否已在仓库中
+func (s *Store) HasBlob(id string) bool {
+	_, err := os.Stat(s.blobPath(id))
+	return err == nil
+}
+
+// NewID 基于时间生成未被占用的快照 ID
+func (s *Store) NewID(now time.Time) string {
+	base := now.Format(IDLayout)
+	id := base
+	for i := 2; ; i++ {
+		if _, err := os.Stat(s.SnapshotPath(id)); os.IsNotExist(err) {
+			return id
+		}
+		id = fmt.Sprintf("%s_%d", base, i)
+	}
+}
+
+// Create 对 source（文件或目录）做一次快照：只写入仓库中尚不存在的块，最后写清单。
+// 清单写入前失败时，已写入的块会在下一次 GC 中回收。
+func (s *Store) Create(gameID, source string, now time.Time) (Snapshot, error) {
+	info, err := os.Stat(source)
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("读取存档路径失败: %w", err)
+	}
+	snapshot := Snapshot{
+		Version:   FormatVersion,
+		ID:        s.NewID(now),
+		GameID:    gameID,
+		CreatedAt: now,
+		Source:    source,
+		Files:     []File{},
+	}
+
+	if !info.IsDir() {
+		snapshot.SingleFile = true
+		file, added, err := s.storeFile(source, filepath.Base(source), info)
+		if err != nil {
+			return Snapshot{}, err
+		}
+		snapshot.Files = append(snapshot.Files, file)
+		snapshot.Size, snapshot.AddedSize = file.Size, added
+	} else {
+		err = filepath.WalkDir(source, func(p string, d fs.DirEntry, walkErr error) error {
+			if walkErr != nil {
+				return walkErr
+			}
+			if p == source {
+				return nil
+			}
+			rel, err := filepath.Rel(source, p)
+			if err != nil {
+				return err
+			}
+			rel = filepath.ToSlash(rel)
+			if d.IsDir() {
+				empty, err := isEmptyDir(p)
+				if err != nil {
+					return err
+				}
+				if empty {
+					snapshot.Dirs = append(snapshot.Dirs, rel)
+				}
+				return nil
+			}
+			// 与 zip 备份一致，只备份普通文件，跳过符号链接等特殊文件
+			if !d.Type().IsRegular() {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			file, added, err := s.storeFile(p, rel, info)
+			if err != nil {
+				return err
+			}
+			snapshot.Files = append(snapshot.Files, file)
+			snapshot.Size += file.Size
+			snapshot.AddedSize += added
+			return nil
+		})
+		if err != nil {
+			return Snapshot{}, fmt.Errorf("备份存档失败: %w", err)
+		}
+	}
+
+	if err := s.Save(snapshot); err != nil {
+		return Snapshot{}, err
+	}
+	return snapshot, nil
+}
+
+// storeFile 分块写入单个文件，返回文件条目与新写入的字节数
+func (s *Store) storeFile(p, rel string, info os.FileInfo) (File, int64, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return File{}, 0, fmt.Errorf("打开文件失败: %w", err)
+	}
+	defer f.Close()
+
+	file := File{Path: rel, Mode: info.Mode().Perm(), ModTime: info.ModTime(), Blobs: []string{}}
+	var added int64
+	fileHash := sha256.New()
+	c := newChunker(f)
+	for {
+		chunk, err := c.next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return File{}, 0, fmt.Errorf("读取文件 %s 失败: %w", rel, err)
+		}
+		fileHash.Write(chunk)
+		sum := sha256.Sum256(chunk)
+		id := hex.EncodeToString(sum[:])
+		n, err := s.putBlob(id, chunk)
+		if err != nil {
+			return File{}, 0, err
+		}
+		file.Blobs = append(file.Blobs, id)
+		file.Size += int64(len(chunk))
+		added += n
+	}
+	file.Hash = hex.EncodeToString(fileHash.Sum(nil))
+	return file, added, nil
+}
+
+// putBlob 写入块（已存在则跳过），返回实际写入的字节数
+func (s *Store) putBlob(id string, chunk []byte) (int64, error) {
+	if s.HasBlob(id) {
+		return 0, nil
+	}
+	var buf bytes.Buffer
+	w, err := flate.NewWriter(&buf, flate.BestSpeed)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := w.Write(chunk); err != nil {
+		return 0, err
+	}
+	if err := w.Close(); err != nil {
+		return 0, err
+	}
+	if err := writeFileAtomic(s.blobPath(id), buf.Bytes()); err != nil {
+		return 0, fmt.Errorf("写入数据块失败: %w", err)
+	}
+	return int64(buf.Len()), nil
+}
+
+// readBlob 读取并校验块内容
+func (s *Store) readBlob(id string) ([]byte, error) {
+	f, err := os.Open(s.blobPath(id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("数据块缺失: %s", id)
+		}
+		return nil, fmt.Errorf("读取数据块失败: %w", err)
+	}
+	defer f.Close()
+	return inflateAndVerify(id, f)
+}
+
+// ImportBlob 把从别处（如云端）取得的压缩块放入仓库，校验内容与 ID 一致
+func (s *Store) ImportBlob(id, compressedPath string) error {
+	if !blobIDPattern.MatchString(id) {
+		return fmt.Errorf("数据块 ID 无效: %q", id)
+	}
+	f, err := os.Open(compressedPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := inflateAndVerify(id, f); err != nil {
+		return err
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.blobPath(id), data)
+}
+
+// BlobFile 返回块的本地文件路径，用于直接上传压缩后的块
+func (s *Store) BlobFile(id string) string {
+	return s.blobPath(id)
+}
+
+func inflateAndVerify(id string, r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(flate.NewReader(r), maxChunkSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("解压数据块 %s 失败: %w", id, err)
+	}
+	sum := sha256.Sum256(data)
+	if len(data) > maxChunkSize || hex.EncodeToString(sum[:]) != id {
+		return nil, fmt.Errorf("数据块 %s 校验失败，备份可能已损坏", id)
+	}
+	return data, nil
+}
+
+// Save 写入快照清单
+func (s *Store) Save(snapshot Snapshot) error {
+	data, err := encodeSnapshot(snapshot)
+	if err != nil {
+		return err
+	}
+	if err := writeFileAtomic(s.SnapshotPath(snapshot.ID), data); err != nil {
+		return fmt.Errorf("写入快照清单失败: %w", err)
+	}
+	return nil
+}
+
+// Load 读取快照清单
+func (s *Store) Load(id string) (Snapshot, error) {
+	if !ValidID(id) {
+		return Snapshot{}, fmt.Errorf("快照 ID 无效: %q", id)
+	}
+	data, err := os.ReadFile(s.SnapshotPath(id))
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("读取快照清单失败: %w", err)
+	}
+	return decodeSnapshot(data)
+}
+
+// List 返回仓库中的全部快照，按创建时间降序；无法解析的清单会被跳过
+func (s *Store) List() ([]Snapshot, error) {
+	entries, err := os.ReadDir(filepath.Join(s.root, snapshotsDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Snapshot{}, nil
+		}
+		return nil, err
+	}
+	snapshots := make([]Snapshot, 0, len(entries))
+	for _, entry := range entries {
+		id, ok := s.IsSnapshotPath(filepath.Join(s.root, snapshotsDir, entry.Name()))
+		if entry.IsDir() || !ok {
+			continue
+		}
+		snapshot, err := s.Load(id)
+		if err != nil {
+			continue
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].CreatedAt.After(snapshots[j].CreatedAt)
+	})
+	return snapshots, nil
+}
+
+// Delete 删除快照清单；块由 GC 统一回收
+func (s *Store) Delete(id string) error {
+	if !ValidID(id) {
+		return fmt.Errorf("快照 ID 无效: %q", id)
+	}
+	if err := os.Remove(s.SnapshotPath(id)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("删除快照失败: %w", err)
+	}
+	return nil
+}
+
+// GCResult 是一次垃圾回收的统计
+type GCResult struct {
+	RemovedBlobs int
+	FreedBytes   int64
+}
+
+// GC 删除不再被任何快照引用的块。
+// 有清单无法解析时放弃回收：宁可多占空间，也不能删掉它引用的块。
+func (s *Store) GC() (GCResult, error) {
+	var result GCResult
+	referenced := make(map[string]struct{})
+	entries, err := os.ReadDir(filepath.Join(s.root, snapshotsDir))
+	if err != nil && !os.IsNotExist(err) {
+		return result, err
+	}
+	for _, entry := range entries {
+		id, ok := s.IsSnapshotPath(filepath.Join(s.root, snapshotsDir, entry.Name()))
+		if entry.IsDir() || !ok {
+			continue
+		}
+		snapshot, err := s.Load(id)
+		if err != nil {
+			return result, fmt.Errorf("快照 %s 无法解析，跳过数据块回收: %w", id, err)
+		}
+		for _, blob := range snapshot.BlobIDs() {
+			referenced[blob] = struct{}{}
+		}
+	}
+
+	err = filepath.WalkDir(filepath.Join(s.root, blobsDir), func(p string, d fs.DirEntry, walkErr error) error {
+		if walkErr != nil {
+			if os.IsNotExist(walkErr) {
+				return nil
+			}
+			return walkErr
+		}
+		if d.IsDir() {
+			return nil
+		}
+		id := strings.TrimSuffix(d.Name(), BlobExt)
+		if _, keep := referenced[id]; keep {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if err := os.Remove(p); err != nil {
+			return err
+		}
+		result.RemovedBlobs++
+		result.FreedBytes += info.Size()
+		return nil
+	})
+	return result, err
+}
+
+// Restore 把快照完整还原到 target：目录快照还原为 target 目录下的内容，单文件快照写为 target 文件。
+// 每个块读取时都会校验，任何块缺失或损坏都会返回错误。
+func (s *Store) Restore(snapshot Snapshot, target string) error {
+	if snapshot.SingleFile {
+		return s.restoreFile(snapshot.Files[0], target)
+	}
+	if err := os.MkdirAll(target, 0o755); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+	for _, dir := range snapshot.Dirs {
+		p, err := joinWithin(target, dir)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(p, 0o755); err != nil {
+			return fmt.Errorf("创建目录失败: %w", err)
+		}
+	}
+	for _, file := range snapshot.Files {
+		p, err := joinWithin(target, file.Path)
+		if err != nil {
+			return err
+		}
+		if err := s.restoreFile(file, p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RestoreFiles 只把快照中的部分文件按相对路径还原到 target 目录下（单文件快照同样写为 target/文件名）。
+// paths 中有快照不包含的文件时不写入任何内容。
+func (s *Store) RestoreFiles(snapshot Snapshot, target string, paths []string) error {
+	byPath := make(map[string]File, len(snapshot.Files))
+	for _, file := range snapshot.Files {
+		byPath[file.Path] = file
+	}
+	selected := make([]File, 0, len(paths))
+	for _, p := range paths {
+		file, ok := byPath[p]
+		if !ok {
+			return fmt.Errorf("快照中不存在文件: %s", p)
+		}
+		selected = append(selected, file)
+	}
+	for _, file := range selected {
+		dest, err := joinWithin(target, file.Path)
+		if err != nil {
+			return err
+		}
+		if err := s.restoreFile(file, dest); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FileHash 返回快照中文件内容的 SHA-256；旧清单没有记录时从块重新计算
+func (s *Store) FileHash(file File) (string, error) {
+	if file.Hash != "" {
+		return file.Hash, nil
+	}
+	h := sha256.New()
+	for _, id := range file.Blobs {
+		data, err := s.readBlob(id)
+		if err != nil {
+			return "", err
+		}
+		h.Write(data)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (s *Store) restoreFile(file File, target string) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return fmt.Errorf("创建目录失败: %w", err)
+	}
+	mode := file.Mode.Perm()
+	if mode == 0 {
+		mode = 0o644
+	}
+	out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, mode|0o200)
+	if err != nil {
+		return fmt.Errorf("创建文件失败: %w", err)
+	}
+	for _, id := range file.Blobs {
+		data, err := s.readBlob(id)
+		if err != nil {
+			out.Close()
+			return err
+		}
+		if _, err := out.Write(data); err != nil {
+			out.Close()
+			return fmt.Errorf("写入文件失败: %w", err)
+		}
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("写入文件失败: %w", err)
+	}
+	if !file.ModTime.IsZero() {
+		_ = os.Chtimes(target, file.ModTime, file.ModTime)
+	}
+	return nil
+}
+
+// MissingBlobs 返回快照引用但仓库中不存在的块
+func (s *Store) MissingBlobs(snapshot Snapshot) []string {
+	var missing []string
+	for _, id := range snapshot.BlobIDs() {
+		if !s.HasBlob(id) {
+			missing = append(missing, id)
+		}
+	}
+	return missing
+}
+
+func joinWithin(base, rel string) (string, error) {
+	p := filepath.Join(base, filepath.FromSlash(rel))
+	r, err := filepath.Rel(base, p)
+	if err != nil || r == "." || r == ".." || strings.HasPrefix(r, ".."+string(filepath.Separator)) || filepath.IsAbs(r) {
+		return "", fmt.Errorf("快照中的路径越出还原目录: %s", rel)
+	}
+	return p, nil
+}
+
+func isEmptyDir(p string) (bool, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	_, err = f.Readdirnames(1)
+	if errors.Is(err, io.EOF) {
+		return true, nil
+	}
+	return false, err
+}
+
+// writeFileAtomic 先写同目录临时文件再 rename，避免中断后留下半截的块或清单
+func writeFileAtomic(p string, data []byte) error {
+	dir := filepath.Dir(p)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, tempPrefix+"*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, p); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,225 @@
+package savestore
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"lunabox/internal/service/cloudprovider/local"
+)
+
+func writeFile(t *testing.T, p string, data []byte) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func randomBytes(seed int64, n int) []byte {
+	data := make([]byte, n)
+	rand.New(rand.NewSource(seed)).Read(data)
+	return data
+}
+
+func countBlobs(t *testing.T, store *Store) int {
+	t.Helper()
+	n := 0
+	filepath.WalkDir(filepath.Join(store.Root(), blobsDir), func(p string, d os.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			n++
+		}
+		return nil
+	})
+	return n
+}
+
+func TestStoreDeduplicatesAndRestoresSnapshots(t *testing.T) {
+	save := t.TempDir()
+	big := randomBytes(1, 12<<20)
+	writeFile(t, filepath.Join(save, "slot1.sav"), big)
+	writeFile(t, filepath.Join(save, "config", "options.ini"), []byte("volume=80"))
+	if err := os.MkdirAll(filepath.Join(save, "screenshots"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	store := Open(t.TempDir())
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.Local)
+	first, err := store.Create("game", save, start)
+	if err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	blobsAfterFirst := countBlobs(t, store)
+
+	// 未变化的存档不应写入任何新块
+	second, err := store.Create("game", save, start)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.ID == first.ID {
+		t.Fatalf("snapshot IDs collide: %s", second.ID)
+	}
+	if second.AddedSize != 0 || countBlobs(t, store) != blobsAfterFirst {
+		t.Fatalf("unchanged save added %d bytes", second.AddedSize)
+	}
+
+	// 在大文件中间插入数据：只有附近的块会变化
+	edited := append(append(append([]byte(nil), big[:5<<20]...), []byte("new quest flag")...), big[5<<20:]...)
+	writeFile(t, filepath.Join(save, "slot1.sav"), edited)
+	third, err := store.Create("game", save, start.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added := countBlobs(t, store) - blobsAfterFirst; added == 0 || added > 3 {
+		t.Fatalf("insert in a 12MiB file added %d blobs, want 1-3", added)
+	}
+	if third.AddedSize >= int64(len(big))/2 {
+		t.Fatalf("insert stored %d new bytes, expected far less than the file size", third.AddedSize)
+	}
+
+	restored := filepath.Join(t.TempDir(), "restore")
+	loaded, err := store.Load(first.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Restore(loaded, restored); err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(restored, "slot1.sav")); !bytes.Equal(data, big) {
+		t.Fatal("restored first snapshot does not match the original save")
+	}
+	if data, _ := os.ReadFile(filepath.Join(restored, "config", "options.ini")); string(data) != "volume=80" {
+		t.Fatalf("options.ini = %q", data)
+	}
+	if info, err := os.Stat(filepath.Join(restored, "screenshots")); err != nil || !info.IsDir() {
+		t.Fatal("empty directory not restored")
+	}
+
+	// 删除旧快照后 GC 只回收不再被引用的块
+	for _, id := range []string{first.ID, second.ID} {
+		if err := store.Delete(id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	result, err := store.GC()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.RemovedBlobs == 0 {
+		t.Fatal("GC removed nothing after pruning snapshots")
+	}
+	latest, _ := store.Load(third.ID)
+	if missing := store.MissingBlobs(latest); len(missing) != 0 {
+		t.Fatalf("GC removed blobs still referenced: %v", missing)
+	}
+	restored = filepath.Join(t.TempDir(), "restore")
+	if err := store.Restore(latest, restored); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(restored, "slot1.sav")); !bytes.Equal(data, edited) {
+		t.Fatal("restored latest snapshot does not match the edited save")
+	}
+}
+
+func TestStoreRejectsCorruptBlobsAndUnsafePaths(t *testing.T) {
+	save := filepath.Join(t.TempDir(), "save.dat")
+	writeFile(t, save, []byte("single file save"))
+	store := Open(t.TempDir())
+	snapshot, err := store.Create("game", save, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !snapshot.SingleFile {
+		t.Fatal("file save should produce a single-file snapshot")
+	}
+	target := filepath.Join(t.TempDir(), "restored.dat")
+	if err := store.Restore(snapshot, target); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(target); string(data) != "single file save" {
+		t.Fatalf("restored = %q", data)
+	}
+
+	if err := os.WriteFile(store.BlobFile(snapshot.Files[0].Blobs[0]), []byte("garbage"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Restore(snapshot, target); err == nil {
+		t.Fatal("restoring a corrupt blob should fail")
+	}
+
+	for _, p := range []string{"../escape", "/abs", "a/../../b"} {
+		bad := []byte(`{"version":1,"id":"x","files":[{"path":"` + p + `","blobs":[]}]}`)
+		if _, err := decodeSnapshot(bad); err == nil {
+			t.Errorf("path %q should be rejected", p)
+		}
+	}
+}
+
+func TestRemotePushFetchAndGC(t *testing.T) {
+	ctx := context.Background()
+	provider, err := local.NewProvider(local.Config{Path: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	remote := NewRemote(provider, provider.GetCloudPath("user", "saves/game/"))
+
+	save := t.TempDir()
+	writeFile(t, filepath.Join(save, "a.sav"), randomBytes(2, 3<<20))
+	deviceA := Open(t.TempDir())
+	first, err := deviceA.Create("game", save, time.Now().Add(-time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := remote.Push(ctx, deviceA, first); err != nil {
+		t.Fatalf("Push failed: %v", err)
+	}
+	writeFile(t, filepath.Join(save, "b.sav"), []byte("second slot"))
+	second, err := deviceA.Create("game", save, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	uploaded, err := remote.Push(ctx, deviceA, second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if uploaded != 1 {
+		t.Fatalf("second push uploaded %d blobs, want only the new file's blob", uploaded)
+	}
+
+	keys, err := remote.ListSnapshots(ctx)
+	if err != nil || len(keys) != 2 {
+		t.Fatalf("snapshots = %v, %v", keys, err)
+	}
+	deviceB := Open(t.TempDir())
+	fetched, err := remote.Fetch(ctx, remote.SnapshotKey(second.ID), deviceB)
+	if err != nil {
+		t.Fatalf("Fetch failed: %v", err)
+	}
+	restored := filepath.Join(t.TempDir(), "restore")
+	if err := deviceB.Restore(fetched, restored); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(restored, "b.sav")); string(data) != "second slot" {
+		t.Fatalf("b.sav = %q", data)
+	}
+
+	if err := remote.Delete(ctx, remote.SnapshotKey(second.ID)); err != nil {
+		t.Fatal(err)
+	}
+	removed, err := remote.GC(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 1 {
+		t.Fatalf("GC removed %d blobs, want 1", removed)
+	}
+	if _, err := remote.Fetch(ctx, remote.SnapshotKey(first.ID), Open(t.TempDir())); err != nil {
+		t.Fatalf("remaining snapshot unrestorable after GC: %v", err)
+	}
+}
@@ -40,6 +40,7 @@ func setupSessionServiceTestDB(t *testing.T) *sql.DB {
 			parent_id TEXT DEFAULT '',
 			secondary_id TEXT DEFAULT '',
 			deleted_at TIMESTAMPTZ NOT NULL,
+			published_at TIMESTAMPTZ,
 			PRIMARY KEY (entity_type, entity_id, parent_id, secondary_id)
 		)`,
 	}
@@ -12,6 +12,7 @@ import (
 	"lunabox/internal/service/cloudprovider"
 	"lunabox/internal/service/gamehelper"
 	launcherpkg "lunabox/internal/service/launcher"
+	"lunabox/internal/service/savedetect"
 	"lunabox/internal/utils/audioutils"
 	"lunabox/internal/utils/processutils"
 	"lunabox/internal/utils/timerutils"
@@ -100,6 +101,8 @@ type activePlaySession struct {
 	game      models.Game
 	done      chan struct{}
 	finalOnce sync.Once
+	// saveWatch 监听未设置存档路径的游戏在本次游玩中的存档写入，为 nil 时不监听
+	saveWatch *savedetect.SessionWatch
 	// activeSeconds 由活跃窗口计时回调更新，供 15 秒心跳持久化读取。
 	activeSeconds   atomic.Int64
 	audioMu         sync.Mutex
@@ -337,7 +340,13 @@ func (s *StartService) startGame(gameID string, options launcherpkg.LaunchOption
 		return false, fmt.Errorf("failed to create play session: %w", err)
 	}
 
-	session := s.registerActiveSession(sessionID, gameID, startTime, game)
+	// 未设置存档路径时，从启动起监听存档根目录的写入，游玩结束后推测存档位置供用户确认
+	var saveWatch *savedetect.SessionWatch
+	if game.SavePath == "" && s.backupService != nil {
+		saveWatch = s.backupService.StartSavePathWatch(gameID, startTime)
+	}
+
+	session := s.registerActiveSession(sessionID, gameID, startTime, game, saveWatch)
 	s.emitGameRuntimeChanged(GameRuntimeChangedEvent{
 		GameID:    gameID,
 		Game:      &game,
@@ -696,6 +705,12 @@ func (s *StartService) finalizePlaySessionOnce(session *activePlaySession, reaso
 	s.unregisterActiveSession(gameID, sessionID)
 	s.restoreSessionAudio(session)
 
+	// 存档写入监听在任何情况下都要停止；只有成功记录的会话才把观察结果作为候选存档路径
+	recordSaveWatch := false
+	defer func() {
+		s.stopSaveWatch(session, recordSaveWatch)
+	}()
+
 	// 确保停止追踪（无论如何都要执行）
 	activeSeconds := s.activeTimeTracker.StopTracking(gameID)
 
@@ -754,12 +769,29 @@ func (s *StartService) finalizePlaySessionOnce(session *activePlaySession, reaso
 	s.emitGameRuntimeIdle(session, "session-finalized")
 	s.requestHomeRefresh()
 
+	// 根据本次游玩期间观察到的存档写入推测存档位置
+	recordSaveWatch = true
+
 	// 自动备份游戏存档
 	if s.config.AutoBackupGameSave && s.backupService != nil {
 		s.autoBackupGameSave(gameID)
 	}
 }
 
+// stopSaveWatch 在后台停止会话的存档写入监听（最后一次轮询可能较慢），record 为 true 时记录候选存档路径
+func (s *StartService) stopSaveWatch(session *activePlaySession, record bool) {
+	if session.saveWatch == nil {
+		return
+	}
+	go func() {
+		if record {
+			s.backupService.RecordSavePathSession(session.gameID, session.saveWatch)
+			return
+		}
+		session.saveWatch.Stop()
+	}()
+}
+
 // EndCurrentPlaySession manually ends LunaBox tracking for the active game.
 // It does not terminate the external game process; it finalizes the current
 // play session and stops monitoring so later process exit cannot write twice.
@@ -778,13 +810,14 @@ func (s *StartService) EndCurrentPlaySession(gameID string) error {
 	return nil
 }
 
-func (s *StartService) registerActiveSession(sessionID string, gameID string, startTime time.Time, game models.Game) *activePlaySession {
+func (s *StartService) registerActiveSession(sessionID string, gameID string, startTime time.Time, game models.Game, saveWatch *savedetect.SessionWatch) *activePlaySession {
 	session := &activePlaySession{
 		sessionID: sessionID,
 		gameID:    gameID,
 		startTime: startTime,
 		game:      game,
 		done:      make(chan struct{}),
+		saveWatch: saveWatch,
 	}
 
 	s.activeSessionsMu.Lock()
@@ -858,6 +891,7 @@ func (s *StartService) deleteShortOrCancelledSession(session *activePlaySession,
 			applog.LogErrorf(s.ctx, "Failed to delete cancelled play session %s: %v", session.sessionID, err)
 		}
 		s.restoreSessionAudio(session)
+		s.stopSaveWatch(session, false)
 		s.activeTimeTracker.StopTracking(session.gameID)
 		s.emitGameRuntimeIdle(session, reason)
 		s.requestHomeRefresh()
@@ -1061,6 +1095,7 @@ func (s *StartService) CleanupPendingSessions() {
 			session.finalOnce.Do(func() {
 				close(session.done)
 				s.unregisterActiveSession(session.gameID, session.sessionID)
+				s.stopSaveWatch(session, false)
 				if err := s.sessionService.completeUnfinishedSessionWithDuration(session.sessionID, endTime, duration); err != nil {
 					applog.LogErrorf(s.ctx, "Failed to complete active session %s during shutdown: %v", session.sessionID, err)
 				}
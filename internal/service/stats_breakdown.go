@@ -0,0 +1,206 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"lunabox/internal/common/enums"
+	"lunabox/internal/common/vo"
+)
+
+const statsDateLayout = "2006-01-02"
+
+// parseStatsDateRange 校验自定义日期范围；日期会直接拼入 SQL，必须先解析为合法日期
+func parseStatsDateRange(startDate, endDate string) (time.Time, time.Time, error) {
+	if startDate == "" || endDate == "" {
+		return time.Time{}, time.Time{}, fmt.Errorf("自定义日期范围需要同时提供开始和结束日期")
+	}
+	start, err := time.ParseInLocation(statsDateLayout, startDate, time.Local)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("无效的开始日期: %s", startDate)
+	}
+	end, err := time.ParseInLocation(statsDateLayout, endDate, time.Local)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("无效的结束日期: %s", endDate)
+	}
+	if end.Before(start) {
+		return time.Time{}, time.Time{}, fmt.Errorf("结束日期不能早于开始日期")
+	}
+	return start, end, nil
+}
+
+// previousPeriodRequest 返回紧邻 [startDate, endDate] 之前的等长周期。
+// 范围恰好是整月（如一个季度）时按月份平移，避免各月天数不同导致错位；否则按天数平移。
+func previousPeriodRequest(dimension enums.Period, startDate, endDate string) (vo.PeriodStatsRequest, error) {
+	start, end, err := parseStatsDateRange(startDate, endDate)
+	if err != nil {
+		return vo.PeriodStatsRequest{}, err
+	}
+
+	previousEnd := start.AddDate(0, 0, -1)
+	var previousStart time.Time
+	if start.Day() == 1 && end.AddDate(0, 0, 1).Day() == 1 {
+		months := (end.Year()-start.Year())*12 + int(end.Month()-start.Month()) + 1
+		previousStart = start.AddDate(0, -months, 0)
+	} else {
+		days := int(end.Sub(start).Hours()/24+0.5) + 1
+		previousStart = start.AddDate(0, 0, -days)
+	}
+	return vo.PeriodStatsRequest{
+		Dimension: dimension,
+		StartDate: previousStart.Format(statsDateLayout),
+		EndDate:   previousEnd.Format(statsDateLayout),
+	}, nil
+}
+
+func (s *StatsService) queryPeriodBreakdowns(startDateExpr, endDateExpr string) (vo.PeriodBreakdowns, error) {
+	rangeFilter := fmt.Sprintf("ps.start_time >= %s AND ps.start_time <= %s + INTERVAL 1 DAY", startDateExpr, endDateExpr)
+	var breakdowns vo.PeriodBreakdowns
+	queries := []struct {
+		target *[]vo.PeriodBreakdownItem
+		query  string
+	}{
+		{target: &breakdowns.Category, query: `
+			SELECT COALESCE(c.id, ''), COALESCE(c.name, ''), SUM(ps.duration), COUNT(*), COUNT(DISTINCT ps.game_id)
+			FROM play_sessions ps
+			JOIN games g ON g.id = ps.game_id
+			LEFT JOIN game_categories gc ON gc.game_id = ps.game_id
+			LEFT JOIN categories c ON c.id = gc.category_id
+			WHERE ` + rangeFilter + `
+			GROUP BY 1, 2`},
+		{target: &breakdowns.Tag, query: `
+			SELECT gt.name, gt.name, SUM(ps.duration), COUNT(*), COUNT(DISTINCT ps.game_id)
+			FROM play_sessions ps
+			JOIN (
+				SELECT DISTINCT game_id, name FROM game_tags
+				WHERE COALESCE(is_spoiler, FALSE) = FALSE AND name IS NOT NULL AND name <> ''
+			) gt ON gt.game_id = ps.game_id
+			WHERE ` + rangeFilter + `
+			GROUP BY 1, 2`},
+		{target: &breakdowns.Company, query: `
+			SELECT TRIM(COALESCE(g.company, '')), TRIM(COALESCE(g.company, '')), SUM(ps.duration), COUNT(*), COUNT(DISTINCT ps.game_id)
+			FROM play_sessions ps
+			JOIN games g ON g.id = ps.game_id
+			WHERE ` + rangeFilter + `
+			GROUP BY 1, 2`},
+		{target: &breakdowns.LaunchMode, query: `
+			SELECT COALESCE(NULLIF(g.launch_mode, ''), 'normal'), COALESCE(NULLIF(g.launch_mode, ''), 'normal'), SUM(ps.duration), COUNT(*), COUNT(DISTINCT ps.game_id)
+			FROM play_sessions ps
+			JOIN games g ON g.id = ps.game_id
+			WHERE ` + rangeFilter + `
+			GROUP BY 1, 2`},
+	}
+
+	for _, item := range queries {
+		rows, err := s.db.QueryContext(s.ctx, item.query+` HAVING SUM(ps.duration) > 0 ORDER BY 3 DESC, 2`)
+		if err != nil {
+			return breakdowns, err
+		}
+		result := make([]vo.PeriodBreakdownItem, 0)
+		for rows.Next() {
+			var row vo.PeriodBreakdownItem
+			if err := rows.Scan(&row.Key, &row.Name, &row.TotalDuration, &row.PlayCount, &row.GameCount); err != nil {
+				rows.Close()
+				return breakdowns, err
+			}
+			result = append(result, row)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return breakdowns, err
+		}
+		*item.target = result
+	}
+	return breakdowns, nil
+}
+
+// comparePeriodStats 计算本期相对上一期的差值
+func comparePeriodStats(current, previous vo.PeriodStats) vo.PeriodStatsDelta {
+	gamePlayKey := func(item vo.GamePlayStats) (string, string, int) {
+		return item.GameID, item.GameName, item.TotalDuration
+	}
+	timePointKey := func(item vo.TimePoint) (string, string, int) { return item.Label, item.Label, item.Duration }
+	tagKey := func(item vo.TagPlayStats) (string, string, int) { return item.Name, item.Name, item.TotalDuration }
+	heatmapKey := func(item vo.HeatmapCell) (string, string, int) { return item.Date, item.Date, item.Duration }
+	hourKey := func(item vo.HourPlayPoint) (string, string, int) {
+		return strconv.Itoa(item.Hour), strconv.Itoa(item.Hour), item.Duration
+	}
+	weekdayKey := func(item vo.WeekdayPlayPoint) (string, string, int) {
+		return strconv.Itoa(item.Weekday), strconv.Itoa(item.Weekday), item.Duration
+	}
+	breakdownKey := func(item vo.PeriodBreakdownItem) (string, string, int) {
+		return item.Key, item.Name, item.TotalDuration
+	}
+
+	return vo.PeriodStatsDelta{
+		PreviousStartDate:      previous.StartDate,
+		PreviousEndDate:        previous.EndDate,
+		TotalPlayCount:         current.TotalPlayCount - previous.TotalPlayCount,
+		TotalPlayDuration:      current.TotalPlayDuration - previous.TotalPlayDuration,
+		TotalGamesCount:        current.TotalGamesCount - previous.TotalGamesCount,
+		CompletedGamesCount:    current.CompletedGamesCount - previous.CompletedGamesCount,
+		LibraryGamesCount:      current.LibraryGamesCount - previous.LibraryGamesCount,
+		AllSessionsCount:       current.AllSessionsCount - previous.AllSessionsCount,
+		AllSessionsDuration:    current.AllSessionsDuration - previous.AllSessionsDuration,
+		AllCompletedGamesCount: current.AllCompletedGamesCount - previous.AllCompletedGamesCount,
+		ActiveDays:             current.ActiveDays - previous.ActiveDays,
+		AvgDailyDuration:       current.AvgDailyDuration - previous.AvgDailyDuration,
+		AvgSessionDuration:     current.AvgSessionDuration - previous.AvgSessionDuration,
+		MaxStreak:              current.MaxStreak - previous.MaxStreak,
+		CurrentStreak:          current.CurrentStreak - previous.CurrentStreak,
+		NewGamesCount:          current.NewGamesCount - previous.NewGamesCount,
+		PlayTimeLeaderboard:    keyedStatsDeltas(current.PlayTimeLeaderboard, previous.PlayTimeLeaderboard, gamePlayKey),
+		Timeline:               positionalStatsDeltas(current.Timeline, previous.Timeline, timePointKey),
+		TagDistribution:        keyedStatsDeltas(current.TagDistribution, previous.TagDistribution, tagKey),
+		Heatmap:                positionalStatsDeltas(current.Heatmap, previous.Heatmap, heatmapKey),
+		HourlyDistribution:     keyedStatsDeltas(current.HourlyDistribution, previous.HourlyDistribution, hourKey),
+		WeekdayDistribution:    keyedStatsDeltas(current.WeekdayDistribution, previous.WeekdayDistribution, weekdayKey),
+		Breakdowns: vo.PeriodBreakdownDeltas{
+			Category:   keyedStatsDeltas(current.Breakdowns.Category, previous.Breakdowns.Category, breakdownKey),
+			Tag:        keyedStatsDeltas(current.Breakdowns.Tag, previous.Breakdowns.Tag, breakdownKey),
+			Company:    keyedStatsDeltas(current.Breakdowns.Company, previous.Breakdowns.Company, breakdownKey),
+			LaunchMode: keyedStatsDeltas(current.Breakdowns.LaunchMode, previous.Breakdowns.LaunchMode, breakdownKey),
+		},
+	}
+}
+
+// keyedStatsDeltas 按 key 对齐两期数据：先按本期顺序，再追加只在上一期出现的分组
+func keyedStatsDeltas[T any](current, previous []T, key func(T) (string, string, int)) []vo.StatsDelta {
+	result := make([]vo.StatsDelta, 0, len(current))
+	index := make(map[string]int, len(current))
+	for _, item := range current {
+		k, name, value := key(item)
+		index[k] = len(result)
+		result = append(result, vo.StatsDelta{Key: k, Name: name, Current: value})
+	}
+	for _, item := range previous {
+		k, name, value := key(item)
+		if i, ok := index[k]; ok {
+			result[i].Previous = value
+			continue
+		}
+		index[k] = len(result)
+		result = append(result, vo.StatsDelta{Key: k, Name: name, Previous: value})
+	}
+	for i := range result {
+		result[i].Delta = result[i].Current - result[i].Previous
+	}
+	return result
+}
+
+// positionalStatsDeltas 按周期内的位置对齐两期数据，Key 使用本期标签
+func positionalStatsDeltas[T any](current, previous []T, key func(T) (string, string, int)) []vo.StatsDelta {
+	result := make([]vo.StatsDelta, 0, len(current))
+	for i, item := range current {
+		k, name, value := key(item)
+		delta := vo.StatsDelta{Key: k, Name: name, Current: value}
+		if i < len(previous) {
+			_, _, delta.Previous = key(previous[i])
+		}
+		delta.Delta = delta.Current - delta.Previous
+		result = append(result, delta)
+	}
+	return result
+}
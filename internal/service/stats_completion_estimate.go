@@ -0,0 +1,347 @@
+package service
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"lunabox/internal/applog"
+	"lunabox/internal/common/enums"
+	"lunabox/internal/common/vo"
+)
+
+const (
+	completionSimilarGamesLimit = 10
+
+	completionBasisSimilar         = "similar"
+	completionBasisMetadata        = "metadata"
+	completionBasisSimilarMetadata = "similar+metadata"
+	completionBasisLibrary         = "library"
+	completionBasisNone            = "none"
+)
+
+type completionGame struct {
+	id         string
+	name       string
+	company    string
+	status     enums.GameStatus
+	played     int
+	lastPlayed sql.NullTime
+	createdAt  sql.NullTime
+	tags       map[string]struct{}
+	length     int // 元数据时长（分钟）
+}
+
+// GetCompletionForecast 预估在玩 / 想玩游戏的剩余通关时长，并按所选周期的日均游玩时长预测积压何时清空。
+// 预估优先参考标签、厂商相近的已通关游戏的实际游玩时长；开启 UseMetadataLength 时
+// 还会参考 VNDB 的平均游玩时长，并按自己已通关游戏的实际时长与 VNDB 时长之比校准。
+func (s *StatsService) GetCompletionForecast(req vo.CompletionForecastRequest) (vo.CompletionForecast, error) {
+	if req.Dimension == "" {
+		req.Dimension = enums.Month
+	}
+	forecast := vo.CompletionForecast{
+		PaceDimension: req.Dimension,
+		EstimatedDays: -1,
+		Games:         make([]vo.GameCompletionEstimate, 0),
+	}
+
+	pace, err := s.buildPeriodStats(vo.PeriodStatsRequest{Dimension: req.Dimension})
+	if err != nil {
+		return forecast, fmt.Errorf("统计游玩节奏失败: %w", err)
+	}
+	forecast.PaceStartDate = pace.StartDate
+	forecast.PaceEndDate = pace.EndDate
+	forecast.AvgDailyDuration = pace.AvgDailyDuration
+
+	games, err := s.loadCompletionGames()
+	if err != nil {
+		return forecast, err
+	}
+	if req.UseMetadataLength {
+		s.fillCompletionMetadataLengths(games)
+	}
+
+	var completed, pending []*completionGame
+	for _, game := range games {
+		switch game.status {
+		case enums.StatusCompleted:
+			if game.played > 0 {
+				completed = append(completed, game)
+			}
+		case enums.StatusPlaying, enums.StatusWantToPlay:
+			pending = append(pending, game)
+		}
+	}
+	sortCompletionBacklog(pending)
+
+	libraryMedian := completionLibraryMedian(completed)
+	paceFactor := completionMetadataPaceFactor(completed)
+	today := time.Now()
+	cumulative := 0
+	for _, game := range pending {
+		estimate := estimateGameCompletion(game, completed, libraryMedian, paceFactor)
+		if estimate.Basis == completionBasisNone {
+			forecast.UnestimatedGamesCount++
+		} else {
+			forecast.EstimatedGamesCount++
+			forecast.TotalRemainingDuration += estimate.RemainingDuration
+			cumulative += estimate.RemainingDuration
+			if forecast.AvgDailyDuration > 0 {
+				estimate.ProjectedFinishDate = projectCompletionDate(today, cumulative, forecast.AvgDailyDuration)
+			}
+		}
+		forecast.Games = append(forecast.Games, estimate)
+	}
+
+	if forecast.AvgDailyDuration > 0 {
+		forecast.EstimatedDays = int(math.Ceil(float64(forecast.TotalRemainingDuration) / float64(forecast.AvgDailyDuration)))
+		forecast.EstimatedClearDate = projectCompletionDate(today, forecast.TotalRemainingDuration, forecast.AvgDailyDuration)
+	}
+	return forecast, nil
+}
+
+func (s *StatsService) loadCompletionGames() (map[string]*completionGame, error) {
+	statuses := []any{enums.StatusCompleted, enums.StatusPlaying, enums.StatusWantToPlay}
+	rows, err := s.db.QueryContext(s.ctx, `
+		SELECT g.id, COALESCE(g.name, ''), TRIM(COALESCE(g.company, '')), g.status,
+			COALESCE(SUM(ps.duration), 0), MAX(ps.start_time), g.created_at
+		FROM games g
+		LEFT JOIN play_sessions ps ON ps.game_id = g.id
+		WHERE g.status IN (?, ?, ?)
+		GROUP BY g.id, g.name, g.company, g.status, g.created_at
+	`, statuses...)
+	if err != nil {
+		return nil, fmt.Errorf("查询游戏游玩时长失败: %w", err)
+	}
+	defer rows.Close()
+
+	games := make(map[string]*completionGame)
+	for rows.Next() {
+		game := &completionGame{tags: make(map[string]struct{})}
+		if err := rows.Scan(&game.id, &game.name, &game.company, &game.status, &game.played, &game.lastPlayed, &game.createdAt); err != nil {
+			return nil, fmt.Errorf("读取游戏游玩时长失败: %w", err)
+		}
+		games[game.id] = game
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取游戏游玩时长失败: %w", err)
+	}
+
+	tagRows, err := s.db.QueryContext(s.ctx, `
+		SELECT DISTINCT gt.game_id, gt.name
+		FROM game_tags gt
+		JOIN games g ON g.id = gt.game_id
+		WHERE g.status IN (?, ?, ?) AND gt.name IS NOT NULL AND gt.name <> ''
+	`, statuses...)
+	if err != nil {
+		return nil, fmt.Errorf("查询游戏标签失败: %w", err)
+	}
+	defer tagRows.Close()
+	for tagRows.Next() {
+		var gameID, name string
+		if err := tagRows.Scan(&gameID, &name); err != nil {
+			return nil, fmt.Errorf("读取游戏标签失败: %w", err)
+		}
+		if game, ok := games[gameID]; ok {
+			game.tags[strings.ToLower(strings.TrimSpace(name))] = struct{}{}
+		}
+	}
+	if err := tagRows.Err(); err != nil {
+		return nil, fmt.Errorf("读取游戏标签失败: %w", err)
+	}
+	return games, nil
+}
+
+// fillCompletionMetadataLengths 读取关联了 VNDB 的游戏的平均游玩时长；失败时仅记录日志，不影响基于本地数据的预估
+func (s *StatsService) fillCompletionMetadataLengths(games map[string]*completionGame) {
+	if s.vndbService == nil || len(games) == 0 {
+		return
+	}
+	rows, err := s.db.QueryContext(s.ctx, `
+		SELECT id, source_id FROM games
+		WHERE source_type = ? AND COALESCE(source_id, '') <> ''
+		UNION
+		SELECT game_id, source_id FROM game_metadata_sources
+		WHERE source_type = ? AND COALESCE(source_id, '') <> ''
+	`, enums.VNDB, enums.VNDB)
+	if err != nil {
+		applog.LogWarningf(s.ctx, "failed to query VNDB ids for completion forecast: %v", err)
+		return
+	}
+	defer rows.Close()
+
+	vnIDsByGame := make(map[string]string)
+	var vnIDs []string
+	for rows.Next() {
+		var gameID, sourceID string
+		if err := rows.Scan(&gameID, &sourceID); err != nil {
+			applog.LogWarningf(s.ctx, "failed to read VNDB ids for completion forecast: %v", err)
+			return
+		}
+		if _, ok := games[gameID]; !ok {
+			continue
+		}
+		vnID := normalizeVNDBID(sourceID)
+		if vnID == "" {
+			continue
+		}
+		vnIDsByGame[gameID] = vnID
+		vnIDs = append(vnIDs, vnID)
+	}
+	if len(vnIDs) == 0 {
+		return
+	}
+
+	lengths, err := s.vndbService.FetchLengthMinutes(s.ctx, vnIDs)
+	if err != nil {
+		applog.LogWarningf(s.ctx, "failed to fetch VNDB lengths for completion forecast: %v", err)
+	}
+	for gameID, vnID := range vnIDsByGame {
+		games[gameID].length = lengths[vnID]
+	}
+}
+
+func estimateGameCompletion(game *completionGame, completed []*completionGame, libraryMedian int, paceFactor float64) vo.GameCompletionEstimate {
+	estimate := vo.GameCompletionEstimate{
+		GameID:                game.id,
+		GameName:              game.name,
+		Company:               game.company,
+		Status:                game.status,
+		PlayedDuration:        game.played,
+		MetadataLengthMinutes: game.length,
+		Basis:                 completionBasisNone,
+	}
+
+	similar, similarCount := estimateFromSimilarGames(game, completed)
+	estimate.SimilarGamesCount = similarCount
+	metadataDuration := int(math.Round(float64(game.length*60) * paceFactor))
+	switch {
+	case similar > 0 && metadataDuration > 0:
+		estimate.EstimatedTotalDuration = (similar + metadataDuration) / 2
+		estimate.Basis = completionBasisSimilarMetadata
+	case similar > 0:
+		estimate.EstimatedTotalDuration = similar
+		estimate.Basis = completionBasisSimilar
+	case metadataDuration > 0:
+		estimate.EstimatedTotalDuration = metadataDuration
+		estimate.Basis = completionBasisMetadata
+	case libraryMedian > 0:
+		estimate.EstimatedTotalDuration = libraryMedian
+		estimate.Basis = completionBasisLibrary
+	default:
+		return estimate
+	}
+	estimate.RemainingDuration = max(estimate.EstimatedTotalDuration-game.played, 0)
+	return estimate
+}
+
+// estimateFromSimilarGames 取相似度最高的若干已通关游戏，按相似度加权平均其游玩时长。
+// 相似度 = 标签的 Jaccard 系数 + 同厂商加 1。
+func estimateFromSimilarGames(game *completionGame, completed []*completionGame) (int, int) {
+	type candidate struct {
+		score  float64
+		played int
+	}
+	var candidates []candidate
+	for _, other := range completed {
+		if other.id == game.id {
+			continue
+		}
+		if score := completionSimilarity(game, other); score > 0 {
+			candidates = append(candidates, candidate{score: score, played: other.played})
+		}
+	}
+	if len(candidates) == 0 {
+		return 0, 0
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+	if len(candidates) > completionSimilarGamesLimit {
+		candidates = candidates[:completionSimilarGamesLimit]
+	}
+
+	var weighted, totalScore float64
+	for _, item := range candidates {
+		weighted += item.score * float64(item.played)
+		totalScore += item.score
+	}
+	return int(math.Round(weighted / totalScore)), len(candidates)
+}
+
+func completionSimilarity(a, b *completionGame) float64 {
+	score := 0.0
+	if a.company != "" && strings.EqualFold(a.company, b.company) {
+		score++
+	}
+	if len(a.tags) == 0 || len(b.tags) == 0 {
+		return score
+	}
+	shared := 0
+	for tag := range a.tags {
+		if _, ok := b.tags[tag]; ok {
+			shared++
+		}
+	}
+	return score + float64(shared)/float64(len(a.tags)+len(b.tags)-shared)
+}
+
+func completionLibraryMedian(completed []*completionGame) int {
+	if len(completed) == 0 {
+		return 0
+	}
+	values := make([]float64, 0, len(completed))
+	for _, game := range completed {
+		values = append(values, float64(game.played))
+	}
+	return int(math.Round(completionMedian(values)))
+}
+
+// completionMetadataPaceFactor 返回自己实际通关时长与 VNDB 平均时长之比的中位数，没有可比数据时为 1
+func completionMetadataPaceFactor(completed []*completionGame) float64 {
+	var ratios []float64
+	for _, game := range completed {
+		if game.length > 0 {
+			ratios = append(ratios, float64(game.played)/float64(game.length*60))
+		}
+	}
+	if len(ratios) == 0 {
+		return 1
+	}
+	return completionMedian(ratios)
+}
+
+func completionMedian(values []float64) float64 {
+	sort.Float64s(values)
+	mid := len(values) / 2
+	if len(values)%2 == 0 {
+		return (values[mid-1] + values[mid]) / 2
+	}
+	return values[mid]
+}
+
+// sortCompletionBacklog 决定预测时的游玩顺序：在玩游戏按最近游玩排在前面，想玩游戏按加入时间排在后面
+func sortCompletionBacklog(games []*completionGame) {
+	sort.SliceStable(games, func(i, j int) bool {
+		a, b := games[i], games[j]
+		if a.status != b.status {
+			return a.status == enums.StatusPlaying
+		}
+		if a.status == enums.StatusPlaying && a.lastPlayed.Valid != b.lastPlayed.Valid {
+			return a.lastPlayed.Valid
+		}
+		if a.status == enums.StatusPlaying && a.lastPlayed.Valid && !a.lastPlayed.Time.Equal(b.lastPlayed.Time) {
+			return a.lastPlayed.Time.After(b.lastPlayed.Time)
+		}
+		if a.createdAt.Valid && b.createdAt.Valid && !a.createdAt.Time.Equal(b.createdAt.Time) {
+			return a.createdAt.Time.Before(b.createdAt.Time)
+		}
+		return a.name < b.name
+	})
+}
+
+func projectCompletionDate(today time.Time, remaining, avgDaily int) string {
+	days := int(math.Ceil(float64(remaining) / float64(avgDaily)))
+	return today.AddDate(0, 0, days).Format(statsDateLayout)
+}
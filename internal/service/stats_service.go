@@ -24,6 +24,8 @@ type StatsService struct {
 	db      *sql.DB
 	config  *appconf.AppConfig
 	runtime wailsruntime.Runtime
+
+	vndbService *VNDBService
 }
 
 func NewStatsService() *StatsService {
@@ -37,6 +39,11 @@ func (s *StatsService) Init(ctx context.Context, db *sql.DB, config *appconf.App
 	s.config = config
 }
 
+//wails:ignore
+func (s *StatsService) SetVNDBService(vndbService *VNDBService) {
+	s.vndbService = vndbService
+}
+
 //wails:ignore
 func (s *StatsService) SetRuntime(runtime wailsruntime.Runtime) {
 	if runtime != nil {
@@ -296,10 +303,41 @@ func (s *StatsService) GetGameStats(req vo.GameStatsRequest) (vo.GameDetailStats
 	return stats, nil
 }
 
+// GetGlobalPeriodStats 返回一个周期的全局统计。传入 start_date / end_date 时使用任意日期范围；
+// compare 为 true 时同时统计紧邻的上一个等长周期，填充 Previous 与 Delta。
 func (s *StatsService) GetGlobalPeriodStats(req vo.PeriodStatsRequest) (vo.PeriodStats, error) {
+	stats, err := s.buildPeriodStats(req)
+	if err != nil || !req.Compare {
+		return stats, err
+	}
+
+	previousReq, err := previousPeriodRequest(req.Dimension, stats.StartDate, stats.EndDate)
+	if err != nil {
+		return stats, err
+	}
+	previous, err := s.buildPeriodStats(previousReq)
+	if err != nil {
+		return stats, err
+	}
+	delta := comparePeriodStats(stats, previous)
+	stats.Previous = &previous
+	stats.Delta = &delta
+	return stats, nil
+}
+
+func (s *StatsService) buildPeriodStats(req vo.PeriodStatsRequest) (vo.PeriodStats, error) {
 	var stats vo.PeriodStats
 	stats.Dimension = req.Dimension
 
+	if req.StartDate != "" || req.EndDate != "" {
+		start, end, err := parseStatsDateRange(req.StartDate, req.EndDate)
+		if err != nil {
+			return stats, err
+		}
+		req.StartDate = start.Format(statsDateLayout)
+		req.EndDate = end.Format(statsDateLayout)
+	}
+
 	var (
 		startDate    string
 		endDate      string
@@ -365,14 +403,14 @@ func (s *StatsService) GetGlobalPeriodStats(req vo.PeriodStatsRequest) (vo.Perio
 		// 获取实际日期范围用于显示
 		if req.Dimension == enums.All {
 			var actualStart, actualEnd string
-			err := s.db.QueryRowContext(s.ctx, "SELECT COALESCE(MIN(start_time::DATE), current_date), current_date FROM play_sessions").Scan(&actualStart, &actualEnd)
+			err := s.db.QueryRowContext(s.ctx, "SELECT strftime(COALESCE(MIN(start_time::DATE), current_date), '%Y-%m-%d'), strftime(current_date, '%Y-%m-%d') FROM play_sessions").Scan(&actualStart, &actualEnd)
 			if err == nil {
 				stats.StartDate = actualStart
 				stats.EndDate = actualEnd
 			}
 		} else {
 			var actualStart, actualEnd string
-			err := s.db.QueryRowContext(s.ctx, fmt.Sprintf("SELECT %s, %s", startDateExpr, endDateExpr)).Scan(&actualStart, &actualEnd)
+			err := s.db.QueryRowContext(s.ctx, fmt.Sprintf("SELECT strftime(%s, '%%Y-%%m-%%d'), strftime(%s, '%%Y-%%m-%%d')", startDateExpr, endDateExpr)).Scan(&actualStart, &actualEnd)
 			if err == nil {
 				stats.StartDate = actualStart
 				stats.EndDate = actualEnd
@@ -749,5 +787,12 @@ func (s *StatsService) GetGlobalPeriodStats(req vo.PeriodStatsRequest) (vo.Perio
 		return stats, err
 	}
 
+	// 10. 按分类、标签、厂商、启动方式拆分
+	stats.Breakdowns, err = s.queryPeriodBreakdowns(startDateExpr, endDateExpr)
+	if err != nil {
+		applog.LogErrorf(s.ctx, "failed to query period breakdowns: %v", err)
+		return stats, err
+	}
+
 	return stats, nil
 }
@@ -0,0 +1,391 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"lunabox/internal/appconf"
+	"lunabox/internal/service"
+	"lunabox/internal/service/cloudprovider"
+	"lunabox/internal/service/cloudprovider/local"
+	"lunabox/internal/service/cloudsync"
+	"lunabox/internal/utils"
+)
+
+// failingUploadProvider 在第 failAfter 次上传后返回错误，用于模拟轮换中途中断。
+type failingUploadProvider struct {
+	*mockProvider
+	uploads   int
+	failAfter int
+}
+
+func (p *failingUploadProvider) UploadFile(ctx context.Context, cloudPath, localPath string) error {
+	p.uploads++
+	if p.failAfter > 0 && p.uploads > p.failAfter {
+		return errors.New("network down")
+	}
+	return p.mockProvider.UploadFile(ctx, cloudPath, localPath)
+}
+
+// unlistedTargetProvider 上传与读回都成功，但新命名空间中的对象不出现在列表里，模拟写入后不可见的存储。
+type unlistedTargetProvider struct {
+	*mockProvider
+	hiddenPrefix string
+}
+
+func (p *unlistedTargetProvider) ListObjects(ctx context.Context, prefix string) ([]string, error) {
+	keys, err := p.mockProvider.ListObjects(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	visible := keys[:0]
+	for _, key := range keys {
+		if !strings.HasPrefix(key, p.hiddenPrefix) {
+			visible = append(visible, key)
+		}
+	}
+	return visible, nil
+}
+
+func newRotationTestService(t *testing.T, provider cloudprovider.CloudStorageProvider, cfg *appconf.AppConfig) *service.BackupService {
+	t.Helper()
+	db, cleanup := setupTestDB(t)
+	t.Cleanup(cleanup)
+
+	now := time.Now().UTC()
+	if _, err := db.Exec(`INSERT INTO games (id, name, created_at, updated_at) VALUES (?, ?, ?, ?)`, "game-1", "G1", now, now); err != nil {
+		t.Fatal(err)
+	}
+
+	backupService := service.NewBackupService()
+	backupService.Init(context.Background(), db, cfg)
+	backupService.SetConfigSaverForTest(func(*appconf.AppConfig) error { return nil })
+	backupService.SetCloudProviderFactoryForTest(func(context.Context, *appconf.AppConfig) (cloudprovider.CloudStorageProvider, error) {
+		key, err := cloudprovider.BackupEncryptionKey(cfg)
+		if err != nil {
+			return nil, err
+		}
+		return cloudprovider.NewEncryptedProvider(provider, key), nil
+	})
+	return backupService
+}
+
+func deriveTestBackupUserID(t *testing.T, password string) string {
+	t.Helper()
+	userID, _, err := utils.DeriveBackupCredentials(password)
+	if err != nil {
+		t.Fatalf("derive backup credentials: %v", err)
+	}
+	return userID
+}
+
+func seedRotationObjects(raw *mockProvider, userID string) map[string]string {
+	objects := map[string]string{
+		"database/latest.zip":          "db-latest",
+		"database/20260101_000000.zip": "db-old",
+		"saves/game-1/latest.zip":      "save-latest",
+		"sync/library/manifest.json":   `{"schema_version":2}`,
+	}
+	for sub, payload := range objects {
+		raw.store[raw.GetCloudPath(userID, sub)] = []byte(payload)
+	}
+	return objects
+}
+
+func TestRotateCloudBackupPassword_MovesAndReencryptsObjects(t *testing.T) {
+	raw := newMockProvider()
+	oldUserID := utils.LegacyBackupUserID("old-pass")
+	cfg := &appconf.AppConfig{CloudBackupEnabled: true, BackupUserID: oldUserID}
+	objects := seedRotationObjects(raw, oldUserID)
+
+	backupService := newRotationTestService(t, raw, cfg)
+	result, err := backupService.RotateCloudBackupPassword("old-pass", "new-pass")
+	if err != nil {
+		t.Fatalf("RotateCloudBackupPassword failed: %v", err)
+	}
+
+	newUserID := deriveTestBackupUserID(t, "new-pass")
+	if cfg.BackupUserID != newUserID || result.UserID != newUserID {
+		t.Fatalf("BackupUserID = %q, result = %q, want %q", cfg.BackupUserID, result.UserID, newUserID)
+	}
+	if cfg.BackupPasswordRotation != nil {
+		t.Fatal("rotation state should be cleared after success")
+	}
+	if result.Total != len(objects) || result.Copied != len(objects) || result.Verified != len(objects) || result.Deleted != len(objects) {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	newKey, err := utils.DecodeBackupEncryptionKey(cfg.BackupEncryptionKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for sub, want := range objects {
+		payload, ok := raw.store[raw.GetCloudPath(newUserID, sub)]
+		if !ok {
+			t.Fatalf("%s missing in new namespace", sub)
+		}
+		if !utils.IsBackupEnvelope(payload) {
+			t.Fatalf("%s not encrypted with new key", sub)
+		}
+		dir := t.TempDir()
+		sealed, plain := dir+"/sealed", dir+"/plain"
+		if err := os.WriteFile(sealed, payload, 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := utils.DecryptBackupFile(newKey, sealed, plain); err != nil {
+			t.Fatalf("decrypt %s: %v", sub, err)
+		}
+		got, _ := os.ReadFile(plain)
+		if string(got) != want {
+			t.Fatalf("%s = %q, want %q", sub, got, want)
+		}
+	}
+	for key := range raw.store {
+		if strings.Contains(key, oldUserID) {
+			t.Fatalf("old object %s should be deleted", key)
+		}
+	}
+}
+
+func TestRotateCloudBackupPassword_ResumesAfterInterruption(t *testing.T) {
+	raw := newMockProvider()
+	oldUserID := utils.LegacyBackupUserID("old-pass")
+	cfg := &appconf.AppConfig{CloudBackupEnabled: true, BackupUserID: oldUserID}
+	objects := seedRotationObjects(raw, oldUserID)
+
+	flaky := &failingUploadProvider{mockProvider: raw, failAfter: 2}
+	backupService := newRotationTestService(t, flaky, cfg)
+	if _, err := backupService.RotateCloudBackupPassword("old-pass", "new-pass"); err == nil {
+		t.Fatal("expected interrupted rotation to fail")
+	}
+	if cfg.BackupUserID != oldUserID {
+		t.Fatal("BackupUserID must not switch before every object is verified")
+	}
+	if cfg.BackupPasswordRotation == nil {
+		t.Fatal("rotation state should be kept for resume")
+	}
+	if status := backupService.GetCloudBackupStatus(); !status.RotationPending {
+		t.Fatal("status should report pending rotation")
+	}
+
+	if _, err := backupService.RotateCloudBackupPassword("old-pass", "other-pass"); err == nil {
+		t.Fatal("a different new password must not hijack a pending rotation")
+	}
+
+	flaky.failAfter = 0
+	result, err := backupService.ResumeCloudPasswordRotation()
+	if err != nil {
+		t.Fatalf("ResumeCloudPasswordRotation failed: %v", err)
+	}
+	if result.Verified != len(objects) || result.Copied != len(objects)-2 || result.Deleted != len(objects) {
+		t.Fatalf("unexpected resume result: %+v", result)
+	}
+	if cfg.BackupUserID != deriveTestBackupUserID(t, "new-pass") || cfg.BackupPasswordRotation != nil {
+		t.Fatal("rotation should finish after resume")
+	}
+}
+
+func TestRotateCloudBackupPassword_RejectsWrongOldPassword(t *testing.T) {
+	raw := newMockProvider()
+	cfg := &appconf.AppConfig{CloudBackupEnabled: true, BackupUserID: utils.LegacyBackupUserID("old-pass")}
+	backupService := newRotationTestService(t, raw, cfg)
+
+	if _, err := backupService.RotateCloudBackupPassword("guess", "new-pass"); err == nil {
+		t.Fatal("expected wrong old password to be rejected")
+	}
+	if cfg.BackupPasswordRotation != nil {
+		t.Fatal("rotation state must not be written for a rejected request")
+	}
+}
+
+func TestRotateCloudBackupPassword_UpgradesLegacyIdentityWithSamePassword(t *testing.T) {
+	raw := newMockProvider()
+	legacyUserID := utils.LegacyBackupUserID("same-pass")
+	cfg := &appconf.AppConfig{CloudBackupEnabled: true, BackupUserID: legacyUserID}
+	objects := seedRotationObjects(raw, legacyUserID)
+
+	backupService := newRotationTestService(t, raw, cfg)
+	result, err := backupService.RotateCloudBackupPassword("same-pass", "same-pass")
+	if err != nil {
+		t.Fatalf("RotateCloudBackupPassword failed: %v", err)
+	}
+	newUserID := deriveTestBackupUserID(t, "same-pass")
+	if newUserID == legacyUserID || cfg.BackupUserID != newUserID {
+		t.Fatalf("BackupUserID = %q, want v2 identity %q", cfg.BackupUserID, newUserID)
+	}
+	if result.Verified != len(objects) || result.Deleted != len(objects) {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+
+	if _, err := backupService.RotateCloudBackupPassword("same-pass", "same-pass"); err == nil {
+		t.Fatal("rotating a v2 identity to the same password must be rejected")
+	}
+}
+
+func TestRotateCloudBackupPassword_KeepsRevisionHistory(t *testing.T) {
+	raw := newMockProvider()
+	oldUserID, oldKey, err := utils.DeriveBackupCredentials("old-pass")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &appconf.AppConfig{CloudBackupEnabled: true, CloudSyncEnabled: true, BackupUserID: oldUserID, BackupEncryptionKey: oldKey}
+	encrypted := func() cloudprovider.CloudStorageProvider {
+		key, err := cloudprovider.BackupEncryptionKey(cfg)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return cloudprovider.NewEncryptedProvider(raw, key)
+	}
+
+	ctx := context.Background()
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+	helper := cloudsync.NewHelper(ctx, db, cfg)
+	start := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+	if _, err := db.Exec(`INSERT INTO games (id, name, status, created_at, updated_at) VALUES (?, ?, ?, ?, ?)`,
+		"1aaa", "G1", "playing", start, start); err != nil {
+		t.Fatal(err)
+	}
+	if err := helper.SyncToCloud(encrypted()); err != nil {
+		t.Fatal(err)
+	}
+	good, _, err := helper.LoadRemoteManifest(encrypted())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`UPDATE games SET status = ?, updated_at = ? WHERE id = ?`, "on_hold", start.Add(time.Minute), "1aaa"); err != nil {
+		t.Fatal(err)
+	}
+	if err := helper.SyncToCloud(encrypted()); err != nil {
+		t.Fatal(err)
+	}
+
+	backupService := newRotationTestService(t, raw, cfg)
+	if _, err := backupService.RotateCloudBackupPassword("old-pass", "new-pass"); err != nil {
+		t.Fatalf("RotateCloudBackupPassword failed: %v", err)
+	}
+	for key := range raw.store {
+		if strings.Contains(key, oldUserID) {
+			t.Fatalf("old object %s should be deleted", key)
+		}
+	}
+
+	// 轮换后 cfg 已切换到新身份与新密钥，历史版本必须能在新命名空间中读出并回滚
+	index, err := helper.LoadHistoryIndex(encrypted())
+	if err != nil {
+		t.Fatalf("LoadHistoryIndex after rotation: %v", err)
+	}
+	found := false
+	for _, revision := range index.Revisions {
+		found = found || revision.RevisionID == good.RevisionID
+	}
+	if len(index.Revisions) != 2 || !found {
+		t.Fatalf("history after rotation = %+v, want 2 revisions including %s", index.Revisions, good.RevisionID)
+	}
+	if err := helper.RollbackToRevision(encrypted(), good.RevisionID); err != nil {
+		t.Fatalf("RollbackToRevision after rotation: %v", err)
+	}
+	var status string
+	if err := db.QueryRow(`SELECT status FROM games WHERE id = ?`, "1aaa").Scan(&status); err != nil || status != "playing" {
+		t.Fatalf("status after rollback = %q, %v", status, err)
+	}
+}
+
+func TestRotateCloudBackupPassword_RequiresObjectsListedInTarget(t *testing.T) {
+	raw := newMockProvider()
+	oldUserID := utils.LegacyBackupUserID("old-pass")
+	cfg := &appconf.AppConfig{CloudBackupEnabled: true, BackupUserID: oldUserID}
+	objects := seedRotationObjects(raw, oldUserID)
+
+	newUserID := deriveTestBackupUserID(t, "new-pass")
+	hidden := &unlistedTargetProvider{mockProvider: raw, hiddenPrefix: raw.GetCloudPath(newUserID, "saves/")}
+	backupService := newRotationTestService(t, hidden, cfg)
+	if _, err := backupService.RotateCloudBackupPassword("old-pass", "new-pass"); err == nil {
+		t.Fatal("expected rotation to fail when copied objects are missing from the target listing")
+	}
+	if cfg.BackupUserID != oldUserID {
+		t.Fatal("BackupUserID must not switch when the target namespace is incomplete")
+	}
+	for sub := range objects {
+		if _, ok := raw.store[raw.GetCloudPath(oldUserID, sub)]; !ok {
+			t.Fatalf("old object %s must not be deleted", sub)
+		}
+	}
+}
+
+func TestRotateCloudBackupPassword_MovesSavesOfGamesMissingLocally(t *testing.T) {
+	ctx := context.Background()
+	raw, err := local.NewProvider(local.Config{Path: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	oldUserID := utils.LegacyBackupUserID("old-pass")
+	cfg := &appconf.AppConfig{CloudBackupEnabled: true, BackupUserID: oldUserID}
+	// 本地库只有 game-1；other-device 是在其他设备添加（或本机已删除）的游戏
+	subs := []string{
+		"saves/game-1/latest.zip",
+		"saves/other-device/latest.zip",
+		"saves/other-device/snapshots/20260101_000000.json",
+		"saves/other-device/blobs/ab12.bin",
+	}
+	payload := filepath.Join(t.TempDir(), "payload")
+	if err := os.WriteFile(payload, []byte("save-data"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	for _, sub := range subs {
+		if err := raw.UploadFile(ctx, raw.GetCloudPath(oldUserID, sub), payload); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	backupService := newRotationTestService(t, raw, cfg)
+	result, err := backupService.RotateCloudBackupPassword("old-pass", "new-pass")
+	if err != nil {
+		t.Fatalf("RotateCloudBackupPassword failed: %v", err)
+	}
+	if result.Total != len(subs) || result.Deleted != len(subs) {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	newUserID := deriveTestBackupUserID(t, "new-pass")
+	for _, sub := range subs {
+		destination := filepath.Join(t.TempDir(), "download")
+		if err := raw.DownloadFile(ctx, raw.GetCloudPath(newUserID, sub), destination); err != nil {
+			t.Fatalf("%s missing in new namespace: %v", sub, err)
+		}
+		if err := raw.DownloadFile(ctx, raw.GetCloudPath(oldUserID, sub), destination); err == nil {
+			t.Fatalf("old object %s should be deleted", sub)
+		}
+	}
+}
+
+func TestSetupAndEnableCloudEncryptionUseInjectedConfigSaver(t *testing.T) {
+	cfg := &appconf.AppConfig{CloudBackupEnabled: true}
+	backupService := newRotationTestService(t, newMockProvider(), cfg)
+	var saved []appconf.AppConfig
+	backupService.SetConfigSaverForTest(func(c *appconf.AppConfig) error {
+		saved = append(saved, *c)
+		return nil
+	})
+
+	userID, err := backupService.SetupCloudBackup("pass")
+	if err != nil {
+		t.Fatalf("SetupCloudBackup failed: %v", err)
+	}
+	if len(saved) != 1 || saved[0].BackupUserID != userID || saved[0].BackupEncryptionKey == "" {
+		t.Fatalf("SetupCloudBackup should persist through the injected saver: %+v", saved)
+	}
+
+	cfg.BackupUserID = utils.LegacyBackupUserID("legacy-pass")
+	cfg.BackupEncryptionKey = ""
+	if err := backupService.EnableCloudEncryption("legacy-pass"); err != nil {
+		t.Fatalf("EnableCloudEncryption failed: %v", err)
+	}
+	if len(saved) != 2 || saved[1].BackupEncryptionKey == "" {
+		t.Fatalf("EnableCloudEncryption should persist through the injected saver: %+v", saved)
+	}
+}
@@ -0,0 +1,207 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"lunabox/internal/appconf"
+	"lunabox/internal/service"
+	"lunabox/internal/service/cloudprovider"
+	"lunabox/internal/utils"
+	"lunabox/internal/utils/archiveutils"
+)
+
+func newSaveSnapshotTestService(t *testing.T, provider cloudprovider.CloudStorageProvider) (*service.BackupService, string, string) {
+	t.Helper()
+	db, cleanup := setupTestDB(t)
+	t.Cleanup(cleanup)
+
+	savePath := filepath.Join(t.TempDir(), "save")
+	if err := os.MkdirAll(savePath, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	gameID := fmt.Sprintf("save-snapshot-%d", time.Now().UnixNano())
+	now := time.Now().UTC()
+	if _, err := db.Exec(`INSERT INTO games (id, name, save_path, created_at, updated_at) VALUES (?, ?, ?, ?, ?)`, gameID, "G", savePath, now, now); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := &appconf.AppConfig{CloudBackupEnabled: true, BackupUserID: utils.LegacyBackupUserID("pass")}
+	backupService := service.NewBackupService()
+	backupService.Init(context.Background(), db, cfg)
+	backupService.SetCloudProviderFactoryForTest(func(context.Context, *appconf.AppConfig) (cloudprovider.CloudStorageProvider, error) {
+		return provider, nil
+	})
+	backupDir, err := backupService.GetBackupDir()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(filepath.Join(backupDir, gameID)) })
+	return backupService, gameID, savePath
+}
+
+func TestGameSaveBackupsAreDeduplicatedSnapshots(t *testing.T) {
+	raw := newMockProvider()
+	backupService, gameID, savePath := newSaveSnapshotTestService(t, raw)
+
+	big := bytes.Repeat([]byte("lunabox save data "), 200_000) // 约 3.6MB，跨多个块
+	if err := os.WriteFile(filepath.Join(savePath, "data.bin"), big, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(savePath, "slot1.sav"), []byte("v1"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	first, err := backupService.CreateBackup(gameID)
+	if err != nil {
+		t.Fatalf("CreateBackup failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(savePath, "slot1.sav"), []byte("v2"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(1100 * time.Millisecond) // 快照 ID 精确到秒
+	second, err := backupService.CreateBackup(gameID)
+	if err != nil {
+		t.Fatalf("CreateBackup failed: %v", err)
+	}
+	if second.AddedSize >= first.AddedSize || second.AddedSize > 1024 {
+		t.Fatalf("second backup should only add the changed slot: first=%d second=%d", first.AddedSize, second.AddedSize)
+	}
+
+	backups, err := backupService.GetGameBackups(gameID)
+	if err != nil || len(backups) != 2 || backups[0].Path != second.Path {
+		t.Fatalf("GetGameBackups = %+v, %v", backups, err)
+	}
+
+	if err := backupService.UploadGameBackupToCloud(gameID, first.Path); err != nil {
+		t.Fatalf("upload first: %v", err)
+	}
+	if err := backupService.UploadGameBackupToCloud(gameID, second.Path); err != nil {
+		t.Fatalf("upload second: %v", err)
+	}
+	for key := range raw.store {
+		if strings.HasSuffix(key, ".zip") {
+			t.Fatalf("snapshot upload should not create zip %s", key)
+		}
+	}
+	cloudItems, err := backupService.GetCloudGameBackups(gameID)
+	if err != nil || len(cloudItems) != 2 {
+		t.Fatalf("GetCloudGameBackups = %+v, %v", cloudItems, err)
+	}
+
+	// 本地恢复第一个快照
+	if err := backupService.RestoreBackup(first.Path); err != nil {
+		t.Fatalf("RestoreBackup failed: %v", err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(savePath, "slot1.sav")); string(got) != "v1" {
+		t.Fatalf("slot1 after local restore = %q", got)
+	}
+
+	// 删除本地快照后从云端恢复第二个快照，缺失的块从云端补齐
+	for _, backup := range backups {
+		if err := backupService.DeleteBackup(backup.Path); err != nil {
+			t.Fatalf("DeleteBackup failed: %v", err)
+		}
+	}
+	if err := backupService.RestoreFromCloud(cloudItems[0].Key, gameID); err != nil {
+		t.Fatalf("RestoreFromCloud failed: %v", err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(savePath, "slot1.sav")); string(got) != "v2" {
+		t.Fatalf("slot1 after cloud restore = %q", got)
+	}
+	if got, _ := os.ReadFile(filepath.Join(savePath, "data.bin")); !bytes.Equal(got, big) {
+		t.Fatal("data.bin not restored from cloud blobs")
+	}
+}
+
+func TestSaveBackupDiffAndSelectiveRestore(t *testing.T) {
+	backupService, gameID, savePath := newSaveSnapshotTestService(t, newMockProvider())
+	writeSave := func(rel, content string) {
+		t.Helper()
+		p := filepath.Join(savePath, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	readSave := func(rel string) string {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join(savePath, filepath.FromSlash(rel)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(data)
+	}
+
+	writeSave("slot1.sav", "slot1-v1")
+	writeSave("nested/slot2.sav", "slot2-v1")
+	snapshot, err := backupService.CreateBackup(gameID)
+	if err != nil {
+		t.Fatalf("CreateBackup failed: %v", err)
+	}
+
+	// 旧版 zip 备份：slot1 已修改，新增 slot3
+	writeSave("slot1.sav", "slot1-v2")
+	writeSave("slot3.sav", "slot3-v1")
+	backupDir, _ := backupService.GetBackupDir()
+	zipPath := filepath.Join(backupDir, gameID, "2020-01-01T00-00-00.zip")
+	if _, err := archiveutils.ZipFileOrDirectory(savePath, zipPath); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := backupService.ListBackupFiles(zipPath)
+	if err != nil || len(files) != 3 || files[0].Path != "nested/slot2.sav" || files[0].Hash == "" {
+		t.Fatalf("ListBackupFiles(zip) = %+v, %v", files, err)
+	}
+
+	diff, err := backupService.DiffBackups(snapshot.Path, zipPath)
+	if err != nil {
+		t.Fatalf("DiffBackups failed: %v", err)
+	}
+	if diff.Added != 1 || diff.Modified != 1 || diff.Unchanged != 1 || diff.Removed != 0 {
+		t.Fatalf("DiffBackups = %+v", diff)
+	}
+
+	writeSave("nested/slot2.sav", "slot2-v2")
+	if err := os.Remove(filepath.Join(savePath, "slot3.sav")); err != nil {
+		t.Fatal(err)
+	}
+	diff, err = backupService.DiffBackupWithCurrentSave(zipPath)
+	if err != nil {
+		t.Fatalf("DiffBackupWithCurrentSave failed: %v", err)
+	}
+	changes := map[string]string{}
+	for _, file := range diff.Files {
+		changes[file.Path] = file.Change
+	}
+	if changes["slot1.sav"] != "unchanged" || changes["nested/slot2.sav"] != "modified" || changes["slot3.sav"] != "removed" {
+		t.Fatalf("DiffBackupWithCurrentSave = %+v", changes)
+	}
+
+	// 只从快照恢复 slot1，从 zip 恢复 slot3，其余文件保持当前状态
+	if err := backupService.RestoreBackupFiles(snapshot.Path, []string{"slot1.sav"}); err != nil {
+		t.Fatalf("RestoreBackupFiles(snapshot) failed: %v", err)
+	}
+	if err := backupService.RestoreBackupFiles(zipPath, []string{"slot3.sav"}); err != nil {
+		t.Fatalf("RestoreBackupFiles(zip) failed: %v", err)
+	}
+	if readSave("slot1.sav") != "slot1-v1" || readSave("slot3.sav") != "slot3-v1" || readSave("nested/slot2.sav") != "slot2-v2" {
+		t.Fatal("selective restore touched the wrong files")
+	}
+	if err := backupService.RestoreBackupFiles(snapshot.Path, []string{"missing.sav"}); err == nil {
+		t.Fatal("restoring a file absent from the backup should fail")
+	}
+
+	safety, err := os.ReadDir(filepath.Join(backupDir, gameID, "pre_restore"))
+	if err != nil || len(safety) != 2 {
+		t.Fatalf("expected a safety backup per selective restore, got %d (%v)", len(safety), err)
+	}
+}
@@ -0,0 +1,273 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"lunabox/internal/appconf"
+	"lunabox/internal/applog"
+	"lunabox/internal/common/enums"
+	"lunabox/internal/service"
+)
+
+type fakeBangumiCollection struct {
+	SubjectID   int            `json:"subject_id"`
+	SubjectType int            `json:"subject_type"`
+	Type        int            `json:"type"`
+	Rate        int            `json:"rate"`
+	Comment     string         `json:"comment"`
+	UpdatedAt   string         `json:"updated_at"`
+	Subject     map[string]any `json:"subject"`
+}
+
+// fakeBangumiServer 保存收藏状态，POST 会像真实接口一样更新收藏并刷新 updated_at
+type fakeBangumiServer struct {
+	t           *testing.T
+	mu          sync.Mutex
+	collections []*fakeBangumiCollection
+	posts       map[string][]map[string]any
+}
+
+func (f *fakeBangumiServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/v0/users/sai/collections":
+		if r.URL.Query().Get("subject_type") != "4" {
+			f.t.Fatalf("收藏列表应只请求游戏条目: %s", r.URL.RawQuery)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(map[string]any{"data": f.collections, "total": len(f.collections), "limit": 50, "offset": 0})
+	case r.Method == http.MethodGet && r.URL.Path == "/v0/subjects/301":
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{"id":301,"type":4,"name":"Remote Only","name_cn":"远端新游戏","summary":"full summary","date":"2020-01-01","infobox":[{"key":"开发","value":"Studio"}],"tags":[]}`)
+	case r.Method == http.MethodPost && strings.HasPrefix(r.URL.Path, "/v0/users/-/collections/"):
+		subjectID := strings.TrimPrefix(r.URL.Path, "/v0/users/-/collections/")
+		var payload map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			f.t.Fatalf("解析收藏请求失败: %v", err)
+		}
+		f.posts[subjectID] = append(f.posts[subjectID], payload)
+		for _, collection := range f.collections {
+			if fmt.Sprint(collection.SubjectID) != subjectID {
+				continue
+			}
+			if value, ok := payload["type"].(float64); ok {
+				collection.Type = int(value)
+			}
+			if value, ok := payload["rate"].(float64); ok {
+				collection.Rate = int(value)
+			}
+			if value, ok := payload["comment"].(string); ok {
+				collection.Comment = value
+			}
+			collection.UpdatedAt = time.Now().Format(time.RFC3339)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		f.t.Fatalf("未预期的请求: %s %s", r.Method, r.URL.Path)
+	}
+}
+
+func TestBangumiCollectionSyncReconcilesBothDirections(t *testing.T) {
+	applog.SetMode(applog.ModeCLI)
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	old := time.Now().Add(-48 * time.Hour)
+	recent := time.Now().Add(-time.Hour)
+	insertBangumiGame(t, db, "remote-newer", enums.StatusCompleted, enums.Bangumi, "302")
+	insertBangumiGame(t, db, "local-newer", enums.StatusOnHold, enums.Bangumi, "303")
+	insertBangumiGame(t, db, "in-sync", enums.StatusPlaying, enums.Bangumi, "304")
+	for id, updatedAt := range map[string]time.Time{"remote-newer": old, "local-newer": time.Now(), "in-sync": old} {
+		if _, err := db.Exec(`UPDATE games SET updated_at = ? WHERE id = ?`, updatedAt, id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := db.Exec(`INSERT INTO game_reviews (game_id, rating, content, created_at, updated_at) VALUES ('local-newer', 8, 'mine', ?, ?)`, time.Now(), time.Now()); err != nil {
+		t.Fatal(err)
+	}
+
+	fake := &fakeBangumiServer{t: t, posts: map[string][]map[string]any{}, collections: []*fakeBangumiCollection{
+		{SubjectID: 301, SubjectType: 4, Type: 3, Rate: 9, Comment: "great", UpdatedAt: recent.Format(time.RFC3339), Subject: map[string]any{"id": 301, "name": "Remote Only"}},
+		{SubjectID: 302, SubjectType: 4, Type: 3, Rate: 7, Comment: "ok", UpdatedAt: recent.Format(time.RFC3339), Subject: map[string]any{"id": 302}},
+		{SubjectID: 303, SubjectType: 4, Type: 1, UpdatedAt: old.Format(time.RFC3339), Subject: map[string]any{"id": 303}},
+		{SubjectID: 304, SubjectType: 4, Type: 3, UpdatedAt: recent.Format(time.RFC3339), Subject: map[string]any{"id": 304}},
+	}}
+	testServer := httptest.NewServer(fake)
+	defer testServer.Close()
+
+	gameSvc := service.NewGameService()
+	gameSvc.SetEventEmitter(func(string, ...interface{}) {})
+	gameSvc.Init(context.Background(), db, &appconf.AppConfig{})
+	bangumiSvc := service.NewBangumiService()
+	bangumiSvc.SetHTTPClient(newBangumiHTTPClient(t, testServer.URL))
+	bangumiSvc.SetEventEmitter(func(string, ...interface{}) {})
+	bangumiSvc.Init(context.Background(), db, &appconf.AppConfig{
+		BangumiAccessToken:        "access-token",
+		BangumiAuthorizedUsername: "sai",
+	})
+	bangumiSvc.SetGameService(gameSvc)
+
+	preview, err := bangumiSvc.PreviewCollectionSync()
+	if err != nil {
+		t.Fatalf("预览 Bangumi 收藏同步失败: %v", err)
+	}
+	if !preview.DryRun || preview.Total != 4 || preview.Created != 1 || preview.Updated != 2 || preview.Unchanged != 1 {
+		t.Fatalf("预览统计异常: %+v", preview)
+	}
+	directions := map[string]string{}
+	for _, item := range preview.Items {
+		for _, change := range item.Changes {
+			directions[item.SubjectID+"/"+change.Field] = change.Direction
+		}
+	}
+	expected := map[string]string{
+		"301/status": "to_local", "301/review": "to_local",
+		"302/status": "to_local", "302/review": "to_local",
+		"303/status": "to_remote", "303/review": "to_remote",
+	}
+	for key, direction := range expected {
+		if directions[key] != direction {
+			t.Fatalf("%s 的同步方向应为 %s，实际为 %q（%+v）", key, direction, directions[key], directions)
+		}
+	}
+	if len(fake.posts) != 0 {
+		t.Fatalf("预览不应写入 Bangumi: %+v", fake.posts)
+	}
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM games`).Scan(&count); err != nil || count != 3 {
+		t.Fatalf("预览不应新建游戏: %d, %v", count, err)
+	}
+
+	result, err := bangumiSvc.SyncCollections()
+	if err != nil {
+		t.Fatalf("Bangumi 收藏同步失败: %v", err)
+	}
+	if result.DryRun || result.Created != 1 || result.Updated != 2 || result.Failed != 0 {
+		t.Fatalf("同步统计异常: %+v", result)
+	}
+
+	var createdID, createdName, createdStatus, createdCompany string
+	if err := db.QueryRow(`
+		SELECT g.id, g.name, g.status, g.company FROM games g
+		JOIN game_metadata_sources s ON s.game_id = g.id
+		WHERE s.source_type = 'bangumi' AND s.source_id = '301'`).Scan(&createdID, &createdName, &createdStatus, &createdCompany); err != nil {
+		t.Fatalf("未新建 Bangumi 收藏中的游戏: %v", err)
+	}
+	if createdName != "远端新游戏" || createdStatus != string(enums.StatusPlaying) || createdCompany != "Studio" {
+		t.Fatalf("新建游戏应使用完整元数据和收藏状态: name=%q status=%q company=%q", createdName, createdStatus, createdCompany)
+	}
+	assertLocalReview(t, db, createdID, 9, "great")
+
+	var status string
+	if err := db.QueryRow(`SELECT status FROM games WHERE id = 'remote-newer'`).Scan(&status); err != nil || status != string(enums.StatusPlaying) {
+		t.Fatalf("Bangumi 较新时应更新本地状态: %q, %v", status, err)
+	}
+	assertLocalReview(t, db, "remote-newer", 7, "ok")
+
+	posts := fake.posts["303"]
+	if len(posts) != 2 || posts[0]["type"] != float64(4) || posts[1]["rate"] != float64(8) || posts[1]["comment"] != "mine" {
+		t.Fatalf("本地较新时应推送状态和评价: %+v", fake.posts)
+	}
+	if len(fake.posts) != 1 {
+		t.Fatalf("只有本地较新的条目需要推送: %+v", fake.posts)
+	}
+
+	again, err := bangumiSvc.PreviewCollectionSync()
+	if err != nil {
+		t.Fatalf("再次预览失败: %v", err)
+	}
+	if again.Unchanged != 4 {
+		t.Fatalf("同步后两侧应一致: %+v", again)
+	}
+}
+
+func TestBangumiCollectionSyncComparesStatusChangeTime(t *testing.T) {
+	applog.SetMode(applog.ModeCLI)
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	old := time.Now().Add(-48 * time.Hour)
+	recent := time.Now().Add(-time.Hour)
+	insertBangumiGame(t, db, "cover-edited", enums.StatusOnHold, enums.Bangumi, "305")
+	insertBangumiGame(t, db, "status-edited", enums.StatusOnHold, enums.Bangumi, "306")
+	if _, err := db.Exec(`UPDATE games SET updated_at = ?, status_updated_at = ?`, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	fake := &fakeBangumiServer{t: t, posts: map[string][]map[string]any{}, collections: []*fakeBangumiCollection{
+		{SubjectID: 305, SubjectType: 4, Type: 3, UpdatedAt: recent.Format(time.RFC3339), Subject: map[string]any{"id": 305}},
+		{SubjectID: 306, SubjectType: 4, Type: 3, UpdatedAt: recent.Format(time.RFC3339), Subject: map[string]any{"id": 306}},
+	}}
+	testServer := httptest.NewServer(fake)
+	defer testServer.Close()
+
+	gameSvc := service.NewGameService()
+	gameSvc.SetEventEmitter(func(string, ...interface{}) {})
+	gameSvc.Init(context.Background(), db, &appconf.AppConfig{})
+	bangumiSvc := service.NewBangumiService()
+	bangumiSvc.SetHTTPClient(newBangumiHTTPClient(t, testServer.URL))
+	bangumiSvc.SetEventEmitter(func(string, ...interface{}) {})
+	bangumiSvc.Init(context.Background(), db, &appconf.AppConfig{
+		BangumiAccessToken:        "access-token",
+		BangumiAuthorizedUsername: "sai",
+	})
+	bangumiSvc.SetGameService(gameSvc)
+
+	// 两个游戏都在 Bangumi 收藏更新之后被编辑，但只有一个改的是状态
+	coverEdited, err := gameSvc.GetGameByID("cover-edited")
+	if err != nil {
+		t.Fatal(err)
+	}
+	coverEdited.CoverURL = "https://example.com/new-cover.jpg"
+	if err := gameSvc.UpdateGame(coverEdited); err != nil {
+		t.Fatalf("更新封面失败: %v", err)
+	}
+	statusEdited, err := gameSvc.GetGameByID("status-edited")
+	if err != nil {
+		t.Fatal(err)
+	}
+	statusEdited.Status = enums.StatusCompleted
+	if err := gameSvc.UpdateGame(statusEdited); err != nil {
+		t.Fatalf("更新状态失败: %v", err)
+	}
+
+	preview, err := bangumiSvc.PreviewCollectionSync()
+	if err != nil {
+		t.Fatalf("预览 Bangumi 收藏同步失败: %v", err)
+	}
+	directions := map[string]string{}
+	for _, item := range preview.Items {
+		for _, change := range item.Changes {
+			directions[item.SubjectID+"/"+change.Field] = change.Direction
+		}
+	}
+	if directions["305/status"] != "to_local" {
+		t.Fatalf("只编辑封面不应让本地状态显得更新: %+v", directions)
+	}
+	if directions["306/status"] != "to_remote" {
+		t.Fatalf("本地状态变更晚于 Bangumi 时应推送到远端: %+v", directions)
+	}
+}
+
+func assertLocalReview(t *testing.T, db *sql.DB, gameID string, rating int, content string) {
+	t.Helper()
+	var gotRating int
+	var gotContent string
+	if err := db.QueryRow(`SELECT rating, content FROM game_reviews WHERE game_id = ?`, gameID).Scan(&gotRating, &gotContent); err != nil {
+		t.Fatalf("读取游戏 %s 的评价失败: %v", gameID, err)
+	}
+	if gotRating != rating || gotContent != content {
+		t.Fatalf("游戏 %s 的评价应为 %d/%q，实际为 %d/%q", gameID, rating, content, gotRating, gotContent)
+	}
+}
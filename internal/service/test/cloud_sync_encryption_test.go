@@ -0,0 +1,193 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"lunabox/internal/service/cloudprovider"
+	"lunabox/internal/service/cloudprovider/umbra"
+	"lunabox/internal/service/cloudsync"
+	"lunabox/internal/utils"
+)
+
+func newEncryptedSyncProvider(t *testing.T, raw cloudprovider.CloudStorageProvider, password string) cloudprovider.CloudStorageProvider {
+	t.Helper()
+	_, encoded, err := utils.DeriveBackupCredentials(password)
+	if err != nil {
+		t.Fatalf("derive key: %v", err)
+	}
+	key, err := utils.DecodeBackupEncryptionKey(encoded)
+	if err != nil {
+		t.Fatalf("decode key: %v", err)
+	}
+	return cloudprovider.NewEncryptedProvider(raw, key)
+}
+
+func TestSyncToCloud_EncryptedProviderNeverStoresPlaintext(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	cfg := newSyncTestConfig()
+	now := time.Now().UTC()
+	if _, err := db.Exec(`INSERT INTO games (id, name, created_at, updated_at) VALUES (?, ?, ?, ?)`, "3aaa", "Secret Title", now, now); err != nil {
+		t.Fatal(err)
+	}
+
+	raw := newMockProvider()
+	provider := newEncryptedSyncProvider(t, raw, "team-password")
+	helper := cloudsync.NewHelper(ctx, db, cfg)
+	if err := helper.SyncToCloud(provider); err != nil {
+		t.Fatalf("SyncToCloud failed: %v", err)
+	}
+
+	raw.mu.Lock()
+	defer raw.mu.Unlock()
+	if len(raw.store) == 0 {
+		t.Fatal("expected uploads")
+	}
+	for key, payload := range raw.store {
+		if !utils.IsBackupEnvelope(payload) {
+			t.Errorf("%s stored without encryption envelope", key)
+		}
+	}
+}
+
+func TestSyncToCloud_EncryptedProviderRejectsPlaintextObjects(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	cfg := newSyncTestConfig()
+
+	raw := newMockProvider()
+	v1Path := raw.GetCloudPath(cfg.BackupUserID, cloudsync.SnapshotKey)
+	raw.store[v1Path] = []byte(`{
+		"schema_version": 1,
+		"revision_id": "v1-rev",
+		"games": [
+			{"id": "5ccc", "name": "G-Remote", "updated_at": "2026-06-15T11:00:00Z", "created_at": "2026-06-15T11:00:00Z"}
+		]
+	}`)
+
+	helper := cloudsync.NewHelper(ctx, db, cfg)
+	err := helper.SyncToCloud(newEncryptedSyncProvider(t, raw, "team-password"))
+	if !errors.Is(err, utils.ErrBackupEnvelopeMissing) {
+		t.Fatalf("SyncToCloud over plaintext object error = %v, want ErrBackupEnvelopeMissing", err)
+	}
+
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM games WHERE id = ?`, "5ccc").Scan(&count); err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Fatalf("plaintext object must not be merged, got count=%d", count)
+	}
+}
+
+func TestSyncToCloud_EncryptedProviderRejectsOtherPassword(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	cfg := newSyncTestConfig()
+	now := time.Now().UTC()
+	if _, err := db.Exec(`INSERT INTO games (id, name, created_at, updated_at) VALUES (?, ?, ?, ?)`, "3aaa", "G1", now, now); err != nil {
+		t.Fatal(err)
+	}
+
+	raw := newMockProvider()
+	helper := cloudsync.NewHelper(ctx, db, cfg)
+	if err := helper.SyncToCloud(newEncryptedSyncProvider(t, raw, "team-password")); err != nil {
+		t.Fatalf("first sync failed: %v", err)
+	}
+
+	otherDB, otherCleanup := setupTestDB(t)
+	defer otherCleanup()
+	otherHelper := cloudsync.NewHelper(ctx, otherDB, cfg)
+	err := otherHelper.SyncToCloud(newEncryptedSyncProvider(t, raw, "wrong-password"))
+	if !errors.Is(err, utils.ErrBackupKeyMismatch) {
+		t.Fatalf("SyncToCloud with other password error = %v, want ErrBackupKeyMismatch", err)
+	}
+}
+
+// umbraMappedProvider 按 Umbra 的路径映射要求同步记录为有效 JSON，与 umbra readSyncUpload 一致
+type umbraMappedProvider struct {
+	*mockProvider
+	userID string
+}
+
+func (p *umbraMappedProvider) RequiresJSONPayload(cloudPath string) bool {
+	return umbra.IsSyncRecordSubPath(strings.TrimPrefix(cloudPath, "v1/"+p.userID+"/"))
+}
+
+func (p *umbraMappedProvider) UploadFile(ctx context.Context, cloudPath, localPath string) error {
+	if p.RequiresJSONPayload(cloudPath) {
+		payload, err := os.ReadFile(localPath)
+		if err != nil {
+			return err
+		}
+		if !json.Valid(payload) {
+			return fmt.Errorf("Umbra 同步文件不是有效 JSON: %s", cloudPath)
+		}
+	}
+	return p.mockProvider.UploadFile(ctx, cloudPath, localPath)
+}
+
+func TestSyncToCloud_EncryptedProviderWrapsUmbraSyncRecordsAsJSON(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	cfg := newSyncTestConfig()
+	now := time.Now().UTC()
+	if _, err := db.Exec(`INSERT INTO games (id, name, created_at, updated_at) VALUES (?, ?, ?, ?)`, "3aaa", "Secret Title", now, now); err != nil {
+		t.Fatal(err)
+	}
+
+	raw := &umbraMappedProvider{mockProvider: newMockProvider(), userID: cfg.BackupUserID}
+	helper := cloudsync.NewHelper(ctx, db, cfg)
+	if err := helper.SyncToCloud(newEncryptedSyncProvider(t, raw, "team-password")); err != nil {
+		t.Fatalf("SyncToCloud failed: %v", err)
+	}
+
+	raw.mu.Lock()
+	syncRecords := 0
+	for key, payload := range raw.store {
+		if raw.RequiresJSONPayload(key) {
+			syncRecords++
+			if !utils.IsBackupJSONEnvelope(payload) {
+				t.Errorf("%s stored without JSON encryption envelope", key)
+			}
+		} else if !utils.IsBackupEnvelope(payload) {
+			t.Errorf("%s stored without encryption envelope", key)
+		}
+		if strings.Contains(string(payload), "Secret Title") {
+			t.Errorf("%s leaks plaintext", key)
+		}
+	}
+	raw.mu.Unlock()
+	if syncRecords == 0 {
+		t.Fatal("expected Umbra sync records to be uploaded")
+	}
+
+	otherDB, otherCleanup := setupTestDB(t)
+	defer otherCleanup()
+	otherHelper := cloudsync.NewHelper(ctx, otherDB, cfg)
+	if err := otherHelper.SyncToCloud(newEncryptedSyncProvider(t, raw, "team-password")); err != nil {
+		t.Fatalf("second device SyncToCloud failed: %v", err)
+	}
+	var name string
+	if err := otherDB.QueryRow(`SELECT name FROM games WHERE id = ?`, "3aaa").Scan(&name); err != nil {
+		t.Fatalf("expected game to sync to second device: %v", err)
+	}
+	if name != "Secret Title" {
+		t.Fatalf("synced name = %q", name)
+	}
+}
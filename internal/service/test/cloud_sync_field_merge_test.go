@@ -0,0 +1,144 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"lunabox/internal/service/cloudsync"
+)
+
+func queryGameFields(t *testing.T, db *sql.DB, id string) (status, summary string) {
+	t.Helper()
+	if err := db.QueryRow(`SELECT COALESCE(status, ''), COALESCE(summary, '') FROM games WHERE id = ?`, id).Scan(&status, &summary); err != nil {
+		t.Fatal(err)
+	}
+	return status, summary
+}
+
+func TestSyncToCloud_ThreeWayMergeKeepsEditsFromBothDevices(t *testing.T) {
+	ctx := context.Background()
+	cfg := newSyncTestConfig()
+	provider := newMockProvider()
+	synced := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+
+	dbA, cleanupA := setupTestDB(t)
+	defer cleanupA()
+	dbB, cleanupB := setupTestDB(t)
+	defer cleanupB()
+	helperA := cloudsync.NewHelper(ctx, dbA, cfg)
+	helperB := cloudsync.NewHelper(ctx, dbB, cfg)
+
+	if _, err := dbA.Exec(`INSERT INTO games (id, name, status, summary, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		"3aaa", "G1", "not_started", "original", synced, synced); err != nil {
+		t.Fatal(err)
+	}
+	if err := helperA.SyncToCloud(provider); err != nil {
+		t.Fatalf("device A bootstrap: %v", err)
+	}
+	if err := helperB.SyncToCloud(provider); err != nil {
+		t.Fatalf("device B initial pull: %v", err)
+	}
+
+	// A 改状态，B 改简介（B 更晚）；整条 LWW 会丢掉 A 的状态修改
+	if _, err := dbA.Exec(`UPDATE games SET status = ?, updated_at = ? WHERE id = ?`, "playing", synced.Add(10*time.Minute), "3aaa"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dbB.Exec(`UPDATE games SET summary = ?, updated_at = ? WHERE id = ?`, "edited on B", synced.Add(20*time.Minute), "3aaa"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := helperA.SyncToCloud(provider); err != nil {
+		t.Fatalf("device A push: %v", err)
+	}
+	if err := helperB.SyncToCloud(provider); err != nil {
+		t.Fatalf("device B merge: %v", err)
+	}
+	if err := helperA.SyncToCloud(provider); err != nil {
+		t.Fatalf("device A pull: %v", err)
+	}
+
+	for name, db := range map[string]*sql.DB{"A": dbA, "B": dbB} {
+		status, summary := queryGameFields(t, db, "3aaa")
+		if status != "playing" || summary != "edited on B" {
+			t.Fatalf("device %s: status=%q summary=%q, want both edits kept", name, status, summary)
+		}
+	}
+
+	conflicts, err := cloudsync.ListConflicts(ctx, dbB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conflicts) != 0 {
+		t.Fatalf("expected no conflicts for disjoint edits, got %+v", conflicts)
+	}
+}
+
+func TestSyncToCloud_ThreeWayMergeRecordsAndResolvesConflict(t *testing.T) {
+	ctx := context.Background()
+	cfg := newSyncTestConfig()
+	provider := newMockProvider()
+	synced := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+
+	dbA, cleanupA := setupTestDB(t)
+	defer cleanupA()
+	dbB, cleanupB := setupTestDB(t)
+	defer cleanupB()
+	helperA := cloudsync.NewHelper(ctx, dbA, cfg)
+	helperB := cloudsync.NewHelper(ctx, dbB, cfg)
+
+	if _, err := dbA.Exec(`INSERT INTO games (id, name, status, created_at, updated_at) VALUES (?, ?, ?, ?, ?)`,
+		"3aaa", "G1", "not_started", synced, synced); err != nil {
+		t.Fatal(err)
+	}
+	if err := helperA.SyncToCloud(provider); err != nil {
+		t.Fatal(err)
+	}
+	if err := helperB.SyncToCloud(provider); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := dbA.Exec(`UPDATE games SET status = ?, updated_at = ? WHERE id = ?`, "completed", synced.Add(10*time.Minute), "3aaa"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dbB.Exec(`UPDATE games SET status = ?, updated_at = ? WHERE id = ?`, "on_hold", synced.Add(20*time.Minute), "3aaa"); err != nil {
+		t.Fatal(err)
+	}
+	if err := helperA.SyncToCloud(provider); err != nil {
+		t.Fatal(err)
+	}
+	if err := helperB.SyncToCloud(provider); err != nil {
+		t.Fatal(err)
+	}
+
+	conflicts, err := cloudsync.ListConflicts(ctx, dbB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conflicts) != 1 || conflicts[0].Field != "status" || conflicts[0].Resolution != cloudsync.ConflictResolutionLocal {
+		t.Fatalf("unexpected conflicts: %+v", conflicts)
+	}
+	if status, _ := queryGameFields(t, dbB, "3aaa"); status != "on_hold" {
+		t.Fatalf("LWW should keep the newer local value, got %q", status)
+	}
+
+	// 用户在 B 上改为采用云端（A）的取值，下一次同步后两端一致
+	if err := helperB.ResolveConflict(cloudsync.EntityGame, "3aaa", "status", cloudsync.ConflictResolutionRemote); err != nil {
+		t.Fatalf("ResolveConflict: %v", err)
+	}
+	if conflicts, _ := cloudsync.ListConflicts(ctx, dbB); len(conflicts) != 0 {
+		t.Fatalf("conflict should be cleared after resolve, got %+v", conflicts)
+	}
+	if err := helperB.SyncToCloud(provider); err != nil {
+		t.Fatal(err)
+	}
+	if err := helperA.SyncToCloud(provider); err != nil {
+		t.Fatal(err)
+	}
+	for name, db := range map[string]*sql.DB{"A": dbA, "B": dbB} {
+		if status, _ := queryGameFields(t, db, "3aaa"); status != "completed" {
+			t.Fatalf("device %s status = %q, want resolved remote value", name, status)
+		}
+	}
+}
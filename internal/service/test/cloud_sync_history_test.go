@@ -0,0 +1,197 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"lunabox/internal/service/cloudsync"
+)
+
+func countStoreKeys(p *mockProvider, prefix string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	count := 0
+	for key := range p.store {
+		if strings.HasPrefix(key, prefix) {
+			count++
+		}
+	}
+	return count
+}
+
+func TestSyncToCloud_RecordsRevisionHistoryAndPrunesOldRevisions(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	cfg := newSyncTestConfig()
+	cfg.CloudSyncHistoryLimit = 2
+	provider := newMockProvider()
+	helper := cloudsync.NewHelper(ctx, db, cfg)
+	start := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+
+	if _, err := db.Exec(`INSERT INTO games (id, name, status, created_at, updated_at) VALUES (?, ?, ?, ?, ?)`,
+		"1aaa", "G1", "not_started", start, start); err != nil {
+		t.Fatal(err)
+	}
+	var revisions []string
+	for i := 0; i < 3; i++ {
+		if i > 0 {
+			if _, err := db.Exec(`UPDATE games SET summary = ?, updated_at = ? WHERE id = ?`,
+				strings.Repeat("x", i), start.Add(time.Duration(i)*time.Minute), "1aaa"); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := helper.SyncToCloud(provider); err != nil {
+			t.Fatalf("sync %d: %v", i, err)
+		}
+		manifest, _, err := helper.LoadRemoteManifest(provider)
+		if err != nil {
+			t.Fatal(err)
+		}
+		revisions = append(revisions, manifest.RevisionID)
+	}
+
+	index, err := helper.LoadHistoryIndex(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(index.Revisions) != 2 || index.Revisions[0].RevisionID != revisions[2] || index.Revisions[1].RevisionID != revisions[1] {
+		t.Fatalf("unexpected history index: %+v (revisions %v)", index.Revisions, revisions)
+	}
+	if index.Revisions[0].GameCount != 1 {
+		t.Fatalf("game count = %d, want 1", index.Revisions[0].GameCount)
+	}
+
+	manifestDir := provider.GetCloudPath(cfg.BackupUserID, cloudsync.HistoryManifestDir)
+	if got := countStoreKeys(provider, manifestDir); got != 2 {
+		t.Fatalf("history manifests = %d, want 2 after pruning", got)
+	}
+	// 每个 revision 的游戏桶内容都不同；被裁剪的第一个 revision 独占的游戏桶对象应被 GC 删除
+	gamesObjectPrefix := provider.GetCloudPath(cfg.BackupUserID, cloudsync.HistoryObjectDir+"/games-")
+	if got := countStoreKeys(provider, gamesObjectPrefix); got != 2 {
+		t.Fatalf("history game objects = %d, want 2 after GC", got)
+	}
+
+	if _, err := helper.LoadRevisionSnapshot(provider, revisions[0]); !errors.Is(err, cloudsync.ErrRevisionNotFound) {
+		t.Fatalf("pruned revision should be gone, got %v", err)
+	}
+	snapshot, err := helper.LoadRevisionSnapshot(provider, revisions[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(snapshot.Games) != 1 || snapshot.Games[0].Summary != "x" {
+		t.Fatalf("unexpected revision snapshot games: %+v", snapshot.Games)
+	}
+}
+
+func TestRollbackToRevision_RestoresLibraryOnAllDevices(t *testing.T) {
+	ctx := context.Background()
+	cfg := newSyncTestConfig()
+	provider := newMockProvider()
+	start := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+
+	dbA, cleanupA := setupTestDB(t)
+	defer cleanupA()
+	dbB, cleanupB := setupTestDB(t)
+	defer cleanupB()
+	helperA := cloudsync.NewHelper(ctx, dbA, cfg)
+	helperB := cloudsync.NewHelper(ctx, dbB, cfg)
+
+	if _, err := dbA.Exec(`INSERT INTO games (id, name, status, created_at, updated_at) VALUES (?, ?, ?, ?, ?), (?, ?, ?, ?, ?)`,
+		"1aaa", "Keep", "playing", start, start,
+		"2bbb", "Deleted later", "completed", start, start); err != nil {
+		t.Fatal(err)
+	}
+	if err := helperA.SyncToCloud(provider); err != nil {
+		t.Fatal(err)
+	}
+	good, _, err := helperA.LoadRemoteManifest(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := helperB.SyncToCloud(provider); err != nil {
+		t.Fatal(err)
+	}
+
+	// 设备 B 上发生了一次错误操作：改坏一条、删掉一条、新增一条
+	later := start.Add(10 * time.Minute)
+	if _, err := dbB.Exec(`UPDATE games SET status = ?, updated_at = ? WHERE id = ?`, "on_hold", later, "1aaa"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dbB.Exec(`DELETE FROM games WHERE id = ?`, "2bbb"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cloudsync.UpsertTombstone(ctx, dbB, cloudsync.EntityGame, "2bbb", later); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dbB.Exec(`INSERT INTO games (id, name, status, created_at, updated_at) VALUES (?, ?, ?, ?, ?)`,
+		"3ccc", "Added by mistake", "not_started", later, later); err != nil {
+		t.Fatal(err)
+	}
+	if err := helperB.SyncToCloud(provider); err != nil {
+		t.Fatal(err)
+	}
+	if err := helperA.SyncToCloud(provider); err != nil {
+		t.Fatal(err)
+	}
+
+	localA, err := helperA.BuildLocalState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	revision, err := helperA.LoadRevisionSnapshot(provider, good.RevisionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes := map[string]string{}
+	for _, change := range cloudsync.DiffSnapshots(localA.Snapshot, revision) {
+		if change.EntityType == cloudsync.EntityGame {
+			changes[change.EntityID] = change.Change
+		}
+	}
+	want := map[string]string{"1aaa": cloudsync.RecordChanged, "2bbb": cloudsync.RecordOnlyRevision, "3ccc": cloudsync.RecordOnlyLocal}
+	for id, change := range want {
+		if changes[id] != change {
+			t.Fatalf("diff %s = %q, want %q (all: %v)", id, changes[id], change, changes)
+		}
+	}
+
+	if err := helperA.RollbackToRevision(provider, good.RevisionID); err != nil {
+		t.Fatalf("RollbackToRevision: %v", err)
+	}
+	if err := helperB.SyncToCloud(provider); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, db := range map[string]*sql.DB{"A": dbA, "B": dbB} {
+		rows, err := db.Query(`SELECT id, status FROM games ORDER BY id`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := map[string]string{}
+		for rows.Next() {
+			var id, status string
+			if err := rows.Scan(&id, &status); err != nil {
+				t.Fatal(err)
+			}
+			got[id] = status
+		}
+		rows.Close()
+		if len(got) != 2 || got["1aaa"] != "playing" || got["2bbb"] != "completed" {
+			t.Fatalf("device %s games after rollback = %v", name, got)
+		}
+	}
+
+	latest, _, err := helperA.LoadRemoteManifest(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest.RevisionID == good.RevisionID {
+		t.Fatal("rollback should be published as a new revision")
+	}
+}
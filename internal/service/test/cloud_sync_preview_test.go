@@ -0,0 +1,112 @@
+package test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"lunabox/internal/service/cloudsync"
+)
+
+func TestPreviewSync_ReportsChangesWithoutWriting(t *testing.T) {
+	ctx := context.Background()
+	cfg := newSyncTestConfig()
+	provider := newMockProvider()
+	start := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+
+	dbA, cleanupA := setupTestDB(t)
+	defer cleanupA()
+	dbB, cleanupB := setupTestDB(t)
+	defer cleanupB()
+	helperA := cloudsync.NewHelper(ctx, dbA, cfg)
+	helperB := cloudsync.NewHelper(ctx, dbB, cfg)
+
+	if _, err := dbA.Exec(`INSERT INTO games (id, name, status, created_at, updated_at) VALUES (?, ?, ?, ?, ?), (?, ?, ?, ?, ?)`,
+		"1aaa", "Shared", "playing", start, start,
+		"2bbb", "To delete", "completed", start, start); err != nil {
+		t.Fatal(err)
+	}
+	if err := helperA.SyncToCloud(provider); err != nil {
+		t.Fatal(err)
+	}
+	if err := helperB.SyncToCloud(provider); err != nil {
+		t.Fatal(err)
+	}
+
+	// A 删除一个游戏并推送；B 本地新增一次游玩记录
+	later := start.Add(10 * time.Minute)
+	if _, err := dbA.Exec(`DELETE FROM games WHERE id = ?`, "2bbb"); err != nil {
+		t.Fatal(err)
+	}
+	if err := cloudsync.UpsertTombstone(ctx, dbA, cloudsync.EntityGame, "2bbb", later); err != nil {
+		t.Fatal(err)
+	}
+	if err := helperA.SyncToCloud(provider); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dbB.Exec(`INSERT INTO play_sessions (id, game_id, start_time, end_time, duration, updated_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		"s1", "1aaa", later, later.Add(time.Hour), 3600, later); err != nil {
+		t.Fatal(err)
+	}
+
+	provider.mu.Lock()
+	storeBefore := len(provider.store)
+	uploadsBefore := len(provider.uploadLog)
+	provider.mu.Unlock()
+	stateBefore, err := cloudsync.LoadSyncState(ctx, dbB)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	preview, err := helperB.PreviewSync(provider)
+	if err != nil {
+		t.Fatalf("PreviewSync: %v", err)
+	}
+
+	provider.mu.Lock()
+	if len(provider.store) != storeBefore || len(provider.uploadLog) != uploadsBefore {
+		t.Errorf("preview must not touch remote: store %d→%d uploads %d→%d", storeBefore, len(provider.store), uploadsBefore, len(provider.uploadLog))
+	}
+	provider.mu.Unlock()
+	var gameCount int
+	if err := dbB.QueryRow(`SELECT COUNT(*) FROM games`).Scan(&gameCount); err != nil {
+		t.Fatal(err)
+	}
+	if gameCount != 2 {
+		t.Errorf("preview must not apply locally, games = %d", gameCount)
+	}
+	stateAfter, err := cloudsync.LoadSyncState(ctx, dbB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stateAfter[cloudsync.StateKeyManifest].RemoteRevisionID != stateBefore[cloudsync.StateKeyManifest].RemoteRevisionID {
+		t.Error("preview must not persist cloud_sync_state")
+	}
+
+	hasChange := func(changes []cloudsync.RecordChange, entityType, entityID, change string) bool {
+		for _, c := range changes {
+			if c.EntityType == entityType && c.EntityID == entityID && c.Change == change {
+				return true
+			}
+		}
+		return false
+	}
+	if !preview.RemoteExists {
+		t.Error("expected remote to exist")
+	}
+	if !hasChange(preview.Local, cloudsync.EntityGame, "2bbb", cloudsync.RecordDeleted) {
+		t.Errorf("local side should delete game 2bbb: %+v", preview.Local)
+	}
+	if !hasChange(preview.Local, cloudsync.EntityTombstone, "game/2bbb", cloudsync.RecordAdded) {
+		t.Errorf("local side should gain tombstone game/2bbb: %+v", preview.Local)
+	}
+	if !hasChange(preview.Remote, cloudsync.EntityPlaySession, "s1", cloudsync.RecordAdded) {
+		t.Errorf("remote side should gain play session s1: %+v", preview.Remote)
+	}
+	if hasChange(preview.Remote, cloudsync.EntityGame, "2bbb", cloudsync.RecordAdded) {
+		t.Errorf("deleted game must not be resurrected on remote: %+v", preview.Remote)
+	}
+	if preview.GameNames["2bbb"] != "To delete" {
+		t.Errorf("game names should include deleted game, got %v", preview.GameNames)
+	}
+}
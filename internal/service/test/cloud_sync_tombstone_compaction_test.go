@@ -0,0 +1,212 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"lunabox/internal/service/cloudsync"
+)
+
+// rewriteRemoteDevices 直接改写远端 manifest 的设备登记，模拟时间流逝或其他设备的同步记录
+func rewriteRemoteDevices(t *testing.T, helper *cloudsync.Helper, provider *mockProvider, devices map[string]time.Time, since time.Time) {
+	t.Helper()
+	manifest, _, err := helper.LoadRemoteManifest(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest.Devices = devices
+	manifest.DevicesSince = since
+	if err := helper.SaveRemoteManifest(provider, manifest); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func onlyDevice(t *testing.T, helper *cloudsync.Helper, provider *mockProvider) string {
+	t.Helper()
+	manifest, _, err := helper.LoadRemoteManifest(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(manifest.Devices) != 1 {
+		t.Fatalf("devices = %v, want exactly the current device", manifest.Devices)
+	}
+	for id := range manifest.Devices {
+		return id
+	}
+	return ""
+}
+
+func countRows(t *testing.T, db *sql.DB, query string, args ...any) int {
+	t.Helper()
+	var n int
+	if err := db.QueryRow(query, args...).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+func TestSyncToCloud_CompactsTombstonesOnceEveryDeviceHasSeenThem(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	cfg := newSyncTestConfig()
+	cfg.CloudSyncTombstoneHorizonDays = 7
+	provider := newMockProvider()
+	helper := cloudsync.NewHelper(ctx, db, cfg)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	if _, err := db.Exec(`INSERT INTO games (id, name, status, created_at, updated_at) VALUES (?, ?, ?, ?, ?)`,
+		"1aaa", "Kept", "playing", now.Add(-time.Hour), now.Add(-time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if err := cloudsync.UpsertTombstone(ctx, db, cloudsync.EntityGame, "2bbb", now.AddDate(0, 0, -30)); err != nil {
+		t.Fatal(err)
+	}
+	if err := helper.SyncToCloud(provider); err != nil {
+		t.Fatalf("bootstrap: %v", err)
+	}
+	if got := countRows(t, db, `SELECT COUNT(*) FROM sync_tombstones WHERE published_at IS NOT NULL`); got != 1 {
+		t.Fatalf("published tombstones = %d, want 1 after first upload", got)
+	}
+	self := onlyDevice(t, helper, provider)
+
+	// 墓碑五天前发布；另一台设备最后一次同步在六天前，尚未看到它
+	publishedAt := now.AddDate(0, 0, -5)
+	if _, err := db.Exec(`UPDATE sync_tombstones SET published_at = ?`, publishedAt); err != nil {
+		t.Fatal(err)
+	}
+	rewriteRemoteDevices(t, helper, provider, map[string]time.Time{
+		self:     now.Add(-48 * time.Hour),
+		"laptop": now.AddDate(0, 0, -6),
+	}, now.AddDate(0, 0, -60))
+	if err := helper.SyncToCloud(provider); err != nil {
+		t.Fatalf("sync with lagging device: %v", err)
+	}
+	if got := countRows(t, db, `SELECT COUNT(*) FROM sync_tombstones`); got != 1 {
+		t.Fatalf("tombstone unseen by laptop must be kept, got %d", got)
+	}
+
+	// laptop 之后同步过一次：所有设备都已看到该墓碑，可以压缩
+	rewriteRemoteDevices(t, helper, provider, map[string]time.Time{
+		self:     now.Add(-48 * time.Hour),
+		"laptop": now.Add(-24 * time.Hour),
+	}, now.AddDate(0, 0, -60))
+	if err := helper.SyncToCloud(provider); err != nil {
+		t.Fatalf("sync after laptop caught up: %v", err)
+	}
+	if got := countRows(t, db, `SELECT COUNT(*) FROM sync_tombstones`); got != 0 {
+		t.Fatalf("local tombstones = %d, want compacted", got)
+	}
+	_, remoteTombstones, _, err := helper.LoadRemoteSingletons(provider, []string{cloudsync.SingletonTombstones})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(remoteTombstones) != 0 {
+		t.Fatalf("remote tombstones = %+v, want compacted", remoteTombstones)
+	}
+	manifest, _, err := helper.LoadRemoteManifest(provider)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !manifest.TombstonesCompactedThrough.Equal(publishedAt) {
+		t.Errorf("compacted through = %s, want %s", manifest.TombstonesCompactedThrough, publishedAt)
+	}
+	if _, ok := manifest.Devices["laptop"]; !ok || !manifest.Devices[self].After(now.Add(-time.Hour)) {
+		t.Errorf("device registry = %v, want laptop kept and current device refreshed", manifest.Devices)
+	}
+	if got := countRows(t, db, `SELECT COUNT(*) FROM games WHERE id = '1aaa'`); got != 1 {
+		t.Error("live game must survive compaction")
+	}
+}
+
+func TestSyncToCloud_DeviceReturningAfterCompactionDoesNotResurrect(t *testing.T) {
+	ctx := context.Background()
+	cfg := newSyncTestConfig()
+	cfg.CloudSyncTombstoneHorizonDays = 7
+	provider := newMockProvider()
+	now := time.Now().UTC().Truncate(time.Second)
+	created := now.Add(-time.Hour)
+
+	dbA, cleanupA := setupTestDB(t)
+	defer cleanupA()
+	dbB, cleanupB := setupTestDB(t)
+	defer cleanupB()
+	helperA := cloudsync.NewHelper(ctx, dbA, cfg)
+	helperB := cloudsync.NewHelper(ctx, dbB, cfg)
+
+	if _, err := dbA.Exec(`INSERT INTO games (id, name, status, created_at, updated_at) VALUES (?, ?, ?, ?, ?), (?, ?, ?, ?, ?)`,
+		"1aaa", "Kept", "playing", created, created,
+		"2bbb", "Deleted", "completed", created, created); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dbA.Exec(`INSERT INTO play_sessions (id, game_id, start_time, end_time, duration, updated_at) VALUES (?, ?, ?, ?, ?, ?), (?, ?, ?, ?, ?, ?)`,
+		"s-keep", "1aaa", created, created.Add(time.Hour), 3600, created,
+		"s-drop", "1aaa", created, created.Add(time.Hour), 3600, created); err != nil {
+		t.Fatal(err)
+	}
+	if err := helperA.SyncToCloud(provider); err != nil {
+		t.Fatalf("device A bootstrap: %v", err)
+	}
+	if err := helperB.SyncToCloud(provider); err != nil {
+		t.Fatalf("device B initial pull: %v", err)
+	}
+
+	// A 删除一个游戏和一条游玩记录，随后这些墓碑过期并在 B 离线期间被压缩
+	if _, err := dbA.Exec(`DELETE FROM games WHERE id = '2bbb'`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := dbA.Exec(`DELETE FROM play_sessions WHERE id = 's-drop'`); err != nil {
+		t.Fatal(err)
+	}
+	if err := cloudsync.UpsertTombstone(ctx, dbA, cloudsync.EntityGame, "2bbb", now); err != nil {
+		t.Fatal(err)
+	}
+	if err := cloudsync.UpsertTombstone(ctx, dbA, cloudsync.EntityPlaySession, "s-drop", now); err != nil {
+		t.Fatal(err)
+	}
+	if err := helperA.SyncToCloud(provider); err != nil {
+		t.Fatalf("device A push deletions: %v", err)
+	}
+	self := onlyDevice(t, helperA, provider)
+	if _, err := dbA.Exec(`UPDATE sync_tombstones SET deleted_at = ?, published_at = ?`, now.AddDate(0, 0, -30), now.AddDate(0, 0, -20)); err != nil {
+		t.Fatal(err)
+	}
+	rewriteRemoteDevices(t, helperA, provider, map[string]time.Time{self: now.Add(-48 * time.Hour)}, now.AddDate(0, 0, -60))
+	if err := helperA.SyncToCloud(provider); err != nil {
+		t.Fatalf("device A compaction: %v", err)
+	}
+	_, remoteTombstones, _, err := helperA.LoadRemoteSingletons(provider, []string{cloudsync.SingletonTombstones})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(remoteTombstones) != 0 {
+		t.Fatalf("remote tombstones = %+v, want compacted before B returns", remoteTombstones)
+	}
+
+	// 两个测试库共享主机名作为设备 ID，改写登记使 B 看起来已因离线超期被移出
+	rewriteRemoteDevices(t, helperA, provider, map[string]time.Time{"desktop": now}, now.AddDate(0, 0, -60))
+	if err := helperB.SyncToCloud(provider); err != nil {
+		t.Fatalf("device B returns: %v", err)
+	}
+	if err := helperA.SyncToCloud(provider); err != nil {
+		t.Fatalf("device A pull: %v", err)
+	}
+
+	for name, db := range map[string]*sql.DB{"A": dbA, "B": dbB} {
+		if got := countRows(t, db, `SELECT COUNT(*) FROM games WHERE id = '2bbb'`); got != 0 {
+			t.Errorf("device %s: compacted game deletion was resurrected", name)
+		}
+		if got := countRows(t, db, `SELECT COUNT(*) FROM play_sessions WHERE id = 's-drop'`); got != 0 {
+			t.Errorf("device %s: compacted session deletion was resurrected", name)
+		}
+		if got := countRows(t, db, `SELECT COUNT(*) FROM play_sessions WHERE id = 's-keep'`); got != 1 {
+			t.Errorf("device %s: surviving session lost", name)
+		}
+		if got := countRows(t, db, `SELECT COUNT(*) FROM games WHERE id = '1aaa'`); got != 1 {
+			t.Errorf("device %s: surviving game lost", name)
+		}
+	}
+}
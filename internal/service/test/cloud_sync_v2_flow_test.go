@@ -289,3 +289,46 @@ func TestSyncToCloud_MigrationFromV1(t *testing.T) {
 		t.Errorf("expected v1-remote game 5ccc to be merged into local DB, got count=%d", count)
 	}
 }
+
+func TestSyncToCloud_LocalDirectoryProviderSyncsBetweenDevices(t *testing.T) {
+	ctx := context.Background()
+	cfg := newSyncTestConfig()
+	cfg.CloudBackupProvider = string(cloudprovider.ProviderLocal)
+	cfg.LocalSyncPath = t.TempDir()
+	if !cloudprovider.IsConfigured(cfg) {
+		t.Fatal("local provider should be configured")
+	}
+	provider, err := cloudprovider.NewCloudProvider(ctx, cfg)
+	if err != nil {
+		t.Fatalf("NewCloudProvider: %v", err)
+	}
+
+	dbA, cleanupA := setupTestDB(t)
+	defer cleanupA()
+	dbB, cleanupB := setupTestDB(t)
+	defer cleanupB()
+	now := time.Now().UTC().Truncate(time.Second)
+	if _, err := dbA.Exec(`INSERT INTO games (id, name, status, created_at, updated_at) VALUES (?, ?, ?, ?, ?)`,
+		"1aaa", "On the NAS", "playing", now, now); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := cloudsync.NewHelper(ctx, dbA, cfg).SyncToCloud(provider); err != nil {
+		t.Fatalf("sync A: %v", err)
+	}
+	if err := cloudsync.NewHelper(ctx, dbB, cfg).SyncToCloud(provider); err != nil {
+		t.Fatalf("sync B: %v", err)
+	}
+
+	var name string
+	if err := dbB.QueryRow(`SELECT name FROM games WHERE id = ?`, "1aaa").Scan(&name); err != nil {
+		t.Fatalf("game not pulled to device B: %v", err)
+	}
+	if name != "On the NAS" {
+		t.Fatalf("name = %q", name)
+	}
+	manifest := filepath.Join(cfg.LocalSyncPath, "LunaBox", "v1", cfg.BackupUserID, filepath.FromSlash(cloudsync.ManifestKey))
+	if _, err := os.Stat(manifest); err != nil {
+		t.Fatalf("manifest not written to the sync directory: %v", err)
+	}
+}
@@ -0,0 +1,125 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"lunabox/internal/appconf"
+	"lunabox/internal/applog"
+	"lunabox/internal/common/enums"
+	"lunabox/internal/common/vo"
+	"lunabox/internal/service"
+)
+
+func TestDataExportServiceWritesCSVNDJSONAndICS(t *testing.T) {
+	applog.SetMode(applog.ModeCLI)
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	insertBangumiGame(t, db, "alpha", enums.StatusPlaying, enums.Local, "")
+	insertBangumiGame(t, db, "beta", enums.StatusCompleted, enums.Local, "")
+	if _, err := db.Exec(`UPDATE games SET name = 'Alpha, Part 1; Remake' WHERE id = 'alpha'`); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	if _, err := db.Exec(`INSERT INTO categories (id, name, created_at, updated_at, is_system) VALUES ('cat-1', 'Favorites', ?, ?, FALSE)`, now, now); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`INSERT INTO game_categories (game_id, category_id) VALUES ('alpha', 'cat-1')`); err != nil {
+		t.Fatal(err)
+	}
+	sessions := []struct {
+		id, gameID string
+		start      time.Time
+		duration   int
+	}{
+		{"s1", "alpha", time.Date(2026, 5, 1, 20, 0, 0, 0, time.Local), 3600},
+		{"s2", "alpha", time.Date(2026, 5, 3, 21, 0, 0, 0, time.Local), 1800},
+		{"s3", "beta", time.Date(2026, 5, 2, 22, 0, 0, 0, time.Local), 600},
+		{"s4", "alpha", time.Date(2026, 6, 1, 10, 0, 0, 0, time.Local), 900},
+	}
+	for _, session := range sessions {
+		if _, err := db.Exec(`INSERT INTO play_sessions (id, game_id, start_time, end_time, duration) VALUES (?, ?, ?, ?, ?)`,
+			session.id, session.gameID, session.start, session.start.Add(time.Duration(session.duration)*time.Second), session.duration); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	exportSvc := service.NewDataExportService()
+	exportSvc.Init(context.Background(), db, &appconf.AppConfig{})
+
+	var buf bytes.Buffer
+	rows, err := exportSvc.WriteExport(&buf, vo.DataExportRequest{
+		Entity:    enums.ExportSessions,
+		StartDate: "2026-05-01",
+		EndDate:   "2026-05-31",
+		Category:  "Favorites",
+	})
+	if err != nil {
+		t.Fatalf("导出游玩记录失败: %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("解析 CSV 失败: %v", err)
+	}
+	if rows != 2 || len(records) != 3 || strings.Join(records[0], ",") != "id,game_id,game_name,start_time,end_time,duration" {
+		t.Fatalf("CSV 应包含表头和按日期、分类筛选后的记录: %v", records)
+	}
+	if records[1][0] != "s1" || records[2][0] != "s2" || records[1][2] != "Alpha, Part 1; Remake" || records[1][5] != "3600" {
+		t.Fatalf("CSV 记录异常: %v", records)
+	}
+
+	buf.Reset()
+	rows, err = exportSvc.WriteExport(&buf, vo.DataExportRequest{Entity: enums.ExportGames, Format: enums.ExportNDJSON, Status: enums.StatusPlaying})
+	if err != nil {
+		t.Fatalf("导出游戏失败: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if rows != 1 || len(lines) != 1 || !strings.HasPrefix(lines[0], `{"id":"alpha","name":`) {
+		t.Fatalf("NDJSON 应按状态筛选并保持字段顺序: %q", buf.String())
+	}
+	var game map[string]any
+	if err := json.Unmarshal([]byte(lines[0]), &game); err != nil {
+		t.Fatalf("解析 NDJSON 失败: %v", err)
+	}
+	if game["categories"] != "Favorites" || game["total_play_duration"] != float64(6300) || game["is_nsfw"] != false {
+		t.Fatalf("NDJSON 字段异常: %+v", game)
+	}
+
+	icsPath := filepath.Join(t.TempDir(), "sessions.ics")
+	rows, err = exportSvc.WriteExportFile(vo.DataExportRequest{Entity: enums.ExportSessions, Format: enums.ExportICS, EndDate: "2026-05-31"}, icsPath)
+	if err != nil {
+		t.Fatalf("导出 iCalendar 失败: %v", err)
+	}
+	content, err := os.ReadFile(icsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ics := string(content)
+	start := time.Date(2026, 5, 1, 20, 0, 0, 0, time.Local).UTC().Format("20060102T150405Z")
+	if rows != 3 || strings.Count(ics, "BEGIN:VEVENT") != 3 || !strings.HasPrefix(ics, "BEGIN:VCALENDAR\r\n") || !strings.HasSuffix(ics, "END:VCALENDAR\r\n") {
+		t.Fatalf("iCalendar 结构异常: %q", ics)
+	}
+	if !strings.Contains(ics, "UID:s1@lunabox\r\nDTSTAMP:") || !strings.Contains(ics, "DTSTART:"+start+"\r\n") || !strings.Contains(ics, `SUMMARY:Alpha\, Part 1\; Remake`) {
+		t.Fatalf("iCalendar 事件异常: %q", ics)
+	}
+
+	for _, req := range []vo.DataExportRequest{
+		{Entity: enums.ExportTags, Format: enums.ExportICS},
+		{Entity: "unknown"},
+		{Entity: enums.ExportGames, Format: "xml"},
+		{Entity: enums.ExportGames, Status: "finished"},
+		{Entity: enums.ExportSessions, StartDate: "2026-05-01'; DROP TABLE games; --"},
+	} {
+		if _, err := exportSvc.WriteExport(&buf, req); err == nil {
+			t.Fatalf("无效的导出请求应报错: %+v", req)
+		}
+	}
+}
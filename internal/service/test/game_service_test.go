@@ -715,6 +715,29 @@ func TestGameService_BatchUpdateStatusUpdatesTimestamp(t *testing.T) {
 	}
 }
 
+func TestGameService_AddGameRecordsStatusChangeTime(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	gameService := service.NewGameService()
+	gameService.Init(context.Background(), db, &appconf.AppConfig{})
+	game := createTestGame()
+	game.ID = "add-status-time"
+	game.Status = enums.StatusPlaying
+	if err := addGameViaMetadata(gameService, game); err != nil {
+		t.Fatalf("添加游戏失败: %v", err)
+	}
+
+	var updatedAt time.Time
+	var statusUpdatedAt *time.Time
+	if err := db.QueryRow(`SELECT updated_at, status_updated_at FROM games WHERE id = ?`, game.ID).Scan(&updatedAt, &statusUpdatedAt); err != nil {
+		t.Fatalf("读取游戏失败: %v", err)
+	}
+	if statusUpdatedAt == nil || !statusUpdatedAt.Equal(updatedAt) {
+		t.Fatalf("新增游戏应记录状态变更时间: status_updated_at=%v updated_at=%v", statusUpdatedAt, updatedAt)
+	}
+}
+
 func TestGameService_UpdateGameFromRemoteRespectsMetadataLock(t *testing.T) {
 	db, cleanup := setupTestDB(t)
 	defer cleanup()
@@ -0,0 +1,217 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"lunabox/internal/appconf"
+	"lunabox/internal/applog"
+	"lunabox/internal/common/enums"
+	"lunabox/internal/common/vo"
+	"lunabox/internal/models"
+	"lunabox/internal/service"
+	"lunabox/internal/service/remotestatus"
+)
+
+func TestRemotePushServiceQueuesRetriesAndDeadLetters(t *testing.T) {
+	applog.SetMode(applog.ModeCLI)
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+	insertBangumiGame(t, db, "queued", enums.StatusNotStarted, enums.Bangumi, "42")
+
+	var mu sync.Mutex
+	var posts []map[string]any
+	failing := false
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if r.URL.Path != "/v0/users/-/collections/42" {
+			t.Fatalf("未预期的请求路径: %s", r.URL.Path)
+		}
+		if failing {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var payload map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Fatalf("解析收藏请求失败: %v", err)
+		}
+		posts = append(posts, payload)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer testServer.Close()
+
+	bangumiSvc := service.NewBangumiService()
+	bangumiSvc.SetHTTPClient(newBangumiHTTPClient(t, testServer.URL))
+	bangumiSvc.SetEventEmitter(func(string, ...interface{}) {})
+	bangumiSvc.Init(context.Background(), db, &appconf.AppConfig{BangumiAccessToken: "access-token"})
+	gameSvc := service.NewGameService()
+	gameSvc.SetEventEmitter(func(string, ...interface{}) {})
+	gameSvc.Init(context.Background(), db, &appconf.AppConfig{})
+	gameSvc.SetBangumiService(bangumiSvc)
+	reviewSvc := service.NewGameReviewService()
+	reviewSvc.Init(context.Background(), db, &appconf.AppConfig{})
+	reviewSvc.SetBangumiService(bangumiSvc)
+
+	now := time.Now()
+	var deadLetters []vo.RemotePushJob
+	pushSvc := service.NewRemotePushService()
+	pushSvc.Init(context.Background(), db, &appconf.AppConfig{})
+	pushSvc.SetNowFunc(func() time.Time { return now })
+	pushSvc.SetEventEmitter(func(name string, data ...interface{}) {
+		if name == "remote-push:dead-letter" {
+			deadLetters = append(deadLetters, data[0].(vo.RemotePushJob))
+		}
+	})
+	pushSvc.SetGameService(gameSvc)
+	pushSvc.SetGameReviewService(reviewSvc)
+	pushSvc.SetBangumiService(bangumiSvc)
+	gameSvc.SetRemotePushService(pushSvc)
+	reviewSvc.SetRemotePushService(pushSvc)
+
+	setStatus := func(status enums.GameStatus) {
+		t.Helper()
+		game, err := gameSvc.GetGameByID("queued")
+		if err != nil {
+			t.Fatalf("读取测试游戏失败: %v", err)
+		}
+		game.Status = status
+		if err := gameSvc.UpdateGame(game); err != nil {
+			t.Fatalf("更新游戏状态失败: %v", err)
+		}
+	}
+	assertQueue := func(pending, dead int) {
+		t.Helper()
+		status, err := pushSvc.GetQueueStatus()
+		if err != nil {
+			t.Fatalf("读取推送队列失败: %v", err)
+		}
+		if status.Pending != pending || len(status.DeadLetters) != dead {
+			t.Fatalf("推送队列应有 %d 个待投递、%d 个死信，实际为 %+v", pending, dead, status)
+		}
+	}
+
+	setStatus(enums.StatusPlaying)
+	rating := 9
+	if _, err := reviewSvc.SaveGameReview(models.GameReview{GameID: "queued", Rating: &rating, Content: "nice"}); err != nil {
+		t.Fatalf("保存评价失败: %v", err)
+	}
+	setStatus(enums.StatusCompleted)
+	if len(posts) != 0 {
+		t.Fatalf("变更只应入队，不应同步推送: %+v", posts)
+	}
+	assertQueue(2, 0)
+
+	if err := pushSvc.DeliverDue(); err != nil {
+		t.Fatalf("投递推送任务失败: %v", err)
+	}
+	if len(posts) != 2 || posts[0]["type"] != float64(2) || posts[1]["rate"] != float64(9) || posts[1]["comment"] != "nice" {
+		t.Fatalf("同一条目的多次状态变更应合并为一次、推送最新状态和评价: %+v", posts)
+	}
+	assertQueue(0, 0)
+
+	failing = true
+	setStatus(enums.StatusOnHold)
+	for attempt := 1; attempt <= remotestatus.MaxAttempts; attempt++ {
+		if err := pushSvc.DeliverDue(); err != nil {
+			t.Fatalf("投递推送任务失败: %v", err)
+		}
+		if attempt < remotestatus.MaxAttempts {
+			assertQueue(1, 0)
+			if err := pushSvc.DeliverDue(); err != nil {
+				t.Fatal(err)
+			}
+			now = now.Add(remotestatus.Backoff(attempt))
+		}
+	}
+	assertQueue(0, 1)
+	if len(deadLetters) != 1 || deadLetters[0].GameName != "Bangumi Game queued" || deadLetters[0].Attempts != remotestatus.MaxAttempts || deadLetters[0].LastError == "" {
+		t.Fatalf("多次失败后应进入死信并通知: %+v", deadLetters)
+	}
+
+	failing = false
+	if err := pushSvc.RetryDeadLetter(deadLetters[0].ID); err != nil {
+		t.Fatalf("重新投递死信失败: %v", err)
+	}
+	if err := pushSvc.DeliverDue(); err != nil {
+		t.Fatalf("投递推送任务失败: %v", err)
+	}
+	if len(posts) != 3 || posts[2]["type"] != float64(4) {
+		t.Fatalf("重新投递应推送当前状态: %+v", posts)
+	}
+	assertQueue(0, 0)
+}
+
+func TestRemotePushServiceReportsStatusPushFailures(t *testing.T) {
+	applog.SetMode(applog.ModeCLI)
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+	insertBangumiGame(t, db, "reported", enums.StatusNotStarted, enums.Bangumi, "42")
+	insertBangumiGame(t, db, "vn-reported", enums.StatusNotStarted, enums.VNDB, "v7")
+
+	responseStatus := http.StatusBadRequest
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(responseStatus)
+	}))
+	defer testServer.Close()
+
+	bangumiSvc := service.NewBangumiService()
+	bangumiSvc.SetHTTPClient(newBangumiHTTPClient(t, testServer.URL))
+	bangumiSvc.SetEventEmitter(func(string, ...interface{}) {})
+	bangumiSvc.Init(context.Background(), db, &appconf.AppConfig{BangumiAccessToken: "access-token"})
+	vndbSvc := service.NewVNDBService()
+	vndbSvc.SetHTTPClient(testServer.Client())
+	vndbSvc.SetAPIBaseURL(testServer.URL)
+	vndbSvc.SetEventEmitter(func(string, ...interface{}) {})
+	vndbSvc.Init(context.Background(), db, &appconf.AppConfig{VNDBAccessToken: "vndb-token", VNDBStatusPushEnabled: boolPtr(true)})
+	var failures []vo.BangumiStatusPushFailureEvent
+	var vndbFailures []vo.VNDBStatusPushFailureEvent
+	gameSvc := service.NewGameService()
+	gameSvc.SetEventEmitter(func(name string, data ...interface{}) {
+		switch name {
+		case "bangumi:status-push-failed":
+			failures = append(failures, data[0].(vo.BangumiStatusPushFailureEvent))
+		case "vndb:status-push-failed":
+			vndbFailures = append(vndbFailures, data[0].(vo.VNDBStatusPushFailureEvent))
+		}
+	})
+	gameSvc.Init(context.Background(), db, &appconf.AppConfig{})
+	gameSvc.SetBangumiService(bangumiSvc)
+	gameSvc.SetVNDBService(vndbSvc)
+
+	now := time.Now()
+	pushSvc := service.NewRemotePushService()
+	pushSvc.Init(context.Background(), db, &appconf.AppConfig{})
+	pushSvc.SetNowFunc(func() time.Time { return now })
+	pushSvc.SetGameService(gameSvc)
+	pushSvc.SetBangumiService(bangumiSvc)
+	pushSvc.SetVNDBService(vndbSvc)
+	gameSvc.SetRemotePushService(pushSvc)
+
+	if err := gameSvc.BatchUpdateStatus([]string{"reported", "vn-reported"}, string(enums.StatusPlaying)); err != nil {
+		t.Fatalf("更新游戏状态失败: %v", err)
+	}
+	if err := pushSvc.DeliverDue(); err != nil {
+		t.Fatalf("投递推送任务失败: %v", err)
+	}
+	if len(failures) != 1 || failures[0].GameID != "reported" || failures[0].SubjectID != "42" || failures[0].LocalStatus != string(enums.StatusPlaying) {
+		t.Fatalf("队列投递失败应与直接推送一样通知界面: %+v", failures)
+	}
+	if len(vndbFailures) != 1 || vndbFailures[0].GameID != "vn-reported" || vndbFailures[0].VNID != "v7" || vndbFailures[0].LocalStatus != string(enums.StatusPlaying) {
+		t.Fatalf("VNDB 推送失败同样应通知界面: %+v", vndbFailures)
+	}
+
+	responseStatus = http.StatusTooManyRequests
+	now = now.Add(remotestatus.Backoff(1))
+	if err := pushSvc.DeliverDue(); err != nil {
+		t.Fatalf("投递推送任务失败: %v", err)
+	}
+	if len(failures) != 1 || len(vndbFailures) != 1 {
+		t.Fatalf("限流等暂时性失败只应延后重试，不应通知界面: %+v %+v", failures, vndbFailures)
+	}
+}
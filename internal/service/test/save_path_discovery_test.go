@@ -0,0 +1,117 @@
+package test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"lunabox/internal/appconf"
+	"lunabox/internal/common/vo"
+	"lunabox/internal/service"
+)
+
+func findSavePathCandidate(candidates []vo.SavePathCandidate, path string) (vo.SavePathCandidate, bool) {
+	for _, candidate := range candidates {
+		if candidate.Path == path {
+			return candidate, true
+		}
+	}
+	return vo.SavePathCandidate{}, false
+}
+
+func TestSavePathDiscoveryFromSessionsAndKnownLocations(t *testing.T) {
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	gameDir := filepath.Join(t.TempDir(), "Sakura")
+	old := time.Now().Add(-24 * time.Hour)
+	writeSave := func(path string, modTime time.Time) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("data"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeSave(filepath.Join(gameDir, "Sakura.exe"), old)
+	writeSave(filepath.Join(gameDir, "savedata", "old.dat"), old)
+
+	gameID := "save-path-discovery"
+	now := time.Now().UTC()
+	if _, err := db.Exec(`INSERT INTO games (id, name, path, game_directory, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		gameID, "Sakura", filepath.Join(gameDir, "Sakura.exe"), gameDir, now, now); err != nil {
+		t.Fatal(err)
+	}
+	backupService := service.NewBackupService()
+	backupService.Init(context.Background(), db, &appconf.AppConfig{})
+	// playSession 模拟一次游玩：启动时开始监听，游玩中写入存档，结束时记录
+	playSession := func() {
+		watch := backupService.StartSavePathWatch(gameID, time.Now())
+		writeSave(filepath.Join(gameDir, "www", "save", "file1.rpgsave"), time.Now())
+		backupService.RecordSavePathSession(gameID, watch)
+	}
+
+	sessionSave := filepath.Join(gameDir, "www", "save")
+	staticSave := filepath.Join(gameDir, "savedata")
+
+	candidates, err := backupService.GetSavePathCandidates(gameID)
+	if err != nil {
+		t.Fatalf("GetSavePathCandidates failed: %v", err)
+	}
+	if _, ok := findSavePathCandidate(candidates, staticSave); !ok {
+		t.Fatalf("in-game savedata should be detected from known locations: %+v", candidates)
+	}
+	before, _ := findSavePathCandidate(candidates, sessionSave)
+
+	playSession()
+	playSession()
+	candidates, err = backupService.GetSavePathCandidates(gameID)
+	if err != nil {
+		t.Fatalf("GetSavePathCandidates failed: %v", err)
+	}
+	observed, ok := findSavePathCandidate(candidates, sessionSave)
+	if !ok || observed.ObservedSessions != 2 || observed.Confidence <= before.Confidence {
+		t.Fatalf("session writes should raise the candidate: before=%+v after=%+v", before, observed)
+	}
+	if candidates[0].Path != sessionSave {
+		t.Fatalf("observed save folder should rank first: %+v", candidates)
+	}
+
+	if err := backupService.DismissSavePathCandidate(gameID, staticSave); err != nil {
+		t.Fatalf("DismissSavePathCandidate failed: %v", err)
+	}
+	candidates, _ = backupService.GetSavePathCandidates(gameID)
+	if _, ok := findSavePathCandidate(candidates, staticSave); ok {
+		t.Fatalf("dismissed candidate should not be proposed again: %+v", candidates)
+	}
+
+	if err := backupService.AcceptSavePathCandidate(gameID, "relative/save"); err == nil {
+		t.Fatal("relative save path should be rejected")
+	}
+	if err := backupService.AcceptSavePathCandidate(gameID, sessionSave); err != nil {
+		t.Fatalf("AcceptSavePathCandidate failed: %v", err)
+	}
+	var savePath string
+	if err := db.QueryRow(`SELECT save_path FROM games WHERE id = ?`, gameID).Scan(&savePath); err != nil || savePath != sessionSave {
+		t.Fatalf("save_path = %q, %v", savePath, err)
+	}
+	var remaining int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM save_path_candidates WHERE game_id = ?`, gameID).Scan(&remaining); err != nil || remaining != 0 {
+		t.Fatalf("candidates should be cleared after accepting: %d, %v", remaining, err)
+	}
+
+	if watch := backupService.StartSavePathWatch(gameID, time.Now()); watch != nil {
+		watch.Stop()
+		t.Fatal("games with a save path should not be watched")
+	}
+	playSession()
+	if err := db.QueryRow(`SELECT COUNT(*) FROM save_path_candidates WHERE game_id = ?`, gameID).Scan(&remaining); err != nil || remaining != 0 {
+		t.Fatalf("games with a save path should not record sessions: %d, %v", remaining, err)
+	}
+}
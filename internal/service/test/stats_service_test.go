@@ -0,0 +1,239 @@
+package test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"lunabox/internal/appconf"
+	"lunabox/internal/applog"
+	"lunabox/internal/common/enums"
+	"lunabox/internal/common/vo"
+	"lunabox/internal/service"
+)
+
+func TestStatsServiceComparesQuartersWithBreakdowns(t *testing.T) {
+	applog.SetMode(applog.ModeCLI)
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	insertBangumiGame(t, db, "steam-game", enums.StatusPlaying, enums.Local, "")
+	insertBangumiGame(t, db, "plain-game", enums.StatusPlaying, enums.Local, "")
+	if _, err := db.Exec(`UPDATE games SET company = 'Studio A', launch_mode = 'steam' WHERE id = 'steam-game'`); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	if _, err := db.Exec(`INSERT INTO categories (id, name, created_at, updated_at, is_system) VALUES ('cat-1', 'Quarterly', ?, ?, FALSE)`, now, now); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`INSERT INTO game_categories (game_id, category_id) VALUES ('steam-game', 'cat-1')`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`INSERT INTO game_tags (id, game_id, name, source, weight, is_spoiler, created_at, updated_at) VALUES
+		('t1', 'steam-game', 'Mystery', 'user', 1, FALSE, ?, ?),
+		('t2', 'steam-game', 'Mystery', 'bangumi', 1, FALSE, ?, ?),
+		('t3', 'steam-game', 'Twist', 'user', 1, TRUE, ?, ?)`, now, now, now, now, now, now); err != nil {
+		t.Fatal(err)
+	}
+	sessions := []struct {
+		id, gameID string
+		start      time.Time
+		duration   int
+	}{
+		{"q3-steam", "steam-game", time.Date(2026, 8, 10, 20, 0, 0, 0, time.Local), 3600},
+		{"q3-plain", "plain-game", time.Date(2026, 9, 30, 21, 0, 0, 0, time.Local), 600},
+		{"q2-steam", "steam-game", time.Date(2026, 4, 1, 10, 0, 0, 0, time.Local), 1800},
+		{"q1-plain", "plain-game", time.Date(2026, 3, 31, 10, 0, 0, 0, time.Local), 900},
+	}
+	for _, session := range sessions {
+		if _, err := db.Exec(`INSERT INTO play_sessions (id, game_id, start_time, end_time, duration) VALUES (?, ?, ?, ?, ?)`,
+			session.id, session.gameID, session.start, session.start.Add(time.Duration(session.duration)*time.Second), session.duration); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	statsSvc := service.NewStatsService()
+	statsSvc.Init(context.Background(), db, &appconf.AppConfig{})
+
+	stats, err := statsSvc.GetGlobalPeriodStats(vo.PeriodStatsRequest{Dimension: enums.Day, StartDate: "2026-07-01", EndDate: "2026-09-30", Compare: true})
+	if err != nil {
+		t.Fatalf("读取季度统计失败: %v", err)
+	}
+	if stats.TotalPlayDuration != 4200 || stats.Previous == nil || stats.Delta == nil {
+		t.Fatalf("季度统计异常: total=%d previous=%v delta=%v", stats.TotalPlayDuration, stats.Previous, stats.Delta)
+	}
+	if stats.Previous.StartDate != "2026-04-01" || stats.Previous.EndDate != "2026-06-30" || stats.Previous.TotalPlayDuration != 1800 {
+		t.Fatalf("整月范围应按月平移到上一季度: %s ~ %s, %d", stats.Previous.StartDate, stats.Previous.EndDate, stats.Previous.TotalPlayDuration)
+	}
+	if stats.Delta.TotalPlayDuration != 2400 || stats.Delta.TotalPlayCount != 1 || stats.Delta.ActiveDays != 1 {
+		t.Fatalf("差值异常: %+v", stats.Delta)
+	}
+
+	category := findStatsDelta(t, stats.Delta.Breakdowns.Category, "cat-1")
+	if category.Name != "Quarterly" || category.Current != 3600 || category.Previous != 1800 || category.Delta != 1800 {
+		t.Fatalf("分类差值异常: %+v", category)
+	}
+	if uncategorized := findStatsDelta(t, stats.Delta.Breakdowns.Category, ""); uncategorized.Current != 600 || uncategorized.Previous != 0 {
+		t.Fatalf("未分类时长异常: %+v", uncategorized)
+	}
+	if len(stats.Breakdowns.Tag) != 1 || stats.Breakdowns.Tag[0].Name != "Mystery" || stats.Breakdowns.Tag[0].TotalDuration != 3600 {
+		t.Fatalf("标签拆分应去重并过滤剧透标签: %+v", stats.Breakdowns.Tag)
+	}
+	if company := findStatsDelta(t, stats.Delta.Breakdowns.Company, "Studio A"); company.Delta != 1800 {
+		t.Fatalf("厂商差值异常: %+v", company)
+	}
+	if steam := findStatsDelta(t, stats.Delta.Breakdowns.LaunchMode, "steam"); steam.Current != 3600 || steam.Previous != 1800 {
+		t.Fatalf("启动方式差值异常: %+v", steam)
+	}
+	if normal := findStatsDelta(t, stats.Delta.Breakdowns.LaunchMode, "normal"); normal.Current != 600 || normal.Previous != 0 {
+		t.Fatalf("启动方式差值异常: %+v", normal)
+	}
+	if len(stats.Delta.Timeline) != len(stats.Timeline) || stats.Delta.Timeline[0].Key != "2026-07-01" {
+		t.Fatalf("时间线差值应按本期位置对齐: %+v", stats.Delta.Timeline[:1])
+	}
+
+	week, err := statsSvc.GetGlobalPeriodStats(vo.PeriodStatsRequest{Dimension: enums.Week, Compare: true})
+	if err != nil || week.Previous == nil {
+		t.Fatalf("默认周维度也应支持对比: %v", err)
+	}
+
+	for _, req := range []vo.PeriodStatsRequest{
+		{Dimension: enums.Day, StartDate: "2026-13-01", EndDate: "2026-12-31"},
+		{Dimension: enums.Day, StartDate: "2026-07-01"},
+		{Dimension: enums.Day, StartDate: "2026-07-02", EndDate: "2026-07-01"},
+		{Dimension: enums.Day, StartDate: "2026-07-01'; DROP TABLE games; --", EndDate: "2026-07-02"},
+	} {
+		if _, err := statsSvc.GetGlobalPeriodStats(req); err == nil {
+			t.Fatalf("无效日期范围应报错: %+v", req)
+		}
+	}
+}
+
+func TestStatsServiceForecastsBacklogCompletion(t *testing.T) {
+	applog.SetMode(applog.ModeCLI)
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	now := time.Now()
+	games := []struct {
+		id, company, tag string
+		status           enums.GameStatus
+		sourceType       enums.SourceType
+		sourceID         string
+		played           int
+	}{
+		{"done-a", "Studio A", "Mystery", enums.StatusCompleted, enums.Local, "", 36000},
+		{"done-b", "Studio B", "Comedy", enums.StatusCompleted, enums.Local, "", 7200},
+		{"done-c", "", "", enums.StatusCompleted, enums.VNDB, "v100", 14400},
+		{"current", "Studio A", "mystery", enums.StatusPlaying, enums.Local, "", 3600},
+		{"wish", "", "", enums.StatusWantToPlay, enums.VNDB, "200", 0},
+		{"unknown", "", "", enums.StatusWantToPlay, enums.Local, "", 0},
+	}
+	for i, game := range games {
+		insertBangumiGame(t, db, game.id, game.status, game.sourceType, game.sourceID)
+		if _, err := db.Exec(`UPDATE games SET company = ?, created_at = ? WHERE id = ?`, game.company, now.Add(time.Duration(i)*time.Minute), game.id); err != nil {
+			t.Fatal(err)
+		}
+		if game.tag != "" {
+			if _, err := db.Exec(`INSERT INTO game_tags (id, game_id, name, source, weight, is_spoiler, created_at, updated_at) VALUES (?, ?, ?, 'user', 1, FALSE, ?, ?)`,
+				"tag-"+game.id, game.id, game.tag, now, now); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if game.played > 0 {
+			start := now.AddDate(0, 0, -90)
+			if game.status == enums.StatusPlaying {
+				start = time.Date(now.Year(), now.Month(), now.Day()-1, 12, 0, 0, 0, time.Local)
+			}
+			if _, err := db.Exec(`INSERT INTO play_sessions (id, game_id, start_time, end_time, duration) VALUES (?, ?, ?, ?, ?)`,
+				"session-"+game.id, game.id, start, start.Add(time.Duration(game.played)*time.Second), game.played); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	var requestedIDs []any
+	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/vn" || r.Header.Get("Authorization") != "" {
+			t.Fatalf("未预期的 VNDB 请求: %s %s auth=%q", r.Method, r.URL.Path, r.Header.Get("Authorization"))
+		}
+		var payload struct {
+			Filters []any  `json:"filters"`
+			Fields  string `json:"fields"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Fatalf("解析 VNDB 请求失败: %v", err)
+		}
+		for _, filter := range payload.Filters[1:] {
+			requestedIDs = append(requestedIDs, filter.([]any)[2])
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"results":[{"id":"v100","length_minutes":240},{"id":"v200","length_minutes":300}]}`))
+	}))
+	defer testServer.Close()
+
+	vndbSvc := service.NewVNDBService()
+	vndbSvc.SetHTTPClient(testServer.Client())
+	vndbSvc.SetAPIBaseURL(testServer.URL)
+	vndbSvc.Init(context.Background(), db, &appconf.AppConfig{})
+	statsSvc := service.NewStatsService()
+	statsSvc.Init(context.Background(), db, &appconf.AppConfig{})
+	statsSvc.SetVNDBService(vndbSvc)
+
+	forecast, err := statsSvc.GetCompletionForecast(vo.CompletionForecastRequest{UseMetadataLength: true})
+	if err != nil {
+		t.Fatalf("预估通关时长失败: %v", err)
+	}
+	if len(requestedIDs) != 2 {
+		t.Fatalf("应只查询关联 VNDB 的游戏: %v", requestedIDs)
+	}
+	if forecast.PaceDimension != enums.Month || forecast.AvgDailyDuration != 3600 {
+		t.Fatalf("应按最近 30 天的日均时长计算节奏: %+v", forecast)
+	}
+	if len(forecast.Games) != 3 || forecast.Games[0].GameID != "current" || forecast.Games[1].GameID != "wish" || forecast.Games[2].GameID != "unknown" {
+		t.Fatalf("在玩游戏应排在想玩游戏之前: %+v", forecast.Games)
+	}
+
+	current := forecast.Games[0]
+	if current.Basis != "similar" || current.SimilarGamesCount != 1 || current.EstimatedTotalDuration != 36000 || current.RemainingDuration != 32400 {
+		t.Fatalf("应参考同厂商同标签的已通关游戏: %+v", current)
+	}
+	wish := forecast.Games[1]
+	if wish.Basis != "metadata" || wish.MetadataLengthMinutes != 300 || wish.EstimatedTotalDuration != 18000 {
+		t.Fatalf("应参考 VNDB 时长并按自己的通关节奏校准: %+v", wish)
+	}
+	unknown := forecast.Games[2]
+	if unknown.Basis != "library" || unknown.EstimatedTotalDuration != 14400 {
+		t.Fatalf("无相似游戏时应回退到已通关游戏的中位时长: %+v", unknown)
+	}
+
+	today := time.Now()
+	if forecast.TotalRemainingDuration != 64800 || forecast.EstimatedDays != 18 || forecast.EstimatedClearDate != today.AddDate(0, 0, 18).Format("2006-01-02") {
+		t.Fatalf("积压清空预测异常: %+v", forecast)
+	}
+	if current.ProjectedFinishDate != today.AddDate(0, 0, 9).Format("2006-01-02") || unknown.ProjectedFinishDate != forecast.EstimatedClearDate {
+		t.Fatalf("逐个游戏的预计通关日期异常: %+v", forecast.Games)
+	}
+
+	withoutMetadata, err := statsSvc.GetCompletionForecast(vo.CompletionForecastRequest{})
+	if err != nil {
+		t.Fatalf("预估通关时长失败: %v", err)
+	}
+	if len(requestedIDs) != 2 || withoutMetadata.Games[1].Basis != "library" || withoutMetadata.Games[1].MetadataLengthMinutes != 0 {
+		t.Fatalf("未开启元数据时长时不应请求 VNDB: %+v", withoutMetadata.Games[1])
+	}
+}
+
+func findStatsDelta(t *testing.T, deltas []vo.StatsDelta, key string) vo.StatsDelta {
+	t.Helper()
+	for _, delta := range deltas {
+		if delta.Key == key {
+			return delta
+		}
+	}
+	t.Fatalf("缺少分组 %q: %+v", key, deltas)
+	return vo.StatsDelta{}
+}
@@ -0,0 +1,245 @@
+package test
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"lunabox/internal/appconf"
+	"lunabox/internal/applog"
+	"lunabox/internal/common/enums"
+	"lunabox/internal/models"
+	"lunabox/internal/service"
+)
+
+// fakeVNDBServer 模拟 VNDB Kana API 的 authinfo 与 ulist 接口，记录收到的 PATCH 请求
+type fakeVNDBServer struct {
+	t       *testing.T
+	mu      sync.Mutex
+	ulist   string
+	patches map[string][]map[string]any
+}
+
+func (f *fakeVNDBServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.Header.Get("Authorization") != "Token vndb-token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	switch {
+	case r.Method == http.MethodGet && r.URL.Path == "/authinfo":
+		_, _ = io.WriteString(w, `{"id":"u42","username":"sai","permissions":["listread","listwrite"]}`)
+	case r.Method == http.MethodPost && r.URL.Path == "/ulist":
+		var payload map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			f.t.Fatalf("解析列表请求失败: %v", err)
+		}
+		if payload["user"] != "u42" || !strings.Contains(payload["fields"].(string), "labels.id") {
+			f.t.Fatalf("列表请求参数异常: %+v", payload)
+		}
+		_, _ = io.WriteString(w, f.ulist)
+	case r.Method == http.MethodPatch && strings.HasPrefix(r.URL.Path, "/ulist/"):
+		var payload map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			f.t.Fatalf("解析列表更新请求失败: %v", err)
+		}
+		vnID := strings.TrimPrefix(r.URL.Path, "/ulist/")
+		f.patches[vnID] = append(f.patches[vnID], payload)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		f.t.Fatalf("未预期的请求: %s %s", r.Method, r.URL.Path)
+	}
+}
+
+func newVNDBTestServices(t *testing.T, fake *fakeVNDBServer) (*sql.DB, *service.VNDBService, *service.GameReviewService, func()) {
+	t.Helper()
+	db, cleanup := setupTestDB(t)
+	testServer := httptest.NewServer(fake)
+	config := &appconf.AppConfig{VNDBAccessToken: "vndb-token", VNDBStatusPushEnabled: boolPtr(true)}
+
+	gameSvc := service.NewGameService()
+	gameSvc.SetEventEmitter(func(string, ...interface{}) {})
+	gameSvc.Init(context.Background(), db, config)
+	vndbSvc := service.NewVNDBService()
+	vndbSvc.SetHTTPClient(testServer.Client())
+	vndbSvc.SetAPIBaseURL(testServer.URL)
+	vndbSvc.SetEventEmitter(func(string, ...interface{}) {})
+	vndbSvc.Init(context.Background(), db, config)
+	vndbSvc.SetGameService(gameSvc)
+	gameSvc.SetVNDBService(vndbSvc)
+	reviewSvc := service.NewGameReviewService()
+	reviewSvc.Init(context.Background(), db, config)
+	reviewSvc.SetVNDBService(vndbSvc)
+
+	insertBangumiGame(t, db, "vn-playing", enums.StatusPlaying, enums.VNDB, "v17")
+	insertBangumiGame(t, db, "vn-legacy-id", enums.StatusCompleted, enums.VNDB, "11")
+	insertBangumiGame(t, db, "not-vndb", enums.StatusCompleted, enums.Bangumi, "500")
+
+	return db, vndbSvc, reviewSvc, func() {
+		testServer.Close()
+		cleanup()
+	}
+}
+
+func TestVNDBServicePushesStatusLabelsAndVotes(t *testing.T) {
+	applog.SetMode(applog.ModeCLI)
+	fake := &fakeVNDBServer{t: t, patches: map[string][]map[string]any{}}
+	_, vndbSvc, reviewSvc, cleanup := newVNDBTestServices(t, fake)
+	defer cleanup()
+
+	info, err := vndbSvc.GetAuthInfo()
+	if err != nil || info.Username != "sai" || !info.CanReadList || !info.CanWriteList {
+		t.Fatalf("令牌信息异常: %+v, %v", info, err)
+	}
+
+	progress, err := vndbSvc.SyncAllGameStatuses()
+	if err != nil {
+		t.Fatalf("同步 VNDB 状态失败: %v", err)
+	}
+	if progress.Total != 2 || progress.SucceededGames != 2 || progress.FailedGames != 0 {
+		t.Fatalf("同步进度异常: %+v", progress)
+	}
+	playing := fake.patches["v17"]
+	if len(playing) != 1 || !sameNumbers(playing[0]["labels_set"], 1) || !sameNumbers(playing[0]["labels_unset"], 2, 3, 4, 5) {
+		t.Fatalf("游玩中应设置 Playing 标签并移除其他状态标签: %+v", playing)
+	}
+	if legacy := fake.patches["v11"]; len(legacy) != 1 || !sameNumbers(legacy[0]["labels_set"], 2) {
+		t.Fatalf("不带 v 前缀的 ID 应规范化后推送: %+v", fake.patches)
+	}
+
+	rating := 8
+	if _, err := reviewSvc.SaveGameReview(models.GameReview{GameID: "vn-playing", Rating: &rating, Content: "good"}); err != nil {
+		t.Fatalf("保存评价失败: %v", err)
+	}
+	result, err := reviewSvc.SyncGameReview("vn-playing", []enums.SourceType{enums.VNDB})
+	if err != nil || len(result.Results) != 1 || result.Results[0].Status != "success" {
+		t.Fatalf("同步 VNDB 评分失败: %+v, %v", result, err)
+	}
+	if votes := fake.patches["v17"]; len(votes) != 2 || votes[1]["vote"] != float64(80) {
+		t.Fatalf("评分应按 10 倍写入 VNDB 投票: %+v", votes)
+	}
+}
+
+func TestVNDBServiceImportsUList(t *testing.T) {
+	applog.SetMode(applog.ModeCLI)
+	recent := time.Now().Add(-time.Hour).Unix()
+	old := time.Now().Add(-72 * time.Hour).Unix()
+	fake := &fakeVNDBServer{t: t, patches: map[string][]map[string]any{}}
+	fake.ulist = `{"more":false,"results":[
+		{"id":"v99","vote":75,"lastmod":` + strconv.FormatInt(recent, 10) + `,"labels":[{"id":5},{"id":7}],
+		 "vn":{"title":"Remote Only","released":"2019-04-01","description":"desc","rating":81.5,"image":{"url":"https://example.com/v99.jpg"},"developers":[{"name":"Studio A"},{"name":"Studio B"}]}},
+		{"id":"v17","vote":null,"lastmod":` + strconv.FormatInt(recent, 10) + `,"labels":[{"id":4}],"vn":{"title":"Playing"}},
+		{"id":"v11","vote":null,"lastmod":` + strconv.FormatInt(old, 10) + `,"labels":[{"id":1}],"vn":{"title":"Legacy"}}
+	]}`
+	db, vndbSvc, _, cleanup := newVNDBTestServices(t, fake)
+	defer cleanup()
+	if _, err := db.Exec(`UPDATE games SET updated_at = ? WHERE id = 'vn-playing'`, time.Now().Add(-48*time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+
+	preview, err := vndbSvc.PreviewUListImport()
+	if err != nil {
+		t.Fatalf("预览 VNDB 列表导入失败: %v", err)
+	}
+	if !preview.DryRun || preview.Total != 3 || preview.Created != 1 || preview.Updated != 1 || preview.Unchanged != 1 {
+		t.Fatalf("预览统计异常: %+v", preview)
+	}
+	var count int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM games`).Scan(&count); err != nil || count != 3 {
+		t.Fatalf("预览不应新建游戏: %d, %v", count, err)
+	}
+
+	report, err := vndbSvc.ImportUList()
+	if err != nil {
+		t.Fatalf("导入 VNDB 列表失败: %v", err)
+	}
+	if report.Created != 1 || report.Updated != 1 || report.Failed != 0 {
+		t.Fatalf("导入统计异常: %+v", report)
+	}
+
+	var createdID, name, status, company, cover string
+	if err := db.QueryRow(`
+		SELECT g.id, g.name, g.status, g.company, g.cover_url FROM games g
+		JOIN game_metadata_sources s ON s.game_id = g.id
+		WHERE s.source_type = 'vndb' AND s.source_id = 'v99'`).Scan(&createdID, &name, &status, &company, &cover); err != nil {
+		t.Fatalf("未新建 VNDB 列表中的游戏: %v", err)
+	}
+	if name != "Remote Only" || status != string(enums.StatusWantToPlay) || company != "Studio A, Studio B" || cover != "https://example.com/v99.jpg" {
+		t.Fatalf("新建游戏字段异常: name=%q status=%q company=%q cover=%q", name, status, company, cover)
+	}
+	assertLocalReview(t, db, createdID, 8, "")
+
+	if err := db.QueryRow(`SELECT status FROM games WHERE id = 'vn-playing'`).Scan(&status); err != nil || status != string(enums.StatusOnHold) {
+		t.Fatalf("VNDB 较新时应把 Dropped 导入为搁置: %q, %v", status, err)
+	}
+	if err := db.QueryRow(`SELECT status FROM games WHERE id = 'vn-legacy-id'`).Scan(&status); err != nil || status != string(enums.StatusCompleted) {
+		t.Fatalf("本地较新时不应覆盖状态: %q, %v", status, err)
+	}
+	if len(fake.patches) != 0 {
+		t.Fatalf("导入不应写入 VNDB: %+v", fake.patches)
+	}
+}
+
+func TestVNDBImportComparesStatusChangeTime(t *testing.T) {
+	applog.SetMode(applog.ModeCLI)
+	recent := time.Now().Add(-time.Hour)
+	fake := &fakeVNDBServer{t: t, patches: map[string][]map[string]any{}}
+	fake.ulist = `{"more":false,"results":[
+		{"id":"v17","vote":null,"lastmod":` + strconv.FormatInt(recent.Unix(), 10) + `,"labels":[{"id":2}],"vn":{"title":"Playing"}},
+		{"id":"v11","vote":null,"lastmod":` + strconv.FormatInt(recent.Unix(), 10) + `,"labels":[{"id":4}],"vn":{"title":"Legacy"}}
+	]}`
+	db, vndbSvc, _, cleanup := newVNDBTestServices(t, fake)
+	defer cleanup()
+
+	// 两个游戏都在 VNDB 列表更新之后被编辑，但只有 vn-legacy-id 改的是状态
+	old := time.Now().Add(-48 * time.Hour)
+	now := time.Now()
+	if _, err := db.Exec(`UPDATE games SET updated_at = ?, status_updated_at = ? WHERE id = 'vn-playing'`, now, old); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`UPDATE games SET updated_at = ?, status_updated_at = ? WHERE id = 'vn-legacy-id'`, now, now); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := vndbSvc.ImportUList()
+	if err != nil {
+		t.Fatalf("导入 VNDB 列表失败: %v", err)
+	}
+	if report.Updated != 1 || report.Unchanged != 1 || report.Failed != 0 {
+		t.Fatalf("导入统计异常: %+v", report)
+	}
+	var status string
+	var statusUpdatedAt time.Time
+	if err := db.QueryRow(`SELECT status, status_updated_at FROM games WHERE id = 'vn-playing'`).Scan(&status, &statusUpdatedAt); err != nil || status != string(enums.StatusCompleted) {
+		t.Fatalf("只改了其他字段时应按状态变更时间导入 VNDB 状态: %q, %v", status, err)
+	}
+	if !statusUpdatedAt.After(old) {
+		t.Fatalf("导入状态后应刷新状态变更时间: %v", statusUpdatedAt)
+	}
+	if err := db.QueryRow(`SELECT status FROM games WHERE id = 'vn-legacy-id'`).Scan(&status); err != nil || status != string(enums.StatusCompleted) {
+		t.Fatalf("本地状态较新时不应覆盖: %q, %v", status, err)
+	}
+}
+
+func sameNumbers(value any, expected ...int) bool {
+	items, ok := value.([]any)
+	if !ok || len(items) != len(expected) {
+		return false
+	}
+	for index, item := range items {
+		if item != float64(expected[index]) {
+			return false
+		}
+	}
+	return true
+}
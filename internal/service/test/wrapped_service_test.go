@@ -0,0 +1,146 @@
+package test
+
+import (
+	"archive/zip"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"lunabox/internal/appconf"
+	"lunabox/internal/applog"
+	"lunabox/internal/common/enums"
+	"lunabox/internal/service"
+)
+
+func TestWrappedServiceBuildsYearReportAndBundle(t *testing.T) {
+	applog.SetMode(applog.ModeCLI)
+	db, cleanup := setupTestDB(t)
+	defer cleanup()
+
+	coverServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		_, _ = w.Write([]byte("\x89PNG\r\n\x1a\ncover"))
+	}))
+	defer coverServer.Close()
+
+	insertBangumiGame(t, db, "finished", enums.StatusCompleted, enums.Local, "")
+	insertBangumiGame(t, db, "ongoing", enums.StatusPlaying, enums.Local, "")
+	insertBangumiGame(t, db, "next-year", enums.StatusCompleted, enums.Local, "")
+	if _, err := db.Exec(`UPDATE games SET cover_url = ?, created_at = ? WHERE id = 'finished'`,
+		coverServer.URL+"/cover.png", time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(`UPDATE games SET cover_url = 'data:image/png;base64,b25nb2luZw==' WHERE id = 'ongoing'`); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	if _, err := db.Exec(`INSERT INTO game_tags (id, game_id, name, source, weight, is_spoiler, created_at, updated_at) VALUES
+		('t1', 'finished', 'Mystery', 'user', 1, FALSE, ?, ?),
+		('t2', 'ongoing', 'Comedy', 'user', 1, FALSE, ?, ?),
+		('t3', 'ongoing', 'Secret', 'user', 1, TRUE, ?, ?)`, now, now, now, now, now, now); err != nil {
+		t.Fatal(err)
+	}
+	sessions := []struct {
+		id, gameID string
+		start      time.Time
+		duration   int
+	}{
+		{"before", "finished", time.Date(2024, 12, 31, 22, 0, 0, 0, time.Local), 3600},
+		{"first", "next-year", time.Date(2025, 1, 2, 10, 0, 0, 0, time.Local), 1800},
+		{"mar-10", "finished", time.Date(2025, 3, 10, 20, 0, 0, 0, time.Local), 7200},
+		{"mar-11", "finished", time.Date(2025, 3, 11, 20, 0, 0, 0, time.Local), 3600},
+		{"mar-12", "finished", time.Date(2025, 3, 12, 21, 0, 0, 0, time.Local), 10800},
+		{"jul", "ongoing", time.Date(2025, 7, 1, 12, 0, 0, 0, time.Local), 600},
+		{"last", "ongoing", time.Date(2025, 12, 31, 23, 0, 0, 0, time.Local), 900},
+		{"after", "next-year", time.Date(2026, 2, 1, 10, 0, 0, 0, time.Local), 1200},
+	}
+	for _, session := range sessions {
+		if _, err := db.Exec(`INSERT INTO play_sessions (id, game_id, start_time, end_time, duration) VALUES (?, ?, ?, ?, ?)`,
+			session.id, session.gameID, session.start, session.start.Add(time.Duration(session.duration)*time.Second), session.duration); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wrappedSvc := service.NewWrappedService()
+	wrappedSvc.Init(context.Background(), db, &appconf.AppConfig{})
+
+	report, err := wrappedSvc.GetWrappedReport(2025)
+	if err != nil {
+		t.Fatalf("生成年度回顾失败: %v", err)
+	}
+	if report.TotalPlayDuration != 24900 || report.TotalPlayCount != 6 || report.GamesPlayedCount != 3 || report.ActiveDays != 6 || report.AvgSessionDuration != 4150 {
+		t.Fatalf("年度汇总异常: %+v", report)
+	}
+	if report.TopStreak.Days != 3 || report.TopStreak.StartDate != "2025-03-10" || report.TopStreak.EndDate != "2025-03-12" {
+		t.Fatalf("最长连续游玩异常: %+v", report.TopStreak)
+	}
+	if report.BiggestSession == nil || report.BiggestSession.GameID != "finished" || report.BiggestSession.Duration != 10800 {
+		t.Fatalf("最长单次游玩异常: %+v", report.BiggestSession)
+	}
+	if report.FirstSession == nil || report.FirstSession.GameID != "next-year" || report.LastSession == nil || report.LastSession.GameID != "ongoing" {
+		t.Fatalf("首末游玩异常: first=%+v last=%+v", report.FirstSession, report.LastSession)
+	}
+	if report.BusiestMonth == nil || report.BusiestMonth.Month != "2025-03" || report.BusiestMonth.TotalDuration != 21600 || report.BusiestMonth.PlayCount != 3 || len(report.Months) != 12 {
+		t.Fatalf("最忙碌月份异常: %+v", report.BusiestMonth)
+	}
+	if report.TopTag == nil || report.TopTag.Name != "Mystery" || report.TopTag.TotalDuration != 21600 {
+		t.Fatalf("年度标签异常: %+v", report.TopTag)
+	}
+	if len(report.TopGames) != 3 || report.TopGames[0].GameID != "finished" || report.TopGames[0].PlayCount != 3 {
+		t.Fatalf("年度排行异常: %+v", report.TopGames)
+	}
+	if report.CompletedCount != 1 || report.CompletedGames[0].GameID != "finished" || report.CompletedGames[0].TotalDuration != 21600 {
+		t.Fatalf("最后一次游玩在次年的游戏不应计入本年通关: %+v", report.CompletedGames)
+	}
+	if report.LongestOwnedFinished == nil || report.LongestOwnedFinished.GameID != "finished" || report.LongestOwnedFinished.OwnedDays != 436 {
+		t.Fatalf("入库最久的通关游戏异常: %+v", report.LongestOwnedFinished)
+	}
+
+	zipPath := filepath.Join(t.TempDir(), "wrapped.zip")
+	if _, err := wrappedSvc.WriteWrappedBundle(2025, zipPath); err != nil {
+		t.Fatalf("导出年度回顾失败: %v", err)
+	}
+	archive, err := zip.OpenReader(zipPath)
+	if err != nil {
+		t.Fatalf("打开导出文件失败: %v", err)
+	}
+	defer archive.Close()
+	pages := make(map[string]string)
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(reader)
+		reader.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		pages[file.Name] = string(content)
+	}
+	if len(pages) != 3 || !strings.Contains(pages["index.html"], "Mystery") || !strings.Contains(pages["index.html"], `href="games.html"`) {
+		t.Fatalf("导出包应包含互相链接的多个页面: %v", len(pages))
+	}
+	if !strings.Contains(pages["games.html"], `src="data:image/png;base64,`) || strings.Contains(pages["games.html"], coverServer.URL) {
+		t.Fatalf("封面应内嵌为 data URL")
+	}
+	for name, page := range pages {
+		if strings.Contains(page, "ZgotmplZ") {
+			t.Fatalf("%s 中存在被过滤的内容", name)
+		}
+	}
+
+	dir := filepath.Join(t.TempDir(), "wrapped")
+	if _, err := wrappedSvc.WriteWrappedBundle(2025, dir); err != nil {
+		t.Fatalf("导出到目录失败: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "moments.html")); err != nil {
+		t.Fatalf("目录导出缺少页面: %v", err)
+	}
+}
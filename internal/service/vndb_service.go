@@ -0,0 +1,696 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"lunabox/internal/appconf"
+	"lunabox/internal/applog"
+	"lunabox/internal/common/enums"
+	"lunabox/internal/common/vo"
+	"lunabox/internal/models"
+	"lunabox/internal/service/cloudsync"
+	"lunabox/internal/service/remotestatus"
+	"lunabox/internal/utils/dbutils"
+	"lunabox/internal/utils/httputils"
+	"lunabox/internal/version"
+	"lunabox/internal/wailsruntime"
+
+	"github.com/google/uuid"
+)
+
+const (
+	vndbDefaultAPIBaseURL  = "https://api.vndb.org/kana"
+	vndbHTTPTimeout        = 30 * time.Second
+	vndbStatusSyncEvent    = "vndb:status-sync-progress"
+	vndbUListPageSize      = 100
+	vndbLengthBatchSize    = 100
+	vndbUListFields        = "id, vote, lastmod, labels.id, vn.title, vn.image.url, vn.released, vn.description, vn.rating, vn.developers.name"
+	vndbPermissionListRead = "listread"
+	vndbPermissionListEdit = "listwrite"
+
+	vndbImportActionCreate    = "create"
+	vndbImportActionUpdate    = "update"
+	vndbImportActionUnchanged = "unchanged"
+	vndbImportActionFailed    = "failed"
+)
+
+// VNDB 默认列表标签 ID
+const (
+	vndbLabelPlaying  = 1
+	vndbLabelFinished = 2
+	vndbLabelStalled  = 3
+	vndbLabelDropped  = 4
+	vndbLabelWishlist = 5
+)
+
+// vndbStatusLabels 是与游戏状态对应的标签；推送状态时只设置其中一个，其余移除。
+// 读取时按此顺序取第一个命中的标签。
+var vndbStatusLabels = []int{vndbLabelFinished, vndbLabelPlaying, vndbLabelStalled, vndbLabelDropped, vndbLabelWishlist}
+
+var errVNDBUnauthorized = errors.New("vndb unauthorized")
+
+type vndbAuthInfo struct {
+	ID          string   `json:"id"`
+	Username    string   `json:"username"`
+	Permissions []string `json:"permissions"`
+}
+
+type vndbUListRequest struct {
+	User    string `json:"user"`
+	Fields  string `json:"fields"`
+	Sort    string `json:"sort"`
+	Results int    `json:"results"`
+	Page    int    `json:"page"`
+}
+
+type vndbUListEntry struct {
+	ID      string `json:"id"`
+	Vote    *int   `json:"vote"`
+	LastMod int64  `json:"lastmod"`
+	Labels  []struct {
+		ID int `json:"id"`
+	} `json:"labels"`
+	VN struct {
+		Title       string  `json:"title"`
+		Released    string  `json:"released"`
+		Description string  `json:"description"`
+		Rating      float64 `json:"rating"`
+		Image       *struct {
+			URL string `json:"url"`
+		} `json:"image"`
+		Developers []struct {
+			Name string `json:"name"`
+		} `json:"developers"`
+	} `json:"vn"`
+}
+
+type vndbUListResponse struct {
+	Results []vndbUListEntry `json:"results"`
+	More    bool             `json:"more"`
+}
+
+type vndbLengthRequest struct {
+	Filters any    `json:"filters"`
+	Fields  string `json:"fields"`
+	Results int    `json:"results"`
+}
+
+type vndbLengthResponse struct {
+	Results []struct {
+		ID            string `json:"id"`
+		LengthMinutes *int   `json:"length_minutes"`
+	} `json:"results"`
+}
+
+// VNDBService 使用用户配置的 VNDB 令牌读写其 ulist（游玩列表）
+type VNDBService struct {
+	ctx         context.Context
+	db          *sql.DB
+	config      *appconf.AppConfig
+	gameService *GameService
+	httpClient  *http.Client
+	apiBaseURL  string
+	emitEvent   func(string, ...interface{})
+	batchSyncMu sync.Mutex
+}
+
+func NewVNDBService() *VNDBService {
+	runtime := wailsruntime.Unavailable()
+	return &VNDBService{
+		apiBaseURL: vndbDefaultAPIBaseURL,
+		emitEvent:  func(name string, data ...interface{}) { runtime.Emit(name, data...) },
+	}
+}
+
+//wails:ignore
+func (s *VNDBService) Init(ctx context.Context, db *sql.DB, config *appconf.AppConfig) {
+	s.ctx = ctx
+	s.db = db
+	s.config = config
+	if s.httpClient == nil {
+		client, _, err := httputils.NewClient(httputils.ClientOptions{
+			Timeout:     vndbHTTPTimeout,
+			ProxyConfig: config,
+		})
+		if err != nil {
+			applog.LogWarningf(ctx, "failed to create VNDB HTTP client with proxy config: %v", err)
+			client = &http.Client{Timeout: vndbHTTPTimeout}
+		}
+		s.httpClient = client
+	}
+}
+
+//wails:ignore
+func (s *VNDBService) SetRuntime(runtime wailsruntime.Runtime) {
+	if runtime == nil {
+		return
+	}
+	s.emitEvent = func(name string, data ...interface{}) {
+		runtime.Emit(name, data...)
+	}
+}
+
+//wails:ignore
+func (s *VNDBService) SetGameService(gameService *GameService) {
+	s.gameService = gameService
+}
+
+//wails:ignore
+func (s *VNDBService) SetHTTPClient(client *http.Client) {
+	if client != nil {
+		s.httpClient = client
+	}
+}
+
+// SetAPIBaseURL 替换 VNDB API 地址，供测试指向本地替身服务
+//
+//wails:ignore
+func (s *VNDBService) SetAPIBaseURL(baseURL string) {
+	if baseURL = strings.TrimRight(strings.TrimSpace(baseURL), "/"); baseURL != "" {
+		s.apiBaseURL = baseURL
+	}
+}
+
+//wails:ignore
+func (s *VNDBService) SetEventEmitter(emit func(string, ...interface{})) {
+	s.emitEvent = emit
+}
+
+// GetAuthInfo 校验 VNDB 令牌，返回对应用户及是否具有列表读写权限
+func (s *VNDBService) GetAuthInfo() (vo.VNDBAuthInfo, error) {
+	info, err := s.fetchAuthInfo(s.resolveContext(nil))
+	if err != nil {
+		return vo.VNDBAuthInfo{}, err
+	}
+	result := vo.VNDBAuthInfo{UserID: info.ID, Username: info.Username, Permissions: info.Permissions}
+	for _, permission := range info.Permissions {
+		switch permission {
+		case vndbPermissionListRead:
+			result.CanReadList = true
+		case vndbPermissionListEdit:
+			result.CanWriteList = true
+		}
+	}
+	if result.Permissions == nil {
+		result.Permissions = []string{}
+	}
+	return result, nil
+}
+
+func (s *VNDBService) SyncAllGameStatuses() (vo.RemoteStatusSyncProgress, error) {
+	s.batchSyncMu.Lock()
+	defer s.batchSyncMu.Unlock()
+
+	ctx := s.resolveContext(nil)
+	return remotestatus.SyncAll(remotestatus.Options{
+		Context: ctx,
+		DB:      s.db,
+		Source:  enums.VNDB,
+		Prepare: func(ctx context.Context) error {
+			return s.requirePermission(ctx, vndbPermissionListEdit)
+		},
+		Push: func(ctx context.Context, game models.Game) error {
+			return s.upsertUListStatus(ctx, game.SourceID, game.Status)
+		},
+		Emit: func(progress vo.RemoteStatusSyncProgress) {
+			if s.ctx != nil && s.emitEvent != nil {
+				s.emitEvent(vndbStatusSyncEvent, progress)
+			}
+		},
+	})
+}
+
+func (s *VNDBService) syncGameStatus(ctx context.Context, game models.Game) error {
+	if !s.isGameEligibleForStatusPush(game) {
+		return nil
+	}
+	return s.upsertUListStatus(ctx, game.SourceID, game.Status)
+}
+
+func (s *VNDBService) isGameEligibleForStatusPush(game models.Game) bool {
+	return s.accessToken() != "" &&
+		appconf.IsVNDBStatusPushEnabled(s.config) &&
+		game.SourceType == enums.VNDB &&
+		normalizeVNDBID(game.SourceID) != ""
+}
+
+// upsertUListStatus 把游戏状态写入 VNDB 列表：设置对应的标签并移除其他状态标签
+func (s *VNDBService) upsertUListStatus(ctx context.Context, vnID string, status enums.GameStatus) error {
+	label, ok := mapGameStatusToVNDBLabel(status)
+	if !ok {
+		return fmt.Errorf("不支持同步的 VNDB 状态: %s", status)
+	}
+	unset := make([]int, 0, len(vndbStatusLabels)-1)
+	for _, other := range vndbStatusLabels {
+		if other != label {
+			unset = append(unset, other)
+		}
+	}
+	return s.patchUList(ctx, vnID, map[string]any{"labels_set": []int{label}, "labels_unset": unset}, "状态")
+}
+
+// syncGameReview 把本地评分写入 VNDB 投票（10~100）；未评分时清除投票。VNDB 列表没有评价正文。
+func (s *VNDBService) syncGameReview(ctx context.Context, vnID string, review models.GameReview) error {
+	var vote any
+	if review.Rating != nil {
+		vote = *review.Rating * 10
+	}
+	return s.patchUList(ctx, vnID, map[string]any{"vote": vote}, "评分")
+}
+
+// PreviewUListImport 读取 VNDB 列表并与本地库比对，返回导入将发生的变化，不写入任何数据
+func (s *VNDBService) PreviewUListImport() (vo.VNDBUListImportReport, error) {
+	return s.importUList(true)
+}
+
+// ImportUList 把 VNDB 列表导入 LunaBox：新建本地没有的游戏；
+// 已有游戏在 VNDB 一侧更新时间较新时，采用 VNDB 的状态和评分。
+func (s *VNDBService) ImportUList() (vo.VNDBUListImportReport, error) {
+	return s.importUList(false)
+}
+
+func (s *VNDBService) importUList(dryRun bool) (vo.VNDBUListImportReport, error) {
+	s.batchSyncMu.Lock()
+	defer s.batchSyncMu.Unlock()
+
+	report := vo.VNDBUListImportReport{DryRun: dryRun, Items: []vo.VNDBUListImportItem{}}
+	if s.gameService == nil {
+		return report, fmt.Errorf("游戏服务未初始化")
+	}
+	ctx := s.resolveContext(nil)
+	entries, err := s.fetchUList(ctx)
+	if err != nil {
+		return report, err
+	}
+
+	for _, entry := range entries {
+		item := s.importUListEntry(ctx, entry, dryRun)
+		switch item.Action {
+		case vndbImportActionCreate:
+			report.Created++
+		case vndbImportActionUpdate:
+			report.Updated++
+		case vndbImportActionUnchanged:
+			report.Unchanged++
+		case vndbImportActionFailed:
+			report.Failed++
+		}
+		report.Items = append(report.Items, item)
+	}
+	report.Total = len(report.Items)
+
+	applog.LogInfof(ctx, "VNDB ulist import (dry run: %t): %d total, %d created, %d updated, %d unchanged, %d failed",
+		dryRun, report.Total, report.Created, report.Updated, report.Unchanged, report.Failed)
+	return report, nil
+}
+
+func (s *VNDBService) importUListEntry(ctx context.Context, entry vndbUListEntry, dryRun bool) vo.VNDBUListImportItem {
+	vnID := normalizeVNDBID(entry.ID)
+	status, hasStatus := vndbEntryStatus(entry)
+	item := vo.VNDBUListImportItem{VNID: vnID, GameName: strings.TrimSpace(entry.VN.Title), Rating: vndbVoteToRating(entry.Vote)}
+	if hasStatus {
+		item.Status = string(status)
+	}
+	fail := func(err error) vo.VNDBUListImportItem {
+		item.Action = vndbImportActionFailed
+		item.Error = err.Error()
+		applog.LogWarningf(ctx, "VNDB ulist import failed for %s: %v", vnID, err)
+		return item
+	}
+
+	gameID, found := s.findLocalGame(vnID)
+	if !found {
+		item.Action = vndbImportActionCreate
+		if dryRun {
+			return item
+		}
+		gameID, err := s.createGameFromUList(entry, vnID, status)
+		if err != nil {
+			return fail(err)
+		}
+		item.GameID = gameID
+		if item.Rating != nil {
+			if err := s.saveLocalRating(ctx, gameID, *item.Rating); err != nil {
+				return fail(err)
+			}
+		}
+		return item
+	}
+
+	item.GameID = gameID
+	remoteUpdatedAt := time.Unix(entry.LastMod, 0)
+	var localName, localStatus string
+	var localStatusUpdatedAt time.Time
+	err := s.db.QueryRowContext(ctx, `
+		SELECT name, COALESCE(status, 'not_started'), COALESCE(status_updated_at, updated_at, created_at, CURRENT_TIMESTAMP)
+		FROM games WHERE id = ?
+	`, gameID).Scan(&localName, &localStatus, &localStatusUpdatedAt)
+	if err != nil {
+		return fail(fmt.Errorf("读取本地游戏失败: %w", err))
+	}
+	item.GameName = localName
+
+	updateStatus := hasStatus && !sameVNDBStatus(enums.GameStatus(localStatus), status) && remoteUpdatedAt.After(localStatusUpdatedAt)
+	updateRating := false
+	if item.Rating != nil {
+		var rating sql.NullInt64
+		var reviewUpdatedAt time.Time
+		err := s.db.QueryRowContext(ctx, `SELECT rating, updated_at FROM game_reviews WHERE game_id = ?`, gameID).Scan(&rating, &reviewUpdatedAt)
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			updateRating = true
+		case err != nil:
+			return fail(fmt.Errorf("读取游戏评价失败: %w", err))
+		default:
+			updateRating = (!rating.Valid || int(rating.Int64) != *item.Rating) && remoteUpdatedAt.After(reviewUpdatedAt)
+		}
+	}
+	if !updateStatus && !updateRating {
+		item.Action = vndbImportActionUnchanged
+		return item
+	}
+	item.Action = vndbImportActionUpdate
+	if dryRun {
+		return item
+	}
+	if updateStatus {
+		if err := updateGameStatusFromRemote(ctx, s.db, gameID, status); err != nil {
+			return fail(err)
+		}
+	}
+	if updateRating {
+		if err := s.saveLocalRating(ctx, gameID, *item.Rating); err != nil {
+			return fail(err)
+		}
+	}
+	return item
+}
+
+// findLocalGame 按 VNDB ID 查找本地游戏，兼容不带 v 前缀的历史记录
+func (s *VNDBService) findLocalGame(vnID string) (string, bool) {
+	if gameID, ok := s.gameService.findGameIDBySource(enums.VNDB, vnID); ok {
+		return gameID, true
+	}
+	return s.gameService.findGameIDBySource(enums.VNDB, strings.TrimPrefix(vnID, "v"))
+}
+
+// createGameFromUList 用列表中附带的条目信息新建游戏，完整元数据可之后从 VNDB 刷新
+func (s *VNDBService) createGameFromUList(entry vndbUListEntry, vnID string, status enums.GameStatus) (string, error) {
+	game := models.Game{
+		ID:          uuid.New().String(),
+		Name:        firstNonEmptyString(entry.VN.Title, vnID),
+		Summary:     entry.VN.Description,
+		Rating:      entry.VN.Rating / 10,
+		ReleaseDate: strings.TrimSpace(entry.VN.Released),
+		SourceType:  enums.VNDB,
+		SourceID:    vnID,
+		Status:      status,
+	}
+	if entry.VN.Image != nil {
+		game.CoverURL = strings.TrimSpace(entry.VN.Image.URL)
+	}
+	developers := make([]string, 0, len(entry.VN.Developers))
+	for _, developer := range entry.VN.Developers {
+		if name := strings.TrimSpace(developer.Name); name != "" {
+			developers = append(developers, name)
+		}
+	}
+	game.Company = strings.Join(developers, ", ")
+
+	if err := s.gameService.AddGameFromWebMetadata(vo.GameMetadataFromWebVO{Source: enums.VNDB, Game: game}); err != nil {
+		return "", fmt.Errorf("新建游戏失败: %w", err)
+	}
+	return game.ID, nil
+}
+
+// saveLocalRating 只更新评分，保留已有的评价正文
+func (s *VNDBService) saveLocalRating(ctx context.Context, gameID string, rating int) error {
+	now := time.Now()
+	return dbutils.WithDuckDBWriteLock(s.db, func() error {
+		_, err := s.db.ExecContext(ctx, `
+			INSERT INTO game_reviews (game_id, rating, content, is_spoiler, created_at, updated_at)
+			VALUES (?, ?, '', FALSE, ?, ?)
+			ON CONFLICT (game_id) DO UPDATE SET
+				rating = EXCLUDED.rating,
+				updated_at = EXCLUDED.updated_at
+		`, gameID, rating, now, now)
+		if err != nil {
+			return fmt.Errorf("保存游戏评分失败: %w", err)
+		}
+		return cloudsync.DeleteTombstone(ctx, s.db, cloudsync.EntityGameReview, gameID)
+	})
+}
+
+// fetchUList 分页读取令牌所属用户的全部列表条目
+func (s *VNDBService) fetchUList(ctx context.Context) ([]vndbUListEntry, error) {
+	info, err := s.fetchAuthInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if !hasVNDBPermission(info, vndbPermissionListRead) {
+		return nil, fmt.Errorf("VNDB 令牌缺少 %s 权限", vndbPermissionListRead)
+	}
+
+	var entries []vndbUListEntry
+	for page := 1; ; page++ {
+		var resp vndbUListResponse
+		request := vndbUListRequest{User: info.ID, Fields: vndbUListFields, Sort: "lastmod", Results: vndbUListPageSize, Page: page}
+		if err := s.doJSON(ctx, http.MethodPost, "/ulist", request, &resp, "列表"); err != nil {
+			return nil, err
+		}
+		entries = append(entries, resp.Results...)
+		if !resp.More || len(resp.Results) == 0 {
+			return entries, nil
+		}
+	}
+}
+
+// FetchLengthMinutes 批量读取条目的平均游玩时长（分钟），结果以规范化的 VNDB ID 为键。
+// 该接口是公开的，未配置令牌时也可使用；没有用户提交时长的条目不会出现在结果中。
+//
+//wails:ignore
+func (s *VNDBService) FetchLengthMinutes(ctx context.Context, ids []string) (map[string]int, error) {
+	normalized := make([]string, 0, len(ids))
+	seen := make(map[string]struct{}, len(ids))
+	for _, id := range ids {
+		id = normalizeVNDBID(id)
+		if _, ok := seen[id]; ok || id == "" {
+			continue
+		}
+		seen[id] = struct{}{}
+		normalized = append(normalized, id)
+	}
+
+	lengths := make(map[string]int, len(normalized))
+	for start := 0; start < len(normalized); start += vndbLengthBatchSize {
+		end := min(start+vndbLengthBatchSize, len(normalized))
+		filters := []any{"or"}
+		for _, id := range normalized[start:end] {
+			filters = append(filters, []any{"id", "=", id})
+		}
+		var resp vndbLengthResponse
+		request := vndbLengthRequest{Filters: filters, Fields: "length_minutes", Results: vndbLengthBatchSize}
+		if end-start == 1 {
+			request.Filters = filters[1]
+		}
+		if err := s.sendJSON(ctx, http.MethodPost, "/vn", s.accessToken(), request, &resp, "条目时长"); err != nil {
+			return lengths, err
+		}
+		for _, item := range resp.Results {
+			if item.LengthMinutes != nil && *item.LengthMinutes > 0 {
+				lengths[normalizeVNDBID(item.ID)] = *item.LengthMinutes
+			}
+		}
+	}
+	return lengths, nil
+}
+
+func (s *VNDBService) fetchAuthInfo(ctx context.Context) (vndbAuthInfo, error) {
+	var info vndbAuthInfo
+	if err := s.doJSON(ctx, http.MethodGet, "/authinfo", nil, &info, "令牌信息"); err != nil {
+		return vndbAuthInfo{}, err
+	}
+	return info, nil
+}
+
+func (s *VNDBService) requirePermission(ctx context.Context, permission string) error {
+	info, err := s.fetchAuthInfo(ctx)
+	if err != nil {
+		return err
+	}
+	if !hasVNDBPermission(info, permission) {
+		return fmt.Errorf("VNDB 令牌缺少 %s 权限", permission)
+	}
+	return nil
+}
+
+func (s *VNDBService) patchUList(ctx context.Context, vnID string, payload map[string]any, operation string) error {
+	vnID = normalizeVNDBID(vnID)
+	if vnID == "" {
+		return fmt.Errorf("无效的 VNDB 条目 ID")
+	}
+	return s.doJSON(ctx, http.MethodPatch, "/ulist/"+url.PathEscape(vnID), payload, nil, operation)
+}
+
+// doJSON 发送一个 VNDB API 请求；result 为 nil 时忽略响应内容
+func (s *VNDBService) doJSON(ctx context.Context, method, path string, payload any, result any, operation string) error {
+	token := s.accessToken()
+	if token == "" {
+		return fmt.Errorf("未配置 VNDB 令牌")
+	}
+	return s.sendJSON(ctx, method, path, token, payload, result, operation)
+}
+
+// sendJSON 发送一个 VNDB API 请求；token 为空时以匿名身份访问公开接口
+func (s *VNDBService) sendJSON(ctx context.Context, method, path, token string, payload any, result any, operation string) error {
+	var body io.Reader
+	if payload != nil {
+		encoded, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("编码 VNDB %s请求失败: %w", operation, err)
+		}
+		body = strings.NewReader(string(encoded))
+	}
+	req, err := http.NewRequestWithContext(s.resolveContext(ctx), method, s.apiBaseURL+path, body)
+	if err != nil {
+		return fmt.Errorf("创建 VNDB %s请求失败: %w", operation, err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Token "+token)
+	}
+	req.Header.Set("User-Agent", version.UserAgent())
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := httputils.DoWithRetry(req.Context(), s.httpClient, req, httputils.RetryPolicy{
+		MaxRetries:    1,
+		FallbackDelay: time.Second,
+		MaxDelay:      30 * time.Second,
+	})
+	if err != nil {
+		return fmt.Errorf("请求 VNDB %s接口失败: %w", operation, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("读取 VNDB %s响应失败: %w", operation, err)
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		return fmt.Errorf("%w: %s", errVNDBUnauthorized, strings.TrimSpace(string(respBody)))
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return httputils.NewStatusError(resp, fmt.Sprintf("VNDB %s接口返回 HTTP %d: %s", operation, resp.StatusCode, strings.TrimSpace(string(respBody))))
+	}
+	if result == nil {
+		return nil
+	}
+	if err := json.Unmarshal(respBody, result); err != nil {
+		return fmt.Errorf("解析 VNDB %s响应失败: %w", operation, err)
+	}
+	return nil
+}
+
+func (s *VNDBService) accessToken() string {
+	if s.config == nil {
+		return ""
+	}
+	return strings.TrimSpace(s.config.VNDBAccessToken)
+}
+
+func (s *VNDBService) resolveContext(ctx context.Context) context.Context {
+	if ctx != nil {
+		return ctx
+	}
+	if s.ctx != nil {
+		return s.ctx
+	}
+	return context.Background()
+}
+
+func hasVNDBPermission(info vndbAuthInfo, permission string) bool {
+	for _, granted := range info.Permissions {
+		if granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeVNDBID 统一为带 v 前缀的小写 ID，如 17 → v17
+func normalizeVNDBID(id string) string {
+	id = strings.ToLower(strings.TrimSpace(id))
+	if id == "" {
+		return ""
+	}
+	if !strings.HasPrefix(id, "v") {
+		id = "v" + id
+	}
+	return id
+}
+
+func mapGameStatusToVNDBLabel(status enums.GameStatus) (int, bool) {
+	switch status {
+	case enums.StatusNotStarted, enums.StatusWantToPlay:
+		return vndbLabelWishlist, true
+	case enums.StatusCompleted:
+		return vndbLabelFinished, true
+	case enums.StatusPlaying:
+		return vndbLabelPlaying, true
+	case enums.StatusOnHold:
+		return vndbLabelStalled, true
+	default:
+		return 0, false
+	}
+}
+
+// vndbEntryStatus 返回列表条目对应的本地状态；Dropped 没有对应状态，按搁置处理
+func vndbEntryStatus(entry vndbUListEntry) (enums.GameStatus, bool) {
+	labels := make(map[int]struct{}, len(entry.Labels))
+	for _, label := range entry.Labels {
+		labels[label.ID] = struct{}{}
+	}
+	for _, label := range vndbStatusLabels {
+		if _, ok := labels[label]; !ok {
+			continue
+		}
+		switch label {
+		case vndbLabelFinished:
+			return enums.StatusCompleted, true
+		case vndbLabelPlaying:
+			return enums.StatusPlaying, true
+		case vndbLabelStalled, vndbLabelDropped:
+			return enums.StatusOnHold, true
+		case vndbLabelWishlist:
+			return enums.StatusWantToPlay, true
+		}
+	}
+	return "", false
+}
+
+func sameVNDBStatus(local, remote enums.GameStatus) bool {
+	localLabel, _ := mapGameStatusToVNDBLabel(local)
+	remoteLabel, _ := mapGameStatusToVNDBLabel(remote)
+	return localLabel == remoteLabel
+}
+
+// vndbVoteToRating 把 VNDB 投票（10~100）换算为 1~10 评分
+func vndbVoteToRating(vote *int) *int {
+	if vote == nil || *vote <= 0 {
+		return nil
+	}
+	rating := min(max((*vote+5)/10, 1), 10)
+	return &rating
+}
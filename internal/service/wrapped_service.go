@@ -0,0 +1,514 @@
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"database/sql"
+	"embed"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"lunabox/internal/appconf"
+	"lunabox/internal/applog"
+	"lunabox/internal/common/enums"
+	"lunabox/internal/common/vo"
+	"lunabox/internal/utils/apputils"
+	"lunabox/internal/version"
+	"lunabox/internal/wailsruntime"
+)
+
+//go:embed templates/wrapped/*.html
+var wrappedTemplates embed.FS
+
+const wrappedTopGamesLimit = 10
+
+// wrappedPages 年度回顾包含的页面，第一页为入口
+var wrappedPages = []wrappedPageLink{
+	{File: "index.html", Title: "总览"},
+	{File: "games.html", Title: "游戏"},
+	{File: "moments.html", Title: "时刻"},
+}
+
+type wrappedPageLink struct {
+	File  string
+	Title string
+}
+
+type wrappedPageData struct {
+	Report           vo.WrappedReport
+	Pages            []wrappedPageLink
+	Current          string
+	MaxMonthDuration int
+	AppName          string
+	AppVersion       string
+	ExportTime       string
+}
+
+// WrappedService 生成年度回顾报告，并导出为内嵌封面的多页 HTML
+type WrappedService struct {
+	ctx             context.Context
+	db              *sql.DB
+	config          *appconf.AppConfig
+	runtime         wailsruntime.Runtime
+	templateService *TemplateService
+}
+
+func NewWrappedService() *WrappedService {
+	return &WrappedService{runtime: wailsruntime.Unavailable()}
+}
+
+//wails:ignore
+func (s *WrappedService) Init(ctx context.Context, db *sql.DB, config *appconf.AppConfig) {
+	s.ctx = ctx
+	s.db = db
+	s.config = config
+}
+
+//wails:ignore
+func (s *WrappedService) SetRuntime(runtime wailsruntime.Runtime) {
+	if runtime != nil {
+		s.runtime = runtime
+	}
+}
+
+//wails:ignore
+func (s *WrappedService) SetTemplateService(templateService *TemplateService) {
+	s.templateService = templateService
+}
+
+// GetWrappedReport 统计指定年份的年度回顾数据，year 为 0 时使用今年
+func (s *WrappedService) GetWrappedReport(year int) (vo.WrappedReport, error) {
+	if year == 0 {
+		year = time.Now().Year()
+	}
+	if year < 1970 || year > 9999 {
+		return vo.WrappedReport{}, fmt.Errorf("无效的年份: %d", year)
+	}
+
+	start := time.Date(year, time.January, 1, 0, 0, 0, 0, time.Local)
+	end := start.AddDate(1, 0, 0)
+	report := vo.WrappedReport{
+		Year:           year,
+		StartDate:      start.Format(statsDateLayout),
+		EndDate:        end.AddDate(0, 0, -1).Format(statsDateLayout),
+		Months:         make([]vo.WrappedMonth, 12),
+		TopGames:       make([]vo.WrappedGame, 0),
+		CompletedGames: make([]vo.WrappedGame, 0),
+	}
+	for i := range report.Months {
+		report.Months[i].Month = start.AddDate(0, i, 0).Format("2006-01")
+	}
+
+	sessions, err := s.loadWrappedSessions(start, end)
+	if err != nil {
+		return report, err
+	}
+	if len(sessions) == 0 {
+		return report, nil
+	}
+
+	games := make(map[string]*vo.WrappedGame)
+	var gameOrder []string
+	days := make(map[string]struct{})
+	for _, session := range sessions {
+		report.TotalPlayDuration += session.Duration
+		report.TotalPlayCount++
+		days[session.StartTime.In(time.Local).Format(statsDateLayout)] = struct{}{}
+
+		month := &report.Months[int(session.StartTime.In(time.Local).Month())-1]
+		month.TotalDuration += session.Duration
+		month.PlayCount++
+
+		if report.BiggestSession == nil || session.Duration > report.BiggestSession.Duration {
+			biggest := session
+			report.BiggestSession = &biggest
+		}
+
+		game, ok := games[session.GameID]
+		if !ok {
+			game = &vo.WrappedGame{GameID: session.GameID, GameName: session.GameName, CoverURL: session.CoverURL}
+			games[session.GameID] = game
+			gameOrder = append(gameOrder, session.GameID)
+		}
+		game.TotalDuration += session.Duration
+		game.PlayCount++
+	}
+
+	first := sessions[0]
+	last := sessions[len(sessions)-1]
+	report.FirstSession = &first
+	report.LastSession = &last
+	report.GamesPlayedCount = len(games)
+	report.ActiveDays = len(days)
+	report.AvgSessionDuration = report.TotalPlayDuration / report.TotalPlayCount
+	report.TopStreak = longestWrappedStreak(days)
+
+	for i := range report.Months {
+		month := report.Months[i]
+		if month.TotalDuration > 0 && (report.BusiestMonth == nil || month.TotalDuration > report.BusiestMonth.TotalDuration) {
+			report.BusiestMonth = &month
+		}
+	}
+
+	for _, gameID := range gameOrder {
+		report.TopGames = append(report.TopGames, *games[gameID])
+	}
+	sort.SliceStable(report.TopGames, func(i, j int) bool {
+		return report.TopGames[i].TotalDuration > report.TopGames[j].TotalDuration
+	})
+	if len(report.TopGames) > wrappedTopGamesLimit {
+		report.TopGames = report.TopGames[:wrappedTopGamesLimit]
+	}
+
+	if report.TopTag, err = s.queryWrappedTopTag(start, end); err != nil {
+		return report, err
+	}
+	if err := s.fillWrappedCompletions(&report, start, end, games); err != nil {
+		return report, err
+	}
+	return report, nil
+}
+
+// ExportWrappedBundle 弹出保存对话框，将年度回顾导出为 zip 包，返回保存路径；用户取消时返回空字符串
+func (s *WrappedService) ExportWrappedBundle(year int) (string, error) {
+	if year == 0 {
+		year = time.Now().Year()
+	}
+	filename, err := s.runtime.SaveFile(wailsruntime.SaveDialogOptions{
+		Filename: fmt.Sprintf("lunabox-wrapped-%d.zip", year),
+		Title:    "导出年度回顾",
+		Filters: []wailsruntime.FileFilter{
+			{
+				DisplayName: "ZIP Archives (*.zip)",
+				Pattern:     "*.zip",
+			},
+		},
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to open save dialog: %w", err)
+	}
+	if filename == "" {
+		return "", nil
+	}
+	if _, err := s.WriteWrappedBundle(year, filename); err != nil {
+		return "", err
+	}
+	return filename, nil
+}
+
+// WriteWrappedBundle 将年度回顾写入 outputPath 并返回报告数据：以 .zip 结尾时写为压缩包，否则写入该目录。
+// 各页面的样式和封面都内嵌在 HTML 中，可离线打开；供 GUI 导出和 lunacli 调用。
+//
+//wails:ignore
+func (s *WrappedService) WriteWrappedBundle(year int, outputPath string) (vo.WrappedReport, error) {
+	report, err := s.GetWrappedReport(year)
+	if err != nil {
+		return report, err
+	}
+	pages, err := s.renderWrappedPages(report)
+	if err != nil {
+		return report, err
+	}
+
+	if strings.EqualFold(filepath.Ext(outputPath), ".zip") {
+		return report, writeWrappedZip(outputPath, pages)
+	}
+	if err := os.MkdirAll(outputPath, 0755); err != nil {
+		return report, fmt.Errorf("创建导出目录失败: %w", err)
+	}
+	for _, page := range wrappedPages {
+		if err := os.WriteFile(filepath.Join(outputPath, page.File), pages[page.File], 0644); err != nil {
+			return report, fmt.Errorf("写入 %s 失败: %w", page.File, err)
+		}
+	}
+	return report, nil
+}
+
+func (s *WrappedService) loadWrappedSessions(start, end time.Time) ([]vo.WrappedSession, error) {
+	rows, err := s.db.QueryContext(s.ctx, `
+		SELECT ps.game_id, COALESCE(g.name, ''), COALESCE(g.cover_url, ''), ps.start_time, COALESCE(ps.duration, 0)
+		FROM play_sessions ps
+		JOIN games g ON g.id = ps.game_id
+		WHERE ps.start_time >= ? AND ps.start_time < ?
+		ORDER BY ps.start_time, ps.id
+	`, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("查询年度游玩记录失败: %w", err)
+	}
+	defer rows.Close()
+
+	var sessions []vo.WrappedSession
+	for rows.Next() {
+		var row vo.WrappedSession
+		if err := rows.Scan(&row.GameID, &row.GameName, &row.CoverURL, &row.StartTime, &row.Duration); err != nil {
+			return nil, fmt.Errorf("读取年度游玩记录失败: %w", err)
+		}
+		sessions = append(sessions, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("读取年度游玩记录失败: %w", err)
+	}
+	return sessions, nil
+}
+
+func (s *WrappedService) queryWrappedTopTag(start, end time.Time) (*vo.WrappedTag, error) {
+	var tag vo.WrappedTag
+	err := s.db.QueryRowContext(s.ctx, `
+		SELECT gt.name, SUM(ps.duration), COUNT(DISTINCT ps.game_id)
+		FROM play_sessions ps
+		JOIN (
+			SELECT DISTINCT game_id, name FROM game_tags
+			WHERE COALESCE(is_spoiler, FALSE) = FALSE AND name IS NOT NULL AND name <> ''
+		) gt ON gt.game_id = ps.game_id
+		WHERE ps.start_time >= ? AND ps.start_time < ?
+		GROUP BY gt.name
+		HAVING SUM(ps.duration) > 0
+		ORDER BY 2 DESC, 1
+		LIMIT 1
+	`, start, end).Scan(&tag.Name, &tag.TotalDuration, &tag.GameCount)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("查询年度标签失败: %w", err)
+	}
+	return &tag, nil
+}
+
+// fillWrappedCompletions 统计本年通关的游戏：状态为已通关，且全部游玩记录中的最后一次发生在本年
+func (s *WrappedService) fillWrappedCompletions(report *vo.WrappedReport, start, end time.Time, games map[string]*vo.WrappedGame) error {
+	rows, err := s.db.QueryContext(s.ctx, `
+		SELECT g.id, COALESCE(g.name, ''), COALESCE(g.cover_url, ''), g.created_at, MAX(ps.start_time)
+		FROM games g
+		JOIN play_sessions ps ON ps.game_id = g.id
+		WHERE g.status = ?
+		GROUP BY g.id, g.name, g.cover_url, g.created_at
+		HAVING MAX(ps.start_time) >= ? AND MAX(ps.start_time) < ?
+		ORDER BY MAX(ps.start_time)
+	`, enums.StatusCompleted, start, end)
+	if err != nil {
+		return fmt.Errorf("查询年度通关游戏失败: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var owned vo.WrappedOwnedGame
+		var addedAt sql.NullTime
+		if err := rows.Scan(&owned.GameID, &owned.GameName, &owned.CoverURL, &addedAt, &owned.FinishedAt); err != nil {
+			return fmt.Errorf("读取年度通关游戏失败: %w", err)
+		}
+		completed := vo.WrappedGame{GameID: owned.GameID, GameName: owned.GameName, CoverURL: owned.CoverURL}
+		if game, ok := games[owned.GameID]; ok {
+			completed = *game
+		}
+		report.CompletedGames = append(report.CompletedGames, completed)
+
+		if !addedAt.Valid || addedAt.Time.After(owned.FinishedAt) {
+			continue
+		}
+		owned.AddedAt = addedAt.Time
+		owned.OwnedDays = int(owned.FinishedAt.Sub(owned.AddedAt).Hours() / 24)
+		if report.LongestOwnedFinished == nil || owned.OwnedDays > report.LongestOwnedFinished.OwnedDays {
+			longest := owned
+			report.LongestOwnedFinished = &longest
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("读取年度通关游戏失败: %w", err)
+	}
+	report.CompletedCount = len(report.CompletedGames)
+	return nil
+}
+
+func (s *WrappedService) renderWrappedPages(report vo.WrappedReport) (map[string][]byte, error) {
+	covers := s.embedWrappedCovers(report)
+	funcMap := template.FuncMap{
+		"formatDuration": formatDuration,
+		"formatDate":     func(t time.Time) string { return t.In(time.Local).Format(statsDateLayout) },
+		"formatTime":     func(t time.Time) string { return t.In(time.Local).Format("2006-01-02 15:04") },
+		"add":            func(a, b int) int { return a + b },
+		"percentOf": func(value, total int) int {
+			if total <= 0 {
+				return 0
+			}
+			return value * 100 / total
+		},
+		// data: URL 默认会被 html/template 过滤，封面已在本地转换为 base64，可以安全输出
+		"cover": func(gameID string) template.URL { return template.URL(covers[gameID]) },
+	}
+	layout, err := template.New("wrapped").Funcs(funcMap).ParseFS(wrappedTemplates, "templates/wrapped/layout.html")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse wrapped layout: %w", err)
+	}
+
+	data := wrappedPageData{
+		Report:     report,
+		Pages:      wrappedPages,
+		AppName:    "LunaBox",
+		AppVersion: version.Version,
+		ExportTime: time.Now().Format("2006-01-02 15:04:05"),
+	}
+	for _, month := range report.Months {
+		data.MaxMonthDuration = max(data.MaxMonthDuration, month.TotalDuration)
+	}
+
+	pages := make(map[string][]byte, len(wrappedPages))
+	for _, page := range wrappedPages {
+		tmpl, err := layout.Clone()
+		if err != nil {
+			return nil, fmt.Errorf("failed to clone wrapped layout: %w", err)
+		}
+		if tmpl, err = tmpl.ParseFS(wrappedTemplates, "templates/wrapped/"+page.File); err != nil {
+			return nil, fmt.Errorf("failed to parse wrapped page %s: %w", page.File, err)
+		}
+		data.Current = page.File
+		var buf strings.Builder
+		if err := tmpl.ExecuteTemplate(&buf, "layout", data); err != nil {
+			return nil, fmt.Errorf("failed to execute wrapped page %s: %w", page.File, err)
+		}
+		pages[page.File] = []byte(buf.String())
+	}
+	return pages, nil
+}
+
+// embedWrappedCovers 将报告中出现的封面转为 data URL；单张封面失败时跳过，不影响导出
+func (s *WrappedService) embedWrappedCovers(report vo.WrappedReport) map[string]string {
+	sources := make(map[string]string)
+	add := func(gameID, coverURL string) {
+		if coverURL != "" {
+			sources[gameID] = coverURL
+		}
+	}
+	for _, game := range report.TopGames {
+		add(game.GameID, game.CoverURL)
+	}
+	for _, game := range report.CompletedGames {
+		add(game.GameID, game.CoverURL)
+	}
+	if report.BiggestSession != nil {
+		add(report.BiggestSession.GameID, report.BiggestSession.CoverURL)
+	}
+	if report.LongestOwnedFinished != nil {
+		add(report.LongestOwnedFinished.GameID, report.LongestOwnedFinished.CoverURL)
+	}
+
+	covers := make(map[string]string, len(sources))
+	for gameID, coverURL := range sources {
+		dataURL, err := s.coverDataURL(coverURL)
+		if err != nil {
+			applog.LogWarningf(s.ctx, "failed to embed wrapped cover for game %s: %v", gameID, err)
+			continue
+		}
+		covers[gameID] = dataURL
+	}
+	return covers
+}
+
+func (s *WrappedService) coverDataURL(coverURL string) (string, error) {
+	switch {
+	case strings.HasPrefix(coverURL, "data:"):
+		return coverURL, nil
+	case strings.HasPrefix(coverURL, "/local/"):
+		dataDir, err := apputils.GetDataDir()
+		if err != nil {
+			return "", err
+		}
+		baseDir, err := filepath.Abs(dataDir)
+		if err != nil {
+			return "", err
+		}
+		fullPath := filepath.Join(baseDir, filepath.FromSlash(strings.TrimPrefix(coverURL, "/local/")))
+		if !strings.HasPrefix(fullPath, baseDir+string(os.PathSeparator)) {
+			return "", fmt.Errorf("封面路径超出数据目录: %s", coverURL)
+		}
+		content, err := os.ReadFile(fullPath)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("data:%s;base64,%s", http.DetectContentType(content), base64.StdEncoding.EncodeToString(content)), nil
+	case strings.HasPrefix(coverURL, "http://"), strings.HasPrefix(coverURL, "https://"):
+		templateService := s.templateService
+		if templateService == nil {
+			templateService = NewTemplateService()
+			templateService.Init(s.ctx, s.db, s.config)
+		}
+		dataURL, err := templateService.fetchImageAsBase64(coverURL)
+		if err != nil {
+			return "", err
+		}
+		if !strings.HasPrefix(dataURL, "data:") {
+			return "", fmt.Errorf("封面无法内嵌: %s", coverURL)
+		}
+		return dataURL, nil
+	default:
+		return "", fmt.Errorf("不支持的封面地址: %s", coverURL)
+	}
+}
+
+// longestWrappedStreak 计算最长的连续游玩天数，并列时取较早的一段
+func longestWrappedStreak(days map[string]struct{}) vo.WrappedStreak {
+	dates := make([]string, 0, len(days))
+	for day := range days {
+		dates = append(dates, day)
+	}
+	sort.Strings(dates)
+
+	var best, current vo.WrappedStreak
+	var previous time.Time
+	for _, day := range dates {
+		date, err := time.ParseInLocation(statsDateLayout, day, time.Local)
+		if err != nil {
+			continue
+		}
+		if current.Days > 0 && previous.AddDate(0, 0, 1).Equal(date) {
+			current.Days++
+			current.EndDate = day
+		} else {
+			current = vo.WrappedStreak{Days: 1, StartDate: day, EndDate: day}
+		}
+		if current.Days > best.Days {
+			best = current
+		}
+		previous = date
+	}
+	return best
+}
+
+func writeWrappedZip(outputPath string, pages map[string][]byte) (err error) {
+	if dir := filepath.Dir(outputPath); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return fmt.Errorf("创建导出目录失败: %w", err)
+		}
+	}
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("创建导出文件失败: %w", err)
+	}
+	defer func() {
+		if closeErr := file.Close(); err == nil && closeErr != nil {
+			err = fmt.Errorf("写入导出文件失败: %w", closeErr)
+		}
+	}()
+
+	archive := zip.NewWriter(file)
+	for _, page := range wrappedPages {
+		writer, err := archive.Create(page.File)
+		if err != nil {
+			return fmt.Errorf("写入 %s 失败: %w", page.File, err)
+		}
+		if _, err := writer.Write(pages[page.File]); err != nil {
+			return fmt.Errorf("写入 %s 失败: %w", page.File, err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		return fmt.Errorf("写入导出文件失败: %w", err)
+	}
+	return nil
+}
@@ -130,6 +130,11 @@ func GetTemplatesDir() (string, error) {
 	return GetSubDir("templates")
 }
 
+// GetMetadataPluginsDir 获取元数据插件目录
+func GetMetadataPluginsDir() (string, error) {
+	return GetSubDir("metadata-plugins")
+}
+
 // GetDesktopDir 获取当前用户桌面目录
 func GetDesktopDir() (string, error) {
 	homeDir, err := os.UserHomeDir()
@@ -1,12 +1,92 @@
 package utils
 
 import (
+	"crypto/hkdf"
+	"crypto/pbkdf2"
 	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
+	"fmt"
 )
 
-// GenerateUserID 根据备份密码生成用户 ID
-func GenerateUserID(password string) string {
+// backupKeyIterations 是备份密码派生加密密钥时 PBKDF2-SHA256 的迭代次数。
+// 派生只在设置/轮换密码时发生一次，结果保存在配置中，因此可以取较高的值。
+const backupKeyIterations = 600_000
+
+// 备份身份（v2）：主密钥 = PBKDF2(密码, 固定域分隔盐)，用户 ID 与加密主密钥都用
+// HKDF 从主密钥派生。用户 ID 出现在每个云端对象路径中，从它反推密码必须付出与
+// 主密钥相同的 PBKDF2 代价。新设备只凭密码就要算出用户 ID 才能找到云端数据，
+// 因此这里的盐只能是固定值；每个对象的随机盐保存在加密信封中（见 backup_envelope.go）。
+const (
+	backupMasterKeySalt     = "lunabox-backup-master-v2"
+	backupUserIDInfo        = "lunabox-backup-user-id"
+	backupEncryptionKeyInfo = "lunabox-backup-encryption-key"
+	backupUserIDSize        = 16
+)
+
+// DeriveBackupCredentials 根据备份密码派生用户 ID 与 base64 编码的加密主密钥。
+// 同一密码在不同设备上得到相同结果。
+func DeriveBackupCredentials(password string) (userID, encryptionKey string, err error) {
+	master, err := pbkdf2.Key(sha256.New, password, []byte(backupMasterKeySalt), backupKeyIterations, backupKeySize)
+	if err != nil {
+		return "", "", fmt.Errorf("derive backup master key: %w", err)
+	}
+	id, err := hkdf.Key(sha256.New, master, nil, backupUserIDInfo, backupUserIDSize)
+	if err != nil {
+		return "", "", fmt.Errorf("derive backup user id: %w", err)
+	}
+	key, err := hkdf.Key(sha256.New, master, nil, backupEncryptionKeyInfo, backupKeySize)
+	if err != nil {
+		return "", "", fmt.Errorf("derive backup key: %w", err)
+	}
+	return hex.EncodeToString(id), base64.StdEncoding.EncodeToString(key), nil
+}
+
+// ResolveBackupCredentials 校验密码是否对应 userID，并返回该身份使用的加密主密钥。
+// 同时识别 v2 身份与旧版（v1）身份，供启用加密和密码轮换校验原密码。
+func ResolveBackupCredentials(password, userID string) (encryptionKey string, ok bool, err error) {
+	if userID == "" {
+		return "", false, nil
+	}
+	if LegacyBackupUserID(password) == userID {
+		key, err := deriveLegacyBackupEncryptionKey(password)
+		return key, err == nil, err
+	}
+	derivedID, key, err := DeriveBackupCredentials(password)
+	if err != nil {
+		return "", false, err
+	}
+	if derivedID != userID {
+		return "", false, nil
+	}
+	return key, true, nil
+}
+
+// LegacyBackupUserID 旧版（v1）用户 ID：密码的单次 SHA-256，可被离线暴力破解。
+// 只用于识别旧配置；旧身份可通过密码轮换（新旧密码可相同）迁移到 v2 身份。
+func LegacyBackupUserID(password string) string {
 	hash := sha256.Sum256([]byte("lunabox-backup:" + password))
 	return hex.EncodeToString(hash[:16])
 }
+
+// deriveLegacyBackupEncryptionKey 旧版身份的加密主密钥，盐由旧版用户 ID 决定。
+func deriveLegacyBackupEncryptionKey(password string) (string, error) {
+	salt := []byte("lunabox-backup-key:" + LegacyBackupUserID(password))
+	key, err := pbkdf2.Key(sha256.New, password, salt, backupKeyIterations, backupKeySize)
+	if err != nil {
+		return "", fmt.Errorf("derive backup key: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(key), nil
+}
+
+// DecodeBackupEncryptionKey 解码配置中保存的加密主密钥。
+func DecodeBackupEncryptionKey(encoded string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("decode backup key: %w", err)
+	}
+	if len(key) != backupKeySize {
+		return nil, fmt.Errorf("invalid backup key length: %d", len(key))
+	}
+	return key, nil
+}
@@ -0,0 +1,396 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// 云端对象加密信封（v1）布局：
+//
+//	magic      [7]byte  "LUNAENC"
+//	version    uint8    = 1
+//	keyCheck   [8]byte  HMAC-SHA256(主密钥, "lunabox-backup-key-check") 前 8 字节
+//	salt       [16]byte 每个对象随机生成，用 HKDF 从主密钥派生对象密钥
+//	chunkSize  uint32   明文分块大小（大端）
+//
+// 之后是若干 AES-256-GCM 分块。nonce 为 8 字节大端分块序号 + 3 字节 0 + 1 字节
+// "最后一块" 标记，整个头部作为每个分块的附加认证数据，防止分块被重排、截断或头部被篡改。
+// 配置了密钥时，没有 magic 的对象一律视为被替换的明文并拒绝读取；只有显式的
+// 加密迁移（DecryptLegacyBackupFile）才会把加密前上传的旧版明文原样读出。
+//
+// 只接受 JSON 载荷的存储（如 Umbra 同步记录）使用 JSON 包装：
+//
+//	{"lunabox_encrypted":"<base64(上述二进制信封)>"}
+const (
+	BackupEnvelopeVersion = 1
+
+	backupEnvelopeMagic      = "LUNAENC"
+	backupEnvelopeHeaderSize = 7 + 1 + 8 + 16 + 4
+	backupKeySize            = 32
+	backupChunkSize          = 64 * 1024
+	backupMaxChunkSize       = 16 * 1024 * 1024
+	backupObjectKeyInfo      = "lunabox-backup-object-v1"
+	backupKeyCheckInfo       = "lunabox-backup-key-check"
+	backupJSONEnvelopeField  = "lunabox_encrypted"
+	backupJSONEnvelopeLimit  = 16 * 1024 * 1024
+)
+
+// backupJSONEnvelope 二进制信封的 JSON 包装
+type backupJSONEnvelope struct {
+	Encrypted string `json:"lunabox_encrypted"`
+}
+
+var (
+	// ErrBackupKeyMissing 表示对象已加密，但本机尚未设置备份密码。
+	ErrBackupKeyMissing = errors.New("云端数据已加密，请先设置备份密码")
+	// ErrBackupKeyMismatch 表示对象由其它备份密码加密。
+	ErrBackupKeyMismatch = errors.New("备份密码与云端数据不匹配，无法解密")
+	// ErrBackupEnvelopeCorrupted 表示信封头部或分块认证失败。
+	ErrBackupEnvelopeCorrupted = errors.New("云端加密数据已损坏或被篡改")
+	// ErrBackupEnvelopeMissing 表示已启用加密，但云端对象是明文，可能被替换为未加密的数据。
+	ErrBackupEnvelopeMissing = errors.New("云端数据未加密，可能已被替换；如需导入加密前上传的数据，请先执行云端加密迁移")
+	// ErrBackupEnvelopeUnsupported 表示信封版本比当前客户端更新。
+	ErrBackupEnvelopeUnsupported = errors.New("云端加密数据版本过新，请更新 LunaBox")
+)
+
+// IsBackupEnvelope 判断数据开头是否为加密信封。
+func IsBackupEnvelope(prefix []byte) bool {
+	return len(prefix) >= len(backupEnvelopeMagic) && string(prefix[:len(backupEnvelopeMagic)]) == backupEnvelopeMagic
+}
+
+// IsBackupEnvelopeFile 判断本地文件是否为加密信封（含 JSON 包装）。
+func IsBackupEnvelopeFile(path string) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	prefix := make([]byte, len(backupEnvelopeMagic))
+	n, err := io.ReadFull(file, prefix)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return false, err
+	}
+	if IsBackupEnvelope(prefix[:n]) {
+		return true, nil
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return false, err
+	}
+	_, wrapped, err := readBackupJSONEnvelope(file)
+	return wrapped, err
+}
+
+// IsBackupJSONEnvelope 判断数据是否为 JSON 包装的加密信封。
+func IsBackupJSONEnvelope(data []byte) bool {
+	_, wrapped, _ := readBackupJSONEnvelope(bytes.NewReader(data))
+	return wrapped
+}
+
+// readBackupJSONEnvelope 读取 JSON 包装并返回其中的二进制信封；不是 JSON 包装时 wrapped=false。
+func readBackupJSONEnvelope(r io.Reader) (sealed []byte, wrapped bool, err error) {
+	reader := bufio.NewReader(r)
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, false, nil
+			}
+			return nil, false, err
+		}
+		if b == ' ' || b == '\t' || b == '\r' || b == '\n' {
+			continue
+		}
+		if b != '{' {
+			return nil, false, nil
+		}
+		if err := reader.UnreadByte(); err != nil {
+			return nil, false, err
+		}
+		break
+	}
+
+	data, err := io.ReadAll(io.LimitReader(reader, backupJSONEnvelopeLimit+1))
+	if err != nil {
+		return nil, false, err
+	}
+	if len(data) > backupJSONEnvelopeLimit {
+		return nil, false, nil
+	}
+	var envelope backupJSONEnvelope
+	if json.Unmarshal(data, &envelope) != nil || envelope.Encrypted == "" {
+		return nil, false, nil
+	}
+	sealed, err = base64.StdEncoding.DecodeString(envelope.Encrypted)
+	if err != nil || !IsBackupEnvelope(sealed) {
+		return nil, true, ErrBackupEnvelopeCorrupted
+	}
+	return sealed, true, nil
+}
+
+// EncryptBackupStream 把 src 的明文以加密信封格式写入 dst。
+func EncryptBackupStream(key []byte, dst io.Writer, src io.Reader) error {
+	if len(key) != backupKeySize {
+		return ErrBackupKeyMissing
+	}
+
+	header := make([]byte, backupEnvelopeHeaderSize)
+	copy(header, backupEnvelopeMagic)
+	header[7] = BackupEnvelopeVersion
+	copy(header[8:16], backupKeyCheck(key))
+	if _, err := rand.Read(header[16:32]); err != nil {
+		return fmt.Errorf("generate envelope salt: %w", err)
+	}
+	binary.BigEndian.PutUint32(header[32:36], backupChunkSize)
+
+	aead, err := newBackupObjectAEAD(key, header[16:32])
+	if err != nil {
+		return err
+	}
+	if _, err := dst.Write(header); err != nil {
+		return fmt.Errorf("write envelope header: %w", err)
+	}
+
+	reader := bufio.NewReaderSize(src, backupChunkSize)
+	plain := make([]byte, backupChunkSize)
+	sealed := make([]byte, 0, backupChunkSize+aead.Overhead())
+	for counter := uint64(0); ; counter++ {
+		n, final, err := readBackupChunk(reader, plain)
+		if err != nil {
+			return fmt.Errorf("read plaintext: %w", err)
+		}
+		sealed = aead.Seal(sealed[:0], backupChunkNonce(counter, final), plain[:n], header)
+		if _, err := dst.Write(sealed); err != nil {
+			return fmt.Errorf("write encrypted chunk: %w", err)
+		}
+		if final {
+			return nil
+		}
+	}
+}
+
+// DecryptBackupStream 校验并解密 src 中的加密信封，把明文写入 dst。
+func DecryptBackupStream(key []byte, dst io.Writer, src io.Reader) error {
+	header := make([]byte, backupEnvelopeHeaderSize)
+	if _, err := io.ReadFull(src, header); err != nil {
+		return ErrBackupEnvelopeCorrupted
+	}
+	if !IsBackupEnvelope(header) {
+		return ErrBackupEnvelopeCorrupted
+	}
+	if header[7] > BackupEnvelopeVersion {
+		return ErrBackupEnvelopeUnsupported
+	}
+	if len(key) != backupKeySize {
+		return ErrBackupKeyMissing
+	}
+	if !hmac.Equal(header[8:16], backupKeyCheck(key)) {
+		return ErrBackupKeyMismatch
+	}
+	chunkSize := int(binary.BigEndian.Uint32(header[32:36]))
+	if chunkSize <= 0 || chunkSize > backupMaxChunkSize {
+		return ErrBackupEnvelopeCorrupted
+	}
+
+	aead, err := newBackupObjectAEAD(key, header[16:32])
+	if err != nil {
+		return err
+	}
+
+	reader := bufio.NewReaderSize(src, chunkSize+aead.Overhead())
+	sealed := make([]byte, chunkSize+aead.Overhead())
+	plain := make([]byte, 0, chunkSize)
+	for counter := uint64(0); ; counter++ {
+		n, final, err := readBackupChunk(reader, sealed)
+		if err != nil {
+			return fmt.Errorf("read encrypted chunk: %w", err)
+		}
+		// 截断在分块边界的对象，其最后一块以 final=false 封装，这里会认证失败
+		plain, err = aead.Open(plain[:0], backupChunkNonce(counter, final), sealed[:n], header)
+		if err != nil {
+			return ErrBackupEnvelopeCorrupted
+		}
+		if _, err := dst.Write(plain); err != nil {
+			return fmt.Errorf("write plaintext: %w", err)
+		}
+		if final {
+			return nil
+		}
+	}
+}
+
+// EncryptBackupFile 把 srcPath 加密为 dstPath。
+func EncryptBackupFile(key []byte, srcPath, dstPath string) error {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	return writeBackupFile(dstPath, func(dst io.Writer) error {
+		return EncryptBackupStream(key, dst, src)
+	})
+}
+
+// EncryptBackupJSONFile 把 srcPath 加密为 JSON 包装的信封写入 dstPath，
+// 供只接受 JSON 载荷的存储使用。
+func EncryptBackupJSONFile(key []byte, srcPath, dstPath string) error {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	var sealed bytes.Buffer
+	if err := EncryptBackupStream(key, &sealed, src); err != nil {
+		return err
+	}
+	payload, err := json.Marshal(backupJSONEnvelope{Encrypted: base64.StdEncoding.EncodeToString(sealed.Bytes())})
+	if err != nil {
+		return fmt.Errorf("encode json envelope: %w", err)
+	}
+	return writeBackupFile(dstPath, func(dst io.Writer) error {
+		_, err := dst.Write(payload)
+		return err
+	})
+}
+
+// DecryptBackupFile 把 srcPath 解密到 dstPath，二进制信封与 JSON 包装均可。
+// 未配置密钥时明文原样复制（返回 encrypted=false）；配置了密钥时明文返回
+// ErrBackupEnvelopeMissing，防止云端对象被替换为未加密的数据。
+func DecryptBackupFile(key []byte, srcPath, dstPath string) (encrypted bool, err error) {
+	return decryptBackupFile(key, srcPath, dstPath, len(key) == 0)
+}
+
+// DecryptLegacyBackupFile 与 DecryptBackupFile 相同，但总是接受旧版明文。
+// 只用于把加密前上传的数据迁移为加密信封的显式流程。
+func DecryptLegacyBackupFile(key []byte, srcPath, dstPath string) (encrypted bool, err error) {
+	return decryptBackupFile(key, srcPath, dstPath, true)
+}
+
+func decryptBackupFile(key []byte, srcPath, dstPath string, allowPlaintext bool) (encrypted bool, err error) {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return false, err
+	}
+	defer src.Close()
+
+	prefix := make([]byte, len(backupEnvelopeMagic))
+	n, err := io.ReadFull(src, prefix)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return false, err
+	}
+	if _, err := src.Seek(0, io.SeekStart); err != nil {
+		return false, err
+	}
+	var sealed io.Reader = src
+	encrypted = IsBackupEnvelope(prefix[:n])
+	if !encrypted {
+		wrappedEnvelope, wrapped, err := readBackupJSONEnvelope(src)
+		if err != nil {
+			return wrapped, err
+		}
+		if _, err := src.Seek(0, io.SeekStart); err != nil {
+			return false, err
+		}
+		if wrapped {
+			encrypted = true
+			sealed = bytes.NewReader(wrappedEnvelope)
+		}
+	}
+
+	if !encrypted && !allowPlaintext {
+		return false, ErrBackupEnvelopeMissing
+	}
+
+	err = writeBackupFile(dstPath, func(dst io.Writer) error {
+		if !encrypted {
+			_, copyErr := io.Copy(dst, src)
+			return copyErr
+		}
+		return DecryptBackupStream(key, dst, sealed)
+	})
+	return encrypted, err
+}
+
+func writeBackupFile(dstPath string, write func(io.Writer) error) error {
+	dst, err := os.Create(dstPath)
+	if err != nil {
+		return err
+	}
+	writer := bufio.NewWriter(dst)
+	if err := write(writer); err != nil {
+		dst.Close()
+		_ = os.Remove(dstPath)
+		return err
+	}
+	if err := writer.Flush(); err != nil {
+		dst.Close()
+		_ = os.Remove(dstPath)
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		_ = os.Remove(dstPath)
+		return err
+	}
+	return nil
+}
+
+// readBackupChunk 尽量读满 buf，并通过预读 1 字节判断这是否为最后一块。
+func readBackupChunk(reader *bufio.Reader, buf []byte) (int, bool, error) {
+	n, err := io.ReadFull(reader, buf)
+	switch {
+	case err == nil:
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return n, true, nil
+	default:
+		return n, false, err
+	}
+	if _, err := reader.Peek(1); err != nil {
+		if errors.Is(err, io.EOF) {
+			return n, true, nil
+		}
+		return n, false, err
+	}
+	return n, false, nil
+}
+
+func newBackupObjectAEAD(key, salt []byte) (cipher.AEAD, error) {
+	objectKey, err := hkdf.Key(sha256.New, key, salt, backupObjectKeyInfo, backupKeySize)
+	if err != nil {
+		return nil, fmt.Errorf("derive object key: %w", err)
+	}
+	block, err := aes.NewCipher(objectKey)
+	if err != nil {
+		return nil, fmt.Errorf("create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+func backupChunkNonce(counter uint64, final bool) []byte {
+	nonce := make([]byte, 12)
+	binary.BigEndian.PutUint64(nonce[:8], counter)
+	if final {
+		nonce[11] = 1
+	}
+	return nonce
+}
+
+func backupKeyCheck(key []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(backupKeyCheckInfo))
+	return mac.Sum(nil)[:8]
+}
@@ -0,0 +1,192 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testBackupKey(t *testing.T) []byte {
+	t.Helper()
+	key := make([]byte, backupKeySize)
+	if _, err := rand.Read(key); err != nil {
+		t.Fatalf("生成测试密钥失败: %v", err)
+	}
+	return key
+}
+
+func TestBackupEnvelopeRoundTrip(t *testing.T) {
+	key := testBackupKey(t)
+	for _, size := range []int{0, 1, backupChunkSize - 1, backupChunkSize, backupChunkSize*2 + 17} {
+		plain := make([]byte, size)
+		if _, err := rand.Read(plain); err != nil {
+			t.Fatalf("生成测试数据失败: %v", err)
+		}
+
+		var sealed bytes.Buffer
+		if err := EncryptBackupStream(key, &sealed, bytes.NewReader(plain)); err != nil {
+			t.Fatalf("size=%d EncryptBackupStream() error = %v", size, err)
+		}
+		if !IsBackupEnvelope(sealed.Bytes()) {
+			t.Fatalf("size=%d 加密结果缺少信封头", size)
+		}
+
+		var opened bytes.Buffer
+		if err := DecryptBackupStream(key, &opened, bytes.NewReader(sealed.Bytes())); err != nil {
+			t.Fatalf("size=%d DecryptBackupStream() error = %v", size, err)
+		}
+		if !bytes.Equal(opened.Bytes(), plain) {
+			t.Fatalf("size=%d 解密结果与原文不一致", size)
+		}
+	}
+}
+
+func TestBackupEnvelopeRejectsWrongKeyAndTampering(t *testing.T) {
+	key := testBackupKey(t)
+	plain := bytes.Repeat([]byte("lunabox"), backupChunkSize/3)
+
+	var sealed bytes.Buffer
+	if err := EncryptBackupStream(key, &sealed, bytes.NewReader(plain)); err != nil {
+		t.Fatalf("EncryptBackupStream() error = %v", err)
+	}
+
+	if err := DecryptBackupStream(testBackupKey(t), &bytes.Buffer{}, bytes.NewReader(sealed.Bytes())); !errors.Is(err, ErrBackupKeyMismatch) {
+		t.Fatalf("错误密钥 error = %v, want ErrBackupKeyMismatch", err)
+	}
+	if err := DecryptBackupStream(nil, &bytes.Buffer{}, bytes.NewReader(sealed.Bytes())); !errors.Is(err, ErrBackupKeyMissing) {
+		t.Fatalf("缺少密钥 error = %v, want ErrBackupKeyMissing", err)
+	}
+
+	tampered := append([]byte(nil), sealed.Bytes()...)
+	tampered[len(tampered)-1] ^= 0xff
+	if err := DecryptBackupStream(key, &bytes.Buffer{}, bytes.NewReader(tampered)); !errors.Is(err, ErrBackupEnvelopeCorrupted) {
+		t.Fatalf("篡改数据 error = %v, want ErrBackupEnvelopeCorrupted", err)
+	}
+
+	// 截掉最后一块：剩余部分缺少 final 标记，必须认证失败
+	truncated := sealed.Bytes()[:backupEnvelopeHeaderSize+backupChunkSize+16]
+	if err := DecryptBackupStream(key, &bytes.Buffer{}, bytes.NewReader(truncated)); !errors.Is(err, ErrBackupEnvelopeCorrupted) {
+		t.Fatalf("截断数据 error = %v, want ErrBackupEnvelopeCorrupted", err)
+	}
+}
+
+func TestDecryptBackupFileRejectsPlaintextWhenKeyConfigured(t *testing.T) {
+	dir := t.TempDir()
+	srcPath := filepath.Join(dir, "legacy.json")
+	dstPath := filepath.Join(dir, "out.json")
+	if err := os.WriteFile(srcPath, []byte(`{"schema_version":4}`), 0o600); err != nil {
+		t.Fatalf("写入明文失败: %v", err)
+	}
+
+	if _, err := DecryptBackupFile(testBackupKey(t), srcPath, dstPath); !errors.Is(err, ErrBackupEnvelopeMissing) {
+		t.Fatalf("DecryptBackupFile() error = %v, want ErrBackupEnvelopeMissing", err)
+	}
+	if _, err := os.Stat(dstPath); !os.IsNotExist(err) {
+		t.Fatalf("拒绝明文时不应写出文件: %v", err)
+	}
+
+	// 未设置密钥时没有降级风险，明文照常读取
+	if encrypted, err := DecryptBackupFile(nil, srcPath, dstPath); err != nil || encrypted {
+		t.Fatalf("DecryptBackupFile(nil) = %v, %v", encrypted, err)
+	}
+}
+
+func TestDecryptLegacyBackupFilePassesThroughPlaintext(t *testing.T) {
+	dir := t.TempDir()
+	srcPath := filepath.Join(dir, "legacy.json")
+	dstPath := filepath.Join(dir, "out.json")
+	if err := os.WriteFile(srcPath, []byte(`{"schema_version":4}`), 0o600); err != nil {
+		t.Fatalf("写入明文失败: %v", err)
+	}
+
+	encrypted, err := DecryptLegacyBackupFile(testBackupKey(t), srcPath, dstPath)
+	if err != nil {
+		t.Fatalf("DecryptLegacyBackupFile() error = %v", err)
+	}
+	if encrypted {
+		t.Fatal("旧版明文被误判为加密信封")
+	}
+	got, err := os.ReadFile(dstPath)
+	if err != nil {
+		t.Fatalf("读取输出失败: %v", err)
+	}
+	if string(got) != `{"schema_version":4}` {
+		t.Fatalf("明文透传结果 = %q", got)
+	}
+}
+
+func TestBackupJSONEnvelopeRoundTrip(t *testing.T) {
+	key := testBackupKey(t)
+	dir := t.TempDir()
+	srcPath := filepath.Join(dir, "record.json")
+	sealedPath := filepath.Join(dir, "sealed.json")
+	dstPath := filepath.Join(dir, "out.json")
+	if err := os.WriteFile(srcPath, []byte(`{"name":"月に寄りそう乙女の作法"}`), 0o600); err != nil {
+		t.Fatalf("写入明文失败: %v", err)
+	}
+
+	if err := EncryptBackupJSONFile(key, srcPath, sealedPath); err != nil {
+		t.Fatalf("EncryptBackupJSONFile() error = %v", err)
+	}
+	sealed, err := os.ReadFile(sealedPath)
+	if err != nil {
+		t.Fatalf("读取密文失败: %v", err)
+	}
+	if !json.Valid(sealed) || !IsBackupJSONEnvelope(sealed) {
+		t.Fatalf("JSON 包装无效: %q", sealed)
+	}
+
+	encrypted, err := DecryptBackupFile(key, sealedPath, dstPath)
+	if err != nil || !encrypted {
+		t.Fatalf("DecryptBackupFile() = %v, %v", encrypted, err)
+	}
+	got, err := os.ReadFile(dstPath)
+	if err != nil {
+		t.Fatalf("读取输出失败: %v", err)
+	}
+	if string(got) != `{"name":"月に寄りそう乙女の作法"}` {
+		t.Fatalf("解密结果 = %q", got)
+	}
+}
+
+func TestDeriveBackupCredentials(t *testing.T) {
+	firstID, firstKey, err := DeriveBackupCredentials("correct horse")
+	if err != nil {
+		t.Fatalf("DeriveBackupCredentials() error = %v", err)
+	}
+	secondID, secondKey, err := DeriveBackupCredentials("correct horse")
+	if err != nil {
+		t.Fatalf("DeriveBackupCredentials() error = %v", err)
+	}
+	otherID, otherKey, err := DeriveBackupCredentials("battery staple")
+	if err != nil {
+		t.Fatalf("DeriveBackupCredentials() error = %v", err)
+	}
+	if firstID != secondID || firstKey != secondKey {
+		t.Fatal("同一密码派生出不同的身份")
+	}
+	if firstID == otherID || firstKey == otherKey {
+		t.Fatal("不同密码派生出相同的身份")
+	}
+	if firstID == LegacyBackupUserID("correct horse") {
+		t.Fatal("用户 ID 不应再是密码的快速哈希")
+	}
+	if _, err := DecodeBackupEncryptionKey(firstKey); err != nil {
+		t.Fatalf("DecodeBackupEncryptionKey() error = %v", err)
+	}
+
+	if key, ok, err := ResolveBackupCredentials("correct horse", firstID); err != nil || !ok || key != firstKey {
+		t.Fatalf("ResolveBackupCredentials(v2) = %q, %v, %v", key, ok, err)
+	}
+	if _, ok, err := ResolveBackupCredentials("battery staple", firstID); err != nil || ok {
+		t.Fatalf("ResolveBackupCredentials(wrong password) = %v, %v", ok, err)
+	}
+	legacyKey, ok, err := ResolveBackupCredentials("correct horse", LegacyBackupUserID("correct horse"))
+	if err != nil || !ok || legacyKey == firstKey {
+		t.Fatalf("ResolveBackupCredentials(legacy) = %q, %v, %v", legacyKey, ok, err)
+	}
+}
@@ -150,6 +150,34 @@ func IsRetryableTransportError(err error) bool {
 	return errors.As(err, &netErr) && (netErr.Timeout() || netErr.Temporary())
 }
 
+// StatusError reports a non-success HTTP response. Callers that queue work
+// can use it to tell temporary server failures from rejected requests.
+type StatusError struct {
+	StatusCode int
+	// RetryAfter is the delay requested by the Retry-After header, or zero.
+	RetryAfter time.Duration
+	Message    string
+}
+
+// NewStatusError builds a StatusError for resp; message is returned by Error.
+func NewStatusError(resp *http.Response, message string) *StatusError {
+	err := &StatusError{StatusCode: resp.StatusCode, Message: message}
+	if delay, ok := ParseRetryAfter(resp.Header.Get("Retry-After"), time.Now()); ok {
+		err.RetryAfter = delay
+	}
+	return err
+}
+
+func (e *StatusError) Error() string {
+	return e.Message
+}
+
+// Temporary reports whether the server asked the client to try again later:
+// HTTP 429 or any 5xx response.
+func (e *StatusError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+}
+
 // ParseRetryAfter parses a Retry-After value containing seconds or an HTTP date.
 func ParseRetryAfter(value string, now time.Time) (time.Duration, bool) {
 	value = strings.TrimSpace(value)
@@ -189,6 +189,20 @@ func TestIsRetryableHTTPStatus(t *testing.T) {
 	}
 }
 
+func TestStatusErrorReadsRetryAfter(t *testing.T) {
+	resp := response(nil, http.StatusTooManyRequests, http.NoBody)
+	resp.Header.Set("Retry-After", "120")
+	err := NewStatusError(resp, "rate limited")
+	if err.Error() != "rate limited" || err.RetryAfter != 2*time.Minute || !err.Temporary() {
+		t.Fatalf("unexpected status error: %+v", err)
+	}
+	for status, temporary := range map[int]bool{400: false, 404: false, 429: true, 500: true, 503: true} {
+		if got := NewStatusError(response(nil, status, http.NoBody), "").Temporary(); got != temporary {
+			t.Errorf("StatusError{%d}.Temporary() = %v, want %v", status, got, temporary)
+		}
+	}
+}
+
 func TestIsRetryableTransportError(t *testing.T) {
 	for _, err := range []error{io.EOF, io.ErrUnexpectedEOF, syscall.ECONNRESET, syscall.EPIPE} {
 		if !IsRetryableTransportError(err) {
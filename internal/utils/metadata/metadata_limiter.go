@@ -148,6 +148,21 @@ func (l *metadataRateLimiter) Policy(source MetadataSource) (MetadataRateLimitPo
 	return policy, ok
 }
 
+// setPolicy 注册或替换单个来源的限速策略，供运行时加载的元数据插件使用
+func (l *metadataRateLimiter) setPolicy(policy MetadataRateLimitPolicy) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.policies[policy.Source] = policy
+	delete(l.sources, policy.Source)
+}
+
+func (l *metadataRateLimiter) removePolicy(source MetadataSource) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.policies, source)
+	delete(l.sources, source)
+}
+
 func (l *metadataRateLimiter) sourceState(source MetadataSource) (MetadataRateLimitPolicy, *metadataSourceLimiter, bool) {
 	l.mu.Lock()
 	defer l.mu.Unlock()
@@ -0,0 +1,561 @@
+package metadata
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"lunabox/internal/common/enums"
+	"lunabox/internal/models"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 元数据插件让外部可执行文件或声明式抓取定义充当数据源，接入新站点无需修改代码。
+// 每个插件由插件目录下的一个 JSON 清单描述（<dir>/<name>.json 或 <dir>/<name>/plugin.json），
+// 清单中的 id 注册为 "plugin:<id>" 形式的 SourceType。
+
+const (
+	PluginProtocolVersion = 1
+
+	PluginKindExecutable = "executable"
+	PluginKindScraper    = "scraper"
+
+	PluginActionFetch  = "fetch"
+	PluginActionSearch = "search"
+)
+
+const (
+	pluginManifestFileName = "plugin.json"
+	defaultPluginTimeout   = 30 * time.Second
+	maxPluginTimeout       = 5 * time.Minute
+	defaultPluginInterval  = time.Second
+	pluginStdoutLimit      = 16 << 20
+	pluginStderrLimit      = 4 << 10
+)
+
+// PluginManifest 插件清单。Command 与 Scraper 二选一：
+// Command 为可执行文件，每次请求启动一次，通过 stdin/stdout 交换 PluginRequest / PluginResponse；
+// Scraper 由内置抓取器按声明解析网页或 JSON 接口。
+type PluginManifest struct {
+	ID             string             `json:"id"`
+	Name           string             `json:"name"`
+	Command        string             `json:"command,omitempty"` // 相对路径以清单所在目录为基准
+	Args           []string           `json:"args,omitempty"`
+	TimeoutSeconds int                `json:"timeout_seconds,omitempty"`
+	RateLimit      PluginRateLimit    `json:"rate_limit,omitempty"`
+	Scraper        *ScraperDefinition `json:"scraper,omitempty"`
+}
+
+// PluginRateLimit 插件请求的限速，与内置数据源一样经过 metadata_limiter；未配置时每秒最多一次请求。
+type PluginRateLimit struct {
+	IntervalMillis int `json:"interval_ms,omitempty"`
+	Limit          int `json:"limit,omitempty"`          // 窗口内最多请求数
+	WindowSeconds  int `json:"window_seconds,omitempty"` // 与 Limit 配合使用
+}
+
+// PluginRequest 写入可执行插件 stdin 的请求。
+// Action 为 fetch 时按 ID 返回一条详情；为 search 时按名称返回最多 Limit 个候选，候选只需包含 id 和 name。
+type PluginRequest struct {
+	Protocol int    `json:"protocol"`
+	Action   string `json:"action"`
+	ID       string `json:"id,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Token    string `json:"token,omitempty"`
+	Language string `json:"language,omitempty"`
+	Limit    int    `json:"limit,omitempty"`
+}
+
+// PluginResponse 可执行插件写到 stdout 的响应，Error 非空时视为请求失败。
+type PluginResponse struct {
+	Results []PluginGame `json:"results"`
+	Error   string       `json:"error,omitempty"`
+}
+
+// PluginGame 插件返回的游戏信息，Rating 为 10 分制（100 分制会自动换算）。
+type PluginGame struct {
+	ID          string      `json:"id"`
+	Name        string      `json:"name"`
+	Aliases     []string    `json:"aliases,omitempty"`
+	CoverURL    string      `json:"cover_url,omitempty"`
+	Company     string      `json:"company,omitempty"`
+	Summary     string      `json:"summary,omitempty"`
+	Rating      float64     `json:"rating,omitempty"`
+	ReleaseDate string      `json:"release_date,omitempty"`
+	Tags        []PluginTag `json:"tags,omitempty"`
+}
+
+// PluginTag 插件返回的标签，也可以直接写成字符串。
+type PluginTag struct {
+	Name    string  `json:"name"`
+	Weight  float64 `json:"weight,omitempty"`
+	Spoiler bool    `json:"spoiler,omitempty"`
+}
+
+func (t *PluginTag) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*t = PluginTag{Name: name}
+		return nil
+	}
+	type pluginTag PluginTag
+	var tag pluginTag
+	if err := json.Unmarshal(data, &tag); err != nil {
+		return err
+	}
+	*t = PluginTag(tag)
+	return nil
+}
+
+// PluginInfo 已加载插件的摘要
+type PluginInfo struct {
+	ID             enums.SourceType
+	Name           string
+	Kind           string
+	ManifestPath   string
+	SupportsSearch bool
+}
+
+type metadataPlugin struct {
+	manifest     PluginManifest
+	source       enums.SourceType
+	manifestPath string
+	dir          string
+	command      string
+	timeout      time.Duration
+}
+
+func (p *metadataPlugin) info() PluginInfo {
+	info := PluginInfo{
+		ID:             p.source,
+		Name:           p.manifest.Name,
+		Kind:           PluginKindExecutable,
+		ManifestPath:   p.manifestPath,
+		SupportsSearch: true,
+	}
+	if p.manifest.Scraper != nil {
+		info.Kind = PluginKindScraper
+		info.SupportsSearch = p.manifest.Scraper.SearchURL != ""
+	}
+	return info
+}
+
+func (p *metadataPlugin) rateLimitPolicy() MetadataRateLimitPolicy {
+	policy := MetadataRateLimitPolicy{
+		Source:         p.source,
+		Interval:       time.Duration(p.manifest.RateLimit.IntervalMillis) * time.Millisecond,
+		UpstreamLimit:  p.manifest.RateLimit.Limit,
+		UpstreamWindow: time.Duration(p.manifest.RateLimit.WindowSeconds) * time.Second,
+	}
+	if !policy.hasLimit() {
+		policy.Interval = defaultPluginInterval
+	}
+	return policy
+}
+
+type pluginRegistry struct {
+	mu      sync.RWMutex
+	plugins map[enums.SourceType]*metadataPlugin
+}
+
+var sharedPluginRegistry = &pluginRegistry{plugins: make(map[enums.SourceType]*metadataPlugin)}
+
+// replace 整体替换已注册插件，并同步各插件在共享限速器中的策略
+func (r *pluginRegistry) replace(plugins map[enums.SourceType]*metadataPlugin) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for source := range r.plugins {
+		sharedMetadataRateLimiter.removePolicy(source)
+	}
+	for _, plugin := range plugins {
+		sharedMetadataRateLimiter.setPolicy(plugin.rateLimitPolicy())
+	}
+	r.plugins = plugins
+}
+
+func (r *pluginRegistry) lookup(source enums.SourceType) (*metadataPlugin, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	plugin, ok := r.plugins[source]
+	return plugin, ok
+}
+
+// LoadPlugins 读取插件目录并替换之前加载的全部插件。目录不存在时视为没有插件。
+// 无效的清单会被跳过，错误汇总后返回，其余插件照常注册。
+func LoadPlugins(dir string) ([]PluginInfo, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		sharedPluginRegistry.replace(make(map[enums.SourceType]*metadataPlugin))
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read metadata plugin directory: %w", err)
+	}
+
+	plugins := make(map[enums.SourceType]*metadataPlugin)
+	var errs []error
+	for _, entry := range entries {
+		manifestPath := filepath.Join(dir, entry.Name())
+		if entry.IsDir() {
+			manifestPath = filepath.Join(manifestPath, pluginManifestFileName)
+			if _, err := os.Stat(manifestPath); err != nil {
+				continue
+			}
+		} else if !strings.EqualFold(filepath.Ext(entry.Name()), ".json") {
+			continue
+		}
+
+		plugin, err := loadPluginManifest(manifestPath)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if existing, ok := plugins[plugin.source]; ok {
+			errs = append(errs, fmt.Errorf("metadata plugin %s: id %q is already used by %s", manifestPath, plugin.source, existing.manifestPath))
+			continue
+		}
+		plugins[plugin.source] = plugin
+	}
+
+	sharedPluginRegistry.replace(plugins)
+	return Plugins(), errors.Join(errs...)
+}
+
+func loadPluginManifest(manifestPath string) (*metadataPlugin, error) {
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return nil, fmt.Errorf("metadata plugin %s: %w", manifestPath, err)
+	}
+	var manifest PluginManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("metadata plugin %s: parse manifest: %w", manifestPath, err)
+	}
+	plugin, err := newMetadataPlugin(manifest, manifestPath)
+	if err != nil {
+		return nil, fmt.Errorf("metadata plugin %s: %w", manifestPath, err)
+	}
+	return plugin, nil
+}
+
+func newMetadataPlugin(manifest PluginManifest, manifestPath string) (*metadataPlugin, error) {
+	manifest.ID = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(manifest.ID)), enums.PluginSourcePrefix)
+	source := enums.PluginSourceType(manifest.ID)
+	if !enums.IsPluginSourceType(source) {
+		return nil, fmt.Errorf("invalid id %q: use 2-32 lowercase letters, digits, '-' or '_' starting with a letter", manifest.ID)
+	}
+	manifest.Name = strings.TrimSpace(manifest.Name)
+	if manifest.Name == "" {
+		manifest.Name = manifest.ID
+	}
+	manifest.Command = strings.TrimSpace(manifest.Command)
+	if (manifest.Command == "") == (manifest.Scraper == nil) {
+		return nil, errors.New("exactly one of command and scraper must be set")
+	}
+
+	plugin := &metadataPlugin{
+		manifest:     manifest,
+		source:       source,
+		manifestPath: manifestPath,
+		dir:          filepath.Dir(manifestPath),
+		timeout:      defaultPluginTimeout,
+	}
+	if manifest.TimeoutSeconds > 0 {
+		plugin.timeout = time.Duration(manifest.TimeoutSeconds) * time.Second
+		if plugin.timeout > maxPluginTimeout {
+			plugin.timeout = maxPluginTimeout
+		}
+	}
+	if manifest.Scraper != nil {
+		if err := manifest.Scraper.compile(); err != nil {
+			return nil, err
+		}
+		return plugin, nil
+	}
+
+	// 带路径分隔符的相对命令以清单目录为基准，裸命令名交给 PATH 查找
+	plugin.command = manifest.Command
+	if !filepath.IsAbs(plugin.command) && strings.ContainsAny(plugin.command, `/\`) {
+		plugin.command = filepath.Join(plugin.dir, filepath.FromSlash(plugin.command))
+	}
+	return plugin, nil
+}
+
+// Plugins 返回已加载的插件，按 ID 排序
+func Plugins() []PluginInfo {
+	sharedPluginRegistry.mu.RLock()
+	defer sharedPluginRegistry.mu.RUnlock()
+	infos := make([]PluginInfo, 0, len(sharedPluginRegistry.plugins))
+	for _, plugin := range sharedPluginRegistry.plugins {
+		infos = append(infos, plugin.info())
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].ID < infos[j].ID })
+	return infos
+}
+
+// IsPluginSource 判断来源是否由已加载的插件提供
+func IsPluginSource(source enums.SourceType) bool {
+	_, ok := sharedPluginRegistry.lookup(source)
+	return ok
+}
+
+// PluginGetter 以插件实现 Getter / CandidateGetter
+type PluginGetter struct {
+	plugin   *metadataPlugin
+	client   *http.Client
+	tagLimit int
+	language string
+}
+
+var _ Getter = (*PluginGetter)(nil)
+var _ CandidateGetter = (*PluginGetter)(nil)
+
+func NewPluginGetter(source enums.SourceType, language string, options ...GetterOption) (*PluginGetter, error) {
+	plugin, ok := sharedPluginRegistry.lookup(source)
+	if !ok {
+		return nil, fmt.Errorf("metadata plugin not found: %s", source)
+	}
+	config := newGetterConfig(options)
+	return &PluginGetter{
+		plugin:   plugin,
+		client:   config.client,
+		tagLimit: config.tagLimit,
+		language: strings.TrimSpace(language),
+	}, nil
+}
+
+// SupportsNameSearch 声明式插件未配置 search_url 时只能按 ID 拉取
+func (p *PluginGetter) SupportsNameSearch() bool {
+	return p.plugin.info().SupportsSearch
+}
+
+func (p *PluginGetter) FetchMetadata(id string, token string) (MetadataResult, error) {
+	id = strings.TrimSpace(id)
+	if id == "" {
+		return MetadataResult{}, fmt.Errorf("%s metadata id is empty", p.plugin.source)
+	}
+
+	var games []PluginGame
+	var err error
+	if scraper := p.plugin.manifest.Scraper; scraper != nil {
+		games, err = p.scrapeDetail(scraper, id)
+	} else {
+		games, err = p.run(PluginRequest{Action: PluginActionFetch, ID: id, Token: token})
+	}
+	if err != nil {
+		return MetadataResult{}, err
+	}
+	if len(games) == 0 || cleanMetadataText(games[0].Name) == "" {
+		return MetadataResult{}, fmt.Errorf("%s plugin returned no game data for id %s", p.plugin.source, id)
+	}
+	return p.convertPluginGame(games[0], id), nil
+}
+
+func (p *PluginGetter) FetchMetadataByName(name string, token string) (MetadataResult, error) {
+	results, err := p.FetchMetadataCandidatesByName(name, token)
+	if err != nil {
+		return MetadataResult{}, err
+	}
+	return results[0], nil
+}
+
+// FetchMetadataCandidatesByName 搜索只需返回 ID 和名称，同名候选再逐个按 ID 拉取详情
+func (p *PluginGetter) FetchMetadataCandidatesByName(name string, token string) ([]MetadataResult, error) {
+	keyword := strings.TrimSpace(name)
+	if keyword == "" {
+		return nil, fmt.Errorf("%s search keyword is empty", p.plugin.source)
+	}
+
+	var items []PluginGame
+	var err error
+	if scraper := p.plugin.manifest.Scraper; scraper != nil {
+		items, err = p.scrapeSearch(scraper, keyword)
+	} else {
+		items, err = p.run(PluginRequest{Action: PluginActionSearch, Name: keyword, Token: token, Limit: metadataSearchCandidateLimit})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]PluginGame, 0, len(items))
+	for _, item := range items {
+		if strings.TrimSpace(item.ID) != "" {
+			candidates = append(candidates, item)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, errors.New("no results found")
+	}
+	if len(candidates) > metadataSearchCandidateLimit {
+		candidates = candidates[:metadataSearchCandidateLimit]
+	}
+
+	candidateNames := make([][]string, len(candidates))
+	for index, candidate := range candidates {
+		candidateNames[index] = append([]string{candidate.Name}, candidate.Aliases...)
+	}
+	indexes := exactMetadataCandidateIndexes(keyword, candidateNames)
+	if len(indexes) == 0 {
+		indexes = []int{0}
+	}
+
+	results := make([]MetadataResult, 0, len(indexes))
+	seenIDs := make(map[string]struct{}, len(indexes))
+	var lastErr error
+	for _, index := range indexes {
+		id := strings.TrimSpace(candidates[index].ID)
+		if _, exists := seenIDs[id]; exists {
+			continue
+		}
+		result, fetchErr := p.FetchMetadata(id, token)
+		if fetchErr != nil {
+			lastErr = fetchErr
+			continue
+		}
+		seenIDs[id] = struct{}{}
+		results = append(results, result)
+	}
+	if len(results) > 0 {
+		return results, nil
+	}
+	if lastErr != nil {
+		return nil, lastErr
+	}
+	return nil, errors.New("no results found")
+}
+
+// run 启动可执行插件处理一次请求，进程在超时后被终止
+func (p *PluginGetter) run(req PluginRequest) ([]PluginGame, error) {
+	source := p.plugin.source
+	req.Protocol = PluginProtocolVersion
+	req.Language = p.language
+	payload, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.plugin.timeout)
+	defer cancel()
+	if err := sharedMetadataRateLimiter.Acquire(ctx, source); err != nil {
+		return nil, fmt.Errorf("%s metadata rate limit wait failed: %w", source, err)
+	}
+
+	stdout := &limitedBuffer{limit: pluginStdoutLimit}
+	stderr := &limitedBuffer{limit: pluginStderrLimit}
+	cmd := exec.CommandContext(ctx, p.plugin.command, p.plugin.manifest.Args...)
+	cmd.Dir = p.plugin.dir
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	hidePluginProcessWindow(cmd)
+
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("%s plugin timed out after %s", source, p.plugin.timeout)
+		}
+		return nil, fmt.Errorf("%s plugin failed: %w, stderr: %s", source, err, strings.TrimSpace(stderr.String()))
+	}
+	if stdout.truncated {
+		return nil, fmt.Errorf("%s plugin output exceeds %d bytes", source, pluginStdoutLimit)
+	}
+
+	var resp PluginResponse
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return nil, fmt.Errorf("parse %s plugin response: %w", source, err)
+	}
+	if message := strings.TrimSpace(resp.Error); message != "" {
+		return nil, fmt.Errorf("%s plugin error: %s", source, message)
+	}
+	return resp.Results, nil
+}
+
+func (p *PluginGetter) convertPluginGame(data PluginGame, fallbackID string) MetadataResult {
+	name := cleanMetadataText(data.Name)
+	sourceID := strings.TrimSpace(data.ID)
+	if sourceID == "" {
+		sourceID = fallbackID
+	}
+	coverURL := strings.TrimSpace(data.CoverURL)
+
+	game := models.Game{
+		Name:           name,
+		Aliases:        normalizeMetadataAliases(name, data.Aliases),
+		CoverURL:       coverURL,
+		CoverSourceURL: coverURL,
+		Company:        cleanMetadataText(data.Company),
+		Summary:        strings.TrimSpace(data.Summary),
+		Rating:         normalizeTenPointRating(data.Rating),
+		ReleaseDate:    normalizePluginDate(data.ReleaseDate),
+		SourceType:     p.plugin.source,
+		SourceID:       sourceID,
+		CachedAt:       time.Now(),
+	}
+	return MetadataResult{Game: game, Tags: p.extractPluginTags(data.Tags)}
+}
+
+func (p *PluginGetter) extractPluginTags(tags []PluginTag) []TagItem {
+	if p.tagLimit == 0 {
+		return nil
+	}
+	result := make([]TagItem, 0, tagItemsCapacity(len(tags), p.tagLimit))
+	seen := make(map[string]struct{}, len(tags))
+	for _, tag := range tags {
+		name := cleanMetadataText(tag.Name)
+		if name == "" {
+			continue
+		}
+		key := strings.ToLower(name)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		weight := tag.Weight
+		if weight <= 0 || weight > 1 {
+			weight = 1
+		}
+		result = append(result, TagItem{
+			Name:      name,
+			Source:    string(p.plugin.source),
+			Weight:    weight,
+			IsSpoiler: tag.Spoiler,
+		})
+		if hasReachedTagLimit(len(result), p.tagLimit) {
+			break
+		}
+	}
+	return result
+}
+
+// normalizePluginDate 去掉 ISO 时间中的时刻部分，再按常见的日文/斜杠日期格式归一化
+func normalizePluginDate(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if date, _, found := strings.Cut(raw, "T"); found && len(date) == len("2006-01-02") {
+		raw = date
+	}
+	return normalizeJapaneseDate(raw)
+}
+
+// limitedBuffer 最多保留 limit 字节，超出部分丢弃但不让子进程因写入失败而退出
+type limitedBuffer struct {
+	bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(data []byte) (int, error) {
+	if remaining := b.limit - b.Len(); remaining < len(data) {
+		b.truncated = true
+		if remaining > 0 {
+			b.Buffer.Write(data[:remaining])
+		}
+		return len(data), nil
+	}
+	return b.Buffer.Write(data)
+}
@@ -0,0 +1,7 @@
+//go:build !windows
+
+package metadata
+
+import "os/exec"
+
+func hidePluginProcessWindow(_ *exec.Cmd) {}
@@ -0,0 +1,18 @@
+//go:build windows
+
+package metadata
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+const createNoWindowFlag uint32 = 0x08000000
+
+// hidePluginProcessWindow 避免控制台插件在 GUI 中弹出命令行窗口
+func hidePluginProcessWindow(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		HideWindow:    true,
+		CreationFlags: createNoWindowFlag,
+	}
+}
@@ -0,0 +1,402 @@
+package metadata
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"lunabox/internal/version"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"golang.org/x/net/html/charset"
+)
+
+const (
+	scraperFormatHTML = "html"
+	scraperFormatJSON = "json"
+)
+
+var scraperNumberPattern = regexp.MustCompile(`\d+(?:\.\d+)?`)
+
+// ScraperDefinition 声明式抓取定义。URL 模板中的 {id}、{name} 会被转义后替换。
+// Format 为 html（默认）时字段使用 CSS 选择器，网页编码按响应头和 meta 自动识别；
+// 为 json 时字段使用以点分隔的路径，数组下标直接写数字，例如 data.items.0.title。
+type ScraperDefinition struct {
+	Format    string            `json:"format,omitempty"`
+	DetailURL string            `json:"detail_url"`
+	SearchURL string            `json:"search_url,omitempty"`
+	Headers   map[string]string `json:"headers,omitempty"`
+	Search    ScraperSearch     `json:"search,omitempty"`
+	Fields    ScraperFields     `json:"fields"`
+}
+
+// ScraperSearch 搜索结果页的提取规则。Items 匹配每个候选，ID 和 Name 相对于候选提取，
+// 选择器为空时直接读取候选本身。
+type ScraperSearch struct {
+	Items string       `json:"items"`
+	ID    ScraperField `json:"id"`
+	Name  ScraperField `json:"name"`
+}
+
+// ScraperFields 详情页的提取规则，Aliases 和 Tags 会读取所有匹配项
+type ScraperFields struct {
+	Name        ScraperField `json:"name"`
+	Aliases     ScraperField `json:"aliases,omitempty"`
+	CoverURL    ScraperField `json:"cover_url,omitempty"`
+	Company     ScraperField `json:"company,omitempty"`
+	Summary     ScraperField `json:"summary,omitempty"`
+	Rating      ScraperField `json:"rating,omitempty"`
+	RatingScale float64      `json:"rating_scale,omitempty"` // 评分满分，默认按 10 分制
+	ReleaseDate ScraperField `json:"release_date,omitempty"`
+	Tags        ScraperField `json:"tags,omitempty"`
+}
+
+// ScraperField 单个字段的提取规则，也可以直接写成选择器字符串。
+// Attr 读取属性而非文本；Pattern 为正则表达式，有捕获组时取第一个捕获组，否则取整个匹配。
+type ScraperField struct {
+	Selector string `json:"selector,omitempty"`
+	Attr     string `json:"attr,omitempty"`
+	Pattern  string `json:"pattern,omitempty"`
+
+	pattern *regexp.Regexp
+}
+
+func (f *ScraperField) UnmarshalJSON(data []byte) error {
+	var selector string
+	if err := json.Unmarshal(data, &selector); err == nil {
+		*f = ScraperField{Selector: selector}
+		return nil
+	}
+	type scraperField ScraperField
+	var field scraperField
+	if err := json.Unmarshal(data, &field); err != nil {
+		return err
+	}
+	*f = ScraperField(field)
+	return nil
+}
+
+func (f *ScraperField) compile(name string) error {
+	f.Selector = strings.TrimSpace(f.Selector)
+	f.Attr = strings.TrimSpace(f.Attr)
+	if f.Pattern == "" {
+		return nil
+	}
+	pattern, err := regexp.Compile(f.Pattern)
+	if err != nil {
+		return fmt.Errorf("scraper field %s: invalid pattern: %w", name, err)
+	}
+	f.pattern = pattern
+	return nil
+}
+
+func (f ScraperField) defined() bool {
+	return f.Selector != "" || f.Attr != "" || f.pattern != nil
+}
+
+// apply 对提取出的原始文本应用正则，未匹配时返回空字符串
+func (f ScraperField) apply(raw string) string {
+	value := strings.TrimSpace(raw)
+	if f.pattern == nil || value == "" {
+		return value
+	}
+	match := f.pattern.FindStringSubmatch(value)
+	if match == nil {
+		return ""
+	}
+	if len(match) > 1 {
+		return strings.TrimSpace(match[1])
+	}
+	return strings.TrimSpace(match[0])
+}
+
+func (d *ScraperDefinition) compile() error {
+	d.Format = strings.ToLower(strings.TrimSpace(d.Format))
+	if d.Format == "" {
+		d.Format = scraperFormatHTML
+	}
+	if d.Format != scraperFormatHTML && d.Format != scraperFormatJSON {
+		return fmt.Errorf("unsupported scraper format %q", d.Format)
+	}
+	d.DetailURL = strings.TrimSpace(d.DetailURL)
+	if err := validateScraperURL(d.DetailURL, "{id}"); err != nil {
+		return fmt.Errorf("scraper detail_url: %w", err)
+	}
+	d.SearchURL = strings.TrimSpace(d.SearchURL)
+	if d.SearchURL != "" {
+		if err := validateScraperURL(d.SearchURL, "{name}"); err != nil {
+			return fmt.Errorf("scraper search_url: %w", err)
+		}
+		if strings.TrimSpace(d.Search.Items) == "" {
+			return errors.New("scraper search.items is required when search_url is set")
+		}
+	}
+
+	fields := map[string]*ScraperField{
+		"search.id":           &d.Search.ID,
+		"search.name":         &d.Search.Name,
+		"fields.name":         &d.Fields.Name,
+		"fields.aliases":      &d.Fields.Aliases,
+		"fields.cover_url":    &d.Fields.CoverURL,
+		"fields.company":      &d.Fields.Company,
+		"fields.summary":      &d.Fields.Summary,
+		"fields.rating":       &d.Fields.Rating,
+		"fields.release_date": &d.Fields.ReleaseDate,
+		"fields.tags":         &d.Fields.Tags,
+	}
+	for name, field := range fields {
+		if err := field.compile(name); err != nil {
+			return err
+		}
+	}
+	if d.Fields.Name.Selector == "" {
+		return errors.New("scraper fields.name selector is required")
+	}
+	return nil
+}
+
+func validateScraperURL(rawURL string, placeholder string) error {
+	if !strings.Contains(rawURL, placeholder) {
+		return fmt.Errorf("must contain %s", placeholder)
+	}
+	parsed, err := url.Parse(strings.ReplaceAll(rawURL, placeholder, "x"))
+	if err != nil {
+		return err
+	}
+	if (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return errors.New("must be an absolute http(s) URL")
+	}
+	return nil
+}
+
+func (p *PluginGetter) scrapeDetail(scraper *ScraperDefinition, id string) ([]PluginGame, error) {
+	pageURL := strings.ReplaceAll(scraper.DetailURL, "{id}", url.PathEscape(id))
+	page, err := p.fetchScraperPage(scraper, pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	fields := scraper.Fields
+	game := PluginGame{
+		ID:          id,
+		Name:        page.first(nil, fields.Name),
+		Aliases:     page.all(nil, fields.Aliases),
+		CoverURL:    page.resolveURL(page.first(nil, fields.CoverURL)),
+		Company:     page.first(nil, fields.Company),
+		Summary:     page.first(nil, fields.Summary),
+		ReleaseDate: page.first(nil, fields.ReleaseDate),
+	}
+	if rawRating := scraperNumberPattern.FindString(page.first(nil, fields.Rating)); rawRating != "" {
+		rating, _ := strconv.ParseFloat(rawRating, 64)
+		if fields.RatingScale > 0 {
+			rating = rating / fields.RatingScale * 10
+		}
+		game.Rating = rating
+	}
+	for _, tag := range page.all(nil, fields.Tags) {
+		game.Tags = append(game.Tags, PluginTag{Name: tag})
+	}
+	return []PluginGame{game}, nil
+}
+
+func (p *PluginGetter) scrapeSearch(scraper *ScraperDefinition, keyword string) ([]PluginGame, error) {
+	if scraper.SearchURL == "" {
+		return nil, fmt.Errorf("%s plugin does not support name search", p.plugin.source)
+	}
+	pageURL := strings.ReplaceAll(scraper.SearchURL, "{name}", url.QueryEscape(keyword))
+	page, err := p.fetchScraperPage(scraper, pageURL)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]PluginGame, 0, metadataSearchCandidateLimit)
+	for _, item := range page.items(scraper.Search.Items) {
+		id := page.first(item, scraper.Search.ID)
+		if id == "" {
+			continue
+		}
+		items = append(items, PluginGame{ID: id, Name: page.first(item, scraper.Search.Name)})
+		if len(items) >= metadataSearchCandidateLimit {
+			break
+		}
+	}
+	return items, nil
+}
+
+func (p *PluginGetter) fetchScraperPage(scraper *ScraperDefinition, pageURL string) (*scraperPage, error) {
+	source := p.plugin.source
+	req, err := http.NewRequest(http.MethodGet, pageURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", version.UserAgent())
+	for key, value := range scraper.Headers {
+		req.Header.Set(key, value)
+	}
+
+	statusCode, header, body, err := doLimitedMetadataRequestBody(p.client, req, source)
+	if err != nil {
+		return nil, err
+	}
+	if statusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s plugin request returned status: %d", source, statusCode)
+	}
+
+	page := &scraperPage{baseURL: req.URL}
+	if scraper.Format == scraperFormatJSON {
+		if err := json.Unmarshal(body, &page.json); err != nil {
+			return nil, fmt.Errorf("parse %s plugin JSON response: %w", source, err)
+		}
+		return page, nil
+	}
+
+	reader, err := charset.NewReader(bytes.NewReader(body), header.Get("Content-Type"))
+	if err != nil {
+		return nil, fmt.Errorf("decode %s plugin page: %w", source, err)
+	}
+	page.doc, err = goquery.NewDocumentFromReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s plugin page: %w", source, err)
+	}
+	return page, nil
+}
+
+// scraperPage 统一 HTML 文档和 JSON 响应的字段读取，scope 为 nil 时从根节点开始
+type scraperPage struct {
+	baseURL *url.URL
+	doc     *goquery.Document
+	json    any
+}
+
+func (p *scraperPage) items(selector string) []any {
+	if p.doc != nil {
+		var items []any
+		p.doc.Find(selector).Each(func(_ int, selection *goquery.Selection) {
+			items = append(items, selection)
+		})
+		return items
+	}
+	value, _ := lookupScraperJSONPath(p.json, selector)
+	items, _ := value.([]any)
+	return items
+}
+
+func (p *scraperPage) first(scope any, field ScraperField) string {
+	values := p.values(scope, field, true)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func (p *scraperPage) all(scope any, field ScraperField) []string {
+	return p.values(scope, field, false)
+}
+
+func (p *scraperPage) values(scope any, field ScraperField, firstOnly bool) []string {
+	if scope == nil && !field.defined() {
+		return nil
+	}
+	var raw []string
+	if p.doc != nil {
+		selection := p.doc.Selection
+		if node, ok := scope.(*goquery.Selection); ok {
+			selection = node
+		}
+		if field.Selector != "" {
+			selection = selection.Find(field.Selector)
+		}
+		if firstOnly {
+			selection = selection.First()
+		}
+		selection.Each(func(_ int, node *goquery.Selection) {
+			if field.Attr != "" {
+				raw = append(raw, node.AttrOr(field.Attr, ""))
+			} else {
+				raw = append(raw, node.Text())
+			}
+		})
+	} else {
+		root := p.json
+		if scope != nil {
+			root = scope
+		}
+		value, ok := lookupScraperJSONPath(root, field.Selector)
+		if !ok {
+			return nil
+		}
+		if list, isList := value.([]any); isList {
+			for _, item := range list {
+				raw = append(raw, scraperJSONString(item))
+			}
+		} else {
+			raw = append(raw, scraperJSONString(value))
+		}
+	}
+
+	values := make([]string, 0, len(raw))
+	for _, item := range raw {
+		if value := field.apply(item); value != "" {
+			values = append(values, value)
+		}
+		if firstOnly && len(values) > 0 {
+			break
+		}
+	}
+	return values
+}
+
+func (p *scraperPage) resolveURL(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	ref, err := url.Parse(raw)
+	if err != nil {
+		return raw
+	}
+	return p.baseURL.ResolveReference(ref).String()
+}
+
+func lookupScraperJSONPath(root any, path string) (any, bool) {
+	current := root
+	if strings.TrimSpace(path) == "" {
+		return current, current != nil
+	}
+	for _, part := range strings.Split(path, ".") {
+		switch node := current.(type) {
+		case map[string]any:
+			value, ok := node[part]
+			if !ok {
+				return nil, false
+			}
+			current = value
+		case []any:
+			index, err := strconv.Atoi(part)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, false
+			}
+			current = node[index]
+		default:
+			return nil, false
+		}
+	}
+	return current, current != nil
+}
+
+func scraperJSONString(value any) string {
+	switch typed := value.(type) {
+	case string:
+		return typed
+	case float64:
+		return strconv.FormatFloat(typed, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(typed)
+	default:
+		return ""
+	}
+}
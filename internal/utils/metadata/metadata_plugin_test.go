@@ -0,0 +1,285 @@
+package metadata
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"lunabox/internal/common/enums"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const metadataPluginHelperEnv = "LUNABOX_METADATA_PLUGIN_HELPER"
+
+// TestMetadataPluginHelperProcess 作为可执行插件被测试启动，不会在普通测试中执行任何断言
+func TestMetadataPluginHelperProcess(t *testing.T) {
+	if os.Getenv(metadataPluginHelperEnv) != "1" {
+		return
+	}
+	var req PluginRequest
+	if err := json.NewDecoder(os.Stdin).Decode(&req); err != nil {
+		fmt.Fprintf(os.Stderr, "decode request: %v", err)
+		os.Exit(2)
+	}
+
+	var resp PluginResponse
+	switch {
+	case req.Protocol != PluginProtocolVersion:
+		resp.Error = "unsupported protocol"
+	case req.Action == PluginActionSearch:
+		resp.Results = []PluginGame{
+			{ID: "1001", Name: "Other Game"},
+			{ID: "1002", Name: req.Name},
+		}
+	case req.Action == PluginActionFetch && req.ID == "1002":
+		resp.Results = []PluginGame{{
+			ID:          "1002",
+			Name:        "Moonlit Garden",
+			Aliases:     []string{"月下の庭", "Moonlit Garden"},
+			CoverURL:    "https://example.com/cover.jpg",
+			Company:     "Garden Works",
+			Rating:      84,
+			ReleaseDate: "2024年3月8日",
+			Tags:        []PluginTag{{Name: "Mystery", Weight: 0.8}, {Name: "Drama"}},
+			Summary:     "lang=" + req.Language,
+		}}
+	default:
+		resp.Error = "not found: " + req.ID
+	}
+	_ = json.NewEncoder(os.Stdout).Encode(resp)
+	os.Exit(0)
+}
+
+func TestLoadPluginsSkipsInvalidManifests(t *testing.T) {
+	dir := t.TempDir()
+	writeMetadataPluginManifest(t, filepath.Join(dir, "getchu.json"), PluginManifest{
+		ID:      "Getchu",
+		Name:    "Getchu.com",
+		Scraper: &ScraperDefinition{DetailURL: "https://example.com/soft?id={id}", Fields: ScraperFields{Name: ScraperField{Selector: "h1"}}},
+	})
+	writeMetadataPluginManifest(t, filepath.Join(dir, "fanza", pluginManifestFileName), PluginManifest{
+		ID:      "fanza",
+		Command: "./fanza-plugin",
+	})
+	writeMetadataPluginManifest(t, filepath.Join(dir, "bad.json"), PluginManifest{ID: "Bad ID", Command: "bad-plugin"})
+	writeMetadataPluginManifest(t, filepath.Join(dir, "both.json"), PluginManifest{
+		ID:      "both",
+		Command: "both-plugin",
+		Scraper: &ScraperDefinition{DetailURL: "https://example.com/{id}", Fields: ScraperFields{Name: ScraperField{Selector: "h1"}}},
+	})
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("ignored"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _, _ = LoadPlugins(filepath.Join(dir, "missing")) })
+
+	plugins, err := LoadPlugins(dir)
+	if err == nil || !strings.Contains(err.Error(), "invalid id") || !strings.Contains(err.Error(), "exactly one of command and scraper") {
+		t.Fatalf("expected invalid manifests to be reported, got %v", err)
+	}
+	if len(plugins) != 2 || plugins[0].ID != "plugin:fanza" || plugins[1].ID != "plugin:getchu" {
+		t.Fatalf("unexpected plugins: %+v", plugins)
+	}
+	if plugins[0].Kind != PluginKindExecutable || plugins[1].Kind != PluginKindScraper || plugins[1].SupportsSearch {
+		t.Fatalf("unexpected plugin kinds: %+v", plugins)
+	}
+	if !IsPluginSource("plugin:getchu") || IsPluginSource("getchu") || IsPluginSource(enums.Steam) {
+		t.Fatal("plugin sources were not registered as expected")
+	}
+	if policy, ok := sharedMetadataRateLimiter.Policy("plugin:getchu"); !ok || policy.Interval != defaultPluginInterval {
+		t.Fatalf("expected default plugin rate limit, got %+v ok=%v", policy, ok)
+	}
+
+	if _, err := LoadPlugins(filepath.Join(dir, "missing")); err != nil {
+		t.Fatalf("missing plugin directory should not be an error: %v", err)
+	}
+	if IsPluginSource("plugin:getchu") {
+		t.Fatal("reloading should unregister previous plugins")
+	}
+	if _, ok := sharedMetadataRateLimiter.Policy("plugin:getchu"); ok {
+		t.Fatal("reloading should remove previous plugin rate limits")
+	}
+}
+
+func TestExecutablePluginGetterUsesJSONProtocol(t *testing.T) {
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(metadataPluginHelperEnv, "1")
+	dir := t.TempDir()
+	writeMetadataPluginManifest(t, filepath.Join(dir, "garden.json"), PluginManifest{
+		ID:        "garden",
+		Name:      "Garden DB",
+		Command:   executable,
+		Args:      []string{"-test.run=^TestMetadataPluginHelperProcess$"},
+		RateLimit: PluginRateLimit{IntervalMillis: 1},
+	})
+	if _, err := LoadPlugins(dir); err != nil {
+		t.Fatalf("LoadPlugins returned an error: %v", err)
+	}
+	t.Cleanup(func() { _, _ = LoadPlugins(filepath.Join(dir, "missing")) })
+
+	getter, err := NewPluginGetter("plugin:garden", "ja-JP", WithTagLimit(1))
+	if err != nil {
+		t.Fatalf("NewPluginGetter returned an error: %v", err)
+	}
+	results, err := getter.FetchMetadataCandidatesByName("Moonlit Garden", "")
+	if err != nil {
+		t.Fatalf("FetchMetadataCandidatesByName returned an error: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("expected the exact-name candidate only, got %+v", results)
+	}
+	game := results[0].Game
+	if game.SourceType != "plugin:garden" || game.SourceID != "1002" || game.Name != "Moonlit Garden" || game.Company != "Garden Works" {
+		t.Fatalf("unexpected plugin game: %+v", game)
+	}
+	if game.Rating != 8.4 || game.ReleaseDate != "2024-03-08" || game.Summary != "lang=ja-JP" {
+		t.Fatalf("plugin fields were not normalized: %+v", game)
+	}
+	if len(game.Aliases) != 1 || game.Aliases[0] != "月下の庭" {
+		t.Fatalf("unexpected aliases: %v", game.Aliases)
+	}
+	if tags := results[0].Tags; len(tags) != 1 || tags[0].Name != "Mystery" || tags[0].Weight != 0.8 || tags[0].Source != "plugin:garden" {
+		t.Fatalf("unexpected tags: %+v", tags)
+	}
+
+	if _, err := getter.FetchMetadata("404", ""); err == nil || !strings.Contains(err.Error(), "garden plugin error: not found: 404") {
+		t.Fatalf("expected plugin error to be surfaced, got %v", err)
+	}
+}
+
+func TestScraperPluginGetterParsesHTMLAndJSON(t *testing.T) {
+	var requested []string
+	client := &http.Client{Transport: metadataRoundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		requested = append(requested, req.URL.String())
+		body := ""
+		contentType := "text/html; charset=utf-8"
+		switch {
+		case req.URL.Path == "/search":
+			body = `<ul class="results">
+				<li><a href="/soft?id=11">Starlight Café</a></li>
+				<li><a href="/soft?id=12">Starlight Café FD</a></li>
+			</ul>`
+		case req.URL.Path == "/soft":
+			body = `<html><body>
+				<h1> Starlight  Café </h1>
+				<img id="cover" src="/img/11.jpg">
+				<table><tr><th>ブランド</th><td class="brand">Night Owl</td></tr>
+				<tr><th>発売日</th><td class="date">2023/07/28</td></tr></table>
+				<div class="score">評価 4.5 / 5</div>
+				<ul class="genres"><li>恋愛</li><li>日常</li></ul>
+			</body></html>`
+		case req.URL.Path == "/api/games/7":
+			contentType = "application/json"
+			body = `{"data":{"title":"Paper Sky","developer":{"name":"Folded"},"aliases":["紙の空"],"score":72,"released":"2022-05-01T00:00:00Z"}}`
+		default:
+			return &http.Response{StatusCode: http.StatusNotFound, Body: io.NopCloser(strings.NewReader("")), Header: make(http.Header), Request: req}, nil
+		}
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(bytes.NewBufferString(body)),
+			Header:     http.Header{"Content-Type": []string{contentType}},
+			Request:    req,
+		}, nil
+	})}
+
+	dir := t.TempDir()
+	writeMetadataPluginManifest(t, filepath.Join(dir, "cafe.json"), PluginManifest{
+		ID:        "cafe",
+		RateLimit: PluginRateLimit{IntervalMillis: 1},
+		Scraper: &ScraperDefinition{
+			DetailURL: "https://cafe.example.com/soft?id={id}",
+			SearchURL: "https://cafe.example.com/search?q={name}",
+			Search: ScraperSearch{
+				Items: "ul.results a",
+				ID:    ScraperField{Attr: "href", Pattern: `id=(\d+)`},
+			},
+			Fields: ScraperFields{
+				Name:        ScraperField{Selector: "h1"},
+				CoverURL:    ScraperField{Selector: "#cover", Attr: "src"},
+				Company:     ScraperField{Selector: "td.brand"},
+				ReleaseDate: ScraperField{Selector: "td.date"},
+				Rating:      ScraperField{Selector: ".score", Pattern: `([\d.]+) /`},
+				RatingScale: 5,
+				Tags:        ScraperField{Selector: "ul.genres li"},
+			},
+		},
+	})
+	writeMetadataPluginManifest(t, filepath.Join(dir, "paper.json"), PluginManifest{
+		ID:        "paper",
+		RateLimit: PluginRateLimit{IntervalMillis: 1},
+		Scraper: &ScraperDefinition{
+			Format:    "json",
+			DetailURL: "https://paper.example.com/api/games/{id}",
+			Fields: ScraperFields{
+				Name:        ScraperField{Selector: "data.title"},
+				Aliases:     ScraperField{Selector: "data.aliases"},
+				Company:     ScraperField{Selector: "data.developer.name"},
+				Rating:      ScraperField{Selector: "data.score"},
+				ReleaseDate: ScraperField{Selector: "data.released"},
+			},
+		},
+	})
+	if _, err := LoadPlugins(dir); err != nil {
+		t.Fatalf("LoadPlugins returned an error: %v", err)
+	}
+	t.Cleanup(func() { _, _ = LoadPlugins(filepath.Join(dir, "missing")) })
+
+	cafe, err := NewPluginGetter("plugin:cafe", "", WithHTTPClient(client))
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := cafe.FetchMetadataByName("Starlight Café", "")
+	if err != nil {
+		t.Fatalf("FetchMetadataByName returned an error: %v", err)
+	}
+	game := result.Game
+	if game.SourceID != "11" || game.Name != "Starlight Café" || game.Company != "Night Owl" || game.ReleaseDate != "2023-07-28" {
+		t.Fatalf("unexpected HTML scraper game: %+v", game)
+	}
+	if game.CoverURL != "https://cafe.example.com/img/11.jpg" || game.Rating != 9 || len(result.Tags) != 2 {
+		t.Fatalf("unexpected HTML scraper details: %+v tags=%+v", game, result.Tags)
+	}
+	if len(requested) != 2 || requested[0] != "https://cafe.example.com/search?q=Starlight+Caf%C3%A9" {
+		t.Fatalf("unexpected requests: %v", requested)
+	}
+
+	paper, err := NewPluginGetter("plugin:paper", "", WithHTTPClient(client))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if paper.SupportsNameSearch() {
+		t.Fatal("scraper without search_url should not support name search")
+	}
+	result, err = paper.FetchMetadata("7", "")
+	if err != nil {
+		t.Fatalf("FetchMetadata returned an error: %v", err)
+	}
+	game = result.Game
+	if game.SourceID != "7" || game.Name != "Paper Sky" || game.Company != "Folded" || game.Rating != 7.2 || game.ReleaseDate != "2022-05-01" {
+		t.Fatalf("unexpected JSON scraper game: %+v", game)
+	}
+	if len(game.Aliases) != 1 || game.Aliases[0] != "紙の空" {
+		t.Fatalf("unexpected JSON scraper aliases: %v", game.Aliases)
+	}
+}
+
+func writeMetadataPluginManifest(t *testing.T, path string, manifest PluginManifest) {
+	t.Helper()
+
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -76,6 +76,16 @@ func Unavailable() Runtime {
 	return unavailableRuntime{}
 }
 
+// IsAvailable reports whether runtime is backed by a real Wails application,
+// i.e. whether events can reach a frontend and dialogs can be shown.
+func IsAvailable(runtime Runtime) bool {
+	if runtime == nil {
+		return false
+	}
+	_, unavailable := runtime.(unavailableRuntime)
+	return !unavailable
+}
+
 func (r *applicationRuntime) Emit(name string, data ...any) bool {
 	if r == nil || r.app == nil {
 		return false
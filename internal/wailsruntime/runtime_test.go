@@ -53,3 +53,15 @@ func TestNormalizeDialogSelection(t *testing.T) {
 		}
 	})
 }
+
+func TestIsAvailable(t *testing.T) {
+	if IsAvailable(nil) {
+		t.Fatal("IsAvailable(nil) = true, want false")
+	}
+	if IsAvailable(Unavailable()) {
+		t.Fatal("IsAvailable(Unavailable()) = true, want false")
+	}
+	if !IsAvailable(New(nil, nil)) {
+		t.Fatal("IsAvailable(New(nil, nil)) = false, want true")
+	}
+}
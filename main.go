@@ -8,11 +8,9 @@ import (
 	"fmt"
 	"log/slog"
 	"lunabox/internal/applog"
-	"lunabox/internal/cli"
 	"lunabox/internal/cli/ipcclient"
 	"lunabox/internal/cli/ipcserver"
 	"lunabox/internal/common/vo"
-	"lunabox/internal/migrations"
 	"lunabox/internal/protocol"
 	"lunabox/internal/utils"
 	"lunabox/internal/utils/apputils"
@@ -32,6 +30,7 @@ import (
 	"time"
 
 	"lunabox/internal/appconf"
+	"lunabox/internal/appdb"
 	_ "lunabox/internal/platform"
 	"lunabox/internal/service"
 
@@ -434,6 +433,11 @@ func main() {
 	args := os.Args[1:]
 	args, launchedByAutostart := extractAutostartLaunchFlag(args)
 
+	// lunabox daemon：不启动 Wails，直接运行服务图
+	if len(args) > 0 && args[0] == daemonCommand {
+		os.Exit(runDaemon(appLogger, args[1:]))
+	}
+
 	// lunabox:// URL：检查 GUI 是否已运行
 	if len(args) == 1 && protocol.IsProtocolURL(args[0]) {
 		req, err := parseProtocolRequest(args[0], goruntime.GOOS != "darwin")
@@ -456,38 +460,12 @@ func main() {
 	runGUI(appLogger, applicationLogLevel, launchedByAutostart)
 }
 func runGUI(appLogger *applog.FileLogger, applicationLogLevel slog.Level, launchedByAutostart bool) {
-	startupService := service.NewStartupService()
-	gameService := service.NewGameService()
-	bangumiService := service.NewBangumiService()
-	hikarinagiService := service.NewHikarinagiService()
-	aiService := service.NewAiService()
-	aiStatsBuilder := service.NewAIStatsBuilder()
-	backupService := service.NewBackupService()
-	cloudSyncService := service.NewCloudSyncService()
-	homeService := service.NewHomeService()
-	statsService := service.NewStatsService()
-	startService := service.NewStartService()
-	integrationService := service.NewIntegrationService()
-	categoryService := service.NewCategoryService()
-	configService := service.NewConfigService()
-	importService := service.NewImportService()
-	versionService := service.NewVersionService()
-	templateService := service.NewTemplateService()
-	updateService := service.NewUpdateService(func() {
+	svc := newAppServices(func() {
 		if shouldRunFrontendQuitSync(config) && appState.RequestFrontendQuitSync("application-update") {
 			return
 		}
 		appState.QuitApplication()
 	})
-	sessionService := service.NewSessionService()
-	downloadService := service.NewDownloadService()
-	gameProgressService := service.NewGameProgressService()
-	gameReviewService := service.NewGameReviewService()
-	tagService := service.NewTagService()
-	gameFilterPresetService := service.NewGameFilterPresetService()
-	mcpReadService := service.NewMCPReadService()
-	mcpServerService := service.NewMCPServerService()
-	portableSetupService := service.NewPortableSetupService()
 
 	var localFileHandler http.Handler
 	var remoteImageProxyHandler http.Handler
@@ -500,74 +478,19 @@ func runGUI(appLogger *applog.FileLogger, applicationLogLevel slog.Level, launch
 	startupDone := make(chan struct{})
 
 	initBoundServices := func(ctx context.Context) {
-		configService.Init(ctx, db, config)
+		svc.init(ctx, db, config)
 		// Go controls the first show so the main window cannot cover the
 		// startup success state while its frontend is loading.
-		configService.SetSuppressInitialWindowShow(true)
-		configService.SetQuitHandler(func() {
+		svc.configService.SetSuppressInitialWindowShow(true)
+		svc.configService.SetQuitHandler(func() {
 			appState.QuitApplication()
 		})
-
-		downloadService.Init(ctx, db, config)
-		gameService.Init(ctx, db, config)
-		bangumiService.Init(ctx, db, config)
-		hikarinagiService.Init(ctx, db, config)
-		tagService.Init(ctx, db, config)
-		gameFilterPresetService.Init(ctx, db, config)
-		aiService.Init(ctx, db, config)
-		aiStatsBuilder.Init(ctx, db, config)
-		backupService.Init(ctx, db, config)
-		cloudSyncService.Init(ctx, db, config)
 		service.ConfigureBackupServiceQuitSyncDBBackupHooks(
-			backupService,
+			svc.backupService,
 			func() { appState.BeginFrontendQuitSyncBackup() },
 			func() { appState.MarkFrontendQuitSyncLocalBackupCreated() },
 			func() { appState.FinishFrontendQuitSyncBackup() },
 		)
-		homeService.Init(ctx, db, config)
-		statsService.Init(ctx, db, config)
-		sessionService.Init(ctx, db, config)
-		startService.Init(ctx, db, config)
-		integrationService.Init(ctx, db, config)
-		categoryService.Init(ctx, db, config)
-		importService.Init(ctx, db, config)
-		versionService.Init(ctx)
-		templateService.Init(ctx, db, config)
-		updateService.Init(ctx)
-		gameProgressService.Init(ctx, db, config)
-		gameReviewService.Init(ctx, db, config)
-		mcpReadService.Init(ctx, db, config)
-		mcpServerService.Init(ctx)
-		portableSetupService.Init(ctx)
-
-		startService.SetBackupService(backupService)
-		startService.SetGameService(gameService)
-		startService.SetIntegrationService(integrationService)
-		startService.SetSessionService(sessionService)
-		downloadService.SetGameService(gameService)
-		configService.SetDownloadService(downloadService)
-		gameService.SetImageDownloadTaskStarter(downloadService.StartCoverImageDownloadTask)
-		gameService.SetTagService(tagService)
-		gameService.SetBangumiService(bangumiService)
-		gameService.SetHikarinagiService(hikarinagiService)
-		gameReviewService.SetBangumiService(bangumiService)
-		gameReviewService.SetHikarinagiService(hikarinagiService)
-		importService.SetGameService(gameService)
-		integrationService.SetGameService(gameService)
-		importService.SetBangumiService(bangumiService)
-		importService.SetHikarinagiService(hikarinagiService)
-		importService.SetSessionService(sessionService)
-		updateService.SetConfigService(configService)
-		mcpReadService.SetGameService(gameService)
-		mcpReadService.SetStartService(startService)
-		mcpReadService.SetSessionService(sessionService)
-		mcpReadService.SetGameProgressService(gameProgressService)
-		mcpReadService.SetTagService(tagService)
-		mcpReadService.SetStatsProvider(aiStatsBuilder)
-		mcpServerService.SetReadService(mcpReadService)
-		configService.SetConfigUpdateHook(func(updatedConfig appconf.AppConfig) error {
-			return mcpServerService.ApplyConfig(updatedConfig)
-		})
 	}
 
 	coordinator := &startupCoordinator{
@@ -586,30 +509,35 @@ func runGUI(appLogger *applog.FileLogger, applicationLogLevel slog.Level, launch
 
 	applicationServices := []application.Service{
 		application.NewService(coordinator),
-		application.NewService(startupService),
-		application.NewService(gameService),
-		application.NewService(bangumiService),
-		application.NewService(hikarinagiService),
-		application.NewService(aiService),
-		application.NewService(backupService),
-		application.NewService(cloudSyncService),
-		application.NewService(homeService),
-		application.NewService(statsService),
-		application.NewService(startService),
-		application.NewService(integrationService),
-		application.NewService(categoryService),
-		application.NewService(configService),
-		application.NewService(importService),
-		application.NewService(versionService),
-		application.NewService(templateService),
-		application.NewService(updateService),
-		application.NewService(sessionService),
-		application.NewService(downloadService),
-		application.NewService(gameProgressService),
-		application.NewService(gameReviewService),
-		application.NewService(tagService),
-		application.NewService(gameFilterPresetService),
-		application.NewService(portableSetupService),
+		application.NewService(svc.startupService),
+		application.NewService(svc.gameService),
+		application.NewService(svc.bangumiService),
+		application.NewService(svc.hikarinagiService),
+		application.NewService(svc.vndbService),
+		application.NewService(svc.remotePushService),
+		application.NewService(svc.aiService),
+		application.NewService(svc.backupService),
+		application.NewService(svc.cloudSyncService),
+		application.NewService(svc.homeService),
+		application.NewService(svc.statsService),
+		application.NewService(svc.startService),
+		application.NewService(svc.integrationService),
+		application.NewService(svc.categoryService),
+		application.NewService(svc.configService),
+		application.NewService(svc.importService),
+		application.NewService(svc.versionService),
+		application.NewService(svc.templateService),
+		application.NewService(svc.wrappedService),
+		application.NewService(svc.dataExportService),
+		application.NewService(svc.metadataPluginService),
+		application.NewService(svc.updateService),
+		application.NewService(svc.sessionService),
+		application.NewService(svc.downloadService),
+		application.NewService(svc.gameProgressService),
+		application.NewService(svc.gameReviewService),
+		application.NewService(svc.tagService),
+		application.NewService(svc.gameFilterPresetService),
+		application.NewService(svc.portableSetupService),
 	}
 
 	shutdownStartupResources := func() {
@@ -644,7 +572,8 @@ func runGUI(appLogger *applog.FileLogger, applicationLogLevel slog.Level, launch
 		if isSystemSessionEnding {
 			shutdownMode = "system-session-ending"
 		}
-		cloudSyncService.StopScheduledSync()
+		svc.cloudSyncService.StopScheduledSync()
+		svc.remotePushService.Stop()
 
 		shutdownStartedAt := time.Now()
 		appLogger.Info("shutdown mode: " + shutdownMode)
@@ -661,7 +590,7 @@ func runGUI(appLogger *applog.FileLogger, applicationLogLevel slog.Level, launch
 			}
 		})
 		logShutdownStep("shutdown MCP server", func() {
-			if err := mcpServerService.Shutdown(); err != nil {
+			if err := svc.mcpServerService.Shutdown(); err != nil {
 				appLogger.Error("failed to shutdown MCP server: " + err.Error())
 			}
 		})
@@ -677,7 +606,7 @@ func runGUI(appLogger *applog.FileLogger, applicationLogLevel slog.Level, launch
 			remoteImageProxyHTTPServer = nil
 		})
 		logShutdownStep("refresh latest config", func() {
-			latestConfig, err := configService.GetAppConfig()
+			latestConfig, err := svc.configService.GetAppConfig()
 			if err != nil {
 				appLogger.Error("failed to get latest config: " + err.Error())
 				return
@@ -687,7 +616,7 @@ func runGUI(appLogger *applog.FileLogger, applicationLogLevel slog.Level, launch
 			config = &latestConfig
 		})
 		logShutdownStep("cleanup pending process selections", func() {
-			startService.CleanupPendingSessions()
+			svc.startService.CleanupPendingSessions()
 		})
 		logShutdownStep("automatic database backup", func() {
 			if isSystemSessionEnding || !config.AutoBackupDB {
@@ -699,7 +628,7 @@ func runGUI(appLogger *applog.FileLogger, applicationLogLevel slog.Level, launch
 					return
 				}
 			}
-			if _, err := backupService.CreateDBBackupForShutdown(); err != nil {
+			if _, err := svc.backupService.CreateDBBackupForShutdown(); err != nil {
 				appLogger.Error("automatic local database backup failed: " + err.Error())
 			}
 		})
@@ -794,7 +723,7 @@ func runGUI(appLogger *applog.FileLogger, applicationLogLevel slog.Level, launch
 		},
 	})
 
-	startupService.SetEventEmitter(func(name string, data ...interface{}) {
+	svc.startupService.SetEventEmitter(func(name string, data ...interface{}) {
 		wailsApp.Event.Emit(name, data...)
 	})
 	newStartupErrorWindow := func(name string, hidden bool) *application.WebviewWindow {
@@ -839,7 +768,7 @@ func runGUI(appLogger *applog.FileLogger, applicationLogLevel slog.Level, launch
 	var showStartupErrorPreview func()
 	if strings.TrimSpace(os.Getenv("FRONTEND_DEVSERVER_URL")) != "" {
 		showStartupErrorPreview = func() {
-			startupService.ReportFailure(
+			svc.startupService.ReportFailure(
 				"开发预览：数据库启动失败\n\n" +
 					"打开数据库失败: IO Error: 无法打开 lunabox.db，文件可能正由另一个进程使用\n\n" +
 					"此信息仅用于检查启动错误窗的界面样式。",
@@ -887,18 +816,7 @@ func runGUI(appLogger *applog.FileLogger, applicationLogLevel slog.Level, launch
 		})
 		appState.SetRuntime(wailsApp, mainWindow)
 		guiRuntime = wailsruntime.New(wailsApp, mainWindow)
-		backupService.SetRuntime(guiRuntime)
-		bangumiService.SetRuntime(guiRuntime)
-		hikarinagiService.SetRuntime(guiRuntime)
-		cloudSyncService.SetRuntime(guiRuntime)
-		configService.SetRuntime(guiRuntime)
-		downloadService.SetRuntime(guiRuntime)
-		gameService.SetRuntime(guiRuntime)
-		importService.SetRuntime(guiRuntime)
-		startService.SetRuntime(guiRuntime)
-		statsService.SetRuntime(guiRuntime)
-		templateService.SetRuntime(guiRuntime)
-		updateService.SetRuntime(guiRuntime)
+		svc.setRuntime(guiRuntime)
 		appState.ConfigureTray(showStartupErrorPreview)
 
 		mainWindow.OnWindowEvent(events.Common.WindowFilesDropped, func(event *application.WindowEvent) {
@@ -934,7 +852,7 @@ func runGUI(appLogger *applog.FileLogger, applicationLogLevel slog.Level, launch
 		if ctx == nil {
 			ctx = context.Background()
 		}
-		if err := sessionService.CleanupUnfinishedSessions(); err != nil {
+		if err := svc.sessionService.CleanupUnfinishedSessions(); err != nil {
 			appLogger.Error("startup cleanup unfinished sessions failed: " + err.Error())
 		}
 		var sessionHookErr error
@@ -950,19 +868,16 @@ func runGUI(appLogger *applog.FileLogger, applicationLogLevel slog.Level, launch
 		if err := guiRuntime.SetAutostart(config.LaunchAtLogin); err != nil {
 			appLogger.Error("failed to sync launch-at-login: " + err.Error())
 		}
-		if err := mcpServerService.ApplyConfig(*config); err != nil {
+		if err := svc.mcpServerService.ApplyConfig(*config); err != nil {
 			appLogger.Error("failed to apply MCP server config: " + err.Error())
 		}
-		cliApp := &cli.CoreApp{
-			Config: config, DB: db, Ctx: ctx, GameService: gameService,
-			StartService: startService, SessionService: sessionService,
-			BackupService: backupService, VersionService: versionService,
-		}
+		cliApp := svc.cliApp(ctx, db, config)
 		ipcHTTPServer = ipcserver.StartServer(cliApp, guiRuntime)
 		if shouldRunAutomaticCloudSync(config) {
-			cloudSyncService.RunStartupSync()
+			svc.cloudSyncService.RunStartupSync()
 		}
-		cloudSyncService.StartScheduledSync()
+		svc.cloudSyncService.StartScheduledSync()
+		svc.remotePushService.Start()
 	}
 
 	initializeApplication := func() error {
@@ -976,56 +891,15 @@ func runGUI(appLogger *applog.FileLogger, applicationLogLevel slog.Level, launch
 		}
 		config = loadedConfig
 
-		if config.PendingFullRestore != "" || config.PendingDBRestore != "" {
-		}
-		if config.PendingFullRestore != "" {
-			restored, restoreErr := service.ExecuteFullDataRestore(config)
-			if restoreErr != nil {
-				appLogger.Error("full data restore failed: " + restoreErr.Error())
-			} else if restored {
-				appLogger.Info("full data restore completed")
-			}
-		}
-		if config.PendingDBRestore != "" {
-			restored, restoreErr := service.ExecuteDBRestore(config)
-			if restoreErr != nil {
-				appLogger.Error("database restore failed: " + restoreErr.Error())
-			} else if restored {
-				appLogger.Info("database restore completed")
-			}
+		// 单实例锁已排除另一个 GUI，此时 IPC 服务器只可能属于 daemon
+		if ipcclient.IsServerRunning() {
+			return errors.New("LunaBox daemon 正在运行，请先停止 daemon 再打开图形界面")
 		}
+		applyPendingRestores(config, appLogger)
 
-		dataDir, err := apputils.GetDataDir()
+		db, err = appdb.Open(ctx, config, appLogger)
 		if err != nil {
-			return fmt.Errorf("获取应用数据目录失败: %w", err)
-		}
-		dbPath := filepath.Join(dataDir, "lunabox.db")
-		db, err = dbutils.OpenDuckDBWithWALRecovery(ctx, dbPath, appLogger)
-		if err != nil {
-			return fmt.Errorf("打开数据库失败: %w", err)
-		}
-		if _, err = db.Exec("SET GLOBAL checkpoint_threshold = '4 MiB'"); err != nil {
-			appLogger.Warning("Failed to set DuckDB automatic checkpoint threshold; using the default: " + err.Error())
-		}
-		timeZone := config.TimeZone
-		if timeZone == "" {
-			timeZone = "UTC"
-		}
-		if _, err = db.Exec(fmt.Sprintf("SET TimeZone = '%s'", timeZone)); err != nil {
-			appLogger.Warning("Failed to set timezone: " + err.Error())
-		}
-
-		if err := migrations.InitSchema(db); err != nil {
-			return fmt.Errorf("初始化数据库结构失败: %w", err)
-		}
-		if err := migrations.Run(ctx, db); err != nil {
-			return fmt.Errorf("数据库迁移失败: %w", err)
-		}
-		if err := migrations.InitIndexes(db); err != nil {
-			return fmt.Errorf("初始化数据库索引失败: %w", err)
-		}
-		if err := dbutils.CheckpointDuckDB(ctx, db); err != nil {
-			appLogger.Warning("Database checkpoint after schema initialization failed; committed changes remain in WAL: " + err.Error())
+			return err
 		}
 
 		preparedLocalFileHandler, err := apputils.NewLocalFileHandler()
@@ -1071,7 +945,7 @@ func runGUI(appLogger *applog.FileLogger, applicationLogLevel slog.Level, launch
 			if startupErr != nil {
 				startupFailed.Store(true)
 				appLogger.Error("application startup failed: " + startupErr.Error())
-				startupService.ReportFailure(startupErr.Error())
+				svc.startupService.ReportFailure(startupErr.Error())
 				close(startupDone)
 				startupWindow.Center()
 				startupWindow.Show()
@@ -1096,7 +970,7 @@ func runGUI(appLogger *applog.FileLogger, applicationLogLevel slog.Level, launch
 		go func() {
 			<-startupDone
 			if startupReady.Load() {
-				dispatchProtocolRequest(req, downloadService, startService, guiRuntime, appLogger)
+				dispatchProtocolRequest(req, svc.downloadService, svc.startService, guiRuntime, appLogger)
 			}
 		}()
 	})
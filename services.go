@@ -0,0 +1,217 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+
+	"lunabox/internal/appconf"
+	"lunabox/internal/applog"
+	"lunabox/internal/cli"
+	"lunabox/internal/service"
+	"lunabox/internal/wailsruntime"
+)
+
+// appServices 应用服务图。GUI 与 daemon 模式共用同一套构造与依赖注入，
+// 两者只在 Wails 绑定、窗口运行时和退出流程上有区别。
+type appServices struct {
+	startupService          *service.StartupService
+	gameService             *service.GameService
+	bangumiService          *service.BangumiService
+	hikarinagiService       *service.HikarinagiService
+	vndbService             *service.VNDBService
+	remotePushService       *service.RemotePushService
+	aiService               *service.AiService
+	aiStatsBuilder          *service.AIStatsBuilder
+	backupService           *service.BackupService
+	cloudSyncService        *service.CloudSyncService
+	homeService             *service.HomeService
+	statsService            *service.StatsService
+	startService            *service.StartService
+	integrationService      *service.IntegrationService
+	categoryService         *service.CategoryService
+	configService           *service.ConfigService
+	importService           *service.ImportService
+	versionService          *service.VersionService
+	templateService         *service.TemplateService
+	wrappedService          *service.WrappedService
+	dataExportService       *service.DataExportService
+	metadataPluginService   *service.MetadataPluginService
+	updateService           *service.UpdateService
+	sessionService          *service.SessionService
+	downloadService         *service.DownloadService
+	gameProgressService     *service.GameProgressService
+	gameReviewService       *service.GameReviewService
+	tagService              *service.TagService
+	gameFilterPresetService *service.GameFilterPresetService
+	mcpReadService          *service.MCPReadService
+	mcpServerService        *service.MCPServerService
+	portableSetupService    *service.PortableSetupService
+}
+
+// newAppServices 构造全部服务。requestQuit 在更新服务需要退出应用以安装新版本时调用，
+// 为 nil 时不支持应用内更新。
+func newAppServices(requestQuit func()) *appServices {
+	return &appServices{
+		startupService:          service.NewStartupService(),
+		gameService:             service.NewGameService(),
+		bangumiService:          service.NewBangumiService(),
+		hikarinagiService:       service.NewHikarinagiService(),
+		vndbService:             service.NewVNDBService(),
+		remotePushService:       service.NewRemotePushService(),
+		aiService:               service.NewAiService(),
+		aiStatsBuilder:          service.NewAIStatsBuilder(),
+		backupService:           service.NewBackupService(),
+		cloudSyncService:        service.NewCloudSyncService(),
+		homeService:             service.NewHomeService(),
+		statsService:            service.NewStatsService(),
+		startService:            service.NewStartService(),
+		integrationService:      service.NewIntegrationService(),
+		categoryService:         service.NewCategoryService(),
+		configService:           service.NewConfigService(),
+		importService:           service.NewImportService(),
+		versionService:          service.NewVersionService(),
+		templateService:         service.NewTemplateService(),
+		wrappedService:          service.NewWrappedService(),
+		dataExportService:       service.NewDataExportService(),
+		metadataPluginService:   service.NewMetadataPluginService(),
+		updateService:           service.NewUpdateService(requestQuit),
+		sessionService:          service.NewSessionService(),
+		downloadService:         service.NewDownloadService(),
+		gameProgressService:     service.NewGameProgressService(),
+		gameReviewService:       service.NewGameReviewService(),
+		tagService:              service.NewTagService(),
+		gameFilterPresetService: service.NewGameFilterPresetService(),
+		mcpReadService:          service.NewMCPReadService(),
+		mcpServerService:        service.NewMCPServerService(),
+		portableSetupService:    service.NewPortableSetupService(),
+	}
+}
+
+// init 注入数据库与配置，并连接服务之间的依赖
+func (s *appServices) init(ctx context.Context, db *sql.DB, config *appconf.AppConfig) {
+	s.configService.Init(ctx, db, config)
+	s.downloadService.Init(ctx, db, config)
+	s.gameService.Init(ctx, db, config)
+	s.bangumiService.Init(ctx, db, config)
+	s.hikarinagiService.Init(ctx, db, config)
+	s.vndbService.Init(ctx, db, config)
+	s.remotePushService.Init(ctx, db, config)
+	s.tagService.Init(ctx, db, config)
+	s.gameFilterPresetService.Init(ctx, db, config)
+	s.aiService.Init(ctx, db, config)
+	s.aiStatsBuilder.Init(ctx, db, config)
+	s.backupService.Init(ctx, db, config)
+	s.cloudSyncService.Init(ctx, db, config)
+	s.homeService.Init(ctx, db, config)
+	s.statsService.Init(ctx, db, config)
+	s.sessionService.Init(ctx, db, config)
+	s.startService.Init(ctx, db, config)
+	s.integrationService.Init(ctx, db, config)
+	s.categoryService.Init(ctx, db, config)
+	s.importService.Init(ctx, db, config)
+	s.versionService.Init(ctx)
+	s.templateService.Init(ctx, db, config)
+	s.wrappedService.Init(ctx, db, config)
+	s.dataExportService.Init(ctx, db, config)
+	s.metadataPluginService.Init(ctx, db, config)
+	s.updateService.Init(ctx)
+	s.gameProgressService.Init(ctx, db, config)
+	s.gameReviewService.Init(ctx, db, config)
+	s.mcpReadService.Init(ctx, db, config)
+	s.mcpServerService.Init(ctx)
+	s.portableSetupService.Init(ctx)
+
+	s.startService.SetBackupService(s.backupService)
+	s.startService.SetGameService(s.gameService)
+	s.startService.SetIntegrationService(s.integrationService)
+	s.startService.SetSessionService(s.sessionService)
+	s.downloadService.SetGameService(s.gameService)
+	s.configService.SetDownloadService(s.downloadService)
+	s.gameService.SetImageDownloadTaskStarter(s.downloadService.StartCoverImageDownloadTask)
+	s.gameService.SetTagService(s.tagService)
+	s.gameService.SetBangumiService(s.bangumiService)
+	s.bangumiService.SetGameService(s.gameService)
+	s.gameService.SetHikarinagiService(s.hikarinagiService)
+	s.gameService.SetVNDBService(s.vndbService)
+	s.vndbService.SetGameService(s.gameService)
+	s.gameReviewService.SetBangumiService(s.bangumiService)
+	s.gameReviewService.SetHikarinagiService(s.hikarinagiService)
+	s.gameReviewService.SetVNDBService(s.vndbService)
+	s.statsService.SetVNDBService(s.vndbService)
+	s.remotePushService.SetGameService(s.gameService)
+	s.remotePushService.SetGameReviewService(s.gameReviewService)
+	s.remotePushService.SetBangumiService(s.bangumiService)
+	s.remotePushService.SetHikarinagiService(s.hikarinagiService)
+	s.remotePushService.SetVNDBService(s.vndbService)
+	s.gameService.SetRemotePushService(s.remotePushService)
+	s.gameReviewService.SetRemotePushService(s.remotePushService)
+	s.importService.SetGameService(s.gameService)
+	s.integrationService.SetGameService(s.gameService)
+	s.importService.SetBangumiService(s.bangumiService)
+	s.importService.SetHikarinagiService(s.hikarinagiService)
+	s.importService.SetSessionService(s.sessionService)
+	s.updateService.SetConfigService(s.configService)
+	s.mcpReadService.SetGameService(s.gameService)
+	s.mcpReadService.SetStartService(s.startService)
+	s.mcpReadService.SetSessionService(s.sessionService)
+	s.mcpReadService.SetGameProgressService(s.gameProgressService)
+	s.mcpReadService.SetTagService(s.tagService)
+	s.mcpReadService.SetStatsProvider(s.aiStatsBuilder)
+	s.mcpReadService.SetStatsService(s.statsService)
+	s.wrappedService.SetTemplateService(s.templateService)
+	s.mcpServerService.SetReadService(s.mcpReadService)
+	s.configService.SetConfigUpdateHook(func(updatedConfig appconf.AppConfig) error {
+		return s.mcpServerService.ApplyConfig(updatedConfig)
+	})
+}
+
+// setRuntime 将窗口运行时交给需要事件、对话框或打开链接的服务
+func (s *appServices) setRuntime(runtime wailsruntime.Runtime) {
+	s.backupService.SetRuntime(runtime)
+	s.bangumiService.SetRuntime(runtime)
+	s.hikarinagiService.SetRuntime(runtime)
+	s.vndbService.SetRuntime(runtime)
+	s.remotePushService.SetRuntime(runtime)
+	s.cloudSyncService.SetRuntime(runtime)
+	s.configService.SetRuntime(runtime)
+	s.downloadService.SetRuntime(runtime)
+	s.gameService.SetRuntime(runtime)
+	s.importService.SetRuntime(runtime)
+	s.startService.SetRuntime(runtime)
+	s.statsService.SetRuntime(runtime)
+	s.templateService.SetRuntime(runtime)
+	s.wrappedService.SetRuntime(runtime)
+	s.dataExportService.SetRuntime(runtime)
+	s.updateService.SetRuntime(runtime)
+}
+
+// cliApp 构造 IPC 服务器执行 lunacli 命令所用的应用上下文
+func (s *appServices) cliApp(ctx context.Context, db *sql.DB, config *appconf.AppConfig) *cli.CoreApp {
+	return &cli.CoreApp{
+		Config: config, DB: db, Ctx: ctx, GameService: s.gameService,
+		StartService: s.startService, SessionService: s.sessionService,
+		CategoryService: s.categoryService, TagService: s.tagService,
+		BackupService: s.backupService, VersionService: s.versionService,
+		WrappedService: s.wrappedService, DataExportService: s.dataExportService,
+	}
+}
+
+// applyPendingRestores 在打开数据库之前执行上次运行时安排的全量或数据库恢复
+func applyPendingRestores(config *appconf.AppConfig, appLogger *applog.FileLogger) {
+	if config.PendingFullRestore != "" {
+		restored, restoreErr := service.ExecuteFullDataRestore(config)
+		if restoreErr != nil {
+			appLogger.Error("full data restore failed: " + restoreErr.Error())
+		} else if restored {
+			appLogger.Info("full data restore completed")
+		}
+	}
+	if config.PendingDBRestore != "" {
+		restored, restoreErr := service.ExecuteDBRestore(config)
+		if restoreErr != nil {
+			appLogger.Error("database restore failed: " + restoreErr.Error())
+		} else if restored {
+			appLogger.Info("database restore completed")
+		}
+	}
+}